@@ -131,6 +131,22 @@ func validateInstanceIsFound(instanceKey *inst.InstanceKey) (instance *inst.Inst
 	return instance
 }
 
+// acknowledgeRecoveryDisabled refuses a forced recovery on given cluster when recoveries are disabled globally or
+// for the cluster, unless explicitly confirmed via --acknowledge-recovery-disabled
+func acknowledgeRecoveryDisabled(clusterName string) {
+	reason, err := logic.RecoveryDisabledReason(clusterName)
+	if err != nil {
+		log.Fatale(err)
+	}
+	if reason == "" {
+		return
+	}
+	if !*config.RuntimeCLIFlags.AckRecoveryDisabled {
+		log.Fatalf("Recoveries are %s. Add --acknowledge-recovery-disabled to recover nonetheless", reason)
+	}
+	inst.AuditOperation("bypass-recovery-disabled", nil, fmt.Sprintf("%s forced a recovery on %+v; recoveries %s", inst.GetMaintenanceOwner(), clusterName, reason))
+}
+
 // CliWrapper is called from main and allows for the instance parameter
 // to take multiple instance names separated by a comma or whitespace.
 func CliWrapper(command string, strict bool, instances string, destination string, owner string, reason string, duration string, pattern string, clusterAlias string, pool string, hostnameFlag string) {
@@ -218,7 +234,7 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			if destinationKey == nil {
 				log.Fatal("Cannot deduce destination:", destination)
 			}
-			_, err := inst.RelocateBelow(instanceKey, destinationKey)
+			_, err := inst.RelocateBelow(instanceKey, destinationKey, *config.RuntimeCLIFlags.AllowCrossCluster)
 			if err != nil {
 				log.Fatale(err)
 			}
@@ -230,7 +246,7 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			if destinationKey == nil {
 				log.Fatal("Cannot deduce destination:", destination)
 			}
-			replicas, _, err, errs := inst.RelocateReplicas(instanceKey, destinationKey, pattern)
+			replicas, _, err, errs := inst.RelocateReplicas(instanceKey, destinationKey, pattern, *config.RuntimeCLIFlags.AllowCrossCluster)
 			if err != nil {
 				log.Fatale(err)
 			} else {
@@ -242,16 +258,33 @@ func Cli(command string, strict bool, instance string, destination string, owner
 				}
 			}
 		}
+	case registerCliCommand("apply-rebalance", "Smart relocation", `Relocate replicas of a cluster so as to balance replicas among sibling intermediate masters`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			moves, err := inst.ApplyRebalance(clusterName)
+			for _, move := range moves {
+				fmt.Println(fmt.Sprintf("%s\t%s\t%s", move.Key.DisplayString(), move.FromKey.DisplayString(), move.ToKey.DisplayString()))
+			}
+			if err != nil {
+				log.Fatale(err)
+			}
+		}
 	case registerCliCommand("take-siblings", "Smart relocation", `Turn all siblings of a replica into its sub-replicas.`):
 		{
 			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
 			if instanceKey == nil {
 				log.Fatal("Cannot deduce instance:", instance)
 			}
-			_, _, err := inst.TakeSiblings(instanceKey)
+			_, takenSiblings, err, errs := inst.TakeSiblings(instanceKey, *config.RuntimeCLIFlags.Noop)
 			if err != nil {
 				log.Fatale(err)
 			}
+			for _, e := range errs {
+				log.Errore(e)
+			}
+			if *config.RuntimeCLIFlags.Noop {
+				fmt.Printf("%d siblings would be taken\n", takenSiblings)
+			}
 			fmt.Println(instanceKey.DisplayString())
 		}
 	case registerCliCommand("regroup-replicas", "Smart relocation", `Given an instance, pick one of its replicas and make it local master of its siblings`):
@@ -323,7 +356,7 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			if destinationKey == nil {
 				log.Fatal("Cannot deduce destination:", destination)
 			}
-			_, err := inst.MoveEquivalent(instanceKey, destinationKey)
+			_, err := inst.MoveEquivalent(instanceKey, destinationKey, *config.RuntimeCLIFlags.AllowCrossCluster)
 			if err != nil {
 				log.Fatale(err)
 			}
@@ -333,7 +366,16 @@ func Cli(command string, strict bool, instance string, destination string, owner
 		{
 			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
 			// destinationKey can be null, in which case the instance repoints to its existing master
-			instance, err := inst.Repoint(instanceKey, destinationKey, inst.GTIDHintNeutral)
+			instance, err := inst.Repoint(instanceKey, destinationKey, inst.GTIDHintNeutral, *config.RuntimeCLIFlags.AllowCrossCluster)
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(fmt.Sprintf("%s<%s", instanceKey.DisplayString(), instance.MasterKey.DisplayString()))
+		}
+	case registerCliCommand("repoint-to-canonical", "Classic file:pos relocation", `Make the given replica replicate from the canonical endpoint of its current master, without changing the binlog coordinates`):
+		{
+			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			instance, err := inst.RepointToCanonical(instanceKey)
 			if err != nil {
 				log.Fatale(err)
 			}
@@ -342,7 +384,7 @@ func Cli(command string, strict bool, instance string, destination string, owner
 	case registerCliCommand("repoint-replicas", "Classic file:pos relocation", `Repoint all replicas of given instance to replicate back from the instance. Use with care`):
 		{
 			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
-			repointedReplicas, err, errs := inst.RepointReplicasTo(instanceKey, pattern, destinationKey)
+			repointedReplicas, err, errs := inst.RepointReplicasTo(instanceKey, pattern, destinationKey, *config.RuntimeCLIFlags.AllowCrossCluster)
 			if err != nil {
 				log.Fatale(err)
 			} else {
@@ -360,7 +402,7 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			if instanceKey == nil {
 				log.Fatal("Cannot deduce instance:", instance)
 			}
-			_, err := inst.TakeMaster(instanceKey, false)
+			_, err := inst.TakeMaster(instanceKey, false, true, *config.RuntimeCLIFlags.Noop)
 			if err != nil {
 				log.Fatale(err)
 			}
@@ -413,19 +455,31 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			if destinationKey == nil {
 				log.Fatal("Cannot deduce destination:", destination)
 			}
-			_, err := inst.MoveBelowGTID(instanceKey, destinationKey)
+			_, err := inst.MoveBelowGTID(instanceKey, destinationKey, *config.RuntimeCLIFlags.AllowCrossCluster)
 			if err != nil {
 				log.Fatale(err)
 			}
 			fmt.Println(fmt.Sprintf("%s<%s", instanceKey.DisplayString(), destinationKey.DisplayString()))
 		}
+	case registerCliCommand("repoint-channel", "GTID relocation", `Make a single replication channel of a multi-source replica replicate from another instance, via GTID`):
+		{
+			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			if destinationKey == nil {
+				log.Fatal("Cannot deduce destination:", destination)
+			}
+			instance, err := inst.RepointChannel(instanceKey, *config.RuntimeCLIFlags.Channel, destinationKey, *config.RuntimeCLIFlags.AllowCrossCluster)
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(fmt.Sprintf("%s<%s (channel '%s')", instance.Key.DisplayString(), destinationKey.DisplayString(), *config.RuntimeCLIFlags.Channel))
+		}
 	case registerCliCommand("move-replicas-gtid", "GTID relocation", `Moves all replicas of a given instance under another (destination) instance using GTID`):
 		{
 			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
 			if destinationKey == nil {
 				log.Fatal("Cannot deduce destination:", destination)
 			}
-			movedReplicas, _, err, errs := inst.MoveReplicasGTID(instanceKey, destinationKey, pattern)
+			movedReplicas, _, err, errs := inst.MoveReplicasGTID(instanceKey, destinationKey, pattern, *config.RuntimeCLIFlags.AllowCrossCluster)
 			if err != nil {
 				log.Fatale(err)
 			} else {
@@ -464,7 +518,7 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			if destinationKey == nil {
 				log.Fatal("Cannot deduce destination:", destination)
 			}
-			_, _, err := inst.MatchBelow(instanceKey, destinationKey, true)
+			_, _, err := inst.MatchBelow(instanceKey, destinationKey, true, *config.RuntimeCLIFlags.AllowCrossCluster)
 			if err != nil {
 				log.Fatale(err)
 			}
@@ -499,7 +553,7 @@ func Cli(command string, strict bool, instance string, destination string, owner
 				log.Fatal("Cannot deduce destination:", destination)
 			}
 
-			matchedReplicas, _, err, errs := inst.MultiMatchReplicas(instanceKey, destinationKey, pattern)
+			matchedReplicas, _, err, errs := inst.MultiMatchReplicas(instanceKey, destinationKey, pattern, *config.RuntimeCLIFlags.AllowCrossCluster)
 			if err != nil {
 				log.Fatale(err)
 			} else {
@@ -583,6 +637,17 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			}
 			fmt.Println(instance.GtidErrant)
 		}
+	case registerCliCommand("which-cluster-gtid-errant", "Replication, general", `Output the list of instances in same cluster as given instance, which have errant GTID`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			instances, err := inst.ReadClusterErrantGTIDInstances(clusterName)
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, clusterInstance := range instances {
+				fmt.Println(fmt.Sprintf("%s\t%s", clusterInstance.Key.DisplayString(), clusterInstance.GtidErrant))
+			}
+		}
 	case registerCliCommand("gtid-errant-reset-master", "Replication, general", `Reset master on instance, remove GTID errant transactions`):
 		{
 			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
@@ -721,6 +786,21 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			}
 			fmt.Println(instanceKey.DisplayString())
 		}
+	case registerCliCommand("change-master-credentials", "Replication, general", `Change replication credentials on all replicas of a cluster, one replica at a time`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			results, err := inst.ChangeClusterMasterCredentials(clusterName, *config.RuntimeCLIFlags.ReplicationUser, *config.RuntimeCLIFlags.ReplicationPasswordRef)
+			for _, result := range results {
+				if result.Success {
+					fmt.Println(fmt.Sprintf("%s\tok\tverified=%t", result.Key.DisplayString(), result.Verified))
+				} else {
+					fmt.Println(fmt.Sprintf("%s\tfailed\t%s", result.Key.DisplayString(), result.Error))
+				}
+			}
+			if err != nil {
+				log.Fatale(err)
+			}
+		}
 	case registerCliCommand("restart-slave-statements", "Replication, general", `Get a list of statements to execute to stop then restore replica to same execution state. Provide --statement for injected statement`):
 		{
 			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
@@ -793,6 +873,33 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			}
 			fmt.Println(instanceKey.DisplayString())
 		}
+	case registerCliCommand("set-cluster-read-only", "Instance", `Turn all instances of a cluster, including the master, read-only`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			results, err := inst.SetClusterReadOnly(clusterName)
+			for _, result := range results {
+				if result.Error != "" {
+					log.Errorf("%s: %s", result.Key.DisplayString(), result.Error)
+				}
+			}
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, result := range results {
+				fmt.Println(result.Key.DisplayString())
+			}
+		}
+	case registerCliCommand("set-cluster-writeable-master-only", "Instance", `Turn the master of a cluster writeable, leaving other instances as they are`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			results, err := inst.SetClusterWriteableMasterOnly(clusterName)
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, result := range results {
+				fmt.Println(result.Key.DisplayString())
+			}
+		}
 		// Binary log operations
 	case registerCliCommand("flush-binary-logs", "Binary logs", `Flush binary logs on an instance`):
 		{
@@ -1151,6 +1258,84 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			}
 			fmt.Println(masters[0].Key.DisplayString())
 		}
+	case registerCliCommand("fan-in-report", "Information", `Output the number of replicas of each intermediate master of a given cluster, and suggested moves to balance them`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			report, err := inst.ClusterFanInReport(clusterName)
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, master := range report.Masters {
+				fmt.Println(fmt.Sprintf("%s\t%s\t%d\t%d", master.Key.DisplayString(), master.DataCenter, master.CountReplicas, master.SuggestedCountReplicas))
+			}
+			for _, move := range report.SuggestedMoves {
+				fmt.Println(fmt.Sprintf("- %s\t%s\t%s", move.Key.DisplayString(), move.FromKey.DisplayString(), move.ToKey.DisplayString()))
+			}
+		}
+	case registerCliCommand("which-candidate", "Information", `Output the replica which would be promoted should the master of a given cluster die now`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			masters, err := inst.ReadClusterMaster(clusterName)
+			if err != nil {
+				log.Fatale(err)
+			}
+			if len(masters) == 0 {
+				log.Fatalf("No writeable masters found for cluster %+v", clusterName)
+			}
+			suggestion, err := logic.SuggestPromotionCandidate(&masters[0].Key)
+			if err != nil {
+				log.Fatale(err)
+			}
+			if suggestion.Candidate == nil {
+				log.Fatalf("No promotion candidate for %+v: %s", masters[0].Key, suggestion.Reason)
+			}
+			fmt.Println(fmt.Sprintf("%s\t%s", suggestion.Candidate.Key.DisplayString(), suggestion.Reason))
+			for _, rejection := range suggestion.RejectedCandidates {
+				fmt.Println(fmt.Sprintf("- %s\t%s", rejection.Key.DisplayString(), rejection.Reason))
+			}
+		}
+	case registerCliCommand("check-failover", "Information", `Check whether the master of a given cluster could be failed over right now`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			report, err := logic.CheckFailoverPreconditions(clusterName)
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, check := range report.Checks {
+				status := "pass"
+				if !check.Passed {
+					status = "FAIL"
+				}
+				fmt.Println(fmt.Sprintf("%s\t%s\t%s", status, check.Name, check.Details))
+			}
+			if !report.Passed {
+				log.Fatalf("Failed checks: %s", strings.Join(report.FailedChecks(), ", "))
+			}
+		}
+	case registerCliCommand("last-snapshot-coordinates", "Information", `Output the most recent binary log coordinates snapshot taken on a given cluster`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			snapshot, err := inst.ReadLastClusterCoordinatesSnapshot(clusterName)
+			if err != nil {
+				log.Fatale(err)
+			}
+			if snapshot == nil {
+				log.Fatalf("No coordinates snapshot found for cluster %+v", clusterName)
+			}
+			instanceKeys := []string{}
+			for instanceKey := range snapshot.Instances {
+				instanceKeys = append(instanceKeys, instanceKey)
+			}
+			sort.Strings(instanceKeys)
+			for _, instanceKey := range instanceKeys {
+				instanceSnapshot := snapshot.Instances[instanceKey]
+				if instanceSnapshot.Success {
+					fmt.Println(fmt.Sprintf("%s\t%s\t%s\t%s", snapshot.SnapshotTimestamp, instanceSnapshot.Key.DisplayString(), instanceSnapshot.SelfBinlogCoordinates.DisplayString(), instanceSnapshot.ExecutedGtidSet))
+				} else {
+					fmt.Println(fmt.Sprintf("%s\t%s\t-\t%s", snapshot.SnapshotTimestamp, instanceSnapshot.Key.DisplayString(), instanceSnapshot.Error))
+				}
+			}
+		}
 	case registerCliCommand("which-cluster-instances", "Information", `Output the list of instances participating in same cluster as given instance`):
 		{
 			clusterName := getClusterName(clusterAlias, instanceKey)
@@ -1206,6 +1391,17 @@ func Cli(command string, strict bool, instance string, destination string, owner
 				fmt.Println(clusterInstance.Key.DisplayString())
 			}
 		}
+	case registerCliCommand("show-downtimed-instances", "Information", `List instances currently downtimed along with owner, reason and remaining time, potentially filtered by cluster`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			instances, err := inst.ReadDowntimedInstances(clusterName)
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, clusterInstance := range instances {
+				fmt.Println(fmt.Sprintf("%s\t%s\t%s\t%s\t%+v", clusterInstance.Key.DisplayString(), clusterInstance.DowntimeOwner, clusterInstance.DowntimeEndTimestamp, clusterInstance.RemainingDowntime, clusterInstance.DowntimeReason))
+			}
+		}
 	case registerCliCommand("which-replicas", "Information", `Output the fully-qualified hostname:port list of replicas of a given instance`):
 		{
 			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
@@ -1292,13 +1488,24 @@ func Cli(command string, strict bool, instance string, destination string, owner
 	case registerCliCommand("tagged", "tags", `List instances tagged by tag-string. Format: "tagname" or "tagname=tagvalue" or comma separated "tag0,tag1=val1,tag2" for intersection of all.`):
 		{
 			tagsString := *config.RuntimeCLIFlags.Tag
-			instanceKeyMap, err := inst.GetInstanceKeysByTags(tagsString)
-			if err != nil {
-				log.Fatale(err)
-			}
 			keysDisplayStrings := []string{}
-			for _, key := range instanceKeyMap.GetInstanceKeys() {
-				keysDisplayStrings = append(keysDisplayStrings, key.DisplayString())
+			if clusterAlias != "" || rawInstanceKey != nil {
+				clusterName := getClusterName(clusterAlias, rawInstanceKey)
+				instances, err := inst.ReadInstancesByTag(clusterName, tagsString)
+				if err != nil {
+					log.Fatale(err)
+				}
+				for _, instance := range instances {
+					keysDisplayStrings = append(keysDisplayStrings, instance.Key.DisplayString())
+				}
+			} else {
+				instanceKeyMap, err := inst.GetInstanceKeysByTags(tagsString)
+				if err != nil {
+					log.Fatale(err)
+				}
+				for _, key := range instanceKeyMap.GetInstanceKeys() {
+					keysDisplayStrings = append(keysDisplayStrings, key.DisplayString())
+				}
 			}
 			sort.Strings(keysDisplayStrings)
 			for _, s := range keysDisplayStrings {
@@ -1312,7 +1519,19 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			if err != nil {
 				log.Fatale(err)
 			}
-			inst.PutInstanceTag(instanceKey, tag)
+			var expireSeconds int
+			if duration != "" {
+				expireSeconds, err = util.SimpleTimeToSeconds(duration)
+				if err != nil {
+					log.Fatale(err)
+				}
+				if expireSeconds < 0 {
+					log.Fatalf("Duration value must be non-negative. Given value: %d", expireSeconds)
+				}
+			}
+			if err := inst.PutExpiringInstanceTag(instanceKey, tag, uint(expireSeconds)); err != nil {
+				log.Fatale(err)
+			}
 			fmt.Println(instanceKey.DisplayString())
 		}
 	case registerCliCommand("untag", "tags", `Remove a tag from an instance`):
@@ -1372,6 +1591,42 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			}
 			fmt.Println(instanceKey.DisplayString())
 		}
+	case registerCliCommand("forget-cluster", "Instance management", `Forget about all instances of a cluster`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			clusterInstances, err := inst.ReadClusterInstances(clusterName)
+			if err != nil {
+				log.Fatale(err)
+			}
+			if err := inst.CheckForgetSafetyThreshold(len(clusterInstances), *config.RuntimeCLIFlags.Force); err != nil {
+				log.Fatale(err)
+			}
+			if err := logic.ForgetCluster(clusterName); err != nil {
+				log.Fatale(err)
+			}
+			for _, clusterInstance := range clusterInstances {
+				fmt.Println(clusterInstance.Key.DisplayString())
+			}
+		}
+	case registerCliCommand("forget-by-pattern", "Instance management", `Forget about all instances whose hostname:port matches given regex pattern`):
+		{
+			if pattern == "" {
+				log.Fatal("No pattern given")
+			}
+			instanceKeys, err := inst.ReadInstanceKeysByPattern(pattern)
+			if err != nil {
+				log.Fatale(err)
+			}
+			if err := inst.CheckForgetSafetyThreshold(len(instanceKeys), *config.RuntimeCLIFlags.Force); err != nil {
+				log.Fatale(err)
+			}
+			if err := logic.ForgetInstancesByPattern(pattern); err != nil {
+				log.Fatale(err)
+			}
+			for _, instanceKey := range instanceKeys {
+				fmt.Println(instanceKey.DisplayString())
+			}
+		}
 	case registerCliCommand("begin-maintenance", "Instance management", `Request a maintenance lock on an instance`):
 		{
 			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
@@ -1452,6 +1707,101 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			}
 			fmt.Println(instanceKey.DisplayString())
 		}
+	case registerCliCommand("extend-downtime", "Instance management", `Extend the active downtime of an instance`):
+		{
+			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			if duration == "" {
+				log.Fatal("--duration option required")
+			}
+			durationSeconds, err := util.SimpleTimeToSeconds(duration)
+			if err != nil {
+				log.Fatale(err)
+			}
+			if err := inst.ExtendDowntime(instanceKey, time.Duration(durationSeconds)*time.Second); err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(instanceKey.DisplayString())
+		}
+	case registerCliCommand("begin-cluster-downtime", "Instance management", `Mark all instances of a cluster as downtimed`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			if reason == "" {
+				log.Fatal("--reason option required")
+			}
+			var durationSeconds int = 0
+			if duration != "" {
+				durationSeconds, err = util.SimpleTimeToSeconds(duration)
+				if err != nil {
+					log.Fatale(err)
+				}
+				if durationSeconds < 0 {
+					log.Fatalf("Duration value must be non-negative. Given value: %d", durationSeconds)
+				}
+			}
+			duration := time.Duration(durationSeconds) * time.Second
+			results, err := inst.BeginDowntimeCluster(clusterName, inst.GetMaintenanceOwner(), reason, duration)
+			for _, result := range results {
+				if result.Error != "" {
+					log.Errorf("%s: %s", result.Key.DisplayString(), result.Error)
+				}
+			}
+			if err != nil {
+				log.Fatale(err)
+			}
+			log.Infof("Downtime duration: %d seconds", durationSeconds)
+			for _, result := range results {
+				fmt.Println(result.Key.DisplayString())
+			}
+		}
+	case registerCliCommand("end-cluster-downtime", "Instance management", `Indicate all instances of a cluster are no longer downtimed`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			results, err := inst.EndDowntimeCluster(clusterName)
+			for _, result := range results {
+				if result.Error != "" {
+					log.Errorf("%s: %s", result.Key.DisplayString(), result.Error)
+				}
+			}
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, result := range results {
+				fmt.Println(result.Key.DisplayString())
+			}
+		}
+	case registerCliCommand("ignore-health-checks", "Instance management", `Mark an instance as unreachable by design: no longer polled nor analyzed`):
+		{
+			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			if reason == "" {
+				log.Fatal("--reason option required")
+			}
+			if err := inst.IgnoreHealthChecks(inst.NewHealthChecksIgnore(instanceKey, inst.GetMaintenanceOwner(), reason)); err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(instanceKey.DisplayString())
+		}
+	case registerCliCommand("unignore-health-checks", "Instance management", `Resume polling and analysis of an instance whose health checks are ignored`):
+		{
+			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			if _, err := inst.UnignoreHealthChecks(instanceKey); err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(instanceKey.DisplayString())
+		}
+	case registerCliCommand("health-checks-ignored", "Instance management", `List instances whose health checks are ignored`):
+		{
+			clusterName := ""
+			if clusterAlias != "" {
+				clusterName = getClusterName(clusterAlias, instanceKey)
+			}
+			instances, err := inst.ReadHealthChecksIgnoredInstances(clusterName)
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, instance := range instances {
+				fmt.Println(fmt.Sprintf("%s\t%s\t%s", instance.Key.DisplayString(), instance.IgnoreHealthChecksOwner, instance.IgnoreHealthChecksReason))
+			}
+		}
 		// Recovery & analysis
 	case registerCliCommand("recover", "Recovery", `Do auto-recovery given a dead instance`), registerCliCommand("recover-lite", "Recovery", `Do auto-recovery given a dead instance. Orchestrator chooses the best course of actionwithout executing external processes`):
 		{
@@ -1459,6 +1809,7 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			if instanceKey == nil {
 				log.Fatal("Cannot deduce instance:", instance)
 			}
+			acknowledgeRecoveryDisabled(validateInstanceIsFound(instanceKey).ClusterName)
 
 			recoveryAttempted, promotedInstanceKey, err := logic.CheckAndRecover(instanceKey, destinationKey, (command == "recover-lite"))
 			if err != nil {
@@ -1474,6 +1825,7 @@ func Cli(command string, strict bool, instance string, destination string, owner
 	case registerCliCommand("force-master-failover", "Recovery", `Forcibly discard master and initiate a failover, even if orchestrator doesn't see a problem. This command lets orchestrator choose the replacement master`):
 		{
 			clusterName := getClusterName(clusterAlias, instanceKey)
+			acknowledgeRecoveryDisabled(clusterName)
 			topologyRecovery, err := logic.ForceMasterFailover(clusterName)
 			if err != nil {
 				log.Fatale(err)
@@ -1487,6 +1839,7 @@ func Cli(command string, strict bool, instance string, destination string, owner
 				log.Fatal("Cannot deduce destination, the instance to promote in place of the master. Please provide with -d")
 			}
 			destination := validateInstanceIsFound(destinationKey)
+			acknowledgeRecoveryDisabled(clusterName)
 			topologyRecovery, err := logic.ForceMasterTakeover(clusterName, destination)
 			if err != nil {
 				log.Fatale(err)
@@ -1553,6 +1906,26 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			}
 			fmt.Println(fmt.Sprintf("%d recoveries acknowldged", countRecoveries))
 		}
+	case registerCliCommand("unacknowledged-recoveries", "Recovery", `List recoveries not yet acknowledged, optionally filtered by cluster`):
+		{
+			clusterName := ""
+			if clusterAlias != "" || instanceKey != nil {
+				clusterName = getClusterName(clusterAlias, instanceKey)
+			}
+			for page := 0; ; page++ {
+				recoveries, err := logic.ReadUnacknowledgedRecoveries(clusterName, page)
+				if err != nil {
+					log.Fatale(err)
+				}
+				for i := range recoveries {
+					recovery := &recoveries[i]
+					fmt.Println(fmt.Sprintf("%d\t%s\t%s\t%+v\t%s\t%s", recovery.Id, recovery.UID, recovery.RecoveryStartTimestamp, recovery.AnalysisEntry.Analysis, recovery.AnalysisEntry.AnalyzedInstanceKey.DisplayString(), recovery.AnalysisEntry.ClusterDetails.ClusterName))
+				}
+				if len(recoveries) < config.AuditPageSize {
+					break
+				}
+			}
+		}
 	// Instance meta
 	case registerCliCommand("register-candidate", "Instance, meta", `Indicate that a specific instance is a preferred candidate for master promotion`):
 		{
@@ -1603,6 +1976,27 @@ func Cli(command string, strict bool, instance string, destination string, owner
 				log.Fatale(err)
 			}
 		}
+	case registerCliCommand("search-audit", "Meta", `Search audit entries by instance, cluster, audit type, time range and message`):
+		{
+			filter := inst.AuditFilter{
+				InstanceKey:   instanceKey,
+				AuditType:     *config.RuntimeCLIFlags.AuditType,
+				FromTimestamp: *config.RuntimeCLIFlags.AuditFrom,
+				ToTimestamp:   *config.RuntimeCLIFlags.AuditTo,
+				MessageMatch:  *config.RuntimeCLIFlags.AuditMessage,
+			}
+			if clusterAlias != "" {
+				filter.ClusterName = getClusterName(clusterAlias, instanceKey)
+			}
+			auditPage, err := inst.AuditOperationsFiltered(filter, *config.RuntimeCLIFlags.Page)
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, audit := range auditPage.Audits {
+				fmt.Println(fmt.Sprintf("%s\t%s\t%s\t%s\t%s", audit.AuditTimestamp, audit.AuditType, audit.AuditInstanceKey.DisplayString(), audit.ClusterName, audit.Message))
+			}
+			log.Infof("search-audit: page %d, %d entries per page, %d matching entries in total", auditPage.Page, auditPage.PageSize, auditPage.TotalCount)
+		}
 	case registerCliCommand("continuous", "Meta", `Enter continuous mode, and actively poll for instances, diagnose problems, do maintenance`):
 		{
 			logic.ContinuousDiscovery()
@@ -1652,6 +2046,30 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			}
 			fmt.Println("hostname resolve cache cleared")
 		}
+	case registerCliCommand("flush-resolve-hostname", "Meta", `Purge given hostname from the hostname resolve cache`):
+		{
+			if rawInstanceKey == nil {
+				log.Fatal("Cannot deduce instance:", instance)
+			}
+			err := inst.FlushResolveHostname(rawInstanceKey.Hostname)
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(rawInstanceKey.Hostname)
+		}
+	case registerCliCommand("show-resolve-cache", "Meta", `Show the content of the hostname resolve cache, with expiry and resolve timestamps`):
+		{
+			if err := inst.LoadHostnameResolveCache(); err != nil {
+				log.Fatale(err)
+			}
+			entries, err := inst.ResolveCacheEntries()
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, entry := range entries {
+				fmt.Println(fmt.Sprintf("%s\t%s\t%s", entry.Hostname, entry.ResolvedHostname, entry.ResolvedTimestamp))
+			}
+		}
 	case registerCliCommand("dump-config", "Meta", `Print out configuration in JSON format`):
 		{
 			jsonString := config.Config.ToJSONString()
@@ -1726,6 +2144,33 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			}
 			fmt.Printf("OK: Global recoveries disabled: %v\n", isDisabled)
 		}
+	case registerCliCommand("disable-cluster-recoveries", "Recovery", `Disallow orchestrator from performing recoveries on a given cluster`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			if err := logic.DisableClusterRecovery(clusterName); err != nil {
+				log.Fatalf("ERROR: Failed to disable recoveries on %s: %v\n", clusterName, err)
+			}
+			fmt.Printf("OK: Orchestrator recoveries DISABLED on %s\n", clusterName)
+		}
+	case registerCliCommand("enable-cluster-recoveries", "Recovery", `Allow orchestrator to perform recoveries on a given cluster`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			if err := logic.EnableClusterRecovery(clusterName); err != nil {
+				log.Fatalf("ERROR: Failed to enable recoveries on %s: %v\n", clusterName, err)
+			}
+			fmt.Printf("OK: Orchestrator recoveries ENABLED on %s\n", clusterName)
+		}
+	case registerCliCommand("recovery-toggles", "Recovery", `Show whether recoveries are disabled globally, and the clusters on which they are disabled`):
+		{
+			toggles, err := logic.ReadRecoveryToggles()
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Printf("Global recoveries disabled: %v\n", toggles.DisabledGlobally)
+			for _, clusterName := range toggles.DisabledClusters {
+				fmt.Printf("Recoveries disabled on: %s\n", clusterName)
+			}
+		}
 	case registerCliCommand("bulk-instances", "", `Return a list of sorted instance names known to orchestrator`):
 		{
 			instances, err := inst.BulkReadInstance()
@@ -89,13 +89,26 @@ func init() {
   Example:
 
   orchestrator -c move-equivalent -i replica.to.revert.master.position.com -d master.to.move.to.com
+	`
+	CommandHelp["apply-rebalance"] = `
+	Perform the relocations suggested by fan-in-report on a given cluster, indicated by instance or alias, one at a
+	time. Before each move, the replica and both intermediate masters are verified to lag no more than
+	ReasonableMaintenanceReplicationLagSeconds. The first failure halts the operation.
+	Output lists the relocated replicas, their former and their new master, tab delimited. Example:
+
+  orchestrator -c apply-rebalance -alias some_alias
 	`
 	CommandHelp["take-siblings"] = `
   Turn all siblings of a replica into its sub-replicas. No action taken for siblings that cannot become
   replicas of given instance (e.g. incompatible versions, binlog format etc.). This is a (faster) shortcut
-  to executing move-below for all siblings of the given instance. Example:
+  to executing move-below for all siblings of the given instance. GTID, Pseudo-GTID or binlog coordinates are
+  used as available, per sibling. A sibling which fails to relocate does not stop the operation; its error is
+  reported. Example:
 
   orchestrator -c take-siblings -i replica.whose.siblings.will.move.below.com
+
+  orchestrator -c take-siblings -i replica.whose.siblings.will.move.below.com --noop
+      Dry run: report how many siblings pass sanity checks, and why others do not, without moving any.
 	`
 	CommandHelp["take-master"] = `
   Turn an instance into a master of its own master; essentially switch the two. Replicas of each of the two
@@ -103,6 +116,9 @@ func init() {
   The instance's master must itself be a replica. It does not necessarily have to be actively replicating.
 
   orchestrator -c take-master -i replica.that.will.switch.places.with.its.master.com
+
+  orchestrator -c take-master -i replica.that.will.switch.places.with.its.master.com --noop
+      Dry run: run sanity checks only, without changing replication.
 	`
 	CommandHelp["repoint"] = `
   Make the given instance replicate from another instance without changing the binglog coordinates. There
@@ -115,6 +131,17 @@ func init() {
       The above will repoint the replica back to its existing master without change
 
   orchestrator -c repoint
+      -i not given, implicitly assumed local hostname
+	`
+	CommandHelp["repoint-to-canonical"] = `
+  Make the given replica replicate from the canonical endpoint (hostname & port, as known to orchestrator) of
+  the master it already replicates from, without changing the binlog coordinates. The master is identified by
+  the replica's Master_UUID where available. Use case: replicas configured with a secondary IP or a proxy port
+  of their master (see ReplicaPointsToWrongEndpoint analysis). Examples:
+
+  orchestrator -c repoint-to-canonical -i replica.to.operate.on.com
+
+  orchestrator -c repoint-to-canonical
       -i not given, implicitly assumed local hostname
 	`
 	CommandHelp["repoint-replicas"] = `
@@ -167,6 +194,17 @@ func init() {
 
   orchestrator -c match -d destination.instance.that.becomes.its.master
       -i not given, implicitly assumed local hostname
+	`
+	CommandHelp["repoint-channel"] = `
+  Make a single replication channel of a multi-source replica replicate from another (destination) instance,
+  leaving other channels intact. The channel must use GTID auto positioning (MASTER_AUTO_POSITION=1).
+  Other relocation commands refuse to operate on multi-source replicas, since they would apply to all channels.
+  Examples:
+
+  orchestrator -c repoint-channel -i multi.source.replica.com --channel=orders -d new.orders.master.com
+
+  orchestrator -c repoint-channel -i multi.source.replica.com --channel="" -d new.master.com
+      Repoints the default channel
 	`
 	CommandHelp["move-replicas-gtid"] = `
   Moves all replicas of a given instance under another (destination) instance using GTID. This is a (faster)
@@ -343,6 +381,18 @@ func init() {
   orchestrator -c reattach-replica-master-host -i detahced.replica.whose.replication.will.amend.com
 
   Issuing this on an attached (i.e. normal) replica will do nothing.
+	`
+	CommandHelp["change-master-credentials"] = `
+  Change replication credentials (CHANGE MASTER TO MASTER_USER=..., MASTER_PASSWORD=...) on all replicas of a
+  cluster, indicated by instance or alias. Replicas are handled one at a time, upstream replicas first: each
+  replica's IO thread is briefly stopped, credentials changed, and the IO thread verified to reconnect.
+  The first failure halts the operation. The password is never given on the command line; provide
+  --replication-password-ref as either ${ENV_VARIABLE} or file:/path/to/file. The password is never logged.
+  Output lists the attempted replicas, tab delimited. Examples:
+
+  orchestrator -c change-master-credentials -alias some_alias --replication-user=repl --replication-password-ref='${REPL_PASSWORD}'
+
+  orchestrator -c change-master-credentials -i some.instance.com --replication-user=repl --replication-password-ref=file:/etc/orchestrator/repl.pwd
 	`
 	CommandHelp["restart-slave-statements"] = `
 	Prints a list of statements to execute to stop then restore replica to same execution state.
@@ -371,6 +421,23 @@ func init() {
   orchestrator -c set-writeable
       -i not given, implicitly assumed local hostname
 	`
+	CommandHelp["set-cluster-read-only"] = `
+  Turn all instances of a cluster, including its master, read-only (and super_read_only per UseSuperReadOnly),
+  as an emergency measure, e.g. upon data corruption. Instances are handled in parallel; each is waited on for
+  up to InstanceBulkOperationsWaitTimeoutSeconds. Instances which could not be set read-only, e.g. unreachable
+  ones, are listed as errors, and do not stop the operation on others.
+  Examples:
+
+  orchestrator -c set-cluster-read-only -alias mycluster
+
+  orchestrator -c set-cluster-read-only -i instance.in.cluster.com
+	`
+	CommandHelp["set-cluster-writeable-master-only"] = `
+  Turn the master of a cluster writeable, leaving all other instances as they are. This is the way back from
+  set-cluster-read-only. Example:
+
+  orchestrator -c set-cluster-writeable-master-only -alias mycluster
+	`
 
 	CommandHelp["flush-binary-logs"] = `
   Flush binary logs on an instance. Examples:
@@ -555,6 +622,55 @@ func init() {
 
   orchestrator -c which-cluster-master -alias some_alias
       assuming some_alias is a known cluster alias (see ClusterNameToAlias or DetectClusterAliasQuery configuration)
+	`
+	CommandHelp["fan-in-report"] = `
+	Output the intermediate masters of a given cluster, indicated by instance or alias, and suggest relocations
+	which would balance replicas among sibling intermediate masters (intermediate masters replicating from the
+	same master, in the same data center). Nothing is changed. Replicas which have replicas of their own, and
+	registered candidates (must/prefer promotion rule), are never suggested to move; replicas only move to an
+	intermediate master in their own data center and region.
+	Output lists each intermediate master with data center, number of replicas and suggested number of replicas,
+	followed by suggested moves: replica, current master, suggested master. Output is tab delimited.
+	Examples:
+
+  orchestrator -c fan-in-report -alias some_alias
+	`
+	CommandHelp["which-candidate"] = `
+	Output the replica which would be promoted should the master of a given cluster, indicated by instance or alias,
+	die now, and why. This applies the same candidate selection a master recovery does (replication position,
+	binlog format, version, promotion rules, data center and geographic constraints), but changes nothing:
+	replication is not stopped and replicas are assessed by their last known state.
+	First line is the candidate and reason. Rejected replicas follow, best ranked first, each with the reason for
+	rejection. Output is tab delimited.
+	Examples:
+
+  orchestrator -c which-candidate -alias some_alias
+
+  orchestrator -c which-candidate -i instance.in.cluster.com
+	`
+	CommandHelp["check-failover"] = `
+	Check whether the master of a given cluster, indicated by instance or alias, could be failed over right now,
+	e.g. ahead of planned maintenance. Checks are: recoveries enabled for the cluster, no recent recovery blocking
+	(active period), a promotion candidate exists (see which-candidate) which is not lagging beyond
+	ReasonableReplicationLagSeconds, has no errant GTIDs and is not downtimed, KV stores are readable, and failover
+	hooks' executables are found. Nothing is changed. Output is tab delimited: pass/FAIL, check, details.
+	Exits with error when any check fails. The same checks are recorded at the start of each recovery.
+	Examples:
+
+  orchestrator -c check-failover -alias some_alias
+
+  orchestrator -c check-failover -i instance.in.cluster.com
+	`
+	CommandHelp["last-snapshot-coordinates"] = `
+	Output the most recent binary log coordinates snapshot taken on a given cluster, indicated by instance or alias.
+	Snapshots are taken via the cluster-snapshot-coordinates API. Output is tab delimited: snapshot timestamp,
+	instance, binary log coordinates and executed GTID set. Instances which could not be read in the snapshot
+	list "-" as coordinates, followed by the read error.
+	Examples:
+
+  orchestrator -c last-snapshot-coordinates -alias some_alias
+
+  orchestrator -c last-snapshot-coordinates -i instance.in.cluster.com
 	`
 	CommandHelp["which-cluster-osc-replicas"] = `
   Output a list of replicas in same cluster as given instance, that would server as good candidates as control replicas
@@ -586,6 +702,14 @@ func init() {
 
   orchestrator -c which-master
       -i not given, implicitly assumed local hostname
+	`
+	CommandHelp["show-downtimed-instances"] = `
+  List instances currently downtimed, one per line, tab delimited: instance, downtime owner, downtime end time,
+  remaining downtime and reason. Optionally filtered by cluster. Examples:
+
+  orchestrator -c show-downtimed-instances
+
+  orchestrator -c show-downtimed-instances -alias mycluster
 	`
 	CommandHelp["which-replicas"] = `
   Output the fully-qualified hostname:port list of replicas (one per line) of a given instance (or empty
@@ -629,6 +753,20 @@ func init() {
   orchestrator -c snapshot-topologies
 	`
 
+	CommandHelp["search-audit"] = `
+  Search audit entries, most recent first, a page at a time (see --page). All filters are optional and
+  combined. The total number of matching entries is logged. Examples:
+
+  orchestrator -c search-audit -i instance.to.investigate.com
+      audit entries of given instance
+
+  orchestrator -c search-audit -alias mycluster --audit-type relocate-below --from '2019-05-01 00:00:00'
+      relocations within given cluster since given time
+
+  orchestrator -c search-audit --message replica-1.company.com --page 1
+      second page of audit entries whose message mentions given text
+	`
+
 	CommandHelp["discover"] = `
   Request that orchestrator cotacts given instance, reads its status, and upsert it into
   orchestrator's respository. Examples:
@@ -652,6 +790,22 @@ func init() {
   orchestrator -c forget -i instance.to.forget.com
 
   Orchestrator will *not* resolve CNAMEs and VIPs for given instance.
+	`
+	CommandHelp["forget-cluster"] = `
+  Request that orchestrator removes all instances of given cluster from its repository, e.g. when
+  decommissioning a cluster. A single audit entry lists the forgotten instances. Refuses to forget
+  more than ForgetInstancesSafetyThreshold instances, unless --force is given. Examples:
+
+  orchestrator -c forget-cluster -alias some_alias
+
+  orchestrator -c forget-cluster -i instance.of.cluster.com --force
+	`
+	CommandHelp["forget-by-pattern"] = `
+  Request that orchestrator removes all instances whose hostname:port matches given regular expression
+  from its repository. A single audit entry lists the forgotten instances. Refuses to forget more than
+  ForgetInstancesSafetyThreshold instances, unless --force is given. Example:
+
+  orchestrator -c forget-by-pattern -pattern "^db-old-[0-9]+[.]company[.]com:"
 	`
 	CommandHelp["begin-maintenance"] = `
   Request a maintenance lock on an instance. Topology changes require placing locks on the minimal set of
@@ -700,12 +854,62 @@ func init() {
 
   orchestrator -c end-downtime -i downtimed.instance.com
 	`
+	CommandHelp["extend-downtime"] = `
+  Extend the active downtime of an instance by given duration. The downtime keeps its owner, reason and
+  begin time. Fails if the instance is not downtimed.
+  Example:
+
+  orchestrator -c extend-downtime -i downtimed.instance.com --duration=1h
+	`
+	CommandHelp["begin-cluster-downtime"] = `
+  Mark all instances of a cluster as downtimed, as with begin-downtime. All instances are downtimed in a
+  single transaction: should any of them fail, none is downtimed. The cluster downtime is recorded as well;
+  with InheritClusterDowntime configured, instances discovered in the cluster while it is downtimed are
+  downtimed for the remainder of the cluster downtime.
+  Examples:
+
+  orchestrator -c begin-cluster-downtime -alias mycluster --duration=3h --reason="planned maintenance"
+      accepted duration format: 10s, 30m, 24h, 3d, 4w
+
+  orchestrator -c begin-cluster-downtime -i instance.in.cluster.com --reason="planned maintenance"
+      --duration not given; default to MaintenanceExpireMinutes (hard coded value)
+	`
+	CommandHelp["end-cluster-downtime"] = `
+  Indicate all instances of a cluster are no longer downtimed, and end the cluster downtime.
+  Example:
+
+  orchestrator -c end-cluster-downtime -alias mycluster
+	`
+	CommandHelp["ignore-health-checks"] = `
+  Mark an instance as unreachable by design, e.g. an analytics replica behind a firewall. Such an instance is no
+  longer polled, is excluded from failure analysis and from the problems list, and is not counted as a replica in
+  its master's analysis. It remains in its topology, with its last known state. Unlike a downtime, this never
+  expires: it lasts until unignore-health-checks. The owner and reason are shown with the instance.
+  Example:
+
+  orchestrator -c ignore-health-checks -i analytics.replica.com --reason="firewalled analytics replica"
+	`
+	CommandHelp["unignore-health-checks"] = `
+  Resume polling and failure analysis of an instance marked by ignore-health-checks.
+  Example:
+
+  orchestrator -c unignore-health-checks -i analytics.replica.com
+	`
+	CommandHelp["health-checks-ignored"] = `
+  List instances whose health checks are ignored, along with owner and reason. Optionally filtered by cluster.
+  Examples:
+
+  orchestrator -c health-checks-ignored
+
+  orchestrator -c health-checks-ignored -alias mycluster
+	`
 
 	CommandHelp["recover"] = `
   Do auto-recovery given a dead instance. Orchestrator chooses the best course of action.
   The given instance must be acknowledged as dead and have replicas, or else there's nothing to do.
   See "replication-analysis" command.
   Orchestrator executes external processes as configured by *Processes variables.
+  When recoveries are disabled globally or for the cluster, --acknowledge-recovery-disabled is required.
   --debug is your friend. Example:
 
   orchestrator -c recover -i dead.instance.com --debug
@@ -723,6 +927,7 @@ func init() {
 	- Orchestrator just treats this command as a DeadMaster failover scenario
   - Orchestrator will issue all relevant pre-failover and post-failover external processes.
   - Orchestrator will not attempt to recover/reconnect the old master
+  - When recoveries are disabled globally or for the cluster, --acknowledge-recovery-disabled is required
 	`
 	CommandHelp["force-master-takeover"] = `
 	Forcibly discard master and promote another (direct child) instance instead, even if everything is running well.
@@ -787,6 +992,27 @@ func init() {
 
   orchestrator -c ack-cluster-recoveries -alias some_alias --reason="dba has taken taken necessary steps"
        Cluster indicated by alias
+	`
+	CommandHelp["disable-cluster-recoveries"] = `
+  Disallow orchestrator from performing automated recoveries on a given cluster. The toggle is persisted in the
+  backend and honored by all orchestrator nodes. Detection and detection hooks still take place. Examples:
+
+  orchestrator -c disable-cluster-recoveries -alias some_alias
+       Cluster indicated by alias
+
+  orchestrator -c disable-cluster-recoveries -i instance.in.a.cluster.com
+       Cluster is indicated by any of its members
+	`
+	CommandHelp["enable-cluster-recoveries"] = `
+  Allow orchestrator to perform automated recoveries on a given cluster, undoing disable-cluster-recoveries.
+  Recoveries remain subject to disable-global-recoveries. Example:
+
+  orchestrator -c enable-cluster-recoveries -alias some_alias
+	`
+	CommandHelp["recovery-toggles"] = `
+  Show whether recoveries are disabled globally, and list the clusters on which recoveries are disabled. Example:
+
+  orchestrator -c recovery-toggles
 	`
 	CommandHelp["ack-instance-recoveries"] = `
   Acknowledge recoveries for a given instance; this unblocks pending future recoveries.
@@ -798,6 +1024,15 @@ func init() {
   orchestrator -c ack-cluster-recoveries -i instance.that.failed.com --reason="dba has taken taken necessary steps"
 	`
 
+	CommandHelp["unacknowledged-recoveries"] = `
+  List recoveries which have not been acknowledged yet, most recent first. Optionally filtered by cluster (provide
+  via -alias or -i). Examples:
+
+  orchestrator -c unacknowledged-recoveries
+
+  orchestrator -c unacknowledged-recoveries -alias mycluster
+	`
+
 	CommandHelp["register-candidate"] = `
   Indicate that a specific instance is a preferred candidate for master promotion. Upon a dead master
   recovery, orchestrator will do its best to promote instances that are marked as candidates. However
@@ -883,6 +1118,20 @@ func init() {
   Clear the hostname resolve cache; it will be refilled by following host discoveries
 
   orchestrator -c reset-hostname-resolve-cache
+	`
+	CommandHelp["flush-resolve-hostname"] = `
+  Purge given hostname from the hostname resolve cache (and from the hostname_resolve backend table), such that it is
+  resolved afresh upon next use. Use when a DNS record has been repointed. Entries resolving into given hostname are
+  purged as well. Note that a running orchestrator service keeps its own in-memory cache; use the
+  /api/hostname-unresolve/:host API on that service to purge it. Example:
+
+  orchestrator -c flush-resolve-hostname -i cname.to.flush
+	`
+	CommandHelp["show-resolve-cache"] = `
+  Show the content of the hostname resolve cache: hostname, resolved hostname and the time the resolve was persisted.
+  Example:
+
+  orchestrator -c show-resolve-cache
 	`
 	CommandHelp["resolve"] = `
   Utility command to resolve a CNAME and return resolved hostname name. Example:
@@ -62,6 +62,17 @@ func main() {
 	config.RuntimeCLIFlags.EnableDatabaseUpdate = flag.Bool("enable-database-update", false, "Enable database update, overrides SkipOrchestratorDatabaseUpdate")
 	config.RuntimeCLIFlags.IgnoreRaftSetup = flag.Bool("ignore-raft-setup", false, "Override RaftEnabled for CLI invocation (CLI by default not allowed for raft setups). NOTE: operations by CLI invocation may not reflect in all raft nodes.")
 	config.RuntimeCLIFlags.Tag = flag.String("tag", "", "tag to add ('tagname' or 'tagname=tagvalue') or to search ('tagname' or 'tagname=tagvalue' or comma separated 'tag0,tag1=val1,tag2' for intersection of all)")
+	config.RuntimeCLIFlags.Force = flag.Bool("force", false, "Force an operation otherwise refused for safety (e.g. bulk forget exceeding ForgetInstancesSafetyThreshold)")
+	config.RuntimeCLIFlags.Channel = flag.String("channel", "", "Replication channel (applies for operations on multi-source replicas)")
+	config.RuntimeCLIFlags.ReplicationUser = flag.String("replication-user", "", "Replication user (applies for change-master-credentials)")
+	config.RuntimeCLIFlags.ReplicationPasswordRef = flag.String("replication-password-ref", "", "Reference to replication password: ${ENV_VARIABLE} or file:/path/to/file (applies for change-master-credentials)")
+	config.RuntimeCLIFlags.AllowCrossCluster = flag.Bool("allow-cross-cluster", false, "Allow relocating a replica below an instance of a different cluster (refused by default, as likely a typo)")
+	config.RuntimeCLIFlags.AckRecoveryDisabled = flag.Bool("acknowledge-recovery-disabled", false, "Force a recovery on a cluster where recoveries are disabled (applies for recover, recover-lite, force-master-failover, force-master-takeover)")
+	config.RuntimeCLIFlags.AuditType = flag.String("audit-type", "", "Audit type to search for (applies for search-audit)")
+	config.RuntimeCLIFlags.AuditFrom = flag.String("from", "", "Earliest audit timestamp to search for, e.g. '2019-05-01 00:00:00' (applies for search-audit)")
+	config.RuntimeCLIFlags.AuditTo = flag.String("to", "", "Latest audit timestamp to search for, e.g. '2019-05-02 00:00:00' (applies for search-audit)")
+	config.RuntimeCLIFlags.AuditMessage = flag.String("message", "", "Text the audit message should contain (applies for search-audit)")
+	config.RuntimeCLIFlags.Page = flag.Int("page", 0, "Page number, 0 based (applies for search-audit)")
 	flag.Parse()
 
 	if *destination != "" && *sibling != "" {
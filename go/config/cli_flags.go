@@ -32,6 +32,17 @@ type CLIFlags struct {
 	EnableDatabaseUpdate       *bool
 	IgnoreRaftSetup            *bool
 	Tag                        *string
+	Force                      *bool
+	Channel                    *string
+	ReplicationUser            *string
+	ReplicationPasswordRef     *string
+	AllowCrossCluster          *bool
+	AckRecoveryDisabled        *bool
+	AuditType                  *string
+	AuditFrom                  *string
+	AuditTo                    *string
+	AuditMessage               *string
+	Page                       *int
 }
 
 var RuntimeCLIFlags CLIFlags
@@ -58,7 +58,9 @@ const (
 	PseudoGTIDIntervalSeconds                    = 5
 	PseudoGTIDExpireMinutes                      = 60
 	CheckAutoPseudoGTIDGrantsIntervalSeconds     = 60
+	CheckEnabledEventsIntervalSeconds            = 60
 	SelectTrueQuery                              = "select 1"
+	DefaultDiscoveryQueue                        = "default"
 )
 
 var deprecatedConfigurationVariables = []string{
@@ -128,6 +130,7 @@ type Configuration struct {
 	MySQLConnectTimeoutSeconds                 int      // Number of seconds before connection is aborted (driver-side)
 	MySQLOrchestratorReadTimeoutSeconds        int      // Number of seconds before backend mysql read operation is aborted (driver-side)
 	MySQLDiscoveryReadTimeoutSeconds           int      // Number of seconds before topology mysql read operation is aborted (driver-side). Used for discovery queries.
+	DiscoveryQueryTimeoutSeconds               int      // Number of seconds before a single discovery query is canceled (context-side). 0 means InstancePollSeconds. A timed out query results in a partially read instance rather than a failed discovery.
 	MySQLTopologyReadTimeoutSeconds            int      // Number of seconds before topology mysql read operation is aborted (driver-side). Used for all but discovery queries.
 	MySQLConnectionLifetimeSeconds             int      // Number of seconds the mysql driver will keep database connection alive before recycling it
 	DefaultInstancePort                        int      // In case port was not specified on command line
@@ -135,18 +138,32 @@ type Configuration struct {
 	ReplicationLagQuery                        string   // custom query to check on replica lg (e.g. heartbeat table). Must return a single row with a single numeric column, which is the lag.
 	ReplicationCredentialsQuery                string   // custom query to get replication credentials. Must return a single row, with two text columns: 1st is username, 2nd is password. This is optional, and can be used by orchestrator to configure replication after master takeover or setup of co-masters. You need to ensure the orchestrator user has the privileges to run this query
 	DiscoverByShowSlaveHosts                   bool     // Attempt SHOW SLAVE HOSTS before PROCESSLIST
-	UseSuperReadOnly                           bool     // Should orchestrator super_read_only any time it sets read_only
+	UseSuperReadOnly                           bool     // Should orchestrator super_read_only any time it sets read_only (e.g. on demotion). Regardless, super_read_only is cleared when making an instance writeable
 	InstancePollSeconds                        uint     // Number of seconds between instance reads
+	StaleInstancePollMultiplier                uint     // An instance not checked for this many InstancePollSeconds is considered stale. Failure analysis does not act on stale data; it rather requests an urgent re-check
 	InstanceWriteBufferSize                    int      // Instance write buffer size (max number of instances to flush in one INSERT ODKU)
 	BufferInstanceWrites                       bool     // Set to 'true' for write-optimization on backend table (compromise: writes can be stale and overwrite non stale data)
 	InstanceFlushIntervalMilliseconds          int      // Max interval between instance write buffer flushes
 	SkipMaxScaleCheck                          bool     // If you don't ever have MaxScale BinlogServer in your topology (and most people don't), set this to 'true' to save some pointless queries
+	BinlogServerVersionPattern                 string   // Regexp pattern matched against the version of an instance, identifying it as a binlog server (other than MaxScale, which is always identified). Empty to disable
 	UnseenInstanceForgetHours                  uint     // Number of hours after which an unseen instance is forgotten
+	ForgetInstancesSafetyThreshold             uint     // Bulk forget (forget-cluster, forget-by-pattern) refuses to forget more than this many instances at once unless forced. 0 means no limit
 	SnapshotTopologiesIntervalHours            uint     // Interval in hour between snapshot-topologies invocation. Default: 0 (disabled)
 	DiscoveryMaxConcurrency                    uint     // Number of goroutines doing hosts discovery
+	DiscoveryFirstSeenReservedConcurrency      uint     // Number of discovery goroutines, out of max concurrency, reserved for instances never discovered before and for urgent discoveries
 	DiscoveryQueueCapacity                     uint     // Buffer size of the discovery queue. Should be greater than the number of DB instances being discovered
 	DiscoveryQueueMaxStatisticsSize            int      // The maximum number of individual secondly statistics taken of the discovery queue
 	DiscoveryCollectionRetentionSeconds        uint     // Number of seconds to retain the discovery collection information
+	DiscoveryBackoffMaxSeconds                 uint     // Upper limit for backing off discovery of an instance that repeatedly fails discovery. 0 disables backoff
+	DiscoveryTimeoutSeconds                    uint     // Number of seconds after which the queries of a discovery are cancelled; a discovery still hung is abandoned and its worker is freed. 0 means no timeout
+	DiscoveryMaxPerSecond                      uint     // Max number of discoveries to start per second, per discovery queue, regardless of DiscoveryMaxConcurrency. 0 means unlimited
+	DiscoveryMaxQueueAgeSeconds                uint     // Discovery requests waiting on the queue for longer than this are dropped rather than processed. 0 means no limit
+	DiscoveryMaxRetries                        uint     // Number of times in a row a failed discovery is retried before giving up on it until next poll. 0 disables retries
+	DiscoveryRetryDelaySeconds                 uint     // Number of seconds to wait before retrying a failed discovery
+	DiscoverySuccessCooldownSeconds            uint     // Discovery requests for an instance successfully discovered within this number of seconds are dropped, unless forced. 0 disables
+	DiscoveryClusterJitterSeconds              uint     // Discoveries of instances of a same cluster requested at once are spread over this number of seconds. 0 disables
+	PersistDiscoveryQueue                      bool     // When true, pending discovery requests are written to the backend database on shutdown, and requeued on startup
+	PersistDiscoveryQueueMaxAgeSeconds         uint     // Persisted discovery requests older than this number of seconds are not requeued on startup
 	InstanceBulkOperationsWaitTimeoutSeconds   uint     // Time to wait on a single instance when doing bulk (many instances) operation
 	HostnameResolveMethod                      string   // Method by which to "normalize" hostname ("none"/"default"/"cname")
 	MySQLHostnameResolveMethod                 string   // Method by which to "normalize" hostname via MySQL server. ("none"/"@@hostname"/"@@report_host"; default "@@hostname")
@@ -157,7 +174,15 @@ type Configuration struct {
 	ProblemIgnoreHostnameFilters               []string // Will minimize problem visualization for hostnames matching given regexp filters
 	VerifyReplicationFilters                   bool     // Include replication filters check before approving topology refactoring
 	ReasonableMaintenanceReplicationLagSeconds int      // Above this value move-up and move-below are blocked
+	AutoRestartReplicationSQLThreadErrorCodes  []uint   // SQL thread error codes (e.g. 1205, 1213) upon which orchestrator issues START SLAVE SQL_THREAD on a stopped replica. Empty (default) disables
+	AutoRestartReplicationSQLThreadMaxPerHour  uint     // Maximum number of automated SQL thread restarts per replica per hour
 	CandidateInstanceExpireMinutes             uint     // Minutes after which a suggestion to use an instance as a candidate replica (to be preferably promoted on master failover) is expired.
+	RecentlyRestartedThresholdSeconds          uint     // Instances with lower uptime are considered recently restarted (e.g. cold buffer pool), and are deprioritized as promotion candidates. 0 disables
+	MasterWritesProbeSchema                    string   // Schema holding the master writes probe table, written to upon master discovery to detect blocked writes (e.g. FLUSH TABLES WITH READ LOCK). Empty (default) disables
+	MasterWritesProbeTimeoutSeconds            uint     // A master writes probe waiting longer than this marks the master's writes as blocked
+	BinlogSummaryIntervalMinutes               uint     // Interval at which SHOW BINARY LOGS is summarized (count, size, first file) on cluster masters. 0 disables
+	BinlogRetentionMarginWarningFiles          uint     // When non zero, a master retaining fewer binary logs than this beyond the oldest one its replicas need is reported as LowBinlogRetentionMargin
+	RequireReplicationSSL                      bool     // When true, any replication link not using SSL is reported as PlaintextReplication. When false, only such links in clusters where other links use SSL are reported
 	AuditLogFile                               string   // Name of log file for audit operations. Disabled when empty.
 	AuditToSyslog                              bool     // If true, audit messages are written to syslog
 	AuditToBackendDB                           bool     // If true, audit messages are written to the backend DB's `audit` table (default: true)
@@ -186,6 +211,8 @@ type Configuration struct {
 	DetectRegionQuery                          string            // Optional query (executed on topology instance) that returns the region of an instance. If provided, must return one row, one column. Overrides RegionPattern and useful for installments where Region cannot be inferred by hostname
 	DetectPhysicalEnvironmentQuery             string            // Optional query (executed on topology instance) that returns the physical environment of an instance. If provided, must return one row, one column. Overrides PhysicalEnvironmentPattern and useful for installments where env cannot be inferred by hostname
 	DetectSemiSyncEnforcedQuery                string            // Optional query (executed on topology instance) to determine whether semi-sync is fully enforced for master writes (async fallback is not allowed under any circumstance). If provided, must return one row, one column, value 0 or 1.
+	DetectBinlogServerQuery                    string            // Optional query (executed on topology instance) to determine whether the instance is a binlog server (other than MaxScale, which is always identified). If provided, must return one row, one column, value 0 or 1.
+	DetectInstancePoolQuery                    string            // Optional query (executed on topology instance) that returns the pools an instance belongs to. If provided, must return one row, one column: a comma delimited list of pool names. Detected memberships expire like submitted ones (see InstancePoolExpiryMinutes)
 	SupportFuzzyPoolHostnames                  bool              // Should "submit-pool-instances" command be able to pass list of fuzzy instances (fuzzy means non-fqdn, but unique enough to recognize). Defaults 'true', implies more queries on backend db
 	InstancePoolExpiryMinutes                  uint              // Time after which entries in database_instance_pool are expired (resubmit via `submit-pool-instances`)
 	PromotionIgnoreHostnameFilters             []string          // Orchestrator will not promote replicas with hostname matching pattern (via -c recovery; for example, avoid promoting dev-dedicated machines)
@@ -212,6 +239,8 @@ type Configuration struct {
 	SeedAcceptableBytesDiff                    int64             // Difference in bytes between seed source & target data size that is still considered as successful copy
 	SeedWaitSecondsBeforeSend                  int64             // Number of seconds for waiting before start send data command on agent
 	AutoPseudoGTID                             bool              // Should orchestrator automatically inject Pseudo-GTID entries to the masters
+	AutoPseudoGTIDIntervalSeconds              uint              // Interval between AutoPseudoGTID injections onto each writeable master
+	AutoPseudoGTIDStatement                    string            // Statement injected by AutoPseudoGTID; {hint} is replaced by a unique, monotonically increasing hint. Requires PseudoGTIDPattern. When empty, orchestrator's own statement is used
 	PseudoGTIDPattern                          string            // Pattern to look for in binary logs that makes for a unique entry (pseudo GTID). When empty, Pseudo-GTID based refactoring is disabled.
 	PseudoGTIDPatternIsFixedSubstring          bool              // If true, then PseudoGTIDPattern is not treated as regular expression but as fixed substring, and can boost search time
 	PseudoGTIDMonotonicHint                    string            // subtring in Pseudo-GTID entry which indicates Pseudo-GTID entries are expected to be monotonically increasing
@@ -219,14 +248,19 @@ type Configuration struct {
 	BinlogEventsChunkSize                      int               // Chunk size (X) for SHOW BINLOG|RELAYLOG EVENTS LIMIT ?,X statements. Smaller means less locking and mroe work to be done
 	SkipBinlogEventsContaining                 []string          // When scanning/comparing binlogs for Pseudo-GTID, skip entries containing given texts. These are NOT regular expressions (would consume too much CPU while scanning binlogs), just substrings to find.
 	ReduceReplicationAnalysisCount             bool              // When true, replication analysis will only report instances where possibility of handled problems is possible in the first place (e.g. will not report most leaf nodes, that are mostly uninteresting). When false, provides an entry for every known instance
+	DeadMasterReplicasQuorumFraction           float64           // Fraction (0, 1] of a master's reachable replicas which must be broken (not replicating, or replicating with a stale heartbeat) for an unreachable master to be analyzed as DeadMaster rather than UnreachableMaster
 	FailureDetectionPeriodBlockMinutes         int               // The time for which an instance's failure discovery is kept "active", so as to avoid concurrent "discoveries" of the instance's failure; this preceeds any recovery process, if any.
 	RecoveryPeriodBlockMinutes                 int               // (supported for backwards compatibility but please use newer `RecoveryPeriodBlockSeconds` instead) The time for which an instance's recovery is kept "active", so as to avoid concurrent recoveries on smae instance as well as flapping
 	RecoveryPeriodBlockSeconds                 int               // (overrides `RecoveryPeriodBlockMinutes`) The time for which an instance's recovery is kept "active", so as to avoid concurrent recoveries on smae instance as well as flapping
+	RecoveryRegistrationExpirySeconds          uint              // The time after which the registration of an unfinished recovery, whose processing orchestrator node is no longer healthy, expires, so that another node may take over
 	RecoveryIgnoreHostnameFilters              []string          // Recovery analysis will completely ignore hosts matching given patterns
 	RecoverMasterClusterFilters                []string          // Only do master recovery on clusters matching these regexp patterns (of course the ".*" pattern matches everything)
 	RecoverIntermediateMasterClusterFilters    []string          // Only do IM recovery on clusters matching these regexp patterns (of course the ".*" pattern matches everything)
+	RecoverySimulationMode                     bool              // When true, automated master recoveries only compute and record their plan (candidate, replicas to move, hooks to run) as a simulated recovery, executing nothing. Other automated recoveries are skipped
 	ProcessesShellCommand                      string            // Shell that executes command scripts
+	ProcessesTimeoutSeconds                    uint              // Time limit for a single hook process; one running longer is killed and considered failed. 0 means no limit
 	OnFailureDetectionProcesses                []string          // Processes to execute when detecting a failover scenario (before making a decision whether to failover or not). May and should use some of these placeholders: {failureType}, {failureDescription}, {command}, {failedHost}, {failureCluster}, {failureClusterAlias}, {failureClusterDomain}, {failedPort}, {successorHost}, {successorPort}, {successorAlias}, {countReplicas}, {replicaHosts}, {isDowntimed}, {autoMasterRecovery}, {autoIntermediateMasterRecovery}
+	OnNoWriteableMasterProcesses               []string          // Processes to execute when a cluster's master is found read_only (NoWriteableMasterStructureWarning); at most once per FailureDetectionPeriodBlockMinutes per master. Uses same placeholders as OnFailureDetectionProcesses
 	PreGracefulTakeoverProcesses               []string          // Processes to execute before doing a failover (aborting operation should any once of them exits with non-zero code; order of execution undefined). May and should use some of these placeholders: {failureType}, {failureDescription}, {command}, {failedHost}, {failureCluster}, {failureClusterAlias}, {failureClusterDomain}, {failedPort}, {successorHost}, {successorPort}, {countReplicas}, {replicaHosts}, {isDowntimed}
 	PreFailoverProcesses                       []string          // Processes to execute before doing a failover (aborting operation should any once of them exits with non-zero code; order of execution undefined). May and should use some of these placeholders: {failureType}, {failureDescription}, {command}, {failedHost}, {failureCluster}, {failureClusterAlias}, {failureClusterDomain}, {failedPort}, {countReplicas}, {replicaHosts}, {isDowntimed}
 	PostFailoverProcesses                      []string          // Processes to execute after doing a failover (order of execution undefined). May and should use some of these placeholders: {failureType}, {failureDescription}, {command}, {failedHost}, {failureCluster}, {failureClusterAlias}, {failureClusterDomain}, {failedPort}, {successorHost}, {successorPort}, {successorAlias}, {countReplicas}, {replicaHosts}, {isDowntimed}, {isSuccessful}, {lostReplicas}, {countLostReplicas}
@@ -235,17 +269,26 @@ type Configuration struct {
 	PostIntermediateMasterFailoverProcesses    []string          // Processes to execute after doing a master failover (order of execution undefined). Uses same placeholders as PostFailoverProcesses
 	PostGracefulTakeoverProcesses              []string          // Processes to execute after runnign a graceful master takeover. Uses same placeholders as PostFailoverProcesses
 	PostTakeMasterProcesses                    []string          // Processes to execute after a successful Take-Master event has taken place
+	ResurrectedOldMasterFenceProcesses         []string          // Processes to execute when FenceResurrectedOldMasterAfterFailover fences a failed master which came back to life. These run at most once per FailureDetectionPeriodBlockMinutes per instance, once it is read_only; failed fences are retried with an exponential backoff. Uses same placeholders as OnFailureDetectionProcesses
 	CoMasterRecoveryMustPromoteOtherCoMaster   bool              // When 'false', anything can get promoted (and candidates are prefered over others). When 'true', orchestrator will promote the other co-master or else fail
 	DetachLostSlavesAfterMasterFailover        bool              // synonym to DetachLostReplicasAfterMasterFailover
 	DetachLostReplicasAfterMasterFailover      bool              // Should replicas that are not to be lost in master recovery (i.e. were more up-to-date than promoted replica) be forcibly detached
 	ApplyMySQLPromotionAfterMasterFailover     bool              // Should orchestrator take upon itself to apply MySQL master promotion: set read_only=0, detach replication, etc.
+	FenceResurrectedOldMasterAfterFailover     bool              // When true, a failed master of an unacknowledged master recovery, seen alive again yet not replicating from its successor, is set read_only (super_read_only per UseSuperReadOnly) and reported as ResurrectedOldMaster
 	PreventCrossDataCenterMasterFailover       bool              // When true (default: false), cross-DC master failover are not allowed, orchestrator will do all it can to only fail over within same DC, or else not fail over at all.
 	PreventCrossRegionMasterFailover           bool              // When true (default: false), cross-region master failover are not allowed, orchestrator will do all it can to only fail over within same region, or else not fail over at all.
+	PreferSameDataCenterMasterFailover         bool              // When true (default: false), master failover prefers promoting a server in the failed master's data center over a better candidate elsewhere, yet promotes a server elsewhere if none is found. Meaningless if PreventCrossDataCenterMasterFailover is 'true'
+	InheritClusterDowntime                     bool              // When true, instances newly discovered in a cluster downtimed via begin-cluster-downtime are downtimed for the remainder of the cluster downtime
 	MasterFailoverLostInstancesDowntimeMinutes uint              // Number of minutes to downtime any server that was lost after a master failover (including failed master & lost replicas). 0 to disable
+	StrandedReplicasRematchSeconds             uint              // Number of seconds following a successful master failover during which replicas still replicating from the failed master are relocated below the promoted master. 0 to disable
+	MasterFailoverCandidateFallbackAttempts    uint              // Number of times a master failover falls back to the next-ranked candidate when the chosen one, re-verified right before promotion, turns out unreachable or with broken replication. 0 to abort the failover instead
 	MasterFailoverDetachSlaveMasterHost        bool              // synonym to MasterFailoverDetachReplicaMasterHost
 	MasterFailoverDetachReplicaMasterHost      bool              // Should orchestrator issue a detach-replica-master-host on newly promoted master (this makes sure the new master will not attempt to replicate old master if that comes back to life). Defaults 'false'. Meaningless if ApplyMySQLPromotionAfterMasterFailover is 'true'.
 	FailMasterPromotionIfSQLThreadNotUpToDate  bool              // when true, and a master failover takes place, if candidate master has not consumed all relay logs, promotion is aborted with error
 	DelayMasterPromotionIfSQLThreadNotUpToDate bool              // when true, and a master failover takes place, if candidate master has not consumed all relay logs, delay promotion until the sql thread has caught up
+	DelayMasterPromotionTimeoutSeconds         uint              // Time limit for DelayMasterPromotionIfSQLThreadNotUpToDate to wait on the promoted replica's sql thread; promotion fails upon timeout. 0 means no limit
+	ResetMasterDelayOnPromotion                bool              // when true, and a master failover has no choice but to promote an intentionally delayed replica (MASTER_DELAY), its delay is reset and its relay logs applied first, within DelayMasterPromotionTimeoutSeconds
+	PromotionMaxSQLThreadLagSeconds            uint              // On master failover, candidates whose sql thread lags (Seconds_Behind_Master, or else estimated off the relay log backlog) by more than this are only promoted when no other candidate exists. Value of 0 disables this feature
 	PostponeSlaveRecoveryOnLagMinutes          uint              // Synonym to PostponeReplicaRecoveryOnLagMinutes
 	PostponeReplicaRecoveryOnLagMinutes        uint              // On crash recovery, replicas that are lagging more than given minutes are only resurrected late in the recovery process, after master/IM has been elected and processes executed. Value of 0 disables this feature
 	OSCIgnoreHostnameFilters                   []string          // OSC replicas recommendation will ignore replica hostnames matching given patterns
@@ -254,7 +297,12 @@ type Configuration struct {
 	GraphiteConvertHostnameDotsToUnderscores   bool              // If true, then hostname's dots are converted to underscores before being used in graphite path
 	GraphitePollSeconds                        int               // Graphite writes interval. 0 disables.
 	URLPrefix                                  string            // URL prefix to run orchestrator on non-root web path, e.g. /orchestrator to put it behind nginx.
+	DNSSeedSRVNames                            []string          // Optional DNS SRV names (e.g. "_mysql._tcp.orders.example.com") periodically resolved; their targets are queued for discovery unless already known
+	DNSSeedIntervalSeconds                     uint              // Interval between resolutions of DNSSeedSRVNames
+	DNSSeedForgetMissing                       bool              // When true, instances whose DNS SRV target disappears from DNSSeedSRVNames are forgotten. Otherwise they are only logged
 	DiscoveryIgnoreReplicaHostnameFilters      []string          // Regexp filters to apply to prevent auto-discovering new replicas. Usage: unreachable servers due to firewalls, applications which trigger binlog dumps
+	DiscoveryQueues                            map[string]uint   // Optional named discovery queues and their max concurrency, e.g. {"dc1": 20, "dc2": 5, "default": 10}. Instances are routed onto the queue named by their data center as per DataCenterPattern, falling back to the default queue
+	ClusterCoordinatesSnapshotTimeoutSeconds   uint              // Time limit for reading binary log coordinates off all instances of a cluster in a cluster coordinates snapshot. Instances not read by then are marked as such
 	ConsulAddress                              string            // Address where Consul HTTP api is found. Example: 127.0.0.1:8500
 	ConsulAclToken                             string            // ACL token used to write to Consul KV
 	ConsulCrossDataCenterDistribution          bool              // should orchestrator automatically auto-deduce all consul DCs and write KVs in all DCs
@@ -301,23 +349,38 @@ func newConfiguration() *Configuration {
 		MySQLConnectTimeoutSeconds:                 2,
 		MySQLOrchestratorReadTimeoutSeconds:        30,
 		MySQLDiscoveryReadTimeoutSeconds:           10,
+		DiscoveryQueryTimeoutSeconds:               0,
 		MySQLTopologyReadTimeoutSeconds:            600,
 		MySQLConnectionLifetimeSeconds:             0,
 		DefaultInstancePort:                        3306,
 		TLSCacheTTLFactor:                          100,
 		InstancePollSeconds:                        5,
+		StaleInstancePollMultiplier:                5,
 		InstanceWriteBufferSize:                    100,
 		BufferInstanceWrites:                       false,
 		InstanceFlushIntervalMilliseconds:          100,
 		SkipMaxScaleCheck:                          false,
+		BinlogServerVersionPattern:                 "",
 		UnseenInstanceForgetHours:                  240,
+		ForgetInstancesSafetyThreshold:             25,
 		SnapshotTopologiesIntervalHours:            0,
 		DiscoverByShowSlaveHosts:                   false,
 		UseSuperReadOnly:                           false,
 		DiscoveryMaxConcurrency:                    300,
+		DiscoveryFirstSeenReservedConcurrency:      2,
 		DiscoveryQueueCapacity:                     100000,
 		DiscoveryQueueMaxStatisticsSize:            120,
 		DiscoveryCollectionRetentionSeconds:        120,
+		DiscoveryBackoffMaxSeconds:                 0,
+		DiscoveryTimeoutSeconds:                    0,
+		DiscoveryMaxPerSecond:                      0,
+		DiscoveryMaxQueueAgeSeconds:                0,
+		DiscoveryMaxRetries:                        0,
+		DiscoveryRetryDelaySeconds:                 1,
+		DiscoverySuccessCooldownSeconds:            0,
+		DiscoveryClusterJitterSeconds:              0,
+		PersistDiscoveryQueue:                      false,
+		PersistDiscoveryQueueMaxAgeSeconds:         600,
 		InstanceBulkOperationsWaitTimeoutSeconds:   10,
 		HostnameResolveMethod:                      "default",
 		MySQLHostnameResolveMethod:                 "@@hostname",
@@ -328,7 +391,15 @@ func newConfiguration() *Configuration {
 		ProblemIgnoreHostnameFilters:               []string{},
 		VerifyReplicationFilters:                   false,
 		ReasonableMaintenanceReplicationLagSeconds: 20,
+		AutoRestartReplicationSQLThreadErrorCodes:  []uint{},
+		AutoRestartReplicationSQLThreadMaxPerHour:  3,
 		CandidateInstanceExpireMinutes:             60,
+		RecentlyRestartedThresholdSeconds:          600,
+		MasterWritesProbeSchema:                    "",
+		MasterWritesProbeTimeoutSeconds:            2,
+		BinlogSummaryIntervalMinutes:               10,
+		BinlogRetentionMarginWarningFiles:          0,
+		RequireReplicationSSL:                      false,
 		AuditLogFile:                               "",
 		AuditToSyslog:                              false,
 		AuditToBackendDB:                           false,
@@ -352,6 +423,8 @@ func newConfiguration() *Configuration {
 		DetectDataCenterQuery:                      "",
 		DetectPhysicalEnvironmentQuery:             "",
 		DetectSemiSyncEnforcedQuery:                "",
+		DetectBinlogServerQuery:                    "",
+		DetectInstancePoolQuery:                    "",
 		SupportFuzzyPoolHostnames:                  true,
 		InstancePoolExpiryMinutes:                  60,
 		PromotionIgnoreHostnameFilters:             []string{},
@@ -376,6 +449,8 @@ func newConfiguration() *Configuration {
 		SeedAcceptableBytesDiff:                    8192,
 		SeedWaitSecondsBeforeSend:                  2,
 		AutoPseudoGTID:                             false,
+		AutoPseudoGTIDIntervalSeconds:              PseudoGTIDIntervalSeconds,
+		AutoPseudoGTIDStatement:                    "",
 		PseudoGTIDPattern:                          "",
 		PseudoGTIDPatternIsFixedSubstring:          false,
 		PseudoGTIDMonotonicHint:                    "",
@@ -383,14 +458,19 @@ func newConfiguration() *Configuration {
 		BinlogEventsChunkSize:                      10000,
 		SkipBinlogEventsContaining:                 []string{},
 		ReduceReplicationAnalysisCount:             true,
+		DeadMasterReplicasQuorumFraction:           1,
 		FailureDetectionPeriodBlockMinutes:         60,
 		RecoveryPeriodBlockMinutes:                 60,
 		RecoveryPeriodBlockSeconds:                 3600,
+		RecoveryRegistrationExpirySeconds:          60,
 		RecoveryIgnoreHostnameFilters:              []string{},
 		RecoverMasterClusterFilters:                []string{},
 		RecoverIntermediateMasterClusterFilters:    []string{},
+		RecoverySimulationMode:                     false,
 		ProcessesShellCommand:                      "bash",
+		ProcessesTimeoutSeconds:                    300,
 		OnFailureDetectionProcesses:                []string{},
+		OnNoWriteableMasterProcesses:               []string{},
 		PreGracefulTakeoverProcesses:               []string{},
 		PreFailoverProcesses:                       []string{},
 		PostMasterFailoverProcesses:                []string{},
@@ -399,15 +479,24 @@ func newConfiguration() *Configuration {
 		PostUnsuccessfulFailoverProcesses:          []string{},
 		PostGracefulTakeoverProcesses:              []string{},
 		PostTakeMasterProcesses:                    []string{},
+		ResurrectedOldMasterFenceProcesses:         []string{},
 		CoMasterRecoveryMustPromoteOtherCoMaster:   true,
 		DetachLostSlavesAfterMasterFailover:        true,
 		ApplyMySQLPromotionAfterMasterFailover:     true,
+		FenceResurrectedOldMasterAfterFailover:     false,
 		PreventCrossDataCenterMasterFailover:       false,
 		PreventCrossRegionMasterFailover:           false,
+		PreferSameDataCenterMasterFailover:         false,
+		InheritClusterDowntime:                     false,
 		MasterFailoverLostInstancesDowntimeMinutes: 0,
+		StrandedReplicasRematchSeconds:             0,
+		MasterFailoverCandidateFallbackAttempts:    2,
 		MasterFailoverDetachSlaveMasterHost:        false,
 		FailMasterPromotionIfSQLThreadNotUpToDate:  false,
 		DelayMasterPromotionIfSQLThreadNotUpToDate: false,
+		DelayMasterPromotionTimeoutSeconds:         300,
+		ResetMasterDelayOnPromotion:                false,
+		PromotionMaxSQLThreadLagSeconds:            0,
 		PostponeSlaveRecoveryOnLagMinutes:          0,
 		OSCIgnoreHostnameFilters:                   []string{},
 		GraphiteAddr:                               "",
@@ -415,7 +504,12 @@ func newConfiguration() *Configuration {
 		GraphiteConvertHostnameDotsToUnderscores:   true,
 		GraphitePollSeconds:                        60,
 		URLPrefix:                                  "",
+		DNSSeedSRVNames:                            []string{},
+		DNSSeedIntervalSeconds:                     60,
+		DNSSeedForgetMissing:                       false,
 		DiscoveryIgnoreReplicaHostnameFilters:      []string{},
+		DiscoveryQueues:                            map[string]uint{},
+		ClusterCoordinatesSnapshotTimeoutSeconds:   5,
 		ConsulAddress:                              "",
 		ConsulAclToken:                             "",
 		ConsulCrossDataCenterDistribution:          false,
@@ -475,6 +569,10 @@ func (this *Configuration) postReadAdjustments() error {
 		}
 	}
 
+	if this.DeadMasterReplicasQuorumFraction <= 0 || this.DeadMasterReplicasQuorumFraction > 1 {
+		return fmt.Errorf("config's DeadMasterReplicasQuorumFraction must be greater than 0 and at most 1")
+	}
+
 	if this.RecoveryPeriodBlockSeconds == 0 && this.RecoveryPeriodBlockMinutes > 0 {
 		// RecoveryPeriodBlockSeconds is a newer addition that overrides RecoveryPeriodBlockMinutes
 		// The code does not consider RecoveryPeriodBlockMinutes anymore, but RecoveryPeriodBlockMinutes
@@ -548,9 +646,21 @@ func (this *Configuration) postReadAdjustments() error {
 		this.KVClusterMasterPrefix = fmt.Sprintf("%s/", this.KVClusterMasterPrefix)
 	}
 	if this.AutoPseudoGTID {
-		this.PseudoGTIDPattern = "drop view if exists `_pseudo_gtid_`"
-		this.PseudoGTIDPatternIsFixedSubstring = true
-		this.PseudoGTIDMonotonicHint = "asc:"
+		if this.AutoPseudoGTIDStatement == "" {
+			this.PseudoGTIDPattern = "drop view if exists `_pseudo_gtid_`"
+			this.PseudoGTIDPatternIsFixedSubstring = true
+			this.PseudoGTIDMonotonicHint = "asc:"
+		} else {
+			if !strings.Contains(this.AutoPseudoGTIDStatement, "{hint}") {
+				return fmt.Errorf("AutoPseudoGTIDStatement must include {hint}")
+			}
+			if this.PseudoGTIDPattern == "" {
+				return fmt.Errorf("AutoPseudoGTIDStatement requires PseudoGTIDPattern, matching the injected statement")
+			}
+		}
+		if this.AutoPseudoGTIDIntervalSeconds == 0 {
+			this.AutoPseudoGTIDIntervalSeconds = PseudoGTIDIntervalSeconds
+		}
 		this.DetectPseudoGTIDQuery = SelectTrueQuery
 	}
 	if this.HTTPAdvertise != "" {
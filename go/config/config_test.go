@@ -215,3 +215,67 @@ func TestHttpAdvertise(t *testing.T) {
 		test.S(t).ExpectNotNil(err)
 	}
 }
+
+func TestAutoPseudoGTID(t *testing.T) {
+	{
+		c := newConfiguration()
+		c.AutoPseudoGTID = true
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(c.PseudoGTIDPattern, "drop view if exists `_pseudo_gtid_`")
+		test.S(t).ExpectEquals(c.AutoPseudoGTIDIntervalSeconds, uint(PseudoGTIDIntervalSeconds))
+	}
+	{
+		c := newConfiguration()
+		c.AutoPseudoGTID = true
+		c.AutoPseudoGTIDStatement = "drop view if exists `meta`.`_pseudo_gtid_hint__asc:{hint}`"
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.AutoPseudoGTID = true
+		c.AutoPseudoGTIDStatement = "drop view if exists `meta`.`_pseudo_gtid_hint__asc:{hint}`"
+		c.PseudoGTIDPattern = "drop view if exists `meta`.`_pseudo_gtid_hint__"
+		c.AutoPseudoGTIDIntervalSeconds = 1
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(c.PseudoGTIDPattern, "drop view if exists `meta`.`_pseudo_gtid_hint__")
+		test.S(t).ExpectEquals(c.AutoPseudoGTIDIntervalSeconds, uint(1))
+	}
+	{
+		c := newConfiguration()
+		c.AutoPseudoGTID = true
+		c.AutoPseudoGTIDStatement = "drop view if exists `meta`.`_pseudo_gtid_hint_`"
+		c.PseudoGTIDPattern = "drop view if exists `meta`.`_pseudo_gtid_hint_"
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestDeadMasterReplicasQuorumFraction(t *testing.T) {
+	{
+		c := newConfiguration()
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(c.DeadMasterReplicasQuorumFraction, float64(1))
+	}
+	{
+		c := newConfiguration()
+		c.DeadMasterReplicasQuorumFraction = 0.5
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.DeadMasterReplicasQuorumFraction = 0
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.DeadMasterReplicasQuorumFraction = 1.5
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+}
@@ -838,4 +838,89 @@ var generateSQLBase = []string{
 	`
 		CREATE INDEX tag_name_idx_database_instance_tags ON database_instance_tags (tag_name)
 	`,
+	`
+		CREATE TABLE IF NOT EXISTS discovery_queue_backlog (
+			hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			port smallint(5) unsigned NOT NULL,
+			reason varchar(128) CHARACTER SET utf8 NOT NULL,
+			queued_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (hostname, port)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS database_cluster_downtime (
+			cluster_name varchar(128) CHARACTER SET ascii NOT NULL,
+			begin_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			end_timestamp timestamp NOT NULL DEFAULT '1971-01-01 00:00:00',
+			owner varchar(128) CHARACTER SET utf8 NOT NULL,
+			reason text CHARACTER SET utf8 NOT NULL,
+			PRIMARY KEY (cluster_name)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS cluster_coordinates_snapshot (
+			cluster_name varchar(128) CHARACTER SET ascii NOT NULL,
+			hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			port smallint(5) unsigned NOT NULL,
+			snapshot_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			read_success tinyint unsigned NOT NULL DEFAULT 0,
+			read_error text CHARACTER SET utf8 NOT NULL,
+			binary_log_file varchar(128) CHARACTER SET ascii NOT NULL DEFAULT '',
+			binary_log_pos bigint(20) unsigned NOT NULL DEFAULT 0,
+			executed_gtid_set text CHARACTER SET ascii NOT NULL,
+			PRIMARY KEY (cluster_name, hostname, port)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS replication_sql_thread_auto_restart (
+			hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			port smallint(5) unsigned NOT NULL,
+			restart_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			last_sql_errno int unsigned NOT NULL DEFAULT 0,
+			PRIMARY KEY (hostname, port, restart_timestamp)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS master_binlog_summary (
+			hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			port smallint(5) unsigned NOT NULL,
+			collected_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			count_binlog_files int unsigned NOT NULL DEFAULT 0,
+			total_binlog_size bigint(20) unsigned NOT NULL DEFAULT 0,
+			first_binlog_file varchar(128) CHARACTER SET ascii NOT NULL DEFAULT '',
+			PRIMARY KEY (hostname, port)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS database_instance_ignore_health_checks (
+			hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			port smallint(5) unsigned NOT NULL,
+			begin_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			owner varchar(128) CHARACTER SET utf8 NOT NULL,
+			reason text CHARACTER SET utf8 NOT NULL,
+			PRIMARY KEY (hostname, port)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS cluster_recovery_disable (
+			cluster_name varchar(128) CHARACTER SET ascii NOT NULL,
+			disabled_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (cluster_name)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS graceful_master_takeover (
+			cluster_name varchar(128) CHARACTER SET ascii NOT NULL,
+			begin_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (cluster_name)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS read_only_master_first_seen (
+			hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			port smallint(5) unsigned NOT NULL,
+			first_seen_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (hostname, port)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
 }
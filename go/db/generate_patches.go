@@ -546,4 +546,238 @@ var generateSQLPatches = []string{
 			database_instance
 			ADD COLUMN region varchar(32) CHARACTER SET ascii NOT NULL AFTER data_center
 	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN semi_sync_master_status TINYINT UNSIGNED NOT NULL DEFAULT 0 AFTER semi_sync_replica_enabled
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN semi_sync_replica_status TINYINT UNSIGNED NOT NULL DEFAULT 0 AFTER semi_sync_master_status
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN semi_sync_master_clients int unsigned NOT NULL DEFAULT 0 AFTER semi_sync_replica_status
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN semi_sync_master_wait_for_replica_count int unsigned NOT NULL DEFAULT 0 AFTER semi_sync_master_clients
+	`,
+	`
+		ALTER TABLE
+			database_instance_tags
+			ADD COLUMN expire_timestamp timestamp NULL DEFAULT NULL
+	`,
+	`
+		CREATE INDEX expire_timestamp_idx_database_instance_tags ON database_instance_tags (expire_timestamp)
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN super_read_only TINYINT UNSIGNED NOT NULL DEFAULT 0 AFTER read_only
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN sql_remaining_delay INT UNSIGNED DEFAULT NULL AFTER sql_delay
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN replication_filters text CHARACTER SET utf8 NOT NULL AFTER has_replication_filters
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN last_check_queries_timed_out TINYINT UNSIGNED NOT NULL DEFAULT 0 AFTER last_check_partial_success
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN mariadb_gtid_current_pos text CHARACTER SET ascii NOT NULL AFTER mariadb_gtid
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN mariadb_gtid_slave_pos text CHARACTER SET ascii NOT NULL AFTER mariadb_gtid_current_pos
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN replication_group_name VARCHAR(64) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER semi_sync_master_wait_for_replica_count
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN replication_group_is_single_primary_mode TINYINT UNSIGNED NOT NULL DEFAULT 1 AFTER replication_group_name
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN replication_group_member_state VARCHAR(16) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER replication_group_is_single_primary_mode
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN replication_group_member_role VARCHAR(16) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER replication_group_member_state
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN replication_group_members text CHARACTER SET ascii NOT NULL AFTER replication_group_member_role
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN replication_group_primary_host varchar(128) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER replication_group_members
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN replication_group_primary_port smallint(5) unsigned NOT NULL DEFAULT 0 AFTER replication_group_primary_host
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN oldest_binary_log_file varchar(128) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER binary_log_pos
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN raw_master_host varchar(128) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER master_port
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN raw_master_port smallint(5) unsigned NOT NULL DEFAULT 0 AFTER raw_master_host
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN replication_channels text CHARACTER SET utf8 NOT NULL AFTER replication_filters
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN slave_net_timeout int unsigned NOT NULL DEFAULT 0 AFTER sql_remaining_delay
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN heartbeat_period decimal(10,3) NOT NULL DEFAULT 0 AFTER slave_net_timeout
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN received_heartbeats bigint unsigned NOT NULL DEFAULT 0 AFTER heartbeat_period
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN seconds_since_last_heartbeat int unsigned DEFAULT NULL AFTER received_heartbeats
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN flavor_name varchar(32) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER version_comment
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN last_sql_errno int unsigned NOT NULL DEFAULT 0 AFTER last_sql_error
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN event_scheduler_enabled TINYINT UNSIGNED NOT NULL DEFAULT 0 AFTER super_read_only
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN count_enabled_events int unsigned NOT NULL DEFAULT 0 AFTER event_scheduler_enabled
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN sql_mode varchar(1024) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER count_enabled_events
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN master_writes_blocked TINYINT UNSIGNED NOT NULL DEFAULT 0 AFTER sql_mode
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN slave_parallel_workers int unsigned NOT NULL DEFAULT 0 AFTER master_writes_blocked
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN has_replication_gaps TINYINT UNSIGNED NOT NULL DEFAULT 0 AFTER slave_parallel_workers
+	`,
+	`
+		CREATE INDEX cluster_name_idx_audit ON audit (cluster_name, audit_timestamp)
+	`,
+	`
+		CREATE INDEX audit_type_idx_audit ON audit (audit_type, audit_timestamp)
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN replication_ssl_cipher varchar(128) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER has_replication_gaps
+	`,
+	`
+		ALTER TABLE
+			topology_recovery_steps
+			ADD COLUMN phase varchar(64) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER message
+	`,
+	`
+		ALTER TABLE
+			topology_recovery_steps
+			ADD COLUMN hostname varchar(128) NOT NULL DEFAULT '' AFTER phase
+	`,
+	`
+		ALTER TABLE
+			topology_recovery_steps
+			ADD COLUMN port smallint(5) unsigned NOT NULL DEFAULT 0 AFTER hostname
+	`,
+	`
+		ALTER TABLE
+			topology_recovery_steps
+			ADD COLUMN outcome varchar(32) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER port
+	`,
+	`
+		ALTER TABLE
+			topology_recovery_steps
+			ADD COLUMN error_message text CHARACTER SET utf8 AFTER outcome
+	`,
+	`
+		ALTER TABLE
+			topology_recovery_steps
+			ADD COLUMN duration_millis bigint unsigned NOT NULL DEFAULT 0 AFTER error_message
+	`,
+	`
+		ALTER TABLE
+			topology_recovery
+			ADD COLUMN geographic_constraint varchar(128) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER all_errors
+	`,
+	`
+		ALTER TABLE
+			topology_recovery
+			ADD COLUMN failure_reason varchar(128) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER geographic_constraint
+	`,
+	`
+		ALTER TABLE
+			topology_recovery
+			ADD COLUMN is_simulated TINYINT UNSIGNED NOT NULL DEFAULT 0 AFTER is_successful
+	`,
+	`
+		ALTER TABLE
+			topology_recovery
+			ADD COLUMN simulation_plan text CHARACTER SET utf8 AFTER failure_reason
+	`,
 }
@@ -157,9 +157,10 @@ func aggregate(results []collection.Metric) AggregatedDiscoveryMetrics {
 		FirstSeen:                       first,
 		LastSeen:                        last,
 		CountDistinctInstanceKeys:       len(names[InstanceKeys]),
+		CountDistinctOkInstanceKeys:     len(names[OkInstanceKeys]),
 		CountDistinctFailedInstanceKeys: len(names[FailedInstanceKeys]),
 		FailedDiscoveries:               counters[FailedDiscoveries],
-		SuccessfulDiscoveries:           counters[Discoveries],
+		SuccessfulDiscoveries:           counters[Discoveries] - counters[FailedDiscoveries],
 		MeanTotalSeconds:                mean(timings[TotalSeconds]),
 		MeanBackendSeconds:              mean(timings[BackendSeconds]),
 		MeanInstanceSeconds:             mean(timings[InstanceSeconds]),
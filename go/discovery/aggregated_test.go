@@ -0,0 +1,36 @@
+package discovery
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/collection"
+	"github.com/github/orchestrator/go/inst"
+	test "github.com/openark/golib/tests"
+)
+
+func TestAggregate(t *testing.T) {
+	test.S(t).ExpectEquals(aggregate(nil), AggregatedDiscoveryMetrics{})
+
+	key1 := inst.InstanceKey{Hostname: "host1", Port: 3306}
+	key2 := inst.InstanceKey{Hostname: "host2", Port: 3306}
+	now := time.Now()
+	results := []collection.Metric{
+		&Metric{Timestamp: now, InstanceKey: key1, BackendLatency: time.Second, InstanceLatency: 3 * time.Second, TotalLatency: 4 * time.Second},
+		&Metric{Timestamp: now.Add(time.Second), InstanceKey: key1, BackendLatency: 3 * time.Second, InstanceLatency: time.Second, TotalLatency: 4 * time.Second},
+		&Metric{Timestamp: now.Add(2 * time.Second), InstanceKey: key2, InstanceLatency: 5 * time.Second, TotalLatency: 5 * time.Second, Err: errors.New("timeout")},
+	}
+	aggregated := aggregate(results)
+	test.S(t).ExpectTrue(aggregated.FirstSeen.Equal(now))
+	test.S(t).ExpectTrue(aggregated.LastSeen.Equal(now.Add(2 * time.Second)))
+	test.S(t).ExpectEquals(aggregated.CountDistinctInstanceKeys, 2)
+	test.S(t).ExpectEquals(aggregated.CountDistinctOkInstanceKeys, 1)
+	test.S(t).ExpectEquals(aggregated.CountDistinctFailedInstanceKeys, 1)
+	test.S(t).ExpectEquals(aggregated.SuccessfulDiscoveries, uint64(2))
+	test.S(t).ExpectEquals(aggregated.FailedDiscoveries, uint64(1))
+	test.S(t).ExpectEquals(aggregated.MaxBackendSeconds, 3.0)
+	test.S(t).ExpectEquals(aggregated.MaxInstanceSeconds, 5.0)
+	test.S(t).ExpectEquals(aggregated.MeanInstanceSeconds, 3.0)
+	test.S(t).ExpectEquals(aggregated.FailedMaxInstanceSeconds, 5.0)
+}
@@ -17,7 +17,10 @@
 /*
 
 package discovery manages a queue of discovery requests: an ordered
-queue with no duplicates.
+queue with no duplicates. Requests of high priority are served ahead
+of requests of normal priority. Among normal priority requests, those for
+keys the queue has never processed are served first, and may use worker
+slots reserved for them.
 
 push() operation never blocks while pop() blocks on an empty queue.
 
@@ -26,6 +29,7 @@ push() operation never blocks while pop() blocks on an empty queue.
 package discovery
 
 import (
+	"sort"
 	"sync"
 	"time"
 
@@ -34,22 +38,72 @@ import (
 	"github.com/openark/golib/log"
 )
 
+// Priority indicates how urgently a discovery request should be served.
+// Keys of higher priority are always consumed before keys of lower priority.
+type Priority int
+
+const (
+	NormalPriority Priority = iota
+	HighPriority
+)
+
 // QueueMetric contains the queue's active and queued sizes
 type QueueMetric struct {
 	Active int
 	Queued int
 }
 
+// queueEntry describes a key waiting on the queue or being processed
+type queueEntry struct {
+	priority   Priority
+	request    DiscoveryRequest
+	queuedAt   time.Time
+	consumedAt time.Time
+	forgotten  bool
+	abandoned  bool
+}
+
 // Queue contains information for managing discovery requests
 type Queue struct {
 	sync.Mutex
 
-	name         string
-	done         chan struct{}
-	queue        chan inst.InstanceKey
-	queuedKeys   map[inst.InstanceKey]time.Time
-	consumedKeys map[inst.InstanceKey]time.Time
-	metrics      []QueueMetric
+	name                string
+	done                chan struct{}
+	doneOnce            sync.Once
+	stopped             bool
+	paused              bool
+	maxConcurrency      uint
+	rateTokens          float64
+	rateRefilledAt      time.Time
+	keysAvailable       *sync.Cond
+	keysReleased        *sync.Cond
+	highPriorityQueue   []inst.InstanceKey
+	firstSeenQueue      []inst.InstanceKey
+	normalPriorityQueue []inst.InstanceKey
+	seenKeys            map[inst.InstanceKey]bool
+	queuedKeys          map[inst.InstanceKey]*queueEntry
+	consumedKeys        map[inst.InstanceKey]*queueEntry
+	abandonedCount      uint
+	backoffKeys         map[inst.InstanceKey]*BackoffEntry
+	metrics             []QueueMetric
+	latencies           []keyLatency
+	dedupedCount        uint64
+	expiredCount        uint64
+	dedupeBuckets       []*dedupeBucket
+	requeueOnCompletion bool
+	requeueDelays       map[inst.InstanceKey]time.Duration
+	requeueTimers       map[inst.InstanceKey]*time.Timer
+	maxRetries          uint
+	retryDelay          time.Duration
+	failedKeys          map[inst.InstanceKey]*FailureEntry
+	droppedCount        uint64
+	cooldownKeys        map[inst.InstanceKey]time.Time
+	cooldownsPurgedAt   time.Time
+	cooldownCount       uint64
+	subscribers         map[chan CompletionEvent]bool
+	clusterClassifier   Classifier
+	clusterPushedAt     map[string]time.Time
+	delayedKeys         map[inst.InstanceKey]*time.Timer
 }
 
 // DiscoveryQueue contains the discovery queue which can then be accessed via an API call for monitoring.
@@ -78,12 +132,7 @@ func CreateOrReturnQueue(name string) *Queue {
 		return q
 	}
 
-	q := &Queue{
-		name:         name,
-		queuedKeys:   make(map[inst.InstanceKey]time.Time),
-		consumedKeys: make(map[inst.InstanceKey]time.Time),
-		queue:        make(chan inst.InstanceKey, config.Config.DiscoveryQueueCapacity),
-	}
+	q := newQueue(name)
 	go q.startMonitoring()
 
 	discoveryQueue[name] = q
@@ -91,6 +140,45 @@ func CreateOrReturnQueue(name string) *Queue {
 	return q
 }
 
+// ReturnQueue returns an existing queue given the name, or nil if no such queue exists
+func ReturnQueue(name string) *Queue {
+	dcLock.Lock()
+	defer dcLock.Unlock()
+
+	return discoveryQueue[name]
+}
+
+// NewQueue creates a queue which is neither registered nor monitored, and is owned by its caller
+func NewQueue(name string) *Queue {
+	return newQueue(name)
+}
+
+// newQueue creates an unregistered, unmonitored queue
+func newQueue(name string) *Queue {
+	q := &Queue{
+		name:                name,
+		done:                make(chan struct{}),
+		highPriorityQueue:   []inst.InstanceKey{},
+		firstSeenQueue:      []inst.InstanceKey{},
+		normalPriorityQueue: make([]inst.InstanceKey, 0, config.Config.DiscoveryQueueCapacity),
+		queuedKeys:          make(map[inst.InstanceKey]*queueEntry),
+		seenKeys:            make(map[inst.InstanceKey]bool),
+		consumedKeys:        make(map[inst.InstanceKey]*queueEntry),
+		backoffKeys:         make(map[inst.InstanceKey]*BackoffEntry),
+		requeueDelays:       make(map[inst.InstanceKey]time.Duration),
+		requeueTimers:       make(map[inst.InstanceKey]*time.Timer),
+		failedKeys:          make(map[inst.InstanceKey]*FailureEntry),
+		cooldownKeys:        make(map[inst.InstanceKey]time.Time),
+		subscribers:         make(map[chan CompletionEvent]bool),
+		clusterPushedAt:     make(map[string]time.Time),
+		delayedKeys:         make(map[inst.InstanceKey]*time.Timer),
+	}
+	q.keysAvailable = sync.NewCond(q)
+	q.keysReleased = sync.NewCond(q)
+
+	return q
+}
+
 // monitoring queue sizes until we are told to stop
 func (q *Queue) startMonitoring() {
 	log.Debugf("Queue.startMonitoring(%s)", q.name)
@@ -108,7 +196,36 @@ func (q *Queue) startMonitoring() {
 
 // Stop monitoring the queue
 func (q *Queue) stopMonitoring() {
-	q.done <- struct{}{}
+	q.doneOnce.Do(func() { close(q.done) })
+}
+
+// Stop shuts down the queue: no further keys are accepted, and Consume
+// returns false once there is nothing left to consume. With wait=true, keys
+// already queued are still consumed, and Stop returns once they have all
+// been released. With wait=false, queued keys are abandoned and Stop returns
+// once the keys being processed have been released.
+func (q *Queue) Stop(wait bool) {
+	q.stopMonitoring()
+
+	q.Lock()
+	defer q.Unlock()
+
+	q.stopped = true
+	q.cancelRequeues()
+	if !wait {
+		q.highPriorityQueue = q.highPriorityQueue[:0]
+		q.firstSeenQueue = q.firstSeenQueue[:0]
+		q.normalPriorityQueue = q.normalPriorityQueue[:0]
+		q.queuedKeys = make(map[inst.InstanceKey]*queueEntry)
+		for key := range q.delayedKeys {
+			q.undelayKey(key)
+		}
+	}
+	q.keysAvailable.Broadcast()
+
+	for len(q.queuedKeys) > 0 || len(q.consumedKeys) > 0 {
+		q.keysReleased.Wait()
+	}
 }
 
 // do a check of the entries in the queue, both those active and queued
@@ -124,64 +241,418 @@ func (q *Queue) collectStatistics() {
 	}
 }
 
-// QueueLen returns the length of the queue (channel size + queued size)
+// QueuedKey describes a key waiting on the queue or being processed
+type QueuedKey struct {
+	Key        inst.InstanceKey
+	Reason     string
+	Priority   Priority
+	QueuedAt   time.Time
+	ConsumedAt time.Time `json:",omitempty"`
+}
+
+// QueueSnapshot lists the keys waiting on the queue, in order of consumption,
+// and the keys being processed
+type QueueSnapshot struct {
+	Queued []QueuedKey
+	Active []QueuedKey
+}
+
+// Snapshot returns the keys currently waiting on the queue and the keys currently being processed
+func (q *Queue) Snapshot() *QueueSnapshot {
+	q.Lock()
+	defer q.Unlock()
+
+	snapshot := &QueueSnapshot{Queued: []QueuedKey{}, Active: []QueuedKey{}}
+	for _, keys := range [][]inst.InstanceKey{q.highPriorityQueue, q.firstSeenQueue, q.normalPriorityQueue} {
+		for _, key := range keys {
+			entry := q.queuedKeys[key]
+			snapshot.Queued = append(snapshot.Queued, QueuedKey{Key: key, Reason: entry.request.Reason, Priority: entry.priority, QueuedAt: entry.queuedAt})
+		}
+	}
+	for key := range q.delayedKeys {
+		entry := q.queuedKeys[key]
+		snapshot.Queued = append(snapshot.Queued, QueuedKey{Key: key, Reason: entry.request.Reason, Priority: entry.priority, QueuedAt: entry.queuedAt})
+	}
+	for key, entry := range q.consumedKeys {
+		snapshot.Active = append(snapshot.Active, QueuedKey{Key: key, Reason: entry.request.Reason, Priority: entry.priority, QueuedAt: entry.queuedAt, ConsumedAt: entry.consumedAt})
+	}
+	sort.Slice(snapshot.Active, func(i, j int) bool { return snapshot.Active[i].ConsumedAt.Before(snapshot.Active[j].ConsumedAt) })
+	return snapshot
+}
+
+// QueueLen returns the number of keys waiting on the queue
 func (q *Queue) QueueLen() int {
 	q.Lock()
 	defer q.Unlock()
 
-	return len(q.queue) + len(q.queuedKeys)
+	return len(q.queuedKeys)
+}
+
+// ActiveWorkers returns the number of keys consumed and not yet released,
+// i.e. the number of discoveries currently in progress. Abandoned keys are not counted.
+func (q *Queue) ActiveWorkers() int {
+	q.Lock()
+	defer q.Unlock()
+
+	return len(q.consumedKeys) - int(q.abandonedCount)
 }
 
-// Push enqueues a key if it is not on a queue and is not being
-// processed; silently returns otherwise.
+// Push enqueues a key with normal priority if it is not on a queue and
+// is not being processed; silently returns otherwise.
 func (q *Queue) Push(key inst.InstanceKey) {
+	q.PushWithPriority(key, NormalPriority)
+}
+
+// PushWithPriority enqueues a key with given priority if it is not on a queue
+// and is not being processed. A key already queued with a lower priority is
+// bumped to the given priority. Silently returns otherwise, or if the key's
+// discovery is being backed off, or if the key was successfully discovered
+// within DiscoverySuccessCooldownSeconds, or if the queue is stopped.
+func (q *Queue) PushWithPriority(key inst.InstanceKey, priority Priority) {
+	q.PushRequest(NewDiscoveryRequest(key, ""), priority)
+}
+
+// PushRequest enqueues a discovery request with given priority, as PushWithPriority
+// does. A request bumping the priority of a queued key replaces the queued request.
+func (q *Queue) PushRequest(request DiscoveryRequest, priority Priority) {
+	request = q.classifyCluster(request)
+
 	q.Lock()
 	defer q.Unlock()
 
-	// is it enqueued already?
-	if _, found := q.queuedKeys[key]; found {
+	q.push(request, priority)
+}
+
+// PushBatch enqueues given keys with normal priority, as Push does, taking the
+// queue's lock once
+func (q *Queue) PushBatch(keys []inst.InstanceKey) {
+	requests := make([]DiscoveryRequest, 0, len(keys))
+	for _, key := range keys {
+		requests = append(requests, NewDiscoveryRequest(key, ""))
+	}
+	q.PushRequests(requests, NormalPriority)
+}
+
+// PushRequests enqueues given discovery requests with given priority, as
+// PushRequest does, taking the queue's lock once
+func (q *Queue) PushRequests(requests []DiscoveryRequest, priority Priority) {
+	// classify a copy, leaving the caller's requests intact
+	classifiedRequests := make([]DiscoveryRequest, 0, len(requests))
+	for _, request := range requests {
+		classifiedRequests = append(classifiedRequests, q.classifyCluster(request))
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	for _, request := range classifiedRequests {
+		q.push(request, priority)
+	}
+}
+
+// push implements PushRequest. Assumes the queue is locked.
+func (q *Queue) push(request DiscoveryRequest, priority Priority) {
+	key := request.Key
+	if q.stopped {
+		return
+	}
+	if !request.Forced && q.isBackedOff(key) {
+		return
+	}
+	if !request.Forced && q.isInCooldown(key) {
+		q.cooldownCount++
 		return
 	}
 
 	// is it being processed now?
 	if _, found := q.consumedKeys[key]; found {
+		q.recordDedupe(key)
+		return
+	}
+
+	// is it enqueued already?
+	if entry, found := q.queuedKeys[key]; found {
+		if priority <= entry.priority {
+			q.recordDedupe(key)
+			return
+		}
+		// bump the key
+		q.undelayKey(key)
+		q.firstSeenQueue = removeKey(q.firstSeenQueue, key)
+		q.normalPriorityQueue = removeKey(q.normalPriorityQueue, key)
+		q.highPriorityQueue = append(q.highPriorityQueue, key)
+		entry.priority = priority
+		entry.request = request
+		return
+	}
+
+	q.queuedKeys[key] = &queueEntry{priority: priority, request: request, queuedAt: time.Now()}
+	if delay := q.jitterDelay(request, priority); delay > 0 {
+		q.delayKey(key, delay)
 		return
 	}
+	q.enqueue(key, priority)
+}
 
-	q.queuedKeys[key] = time.Now()
-	q.queue <- key
+// enqueue appends a queued key to its lane. Assumes the queue is locked.
+func (q *Queue) enqueue(key inst.InstanceKey, priority Priority) {
+	if priority == HighPriority {
+		q.highPriorityQueue = append(q.highPriorityQueue, key)
+	} else if !q.seenKeys[key] {
+		q.firstSeenQueue = append(q.firstSeenQueue, key)
+	} else {
+		q.normalPriorityQueue = append(q.normalPriorityQueue, key)
+	}
+	q.keysAvailable.Signal()
 }
 
-// Consume fetches a key to process; blocks if queue is empty.
+// Consume fetches a key to process; blocks if queue is empty, or if
+// the queue's max concurrency is reached, or if DiscoveryMaxPerSecond
+// keys have been consumed in the last second.
+// High priority keys are fetched before normal priority keys, and keys
+// never processed before are fetched before other normal priority keys.
+// Keys which have waited on the queue for longer than DiscoveryMaxQueueAgeSeconds
+// are dropped.
 // Release must be called once after Consume.
-func (q *Queue) Consume() inst.InstanceKey {
-	q.Lock()
-	queue := q.queue
-	q.Unlock()
-
-	key := <-queue
+// Consume returns false when the queue is stopped and there are
+// no more keys to consume.
+func (q *Queue) Consume() (inst.InstanceKey, bool) {
+	request, ok := q.ConsumeRequest()
+	return request.Key, ok
+}
 
+// ConsumeRequest fetches a discovery request to process, as Consume does.
+func (q *Queue) ConsumeRequest() (DiscoveryRequest, bool) {
 	q.Lock()
 	defer q.Unlock()
 
+	for {
+		for !q.canConsume() {
+			if q.stopped && len(q.queuedKeys) == 0 {
+				return DiscoveryRequest{}, false
+			}
+			q.keysAvailable.Wait()
+		}
+		if q.dropExpiredKeys() {
+			continue
+		}
+		delay := q.reserveRateToken()
+		if delay == 0 {
+			break
+		}
+		q.Unlock()
+		time.Sleep(delay)
+		q.Lock()
+	}
+
+	lane := q.nextLane()
+	key := (*lane)[0]
+	*lane = (*lane)[1:]
+	entry := q.queuedKeys[key]
+	entry.consumedAt = time.Now()
+
 	// alarm if have been waiting for too long
-	timeOnQueue := time.Since(q.queuedKeys[key])
+	timeOnQueue := entry.consumedAt.Sub(entry.queuedAt)
 	if timeOnQueue > time.Duration(config.Config.InstancePollSeconds)*time.Second {
 		log.Warningf("key %v spent %.4fs waiting on a discoveryQueue", key, timeOnQueue.Seconds())
 	}
 
-	q.consumedKeys[key] = q.queuedKeys[key]
+	q.consumedKeys[key] = entry
 
 	delete(q.queuedKeys, key)
 
-	return key
+	return entry.request, true
 }
 
 // Release removes a key from a list of being processed keys
 // which allows that key to be pushed into the queue again.
+// With requeue on completion enabled, the key is scheduled to be pushed
+// again unless it has been forgotten.
 func (q *Queue) Release(key inst.InstanceKey) {
 	q.Lock()
 	defer q.Unlock()
 
+	q.release(key, nil)
+}
+
+// Abandon marks a consumed key as abandoned by its worker, e.g. when its processing timed out yet
+// cannot be interrupted. An abandoned key no longer counts towards the max concurrency, but is still
+// considered as being processed, and is not consumed again, until it is released.
+func (q *Queue) Abandon(key inst.InstanceKey) {
+	q.Lock()
+	defer q.Unlock()
+
+	if entry, found := q.consumedKeys[key]; found && !entry.abandoned {
+		entry.abandoned = true
+		q.abandonedCount++
+		q.keysAvailable.Signal()
+	}
+}
+
+// release implements Release, notifying subscribers of the completion of
+// given key with given result. Assumes the queue is locked.
+func (q *Queue) release(key inst.InstanceKey, err error) {
+	if entry, found := q.consumedKeys[key]; found {
+		if entry.abandoned {
+			q.abandonedCount--
+		}
+		q.seenKeys[key] = true
+		q.recordLatency(entry)
+		q.notifyCompletion(CompletionEvent{Key: key, StartedAt: entry.consumedAt, FinishedAt: time.Now(), Err: err})
+		if q.requeueOnCompletion && !entry.forgotten && !q.stopped {
+			q.scheduleRequeue(key, q.requeueDelay(key), RequeueReason)
+		}
+	}
 	delete(q.consumedKeys, key)
+	q.keysReleased.Broadcast()
+	q.keysAvailable.Signal()
+}
+
+// dropExpiredKeys removes keys which have waited on the queue for longer than
+// DiscoveryMaxQueueAgeSeconds from the head of the queue, up to the next key to
+// be consumed. Returns true when any key was dropped. Assumes the queue is locked.
+func (q *Queue) dropExpiredKeys() (dropped bool) {
+	maxQueueAge := time.Duration(config.Config.DiscoveryMaxQueueAgeSeconds) * time.Second
+	if maxQueueAge == 0 {
+		return false
+	}
+	for lane := q.nextLane(); lane != nil && time.Since(q.queuedKeys[(*lane)[0]].queuedAt) > maxQueueAge; lane = q.nextLane() {
+		key := (*lane)[0]
+		*lane = (*lane)[1:]
+		log.Debugf("dropping key %v which has been waiting on discoveryQueue %s for over %ds", key, q.name, config.Config.DiscoveryMaxQueueAgeSeconds)
+		delete(q.queuedKeys, key)
+		q.expiredCount++
+		dropped = true
+	}
+	if dropped {
+		q.keysReleased.Broadcast()
+	}
+	return dropped
+}
+
+// ExpiredCount returns the number of keys dropped for having waited on the queue
+// for longer than DiscoveryMaxQueueAgeSeconds
+func (q *Queue) ExpiredCount() uint64 {
+	q.Lock()
+	defer q.Unlock()
+
+	return q.expiredCount
+}
+
+// canConsume checks whether there are keys to consume without exceeding
+// the max concurrency, and the queue is not paused. A stopped queue is
+// consumed even if paused. Assumes the queue is locked.
+func (q *Queue) canConsume() bool {
+	if q.paused && !q.stopped {
+		return false
+	}
+	return q.nextLane() != nil
+}
+
+// nextLane returns the lane from which the next key is to be consumed, or nil
+// when there is no key that may be consumed without exceeding the max concurrency.
+// Keys already processed in the past, of normal priority, may not use the last
+// DiscoveryFirstSeenReservedConcurrency worker slots, which are kept for high
+// priority keys and keys never processed before. Assumes the queue is locked.
+func (q *Queue) nextLane() *[]inst.InstanceKey {
+	consumed := uint(len(q.consumedKeys)) - q.abandonedCount
+	if q.maxConcurrency > 0 && consumed >= q.maxConcurrency {
+		return nil
+	}
+	if len(q.highPriorityQueue) > 0 {
+		return &q.highPriorityQueue
+	}
+	if len(q.firstSeenQueue) > 0 {
+		return &q.firstSeenQueue
+	}
+	if len(q.normalPriorityQueue) > 0 {
+		reserved := config.Config.DiscoveryFirstSeenReservedConcurrency
+		if q.maxConcurrency > reserved && consumed >= q.maxConcurrency-reserved {
+			return nil
+		}
+		return &q.normalPriorityQueue
+	}
+	return nil
+}
+
+// reserveRateToken takes a token off the queue's token bucket, which is refilled
+// at DiscoveryMaxPerSecond tokens per second. Returns 0 when a token was taken, or
+// else the time until a token is available. Assumes the queue is locked.
+func (q *Queue) reserveRateToken() time.Duration {
+	maxPerSecond := float64(config.Config.DiscoveryMaxPerSecond)
+	if maxPerSecond == 0 {
+		return 0
+	}
+	now := time.Now()
+	if q.rateRefilledAt.IsZero() {
+		q.rateTokens = maxPerSecond
+	} else {
+		q.rateTokens += now.Sub(q.rateRefilledAt).Seconds() * maxPerSecond
+	}
+	if q.rateTokens > maxPerSecond {
+		q.rateTokens = maxPerSecond
+	}
+	q.rateRefilledAt = now
+	if q.rateTokens >= 1 {
+		q.rateTokens--
+		return 0
+	}
+	return time.Duration((1 - q.rateTokens) / maxPerSecond * float64(time.Second))
+}
+
+// Pause stops keys from being consumed. Keys are still pushed onto the queue
+// while it is paused, and keys already being processed are unaffected.
+func (q *Queue) Pause() {
+	q.Lock()
+	defer q.Unlock()
+
+	q.paused = true
+}
+
+// Resume lets keys be consumed again after Pause
+func (q *Queue) Resume() {
+	q.Lock()
+	defer q.Unlock()
+
+	q.paused = false
+	q.keysAvailable.Broadcast()
+}
+
+// Paused returns true when the queue is paused
+func (q *Queue) Paused() bool {
+	q.Lock()
+	defer q.Unlock()
+
+	return q.paused
+}
+
+// MaxConcurrency returns the max number of keys which may be processed concurrently;
+// 0 for unlimited
+func (q *Queue) MaxConcurrency() uint {
+	q.Lock()
+	defer q.Unlock()
+
+	return q.maxConcurrency
+}
+
+// SetMaxConcurrency limits the number of keys which may be processed concurrently;
+// 0 for unlimited. Lowering the limit does not affect keys already being processed,
+// but prevents further keys from being consumed until the number of keys being
+// processed drops below the new limit.
+func (q *Queue) SetMaxConcurrency(maxConcurrency uint) {
+	q.Lock()
+	defer q.Unlock()
+
+	q.maxConcurrency = maxConcurrency
+	q.keysAvailable.Broadcast()
+}
+
+// removeKey returns given keys list without given key
+func removeKey(keys []inst.InstanceKey, key inst.InstanceKey) []inst.InstanceKey {
+	for i := range keys {
+		if keys[i] == key {
+			return append(keys[:i], keys[i+1:]...)
+		}
+	}
+	return keys
 }
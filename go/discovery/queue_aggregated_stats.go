@@ -17,6 +17,9 @@
 package discovery
 
 import (
+	"time"
+
+	"github.com/github/orchestrator/go/config"
 	"github.com/montanaflynn/stats"
 
 	"github.com/openark/golib/log"
@@ -36,6 +39,67 @@ type AggregatedQueueMetrics struct {
 	QueuedMaxEntries    float64
 }
 
+// QueueStats contains aggregated latencies of keys released from the queue
+// over the last DiscoveryCollectionRetentionSeconds: the time keys spent
+// waiting on the queue, and the time taken to process them.
+type QueueStats struct {
+	Count                   int
+	MedianWaitSeconds       float64
+	P95WaitSeconds          float64
+	MaxWaitSeconds          float64
+	MedianProcessingSeconds float64
+	P95ProcessingSeconds    float64
+	MaxProcessingSeconds    float64
+}
+
+// keyLatency is the wait and processing time of a single released key
+type keyLatency struct {
+	releasedAt time.Time
+	wait       time.Duration
+	processing time.Duration
+}
+
+// recordLatency records the latencies of a key being released and removes
+// entries which are too old. Assumes the queue is locked.
+func (q *Queue) recordLatency(entry *queueEntry) {
+	now := time.Now()
+	q.latencies = append(q.latencies, keyLatency{
+		releasedAt: now,
+		wait:       entry.consumedAt.Sub(entry.queuedAt),
+		processing: now.Sub(entry.consumedAt),
+	})
+	retention := time.Duration(config.Config.DiscoveryCollectionRetentionSeconds) * time.Second
+	expired := 0
+	for expired < len(q.latencies) && now.Sub(q.latencies[expired].releasedAt) > retention {
+		expired++
+	}
+	q.latencies = q.latencies[expired:]
+}
+
+// Stats returns aggregated wait and processing latencies of recently released keys
+func (q *Queue) Stats() *QueueStats {
+	q.Lock()
+	defer q.Unlock()
+
+	if len(q.latencies) == 0 {
+		return &QueueStats{}
+	}
+	var waits, processings stats.Float64Data
+	for _, latency := range q.latencies {
+		waits = append(waits, latency.wait.Seconds())
+		processings = append(processings, latency.processing.Seconds())
+	}
+	return &QueueStats{
+		Count:                   len(q.latencies),
+		MedianWaitSeconds:       median(waits),
+		P95WaitSeconds:          percentile(waits, 95),
+		MaxWaitSeconds:          max(waits),
+		MedianProcessingSeconds: median(processings),
+		P95ProcessingSeconds:    percentile(processings, 95),
+		MaxProcessingSeconds:    max(processings),
+	}
+}
+
 // we pull out values in ints so convert to float64 for metric calculations
 func intSliceToFloat64Slice(someInts []int) stats.Float64Data {
 	var slice stats.Float64Data
@@ -0,0 +1,105 @@
+/*
+   Copyright 2017 Shlomi Noach, GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package discovery
+
+import (
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/openark/golib/log"
+)
+
+const (
+	backoffFailuresThreshold = 3
+	backoffInitialDuration   = time.Minute
+	backoffFactor            = 5
+)
+
+// BackoffEntry describes a key whose discovery keeps failing
+type BackoffEntry struct {
+	Key                 inst.InstanceKey
+	ConsecutiveFailures int
+	BackoffUntil        time.Time
+}
+
+// backoffDuration returns the time to back off discovery of a key given its number
+// of consecutive failures: 1m, 5m, 25m, ... capped at config.Config.DiscoveryBackoffMaxSeconds
+func backoffDuration(consecutiveFailures int) time.Duration {
+	maxDuration := time.Duration(config.Config.DiscoveryBackoffMaxSeconds) * time.Second
+	if maxDuration == 0 || consecutiveFailures < backoffFailuresThreshold {
+		return 0
+	}
+	duration := backoffInitialDuration
+	for i := backoffFailuresThreshold; i < consecutiveFailures && duration < maxDuration; i++ {
+		duration = duration * backoffFactor
+	}
+	if duration > maxDuration {
+		duration = maxDuration
+	}
+	return duration
+}
+
+// isBackedOff checks whether discovery requests for given key are to be ignored.
+// Assumes the queue is locked.
+func (q *Queue) isBackedOff(key inst.InstanceKey) bool {
+	entry, found := q.backoffKeys[key]
+	if !found {
+		return false
+	}
+	return time.Now().Before(entry.BackoffUntil)
+}
+
+// RecordResult tracks consecutive discovery failures of a key and backs off
+// further discovery requests of the key as needed. A successful discovery
+// resets the backoff and starts the key's cooldown. It is only called for
+// discoveries actually attempted, such that a skipped discovery (e.g. of an
+// up to date instance) does not start a cooldown.
+func (q *Queue) RecordResult(key inst.InstanceKey, err error) {
+	q.Lock()
+	defer q.Unlock()
+
+	if err == nil {
+		delete(q.backoffKeys, key)
+		q.startCooldown(key)
+		return
+	}
+	entry, found := q.backoffKeys[key]
+	if !found {
+		entry = &BackoffEntry{Key: key}
+		q.backoffKeys[key] = entry
+	}
+	entry.ConsecutiveFailures++
+	if duration := backoffDuration(entry.ConsecutiveFailures); duration > 0 {
+		entry.BackoffUntil = time.Now().Add(duration)
+		log.Warningf("discovery of %+v failed %d consecutive times; backing off for %+v", key, entry.ConsecutiveFailures, duration)
+	}
+}
+
+// BackoffKeys returns the keys whose discovery is currently being backed off
+func (q *Queue) BackoffKeys() []BackoffEntry {
+	q.Lock()
+	defer q.Unlock()
+
+	entries := []BackoffEntry{}
+	for key, entry := range q.backoffKeys {
+		if q.isBackedOff(key) {
+			entries = append(entries, *entry)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,82 @@
+/*
+   Copyright 2017 Shlomi Noach, GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package discovery
+
+import (
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+)
+
+// cooldownDuration returns the time during which requests for a successfully
+// processed key are dropped
+func cooldownDuration() time.Duration {
+	return time.Duration(config.Config.DiscoverySuccessCooldownSeconds) * time.Second
+}
+
+// startCooldown drops further requests for given key, just successfully
+// discovered, for the cooldown duration.
+// Expired cooldowns are removed at most once per cooldown duration.
+// Assumes the queue is locked.
+func (q *Queue) startCooldown(key inst.InstanceKey) {
+	duration := cooldownDuration()
+	if duration == 0 {
+		return
+	}
+	now := time.Now()
+	if now.Sub(q.cooldownsPurgedAt) > duration {
+		for cooldownKey, until := range q.cooldownKeys {
+			if now.After(until) {
+				delete(q.cooldownKeys, cooldownKey)
+			}
+		}
+		q.cooldownsPurgedAt = now
+	}
+	q.cooldownKeys[key] = now.Add(duration)
+}
+
+// isInCooldown checks whether given key was successfully processed within the
+// cooldown duration. Assumes the queue is locked.
+func (q *Queue) isInCooldown(key inst.InstanceKey) bool {
+	until, found := q.cooldownKeys[key]
+	if !found {
+		return false
+	}
+	if time.Now().After(until) {
+		delete(q.cooldownKeys, key)
+		return false
+	}
+	return true
+}
+
+// InCooldown returns true when given key was successfully processed within the
+// last DiscoverySuccessCooldownSeconds, hence requests for it are dropped unless forced
+func (q *Queue) InCooldown(key inst.InstanceKey) bool {
+	q.Lock()
+	defer q.Unlock()
+
+	return q.isInCooldown(key)
+}
+
+// CooldownCount returns the number of requests dropped for keys in cooldown
+func (q *Queue) CooldownCount() uint64 {
+	q.Lock()
+	defer q.Unlock()
+
+	return q.cooldownCount
+}
@@ -0,0 +1,96 @@
+/*
+   Copyright 2017 Shlomi Noach, GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package discovery
+
+import (
+	"sort"
+	"time"
+
+	"github.com/github/orchestrator/go/inst"
+)
+
+const (
+	dedupeBucketDuration = time.Minute
+	dedupeBucketsCount   = 10
+	dedupeTopKeysCount   = 10
+)
+
+// DedupedKey is a key along with the number of times its discovery request was deduplicated
+type DedupedKey struct {
+	Key   inst.InstanceKey
+	Count int
+}
+
+// DedupeStats describes the discovery requests ignored by the queue because the key
+// was already queued or being processed: the total number of such requests, and the
+// most frequently deduplicated keys over the last WindowSeconds.
+type DedupeStats struct {
+	Deduplicated  uint64
+	WindowSeconds int
+	TopKeys       []DedupedKey
+}
+
+// dedupeBucket counts deduplicated requests per key over a fixed period of time
+type dedupeBucket struct {
+	startedAt time.Time
+	counts    map[inst.InstanceKey]int
+}
+
+// recordDedupe counts a deduplicated request for given key. Assumes the queue is locked.
+func (q *Queue) recordDedupe(key inst.InstanceKey) {
+	q.dedupedCount++
+
+	now := time.Now()
+	if len(q.dedupeBuckets) == 0 || now.Sub(q.dedupeBuckets[len(q.dedupeBuckets)-1].startedAt) >= dedupeBucketDuration {
+		q.dedupeBuckets = append(q.dedupeBuckets, &dedupeBucket{startedAt: now, counts: make(map[inst.InstanceKey]int)})
+		if len(q.dedupeBuckets) > dedupeBucketsCount {
+			q.dedupeBuckets = q.dedupeBuckets[len(q.dedupeBuckets)-dedupeBucketsCount:]
+		}
+	}
+	q.dedupeBuckets[len(q.dedupeBuckets)-1].counts[key]++
+}
+
+// DedupeStats returns the number of deduplicated discovery requests, and the keys
+// most frequently deduplicated recently.
+func (q *Queue) DedupeStats() *DedupeStats {
+	q.Lock()
+	defer q.Unlock()
+
+	window := time.Duration(dedupeBucketsCount) * dedupeBucketDuration
+	counts := make(map[inst.InstanceKey]int)
+	for _, bucket := range q.dedupeBuckets {
+		if time.Since(bucket.startedAt) > window {
+			continue
+		}
+		for key, count := range bucket.counts {
+			counts[key] += count
+		}
+	}
+	topKeys := []DedupedKey{}
+	for key, count := range counts {
+		topKeys = append(topKeys, DedupedKey{Key: key, Count: count})
+	}
+	sort.Slice(topKeys, func(i, j int) bool { return topKeys[i].Count > topKeys[j].Count })
+	if len(topKeys) > dedupeTopKeysCount {
+		topKeys = topKeys[:dedupeTopKeysCount]
+	}
+	return &DedupeStats{
+		Deduplicated:  q.dedupedCount,
+		WindowSeconds: int(window.Seconds()),
+		TopKeys:       topKeys,
+	}
+}
@@ -0,0 +1,83 @@
+/*
+   Copyright 2017 Shlomi Noach, GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package discovery
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/inst"
+)
+
+// CompletionEvent describes the processing of a key, emitted once the key is released
+type CompletionEvent struct {
+	Key        inst.InstanceKey
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Err        error
+}
+
+// Subscribe returns a channel on which a CompletionEvent is sent whenever a key
+// is released. Events are dropped when the channel's buffer is full, such that a
+// slow subscriber never blocks the queue. Unsubscribe must be called once done.
+func (q *Queue) Subscribe(bufferSize int) chan CompletionEvent {
+	q.Lock()
+	defer q.Unlock()
+
+	events := make(chan CompletionEvent, bufferSize)
+	q.subscribers[events] = true
+	return events
+}
+
+// Unsubscribe stops sending events on given channel, which was returned by Subscribe
+func (q *Queue) Unsubscribe(events chan CompletionEvent) {
+	q.Lock()
+	defer q.Unlock()
+
+	delete(q.subscribers, events)
+}
+
+// notifyCompletion sends given event to all subscribers, without blocking.
+// Assumes the queue is locked.
+func (q *Queue) notifyCompletion(event CompletionEvent) {
+	for events := range q.subscribers {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// WaitForDiscovery blocks until given key is next released, or until timeout passes.
+// Returns the key's processing error, if any.
+func (q *Queue) WaitForDiscovery(key inst.InstanceKey, timeout time.Duration) error {
+	events := q.Subscribe(1000)
+	defer q.Unsubscribe(events)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case event := <-events:
+			if event.Key == key {
+				return event.Err
+			}
+		case <-timer.C:
+			return fmt.Errorf("timed out waiting %+v for discovery of %+v", timeout, key)
+		}
+	}
+}
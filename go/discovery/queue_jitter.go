@@ -0,0 +1,97 @@
+/*
+   Copyright 2017 Shlomi Noach, GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package discovery
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+)
+
+// SetClusterClassifier has the queue spread the consumption of normal priority keys
+// of a same cluster, pushed within DiscoveryClusterJitterSeconds of each other,
+// over DiscoveryClusterJitterSeconds. The first such key is consumed right away,
+// others are held aside for a random delay, during which they take no worker slot.
+// The classifier returns the cluster name of a key; it is not called while the
+// queue is locked, and so may be slow.
+func (q *Queue) SetClusterClassifier(classifier Classifier) {
+	q.Lock()
+	defer q.Unlock()
+
+	q.clusterClassifier = classifier
+}
+
+// classifyCluster sets the cluster of given request, when a cluster classifier
+// is configured. Assumes the queue is not locked.
+func (q *Queue) classifyCluster(request DiscoveryRequest) DiscoveryRequest {
+	q.Lock()
+	classifier := q.clusterClassifier
+	q.Unlock()
+
+	if classifier != nil && config.Config.DiscoveryClusterJitterSeconds > 0 {
+		request.clusterName = classifier(request.Key)
+	}
+	return request
+}
+
+// jitterDelay returns the time by which to delay consumption of given request.
+// Assumes the queue is locked.
+func (q *Queue) jitterDelay(request DiscoveryRequest, priority Priority) time.Duration {
+	window := time.Duration(config.Config.DiscoveryClusterJitterSeconds) * time.Second
+	if window == 0 || request.clusterName == "" || priority != NormalPriority {
+		return 0
+	}
+	now := time.Now()
+	if pushedAt, found := q.clusterPushedAt[request.clusterName]; !found || now.Sub(pushedAt) >= window {
+		q.clusterPushedAt[request.clusterName] = now
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(window)))
+}
+
+// delayKey holds given queued key aside, and places it in its lane once given
+// delay has passed. Assumes the queue is locked.
+func (q *Queue) delayKey(key inst.InstanceKey, delay time.Duration) {
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
+		q.Lock()
+		defer q.Unlock()
+
+		if q.delayedKeys[key] != timer {
+			// bumped, forgotten or stopped meanwhile
+			return
+		}
+		delete(q.delayedKeys, key)
+		if entry, found := q.queuedKeys[key]; found {
+			q.enqueue(key, entry.priority)
+		}
+	})
+	q.delayedKeys[key] = timer
+}
+
+// undelayKey cancels the delay of given key, if delayed, returning true if it was.
+// Assumes the queue is locked.
+func (q *Queue) undelayKey(key inst.InstanceKey) bool {
+	timer, found := q.delayedKeys[key]
+	if found {
+		timer.Stop()
+		delete(q.delayedKeys, key)
+	}
+	return found
+}
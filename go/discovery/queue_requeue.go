@@ -0,0 +1,112 @@
+/*
+   Copyright 2017 Shlomi Noach, GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package discovery
+
+import (
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+)
+
+// EnableRequeueOnCompletion turns the queue into a poller: once a key is released,
+// it is automatically pushed again after its requeue delay, which defaults to
+// config.Config.InstancePollSeconds. Use Forget to take a key out of the cycle.
+func (q *Queue) EnableRequeueOnCompletion() {
+	q.Lock()
+	defer q.Unlock()
+
+	q.requeueOnCompletion = true
+}
+
+// SetRequeueDelay overrides the delay after which given key is requeued upon
+// completion. A zero delay reverts to the default.
+func (q *Queue) SetRequeueDelay(key inst.InstanceKey, delay time.Duration) {
+	q.Lock()
+	defer q.Unlock()
+
+	if delay == 0 {
+		delete(q.requeueDelays, key)
+		return
+	}
+	q.requeueDelays[key] = delay
+}
+
+// Forget takes given key out of the requeue cycle: a pending requeue is cancelled,
+// the key is removed from the queue, and if the key is being processed it
+// will not be requeued once released. The key may be pushed again later on,
+// in which case it is considered as never processed before.
+func (q *Queue) Forget(key inst.InstanceKey) {
+	q.Lock()
+	defer q.Unlock()
+
+	if timer, found := q.requeueTimers[key]; found {
+		timer.Stop()
+		delete(q.requeueTimers, key)
+	}
+	delete(q.requeueDelays, key)
+	delete(q.seenKeys, key)
+	q.undelayKey(key)
+	if entry, found := q.consumedKeys[key]; found {
+		entry.forgotten = true
+	}
+	if _, found := q.queuedKeys[key]; found {
+		q.highPriorityQueue = removeKey(q.highPriorityQueue, key)
+		q.firstSeenQueue = removeKey(q.firstSeenQueue, key)
+		q.normalPriorityQueue = removeKey(q.normalPriorityQueue, key)
+		delete(q.queuedKeys, key)
+		q.keysReleased.Broadcast()
+	}
+}
+
+// requeueDelay returns the delay after which given key is requeued upon completion.
+// Assumes the queue is locked.
+func (q *Queue) requeueDelay(key inst.InstanceKey) time.Duration {
+	if delay, found := q.requeueDelays[key]; found {
+		return delay
+	}
+	return time.Duration(config.Config.InstancePollSeconds) * time.Second
+}
+
+// scheduleRequeue pushes given key again after given delay, for given reason.
+// Assumes the queue is locked.
+func (q *Queue) scheduleRequeue(key inst.InstanceKey, delay time.Duration, reason string) {
+	if _, found := q.requeueTimers[key]; found {
+		return
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
+		q.Lock()
+		defer q.Unlock()
+
+		if q.requeueTimers[key] != timer {
+			// forgotten or stopped meanwhile
+			return
+		}
+		delete(q.requeueTimers, key)
+		q.push(NewDiscoveryRequest(key, reason), NormalPriority)
+	})
+	q.requeueTimers[key] = timer
+}
+
+// cancelRequeues stops all pending requeues. Assumes the queue is locked.
+func (q *Queue) cancelRequeues() {
+	for key, timer := range q.requeueTimers {
+		timer.Stop()
+		delete(q.requeueTimers, key)
+	}
+}
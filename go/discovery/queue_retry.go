@@ -0,0 +1,108 @@
+/*
+   Copyright 2017 Shlomi Noach, GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package discovery
+
+import (
+	"time"
+
+	"github.com/github/orchestrator/go/inst"
+	"github.com/openark/golib/log"
+)
+
+// FailureEntry describes a key whose processing has failed
+type FailureEntry struct {
+	Key           inst.InstanceKey
+	Failures      int
+	Retries       uint
+	LastError     string
+	LastFailureAt time.Time
+}
+
+// SetRetryPolicy has keys whose processing failed pushed again after retryDelay,
+// up to maxRetries times in a row, after which they are dropped. 0 maxRetries
+// disables retries.
+func (q *Queue) SetRetryPolicy(maxRetries uint, retryDelay time.Duration) {
+	q.Lock()
+	defer q.Unlock()
+
+	q.maxRetries = maxRetries
+	q.retryDelay = retryDelay
+}
+
+// ReleaseWithResult releases a key, as Release does, and records the result of
+// its processing. A key whose processing failed is retried as per the
+// queue's retry policy.
+func (q *Queue) ReleaseWithResult(key inst.InstanceKey, err error) {
+	q.Lock()
+	defer q.Unlock()
+
+	if err == nil {
+		delete(q.failedKeys, key)
+		q.release(key, nil)
+		return
+	}
+
+	entry, found := q.failedKeys[key]
+	if !found {
+		entry = &FailureEntry{Key: key}
+		q.failedKeys[key] = entry
+	}
+	entry.Failures++
+	entry.LastError = err.Error()
+	entry.LastFailureAt = time.Now()
+
+	if entry.Retries >= q.maxRetries {
+		if q.maxRetries > 0 {
+			log.Warningf("dropping key %v from discoveryQueue %s after %d retries: %+v", key, q.name, entry.Retries, err)
+			q.droppedCount++
+		}
+		entry.Retries = 0
+		q.release(key, err)
+		return
+	}
+	entry.Retries++
+	consumedEntry, consumed := q.consumedKeys[key]
+	q.release(key, err)
+	if consumed && !consumedEntry.forgotten && !q.stopped {
+		if timer, found := q.requeueTimers[key]; found {
+			// retry rather than wait for the next poll
+			timer.Stop()
+			delete(q.requeueTimers, key)
+		}
+		q.scheduleRequeue(key, q.retryDelay, RetryReason)
+	}
+}
+
+// Failures returns the keys whose latest processing failed
+func (q *Queue) Failures() []FailureEntry {
+	q.Lock()
+	defer q.Unlock()
+
+	failures := []FailureEntry{}
+	for _, entry := range q.failedKeys {
+		failures = append(failures, *entry)
+	}
+	return failures
+}
+
+// DroppedCount returns the number of keys dropped after exhausting their retries
+func (q *Queue) DroppedCount() uint64 {
+	q.Lock()
+	defer q.Unlock()
+
+	return q.droppedCount
+}
@@ -0,0 +1,644 @@
+package discovery
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	test "github.com/openark/golib/tests"
+)
+
+var key1 = inst.InstanceKey{Hostname: "host1", Port: 3306}
+var key2 = inst.InstanceKey{Hostname: "host2", Port: 3306}
+var key3 = inst.InstanceKey{Hostname: "host3", Port: 3306}
+
+func consume(q *Queue) inst.InstanceKey {
+	key, _ := q.Consume()
+	return key
+}
+
+func TestQueuePushConsume(t *testing.T) {
+	q := newQueue("test")
+	q.Push(key1)
+	q.Push(key2)
+	q.Push(key1)
+	test.S(t).ExpectEquals(q.QueueLen(), 2)
+
+	test.S(t).ExpectEquals(consume(q), key1)
+	// being processed; ignored
+	q.Push(key1)
+	test.S(t).ExpectEquals(q.QueueLen(), 1)
+	q.Release(key1)
+
+	test.S(t).ExpectEquals(consume(q), key2)
+	q.Release(key2)
+	test.S(t).ExpectEquals(q.QueueLen(), 0)
+}
+
+func TestQueuePriority(t *testing.T) {
+	q := newQueue("test")
+	q.Push(key1)
+	q.Push(key2)
+	q.PushWithPriority(key3, HighPriority)
+	test.S(t).ExpectEquals(q.QueueLen(), 3)
+
+	test.S(t).ExpectEquals(consume(q), key3)
+	test.S(t).ExpectEquals(consume(q), key1)
+	test.S(t).ExpectEquals(consume(q), key2)
+}
+
+func TestQueuePriorityBump(t *testing.T) {
+	q := newQueue("test")
+	q.Push(key1)
+	q.Push(key2)
+	q.Push(key3)
+	q.PushWithPriority(key3, HighPriority)
+	q.PushWithPriority(key2, HighPriority)
+	// lower priority does not demote
+	q.Push(key3)
+	test.S(t).ExpectEquals(q.QueueLen(), 3)
+
+	test.S(t).ExpectEquals(consume(q), key3)
+	test.S(t).ExpectEquals(consume(q), key2)
+	test.S(t).ExpectEquals(consume(q), key1)
+	test.S(t).ExpectEquals(q.QueueLen(), 0)
+}
+
+func TestQueueCounters(t *testing.T) {
+	q := newQueue("test")
+	q.Push(key1)
+	q.Push(key2)
+	q.Push(key3)
+	test.S(t).ExpectEquals(q.QueueLen(), 3)
+	test.S(t).ExpectEquals(q.ActiveWorkers(), 0)
+
+	consumed := make(chan inst.InstanceKey)
+	release := make(chan bool)
+	for i := 0; i < 2; i++ {
+		go func() {
+			key := consume(q)
+			consumed <- key
+			<-release
+			q.Release(key)
+			consumed <- key
+		}()
+	}
+	<-consumed
+	<-consumed
+	test.S(t).ExpectEquals(q.QueueLen(), 1)
+	test.S(t).ExpectEquals(q.ActiveWorkers(), 2)
+
+	release <- true
+	<-consumed
+	test.S(t).ExpectEquals(q.QueueLen(), 1)
+	test.S(t).ExpectEquals(q.ActiveWorkers(), 1)
+
+	release <- true
+	<-consumed
+	test.S(t).ExpectEquals(q.ActiveWorkers(), 0)
+}
+
+func TestQueueDrainsAllKeys(t *testing.T) {
+	q := newQueue("test")
+	keys := []inst.InstanceKey{}
+	for i := 0; i < 10; i++ {
+		key := inst.InstanceKey{Hostname: fmt.Sprintf("host%d", i), Port: 3306}
+		keys = append(keys, key)
+		q.Push(key)
+	}
+
+	var processedMutex sync.Mutex
+	processed := make(map[inst.InstanceKey]int)
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				key, ok := q.Consume()
+				if !ok {
+					return
+				}
+				processedMutex.Lock()
+				processed[key]++
+				processedMutex.Unlock()
+				q.Release(key)
+			}
+		}()
+	}
+	q.Stop(true)
+	wg.Wait()
+
+	test.S(t).ExpectEquals(q.QueueLen(), 0)
+	test.S(t).ExpectEquals(q.ActiveWorkers(), 0)
+	test.S(t).ExpectEquals(len(processed), len(keys))
+	for _, key := range keys {
+		test.S(t).ExpectEquals(processed[key], 1)
+	}
+}
+
+func TestQueueBackoff(t *testing.T) {
+	defer func(seconds uint) { config.Config.DiscoveryBackoffMaxSeconds = seconds }(config.Config.DiscoveryBackoffMaxSeconds)
+	config.Config.DiscoveryBackoffMaxSeconds = 600
+
+	test.S(t).ExpectEquals(backoffDuration(2), time.Duration(0))
+	test.S(t).ExpectEquals(backoffDuration(3), time.Minute)
+	test.S(t).ExpectEquals(backoffDuration(4), 5*time.Minute)
+	test.S(t).ExpectEquals(backoffDuration(5), 10*time.Minute)
+	test.S(t).ExpectEquals(backoffDuration(50), 10*time.Minute)
+
+	q := newQueue("test")
+	failure := fmt.Errorf("failed")
+	q.RecordResult(key1, failure)
+	q.RecordResult(key1, failure)
+	test.S(t).ExpectEquals(len(q.BackoffKeys()), 0)
+	q.Push(key1)
+	test.S(t).ExpectEquals(q.QueueLen(), 1)
+	q.Release(consume(q))
+
+	q.RecordResult(key1, failure)
+	test.S(t).ExpectEquals(len(q.BackoffKeys()), 1)
+	test.S(t).ExpectEquals(q.BackoffKeys()[0].ConsecutiveFailures, 3)
+	q.Push(key1)
+	q.PushWithPriority(key1, HighPriority)
+	test.S(t).ExpectEquals(q.QueueLen(), 0)
+
+	// e.g. an urgent re-check of a stale instance
+	request := NewDiscoveryRequest(key1, "stale")
+	request.Forced = true
+	q.PushRequest(request, HighPriority)
+	test.S(t).ExpectEquals(q.QueueLen(), 1)
+	q.Release(consume(q))
+
+	q.RecordResult(key1, nil)
+	test.S(t).ExpectEquals(len(q.BackoffKeys()), 0)
+	q.Push(key1)
+	test.S(t).ExpectEquals(q.QueueLen(), 1)
+}
+
+func TestQueueBackoffDisabled(t *testing.T) {
+	q := newQueue("test")
+	for i := 0; i < 10; i++ {
+		q.RecordResult(key1, fmt.Errorf("failed"))
+	}
+	test.S(t).ExpectEquals(len(q.BackoffKeys()), 0)
+	q.Push(key1)
+	test.S(t).ExpectEquals(q.QueueLen(), 1)
+}
+
+func TestQueueStopAbandonsQueuedKeys(t *testing.T) {
+	q := newQueue("test")
+	q.Push(key1)
+	q.Push(key2)
+	q.Push(key3)
+	test.S(t).ExpectEquals(consume(q), key1)
+
+	stopped := make(chan bool)
+	go func() {
+		q.Stop(false)
+		stopped <- true
+	}()
+	q.Push(key1)
+	q.Release(key1)
+	<-stopped
+
+	test.S(t).ExpectEquals(q.QueueLen(), 0)
+	_, ok := q.Consume()
+	test.S(t).ExpectFalse(ok)
+	q.Push(key1)
+	test.S(t).ExpectEquals(q.QueueLen(), 0)
+}
+
+func TestQueueStats(t *testing.T) {
+	q := newQueue("test")
+	test.S(t).ExpectEquals(q.Stats().Count, 0)
+
+	q.Push(key1)
+	q.Push(key2)
+	q.Release(consume(q))
+	q.Release(consume(q))
+
+	stats := q.Stats()
+	test.S(t).ExpectEquals(stats.Count, 2)
+	test.S(t).ExpectTrue(stats.MaxWaitSeconds >= stats.MedianWaitSeconds)
+	test.S(t).ExpectTrue(stats.MaxProcessingSeconds >= stats.MedianProcessingSeconds)
+}
+
+func TestQueueMaxConcurrency(t *testing.T) {
+	q := newQueue("test")
+	q.SetMaxConcurrency(1)
+	test.S(t).ExpectEquals(q.MaxConcurrency(), uint(1))
+	q.Push(key1)
+	q.Push(key2)
+	test.S(t).ExpectEquals(consume(q), key1)
+
+	consumed := make(chan inst.InstanceKey)
+	go func() {
+		consumed <- consume(q)
+	}()
+	select {
+	case <-consumed:
+		t.Errorf("expected Consume() to block on max concurrency")
+	case <-time.After(50 * time.Millisecond):
+	}
+	q.SetMaxConcurrency(2)
+	test.S(t).ExpectEquals(<-consumed, key2)
+	test.S(t).ExpectEquals(q.ActiveWorkers(), 2)
+}
+
+func TestQueueAbandon(t *testing.T) {
+	q := newQueue("test")
+	q.SetMaxConcurrency(1)
+	q.Push(key1)
+	q.Push(key2)
+	test.S(t).ExpectEquals(consume(q), key1)
+
+	q.Abandon(key1)
+	test.S(t).ExpectEquals(q.ActiveWorkers(), 0)
+	test.S(t).ExpectEquals(consume(q), key2)
+	test.S(t).ExpectEquals(q.ActiveWorkers(), 1)
+
+	// abandoned key is still being processed
+	q.Push(key1)
+	test.S(t).ExpectEquals(q.QueueLen(), 0)
+
+	q.Release(key1)
+	q.Release(key2)
+	test.S(t).ExpectEquals(q.ActiveWorkers(), 0)
+	q.Push(key1)
+	test.S(t).ExpectEquals(q.QueueLen(), 1)
+}
+
+func TestQueueMaxPerSecond(t *testing.T) {
+	defer func(maxPerSecond uint) { config.Config.DiscoveryMaxPerSecond = maxPerSecond }(config.Config.DiscoveryMaxPerSecond)
+	config.Config.DiscoveryMaxPerSecond = 20
+
+	q := newQueue("test")
+	for i := 0; i < 25; i++ {
+		q.Push(inst.InstanceKey{Hostname: fmt.Sprintf("host%d", i), Port: 3306})
+	}
+	startTime := time.Now()
+	// a full second's worth of tokens is available immediately
+	for i := 0; i < 20; i++ {
+		q.Release(consume(q))
+	}
+	test.S(t).ExpectTrue(time.Since(startTime) < 100*time.Millisecond)
+	for i := 0; i < 5; i++ {
+		q.Release(consume(q))
+	}
+	test.S(t).ExpectTrue(time.Since(startTime) >= 200*time.Millisecond)
+}
+
+func TestQueueDedupeStats(t *testing.T) {
+	q := newQueue("test")
+	q.Push(key1)
+	q.Push(key2)
+	q.Push(key1)
+	q.Push(key1)
+	q.Push(key2)
+	// bumping is not a dedupe
+	q.PushWithPriority(key2, HighPriority)
+
+	stats := q.DedupeStats()
+	test.S(t).ExpectEquals(stats.Deduplicated, uint64(3))
+	test.S(t).ExpectEquals(len(stats.TopKeys), 2)
+	test.S(t).ExpectEquals(stats.TopKeys[0].Key, key1)
+	test.S(t).ExpectEquals(stats.TopKeys[0].Count, 2)
+	test.S(t).ExpectEquals(stats.TopKeys[1].Key, key2)
+	test.S(t).ExpectEquals(stats.TopKeys[1].Count, 1)
+}
+
+func TestQueueRequeueOnCompletion(t *testing.T) {
+	q := newQueue("test")
+	q.EnableRequeueOnCompletion()
+	q.SetRequeueDelay(key1, 10*time.Millisecond)
+	q.SetRequeueDelay(key2, 10*time.Millisecond)
+	q.Push(key1)
+	q.Push(key2)
+
+	test.S(t).ExpectEquals(consume(q), key1)
+	test.S(t).ExpectEquals(consume(q), key2)
+	test.S(t).ExpectEquals(q.QueueLen(), 0)
+
+	// key2 is forgotten while being processed, and is not requeued
+	q.Forget(key2)
+	q.Release(key1)
+	q.Release(key2)
+	test.S(t).ExpectEquals(consume(q), key1)
+	q.Release(key1)
+
+	time.Sleep(50 * time.Millisecond)
+	test.S(t).ExpectEquals(q.QueueLen(), 1)
+	q.Forget(key1)
+	test.S(t).ExpectEquals(q.QueueLen(), 0)
+
+	time.Sleep(50 * time.Millisecond)
+	test.S(t).ExpectEquals(q.QueueLen(), 0)
+}
+
+func TestQueueSnapshot(t *testing.T) {
+	q := newQueue("test")
+	q.Push(key1)
+	q.Push(key2)
+	q.PushWithPriority(key3, HighPriority)
+	test.S(t).ExpectEquals(consume(q), key3)
+
+	snapshot := q.Snapshot()
+	test.S(t).ExpectEquals(len(snapshot.Queued), 2)
+	test.S(t).ExpectEquals(snapshot.Queued[0].Key, key1)
+	test.S(t).ExpectEquals(snapshot.Queued[1].Key, key2)
+	test.S(t).ExpectEquals(len(snapshot.Active), 1)
+	test.S(t).ExpectEquals(snapshot.Active[0].Key, key3)
+	test.S(t).ExpectEquals(snapshot.Active[0].Priority, HighPriority)
+	test.S(t).ExpectFalse(snapshot.Active[0].ConsumedAt.IsZero())
+}
+
+func TestQueueMaxQueueAge(t *testing.T) {
+	defer func(seconds uint) { config.Config.DiscoveryMaxQueueAgeSeconds = seconds }(config.Config.DiscoveryMaxQueueAgeSeconds)
+	config.Config.DiscoveryMaxQueueAgeSeconds = 60
+
+	q := newQueue("test")
+	q.Push(key1)
+	q.Push(key2)
+	q.PushWithPriority(key3, HighPriority)
+	q.queuedKeys[key3].queuedAt = time.Now().Add(-2 * time.Minute)
+	q.queuedKeys[key1].queuedAt = time.Now().Add(-2 * time.Minute)
+
+	test.S(t).ExpectEquals(consume(q), key2)
+	test.S(t).ExpectEquals(q.QueueLen(), 0)
+	test.S(t).ExpectEquals(q.ExpiredCount(), uint64(2))
+}
+
+func TestQueueRetryPolicy(t *testing.T) {
+	q := newQueue("test")
+	q.SetRetryPolicy(2, 10*time.Millisecond)
+	q.Push(key1)
+
+	for i := 0; i < 3; i++ {
+		test.S(t).ExpectEquals(consume(q), key1)
+		q.ReleaseWithResult(key1, fmt.Errorf("failure %d", i))
+	}
+	failures := q.Failures()
+	test.S(t).ExpectEquals(len(failures), 1)
+	test.S(t).ExpectEquals(failures[0].Failures, 3)
+	test.S(t).ExpectEquals(failures[0].LastError, "failure 2")
+	test.S(t).ExpectEquals(q.DroppedCount(), uint64(1))
+
+	// no further retries
+	time.Sleep(50 * time.Millisecond)
+	test.S(t).ExpectEquals(q.QueueLen(), 0)
+
+	q.Push(key1)
+	test.S(t).ExpectEquals(consume(q), key1)
+	q.ReleaseWithResult(key1, nil)
+	test.S(t).ExpectEquals(len(q.Failures()), 0)
+}
+
+func TestQueuePauseResume(t *testing.T) {
+	q := newQueue("test")
+	q.Pause()
+	test.S(t).ExpectTrue(q.Paused())
+	q.Push(key1)
+	q.Push(key2)
+	q.Push(key1)
+	test.S(t).ExpectEquals(q.QueueLen(), 2)
+
+	consumed := make(chan inst.InstanceKey)
+	go func() {
+		consumed <- consume(q)
+	}()
+	select {
+	case <-consumed:
+		t.Errorf("expected no key to be consumed while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	q.Resume()
+	test.S(t).ExpectFalse(q.Paused())
+	test.S(t).ExpectEquals(<-consumed, key1)
+	test.S(t).ExpectEquals(consume(q), key2)
+}
+
+func TestQueueStress(t *testing.T) {
+	const keysCount = 5000
+	const workersCount = 64
+
+	q := newQueue("test")
+	q.SetMaxConcurrency(workersCount / 2)
+
+	var processedMutex sync.Mutex
+	processed := make(map[inst.InstanceKey]bool)
+	var workers sync.WaitGroup
+	for i := 0; i < workersCount; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for {
+				key, ok := q.Consume()
+				if !ok {
+					return
+				}
+				// keys being processed are pushed again, and deduplicated
+				q.Push(key)
+				processedMutex.Lock()
+				processed[key] = true
+				processedMutex.Unlock()
+				q.Release(key)
+			}
+		}()
+	}
+
+	var pushers sync.WaitGroup
+	for p := 0; p < 4; p++ {
+		pushers.Add(1)
+		go func(p int) {
+			defer pushers.Done()
+			for i := 0; i < keysCount; i++ {
+				key := inst.InstanceKey{Hostname: fmt.Sprintf("host%d", i), Port: 3306}
+				if i%10 == p {
+					q.PushWithPriority(key, HighPriority)
+				} else {
+					q.Push(key)
+				}
+			}
+		}(p)
+	}
+	pushers.Wait()
+	q.Stop(true)
+	workers.Wait()
+
+	test.S(t).ExpectEquals(len(processed), keysCount)
+	test.S(t).ExpectEquals(q.QueueLen(), 0)
+	test.S(t).ExpectEquals(q.ActiveWorkers(), 0)
+}
+
+func TestQueuePushRequest(t *testing.T) {
+	q := newQueue("test")
+	q.PushRequest(NewDiscoveryRequest(key1, "poll"), NormalPriority)
+	q.PushRequest(NewDiscoveryRequest(key2, "poll"), NormalPriority)
+	q.PushRequest(NewDiscoveryRequest(key2, "failed check"), HighPriority)
+	q.PushRequest(NewDiscoveryRequest(key1, "master of host3:3306"), NormalPriority)
+
+	request, ok := q.ConsumeRequest()
+	test.S(t).ExpectTrue(ok)
+	test.S(t).ExpectEquals(request.Key, key2)
+	test.S(t).ExpectEquals(request.Reason, "failed check")
+	request, _ = q.ConsumeRequest()
+	test.S(t).ExpectEquals(request.Key, key1)
+	test.S(t).ExpectEquals(request.Reason, "poll")
+}
+
+func TestQueueSuccessCooldown(t *testing.T) {
+	defer func(seconds uint) { config.Config.DiscoverySuccessCooldownSeconds = seconds }(config.Config.DiscoverySuccessCooldownSeconds)
+	config.Config.DiscoverySuccessCooldownSeconds = 60
+
+	q := newQueue("test")
+	q.Push(key1)
+	q.Push(key2)
+	q.Push(key3)
+	test.S(t).ExpectEquals(consume(q), key1)
+	q.RecordResult(key1, nil)
+	q.ReleaseWithResult(key1, nil)
+	test.S(t).ExpectEquals(consume(q), key2)
+	q.RecordResult(key2, fmt.Errorf("failure"))
+	q.ReleaseWithResult(key2, fmt.Errorf("failure"))
+	// key3's discovery is skipped, e.g. as it is up to date: no result is recorded
+	test.S(t).ExpectEquals(consume(q), key3)
+	q.ReleaseWithResult(key3, nil)
+
+	test.S(t).ExpectTrue(q.InCooldown(key1))
+	test.S(t).ExpectFalse(q.InCooldown(key2))
+	test.S(t).ExpectFalse(q.InCooldown(key3))
+	q.Push(key1)
+	q.Push(key2)
+	q.Push(key3)
+	test.S(t).ExpectEquals(q.QueueLen(), 2)
+	test.S(t).ExpectEquals(q.CooldownCount(), uint64(1))
+
+	request := NewDiscoveryRequest(key1, "api")
+	request.Forced = true
+	q.PushRequest(request, NormalPriority)
+	test.S(t).ExpectEquals(q.QueueLen(), 3)
+
+	// expired cooldowns are removed lazily
+	q.cooldownKeys[key1] = time.Now().Add(-time.Second)
+	test.S(t).ExpectFalse(q.InCooldown(key1))
+	test.S(t).ExpectEquals(len(q.cooldownKeys), 0)
+}
+
+func TestQueueCompletionEvents(t *testing.T) {
+	q := newQueue("test")
+	events := q.Subscribe(1)
+	defer q.Unsubscribe(events)
+
+	q.Push(key1)
+	q.Push(key2)
+	test.S(t).ExpectEquals(consume(q), key1)
+	q.ReleaseWithResult(key1, fmt.Errorf("failure"))
+	// channel is full; event is dropped rather than block the queue
+	test.S(t).ExpectEquals(consume(q), key2)
+	q.Release(key2)
+
+	event := <-events
+	test.S(t).ExpectEquals(event.Key, key1)
+	test.S(t).ExpectNotNil(event.Err)
+	test.S(t).ExpectFalse(event.FinishedAt.Before(event.StartedAt))
+	select {
+	case event = <-events:
+		t.Errorf("unexpected event: %+v", event)
+	default:
+	}
+}
+
+func TestQueueWaitForDiscovery(t *testing.T) {
+	q := newQueue("test")
+	q.Push(key1)
+	q.Push(key2)
+	go func() {
+		for _, key := range []inst.InstanceKey{consume(q), consume(q)} {
+			time.Sleep(50 * time.Millisecond)
+			q.ReleaseWithResult(key, nil)
+		}
+	}()
+	test.S(t).ExpectNil(q.WaitForDiscovery(key2, time.Second))
+	test.S(t).ExpectNotNil(q.WaitForDiscovery(key3, 10*time.Millisecond))
+}
+
+func TestQueueFirstSeenLane(t *testing.T) {
+	defer func(reserved uint) { config.Config.DiscoveryFirstSeenReservedConcurrency = reserved }(config.Config.DiscoveryFirstSeenReservedConcurrency)
+	config.Config.DiscoveryFirstSeenReservedConcurrency = 1
+
+	q := newQueue("test")
+	q.SetMaxConcurrency(2)
+	// have key1, key2 seen
+	q.Push(key1)
+	q.Push(key2)
+	q.Release(consume(q))
+	q.Release(consume(q))
+
+	q.Push(key1)
+	q.Push(key2)
+	q.Push(key3)
+	test.S(t).ExpectEquals(q.QueueLen(), 3)
+	// key3 was never seen, and is consumed ahead of key1, key2
+	test.S(t).ExpectEquals(consume(q), key3)
+	test.S(t).ExpectFalse(q.canConsume())
+
+	q.Release(key3)
+	test.S(t).ExpectEquals(consume(q), key1)
+	// last slot is reserved
+	test.S(t).ExpectFalse(q.canConsume())
+	q.PushWithPriority(key3, HighPriority)
+	test.S(t).ExpectEquals(consume(q), key3)
+}
+
+func TestQueuePushBatch(t *testing.T) {
+	q := newQueue("test")
+	q.Push(key2)
+	q.PushBatch([]inst.InstanceKey{key1, key2, key3, key1})
+	test.S(t).ExpectEquals(q.QueueLen(), 3)
+	test.S(t).ExpectEquals(consume(q), key2)
+	test.S(t).ExpectEquals(consume(q), key1)
+	test.S(t).ExpectEquals(consume(q), key3)
+	test.S(t).ExpectEquals(q.DedupeStats().Deduplicated, uint64(2))
+}
+
+func TestQueueClusterJitter(t *testing.T) {
+	defer func(seconds uint) { config.Config.DiscoveryClusterJitterSeconds = seconds }(config.Config.DiscoveryClusterJitterSeconds)
+	config.Config.DiscoveryClusterJitterSeconds = 1
+
+	q := newQueue("test")
+	q.SetClusterClassifier(func(key inst.InstanceKey) string {
+		if key == key3 {
+			return "other"
+		}
+		return "cluster"
+	})
+	q.PushBatch([]inst.InstanceKey{key1, key2, key3})
+	test.S(t).ExpectEquals(q.QueueLen(), 3)
+	test.S(t).ExpectEquals(len(q.delayedKeys), 1)
+
+	// key2 is held aside, with no effect on other keys
+	test.S(t).ExpectEquals(consume(q), key1)
+	test.S(t).ExpectEquals(consume(q), key3)
+	test.S(t).ExpectEquals(consume(q), key2)
+	test.S(t).ExpectEquals(len(q.delayedKeys), 0)
+}
+
+func TestQueuePushRequestsLeavesRequestsIntact(t *testing.T) {
+	defer func(seconds uint) { config.Config.DiscoveryClusterJitterSeconds = seconds }(config.Config.DiscoveryClusterJitterSeconds)
+	config.Config.DiscoveryClusterJitterSeconds = 1
+
+	q := newQueue("test")
+	q.SetClusterClassifier(func(key inst.InstanceKey) string { return "cluster" })
+	requests := []DiscoveryRequest{NewDiscoveryRequest(key1, "api"), NewDiscoveryRequest(key2, "api")}
+	q.PushRequests(requests, NormalPriority)
+	test.S(t).ExpectEquals(q.QueueLen(), 2)
+	for _, request := range requests {
+		test.S(t).ExpectEquals(request.clusterName, "")
+	}
+	q.Stop(false)
+}
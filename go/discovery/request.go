@@ -0,0 +1,46 @@
+/*
+   Copyright 2017 Shlomi Noach, GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package discovery
+
+import (
+	"time"
+
+	"github.com/github/orchestrator/go/inst"
+)
+
+// Reasons for discovery requests made by the queue itself
+const (
+	RequeueReason = "requeue"
+	RetryReason   = "retry"
+)
+
+// DiscoveryRequest is a request to discover a key, along with what triggered the request.
+// A forced request is subject neither to the cooldown following a successful discovery, nor to the backoff
+// following repeated failures.
+type DiscoveryRequest struct {
+	Key         inst.InstanceKey
+	Reason      string
+	RequestedAt time.Time
+	Forced      bool
+
+	clusterName string
+}
+
+// NewDiscoveryRequest creates a discovery request for given key, requested now
+func NewDiscoveryRequest(key inst.InstanceKey, reason string) DiscoveryRequest {
+	return DiscoveryRequest{Key: key, Reason: reason, RequestedAt: time.Now()}
+}
@@ -0,0 +1,172 @@
+/*
+   Copyright 2017 Shlomi Noach, GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package discovery
+
+import (
+	"time"
+
+	"github.com/github/orchestrator/go/inst"
+)
+
+// Classifier returns the name of the queue to which an instance key is routed.
+// An empty name stands for the default queue.
+type Classifier func(instanceKey inst.InstanceKey) string
+
+// Router routes discovery requests onto named queues, based on a classifier.
+// Keys classified onto an unknown queue are routed onto the default queue.
+type Router struct {
+	classifier   Classifier
+	defaultQueue *Queue
+	queues       map[string]*Queue
+}
+
+// NewRouter creates a router onto given queues, falling back to given default queue
+func NewRouter(classifier Classifier, defaultQueue *Queue, queues map[string]*Queue) *Router {
+	return &Router{
+		classifier:   classifier,
+		defaultQueue: defaultQueue,
+		queues:       queues,
+	}
+}
+
+// Queue returns the queue to which given key is routed
+func (r *Router) Queue(key inst.InstanceKey) *Queue {
+	if r.classifier == nil {
+		return r.defaultQueue
+	}
+	if q, found := r.queues[r.classifier(key)]; found {
+		return q
+	}
+	return r.defaultQueue
+}
+
+// Queues returns all queues of this router, including the default queue
+func (r *Router) Queues() []*Queue {
+	queues := []*Queue{r.defaultQueue}
+	for _, q := range r.queues {
+		if q != r.defaultQueue {
+			queues = append(queues, q)
+		}
+	}
+	return queues
+}
+
+// Push routes given key onto its queue with normal priority
+func (r *Router) Push(key inst.InstanceKey) {
+	r.Queue(key).Push(key)
+}
+
+// PushWithPriority routes given key onto its queue with given priority
+func (r *Router) PushWithPriority(key inst.InstanceKey, priority Priority) {
+	r.Queue(key).PushWithPriority(key, priority)
+}
+
+// PushRequest routes given discovery request onto its key's queue with given priority
+func (r *Router) PushRequest(request DiscoveryRequest, priority Priority) {
+	r.Queue(request.Key).PushRequest(request, priority)
+}
+
+// PushRequests routes given discovery requests onto their keys' queues with given
+// priority, taking each queue's lock once
+func (r *Router) PushRequests(requests []DiscoveryRequest, priority Priority) {
+	queueRequests := make(map[*Queue][]DiscoveryRequest)
+	for _, request := range requests {
+		q := r.Queue(request.Key)
+		queueRequests[q] = append(queueRequests[q], request)
+	}
+	for q, requests := range queueRequests {
+		q.PushRequests(requests, priority)
+	}
+}
+
+// QueueLen returns the number of keys waiting on all queues
+func (r *Router) QueueLen() (queueLen int) {
+	for _, q := range r.Queues() {
+		queueLen += q.QueueLen()
+	}
+	return queueLen
+}
+
+// ActiveWorkers returns the number of keys being processed on all queues
+func (r *Router) ActiveWorkers() (activeWorkers int) {
+	for _, q := range r.Queues() {
+		activeWorkers += q.ActiveWorkers()
+	}
+	return activeWorkers
+}
+
+// ExpiredCount returns the number of keys dropped from all queues for having
+// waited too long
+func (r *Router) ExpiredCount() (expiredCount uint64) {
+	for _, q := range r.Queues() {
+		expiredCount += q.ExpiredCount()
+	}
+	return expiredCount
+}
+
+// DroppedCount returns the number of keys dropped from all queues after
+// exhausting their retries
+func (r *Router) DroppedCount() (droppedCount uint64) {
+	for _, q := range r.Queues() {
+		droppedCount += q.DroppedCount()
+	}
+	return droppedCount
+}
+
+// Pause pauses all queues
+func (r *Router) Pause() {
+	for _, q := range r.Queues() {
+		q.Pause()
+	}
+}
+
+// Resume resumes all queues
+func (r *Router) Resume() {
+	for _, q := range r.Queues() {
+		q.Resume()
+	}
+}
+
+// Paused returns true when all queues are paused
+func (r *Router) Paused() bool {
+	for _, q := range r.Queues() {
+		if !q.Paused() {
+			return false
+		}
+	}
+	return true
+}
+
+// CooldownCount returns the number of requests dropped from all queues for
+// keys in cooldown
+func (r *Router) CooldownCount() (cooldownCount uint64) {
+	for _, q := range r.Queues() {
+		cooldownCount += q.CooldownCount()
+	}
+	return cooldownCount
+}
+
+// WaitForDiscovery blocks until given key is next released from its queue, or until
+// timeout passes. Returns the key's processing error, if any.
+func (r *Router) WaitForDiscovery(key inst.InstanceKey, timeout time.Duration) error {
+	return r.Queue(key).WaitForDiscovery(key, timeout)
+}
+
+// Forget takes given key out of its queue's requeue cycle. See Queue.Forget
+func (r *Router) Forget(key inst.InstanceKey) {
+	r.Queue(key).Forget(key)
+}
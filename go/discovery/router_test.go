@@ -0,0 +1,40 @@
+package discovery
+
+import (
+	"testing"
+
+	"github.com/github/orchestrator/go/inst"
+	test "github.com/openark/golib/tests"
+)
+
+func TestRouter(t *testing.T) {
+	defaultQueue := newQueue("default")
+	dc1Queue := newQueue("dc1")
+	classifier := func(instanceKey inst.InstanceKey) string {
+		return instanceKey.Hostname[len(instanceKey.Hostname)-3:]
+	}
+	router := NewRouter(classifier, defaultQueue, map[string]*Queue{"dc1": dc1Queue})
+
+	dc1Key := inst.InstanceKey{Hostname: "host.dc1", Port: 3306}
+	dc2Key := inst.InstanceKey{Hostname: "host.dc2", Port: 3306}
+	test.S(t).ExpectTrue(router.Queue(dc1Key) == dc1Queue)
+	test.S(t).ExpectTrue(router.Queue(dc2Key) == defaultQueue)
+	test.S(t).ExpectEquals(len(router.Queues()), 2)
+
+	router.Push(dc1Key)
+	router.PushWithPriority(dc2Key, HighPriority)
+	test.S(t).ExpectEquals(dc1Queue.QueueLen(), 1)
+	test.S(t).ExpectEquals(defaultQueue.QueueLen(), 1)
+	test.S(t).ExpectEquals(router.QueueLen(), 2)
+
+	test.S(t).ExpectEquals(consume(dc1Queue), dc1Key)
+	test.S(t).ExpectEquals(router.ActiveWorkers(), 1)
+}
+
+func TestRouterNoClassifier(t *testing.T) {
+	defaultQueue := newQueue("default")
+	router := NewRouter(nil, defaultQueue, map[string]*Queue{})
+	router.Push(key1)
+	test.S(t).ExpectTrue(router.Queue(key1) == defaultQueue)
+	test.S(t).ExpectEquals(defaultQueue.QueueLen(), 1)
+}
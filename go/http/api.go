@@ -17,6 +17,7 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net"
@@ -222,7 +223,68 @@ func (this *HttpAPI) AsyncDiscover(params martini.Params, r render.Render, req *
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Asynchronous discovery initiated for Instance: %+v", instanceKey)})
 }
 
-// Discover issues a synchronous read on an instance
+// DiscoverCluster queues discovery of all instances of given cluster
+func (this *HttpAPI) DiscoverCluster(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	count, err := logic.DiscoverCluster(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Discovery queued for %d instances of cluster %s", count, clusterName), Details: count})
+}
+
+// ClusterSnapshotCoordinates reads binary log coordinates and executed GTID sets off all instances of a cluster
+// within a short period of time, stores and returns the snapshot
+func (this *HttpAPI) ClusterSnapshotCoordinates(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	snapshot, err := logic.SnapshotClusterCoordinates(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err), Details: snapshot})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Coordinates snapshot taken on cluster %s; %d out of %d instances could not be read", clusterName, snapshot.CountFailed(), len(snapshot.Instances)), Details: snapshot})
+}
+
+// LastSnapshotCoordinates returns the most recent coordinates snapshot taken on a cluster
+func (this *HttpAPI) LastSnapshotCoordinates(params martini.Params, r render.Render, req *http.Request) {
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	snapshot, err := inst.ReadLastClusterCoordinatesSnapshot(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	if snapshot == nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("No coordinates snapshot found for cluster %s", clusterName)})
+		return
+	}
+	r.JSON(http.StatusOK, snapshot)
+}
+
+// Discover issues a synchronous read on an instance. An instance discovered within the
+// last DiscoverySuccessCooldownSeconds is not read again, unless forced via "force=true".
 func (this *HttpAPI) Discover(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
 		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
@@ -233,6 +295,14 @@ func (this *HttpAPI) Discover(params martini.Params, r render.Render, req *http.
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
+	force := (req.URL.Query().Get("force") == "true")
+	if !force && logic.IsDiscoveryInCooldown(instanceKey) {
+		instance, found, err := inst.ReadInstance(&instanceKey)
+		if err == nil && found {
+			Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Instance recently discovered: %+v", instance.Key), Details: instance})
+			return
+		}
+	}
 	instance, err := inst.ReadTopologyInstance(&instanceKey)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
@@ -242,7 +312,7 @@ func (this *HttpAPI) Discover(params martini.Params, r render.Render, req *http.
 	if orcraft.IsRaftEnabled() {
 		orcraft.PublishCommand("discover", instanceKey)
 	} else {
-		logic.DiscoverInstance(instanceKey)
+		logic.DiscoverInstance(context.Background(), instanceKey, "api")
 	}
 
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Instance discovered: %+v", instance.Key), Details: instance})
@@ -306,12 +376,61 @@ func (this *HttpAPI) ForgetCluster(params martini.Params, r render.Render, req *
 		return
 	}
 
+	clusterInstances, err := inst.ReadClusterInstances(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	force := (req.URL.Query().Get("force") == "true")
+	if err := inst.CheckForgetSafetyThreshold(len(clusterInstances), force); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("forget-cluster", clusterName)
+	} else {
+		err = logic.ForgetCluster(clusterName)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Cluster forgotten: %+v", clusterName), Details: len(clusterInstances)})
+}
+
+// ForgetByPattern forgets all instances whose hostname:port matches the "pattern" regular expression
+func (this *HttpAPI) ForgetByPattern(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	pattern := req.URL.Query().Get("pattern")
+	if pattern == "" {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Missing pattern"})
+		return
+	}
+	instanceKeys, err := inst.ReadInstanceKeysByPattern(pattern)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	force := (req.URL.Query().Get("force") == "true")
+	if err := inst.CheckForgetSafetyThreshold(len(instanceKeys), force); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
 	if orcraft.IsRaftEnabled() {
-		orcraft.PublishCommand("forget-cluster", clusterName)
+		_, err = orcraft.PublishCommand("forget-by-pattern", pattern)
 	} else {
-		inst.ForgetCluster(clusterName)
+		err = logic.ForgetInstancesByPattern(pattern)
 	}
-	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Cluster forgotten: %+v", clusterName)})
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Instances forgotten: %d", len(instanceKeys)), Details: instanceKeys})
 }
 
 // Resolve tries to resolve hostname and then checks to see if port is open on that host.
@@ -491,6 +610,196 @@ func (this *HttpAPI) EndDowntime(params martini.Params, r render.Render, req *ht
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Downtime ended: %+v", instanceKey), Details: instanceKey})
 }
 
+// IgnoreHealthChecks marks an instance as unreachable by design: it is no longer polled nor analyzed
+func (this *HttpAPI) IgnoreHealthChecks(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	ignore := inst.NewHealthChecksIgnore(&instanceKey, params["owner"], params["reason"])
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("ignore-health-checks", ignore)
+	} else {
+		err = inst.IgnoreHealthChecks(ignore)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Health checks ignored: %+v", instanceKey), Details: instanceKey})
+}
+
+// UnignoreHealthChecks resumes polling and analysis of an instance
+func (this *HttpAPI) UnignoreHealthChecks(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("unignore-health-checks", instanceKey)
+	} else {
+		_, err = inst.UnignoreHealthChecks(&instanceKey)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Health checks resumed: %+v", instanceKey), Details: instanceKey})
+}
+
+// ExtendDowntime extends the active downtime of an instance by given duration
+func (this *HttpAPI) ExtendDowntime(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	durationSeconds, err := util.SimpleTimeToSeconds(params["duration"])
+	if err == nil && durationSeconds <= 0 {
+		err = fmt.Errorf("Duration value must be positive. Given value: %d", durationSeconds)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	duration := time.Duration(durationSeconds) * time.Second
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("extend-downtime", inst.NewDowntime(&instanceKey, "", "", duration))
+	} else {
+		err = inst.ExtendDowntime(&instanceKey, duration)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: instanceKey})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Downtime extended by %+v: %+v", duration, instanceKey), Details: instanceKey})
+}
+
+// BeginDowntimeCluster sets a downtime flag on all instances of a cluster, with optional duration
+func (this *HttpAPI) BeginDowntimeCluster(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	var durationSeconds int = 0
+	if params["duration"] != "" {
+		durationSeconds, err = util.SimpleTimeToSeconds(params["duration"])
+		if durationSeconds < 0 {
+			err = fmt.Errorf("Duration value must be non-negative. Given value: %d", durationSeconds)
+		}
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+			return
+		}
+	}
+	duration := time.Duration(durationSeconds) * time.Second
+	var results []inst.ClusterDowntimeResult
+	if orcraft.IsRaftEnabled() {
+		var response interface{}
+		response, err = orcraft.PublishCommand("begin-downtime-cluster", inst.NewClusterDowntime(clusterName, params["owner"], params["reason"], duration))
+		results, _ = response.([]inst.ClusterDowntimeResult)
+	} else {
+		results, err = inst.BeginDowntimeCluster(clusterName, params["owner"], params["reason"], duration)
+	}
+
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: results})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Downtime begun on %d instances of %s", len(results), clusterName), Details: results})
+}
+
+// EndDowntimeCluster terminates downtime (removes downtime flag) for all instances of a cluster
+func (this *HttpAPI) EndDowntimeCluster(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	var results []inst.ClusterDowntimeResult
+	if orcraft.IsRaftEnabled() {
+		var response interface{}
+		response, err = orcraft.PublishCommand("end-downtime-cluster", clusterName)
+		results, _ = response.([]inst.ClusterDowntimeResult)
+	} else {
+		results, err = inst.EndDowntimeCluster(clusterName)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: results})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Downtime ended on %d instances of %s", len(results), clusterName), Details: results})
+}
+
+// SetClusterReadOnly sets all instances of a cluster, including its master, read_only
+func (this *HttpAPI) SetClusterReadOnly(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	results, err := inst.SetClusterReadOnly(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: results})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Set read_only on %d instances of %s", len(results), clusterName), Details: results})
+}
+
+// SetClusterWriteableMasterOnly makes the master of a cluster writeable, leaving its other instances as they are
+func (this *HttpAPI) SetClusterWriteableMasterOnly(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	results, err := inst.SetClusterWriteableMasterOnly(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: results})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Master of %s set writeable", clusterName), Details: results})
+}
+
 // MoveUp attempts to move an instance up the topology
 func (this *HttpAPI) MoveUp(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
@@ -533,6 +842,12 @@ func (this *HttpAPI) MoveUpReplicas(params martini.Params, r render.Render, req
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Moved up %d replicas of %+v below %+v; %d errors: %+v", len(replicas), instanceKey, newMaster.Key, len(errs), errs), Details: replicas})
 }
 
+// allowCrossCluster returns true when the request explicitly allows moving an instance below an instance of
+// a different cluster, via "allow-cross-cluster=true"
+func allowCrossCluster(req *http.Request) bool {
+	return req.URL.Query().Get("allow-cross-cluster") == "true"
+}
+
 // Repoint positiones a replica under another (or same) master with exact same coordinates.
 // Useful for binlog servers
 func (this *HttpAPI) Repoint(params martini.Params, r render.Render, req *http.Request, user auth.User) {
@@ -551,7 +866,7 @@ func (this *HttpAPI) Repoint(params martini.Params, r render.Render, req *http.R
 		return
 	}
 
-	instance, err := inst.Repoint(&instanceKey, &belowKey, inst.GTIDHintNeutral)
+	instance, err := inst.Repoint(&instanceKey, &belowKey, inst.GTIDHintNeutral, allowCrossCluster(req))
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -560,6 +875,53 @@ func (this *HttpAPI) Repoint(params martini.Params, r render.Render, req *http.R
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Instance %+v repointed below %+v", instanceKey, belowKey), Details: instance})
 }
 
+// RepointToCanonical repoints a replica onto the canonical endpoint of its current master, keeping coordinates
+func (this *HttpAPI) RepointToCanonical(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	instance, err := inst.RepointToCanonical(&instanceKey)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Instance %+v repointed to canonical endpoint %+v", instanceKey, instance.MasterKey), Details: instance})
+}
+
+// RepointChannel repoints a single replication channel of a multi-source replica onto another instance, via GTID
+func (this *HttpAPI) RepointChannel(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	belowKey, err := this.getInstanceKey(params["belowHost"], params["belowPort"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	instance, err := inst.RepointChannel(&instanceKey, params["channel"], &belowKey, allowCrossCluster(req))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Channel '%s' of instance %+v repointed below %+v", params["channel"], instanceKey, belowKey), Details: instance})
+}
+
 // MoveUpReplicas attempts to move up all replicas of an instance
 func (this *HttpAPI) RepointReplicas(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
@@ -725,6 +1087,23 @@ func (this *HttpAPI) LocateErrantGTID(params martini.Params, r render.Render, re
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("located errant GTID"), Details: errantBinlogs})
 }
 
+// ClusterErrantGTID lists the instances of a cluster which have errant GTID
+func (this *HttpAPI) ClusterErrantGTID(params martini.Params, r render.Render, req *http.Request) {
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	instances, err := inst.ReadClusterErrantGTIDInstances(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, instances)
+}
+
 // ErrantGTIDResetMaster removes errant transactions on a server by way of RESET MASTER
 func (this *HttpAPI) ErrantGTIDResetMaster(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
@@ -810,7 +1189,7 @@ func (this *HttpAPI) MoveBelowGTID(params martini.Params, r render.Render, req *
 		return
 	}
 
-	instance, err := inst.MoveBelowGTID(&instanceKey, &belowKey)
+	instance, err := inst.MoveBelowGTID(&instanceKey, &belowKey, allowCrossCluster(req))
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -836,7 +1215,7 @@ func (this *HttpAPI) MoveReplicasGTID(params martini.Params, r render.Render, re
 		return
 	}
 
-	movedReplicas, _, err, errs := inst.MoveReplicasGTID(&instanceKey, &belowKey, req.URL.Query().Get("pattern"))
+	movedReplicas, _, err, errs := inst.MoveReplicasGTID(&instanceKey, &belowKey, req.URL.Query().Get("pattern"), allowCrossCluster(req))
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -857,13 +1236,18 @@ func (this *HttpAPI) TakeSiblings(params martini.Params, r render.Render, req *h
 		return
 	}
 
-	instance, count, err := inst.TakeSiblings(&instanceKey)
+	dryRun := (req.URL.Query().Get("dry-run") == "true")
+	instance, count, err, errs := inst.TakeSiblings(&instanceKey, dryRun)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
 
-	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Took %d siblings of %+v", count, instanceKey), Details: instance})
+	if dryRun {
+		Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Dry run: would take %d siblings of %+v; %d errors: %+v", count, instanceKey, len(errs), errs), Details: instance})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Took %d siblings of %+v; %d errors: %+v", count, instanceKey, len(errs), errs), Details: instance})
 }
 
 // TakeMaster
@@ -878,12 +1262,17 @@ func (this *HttpAPI) TakeMaster(params martini.Params, r render.Render, req *htt
 		return
 	}
 
-	instance, err := inst.TakeMaster(&instanceKey, false)
+	dryRun := (req.URL.Query().Get("dry-run") == "true")
+	instance, err := inst.TakeMaster(&instanceKey, false, true, dryRun)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
 
+	if dryRun {
+		Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Dry run: %+v can take its master", instanceKey), Details: instance})
+		return
+	}
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("%+v took its master", instanceKey), Details: instance})
 }
 
@@ -905,7 +1294,7 @@ func (this *HttpAPI) RelocateBelow(params martini.Params, r render.Render, req *
 		return
 	}
 
-	instance, err := inst.RelocateBelow(&instanceKey, &belowKey)
+	instance, err := inst.RelocateBelow(&instanceKey, &belowKey, allowCrossCluster(req))
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -931,7 +1320,7 @@ func (this *HttpAPI) RelocateReplicas(params martini.Params, r render.Render, re
 		return
 	}
 
-	replicas, _, err, errs := inst.RelocateReplicas(&instanceKey, &belowKey, req.URL.Query().Get("pattern"))
+	replicas, _, err, errs := inst.RelocateReplicas(&instanceKey, &belowKey, req.URL.Query().Get("pattern"), allowCrossCluster(req))
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -957,7 +1346,7 @@ func (this *HttpAPI) MoveEquivalent(params martini.Params, r render.Render, req
 		return
 	}
 
-	instance, err := inst.MoveEquivalent(&instanceKey, &belowKey)
+	instance, err := inst.MoveEquivalent(&instanceKey, &belowKey, allowCrossCluster(req))
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -1018,7 +1407,7 @@ func (this *HttpAPI) MatchBelow(params martini.Params, r render.Render, req *htt
 		return
 	}
 
-	instance, matchedCoordinates, err := inst.MatchBelow(&instanceKey, &belowKey, true)
+	instance, matchedCoordinates, err := inst.MatchBelow(&instanceKey, &belowKey, true, allowCrossCluster(req))
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -1065,7 +1454,7 @@ func (this *HttpAPI) MultiMatchReplicas(params martini.Params, r render.Render,
 		return
 	}
 
-	replicas, newMaster, err, errs := inst.MultiMatchReplicas(&instanceKey, &belowKey, req.URL.Query().Get("pattern"))
+	replicas, newMaster, err, errs := inst.MultiMatchReplicas(&instanceKey, &belowKey, req.URL.Query().Get("pattern"), allowCrossCluster(req))
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -1742,6 +2131,10 @@ func (this *HttpAPI) ClusterInfo(params martini.Params, r render.Render, req *ht
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
 		return
 	}
+	if clusterInfo.BinlogRetention, err = inst.ReadClusterBinlogRetention(clusterName); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
 
 	r.JSON(http.StatusOK, clusterInfo)
 }
@@ -1869,6 +2262,24 @@ func (this *HttpAPI) TagValue(params martini.Params, r render.Render, req *http.
 // Tagged return instance keys tagged by "tag" query param
 func (this *HttpAPI) Tagged(params martini.Params, r render.Render, req *http.Request) {
 	tagsString := req.URL.Query().Get("tag")
+	if clusterHint := req.URL.Query().Get("clusterHint"); clusterHint != "" {
+		clusterName, err := figureClusterName(clusterHint)
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+			return
+		}
+		instances, err := inst.ReadInstancesByTag(clusterName, tagsString)
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+			return
+		}
+		instanceKeys := []inst.InstanceKey{}
+		for _, instance := range instances {
+			instanceKeys = append(instanceKeys, instance.Key)
+		}
+		r.JSON(http.StatusOK, instanceKeys)
+		return
+	}
 	instanceKeyMap, err := inst.GetInstanceKeysByTags(tagsString)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
@@ -1891,10 +2302,17 @@ func (this *HttpAPI) Tag(params martini.Params, r render.Render, req *http.Reque
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
+	var expireSeconds int
+	if expire := req.URL.Query().Get("expire"); expire != "" {
+		if expireSeconds, err = util.SimpleTimeToSeconds(expire); err != nil || expireSeconds < 0 {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Invalid expire: %s", expire)})
+			return
+		}
+	}
 	if orcraft.IsRaftEnabled() {
-		_, err = orcraft.PublishCommand("put-instance-tag", inst.InstanceTag{Key: instanceKey, T: *tag})
+		_, err = orcraft.PublishCommand("put-instance-tag", inst.InstanceTag{Key: instanceKey, T: *tag, ExpireSeconds: uint(expireSeconds)})
 	} else {
-		err = inst.PutInstanceTag(&instanceKey, tag)
+		err = inst.PutExpiringInstanceTag(&instanceKey, tag, uint(expireSeconds))
 	}
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
@@ -1930,77 +2348,173 @@ func (this *HttpAPI) Untag(params martini.Params, r render.Render, req *http.Req
 func (this *HttpAPI) UntagAll(params martini.Params, r render.Render, req *http.Request) {
 	tag, err := getTag(params, req)
 	if err != nil {
-		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	untagged, err := inst.Untag(nil, tag)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("%s removed from %+v instances", tag.TagName, len(*untagged)), Details: untagged.GetInstanceKeys()})
+}
+
+// Write a cluster's master (or all clusters masters) to kv stores.
+// This should generally only happen once in a lifetime of a cluster. Otherwise KV
+// stores are updated via failovers.
+func (this *HttpAPI) SubmitMastersToKvStores(params martini.Params, r render.Render, req *http.Request) {
+	clusterName, err := getClusterNameIfExists(params)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	kvPairs, submittedCount, err := logic.SubmitMastersToKvStores(clusterName, true)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Submitted %d masters", submittedCount), Details: kvPairs})
+}
+
+// Clusters provides list of known masters
+func (this *HttpAPI) Masters(params martini.Params, r render.Render, req *http.Request) {
+	instances, err := inst.ReadWriteableClustersMasters()
+
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, instances)
+}
+
+// ClusterMaster returns the writable master of a given cluster
+func (this *HttpAPI) ClusterMaster(params martini.Params, r render.Render, req *http.Request) {
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	masters, err := inst.ReadClusterMaster(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	if len(masters) == 0 {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("No masters found for %+v", clusterName)})
+		return
+	}
+
+	r.JSON(http.StatusOK, masters[0])
+}
+
+// SuggestPromotion returns the replica which would be promoted should the master of given cluster die now,
+// along with reasons for rejecting other replicas. Nothing is changed.
+func (this *HttpAPI) SuggestPromotion(params martini.Params, r render.Render, req *http.Request) {
+	clusterName, err := figureClusterName(params["clusterName"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	masters, err := inst.ReadClusterMaster(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	if len(masters) == 0 {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("No masters found for %+v", clusterName)})
+		return
+	}
+	suggestion, err := logic.SuggestPromotionCandidate(&masters[0].Key)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	message := fmt.Sprintf("No promotion candidate for %+v: %s", masters[0].Key, suggestion.Reason)
+	if suggestion.Candidate != nil {
+		message = fmt.Sprintf("Would promote %+v: %s", suggestion.Candidate.Key, suggestion.Reason)
+	}
+	Respond(r, &APIResponse{Code: OK, Message: message, Details: suggestion})
+}
+
+// FailoverPreflight evaluates whether the master of a cluster could be failed over right now, per precondition
+func (this *HttpAPI) FailoverPreflight(params martini.Params, r render.Render, req *http.Request) {
+	clusterName, err := figureClusterName(params["clusterName"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
 		return
 	}
-	untagged, err := inst.Untag(nil, tag)
+	report, err := logic.CheckFailoverPreconditions(clusterName)
 	if err != nil {
-		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
 		return
 	}
-
-	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("%s removed from %+v instances", tag.TagName, len(*untagged)), Details: untagged.GetInstanceKeys()})
+	Respond(r, &APIResponse{Code: OK, Message: report.Summary(), Details: report})
 }
 
-// Write a cluster's master (or all clusters masters) to kv stores.
-// This should generally only happen once in a lifetime of a cluster. Otherwise KV
-// stores are updated via failovers.
-func (this *HttpAPI) SubmitMastersToKvStores(params martini.Params, r render.Render, req *http.Request) {
-	clusterName, err := getClusterNameIfExists(params)
+// ClusterFanInReport lists the intermediate masters of a cluster with their number of replicas, and suggests
+// relocations which would balance replicas among sibling intermediate masters
+func (this *HttpAPI) ClusterFanInReport(params martini.Params, r render.Render, req *http.Request) {
+	clusterName, err := figureClusterName(params["clusterName"])
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
 		return
 	}
-	kvPairs, submittedCount, err := logic.SubmitMastersToKvStores(clusterName, true)
+	report, err := inst.ClusterFanInReport(clusterName)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
 		return
 	}
-	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Submitted %d masters", submittedCount), Details: kvPairs})
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("%d intermediate masters; %d suggested moves", len(report.Masters), len(report.SuggestedMoves)), Details: report})
 }
 
-// Clusters provides list of known masters
-func (this *HttpAPI) Masters(params martini.Params, r render.Render, req *http.Request) {
-	instances, err := inst.ReadWriteableClustersMasters()
-
+// ApplyRebalance performs the relocations suggested by the cluster's fan-in report, one at a time
+func (this *HttpAPI) ApplyRebalance(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName, err := figureClusterName(params["clusterName"])
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
 		return
 	}
-
-	r.JSON(http.StatusOK, instances)
+	moves, err := inst.ApplyRebalance(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: moves})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Relocated %d replicas of %s", len(moves), clusterName), Details: moves})
 }
 
-// ClusterMaster returns the writable master of a given cluster
-func (this *HttpAPI) ClusterMaster(params martini.Params, r render.Render, req *http.Request) {
-	clusterName, err := figureClusterName(getClusterHint(params))
+// Downtimed lists downtimed instances, potentially filtered by cluster
+func (this *HttpAPI) Downtimed(params martini.Params, r render.Render, req *http.Request) {
+	clusterName, err := getClusterNameIfExists(params)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
 		return
 	}
 
-	masters, err := inst.ReadClusterMaster(clusterName)
+	instances, err := inst.ReadDowntimedInstances(clusterName)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
 		return
 	}
-	if len(masters) == 0 {
-		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("No masters found for %+v", clusterName)})
-		return
-	}
 
-	r.JSON(http.StatusOK, masters[0])
+	r.JSON(http.StatusOK, instances)
 }
 
-// Downtimed lists downtimed instances, potentially filtered by cluster
-func (this *HttpAPI) Downtimed(params martini.Params, r render.Render, req *http.Request) {
+// HealthChecksIgnored lists instances whose health checks are ignored, potentially filtered by cluster
+func (this *HttpAPI) HealthChecksIgnored(params martini.Params, r render.Render, req *http.Request) {
 	clusterName, err := getClusterNameIfExists(params)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
 		return
 	}
 
-	instances, err := inst.ReadDowntimedInstances(clusterName)
+	instances, err := inst.ReadHealthChecksIgnoredInstances(clusterName)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
 		return
@@ -2050,7 +2564,38 @@ func (this *HttpAPI) Problems(params martini.Params, r render.Render, req *http.
 	r.JSON(http.StatusOK, instances)
 }
 
-// Audit provides list of audit entries by given page number
+// getAuditFilter reads audit search query params: instance, cluster, type, from, to, message.
+// Returns nil when none is given.
+func getAuditFilter(req *http.Request) (*inst.AuditFilter, error) {
+	query := req.URL.Query()
+	filter := &inst.AuditFilter{
+		AuditType:     query.Get("type"),
+		FromTimestamp: query.Get("from"),
+		ToTimestamp:   query.Get("to"),
+		MessageMatch:  query.Get("message"),
+	}
+	if instance := query.Get("instance"); instance != "" {
+		instanceKey, err := inst.ParseResolveInstanceKey(instance)
+		if err != nil {
+			return nil, err
+		}
+		filter.InstanceKey = instanceKey
+	}
+	if clusterHint := query.Get("cluster"); clusterHint != "" {
+		clusterName, err := figureClusterName(clusterHint)
+		if err != nil {
+			return nil, err
+		}
+		filter.ClusterName = clusterName
+	}
+	if *filter == (inst.AuditFilter{}) {
+		return nil, nil
+	}
+	return filter, nil
+}
+
+// Audit provides list of audit entries by given page number. When given search query params
+// (see getAuditFilter), it provides a page of matching audit entries along with their total count.
 func (this *HttpAPI) Audit(params martini.Params, r render.Render, req *http.Request) {
 	page, err := strconv.Atoi(params["page"])
 	if err != nil || page < 0 {
@@ -2061,6 +2606,24 @@ func (this *HttpAPI) Audit(params martini.Params, r render.Render, req *http.Req
 		auditedInstanceKey = &instanceKey
 	}
 
+	filter, err := getAuditFilter(req)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	if filter != nil {
+		if auditedInstanceKey != nil {
+			filter.InstanceKey = auditedInstanceKey
+		}
+		auditPage, err := inst.AuditOperationsFiltered(*filter, page)
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+			return
+		}
+		r.JSON(http.StatusOK, auditPage)
+		return
+	}
+
 	audits, err := inst.ReadRecentAudit(auditedInstanceKey, page)
 
 	if err != nil {
@@ -2073,7 +2636,7 @@ func (this *HttpAPI) Audit(params martini.Params, r render.Render, req *http.Req
 
 // HostnameResolveCache shows content of in-memory hostname cache
 func (this *HttpAPI) HostnameResolveCache(params martini.Params, r render.Render, req *http.Request) {
-	content, err := inst.HostnameResolveCache()
+	content, err := inst.ResolveCacheEntries()
 
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
@@ -2099,6 +2662,22 @@ func (this *HttpAPI) ResetHostnameResolveCache(params martini.Params, r render.R
 	Respond(r, &APIResponse{Code: OK, Message: "Hostname cache cleared"})
 }
 
+// FlushResolveHostname purges given hostname from the hostname resolve cache
+func (this *HttpAPI) FlushResolveHostname(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	err := inst.FlushResolveHostname(params["host"])
+
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Hostname resolve flushed: %s", params["host"]), Details: params["host"]})
+}
+
 // DeregisterHostnameUnresolve deregisters the unresolve name used previously
 func (this *HttpAPI) DeregisterHostnameUnresolve(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
@@ -2303,6 +2882,10 @@ func (this *HttpAPI) DiscoveryMetricsRaw(params martini.Params, r render.Render,
 // specified time.
 func (this *HttpAPI) DiscoveryMetricsAggregated(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	seconds, err := strconv.Atoi(params["seconds"])
+	if err != nil || seconds <= 0 {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Invalid value provided for seconds"})
+		return
+	}
 
 	refTime := time.Now().Add(-time.Duration(seconds) * time.Second)
 	aggregated, err := discovery.AggregatedSince(discoveryMetrics, refTime)
@@ -2314,6 +2897,16 @@ func (this *HttpAPI) DiscoveryMetricsAggregated(params martini.Params, r render.
 	r.JSON(http.StatusOK, aggregated)
 }
 
+// getDiscoveryQueue returns the discovery queue named by the "queueName" param, or the
+// default discovery queue when no name is given. Returns nil for an unknown queue.
+func getDiscoveryQueue(params martini.Params) *discovery.Queue {
+	queueName := params["queueName"]
+	if queueName == "" {
+		return discovery.CreateOrReturnQueue(config.DefaultDiscoveryQueue)
+	}
+	return discovery.ReturnQueue(queueName)
+}
+
 // DiscoveryQueueMetricsRaw returns the raw queue metrics (active and
 // queued values), data taken secondly for the last N seconds.
 func (this *HttpAPI) DiscoveryQueueMetricsRaw(params martini.Params, r render.Render, req *http.Request, user auth.User) {
@@ -2324,7 +2917,11 @@ func (this *HttpAPI) DiscoveryQueueMetricsRaw(params martini.Params, r render.Re
 		return
 	}
 
-	queue := discovery.CreateOrReturnQueue("DEFAULT")
+	queue := getDiscoveryQueue(params)
+	if queue == nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unknown discovery queue"})
+		return
+	}
 	metrics := queue.DiscoveryQueueMetrics(seconds)
 	log.Debugf("DiscoveryQueueMetricsRaw data: %+v", metrics)
 
@@ -2342,13 +2939,113 @@ func (this *HttpAPI) DiscoveryQueueMetricsAggregated(params martini.Params, r re
 		return
 	}
 
-	queue := discovery.CreateOrReturnQueue("DEFAULT")
+	queue := getDiscoveryQueue(params)
+	if queue == nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unknown discovery queue"})
+		return
+	}
 	aggregated := queue.AggregatedDiscoveryQueueMetrics(seconds)
 	log.Debugf("DiscoveryQueueMetricsAggregated data: %+v", aggregated)
 
 	r.JSON(http.StatusOK, aggregated)
 }
 
+// DiscoveryQueueStats returns the aggregated wait and processing latencies of recently discovered keys
+func (this *HttpAPI) DiscoveryQueueStats(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	queue := getDiscoveryQueue(params)
+	if queue == nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unknown discovery queue"})
+		return
+	}
+	r.JSON(http.StatusOK, queue.Stats())
+}
+
+// SetDiscoveryMaxConcurrency changes the number of concurrent discoveries at runtime
+func (this *HttpAPI) SetDiscoveryMaxConcurrency(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	maxConcurrency, err := strconv.ParseUint(params["maxConcurrency"], 10, 0)
+	if err != nil || maxConcurrency == 0 {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Invalid value provided for maxConcurrency"})
+		return
+	}
+	logic.SetDiscoveryMaxConcurrency(uint(maxConcurrency))
+	inst.AuditOperation("set-discovery-max-concurrency", nil, fmt.Sprintf("Set to %d", maxConcurrency))
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Discovery max concurrency set to %d", maxConcurrency), Details: maxConcurrency})
+}
+
+// DiscoveryQueue returns the keys waiting on the discovery queue and the keys being discovered
+func (this *HttpAPI) DiscoveryQueue(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	queue := getDiscoveryQueue(params)
+	if queue == nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unknown discovery queue"})
+		return
+	}
+	r.JSON(http.StatusOK, queue.Snapshot())
+}
+
+// PauseDiscovery stops dispatching discovery requests; requests are still queued meanwhile
+func (this *HttpAPI) PauseDiscovery(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	logic.PauseDiscovery()
+	inst.AuditOperation("pause-discovery", nil, "Discovery paused")
+
+	Respond(r, &APIResponse{Code: OK, Message: "Discovery paused", Details: logic.IsDiscoveryPaused()})
+}
+
+// ResumeDiscovery resumes dispatching discovery requests
+func (this *HttpAPI) ResumeDiscovery(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	logic.ResumeDiscovery()
+	inst.AuditOperation("resume-discovery", nil, "Discovery resumed")
+
+	Respond(r, &APIResponse{Code: OK, Message: "Discovery resumed", Details: logic.IsDiscoveryPaused()})
+}
+
+// IsDiscoveryPaused returns whether discovery is paused
+func (this *HttpAPI) IsDiscoveryPaused(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	r.JSON(http.StatusOK, logic.IsDiscoveryPaused())
+}
+
+// DiscoveryQueueFailures returns the keys whose latest discovery failed, along with their last error
+func (this *HttpAPI) DiscoveryQueueFailures(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	queue := getDiscoveryQueue(params)
+	if queue == nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unknown discovery queue"})
+		return
+	}
+	r.JSON(http.StatusOK, queue.Failures())
+}
+
+// DiscoveryQueueDedupeStats returns the number of deduplicated discovery requests and the most frequently deduplicated keys
+func (this *HttpAPI) DiscoveryQueueDedupeStats(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	queue := getDiscoveryQueue(params)
+	if queue == nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unknown discovery queue"})
+		return
+	}
+	r.JSON(http.StatusOK, queue.DedupeStats())
+}
+
+// DiscoveryQueueBackoff returns the keys whose discovery is currently being backed off due to repeated failures
+func (this *HttpAPI) DiscoveryQueueBackoff(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	queue := getDiscoveryQueue(params)
+	if queue == nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unknown discovery queue"})
+		return
+	}
+	r.JSON(http.StatusOK, queue.BackoffKeys())
+}
+
 // BackendQueryMetricsRaw returns the raw backend query metrics
 func (this *HttpAPI) BackendQueryMetricsRaw(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	seconds, err := strconv.Atoi(params["seconds"])
@@ -2924,8 +3621,9 @@ func (this *HttpAPI) ReloadConfiguration(params martini.Params, r render.Render,
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Config reloaded")})
 }
 
-// ReplicationAnalysis retuens list of issues
-func (this *HttpAPI) replicationAnalysis(clusterName string, instanceKey *inst.InstanceKey, params martini.Params, r render.Render, req *http.Request) {
+// replicationAnalysis retuens list of issues, possibly filtered by cluster or instance. When muted, only lists
+// entries muted due to downtime; otherwise lists all entries, muted included.
+func (this *HttpAPI) replicationAnalysis(clusterName string, instanceKey *inst.InstanceKey, muted bool, params martini.Params, r render.Render, req *http.Request) {
 	analysis, err := inst.GetReplicationAnalysis(clusterName, &inst.ReplicationAnalysisHints{IncludeDowntimed: true})
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Cannot get analysis: %+v", err)})
@@ -2941,17 +3639,25 @@ func (this *HttpAPI) replicationAnalysis(clusterName string, instanceKey *inst.I
 		}
 		analysis = filtered
 	}
-
+	if muted {
+		_, mutedAnalysis := inst.SplitMutedAnalysis(analysis)
+		Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Muted analysis"), Details: mutedAnalysis})
+		return
+	}
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Analysis"), Details: analysis})
 }
 
 // ReplicationAnalysis retuens list of issues
 func (this *HttpAPI) ReplicationAnalysis(params martini.Params, r render.Render, req *http.Request) {
-	this.replicationAnalysis("", nil, params, r, req)
+	this.replicationAnalysis("", nil, false, params, r, req)
 }
 
 // ReplicationAnalysis retuens list of issues
 func (this *HttpAPI) ReplicationAnalysisForCluster(params martini.Params, r render.Render, req *http.Request) {
+	this.replicationAnalysisForCluster(false, params, r, req)
+}
+
+func (this *HttpAPI) replicationAnalysisForCluster(muted bool, params martini.Params, r render.Render, req *http.Request) {
 	clusterName := params["clusterName"]
 
 	var err error
@@ -2963,11 +3669,15 @@ func (this *HttpAPI) ReplicationAnalysisForCluster(params martini.Params, r rend
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Cannot get cluster name: %+v", params["clusterName"])})
 		return
 	}
-	this.replicationAnalysis(clusterName, nil, params, r, req)
+	this.replicationAnalysis(clusterName, nil, muted, params, r, req)
 }
 
 // ReplicationAnalysis retuens list of issues
 func (this *HttpAPI) ReplicationAnalysisForKey(params martini.Params, r render.Render, req *http.Request) {
+	this.replicationAnalysisForKey(false, params, r, req)
+}
+
+func (this *HttpAPI) replicationAnalysisForKey(muted bool, params martini.Params, r render.Render, req *http.Request) {
 	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Cannot get analysis: %+v", err)})
@@ -2977,7 +3687,23 @@ func (this *HttpAPI) ReplicationAnalysisForKey(params martini.Params, r render.R
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Cannot get analysis: invalid key %+v", instanceKey)})
 		return
 	}
-	this.replicationAnalysis("", &instanceKey, params, r, req)
+	this.replicationAnalysis("", &instanceKey, muted, params, r, req)
+}
+
+// MutedReplicationAnalysis returns analysis entries suppressed due to downtime: failures of downtimed instances,
+// or of masters whose replicas are all downtimed
+func (this *HttpAPI) MutedReplicationAnalysis(params martini.Params, r render.Render, req *http.Request) {
+	this.replicationAnalysis("", nil, true, params, r, req)
+}
+
+// MutedReplicationAnalysisForCluster returns analysis entries of given cluster suppressed due to downtime
+func (this *HttpAPI) MutedReplicationAnalysisForCluster(params martini.Params, r render.Render, req *http.Request) {
+	this.replicationAnalysisForCluster(true, params, r, req)
+}
+
+// MutedReplicationAnalysisForKey returns analysis entries of given instance suppressed due to downtime
+func (this *HttpAPI) MutedReplicationAnalysisForKey(params martini.Params, r render.Render, req *http.Request) {
+	this.replicationAnalysisForKey(true, params, r, req)
 }
 
 // RecoverLite attempts recovery on a given instance, without executing external processes
@@ -2986,6 +3712,23 @@ func (this *HttpAPI) RecoverLite(params martini.Params, r render.Render, req *ht
 	this.Recover(params, r, req, user)
 }
 
+// acknowledgeRecoveryDisabled requires a forced recovery on given cluster to be explicitly confirmed via
+// acknowledgeRecoveryDisabled=true when recoveries are disabled globally or for the cluster
+func acknowledgeRecoveryDisabled(clusterName string, req *http.Request, user auth.User) error {
+	reason, err := logic.RecoveryDisabledReason(clusterName)
+	if err != nil {
+		return err
+	}
+	if reason == "" {
+		return nil
+	}
+	if req.URL.Query().Get("acknowledgeRecoveryDisabled") != "true" {
+		return fmt.Errorf("Recoveries are %s. Add acknowledgeRecoveryDisabled=true to recover nonetheless", reason)
+	}
+	inst.AuditOperation("bypass-recovery-disabled", nil, fmt.Sprintf("%s forced a recovery on %+v; recoveries %s", getUserId(req, user), clusterName, reason))
+	return nil
+}
+
 // Recover attempts recovery on a given instance
 func (this *HttpAPI) Recover(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
@@ -3002,6 +3745,32 @@ func (this *HttpAPI) Recover(params martini.Params, r render.Render, req *http.R
 		candidateKey = &key
 	}
 
+	instance, _, err := inst.ReadInstance(&instanceKey)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: instanceKey})
+		return
+	}
+	if instance == nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Instance not found", Details: instanceKey})
+		return
+	}
+	blockingRecoveryId, remainingSeconds, err := logic.ReadClusterRecoveryBlock(instance.ClusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: instanceKey})
+		return
+	}
+	if blockingRecoveryId != 0 {
+		if req.URL.Query().Get("acknowledgeRecoveryBlock") != "true" {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Cluster %+v is blocked for another %d seconds by recovery %d (RecoveryPeriodBlockSeconds). Add acknowledgeRecoveryBlock=true to recover nonetheless", instance.ClusterName, remainingSeconds, blockingRecoveryId), Details: instanceKey})
+			return
+		}
+		inst.AuditOperation("bypass-recovery-block", &instanceKey, fmt.Sprintf("%s bypassed block of recovery %d on %+v with %d seconds remaining", getUserId(req, user), blockingRecoveryId, instance.ClusterName, remainingSeconds))
+	}
+	if err := acknowledgeRecoveryDisabled(instance.ClusterName, req, user); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: instanceKey})
+		return
+	}
+
 	skipProcesses := (req.URL.Query().Get("skipProcesses") == "true") || (params["skipProcesses"] == "true")
 	recoveryAttempted, promotedInstanceKey, err := logic.CheckAndRecover(&instanceKey, candidateKey, skipProcesses)
 	if err != nil {
@@ -3019,6 +3788,26 @@ func (this *HttpAPI) Recover(params martini.Params, r render.Render, req *http.R
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Recovery executed on %+v", instanceKey), Details: *promotedInstanceKey})
 }
 
+// RecoverLaggingReplicas relocates replicas still replicating from the failed master of the cluster's latest
+// successful master recovery below the promoted master
+func (this *HttpAPI) RecoverLaggingReplicas(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName, err := figureClusterName(params["clusterName"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	salvagedReplicas, err := logic.RematchClusterStrandedReplicas(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: salvagedReplicas})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Recovered %d lagging replicas in %+v", len(salvagedReplicas), clusterName), Details: salvagedReplicas})
+}
+
 // GracefulMasterTakeover gracefully fails over a master onto its single replica.
 func (this *HttpAPI) GracefulMasterTakeover(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
@@ -3045,7 +3834,8 @@ func (this *HttpAPI) GracefulMasterTakeover(params martini.Params, r render.Rend
 	Respond(r, &APIResponse{Code: OK, Message: "graceful-master-takeover: successor promoted", Details: topologyRecovery})
 }
 
-// ForceMasterFailover fails over a master (even if there's no particular problem with the master)
+// ForceMasterFailover fails over a master (even if there's no particular problem with the master).
+// With dryRun=true, the failover is only simulated: its plan is recorded as a simulated recovery.
 func (this *HttpAPI) ForceMasterFailover(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
 		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
@@ -3056,6 +3846,19 @@ func (this *HttpAPI) ForceMasterFailover(params martini.Params, r render.Render,
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
+	if req.URL.Query().Get("dryRun") == "true" {
+		topologyRecovery, err := logic.SimulateMasterFailover(clusterName)
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: topologyRecovery})
+			return
+		}
+		Respond(r, &APIResponse{Code: OK, Message: "Master failover simulated; nothing executed", Details: topologyRecovery})
+		return
+	}
+	if err := acknowledgeRecoveryDisabled(clusterName, req, user); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
 	topologyRecovery, err := logic.ForceMasterFailover(clusterName)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
@@ -3090,6 +3893,10 @@ func (this *HttpAPI) ForceMasterTakeover(params martini.Params, r render.Render,
 		return
 	}
 
+	if err := acknowledgeRecoveryDisabled(clusterName, req, user); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
 	topologyRecovery, err := logic.ForceMasterTakeover(clusterName, designatedInstance)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
@@ -3215,6 +4022,9 @@ func (this *HttpAPI) AuditRecovery(params martini.Params, r render.Render, req *
 		unacknowledgedOnly := (req.URL.Query().Get("unacknowledged") == "true")
 		audits, err = logic.ReadRecentRecoveries(params["clusterName"], unacknowledgedOnly, page)
 	}
+	if err == nil {
+		err = logic.ReadTopologyRecoveriesTimelines(audits)
+	}
 
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
@@ -3224,6 +4034,29 @@ func (this *HttpAPI) AuditRecovery(params martini.Params, r render.Render, req *
 	r.JSON(http.StatusOK, audits)
 }
 
+// UnacknowledgedRecoveries lists a page of recoveries not yet acknowledged, optionally filtered by cluster
+func (this *HttpAPI) UnacknowledgedRecoveries(params martini.Params, r render.Render, req *http.Request) {
+	clusterName := ""
+	if clusterHint := getClusterHint(params); clusterHint != "" {
+		var err error
+		if clusterName, err = figureClusterName(clusterHint); err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+			return
+		}
+	}
+	page, err := strconv.Atoi(params["page"])
+	if err != nil || page < 0 {
+		page = 0
+	}
+	recoveries, err := logic.ReadUnacknowledgedRecoveries(clusterName, page)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, recoveries)
+}
+
 // ActiveClusterRecovery returns recoveries in-progress for a given cluster
 func (this *HttpAPI) ActiveClusterRecovery(params martini.Params, r render.Render, req *http.Request) {
 	recoveries, err := logic.ReadActiveClusterRecovery(params["clusterName"])
@@ -3501,6 +4334,66 @@ func (this *HttpAPI) CheckGlobalRecoveries(params martini.Params, r render.Rende
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Global recoveries %+v", details), Details: details})
 }
 
+// DisableClusterRecoveries disables recoveries on a given cluster
+func (this *HttpAPI) DisableClusterRecoveries(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("disable-cluster-recoveries", clusterName)
+	} else {
+		err = logic.DisableClusterRecovery(clusterName)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Disabled recoveries on %+v", clusterName), Details: "disabled"})
+}
+
+// EnableClusterRecoveries enables recoveries on a given cluster. Recoveries are still subject to the global toggle.
+func (this *HttpAPI) EnableClusterRecoveries(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("enable-cluster-recoveries", clusterName)
+	} else {
+		err = logic.EnableClusterRecovery(clusterName)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Enabled recoveries on %+v", clusterName), Details: "enabled"})
+}
+
+// RecoveryToggles reports whether recoveries are disabled globally, and the clusters on which they are disabled
+func (this *HttpAPI) RecoveryToggles(params martini.Params, r render.Render, req *http.Request) {
+	toggles, err := logic.ReadRecoveryToggles()
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: "Recovery toggles", Details: toggles})
+}
+
 func (this *HttpAPI) getSynonymPath(path string) (synonymPath string) {
 	pathBase := strings.Split(path, "/")[0]
 	if synonym, ok := apiSynonyms[pathBase]; ok {
@@ -3542,6 +4435,7 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "relocate/:host/:port/:belowHost/:belowPort", this.RelocateBelow)
 	this.registerAPIRequest(m, "relocate-below/:host/:port/:belowHost/:belowPort", this.RelocateBelow)
 	this.registerAPIRequest(m, "relocate-slaves/:host/:port/:belowHost/:belowPort", this.RelocateReplicas)
+	this.registerAPIRequest(m, "apply-rebalance/:clusterName", this.ApplyRebalance)
 	this.registerAPIRequest(m, "regroup-slaves/:host/:port", this.RegroupReplicas)
 
 	// Classic file:pos relocation:
@@ -3550,6 +4444,8 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "move-below/:host/:port/:siblingHost/:siblingPort", this.MoveBelow)
 	this.registerAPIRequest(m, "move-equivalent/:host/:port/:belowHost/:belowPort", this.MoveEquivalent)
 	this.registerAPIRequest(m, "repoint/:host/:port/:belowHost/:belowPort", this.Repoint)
+	this.registerAPIRequest(m, "repoint-to-canonical/:host/:port", this.RepointToCanonical)
+	this.registerAPIRequest(m, "repoint-channel/:host/:port/:channel/:belowHost/:belowPort", this.RepointChannel)
 	this.registerAPIRequest(m, "repoint-slaves/:host/:port", this.RepointReplicas)
 	this.registerAPIRequest(m, "make-co-master/:host/:port", this.MakeCoMaster)
 	this.registerAPIRequest(m, "enslave-siblings/:host/:port", this.TakeSiblings)
@@ -3579,6 +4475,7 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "enable-gtid/:host/:port", this.EnableGTID)
 	this.registerAPIRequest(m, "disable-gtid/:host/:port", this.DisableGTID)
 	this.registerAPIRequest(m, "locate-gtid-errant/:host/:port", this.LocateErrantGTID)
+	this.registerAPIRequest(m, "cluster-gtid-errant/:clusterHint", this.ClusterErrantGTID)
 	this.registerAPIRequest(m, "gtid-errant-reset-master/:host/:port", this.ErrantGTIDResetMaster)
 	this.registerAPIRequest(m, "gtid-errant-inject-empty/:host/:port", this.ErrantGTIDInjectEmpty)
 	this.registerAPIRequest(m, "skip-query/:host/:port", this.SkipQuery)
@@ -3606,6 +4503,8 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	// Instance:
 	this.registerAPIRequest(m, "set-read-only/:host/:port", this.SetReadOnly)
 	this.registerAPIRequest(m, "set-writeable/:host/:port", this.SetWriteable)
+	this.registerAPIRequest(m, "set-cluster-read-only/:clusterHint", this.SetClusterReadOnly)
+	this.registerAPIRequest(m, "set-cluster-writeable-master-only/:clusterHint", this.SetClusterWriteableMasterOnly)
 	this.registerAPIRequest(m, "kill-query/:host/:port/:process", this.KillQuery)
 
 	// Binary logs:
@@ -3637,10 +4536,15 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 
 	this.registerAPIRequest(m, "masters", this.Masters)
 	this.registerAPIRequest(m, "master/:clusterHint", this.ClusterMaster)
+	this.registerAPIRequest(m, "suggest-promotion/:clusterName", this.SuggestPromotion)
+	this.registerAPIRequest(m, "failover-preflight/:clusterName", this.FailoverPreflight)
+	this.registerAPIRequest(m, "cluster-fan-in/:clusterName", this.ClusterFanInReport)
 	this.registerAPIRequest(m, "instance-replicas/:host/:port", this.InstanceReplicas)
 	this.registerAPIRequest(m, "all-instances", this.AllInstances)
 	this.registerAPIRequest(m, "downtimed", this.Downtimed)
 	this.registerAPIRequest(m, "downtimed/:clusterHint", this.Downtimed)
+	this.registerAPIRequest(m, "health-checks-ignored", this.HealthChecksIgnored)
+	this.registerAPIRequest(m, "health-checks-ignored/:clusterHint", this.HealthChecksIgnored)
 	this.registerAPIRequest(m, "topology/:clusterHint", this.AsciiTopology)
 	this.registerAPIRequest(m, "topology/:host/:port", this.AsciiTopology)
 	this.registerAPIRequest(m, "topology-tabulated/:clusterHint", this.AsciiTopologyTabulated)
@@ -3667,9 +4571,13 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "instance/:host/:port", this.Instance)
 	this.registerAPIRequest(m, "discover/:host/:port", this.Discover)
 	this.registerAPIRequest(m, "async-discover/:host/:port", this.AsyncDiscover)
+	this.registerAPIRequest(m, "discover-cluster/:clusterName", this.DiscoverCluster)
+	this.registerAPIRequest(m, "cluster-snapshot-coordinates/:clusterName", this.ClusterSnapshotCoordinates)
+	this.registerAPIRequest(m, "last-snapshot-coordinates/:clusterName", this.LastSnapshotCoordinates)
 	this.registerAPIRequest(m, "refresh/:host/:port", this.Refresh)
 	this.registerAPIRequest(m, "forget/:host/:port", this.Forget)
 	this.registerAPIRequest(m, "forget-cluster/:clusterHint", this.ForgetCluster)
+	this.registerAPIRequest(m, "forget-by-pattern", this.ForgetByPattern)
 	this.registerAPIRequest(m, "begin-maintenance/:host/:port/:owner/:reason", this.BeginMaintenance)
 	this.registerAPIRequest(m, "end-maintenance/:host/:port", this.EndMaintenanceByInstanceKey)
 	this.registerAPIRequest(m, "in-maintenance/:host/:port", this.InMaintenance)
@@ -3678,15 +4586,25 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "begin-downtime/:host/:port/:owner/:reason", this.BeginDowntime)
 	this.registerAPIRequest(m, "begin-downtime/:host/:port/:owner/:reason/:duration", this.BeginDowntime)
 	this.registerAPIRequest(m, "end-downtime/:host/:port", this.EndDowntime)
+	this.registerAPIRequest(m, "extend-downtime/:host/:port/:duration", this.ExtendDowntime)
+	this.registerAPIRequest(m, "begin-downtime-cluster/:clusterName/:owner/:reason", this.BeginDowntimeCluster)
+	this.registerAPIRequest(m, "begin-downtime-cluster/:clusterName/:owner/:reason/:duration", this.BeginDowntimeCluster)
+	this.registerAPIRequest(m, "end-downtime-cluster/:clusterName", this.EndDowntimeCluster)
+	this.registerAPIRequest(m, "ignore-health-checks/:host/:port/:owner/:reason", this.IgnoreHealthChecks)
+	this.registerAPIRequest(m, "unignore-health-checks/:host/:port", this.UnignoreHealthChecks)
 
 	// Recovery:
 	this.registerAPIRequest(m, "replication-analysis", this.ReplicationAnalysis)
 	this.registerAPIRequest(m, "replication-analysis/:clusterName", this.ReplicationAnalysisForCluster)
 	this.registerAPIRequest(m, "replication-analysis/instance/:host/:port", this.ReplicationAnalysisForKey)
+	this.registerAPIRequest(m, "replication-analysis-muted", this.MutedReplicationAnalysis)
+	this.registerAPIRequest(m, "replication-analysis-muted/:clusterName", this.MutedReplicationAnalysisForCluster)
+	this.registerAPIRequest(m, "replication-analysis-muted/instance/:host/:port", this.MutedReplicationAnalysisForKey)
 	this.registerAPIRequest(m, "recover/:host/:port", this.Recover)
 	this.registerAPIRequest(m, "recover/:host/:port/:candidateHost/:candidatePort", this.Recover)
 	this.registerAPIRequest(m, "recover-lite/:host/:port", this.RecoverLite)
 	this.registerAPIRequest(m, "recover-lite/:host/:port/:candidateHost/:candidatePort", this.RecoverLite)
+	this.registerAPIRequest(m, "recover-lagging-replicas/:clusterName", this.RecoverLaggingReplicas)
 	this.registerAPIRequest(m, "graceful-master-takeover/:host/:port", this.GracefulMasterTakeover)
 	this.registerAPIRequest(m, "graceful-master-takeover/:host/:port/:designatedHost/:designatedPort", this.GracefulMasterTakeover)
 	this.registerAPIRequest(m, "graceful-master-takeover/:clusterHint", this.GracefulMasterTakeover)
@@ -3719,11 +4637,18 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "ack-recovery/:recoveryId", this.AcknowledgeRecovery)
 	this.registerAPIRequest(m, "ack-recovery/uid/:uid", this.AcknowledgeRecovery)
 	this.registerAPIRequest(m, "ack-all-recoveries", this.AcknowledgeAllRecoveries)
+	this.registerAPIRequest(m, "unacknowledged-recoveries", this.UnacknowledgedRecoveries)
+	this.registerAPIRequest(m, "unacknowledged-recoveries/:page", this.UnacknowledgedRecoveries)
+	this.registerAPIRequest(m, "unacknowledged-recoveries/cluster/:clusterHint", this.UnacknowledgedRecoveries)
+	this.registerAPIRequest(m, "unacknowledged-recoveries/cluster/:clusterHint/:page", this.UnacknowledgedRecoveries)
 	this.registerAPIRequest(m, "blocked-recoveries", this.BlockedRecoveries)
 	this.registerAPIRequest(m, "blocked-recoveries/cluster/:clusterName", this.BlockedRecoveries)
 	this.registerAPIRequest(m, "disable-global-recoveries", this.DisableGlobalRecoveries)
 	this.registerAPIRequest(m, "enable-global-recoveries", this.EnableGlobalRecoveries)
 	this.registerAPIRequest(m, "check-global-recoveries", this.CheckGlobalRecoveries)
+	this.registerAPIRequest(m, "disable-cluster-recoveries/:clusterHint", this.DisableClusterRecoveries)
+	this.registerAPIRequest(m, "enable-cluster-recoveries/:clusterHint", this.EnableClusterRecoveries)
+	this.registerAPIRequest(m, "recovery-toggles", this.RecoveryToggles)
 
 	// General
 	this.registerAPIRequest(m, "problems", this.Problems)
@@ -3753,6 +4678,7 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequestNoProxy(m, "reload-configuration", this.ReloadConfiguration)
 	this.registerAPIRequestNoProxy(m, "hostname-resolve-cache", this.HostnameResolveCache)
 	this.registerAPIRequestNoProxy(m, "reset-hostname-resolve-cache", this.ResetHostnameResolveCache)
+	this.registerAPIRequestNoProxy(m, "hostname-unresolve/:host", this.FlushResolveHostname)
 	// Meta
 	this.registerAPIRequest(m, "routed-leader-check", this.LeaderCheck)
 	this.registerAPIRequest(m, "reelect", this.Reelect)
@@ -3768,7 +4694,23 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "discovery-metrics-raw/:seconds", this.DiscoveryMetricsRaw)
 	this.registerAPIRequest(m, "discovery-metrics-aggregated/:seconds", this.DiscoveryMetricsAggregated)
 	this.registerAPIRequest(m, "discovery-queue-metrics-raw/:seconds", this.DiscoveryQueueMetricsRaw)
+	this.registerAPIRequest(m, "discovery-queue-metrics-raw/:seconds/:queueName", this.DiscoveryQueueMetricsRaw)
 	this.registerAPIRequest(m, "discovery-queue-metrics-aggregated/:seconds", this.DiscoveryQueueMetricsAggregated)
+	this.registerAPIRequest(m, "discovery-queue-metrics-aggregated/:seconds/:queueName", this.DiscoveryQueueMetricsAggregated)
+	this.registerAPIRequest(m, "discovery-queue-stats", this.DiscoveryQueueStats)
+	this.registerAPIRequest(m, "discovery-queue-stats/:queueName", this.DiscoveryQueueStats)
+	this.registerAPIRequest(m, "discovery-queue", this.DiscoveryQueue)
+	this.registerAPIRequest(m, "discovery-queue/:queueName", this.DiscoveryQueue)
+	this.registerAPIRequest(m, "discovery-queue-failures", this.DiscoveryQueueFailures)
+	this.registerAPIRequest(m, "discovery-queue-failures/:queueName", this.DiscoveryQueueFailures)
+	this.registerAPIRequest(m, "discovery-queue-dedupe-stats", this.DiscoveryQueueDedupeStats)
+	this.registerAPIRequest(m, "discovery-queue-dedupe-stats/:queueName", this.DiscoveryQueueDedupeStats)
+	this.registerAPIRequest(m, "discovery-queue-backoff", this.DiscoveryQueueBackoff)
+	this.registerAPIRequest(m, "discovery-queue-backoff/:queueName", this.DiscoveryQueueBackoff)
+	this.registerAPIRequest(m, "set-discovery-max-concurrency/:maxConcurrency", this.SetDiscoveryMaxConcurrency)
+	this.registerAPIRequest(m, "pause-discovery", this.PauseDiscovery)
+	this.registerAPIRequest(m, "resume-discovery", this.ResumeDiscovery)
+	this.registerAPIRequest(m, "is-discovery-paused", this.IsDiscoveryPaused)
 	this.registerAPIRequest(m, "backend-query-metrics-raw/:seconds", this.BackendQueryMetricsRaw)
 	this.registerAPIRequest(m, "backend-query-metrics-aggregated/:seconds", this.BackendQueryMetricsAggregated)
 
@@ -53,3 +53,10 @@ func TestKnownPaths(t *testing.T) {
 		test.S(t).ExpectTrue(pathsMap[synonym])
 	}
 }
+
+func TestGetDiscoveryQueue(t *testing.T) {
+	defaultQueue := getDiscoveryQueue(martini.Params{})
+	test.S(t).ExpectTrue(defaultQueue != nil)
+	test.S(t).ExpectTrue(getDiscoveryQueue(martini.Params{"queueName": config.DefaultDiscoveryQueue}) == defaultQueue)
+	test.S(t).ExpectTrue(getDiscoveryQueue(martini.Params{"queueName": "no-such-queue"}) == nil)
+}
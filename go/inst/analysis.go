@@ -18,6 +18,8 @@ package inst
 
 import (
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 
 	"github.com/github/orchestrator/go/config"
@@ -53,6 +55,23 @@ const (
 	AllIntermediateMasterSlavesNotReplicating                          = "AllIntermediateMasterSlavesNotReplicating"
 	FirstTierSlaveFailingToConnectToMaster                             = "FirstTierSlaveFailingToConnectToMaster"
 	BinlogServerFailingToConnectToMaster                               = "BinlogServerFailingToConnectToMaster"
+	DuplicateServerID                                                  = "DuplicateServerID"
+	LockedSemiSyncMaster                                               = "LockedSemiSyncMaster"
+	BlockedMaster                                                      = "BlockedMaster"
+	LowBinlogRetentionMargin                                           = "LowBinlogRetentionMargin"
+	ReplicationFiltersInUnfilteredCluster                              = "ReplicationFiltersInUnfilteredCluster"
+	ReplicaBinlogMissingOnMaster                                       = "ReplicaBinlogMissingOnMaster"
+	MasterHostDrift                                                    = "MasterHostDrift"
+	BrokenReplicationChannel                                           = "BrokenReplicationChannel"
+	LaggingReplicationChannel                                          = "LaggingReplicationChannel"
+	ReplicaHeartbeatStalled                                            = "ReplicaHeartbeatStalled"
+	NoLogSlaveUpdatesOnIntermediateMasters                             = "NoLogSlaveUpdatesOnIntermediateMasters"
+	MixedFlavorsInCluster                                              = "MixedFlavorsInCluster"
+	ReplicaPointsToWrongEndpoint                                       = "ReplicaPointsToWrongEndpoint"
+	ReplicaWithEnabledEvents                                           = "ReplicaWithEnabledEvents"
+	CircularReplication                                                = "CircularReplication"
+	PlaintextReplication                                               = "PlaintextReplication"
+	ResurrectedOldMaster                                               = "ResurrectedOldMaster"
 )
 
 const (
@@ -101,55 +120,93 @@ const (
 
 // ReplicationAnalysis notes analysis on replication chain status, per instance
 type ReplicationAnalysis struct {
-	AnalyzedInstanceKey                       InstanceKey
-	AnalyzedInstanceMasterKey                 InstanceKey
-	ClusterDetails                            ClusterInfo
-	AnalyzedInstanceDataCenter                string
-	AnalyzedInstanceRegion                    string
-	AnalyzedInstancePhysicalEnvironment       string
-	IsMaster                                  bool
-	IsCoMaster                                bool
-	LastCheckValid                            bool
-	LastCheckPartialSuccess                   bool
-	CountReplicas                             uint
-	CountValidReplicas                        uint
-	CountValidReplicatingReplicas             uint
-	CountReplicasFailingToConnectToMaster     uint
-	CountDowntimedReplicas                    uint
-	ReplicationDepth                          uint
-	SlaveHosts                                InstanceKeyMap
-	IsFailingToConnectToMaster                bool
-	Analysis                                  AnalysisCode
-	Description                               string
-	StructureAnalysis                         []StructureAnalysisCode
-	IsDowntimed                               bool
-	IsReplicasDowntimed                       bool // as good as downtimed because all replicas are downtimed AND analysis is all about the replicas (e.e. AllMasterSlavesNotReplicating)
-	DowntimeEndTimestamp                      string
-	DowntimeRemainingSeconds                  int
-	IsBinlogServer                            bool
-	PseudoGTIDImmediateTopology               bool
-	OracleGTIDImmediateTopology               bool
-	MariaDBGTIDImmediateTopology              bool
-	BinlogServerImmediateTopology             bool
-	CountLoggingReplicas                      uint
-	CountStatementBasedLoggingReplicas        uint
-	CountMixedBasedLoggingReplicas            uint
-	CountRowBasedLoggingReplicas              uint
-	CountDistinctMajorVersionsLoggingReplicas uint
-	CountDelayedReplicas                      uint
-	CountLaggingReplicas                      uint
-	IsActionableRecovery                      bool
-	ProcessingNodeHostname                    string
-	ProcessingNodeToken                       string
-	CountAdditionalAgreeingNodes              int
-	StartActivePeriod                         string
-	SkippableDueToDowntime                    bool
-	GTIDMode                                  string
-	MinReplicaGTIDMode                        string
-	MaxReplicaGTIDMode                        string
-	MaxReplicaGTIDErrant                      string
-	CommandHint                               string
-	IsReadOnly                                bool
+	AnalyzedInstanceKey                          InstanceKey
+	AnalyzedInstanceMasterKey                    InstanceKey
+	ClusterDetails                               ClusterInfo
+	AnalyzedInstanceDataCenter                   string
+	AnalyzedInstanceRegion                       string
+	AnalyzedInstancePhysicalEnvironment          string
+	IsMaster                                     bool
+	IsCoMaster                                   bool
+	LastCheckValid                               bool
+	LastCheckPartialSuccess                      bool
+	IsStale                                      bool
+	CountReplicas                                uint
+	CountStaleReplicas                           uint
+	CountValidReplicas                           uint
+	CountValidReplicatingReplicas                uint
+	CountStaleHeartbeatReplicas                  uint
+	DeadMasterReplicasQuorum                     uint
+	ValidReplicatingReplicasPerDataCenter        map[string]uint
+	CountReplicasFailingToConnectToMaster        uint
+	CountDowntimedReplicas                       uint
+	CountDowntimedValidReplicas                  uint
+	CountDowntimedValidReplicatingReplicas       uint
+	CountDowntimedStaleHeartbeatReplicas         uint
+	ReplicationDepth                             uint
+	SlaveHosts                                   InstanceKeyMap
+	IsFailingToConnectToMaster                   bool
+	Analysis                                     AnalysisCode
+	Description                                  string
+	StructureAnalysis                            []StructureAnalysisCode
+	IsDowntimed                                  bool
+	IsReplicasDowntimed                          bool // as good as downtimed because all replicas are downtimed AND analysis is all about the replicas (e.e. AllMasterSlavesNotReplicating)
+	DowntimeEndTimestamp                         string
+	DowntimeRemainingSeconds                     int
+	DowntimeOwner                                string
+	DowntimeReason                               string
+	IsBinlogServer                               bool
+	PseudoGTIDImmediateTopology                  bool
+	OracleGTIDImmediateTopology                  bool
+	MariaDBGTIDImmediateTopology                 bool
+	BinlogServerImmediateTopology                bool
+	GTIDWithBinlogServersImmediateTopology       bool // replicas are GTID replicas and binlog servers, both
+	CountBinlogServerSubReplicas                 uint // replicas of binlog servers replicating from this instance
+	CountValidBinlogServerSubReplicas            uint
+	CountValidReplicatingBinlogServerSubReplicas uint
+	IsReplicationGroupMember                     bool
+	IsReplicationGroupSecondary                  bool
+	CountLoggingReplicas                         uint
+	CountStatementBasedLoggingReplicas           uint
+	CountMixedBasedLoggingReplicas               uint
+	CountRowBasedLoggingReplicas                 uint
+	CountDistinctMajorVersionsLoggingReplicas    uint
+	CountDelayedReplicas                         uint
+	CountLaggingReplicas                         uint
+	IsActionableRecovery                         bool
+	ProcessingNodeHostname                       string
+	ProcessingNodeToken                          string
+	CountAdditionalAgreeingNodes                 int
+	StartActivePeriod                            string
+	SkippableDueToDowntime                       bool
+	GTIDMode                                     string
+	MinReplicaGTIDMode                           string
+	MaxReplicaGTIDMode                           string
+	MaxReplicaGTIDErrant                         string
+	CommandHint                                  string
+	IsReadOnly                                   bool
+	SemiSyncMasterEnabled                        bool
+	SemiSyncMasterStatus                         bool
+	SemiSyncMasterWaitForReplicaCount            uint
+	SemiSyncMasterClients                        uint
+	MasterWritesBlocked                          bool
+	DuplicateServerIDInstances                   InstanceKeyMap
+	ReplicaBinlogFile                            string
+	MasterOldestBinlogFile                       string
+	RawMasterKey                                 InstanceKey
+	ActualMasterKey                              InstanceKey
+	HeartbeatPeriodSeconds                       float64
+	SecondsSinceLastHeartbeat                    int64
+	NoLogSlaveUpdatesInstances                   InstanceKeyMap
+	ClusterFlavorNames                           []string
+	CanonicalMasterKey                           InstanceKey
+	CountEnabledEvents                           uint
+	BinlogRetentionMarginFiles                   int
+	CircularReplicationMembers                   []InstanceKey
+	PlaintextReplicationInstances                InstanceKeyMap
+	MostCommonReplicaErrno                       int
+	MostCommonReplicaError                       string
+	CountReplicasWithMostCommonError             uint
 }
 
 type AnalysisMap map[string](*ReplicationAnalysis)
@@ -165,6 +222,21 @@ func (this *ReplicationAnalysis) ReadReplicaHostsFromString(replicaHostsString s
 	return this.SlaveHosts.ReadCommaDelimitedList(replicaHostsString)
 }
 
+// ValidReplicatingReplicasPerDataCenterString returns a "dc:count" comma delimited listing of valid
+// replicating replicas per data center, sorted by data center
+func (this *ReplicationAnalysis) ValidReplicatingReplicasPerDataCenterString() string {
+	dataCenters := []string{}
+	for dataCenter := range this.ValidReplicatingReplicasPerDataCenter {
+		dataCenters = append(dataCenters, dataCenter)
+	}
+	sort.Strings(dataCenters)
+	result := []string{}
+	for _, dataCenter := range dataCenters {
+		result = append(result, fmt.Sprintf("%s:%d", dataCenter, this.ValidReplicatingReplicasPerDataCenter[dataCenter]))
+	}
+	return strings.Join(result, ",")
+}
+
 // AnalysisString returns a human friendly description of all analysis issues
 func (this *ReplicationAnalysis) AnalysisString() string {
 	result := []string{}
@@ -177,6 +249,111 @@ func (this *ReplicationAnalysis) AnalysisString() string {
 	return strings.Join(result, ", ")
 }
 
+// HasStructureAnalysis returns true when given structure analysis code applies to the analyzed instance
+func (this *ReplicationAnalysis) HasStructureAnalysis(code StructureAnalysisCode) bool {
+	for _, structureAnalysis := range this.StructureAnalysis {
+		if structureAnalysis == code {
+			return true
+		}
+	}
+	return false
+}
+
+// DowntimeString returns a human readable description of the analyzed instance's downtime, or an empty string
+// when it is not downtimed
+func (this *ReplicationAnalysis) DowntimeString() string {
+	if !this.IsDowntimed {
+		return ""
+	}
+	return fmt.Sprintf("downtimed by %s until %s (%ds remaining): %s", this.DowntimeOwner, this.DowntimeEndTimestamp, this.DowntimeRemainingSeconds, this.DowntimeReason)
+}
+
+// IsBasedOnStaleData returns true when the analyzed instance or any of its replicas has not been polled
+// recently. Such analysis may reflect orchestrator's own absence rather than a topology failure.
+func (this *ReplicationAnalysis) IsBasedOnStaleData() bool {
+	return this.IsStale || this.CountStaleReplicas > 0
+}
+
+// SplitMutedAnalysis separates analysis entries which are muted, i.e. suppressed due to downtime (see
+// SkippableDueToDowntime), from the rest
+func SplitMutedAnalysis(analysisEntries []ReplicationAnalysis) (active []ReplicationAnalysis, muted []ReplicationAnalysis) {
+	active = []ReplicationAnalysis{}
+	muted = []ReplicationAnalysis{}
+	for _, analysisEntry := range analysisEntries {
+		if analysisEntry.SkippableDueToDowntime {
+			muted = append(muted, analysisEntry)
+		} else {
+			active = append(active, analysisEntry)
+		}
+	}
+	return active, muted
+}
+
+// CountBrokenReplicas returns the number of reachable replicas which are either not replicating, or are
+// replicating yet with a stale heartbeat, i.e. in practice they receive nothing from their master
+func (this *ReplicationAnalysis) CountBrokenReplicas() uint {
+	return this.CountValidReplicas - this.CountValidReplicatingReplicas + this.CountStaleHeartbeatReplicas
+}
+
+// mostCommonReplicationError returns the replication error shared by most of given replicas which are not
+// replicating. A stopped SQL thread's error takes precedence over an IO thread's error, which has no number.
+// Replicas stopped without error are not counted.
+func mostCommonReplicationError(replicas [](*Instance)) (errno int, message string, count uint) {
+	type replicationError struct {
+		errno   int
+		message string
+		count   uint
+	}
+	replicationErrors := make(map[string]*replicationError)
+	for _, replica := range replicas {
+		if replica.ReplicaRunning() {
+			continue
+		}
+		var errorKey string
+		replicaError := &replicationError{}
+		if replica.ReplicationSQLThreadState != ReplicationThreadStateRunning && replica.LastSQLError != "" {
+			// same error number, different messages (e.g. relay log positions): counted as same error
+			errorKey = fmt.Sprintf("sql:%d", replica.LastSQLErrno)
+			if replica.LastSQLErrno == 0 {
+				errorKey = fmt.Sprintf("sql:%s", replica.LastSQLError)
+			}
+			replicaError.errno, replicaError.message = replica.LastSQLErrno, replica.LastSQLError
+		} else if replica.LastIOError != "" {
+			errorKey = fmt.Sprintf("io:%s", replica.LastIOError)
+			replicaError.message = replica.LastIOError
+		} else {
+			continue
+		}
+		if _, found := replicationErrors[errorKey]; !found {
+			replicationErrors[errorKey] = replicaError
+		}
+		replicationErrors[errorKey].count++
+		if replicationErrors[errorKey].count > count {
+			errno, message, count = replicationErrors[errorKey].errno, replicationErrors[errorKey].message, replicationErrors[errorKey].count
+		}
+	}
+	return errno, message, count
+}
+
+// deadMasterReplicasQuorum returns the number of broken replicas, out of given count of reachable replicas,
+// required to analyze an unreachable master as dead, per DeadMasterReplicasQuorumFraction
+func deadMasterReplicasQuorum(countValidReplicas uint) uint {
+	// Tolerate floating point error, e.g. 0.3 * 10 computing as 3.0000000000000004
+	quorum := uint(math.Ceil(config.Config.DeadMasterReplicasQuorumFraction*float64(countValidReplicas) - 1e-9))
+	if quorum == 0 {
+		quorum = 1
+	}
+	return quorum
+}
+
+// StaleInstanceSeconds returns the time since last check after which an instance's data is considered stale
+func StaleInstanceSeconds() uint {
+	if config.Config.StaleInstancePollMultiplier == 0 {
+		return config.Config.InstancePollSeconds
+	}
+	return config.Config.InstancePollSeconds * config.Config.StaleInstancePollMultiplier
+}
+
 // ValidSecondsFromSeenToLastAttemptedCheck returns the maximum allowed elapsed time
 // between last_attempted_check to last_checked before we consider the instance as invalid.
 func ValidSecondsFromSeenToLastAttemptedCheck() uint {
@@ -19,6 +19,7 @@ package inst
 import (
 	"fmt"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/github/orchestrator/go/config"
@@ -51,11 +52,155 @@ func initializeAnalysisDaoPostConfiguration() {
 	recentInstantAnalysis = cache.New(time.Duration(config.RecoveryPollSeconds*2)*time.Second, time.Second)
 }
 
+// gracefulMasterTakeoverExpiryMinutes is the time after which a graceful master takeover mark, which was never
+// cleared, no longer applies
+const gracefulMasterTakeoverExpiryMinutes = 60
+
+// BeginGracefulMasterTakeover marks given cluster as undergoing a graceful master takeover, during which its
+// master is deliberately read_only. The mark is kept in the backend, and so applies to all orchestrator nodes.
+func BeginGracefulMasterTakeover(clusterName string) error {
+	_, err := db.ExecOrchestrator(`
+			insert
+				into graceful_master_takeover (
+					cluster_name, begin_timestamp
+				) values (
+					?, NOW()
+				)
+				on duplicate key update
+					begin_timestamp=values(begin_timestamp)
+			`,
+		clusterName,
+	)
+	return log.Errore(err)
+}
+
+// EndGracefulMasterTakeover clears the mark set by BeginGracefulMasterTakeover
+func EndGracefulMasterTakeover(clusterName string) error {
+	_, err := db.ExecOrchestrator(`
+			delete from graceful_master_takeover where cluster_name = ?
+			`,
+		clusterName,
+	)
+	return log.Errore(err)
+}
+
+// readGracefulMasterTakeoverClusters returns the clusters marked by BeginGracefulMasterTakeover
+func readGracefulMasterTakeoverClusters() (clusters map[string]bool, err error) {
+	clusters = make(map[string]bool)
+	query := `
+		select
+			cluster_name
+		from
+			graceful_master_takeover
+		where
+			begin_timestamp > NOW() - interval ? minute
+		`
+	err = db.QueryOrchestrator(query, sqlutils.Args(gracefulMasterTakeoverExpiryMinutes), func(m sqlutils.RowMap) error {
+		clusters[m.GetString("cluster_name")] = true
+		return nil
+	})
+	return clusters, log.Errore(err)
+}
+
+// readOnlyMastersFirstSeen maps masters found to be read_only to the time they were first found so. It is kept
+// in the backend, such that it survives a restart or a change of leader.
+type readOnlyMastersFirstSeen struct {
+	firstSeen map[InstanceKey]time.Time
+	// changed lists masters since found to be read_only, or no longer so, yet to be written to the backend
+	changed map[InstanceKey]bool
+}
+
+func newReadOnlyMastersFirstSeen() *readOnlyMastersFirstSeen {
+	return &readOnlyMastersFirstSeen{
+		firstSeen: make(map[InstanceKey]time.Time),
+		changed:   make(map[InstanceKey]bool),
+	}
+}
+
+// readReadOnlyMastersFirstSeen reads the masters found to be read_only from the backend
+func readReadOnlyMastersFirstSeen() (*readOnlyMastersFirstSeen, error) {
+	readOnlyMasters := newReadOnlyMastersFirstSeen()
+	query := `
+		select
+			hostname,
+			port,
+			unix_timestamp(first_seen_timestamp) as first_seen_unixtime
+		from
+			read_only_master_first_seen
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(), func(m sqlutils.RowMap) error {
+		instanceKey := InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")}
+		readOnlyMasters.firstSeen[instanceKey] = time.Unix(m.GetInt64("first_seen_unixtime"), 0)
+		return nil
+	})
+	return readOnlyMasters, log.Errore(err)
+}
+
+// writeReadOnlyMastersFirstSeen writes the changes noted by readOnlyMasterSince to the backend
+func writeReadOnlyMastersFirstSeen(readOnlyMasters *readOnlyMastersFirstSeen) error {
+	for instanceKey := range readOnlyMasters.changed {
+		var err error
+		if _, found := readOnlyMasters.firstSeen[instanceKey]; found {
+			// Found read_only during this very analysis
+			_, err = db.ExecOrchestrator(`
+					insert ignore
+						into read_only_master_first_seen (
+							hostname, port, first_seen_timestamp
+						) values (
+							?, ?, NOW()
+						)
+					`,
+				instanceKey.Hostname, instanceKey.Port,
+			)
+		} else {
+			_, err = db.ExecOrchestrator(`
+					delete from read_only_master_first_seen where hostname = ? and port = ?
+					`,
+				instanceKey.Hostname, instanceKey.Port,
+			)
+		}
+		if err != nil {
+			return log.Errore(err)
+		}
+		delete(readOnlyMasters.changed, instanceKey)
+	}
+	return nil
+}
+
+// readOnlyMasterSince returns the time since which the analyzed instance, a master with replicas, is known to be
+// read_only, or zero time if it is not
+func readOnlyMasterSince(a *ReplicationAnalysis, readOnlyMasters *readOnlyMastersFirstSeen) time.Time {
+	since, found := readOnlyMasters.firstSeen[a.AnalyzedInstanceKey]
+	if !a.IsMaster || a.CountReplicas == 0 || !a.IsReadOnly || a.IsReplicationGroupSecondary {
+		if found {
+			delete(readOnlyMasters.firstSeen, a.AnalyzedInstanceKey)
+			readOnlyMasters.changed[a.AnalyzedInstanceKey] = true
+		}
+		return time.Time{}
+	}
+	if found {
+		return since
+	}
+	since = time.Now()
+	readOnlyMasters.firstSeen[a.AnalyzedInstanceKey] = since
+	readOnlyMasters.changed[a.AnalyzedInstanceKey] = true
+	return since
+}
+
+// isNoWriteableMaster returns true when the analyzed master has been read_only for longer than a polling cycle.
+// A downtimed master, or one undergoing a graceful takeover, is expected to be read_only.
+func isNoWriteableMaster(a *ReplicationAnalysis, readOnlySince time.Time, gracefulMasterTakeover bool, now time.Time) bool {
+	if readOnlySince.IsZero() || a.IsDowntimed || gracefulMasterTakeover {
+		return false
+	}
+	return now.Sub(readOnlySince) > time.Duration(config.Config.InstancePollSeconds)*time.Second
+}
+
 // GetReplicationAnalysis will check for replication problems (dead master; unreachable master; etc)
 func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints) ([]ReplicationAnalysis, error) {
 	result := []ReplicationAnalysis{}
 
-	args := sqlutils.Args(ValidSecondsFromSeenToLastAttemptedCheck(), config.Config.ReasonableReplicationLagSeconds, clusterName)
+	args := sqlutils.Args(ValidSecondsFromSeenToLastAttemptedCheck(), StaleInstanceSeconds(), StaleInstanceSeconds(), config.Config.ReasonableReplicationLagSeconds, clusterName)
 	analysisQueryReductionClause := ``
 
 	if config.Config.ReduceReplicationAnalysisCount {
@@ -82,6 +227,11 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 		            AND master_instance.last_io_error like '%error %connecting to master%'
 		          ) /* AS is_failing_to_connect_to_master */)
 				OR (COUNT(replica_instance.server_id) /* AS count_replicas */ > 0)
+				OR (MIN(
+						master_instance.semi_sync_master_enabled
+						AND master_instance.semi_sync_master_clients < master_instance.semi_sync_master_wait_for_replica_count
+					) /* AS is_semi_sync_master_missing_replicas */)
+				OR (MIN(master_instance.master_writes_blocked) /* AS master_writes_blocked */)
 			`
 		args = append(args, ValidSecondsFromSeenToLastAttemptedCheck())
 	}
@@ -104,6 +254,9 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 				and master_instance.last_attempted_check <= master_instance.last_seen + interval ? second
 		        	) = 1 AS is_last_check_valid,
 						MIN(master_instance.last_check_partial_success) as last_check_partial_success,
+						MIN(master_instance.last_checked < now() - interval ? second) AS is_stale,
+						IFNULL(SUM(replica_instance.last_checked < now() - interval ? second),
+							0) AS count_stale_replicas,
 		        MIN(master_instance.master_host IN ('' , '_')
 		            OR master_instance.master_port = 0
 								OR substr(master_instance.master_host, 1, 2) = '//') AS is_master,
@@ -112,6 +265,14 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 		                ':',
 		                master_instance.port) = master_instance.cluster_name) AS is_cluster_master,
 						MIN(master_instance.gtid_mode) AS gtid_mode,
+						MIN(master_instance.semi_sync_master_enabled) AS semi_sync_master_enabled,
+						MIN(master_instance.semi_sync_master_status) AS semi_sync_master_status,
+						MIN(master_instance.semi_sync_master_wait_for_replica_count) AS semi_sync_master_wait_for_replica_count,
+						MIN(master_instance.semi_sync_master_clients) AS semi_sync_master_clients,
+						MIN(master_instance.master_writes_blocked) AS master_writes_blocked,
+						MIN(master_instance.replication_group_name != '') AS is_replication_group_member,
+						MIN(master_instance.replication_group_name != ''
+							AND master_instance.replication_group_member_role != 'PRIMARY') AS is_replication_group_secondary,
 		        COUNT(replica_instance.server_id) AS count_replicas,
 		        IFNULL(SUM(replica_instance.last_checked <= replica_instance.last_seen),
 		                0) AS count_valid_slaves,
@@ -119,6 +280,12 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 		                    AND replica_instance.slave_io_running != 0
 		                    AND replica_instance.slave_sql_running != 0),
 		                0) AS count_valid_replicating_slaves,
+		        IFNULL(SUM(replica_instance.last_checked <= replica_instance.last_seen
+		                    AND replica_instance.slave_io_running != 0
+		                    AND replica_instance.slave_sql_running != 0
+		                    AND replica_instance.heartbeat_period > 0
+		                    AND replica_instance.seconds_since_last_heartbeat > 2 * replica_instance.heartbeat_period),
+		                0) AS count_stale_heartbeat_replicas,
 		        IFNULL(SUM(replica_instance.last_checked <= replica_instance.last_seen
 		                    AND replica_instance.slave_io_running = 0
 		                    AND replica_instance.last_io_error like '%%error %%connecting to master%%'
@@ -139,8 +306,14 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 				    		IFNULL(master_downtime.end_timestamp, '')
 				    	) AS downtime_end_timestamp,
 			    	MIN(
-				    		IFNULL(unix_timestamp() - unix_timestamp(master_downtime.end_timestamp), 0)
+				    		IFNULL(unix_timestamp(master_downtime.end_timestamp) - unix_timestamp(), 0)
 				    	) AS downtime_remaining_seconds,
+			    	MIN(
+				    		IFNULL(master_downtime.owner, '')
+				    	) AS downtime_owner,
+			    	MIN(
+				    		IFNULL(master_downtime.reason, '')
+				    	) AS downtime_reason,
 			    	MIN(
 				    		master_instance.binlog_server
 				    	) AS is_binlog_server,
@@ -188,7 +361,8 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
               0) AS count_row_based_loggin_slaves,
 						IFNULL(SUM(replica_instance.sql_delay > 0),
               0) AS count_delayed_replicas,
-						IFNULL(SUM(replica_instance.slave_lag_seconds > ?),
+						IFNULL(SUM(replica_instance.sql_delay = 0
+								AND replica_instance.slave_lag_seconds > ?),
               0) AS count_lagging_replicas,
 						IFNULL(MIN(replica_instance.gtid_mode), '')
               AS min_replica_gtid_mode,
@@ -204,6 +378,27 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 								replica_downtime.downtime_active is not null
 								and ifnull(replica_downtime.end_timestamp, now()) > now()),
               0) AS count_downtimed_replicas,
+						IFNULL(SUM(
+								replica_downtime.downtime_active is not null
+								and ifnull(replica_downtime.end_timestamp, now()) > now()
+								and replica_instance.last_checked <= replica_instance.last_seen),
+              0) AS count_downtimed_valid_replicas,
+						IFNULL(SUM(
+								replica_downtime.downtime_active is not null
+								and ifnull(replica_downtime.end_timestamp, now()) > now()
+								and replica_instance.last_checked <= replica_instance.last_seen
+								and replica_instance.slave_io_running != 0
+								and replica_instance.slave_sql_running != 0),
+              0) AS count_downtimed_valid_replicating_replicas,
+						IFNULL(SUM(
+								replica_downtime.downtime_active is not null
+								and ifnull(replica_downtime.end_timestamp, now()) > now()
+								and replica_instance.last_checked <= replica_instance.last_seen
+								and replica_instance.slave_io_running != 0
+								and replica_instance.slave_sql_running != 0
+								and replica_instance.heartbeat_period > 0
+								and replica_instance.seconds_since_last_heartbeat > 2 * replica_instance.heartbeat_period),
+              0) AS count_downtimed_stale_heartbeat_replicas,
 						COUNT(DISTINCT case
 								when replica_instance.log_bin AND replica_instance.log_slave_updates
 								then replica_instance.major_version
@@ -217,7 +412,11 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
           LEFT JOIN
 		        database_instance replica_instance ON (COALESCE(hostname_resolve.resolved_hostname,
               master_instance.hostname) = replica_instance.master_host
-							AND master_instance.port = replica_instance.master_port)
+							AND master_instance.port = replica_instance.master_port
+							AND NOT EXISTS (
+								SELECT 1 FROM database_instance_ignore_health_checks
+								WHERE database_instance_ignore_health_checks.hostname = replica_instance.hostname
+									AND database_instance_ignore_health_checks.port = replica_instance.port))
           LEFT JOIN
 		        database_instance_maintenance ON (master_instance.hostname = database_instance_maintenance.hostname
 							AND master_instance.port = database_instance_maintenance.port
@@ -232,8 +431,12 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 							AND replica_downtime.downtime_active = 1)
         	LEFT JOIN
 		        cluster_alias ON (cluster_alias.cluster_name = master_instance.cluster_name)
+					LEFT JOIN
+		        database_instance_ignore_health_checks as master_ignore ON (master_instance.hostname = master_ignore.hostname
+							AND master_instance.port = master_ignore.port)
 		    WHERE
 		    	database_instance_maintenance.database_instance_maintenance_id IS NULL
+		    	AND master_ignore.hostname IS NULL
 		    	AND ? IN ('', master_instance.cluster_name)
 		    GROUP BY
 			    master_instance.hostname,
@@ -245,7 +448,23 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 			    count_replicas DESC
 	`, analysisQueryReductionClause)
 
-	err := db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+	binlogServerSubReplicasCounts, err := readBinlogServerSubReplicasCounts(clusterName)
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	validReplicatingReplicasPerDataCenter, err := readValidReplicatingReplicasPerDataCenter(clusterName)
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	gracefulMasterTakeoverClusters, err := readGracefulMasterTakeoverClusters()
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	readOnlyMasters, err := readReadOnlyMastersFirstSeen()
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
 		a := ReplicationAnalysis{
 			Analysis:               NoProblem,
 			ProcessingNodeHostname: process.ThisHostname,
@@ -264,16 +483,28 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 		a.GTIDMode = m.GetString("gtid_mode")
 		a.LastCheckValid = m.GetBool("is_last_check_valid")
 		a.LastCheckPartialSuccess = m.GetBool("last_check_partial_success")
+		a.IsStale = m.GetBool("is_stale")
+		a.CountStaleReplicas = m.GetUint("count_stale_replicas")
 		a.CountReplicas = m.GetUint("count_replicas")
 		a.CountValidReplicas = m.GetUint("count_valid_slaves")
 		a.CountValidReplicatingReplicas = m.GetUint("count_valid_replicating_slaves")
+		a.CountStaleHeartbeatReplicas = m.GetUint("count_stale_heartbeat_replicas")
+		a.ValidReplicatingReplicasPerDataCenter = validReplicatingReplicasPerDataCenter[a.AnalyzedInstanceKey]
+		if a.ValidReplicatingReplicasPerDataCenter == nil {
+			a.ValidReplicatingReplicasPerDataCenter = make(map[string]uint)
+		}
 		a.CountReplicasFailingToConnectToMaster = m.GetUint("count_replicas_failing_to_connect_to_master")
 		a.CountDowntimedReplicas = m.GetUint("count_downtimed_replicas")
+		a.CountDowntimedValidReplicas = m.GetUint("count_downtimed_valid_replicas")
+		a.CountDowntimedValidReplicatingReplicas = m.GetUint("count_downtimed_valid_replicating_replicas")
+		a.CountDowntimedStaleHeartbeatReplicas = m.GetUint("count_downtimed_stale_heartbeat_replicas")
 		a.ReplicationDepth = m.GetUint("replication_depth")
 		a.IsFailingToConnectToMaster = m.GetBool("is_failing_to_connect_to_master")
 		a.IsDowntimed = m.GetBool("is_downtimed")
 		a.DowntimeEndTimestamp = m.GetString("downtime_end_timestamp")
 		a.DowntimeRemainingSeconds = m.GetInt("downtime_remaining_seconds")
+		a.DowntimeOwner = m.GetString("downtime_owner")
+		a.DowntimeReason = m.GetString("downtime_reason")
 		a.IsBinlogServer = m.GetBool("is_binlog_server")
 		a.ClusterDetails.ReadRecoveryInfo()
 
@@ -286,6 +517,12 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 		a.MariaDBGTIDImmediateTopology = countValidMariaDBGTIDSlaves == a.CountValidReplicas && a.CountValidReplicas > 0
 		countValidBinlogServerSlaves := m.GetUint("count_valid_binlog_server_slaves")
 		a.BinlogServerImmediateTopology = countValidBinlogServerSlaves == a.CountValidReplicas && a.CountValidReplicas > 0
+		a.GTIDWithBinlogServersImmediateTopology = isGTIDWithBinlogServersTopology(a.CountValidReplicas, countValidOracleGTIDSlaves, countValidMariaDBGTIDSlaves, countValidBinlogServerSlaves)
+		if counts, ok := binlogServerSubReplicasCounts[a.AnalyzedInstanceKey]; ok {
+			a.CountBinlogServerSubReplicas = counts.CountReplicas
+			a.CountValidBinlogServerSubReplicas = counts.CountValidReplicas
+			a.CountValidReplicatingBinlogServerSubReplicas = counts.CountValidReplicatingReplicas
+		}
 		a.PseudoGTIDImmediateTopology = m.GetBool("is_pseudo_gtid")
 
 		a.MinReplicaGTIDMode = m.GetString("min_replica_gtid_mode")
@@ -302,32 +539,40 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 		a.CountLaggingReplicas = m.GetUint("count_lagging_replicas")
 
 		a.IsReadOnly = m.GetUint("read_only") == 1
+		a.SemiSyncMasterEnabled = m.GetBool("semi_sync_master_enabled")
+		a.SemiSyncMasterStatus = m.GetBool("semi_sync_master_status")
+		a.SemiSyncMasterWaitForReplicaCount = m.GetUint("semi_sync_master_wait_for_replica_count")
+		a.SemiSyncMasterClients = m.GetUint("semi_sync_master_clients")
+		a.MasterWritesBlocked = m.GetBool("master_writes_blocked")
+		a.IsReplicationGroupMember = m.GetBool("is_replication_group_member")
+		a.IsReplicationGroupSecondary = m.GetBool("is_replication_group_secondary")
 
 		if !a.LastCheckValid {
-			analysisMessage := fmt.Sprintf("analysis: IsMaster: %+v, LastCheckValid: %+v, LastCheckPartialSuccess: %+v, CountReplicas: %+v, CountValidReplicatingReplicas: %+v, CountLaggingReplicas: %+v, CountDelayedReplicas: %+v, ",
-				a.IsMaster, a.LastCheckValid, a.LastCheckPartialSuccess, a.CountReplicas, a.CountValidReplicatingReplicas, a.CountLaggingReplicas, a.CountDelayedReplicas,
+			analysisMessage := fmt.Sprintf("analysis: IsMaster: %+v, LastCheckValid: %+v, LastCheckPartialSuccess: %+v, IsStale: %+v, CountReplicas: %+v, CountValidReplicatingReplicas: %+v, CountLaggingReplicas: %+v, CountDelayedReplicas: %+v, ",
+				a.IsMaster, a.LastCheckValid, a.LastCheckPartialSuccess, a.IsStale, a.CountReplicas, a.CountValidReplicatingReplicas, a.CountLaggingReplicas, a.CountDelayedReplicas,
 			)
 			if util.ClearToLog("analysis_dao", analysisMessage) {
 				log.Debugf(analysisMessage)
 			}
 		}
-		if a.IsMaster && !a.LastCheckValid && a.CountReplicas == 0 {
-			a.Analysis = DeadMasterWithoutSlaves
-			a.Description = "Master cannot be reached by orchestrator and has no slave"
-			//
-		} else if a.IsMaster && !a.LastCheckValid && a.CountValidReplicas == a.CountReplicas && a.CountValidReplicatingReplicas == 0 {
-			a.Analysis = DeadMaster
-			a.Description = "Master cannot be reached by orchestrator and none of its replicas is replicating"
-			//
-		} else if a.IsMaster && !a.LastCheckValid && a.CountReplicas > 0 && a.CountValidReplicas == 0 && a.CountValidReplicatingReplicas == 0 {
-			a.Analysis = DeadMasterAndSlaves
-			a.Description = "Master cannot be reached by orchestrator and none of its replicas is replicating"
+		if a.IsMaster && !a.LastCheckValid && a.CountStaleHeartbeatReplicas > 0 {
+			// An overdue heartbeat alone does not tell a replica is broken: a busy master sends no heartbeats
+			countStalled, countDowntimedStalled, err := countStalledReplicas(&a.AnalyzedInstanceKey)
+			if err != nil {
+				return log.Errore(err)
+			}
+			a.CountStaleHeartbeatReplicas, a.CountDowntimedStaleHeartbeatReplicas = countStalled, countDowntimedStalled
+		}
+		deadMasterAnalysis, deadMasterDescription := analyzeDeadMaster(&a)
+		if a.IsReplicationGroupMember {
+			// Group Replication members are not subject to classic replication analysis: a member with no
+			// replicas is not a lonely master, and the group itself handles member failure and primary election.
 			//
-		} else if a.IsMaster && !a.LastCheckValid && a.CountValidReplicas < a.CountReplicas && a.CountValidReplicas > 0 && a.CountValidReplicatingReplicas == 0 {
-			a.Analysis = DeadMasterAndSomeSlaves
-			a.Description = "Master cannot be reached by orchestrator; some of its replicas are unreachable and none of its reachable replicas is replicating"
+		} else if a.IsMaster && !a.LastCheckValid && deadMasterAnalysis != NoProblem {
+			a.Analysis = deadMasterAnalysis
+			a.Description = deadMasterDescription
 			//
-		} else if a.IsMaster && !a.LastCheckValid && a.CountLaggingReplicas == a.CountReplicas && a.CountDelayedReplicas < a.CountReplicas && a.CountValidReplicatingReplicas > 0 {
+		} else if a.IsMaster && !a.LastCheckValid && a.CountLaggingReplicas > 0 && a.CountLaggingReplicas+a.CountDelayedReplicas == a.CountReplicas && a.CountValidReplicatingReplicas > 0 {
 			a.Analysis = UnreachableMasterWithLaggingReplicas
 			a.Description = "Master cannot be reached by orchestrator and all of its replicas are lagging"
 			//
@@ -351,6 +596,18 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 			a.Analysis = AllMasterSlavesNotReplicatingOrDead
 			a.Description = "Master is reachable but none of its replicas is replicating"
 			//
+		} else if a.IsMaster && a.LastCheckValid && a.SemiSyncMasterEnabled && a.SemiSyncMasterClients < a.SemiSyncMasterWaitForReplicaCount {
+			a.Analysis = LockedSemiSyncMaster
+			a.Description = "Semi sync master has fewer connected semi sync replicas than rpl_semi_sync_master_wait_for_slave_count; writes are blocked or fell back to async"
+			//
+		} else if a.IsMaster && a.LastCheckValid && a.MasterWritesBlocked && a.SemiSyncMasterEnabled {
+			a.Analysis = LockedSemiSyncMaster
+			a.Description = "Semi sync master is reachable but its writes probe is blocked; possibly waiting on semi sync replica acknowledgement"
+			//
+		} else if a.IsMaster && a.LastCheckValid && a.MasterWritesBlocked {
+			a.Analysis = BlockedMaster
+			a.Description = "Master is reachable but its writes probe is blocked; possibly locked by FLUSH TABLES WITH READ LOCK"
+			//
 		} else /* co-master */ if a.IsCoMaster && !a.LastCheckValid && a.CountReplicas > 0 && a.CountValidReplicas == a.CountReplicas && a.CountValidReplicatingReplicas == 0 {
 			a.Analysis = DeadCoMaster
 			a.Description = "Co-master cannot be reached by orchestrator and none of its replicas is replicating"
@@ -485,7 +742,7 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 				a.StructureAnalysis = append(a.StructureAnalysis, ErrantGTIDStructureWarning)
 			}
 
-			if a.IsMaster && a.IsReadOnly {
+			if isNoWriteableMaster(&a, readOnlyMasterSince(&a, readOnlyMasters), gracefulMasterTakeoverClusters[a.ClusterDetails.ClusterName], time.Now()) {
 				a.StructureAnalysis = append(a.StructureAnalysis, NoWriteableMasterStructureWarning)
 			}
 
@@ -502,10 +759,429 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 	if err != nil {
 		return result, log.Errore(err)
 	}
+	if err := writeReadOnlyMastersFirstSeen(readOnlyMasters); err != nil {
+		return result, log.Errore(err)
+	}
+	for i := range result {
+		if err := annotateReplicasReplicationError(&result[i]); err != nil {
+			return result, log.Errore(err)
+		}
+	}
+	duplicateServerIDAnalysis, err := getDuplicateServerIDAnalysis(clusterName, hints)
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	result = append(result, duplicateServerIDAnalysis...)
+	replicationFiltersAnalysis, err := getReplicationFiltersAnalysis(clusterName, hints)
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	result = append(result, replicationFiltersAnalysis...)
+	replicaBinlogMissingAnalysis, err := getReplicaBinlogMissingAnalysis(clusterName, hints)
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	result = append(result, replicaBinlogMissingAnalysis...)
+	masterHostDriftAnalysis, err := getMasterHostDriftAnalysis(clusterName, hints)
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	result = append(result, masterHostDriftAnalysis...)
+	replicationChannelsAnalysis, err := getReplicationChannelsAnalysis(clusterName, hints)
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	result = append(result, replicationChannelsAnalysis...)
+	replicaHeartbeatAnalysis, err := getReplicaHeartbeatAnalysis(clusterName, hints)
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	result = append(result, replicaHeartbeatAnalysis...)
+	logSlaveUpdatesAnalysis, err := getLogSlaveUpdatesAnalysis(clusterName, hints)
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	result = append(result, logSlaveUpdatesAnalysis...)
+	mixedFlavorsAnalysis, err := getMixedFlavorsAnalysis(clusterName, hints)
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	result = append(result, mixedFlavorsAnalysis...)
+	wrongEndpointAnalysis, err := getReplicaWrongEndpointAnalysis(clusterName, hints)
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	result = append(result, wrongEndpointAnalysis...)
+	enabledEventsAnalysis, err := getReplicaEnabledEventsAnalysis(clusterName, hints)
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	result = append(result, enabledEventsAnalysis...)
+	binlogRetentionAnalysis, err := getBinlogRetentionAnalysis(clusterName, hints)
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	result = append(result, binlogRetentionAnalysis...)
+	circularReplicationAnalysis, err := getCircularReplicationAnalysis(clusterName, hints)
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	result = append(result, circularReplicationAnalysis...)
+	plaintextReplicationAnalysis, err := getPlaintextReplicationAnalysis(clusterName, hints)
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	result = append(result, plaintextReplicationAnalysis...)
+	resurrectedOldMasterAnalysis, err := getResurrectedOldMasterAnalysis(clusterName, hints)
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	result = append(result, resurrectedOldMasterAnalysis...)
 	// TODO: result, err = getConcensusReplicationAnalysis(result)
 	return result, log.Errore(err)
 }
 
+// binlogServerSubReplicasCount counts the replicas of the binlog servers of a master
+type binlogServerSubReplicasCount struct {
+	CountReplicas                 uint
+	CountValidReplicas            uint
+	CountValidReplicatingReplicas uint
+}
+
+// isGTIDWithBinlogServersTopology tells whether a master's valid replicas are binlog servers and GTID replicas, both
+func isGTIDWithBinlogServersTopology(countValidReplicas, countValidOracleGTIDReplicas, countValidMariaDBGTIDReplicas, countValidBinlogServerReplicas uint) bool {
+	if countValidBinlogServerReplicas == 0 || countValidBinlogServerReplicas >= countValidReplicas {
+		return false
+	}
+	return countValidOracleGTIDReplicas+countValidBinlogServerReplicas == countValidReplicas ||
+		countValidMariaDBGTIDReplicas+countValidBinlogServerReplicas == countValidReplicas
+}
+
+// readBinlogServerSubReplicasCounts counts, per master, the replicas of the binlog servers replicating from that
+// master. These replicas are not in the master's immediate topology, yet are effectively its replicas. A replica
+// of a binlog server only counts as replicating when its binlog server is valid and replicating as well: otherwise
+// it receives nothing from the master.
+func readBinlogServerSubReplicasCounts(clusterName string) (map[InstanceKey]*binlogServerSubReplicasCount, error) {
+	counts := make(map[InstanceKey]*binlogServerSubReplicasCount)
+	query := `
+		select
+			binlog_server.master_host,
+			binlog_server.master_port,
+			count(*) as count_replicas,
+			ifnull(sum(sub_replica.last_checked <= sub_replica.last_seen), 0) as count_valid_replicas,
+			ifnull(sum(sub_replica.last_checked <= sub_replica.last_seen
+				and sub_replica.slave_io_running != 0
+				and sub_replica.slave_sql_running != 0
+				and binlog_server.last_checked <= binlog_server.last_seen
+				and binlog_server.slave_io_running != 0), 0) as count_valid_replicating_replicas
+		from
+			database_instance binlog_server
+			join database_instance sub_replica on (
+				sub_replica.master_host = binlog_server.hostname
+				and sub_replica.master_port = binlog_server.port
+			)
+		where
+			binlog_server.binlog_server = 1
+			and ? in ('', binlog_server.cluster_name)
+		group by
+			binlog_server.master_host,
+			binlog_server.master_port
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(clusterName), func(m sqlutils.RowMap) error {
+		masterKey := InstanceKey{Hostname: m.GetString("master_host"), Port: m.GetInt("master_port")}
+		counts[masterKey] = &binlogServerSubReplicasCount{
+			CountReplicas:                 m.GetUint("count_replicas"),
+			CountValidReplicas:            m.GetUint("count_valid_replicas"),
+			CountValidReplicatingReplicas: m.GetUint("count_valid_replicating_replicas"),
+		}
+		return nil
+	})
+	return counts, log.Errore(err)
+}
+
+// annotateReplicasReplicationError adds the most common replication error of the replicas of a master none of
+// whose replicas is replicating to the analysis and its description, such that hooks may tell what went wrong
+// (e.g. full disks, an invalid statement) without further digging
+func annotateReplicasReplicationError(a *ReplicationAnalysis) error {
+	switch a.Analysis {
+	case AllMasterSlavesNotReplicating, AllMasterSlavesNotReplicatingOrDead:
+	default:
+		return nil
+	}
+	replicas, err := ReadReplicaInstances(&a.AnalyzedInstanceKey)
+	if err != nil {
+		return err
+	}
+	a.MostCommonReplicaErrno, a.MostCommonReplicaError, a.CountReplicasWithMostCommonError = mostCommonReplicationError(replicas)
+	if a.CountReplicasWithMostCommonError == 0 {
+		return nil
+	}
+	if a.MostCommonReplicaErrno != 0 {
+		a.Description = fmt.Sprintf("%s; most common replica error (%d replicas): %d: %s", a.Description, a.CountReplicasWithMostCommonError, a.MostCommonReplicaErrno, a.MostCommonReplicaError)
+	} else {
+		a.Description = fmt.Sprintf("%s; most common replica error (%d replicas): %s", a.Description, a.CountReplicasWithMostCommonError, a.MostCommonReplicaError)
+	}
+	return nil
+}
+
+// readValidReplicatingReplicasPerDataCenter counts, per master, its valid replicating replicas in each data center.
+// Replicas are matched to their master as in GetReplicationAnalysis.
+func readValidReplicatingReplicasPerDataCenter(clusterName string) (map[InstanceKey]map[string]uint, error) {
+	counts := make(map[InstanceKey]map[string]uint)
+	query := `
+		select
+			master_instance.hostname,
+			master_instance.port,
+			replica_instance.data_center,
+			count(*) as count_replicas
+		from
+			database_instance master_instance
+			left join hostname_resolve on (master_instance.hostname = hostname_resolve.hostname)
+			join database_instance replica_instance on (
+				coalesce(hostname_resolve.resolved_hostname, master_instance.hostname) = replica_instance.master_host
+				and master_instance.port = replica_instance.master_port
+			)
+			left join database_instance_ignore_health_checks on (
+				database_instance_ignore_health_checks.hostname = replica_instance.hostname
+				and database_instance_ignore_health_checks.port = replica_instance.port
+			)
+		where
+			database_instance_ignore_health_checks.hostname is null
+			and replica_instance.last_checked <= replica_instance.last_seen
+			and replica_instance.slave_io_running != 0
+			and replica_instance.slave_sql_running != 0
+			and ? in ('', master_instance.cluster_name)
+		group by
+			master_instance.hostname,
+			master_instance.port,
+			replica_instance.data_center
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(clusterName), func(m sqlutils.RowMap) error {
+		masterKey := InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")}
+		if _, found := counts[masterKey]; !found {
+			counts[masterKey] = make(map[string]uint)
+		}
+		counts[masterKey][m.GetString("data_center")] = m.GetUint("count_replicas")
+		return nil
+	})
+	return counts, log.Errore(err)
+}
+
+// analyzeDeadMaster returns the analysis of an unreachable master, or NoProblem if its replicas do not tell it
+// is dead. Replicas of its binlog servers count as its replicas. Downtimed replicas are excluded from the counts:
+// they are expected to be broken, and do not count toward the broken replicas quorum. Sets the analysis'
+// DeadMasterReplicasQuorum.
+func analyzeDeadMaster(a *ReplicationAnalysis) (analysis AnalysisCode, description string) {
+	countReplicas := a.CountReplicas - a.CountDowntimedReplicas + a.CountBinlogServerSubReplicas
+	countValidReplicas := a.CountValidReplicas - a.CountDowntimedValidReplicas + a.CountValidBinlogServerSubReplicas
+	countValidReplicatingReplicas := a.CountValidReplicatingReplicas - a.CountDowntimedValidReplicatingReplicas + a.CountValidReplicatingBinlogServerSubReplicas
+	countBrokenReplicas := countValidReplicas - countValidReplicatingReplicas + a.CountStaleHeartbeatReplicas - a.CountDowntimedStaleHeartbeatReplicas
+
+	a.DeadMasterReplicasQuorum = deadMasterReplicasQuorum(countValidReplicas)
+	hasDeadMasterReplicasQuorum := countValidReplicas > 0 && countBrokenReplicas >= a.DeadMasterReplicasQuorum
+
+	if countReplicas == 0 {
+		if a.CountDowntimedReplicas > 0 {
+			return DeadMasterWithoutSlaves, "Master cannot be reached by orchestrator and all of its replicas are downtimed"
+		}
+		return DeadMasterWithoutSlaves, "Master cannot be reached by orchestrator and has no slave"
+	}
+	if countValidReplicas == countReplicas && hasDeadMasterReplicasQuorum {
+		if countValidReplicatingReplicas > 0 {
+			return DeadMaster, fmt.Sprintf("Master cannot be reached by orchestrator and %d of its %d replicas are broken (quorum: %d)", countBrokenReplicas, countValidReplicas, a.DeadMasterReplicasQuorum)
+		}
+		return DeadMaster, "Master cannot be reached by orchestrator and none of its replicas is replicating"
+	}
+	if countValidReplicas == 0 && countValidReplicatingReplicas == 0 {
+		return DeadMasterAndSlaves, "Master cannot be reached by orchestrator and none of its replicas is replicating"
+	}
+	if countValidReplicas < countReplicas && hasDeadMasterReplicasQuorum {
+		return DeadMasterAndSomeSlaves, "Master cannot be reached by orchestrator; some of its replicas are unreachable and none of its reachable replicas is replicating"
+	}
+	return NoProblem, ""
+}
+
+// getDuplicateServerIDAnalysis returns a DuplicateServerID analysis entry for each instance which shares
+// its server_id or server_uuid with other instances in its cluster.
+func getDuplicateServerIDAnalysis(clusterName string, hints *ReplicationAnalysisHints) ([]ReplicationAnalysis, error) {
+	result := []ReplicationAnalysis{}
+	duplicates, err := ReadDuplicateServerIDInstances(clusterName)
+	if err != nil {
+		return result, err
+	}
+	instanceKeys := []InstanceKey{}
+	for instanceKey := range duplicates {
+		instanceKeys = append(instanceKeys, instanceKey)
+	}
+	instances, err := readInstancesByKeys(instanceKeys)
+	if err != nil {
+		return result, err
+	}
+	for _, instance := range instances {
+		duplicateKeys := duplicates[instance.Key]
+		if !isAnalyzableInstance(instance, hints) {
+			continue
+		}
+		a := newInstanceReplicationAnalysis(instance, DuplicateServerID, fmt.Sprintf("Instance shares its server_id or server_uuid with %s", duplicateKeys.ToCommaDelimitedList()))
+		a.DuplicateServerIDInstances = *duplicateKeys
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+// getReplicationFiltersAnalysis returns a ReplicationFiltersInUnfilteredCluster analysis entry for each
+// instance which uses replication filters while its cluster's master does not.
+func getReplicationFiltersAnalysis(clusterName string, hints *ReplicationAnalysisHints) ([]ReplicationAnalysis, error) {
+	result := []ReplicationAnalysis{}
+	instances, err := ReadReplicationFilteredInstancesInUnfilteredClusters(clusterName)
+	if err != nil {
+		return result, err
+	}
+	for _, instance := range instances {
+		if !isAnalyzableInstance(instance, hints) {
+			continue
+		}
+		a := newInstanceReplicationAnalysis(instance, ReplicationFiltersInUnfilteredCluster, fmt.Sprintf("Instance uses replication filters in an otherwise unfiltered cluster: %s", instance.ReplicationFilters.String()))
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+// getReplicaBinlogMissingAnalysis returns a ReplicaBinlogMissingOnMaster analysis entry for each replica
+// which fails replicating because the binary log it requests has been purged off its master.
+func getReplicaBinlogMissingAnalysis(clusterName string, hints *ReplicationAnalysisHints) ([]ReplicationAnalysis, error) {
+	result := []ReplicationAnalysis{}
+	replicas, err := ReadReplicasFailingOnMasterBinlogs(clusterName)
+	if err != nil {
+		return result, err
+	}
+	for _, replica := range replicas {
+		if !isAnalyzableInstance(replica, hints) {
+			continue
+		}
+		master, found, err := ReadInstance(&replica.MasterKey)
+		if err != nil {
+			return result, err
+		}
+		if !found || !replica.HasBinlogMissingOnMaster(master) {
+			continue
+		}
+		a := newInstanceReplicationAnalysis(replica, ReplicaBinlogMissingOnMaster, fmt.Sprintf("Replica requests binary log %s, but the oldest binary log on master %s is %s", replica.ReadBinlogCoordinates.LogFile, master.Key.StringCode(), master.OldestBinaryLogFile))
+		a.ReplicaBinlogFile = replica.ReadBinlogCoordinates.LogFile
+		a.MasterOldestBinlogFile = master.OldestBinaryLogFile
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+// getMasterHostDriftAnalysis returns a MasterHostDrift analysis entry for each replica whose configured master
+// host (e.g. a VIP) leads to a different server than the master orchestrator acknowledges for it.
+func getMasterHostDriftAnalysis(clusterName string, hints *ReplicationAnalysisHints) ([]ReplicationAnalysis, error) {
+	result := []ReplicationAnalysis{}
+	drifted, err := ReadMasterHostDriftedReplicas(clusterName)
+	if err != nil {
+		return result, err
+	}
+	for instanceKey, actualMasterKey := range drifted {
+		instanceKey := instanceKey
+		instance, found, err := ReadInstance(&instanceKey)
+		if err != nil {
+			return result, err
+		}
+		if !found {
+			continue
+		}
+		if !isAnalyzableInstance(instance, hints) {
+			continue
+		}
+		a := newInstanceReplicationAnalysis(instance, MasterHostDrift, fmt.Sprintf("Replica's master host %s is resolved as %s, but leads to %s", instance.RawMasterKey.StringCode(), instance.MasterKey.StringCode(), actualMasterKey.StringCode()))
+		a.RawMasterKey = instance.RawMasterKey
+		a.ActualMasterKey = actualMasterKey
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+// getReplicationChannelsAnalysis evaluates each replication channel of multi-source replicas independently, and
+// returns a BrokenReplicationChannel and/or LaggingReplicationChannel analysis entry per replica, listing the
+// affected channels.
+func getReplicationChannelsAnalysis(clusterName string, hints *ReplicationAnalysisHints) ([]ReplicationAnalysis, error) {
+	result := []ReplicationAnalysis{}
+	instances, err := ReadMultiSourceReplicas(clusterName)
+	if err != nil {
+		return result, err
+	}
+	for _, instance := range instances {
+		if !isAnalyzableInstance(instance, hints) {
+			continue
+		}
+		brokenChannels := []string{}
+		laggingChannels := []string{}
+		for _, channel := range instance.ReplicationChannels {
+			if channel.IsBroken() {
+				brokenChannels = append(brokenChannels, fmt.Sprintf("'%s' (master %s)", channel.Name, channel.MasterKey.StringCode()))
+			} else if channel.IsLagging() {
+				laggingChannels = append(laggingChannels, fmt.Sprintf("'%s' (master %s, %ds)", channel.Name, channel.MasterKey.StringCode(), channel.SecondsBehindMaster.Int64))
+			}
+		}
+		if len(brokenChannels) > 0 {
+			result = append(result, newInstanceReplicationAnalysis(instance, BrokenReplicationChannel, fmt.Sprintf("Replication channels not replicating: %s", strings.Join(brokenChannels, ", "))))
+		}
+		if len(laggingChannels) > 0 {
+			result = append(result, newInstanceReplicationAnalysis(instance, LaggingReplicationChannel, fmt.Sprintf("Replication channels lagging: %s", strings.Join(laggingChannels, ", "))))
+		}
+	}
+	return result, nil
+}
+
+// isAnalyzableInstance returns false for instances which should not be reported by per-instance analysis
+func isAnalyzableInstance(instance *Instance, hints *ReplicationAnalysisHints) bool {
+	if RegexpMatchPatterns(instance.Key.Hostname, config.Config.RecoveryIgnoreHostnameFilters) {
+		return false
+	}
+	if instance.IsDowntimed && !hints.IncludeDowntimed {
+		return false
+	}
+	if instance.IgnoreHealthChecks {
+		return false
+	}
+	return true
+}
+
+// newInstanceReplicationAnalysis creates an analysis entry for a single instance, as opposed to
+// analysis of a master and its replicas.
+func newInstanceReplicationAnalysis(instance *Instance, analysis AnalysisCode, description string) ReplicationAnalysis {
+	a := ReplicationAnalysis{
+		AnalyzedInstanceKey:                 instance.Key,
+		AnalyzedInstanceMasterKey:           instance.MasterKey,
+		AnalyzedInstanceDataCenter:          instance.DataCenter,
+		AnalyzedInstanceRegion:              instance.Region,
+		AnalyzedInstancePhysicalEnvironment: instance.PhysicalEnvironment,
+		Analysis:                            analysis,
+		Description:                         description,
+		IsMaster:                            instance.IsMaster(),
+		IsCoMaster:                          instance.IsCoMaster,
+		LastCheckValid:                      instance.IsLastCheckValid,
+		IsStale:                             instance.IsStale,
+		ReplicationDepth:                    instance.ReplicationDepth,
+		IsDowntimed:                         instance.IsDowntimed,
+		SkippableDueToDowntime:              instance.IsDowntimed,
+		DowntimeEndTimestamp:                instance.DowntimeEndTimestamp,
+		DowntimeRemainingSeconds:            int(instance.RemainingDowntime.Seconds()),
+		DowntimeOwner:                       instance.DowntimeOwner,
+		DowntimeReason:                      instance.DowntimeReason,
+		IsBinlogServer:                      instance.IsBinlogServer(),
+		IsReadOnly:                          instance.ReadOnly,
+		GTIDMode:                            instance.GTIDMode,
+		ProcessingNodeHostname:              process.ThisHostname,
+		ProcessingNodeToken:                 util.ProcessToken.Hash,
+	}
+	a.ClusterDetails.ClusterName = instance.ClusterName
+	a.ClusterDetails.ClusterAlias, _ = ReadAliasByClusterName(instance.ClusterName)
+	a.ClusterDetails.ReadRecoveryInfo()
+	return a
+}
+
 func getConcensusReplicationAnalysis(analysisEntries []ReplicationAnalysis) ([]ReplicationAnalysis, error) {
 	if !orcraft.IsRaftEnabled() {
 		return analysisEntries, nil
@@ -680,3 +1356,338 @@ func ReadPeerAnalysisMap() (peerAnalysisMap PeerAnalysisMap, err error) {
 	})
 	return peerAnalysisMap, log.Errore(err)
 }
+
+// isStalledReplica checks IsReplicationStalled on given replica with an overdue heartbeat, based on its own and
+// its master's coordinates as recorded a heartbeat stall window ago
+func isStalledReplica(replica *Instance, master *Instance) (bool, error) {
+	window := replica.HeartbeatStallWindowSeconds()
+	masterPreviousCoordinates, _, err := GetCoordinatesForInstanceAsOf(&master.Key, window)
+	if err != nil {
+		return false, err
+	}
+	_, previousRelaylogCoordinates, err := GetCoordinatesForInstanceAsOf(&replica.Key, window)
+	if err != nil {
+		return false, err
+	}
+	return replica.IsReplicationStalled(master, masterPreviousCoordinates, previousRelaylogCoordinates), nil
+}
+
+// countStalledReplicas returns the number of replicating replicas of given master which are stalled, and how many
+// of those are downtimed. Replicas are only stalled when the master's binary logs advanced while their relay logs
+// did not; see IsReplicationStalled.
+func countStalledReplicas(masterKey *InstanceKey) (countStalled uint, countDowntimedStalled uint, err error) {
+	master, found, err := ReadInstance(masterKey)
+	if err != nil || !found {
+		return 0, 0, err
+	}
+	replicas, err := ReadReplicasWithOverdueHeartbeat(master.ClusterName)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, replica := range replicas {
+		if !replica.MasterKey.Equals(masterKey) || !replica.IsLastCheckValid || !replica.ReplicationSQLThreadState.IsRunning() {
+			continue
+		}
+		stalled, err := isStalledReplica(replica, master)
+		if err != nil {
+			return 0, 0, err
+		}
+		if !stalled {
+			continue
+		}
+		countStalled++
+		if replica.IsDowntimed {
+			countDowntimedStalled++
+		}
+	}
+	return countStalled, countDowntimedStalled, nil
+}
+
+// getReplicaHeartbeatAnalysis returns a ReplicaHeartbeatStalled analysis entry for each replica which received
+// neither events nor heartbeats for over twice its heartbeat period, while its master's binary logs advanced.
+func getReplicaHeartbeatAnalysis(clusterName string, hints *ReplicationAnalysisHints) ([]ReplicationAnalysis, error) {
+	result := []ReplicationAnalysis{}
+	replicas, err := ReadReplicasWithOverdueHeartbeat(clusterName)
+	if err != nil {
+		return result, err
+	}
+	for _, replica := range replicas {
+		if !isAnalyzableInstance(replica, hints) {
+			continue
+		}
+		master, found, err := ReadInstance(&replica.MasterKey)
+		if err != nil {
+			return result, err
+		}
+		if !found {
+			continue
+		}
+		stalled, err := isStalledReplica(replica, master)
+		if err != nil {
+			return result, err
+		}
+		if !stalled {
+			continue
+		}
+		a := newInstanceReplicationAnalysis(replica, ReplicaHeartbeatStalled, fmt.Sprintf("Replica received no heartbeat for %ds (heartbeat period: %.3fs) nor events, while master %s is writing", replica.SecondsSinceLastHeartbeat.Int64, replica.HeartbeatPeriodSeconds, master.Key.StringCode()))
+		a.HeartbeatPeriodSeconds = replica.HeartbeatPeriodSeconds
+		a.SecondsSinceLastHeartbeat = replica.SecondsSinceLastHeartbeat.Int64
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+// getLogSlaveUpdatesAnalysis returns, per cluster, a single NoLogSlaveUpdatesOnIntermediateMasters analysis entry
+// listing the intermediate masters which do not have log_slave_updates enabled. Their replicas do not receive
+// changes made upstream. The entry is reported on the cluster's master.
+func getLogSlaveUpdatesAnalysis(clusterName string, hints *ReplicationAnalysisHints) ([]ReplicationAnalysis, error) {
+	result := []ReplicationAnalysis{}
+	instances, err := ReadIntermediateMastersWithoutLogSlaveUpdates(clusterName)
+	if err != nil {
+		return result, err
+	}
+	clustersInstances := make(map[string]([](*Instance)))
+	clusterNames := []string{}
+	for _, instance := range instances {
+		if !isAnalyzableInstance(instance, hints) {
+			continue
+		}
+		if _, ok := clustersInstances[instance.ClusterName]; !ok {
+			clusterNames = append(clusterNames, instance.ClusterName)
+		}
+		clustersInstances[instance.ClusterName] = append(clustersInstances[instance.ClusterName], instance)
+	}
+	for _, instanceClusterName := range clusterNames {
+		clusterInstances := clustersInstances[instanceClusterName]
+		analyzedInstance := clusterInstances[0]
+		if masters, err := ReadClusterMaster(instanceClusterName); err == nil && len(masters) > 0 {
+			analyzedInstance = masters[0]
+		}
+		keys := NewInstanceKeyMap()
+		keys.AddInstances(clusterInstances)
+		a := newInstanceReplicationAnalysis(analyzedInstance, NoLogSlaveUpdatesOnIntermediateMasters, fmt.Sprintf("Intermediate masters without log_slave_updates: %s", keys.ToCommaDelimitedList()))
+		a.NoLogSlaveUpdatesInstances = *keys
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+// getMixedFlavorsAnalysis returns, per cluster, a single MixedFlavorsInCluster analysis entry when the cluster
+// mixes server flavors (e.g. MySQL and MariaDB). The entry is reported on the cluster's master.
+func getMixedFlavorsAnalysis(clusterName string, hints *ReplicationAnalysisHints) ([]ReplicationAnalysis, error) {
+	result := []ReplicationAnalysis{}
+	clustersFlavorNames, err := ReadClustersFlavorNames(clusterName)
+	if err != nil {
+		return result, err
+	}
+	for instanceClusterName, flavorNames := range clustersFlavorNames {
+		if len(flavorNames) <= 1 {
+			continue
+		}
+		masters, err := ReadClusterMaster(instanceClusterName)
+		if err != nil {
+			return result, err
+		}
+		if len(masters) == 0 || !isAnalyzableInstance(masters[0], hints) {
+			continue
+		}
+		a := newInstanceReplicationAnalysis(masters[0], MixedFlavorsInCluster, fmt.Sprintf("Cluster mixes server flavors: %s", strings.Join(flavorNames, ", ")))
+		a.ClusterFlavorNames = flavorNames
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+// getReplicaWrongEndpointAnalysis returns a ReplicaPointsToWrongEndpoint analysis entry for each replica whose
+// configured master endpoint (host & port) is not the canonical key of the master it replicates from, e.g. a
+// secondary IP or a proxy port. Such replicas may replicate just fine, but are missed by tooling which
+// relies on canonical hostnames.
+func getReplicaWrongEndpointAnalysis(clusterName string, hints *ReplicationAnalysisHints) ([]ReplicationAnalysis, error) {
+	result := []ReplicationAnalysis{}
+	replicas, err := ReadReplicasPointingToWrongEndpoint(clusterName)
+	if err != nil {
+		return result, err
+	}
+	instanceKeys := []InstanceKey{}
+	for instanceKey := range replicas {
+		instanceKeys = append(instanceKeys, instanceKey)
+	}
+	instances, err := readInstancesByKeys(instanceKeys)
+	if err != nil {
+		return result, err
+	}
+	for _, instance := range instances {
+		canonicalMasterKey := replicas[instance.Key]
+		if !isAnalyzableInstance(instance, hints) {
+			continue
+		}
+		a := newInstanceReplicationAnalysis(instance, ReplicaPointsToWrongEndpoint, fmt.Sprintf("Replica is configured to replicate from %s, which is not the canonical endpoint %s of its master", instance.RawMasterKey.StringCode(), canonicalMasterKey.StringCode()))
+		a.RawMasterKey = instance.RawMasterKey
+		a.CanonicalMasterKey = canonicalMasterKey
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+// getReplicaEnabledEventsAnalysis returns a ReplicaWithEnabledEvents analysis entry for each read-only replica
+// running the event scheduler with enabled events, which write data the master never sees.
+func getReplicaEnabledEventsAnalysis(clusterName string, hints *ReplicationAnalysisHints) ([]ReplicationAnalysis, error) {
+	result := []ReplicationAnalysis{}
+	instances, err := ReadReplicasWithEnabledEvents(clusterName)
+	if err != nil {
+		return result, err
+	}
+	for _, instance := range instances {
+		if !isAnalyzableInstance(instance, hints) {
+			continue
+		}
+		a := newInstanceReplicationAnalysis(instance, ReplicaWithEnabledEvents, fmt.Sprintf("Replica has event_scheduler=ON with %d enabled events", instance.CountEnabledEvents))
+		a.CountEnabledEvents = instance.CountEnabledEvents
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+// getBinlogRetentionAnalysis returns a LowBinlogRetentionMargin analysis entry for each writeable master whose
+// oldest retained binary log is fewer than BinlogRetentionMarginWarningFiles binary logs behind the oldest binary
+// log its replicas need. This is based on the binary logs summary collected every BinlogSummaryIntervalMinutes.
+func getBinlogRetentionAnalysis(clusterName string, hints *ReplicationAnalysisHints) ([]ReplicationAnalysis, error) {
+	result := []ReplicationAnalysis{}
+	if config.Config.BinlogRetentionMarginWarningFiles == 0 {
+		return result, nil
+	}
+	var masters [](*Instance)
+	var err error
+	if clusterName == "" {
+		masters, err = ReadWriteableClustersMasters()
+	} else {
+		masters, err = ReadClusterWriteableMaster(clusterName)
+	}
+	if err != nil {
+		return result, err
+	}
+	for _, master := range masters {
+		if !isAnalyzableInstance(master, hints) {
+			continue
+		}
+		retention, err := ReadBinlogRetention(&master.Key)
+		if err != nil {
+			return result, err
+		}
+		if retention == nil || retention.SlowestReplicaKey == nil || retention.MarginFiles >= int(config.Config.BinlogRetentionMarginWarningFiles) {
+			continue
+		}
+		a := newInstanceReplicationAnalysis(master, LowBinlogRetentionMargin, fmt.Sprintf("Oldest binary log %s is %d binary logs behind %s, needed by replica %s", retention.Summary.FirstBinlogFile, retention.MarginFiles, retention.SlowestReplicaBinlogFile, retention.SlowestReplicaKey.StringCode()))
+		a.MasterOldestBinlogFile = retention.Summary.FirstBinlogFile
+		a.ReplicaBinlogFile = retention.SlowestReplicaBinlogFile
+		a.BinlogRetentionMarginFiles = retention.MarginFiles
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+// getCircularReplicationAnalysis returns a CircularReplication analysis entry for each replication loop, other
+// than co-master pairs, reported on the loop's smallest instance key. Instances in such a loop confuse analysis
+// of their cluster: none of them is a master, and each is an intermediate master.
+func getCircularReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints) ([]ReplicationAnalysis, error) {
+	result := []ReplicationAnalysis{}
+	masterOf, err := readMasterKeysMap(clusterName)
+	if err != nil {
+		return result, err
+	}
+	for _, cycle := range findReplicationCycles(masterOf) {
+		// Loops are rare: only then is the full instance read
+		instance, found, err := ReadInstance(&cycle[0])
+		if err != nil {
+			return result, err
+		}
+		if !found || !isAnalyzableInstance(instance, hints) {
+			continue
+		}
+		a := newInstanceReplicationAnalysis(instance, CircularReplication, fmt.Sprintf("Replication loop: %s", replicationCycleDescription(cycle)))
+		a.CircularReplicationMembers = cycle
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+// plaintextReplicas returns those of given replicas of a single cluster which replicate without SSL, if those
+// are worth reporting: always when SSL is required, otherwise only when other replicas of the cluster use SSL.
+func plaintextReplicas(clusterReplicas [](*Instance), requireSSL bool) [](*Instance) {
+	plaintext := [](*Instance){}
+	countSSL := 0
+	for _, replica := range clusterReplicas {
+		if replica.HasPlaintextReplication() {
+			plaintext = append(plaintext, replica)
+		}
+		if replica.AllowTLS {
+			countSSL++
+		}
+	}
+	if !requireSSL && countSSL == 0 {
+		return [](*Instance){}
+	}
+	return plaintext
+}
+
+// getPlaintextReplicationAnalysis returns, per cluster, a single PlaintextReplication analysis entry listing
+// replicas which replicate without SSL, see RequireReplicationSSL. The entry is reported on the cluster's master.
+func getPlaintextReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints) ([]ReplicationAnalysis, error) {
+	result := []ReplicationAnalysis{}
+	replicas, err := readInstancesByCondition(`
+			master_host != ''
+			and ? IN ('', cluster_name)
+		`, sqlutils.Args(clusterName), "")
+	if err != nil {
+		return result, err
+	}
+	clustersReplicas := make(map[string]([](*Instance)))
+	clusterNames := []string{}
+	for _, replica := range replicas {
+		if !replica.MasterKey.IsValid() || !isAnalyzableInstance(replica, hints) {
+			continue
+		}
+		if _, ok := clustersReplicas[replica.ClusterName]; !ok {
+			clusterNames = append(clusterNames, replica.ClusterName)
+		}
+		clustersReplicas[replica.ClusterName] = append(clustersReplicas[replica.ClusterName], replica)
+	}
+	for _, instanceClusterName := range clusterNames {
+		plaintext := plaintextReplicas(clustersReplicas[instanceClusterName], config.Config.RequireReplicationSSL)
+		if len(plaintext) == 0 {
+			continue
+		}
+		analyzedInstance := plaintext[0]
+		if masters, err := ReadClusterMaster(instanceClusterName); err == nil && len(masters) > 0 {
+			analyzedInstance = masters[0]
+		}
+		keys := NewInstanceKeyMap()
+		keys.AddInstances(plaintext)
+		a := newInstanceReplicationAnalysis(analyzedInstance, PlaintextReplication, fmt.Sprintf("Replicas replicating without SSL: %s", keys.ToCommaDelimitedList()))
+		a.PlaintextReplicationInstances = *keys
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+// getResurrectedOldMasterAnalysis returns a ResurrectedOldMaster analysis entry for each failed master of an
+// unacknowledged master recovery which is seen alive again, yet does not replicate from its successor. Such
+// a server may take writes from clients holding stale connections. Opt in via FenceResurrectedOldMasterAfterFailover.
+func getResurrectedOldMasterAnalysis(clusterName string, hints *ReplicationAnalysisHints) ([]ReplicationAnalysis, error) {
+	result := []ReplicationAnalysis{}
+	if !config.Config.FenceResurrectedOldMasterAfterFailover {
+		return result, nil
+	}
+	instances, err := ReadResurrectedOldMasters(clusterName)
+	if err != nil {
+		return result, err
+	}
+	for _, instance := range instances {
+		if !instance.IsLastCheckValid || !isAnalyzableInstance(instance, hints) {
+			continue
+		}
+		a := newInstanceReplicationAnalysis(instance, ResurrectedOldMaster, "Failed master of an unacknowledged recovery is alive and does not replicate from the master which replaced it")
+		result = append(result, a)
+	}
+	return result, nil
+}
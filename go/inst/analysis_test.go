@@ -0,0 +1,423 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package inst
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestReadValidReplicatingReplicasPerDataCenter(t *testing.T) {
+	defer useSQLiteBackend(t)()
+
+	instances := mkTestInstances()
+	// 720 & 730 replicate from 710, in different data centers; 720 is a co-master
+	instances[0].MasterKey = i720k
+	instances[1].MasterKey = i710k
+	instances[2].MasterKey = i710k
+	for i, dataCenter := range []string{"dc1", "dc1", "dc2"} {
+		instances[i].DataCenter = dataCenter
+		instances[i].ClusterName = "dcs"
+		instances[i].Slave_IO_Running = true
+		instances[i].Slave_SQL_Running = true
+	}
+	test.S(t).ExpectNil(writeManyInstances(instances, true, true))
+
+	counts, err := readValidReplicatingReplicasPerDataCenter("dcs")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(counts), 2)
+	test.S(t).ExpectEquals(len(counts[i710k]), 2)
+	test.S(t).ExpectEquals(counts[i710k]["dc1"], uint(1))
+	test.S(t).ExpectEquals(counts[i710k]["dc2"], uint(1))
+	test.S(t).ExpectEquals(len(counts[i720k]), 1)
+	test.S(t).ExpectEquals(counts[i720k]["dc1"], uint(1))
+
+	// a replica not replicating is not counted
+	instances[2].Slave_SQL_Running = false
+	test.S(t).ExpectNil(writeManyInstances(instances[2:], true, true))
+	counts, err = readValidReplicatingReplicasPerDataCenter("dcs")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(counts[i710k]), 1)
+
+	counts, err = readValidReplicatingReplicasPerDataCenter("other")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(counts), 0)
+}
+
+func TestValidReplicatingReplicasPerDataCenterString(t *testing.T) {
+	analysis := ReplicationAnalysis{}
+	test.S(t).ExpectEquals(analysis.ValidReplicatingReplicasPerDataCenterString(), "")
+
+	analysis.ValidReplicatingReplicasPerDataCenter = map[string]uint{"dc2": 2, "dc1": 1}
+	test.S(t).ExpectEquals(analysis.ValidReplicatingReplicasPerDataCenterString(), "dc1:1,dc2:2")
+}
+
+func TestDistinctNonEmptySorted(t *testing.T) {
+	test.S(t).ExpectEquals(len(distinctNonEmptySorted("")), 0)
+	test.S(t).ExpectEquals(strings.Join(distinctNonEmptySorted("dc2,,dc1,dc2"), ","), "dc1,dc2")
+}
+
+func TestPlaintextReplicas(t *testing.T) {
+	sslReplica := &Instance{Key: InstanceKey{Hostname: "db-1", Port: 3306}, AllowTLS: true}
+	plaintextReplica := &Instance{Key: InstanceKey{Hostname: "db-2", Port: 3306}}
+	plaintextChannelReplica := &Instance{Key: InstanceKey{Hostname: "db-3", Port: 3306}, AllowTLS: true, ReplicationChannels: ReplicationChannels{{Name: "c1", AllowTLS: true}, {Name: "c2"}}}
+
+	test.S(t).ExpectFalse(sslReplica.HasPlaintextReplication())
+	test.S(t).ExpectTrue(plaintextReplica.HasPlaintextReplication())
+	test.S(t).ExpectTrue(plaintextChannelReplica.HasPlaintextReplication())
+
+	test.S(t).ExpectEquals(len(plaintextReplicas([](*Instance){sslReplica}, true)), 0)
+	test.S(t).ExpectEquals(len(plaintextReplicas([](*Instance){plaintextReplica}, false)), 0)
+	test.S(t).ExpectEquals(len(plaintextReplicas([](*Instance){plaintextReplica}, true)), 1)
+	{
+		plaintext := plaintextReplicas([](*Instance){sslReplica, plaintextReplica, plaintextChannelReplica}, false)
+		test.S(t).ExpectEquals(len(plaintext), 2)
+		test.S(t).ExpectEquals(plaintext[0], plaintextReplica)
+		test.S(t).ExpectEquals(plaintext[1], plaintextChannelReplica)
+	}
+}
+
+func TestDeadMasterReplicasQuorum(t *testing.T) {
+	defer func(fraction float64) { config.Config.DeadMasterReplicasQuorumFraction = fraction }(config.Config.DeadMasterReplicasQuorumFraction)
+
+	config.Config.DeadMasterReplicasQuorumFraction = 1
+	test.S(t).ExpectEquals(deadMasterReplicasQuorum(0), uint(1))
+	test.S(t).ExpectEquals(deadMasterReplicasQuorum(3), uint(3))
+
+	config.Config.DeadMasterReplicasQuorumFraction = 0.5
+	test.S(t).ExpectEquals(deadMasterReplicasQuorum(3), uint(2))
+	test.S(t).ExpectEquals(deadMasterReplicasQuorum(4), uint(2))
+
+	config.Config.DeadMasterReplicasQuorumFraction = 0.3
+	test.S(t).ExpectEquals(deadMasterReplicasQuorum(10), uint(3))
+}
+
+func TestCountBrokenReplicas(t *testing.T) {
+	analysis := ReplicationAnalysis{CountValidReplicas: 4, CountValidReplicatingReplicas: 3, CountStaleHeartbeatReplicas: 1}
+	test.S(t).ExpectEquals(analysis.CountBrokenReplicas(), uint(2))
+}
+
+func TestNoWriteableMaster(t *testing.T) {
+	analysis := ReplicationAnalysis{
+		AnalyzedInstanceKey: InstanceKey{Hostname: "no-writeable-master", Port: 3306},
+		ClusterDetails:      ClusterInfo{ClusterName: "no-writeable-master:3306"},
+		IsMaster:            true,
+		IsReadOnly:          true,
+		CountReplicas:       2,
+	}
+	readOnlyMasters := newReadOnlyMastersFirstSeen()
+
+	since := readOnlyMasterSince(&analysis, readOnlyMasters)
+	test.S(t).ExpectFalse(since.IsZero())
+	test.S(t).ExpectEquals(readOnlyMasterSince(&analysis, readOnlyMasters), since)
+	test.S(t).ExpectTrue(readOnlyMasters.changed[analysis.AnalyzedInstanceKey])
+
+	pollDuration := time.Duration(config.Config.InstancePollSeconds) * time.Second
+	test.S(t).ExpectFalse(isNoWriteableMaster(&analysis, since, false, since))
+	test.S(t).ExpectTrue(isNoWriteableMaster(&analysis, since, false, since.Add(pollDuration+time.Second)))
+	test.S(t).ExpectFalse(isNoWriteableMaster(&analysis, since, true, since.Add(pollDuration+time.Second)))
+
+	analysis.IsDowntimed = true
+	test.S(t).ExpectFalse(isNoWriteableMaster(&analysis, since, false, since.Add(pollDuration+time.Second)))
+
+	analysis.IsReadOnly = false
+	test.S(t).ExpectTrue(readOnlyMasterSince(&analysis, readOnlyMasters).IsZero())
+	_, found := readOnlyMasters.firstSeen[analysis.AnalyzedInstanceKey]
+	test.S(t).ExpectFalse(found)
+}
+
+func TestReadOnlyMastersFirstSeenPersisted(t *testing.T) {
+	defer useSQLiteBackend(t)()
+
+	analysis := ReplicationAnalysis{AnalyzedInstanceKey: i710k, IsMaster: true, IsReadOnly: true, CountReplicas: 2}
+	readOnlyMasters, err := readReadOnlyMastersFirstSeen()
+	test.S(t).ExpectNil(err)
+	since := readOnlyMasterSince(&analysis, readOnlyMasters)
+	test.S(t).ExpectNil(writeReadOnlyMastersFirstSeen(readOnlyMasters))
+	test.S(t).ExpectEquals(len(readOnlyMasters.changed), 0)
+
+	// As read by another node, or after a restart
+	readOnlyMasters, err = readReadOnlyMastersFirstSeen()
+	test.S(t).ExpectNil(err)
+	persistedSince := readOnlyMasterSince(&analysis, readOnlyMasters)
+	test.S(t).ExpectTrue(persistedSince.Sub(since) < 2*time.Second && since.Sub(persistedSince) < 2*time.Second)
+	test.S(t).ExpectEquals(len(readOnlyMasters.changed), 0)
+
+	analysis.IsReadOnly = false
+	readOnlyMasterSince(&analysis, readOnlyMasters)
+	test.S(t).ExpectNil(writeReadOnlyMastersFirstSeen(readOnlyMasters))
+	readOnlyMasters, err = readReadOnlyMastersFirstSeen()
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(readOnlyMasters.firstSeen), 0)
+}
+
+func TestGracefulMasterTakeoverPersisted(t *testing.T) {
+	defer useSQLiteBackend(t)()
+
+	test.S(t).ExpectNil(BeginGracefulMasterTakeover("c1"))
+	clusters, err := readGracefulMasterTakeoverClusters()
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(clusters["c1"])
+	test.S(t).ExpectFalse(clusters["c2"])
+
+	test.S(t).ExpectNil(EndGracefulMasterTakeover("c1"))
+	clusters, err = readGracefulMasterTakeoverClusters()
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectFalse(clusters["c1"])
+}
+
+func TestAnalyzeDeadMaster(t *testing.T) {
+	defer func(fraction float64) { config.Config.DeadMasterReplicasQuorumFraction = fraction }(config.Config.DeadMasterReplicasQuorumFraction)
+	config.Config.DeadMasterReplicasQuorumFraction = 1
+	{
+		analysis := ReplicationAnalysis{CountReplicas: 2, CountValidReplicas: 2}
+		code, _ := analyzeDeadMaster(&analysis)
+		test.S(t).ExpectEquals(string(code), DeadMaster)
+		test.S(t).ExpectEquals(analysis.DeadMasterReplicasQuorum, uint(2))
+	}
+	{
+		// Only replicas are downtimed, and still replicating
+		analysis := ReplicationAnalysis{CountReplicas: 2, CountValidReplicas: 2, CountValidReplicatingReplicas: 2, CountDowntimedReplicas: 2, CountDowntimedValidReplicas: 2, CountDowntimedValidReplicatingReplicas: 2}
+		code, description := analyzeDeadMaster(&analysis)
+		test.S(t).ExpectEquals(string(code), DeadMasterWithoutSlaves)
+		test.S(t).ExpectTrue(strings.Contains(description, "downtimed"))
+	}
+	{
+		// Only replicas are downtimed, and broken
+		analysis := ReplicationAnalysis{CountReplicas: 2, CountValidReplicas: 2, CountDowntimedReplicas: 2, CountDowntimedValidReplicas: 2}
+		code, _ := analyzeDeadMaster(&analysis)
+		test.S(t).ExpectEquals(string(code), DeadMasterWithoutSlaves)
+	}
+	{
+		// A downtimed, broken replica does not count toward the quorum
+		analysis := ReplicationAnalysis{CountReplicas: 3, CountValidReplicas: 3, CountValidReplicatingReplicas: 2, CountDowntimedReplicas: 1, CountDowntimedValidReplicas: 1}
+		code, _ := analyzeDeadMaster(&analysis)
+		test.S(t).ExpectEquals(code, NoProblem)
+		test.S(t).ExpectEquals(analysis.DeadMasterReplicasQuorum, uint(2))
+	}
+	{
+		// A downtimed, replicating replica does not prevent the quorum
+		analysis := ReplicationAnalysis{CountReplicas: 3, CountValidReplicas: 3, CountValidReplicatingReplicas: 1, CountStaleHeartbeatReplicas: 1, CountDowntimedReplicas: 1, CountDowntimedValidReplicas: 1, CountDowntimedValidReplicatingReplicas: 1, CountDowntimedStaleHeartbeatReplicas: 1}
+		code, _ := analyzeDeadMaster(&analysis)
+		test.S(t).ExpectEquals(string(code), DeadMaster)
+	}
+	{
+		analysis := ReplicationAnalysis{CountReplicas: 3, CountValidReplicas: 2, CountValidReplicatingReplicas: 1, CountDowntimedReplicas: 1, CountDowntimedValidReplicas: 1, CountDowntimedValidReplicatingReplicas: 1}
+		code, _ := analyzeDeadMaster(&analysis)
+		test.S(t).ExpectEquals(string(code), DeadMasterAndSomeSlaves)
+	}
+	{
+		// The only replica is a broken binlog server, whose replicas still replicate from it
+		analysis := ReplicationAnalysis{CountReplicas: 1, CountValidReplicas: 1, CountBinlogServerSubReplicas: 2, CountValidBinlogServerSubReplicas: 2, CountValidReplicatingBinlogServerSubReplicas: 0}
+		code, _ := analyzeDeadMaster(&analysis)
+		test.S(t).ExpectEquals(string(code), DeadMaster)
+		test.S(t).ExpectEquals(analysis.DeadMasterReplicasQuorum, uint(3))
+	}
+	{
+		// Replicas of a binlog server which receive from the master prevent the quorum
+		analysis := ReplicationAnalysis{CountReplicas: 2, CountValidReplicas: 2, CountBinlogServerSubReplicas: 1, CountValidBinlogServerSubReplicas: 1, CountValidReplicatingBinlogServerSubReplicas: 1}
+		code, _ := analyzeDeadMaster(&analysis)
+		test.S(t).ExpectEquals(code, NoProblem)
+	}
+	{
+		// An unreachable replica of a binlog server
+		analysis := ReplicationAnalysis{CountReplicas: 1, CountValidReplicas: 1, CountBinlogServerSubReplicas: 2, CountValidBinlogServerSubReplicas: 1}
+		code, _ := analyzeDeadMaster(&analysis)
+		test.S(t).ExpectEquals(string(code), DeadMasterAndSomeSlaves)
+	}
+}
+
+func TestIsGTIDWithBinlogServersTopology(t *testing.T) {
+	// Oracle GTID replicas and binlog servers
+	test.S(t).ExpectTrue(isGTIDWithBinlogServersTopology(3, 2, 0, 1))
+	// MariaDB GTID replicas and binlog servers
+	test.S(t).ExpectTrue(isGTIDWithBinlogServersTopology(3, 0, 1, 2))
+	// No binlog servers
+	test.S(t).ExpectFalse(isGTIDWithBinlogServersTopology(3, 3, 0, 0))
+	// Only binlog servers
+	test.S(t).ExpectFalse(isGTIDWithBinlogServersTopology(2, 0, 0, 2))
+	// A non-GTID replica
+	test.S(t).ExpectFalse(isGTIDWithBinlogServersTopology(3, 1, 0, 1))
+	// Mixed Oracle and MariaDB GTID replicas
+	test.S(t).ExpectFalse(isGTIDWithBinlogServersTopology(3, 1, 1, 1))
+	test.S(t).ExpectFalse(isGTIDWithBinlogServersTopology(0, 0, 0, 0))
+}
+
+func TestReadBinlogServerSubReplicasCounts(t *testing.T) {
+	defer useSQLiteBackend(t)()
+
+	// i710 is the master, i720 a binlog server replicating from it, i730 and i740 replicas of the binlog server
+	instances := mkTestInstances()
+	i740 := *instances[2]
+	i740.Key = InstanceKey{Hostname: "i740", Port: 3306}
+	instances = append(instances, &i740)
+	binlogServer := instances[1]
+	binlogServer.IsDetectedBinlogServer = true
+	binlogServer.MasterKey = i710k
+	binlogServer.Slave_IO_Running = true
+	for _, replica := range instances[2:] {
+		replica.MasterKey = i720k
+	}
+	instances[2].Slave_IO_Running = true
+	instances[2].Slave_SQL_Running = true
+	test.S(t).ExpectNil(writeManyInstances(instances, true, true))
+
+	counts, err := readBinlogServerSubReplicasCounts("")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(counts), 1)
+	test.S(t).ExpectEquals(*counts[i710k], binlogServerSubReplicasCount{CountReplicas: 2, CountValidReplicas: 2, CountValidReplicatingReplicas: 1})
+
+	// Replicas of a binlog server which does not replicate receive nothing from the master
+	binlogServer.Slave_IO_Running = false
+	test.S(t).ExpectNil(writeManyInstances(instances[1:2], true, true))
+	counts, err = readBinlogServerSubReplicasCounts("")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(*counts[i710k], binlogServerSubReplicasCount{CountReplicas: 2, CountValidReplicas: 2, CountValidReplicatingReplicas: 0})
+
+	counts, err = readBinlogServerSubReplicasCounts("no-such-cluster")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(counts), 0)
+}
+
+func TestSplitMutedAnalysis(t *testing.T) {
+	analysisEntries := []ReplicationAnalysis{
+		{Analysis: DeadIntermediateMaster, SkippableDueToDowntime: true},
+		{Analysis: DeadMaster},
+	}
+	active, muted := SplitMutedAnalysis(analysisEntries)
+	test.S(t).ExpectEquals(len(active), 1)
+	test.S(t).ExpectEquals(string(active[0].Analysis), DeadMaster)
+	test.S(t).ExpectEquals(len(muted), 1)
+	test.S(t).ExpectEquals(string(muted[0].Analysis), DeadIntermediateMaster)
+}
+
+func TestMostCommonReplicationError(t *testing.T) {
+	newReplica := func(sqlState ReplicationThreadState, sqlErrno int, sqlError string, ioError string) *Instance {
+		return &Instance{
+			ReplicationSQLThreadState: sqlState,
+			ReplicationIOThreadState:  ReplicationThreadStateRunning,
+			LastSQLErrno:              sqlErrno,
+			LastSQLError:              sqlError,
+			LastIOError:               ioError,
+		}
+	}
+	{
+		errno, message, count := mostCommonReplicationError([](*Instance){})
+		test.S(t).ExpectEquals(errno, 0)
+		test.S(t).ExpectEquals(message, "")
+		test.S(t).ExpectEquals(count, uint(0))
+	}
+	{
+		replicas := [](*Instance){
+			newReplica(ReplicationThreadStateStopped, 1062, "Duplicate entry '1' at relay log position 100", ""),
+			newReplica(ReplicationThreadStateStopped, 1146, "Table 't' doesn't exist", ""),
+			newReplica(ReplicationThreadStateStopped, 1062, "Duplicate entry '1' at relay log position 200", ""),
+			newReplica(ReplicationThreadStateStopped, 0, "", ""),
+			newReplica(ReplicationThreadStateRunning, 0, "", ""),
+		}
+		errno, message, count := mostCommonReplicationError(replicas)
+		test.S(t).ExpectEquals(errno, 1062)
+		test.S(t).ExpectEquals(message, "Duplicate entry '1' at relay log position 100")
+		test.S(t).ExpectEquals(count, uint(2))
+	}
+	{
+		replicas := [](*Instance){
+			newReplica(ReplicationThreadStateStopped, 1146, "Table 't' doesn't exist", ""),
+		}
+		for i := 0; i < 2; i++ {
+			replica := newReplica(ReplicationThreadStateRunning, 0, "", "Got fatal error 1236 from master")
+			replica.ReplicationIOThreadState = ReplicationThreadStateStopped
+			replicas = append(replicas, replica)
+		}
+		errno, message, count := mostCommonReplicationError(replicas)
+		test.S(t).ExpectEquals(errno, 0)
+		test.S(t).ExpectEquals(message, "Got fatal error 1236 from master")
+		test.S(t).ExpectEquals(count, uint(2))
+	}
+}
+
+func TestGetReplicaWrongEndpointAnalysis(t *testing.T) {
+	defer useSQLiteBackend(t)()
+
+	instances := mkTestInstances()
+	// 720 replicates from 710 via a secondary IP; 730 replicates from 710 via its canonical key
+	instances[0].ServerUUID = "00000000-0000-0000-0000-000000000710"
+	instances[1].MasterKey = i710k
+	instances[1].RawMasterKey = InstanceKey{Hostname: "10.0.0.71", Port: i710k.Port}
+	instances[1].MasterUUID = instances[0].ServerUUID
+	instances[2].MasterKey = i710k
+	instances[2].RawMasterKey = i710k
+	instances[2].MasterUUID = instances[0].ServerUUID
+	for _, instance := range instances {
+		instance.ClusterName = "wrong-endpoint"
+	}
+	test.S(t).ExpectNil(writeManyInstances(instances, true, true))
+
+	replicas, err := ReadReplicasPointingToWrongEndpoint("wrong-endpoint")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(replicas), 1)
+	test.S(t).ExpectEquals(replicas[i720k], i710k)
+	replicas, err = ReadReplicasPointingToWrongEndpoint("other")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(replicas), 0)
+
+	analysis, err := getReplicaWrongEndpointAnalysis("wrong-endpoint", &ReplicationAnalysisHints{})
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(analysis), 1)
+	test.S(t).ExpectEquals(analysis[0].Analysis, AnalysisCode(ReplicaPointsToWrongEndpoint))
+	test.S(t).ExpectEquals(analysis[0].AnalyzedInstanceKey, i720k)
+	test.S(t).ExpectEquals(analysis[0].RawMasterKey, instances[1].RawMasterKey)
+	test.S(t).ExpectEquals(analysis[0].CanonicalMasterKey, i710k)
+
+	test.S(t).ExpectNil(BeginDowntime(NewDowntime(&i720k, "dba", "rebuilding", time.Hour)))
+	analysis, err = getReplicaWrongEndpointAnalysis("wrong-endpoint", &ReplicationAnalysisHints{})
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(analysis), 0)
+	analysis, err = getReplicaWrongEndpointAnalysis("wrong-endpoint", &ReplicationAnalysisHints{IncludeDowntimed: true})
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(analysis), 1)
+}
+
+func TestGetDuplicateServerIDAnalysis(t *testing.T) {
+	defer useSQLiteBackend(t)()
+
+	instances := mkTestInstances()
+	// 710 & 720 share server_id; 730 & 740 are not fully read yet
+	instances[1].ServerID = instances[0].ServerID
+	instances[2].ServerID = 0
+	i740 := *instances[2]
+	i740.Key = InstanceKey{Hostname: "i740", Port: 3306}
+	instances = append(instances, &i740)
+	for _, instance := range instances {
+		instance.ClusterName = "duplicates"
+	}
+	test.S(t).ExpectNil(writeManyInstances(instances, true, true))
+
+	analysis, err := getDuplicateServerIDAnalysis("duplicates", &ReplicationAnalysisHints{})
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(analysis), 2)
+	for _, a := range analysis {
+		test.S(t).ExpectEquals(a.Analysis, AnalysisCode(DuplicateServerID))
+		test.S(t).ExpectEquals(len(a.DuplicateServerIDInstances), 1)
+	}
+	analysis, err = getDuplicateServerIDAnalysis("other", &ReplicationAnalysisHints{})
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(analysis), 0)
+}
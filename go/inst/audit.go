@@ -22,5 +22,25 @@ type Audit struct {
 	AuditTimestamp   string
 	AuditType        string
 	AuditInstanceKey InstanceKey
+	ClusterName      string
 	Message          string
 }
+
+// AuditFilter narrows down an audit search. Empty fields do not filter. Timestamps are inclusive, in
+// backend format (e.g. "2019-05-01 00:00:00"). MessageMatch is a substring of the audit message.
+type AuditFilter struct {
+	InstanceKey   *InstanceKey
+	ClusterName   string
+	AuditType     string
+	FromTimestamp string
+	ToTimestamp   string
+	MessageMatch  string
+}
+
+// AuditPage is a single page of audit search results, along with the total number of matching entries
+type AuditPage struct {
+	Page       int
+	PageSize   int
+	TotalCount int64
+	Audits     []Audit
+}
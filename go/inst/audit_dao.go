@@ -25,6 +25,7 @@ import (
 	"github.com/rcrowley/go-metrics"
 	"log/syslog"
 	"os"
+	"strings"
 	"time"
 )
 
@@ -33,6 +34,9 @@ var syslogWriter *syslog.Writer
 
 var auditOperationCounter = metrics.NewCounter()
 
+// auditPurgeBatchSize is the audit_id range deleted by a single statement when purging old audit entries
+const auditPurgeBatchSize = 1000
+
 func init() {
 	metrics.Register("audit.write", auditOperationCounter)
 }
@@ -107,15 +111,49 @@ func AuditOperation(auditType string, instanceKey *InstanceKey, message string)
 	return nil
 }
 
-// ReadRecentAudit returns a list of audit entries order chronologically descending, using page number.
-func ReadRecentAudit(instanceKey *InstanceKey, page int) ([]Audit, error) {
-	res := []Audit{}
+// escapeLikePattern escapes LIKE wildcards in given text, such that it is matched literally. The escape character
+// is '!', which, unlike backslash, needs no escaping in either MySQL or SQLite string literals.
+func escapeLikePattern(text string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(text)
+}
+
+// whereClause returns the "where" clause and arguments matching this filter, or an empty clause if nothing is filtered
+func (filter *AuditFilter) whereClause() (string, []interface{}) {
+	conditions := []string{}
 	args := sqlutils.Args()
-	whereCondition := ``
-	if instanceKey != nil {
-		whereCondition = `where hostname=? and port=?`
-		args = append(args, instanceKey.Hostname, instanceKey.Port)
+	if filter.InstanceKey != nil {
+		conditions = append(conditions, `hostname=? and port=?`)
+		args = append(args, filter.InstanceKey.Hostname, filter.InstanceKey.Port)
+	}
+	if filter.ClusterName != "" {
+		conditions = append(conditions, `cluster_name=?`)
+		args = append(args, filter.ClusterName)
+	}
+	if filter.AuditType != "" {
+		conditions = append(conditions, `audit_type=?`)
+		args = append(args, filter.AuditType)
+	}
+	if filter.FromTimestamp != "" {
+		conditions = append(conditions, `audit_timestamp >= ?`)
+		args = append(args, filter.FromTimestamp)
+	}
+	if filter.ToTimestamp != "" {
+		conditions = append(conditions, `audit_timestamp <= ?`)
+		args = append(args, filter.ToTimestamp)
+	}
+	if filter.MessageMatch != "" {
+		conditions = append(conditions, `message like ? escape '!'`)
+		args = append(args, "%"+escapeLikePattern(filter.MessageMatch)+"%")
+	}
+	if len(conditions) == 0 {
+		return "", args
 	}
+	return fmt.Sprintf("where %s", strings.Join(conditions, " and ")), args
+}
+
+// readAudit reads a page of audit entries matching given condition, order chronologically descending
+func readAudit(whereCondition string, args []interface{}, page int) ([]Audit, error) {
+	res := []Audit{}
 	query := fmt.Sprintf(`
 		select
 			audit_id,
@@ -123,6 +161,7 @@ func ReadRecentAudit(instanceKey *InstanceKey, page int) ([]Audit, error) {
 			audit_type,
 			hostname,
 			port,
+			cluster_name,
 			message
 		from
 			audit
@@ -140,6 +179,7 @@ func ReadRecentAudit(instanceKey *InstanceKey, page int) ([]Audit, error) {
 		audit.AuditType = m.GetString("audit_type")
 		audit.AuditInstanceKey.Hostname = m.GetString("hostname")
 		audit.AuditInstanceKey.Port = m.GetInt("port")
+		audit.ClusterName = m.GetString("cluster_name")
 		audit.Message = m.GetString("message")
 
 		res = append(res, audit)
@@ -150,10 +190,81 @@ func ReadRecentAudit(instanceKey *InstanceKey, page int) ([]Audit, error) {
 		log.Errore(err)
 	}
 	return res, err
+}
 
+// ReadRecentAudit returns a list of audit entries order chronologically descending, using page number.
+func ReadRecentAudit(instanceKey *InstanceKey, page int) ([]Audit, error) {
+	filter := AuditFilter{InstanceKey: instanceKey}
+	whereCondition, args := filter.whereClause()
+	return readAudit(whereCondition, args, page)
+}
+
+// AuditOperationsFiltered returns a page of audit entries matching given filter, order chronologically
+// descending, along with the total number of matching entries.
+func AuditOperationsFiltered(filter AuditFilter, page int) (*AuditPage, error) {
+	auditPage := &AuditPage{Page: page, PageSize: config.AuditPageSize, Audits: []Audit{}}
+	whereCondition, args := filter.whereClause()
+	query := fmt.Sprintf(`
+		select
+			count(*) as count_audits
+		from
+			audit
+		%s
+		`, whereCondition)
+	err := db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		auditPage.TotalCount = m.GetInt64("count_audits")
+		return nil
+	})
+	if err != nil {
+		return auditPage, log.Errore(err)
+	}
+	if auditPage.TotalCount == 0 {
+		return auditPage, nil
+	}
+	auditPage.Audits, err = readAudit(whereCondition, args, page)
+	return auditPage, err
 }
 
-// ExpireAudit removes old rows from the audit table
+// ExpireAudit removes rows older than AuditPurgeDays from the audit table. Rows are deleted in batches of
+// audit_id ranges, such that the purge does not lock the table for long.
 func ExpireAudit() error {
-	return ExpireTableData("audit", "audit_timestamp")
+	var minAuditId, maxAuditId int64
+	query := `
+		select
+			ifnull(min(audit_id), 0) as min_audit_id,
+			ifnull(max(audit_id), 0) as max_audit_id
+		from
+			audit
+		where
+			audit_timestamp < NOW() - INTERVAL ? DAY
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(config.AuditPurgeDays), func(m sqlutils.RowMap) error {
+		minAuditId = m.GetInt64("min_audit_id")
+		maxAuditId = m.GetInt64("max_audit_id")
+		return nil
+	})
+	if err != nil {
+		return log.Errore(err)
+	}
+	if maxAuditId == 0 {
+		return nil
+	}
+	for fromAuditId := minAuditId; fromAuditId <= maxAuditId; fromAuditId += auditPurgeBatchSize {
+		fromAuditId := fromAuditId
+		writeFunc := func() error {
+			_, err := db.ExecOrchestrator(`
+					delete from audit
+					where
+						audit_id >= ?
+						and audit_id < ?
+						and audit_timestamp < NOW() - INTERVAL ? DAY
+					`, fromAuditId, fromAuditId+auditPurgeBatchSize, config.AuditPurgeDays,
+			)
+			return log.Errore(err)
+		}
+		if err := ExecDBWriteFunc(writeFunc); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,72 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package inst
+
+import (
+	"testing"
+
+	"github.com/github/orchestrator/go/db"
+	test "github.com/openark/golib/tests"
+)
+
+func TestAuditFilterWhereClause(t *testing.T) {
+	{
+		filter := AuditFilter{}
+		whereClause, args := filter.whereClause()
+		test.S(t).ExpectEquals(whereClause, "")
+		test.S(t).ExpectEquals(len(args), 0)
+	}
+	{
+		filter := AuditFilter{InstanceKey: &InstanceKey{Hostname: "db-1", Port: 3306}}
+		whereClause, args := filter.whereClause()
+		test.S(t).ExpectEquals(whereClause, "where hostname=? and port=?")
+		test.S(t).ExpectEquals(len(args), 2)
+	}
+	{
+		filter := AuditFilter{ClusterName: "db-1:3306", AuditType: "relocate-below", FromTimestamp: "2019-05-01 00:00:00", MessageMatch: "db-2"}
+		whereClause, args := filter.whereClause()
+		test.S(t).ExpectEquals(whereClause, "where cluster_name=? and audit_type=? and audit_timestamp >= ? and message like ? escape '!'")
+		test.S(t).ExpectEquals(len(args), 4)
+		test.S(t).ExpectEquals(args[3], "%db-2%")
+	}
+	{
+		filter := AuditFilter{MessageMatch: "100%_done!"}
+		_, args := filter.whereClause()
+		test.S(t).ExpectEquals(args[0], "%100!%!_done!!%")
+	}
+}
+
+func TestAuditOperationsFilteredMatchesMessageLiterally(t *testing.T) {
+	defer useSQLiteBackend(t)()
+
+	for _, message := range []string{"lag at 50% of threshold", "lag at 500 of threshold", "moved db_2", "moved db-2"} {
+		_, err := db.ExecOrchestrator(`insert into audit (audit_timestamp, audit_type, hostname, port, message) values (now(), 'test', 'db-1', 3306, ?)`, message)
+		test.S(t).ExpectNil(err)
+	}
+	auditPage, err := AuditOperationsFiltered(AuditFilter{MessageMatch: "50%"}, 0)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(auditPage.TotalCount, int64(1))
+	test.S(t).ExpectEquals(auditPage.Audits[0].Message, "lag at 50% of threshold")
+
+	auditPage, err = AuditOperationsFiltered(AuditFilter{MessageMatch: "db_2"}, 0)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(auditPage.TotalCount, int64(1))
+	test.S(t).ExpectEquals(auditPage.Audits[0].Message, "moved db_2")
+
+	auditPage, err = AuditOperationsFiltered(AuditFilter{MessageMatch: "moved"}, 0)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(auditPage.TotalCount, int64(2))
+}
@@ -0,0 +1,60 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+// BinlogSummary summarizes the binary logs of a master, as listed by SHOW BINARY LOGS
+type BinlogSummary struct {
+	Key                InstanceKey
+	CollectedTimestamp string
+	CountBinlogFiles   uint
+	TotalBinlogSize    int64
+	FirstBinlogFile    string
+}
+
+// BinlogRetention compares the oldest binary log retained by a master with the oldest binary log its replicas
+// still need. MarginFiles is the number of binary logs which may be purged before the slowest replica is
+// affected; it is negative when the slowest replica needs binary logs which are already purged.
+// SlowestReplicaKey is nil when no replica's position is known, in which case MarginFiles is meaningless.
+type BinlogRetention struct {
+	Summary                  BinlogSummary
+	SlowestReplicaKey        *InstanceKey
+	SlowestReplicaBinlogFile string
+	MarginFiles              int
+}
+
+// computeBinlogRetention computes the binary log retention margin of a master, given its binary logs summary
+// and its replicas. Only replicas with a valid last check and known IO thread coordinates are accounted for.
+func computeBinlogRetention(summary *BinlogSummary, replicas [](*Instance)) *BinlogRetention {
+	retention := &BinlogRetention{Summary: *summary}
+	var slowestReplica *Instance
+	for _, replica := range replicas {
+		if !replica.IsLastCheckValid || replica.ReadBinlogCoordinates.IsEmpty() {
+			continue
+		}
+		if slowestReplica == nil || replica.ReadBinlogCoordinates.FileSmallerThan(&slowestReplica.ReadBinlogCoordinates) {
+			slowestReplica = replica
+		}
+	}
+	if slowestReplica == nil || summary.FirstBinlogFile == "" {
+		return retention
+	}
+	firstBinlogCoordinates := BinlogCoordinates{LogFile: summary.FirstBinlogFile}
+	retention.SlowestReplicaKey = &slowestReplica.Key
+	retention.SlowestReplicaBinlogFile = slowestReplica.ReadBinlogCoordinates.LogFile
+	retention.MarginFiles = firstBinlogCoordinates.FileNumberDistance(&slowestReplica.ReadBinlogCoordinates)
+	return retention
+}
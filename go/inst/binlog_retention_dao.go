@@ -0,0 +1,156 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"context"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// binlogSummaryExpiryIntervals is the number of BinlogSummaryIntervalMinutes after which an uncollected summary,
+// e.g. of a master which is no longer a master, expires
+const binlogSummaryExpiryIntervals = 3
+
+// ReadTopologyBinlogSummary summarizes the binary logs of given instance off SHOW BINARY LOGS
+func ReadTopologyBinlogSummary(instanceKey *InstanceKey) (*BinlogSummary, error) {
+	summary := &BinlogSummary{Key: *instanceKey}
+	sqlDB, err := db.OpenDiscovery(instanceKey.Hostname, instanceKey.Port)
+	if err != nil {
+		return summary, err
+	}
+	discoveryDB := newDiscoveryDB(context.Background(), sqlDB, discoveryQueryTimeout())
+	// Binary logs are listed oldest first
+	err = discoveryDB.QueryRowsMap("show binary logs", func(m sqlutils.RowMap) error {
+		if summary.FirstBinlogFile == "" {
+			summary.FirstBinlogFile = m.GetString("Log_name")
+		}
+		summary.CountBinlogFiles++
+		summary.TotalBinlogSize += m.GetInt64("File_size")
+		return nil
+	})
+	return summary, err
+}
+
+// WriteBinlogSummary stores given binary logs summary, timestamped now
+func WriteBinlogSummary(summary *BinlogSummary) error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			replace
+				into master_binlog_summary (
+					hostname, port, collected_timestamp, count_binlog_files, total_binlog_size, first_binlog_file
+				) values (
+					?, ?, NOW(), ?, ?, ?
+				)
+			`, summary.Key.Hostname, summary.Key.Port, summary.CountBinlogFiles, summary.TotalBinlogSize, summary.FirstBinlogFile,
+		)
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
+// ReadBinlogSummary reads the last collected binary logs summary of given instance. Returns nil if none collected.
+func ReadBinlogSummary(instanceKey *InstanceKey) (summary *BinlogSummary, err error) {
+	query := `
+		select
+			hostname,
+			port,
+			collected_timestamp,
+			count_binlog_files,
+			total_binlog_size,
+			first_binlog_file
+		from
+			master_binlog_summary
+		where
+			hostname = ?
+			and port = ?
+		`
+	err = db.QueryOrchestrator(query, sqlutils.Args(instanceKey.Hostname, instanceKey.Port), func(m sqlutils.RowMap) error {
+		summary = &BinlogSummary{
+			Key:                InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")},
+			CollectedTimestamp: m.GetString("collected_timestamp"),
+			CountBinlogFiles:   m.GetUint("count_binlog_files"),
+			TotalBinlogSize:    m.GetInt64("total_binlog_size"),
+			FirstBinlogFile:    m.GetString("first_binlog_file"),
+		}
+		return nil
+	})
+	return summary, log.Errore(err)
+}
+
+// ExpireBinlogSummaries removes binary logs summaries which have not been collected for a while
+func ExpireBinlogSummaries() error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+				delete from master_binlog_summary
+				where collected_timestamp < NOW() - INTERVAL ? MINUTE
+				`, binlogSummaryExpiryIntervals*config.Config.BinlogSummaryIntervalMinutes,
+		)
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
+// CollectMastersBinlogSummaries collects and stores the binary logs summary of all writeable cluster masters.
+// This runs every BinlogSummaryIntervalMinutes rather than upon each poll.
+func CollectMastersBinlogSummaries() error {
+	masters, err := ReadWriteableClustersMasters()
+	if err != nil {
+		return log.Errore(err)
+	}
+	for _, master := range masters {
+		if !master.LogBinEnabled || !master.IsLastCheckValid {
+			continue
+		}
+		summary, err := ReadTopologyBinlogSummary(&master.Key)
+		if err != nil {
+			log.Errorf("CollectMastersBinlogSummaries: %+v: %+v", master.Key, err)
+			continue
+		}
+		if err := WriteBinlogSummary(summary); err != nil {
+			continue
+		}
+	}
+	return ExpireBinlogSummaries()
+}
+
+// ReadBinlogRetention returns the binary log retention margin of given master, based on its last collected
+// binary logs summary. Returns nil if no summary has been collected.
+func ReadBinlogRetention(masterKey *InstanceKey) (*BinlogRetention, error) {
+	summary, err := ReadBinlogSummary(masterKey)
+	if err != nil || summary == nil {
+		return nil, err
+	}
+	replicas, err := ReadReplicaInstances(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	return computeBinlogRetention(summary, replicas), nil
+}
+
+// ReadClusterBinlogRetention returns the binary log retention margin of the writeable master of given cluster.
+// Returns nil if no summary has been collected.
+func ReadClusterBinlogRetention(clusterName string) (*BinlogRetention, error) {
+	masters, err := ReadClusterWriteableMaster(clusterName)
+	if err != nil || len(masters) == 0 {
+		return nil, err
+	}
+	return ReadBinlogRetention(&masters[0].Key)
+}
@@ -0,0 +1,48 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestComputeBinlogRetention(t *testing.T) {
+	summary := &BinlogSummary{Key: key1, CountBinlogFiles: 5, FirstBinlogFile: "mysql-bin.000100"}
+	newReplica := func(key InstanceKey, logFile string) *Instance {
+		return &Instance{Key: key, MasterKey: key1, IsLastCheckValid: true, ReadBinlogCoordinates: BinlogCoordinates{LogFile: logFile, LogPos: 4}}
+	}
+	{
+		retention := computeBinlogRetention(summary, [](*Instance){})
+		test.S(t).ExpectTrue(retention.SlowestReplicaKey == nil)
+		test.S(t).ExpectEquals(retention.Summary.CountBinlogFiles, uint(5))
+	}
+	{
+		replicas := [](*Instance){newReplica(key2, "mysql-bin.000104"), newReplica(key3, "mysql-bin.000103")}
+		retention := computeBinlogRetention(summary, replicas)
+		test.S(t).ExpectEquals(*retention.SlowestReplicaKey, key3)
+		test.S(t).ExpectEquals(retention.SlowestReplicaBinlogFile, "mysql-bin.000103")
+		test.S(t).ExpectEquals(retention.MarginFiles, 3)
+	}
+	{
+		replicas := [](*Instance){newReplica(key2, "mysql-bin.000104"), newReplica(key3, "mysql-bin.000098")}
+		replicas[1].IsLastCheckValid = false
+		test.S(t).ExpectEquals(computeBinlogRetention(summary, replicas).MarginFiles, 4)
+		replicas[1].IsLastCheckValid = true
+		test.S(t).ExpectEquals(computeBinlogRetention(summary, replicas).MarginFiles, -2)
+	}
+}
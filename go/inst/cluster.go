@@ -86,6 +86,10 @@ type ClusterInfo struct {
 	HeuristicLag                           int64
 	HasAutomatedMasterRecovery             bool
 	HasAutomatedIntermediateMasterRecovery bool
+	DataCenters                            []string         // sorted, distinct data centers of the cluster's instances
+	PhysicalEnvironments                   []string         // sorted, distinct physical environments of the cluster's instances
+	CoMasters                              []string         // sorted keys of the cluster's co-masters, if it runs a master-master pair
+	BinlogRetention                        *BinlogRetention // master's binary log retention margin; only read by the cluster-info API
 }
 
 // ReadRecoveryInfo
@@ -34,6 +34,8 @@ func ReadClusterNameByAlias(alias string) (clusterName string, err error) {
 		where
 			alias = ?
 			or cluster_name = ?
+		order by
+			last_registered asc
 		`
 	err = db.QueryOrchestrator(query, sqlutils.Args(alias, alias), func(m sqlutils.RowMap) error {
 		clusterName = m.GetString("cluster_name")
@@ -76,7 +78,7 @@ func ReadAliasByClusterName(clusterName string) (alias string, err error) {
 		alias = m.GetString("alias")
 		return nil
 	})
-	return clusterName, err
+	return alias, err
 }
 
 // WriteClusterAlias will write (and override) a single cluster name mapping
@@ -109,9 +111,124 @@ func writeClusterAliasManualOverride(clusterName string, alias string) error {
 	return ExecDBWriteFunc(writeFunc)
 }
 
+// clusterAliasExpiryMinutes is the time after which the alias of a vanished cluster stops resolving
+const clusterAliasExpiryMinutes = 10
+
+// readClusterAliasMap returns the current cluster_name => alias mapping
+func readClusterAliasMap() (aliases map[string]string, err error) {
+	aliases = make(map[string]string)
+	query := `
+		select
+			cluster_name,
+			alias
+		from
+			cluster_alias
+		`
+	err = db.QueryOrchestratorRowsMap(query, func(m sqlutils.RowMap) error {
+		aliases[m.GetString("cluster_name")] = m.GetString("alias")
+		return nil
+	})
+	return aliases, log.Errore(err)
+}
+
+// expireClusterAliases removes aliases of clusters that no longer exist (e.g. merged into another cluster).
+// Such an alias is removed once expired, or immediately when an existing cluster has taken over the alias
+// (e.g. the cluster was split and the alias now belongs to a new cluster).
+func expireClusterAliases() error {
+	type clusterAliasRow struct {
+		clusterName   string
+		alias         string
+		isExpired     bool
+		clusterExists bool
+	}
+	rows := []clusterAliasRow{}
+	query := `
+		select
+			cluster_alias.cluster_name,
+			cluster_alias.alias,
+			cluster_alias.last_registered < now() - interval ? minute as is_expired,
+			exists (
+				select 1 from database_instance where database_instance.cluster_name = cluster_alias.cluster_name
+			) as cluster_exists
+		from
+			cluster_alias
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(clusterAliasExpiryMinutes), func(m sqlutils.RowMap) error {
+		rows = append(rows, clusterAliasRow{
+			clusterName:   m.GetString("cluster_name"),
+			alias:         m.GetString("alias"),
+			isExpired:     m.GetBool("is_expired"),
+			clusterExists: m.GetBool("cluster_exists"),
+		})
+		return nil
+	})
+	if err != nil {
+		return log.Errore(err)
+	}
+	liveAliases := make(map[string]bool)
+	for _, row := range rows {
+		if row.clusterExists {
+			liveAliases[row.alias] = true
+		}
+	}
+	for _, row := range rows {
+		if row.clusterExists {
+			continue
+		}
+		if !row.isExpired && !liveAliases[row.alias] {
+			continue
+		}
+		clusterName := row.clusterName
+		writeFunc := func() error {
+			_, err := db.ExecOrchestrator(`
+				delete from cluster_alias where cluster_name = ?
+				`, clusterName)
+			return log.Errore(err)
+		}
+		if ferr := ExecDBWriteFunc(writeFunc); ferr != nil {
+			err = ferr
+		}
+	}
+	return err
+}
+
+// auditClusterAliasChanges audits aliases which changed, moved between clusters or expired, compared
+// with given previous cluster_name => alias mapping. New aliases of new clusters are not audited.
+func auditClusterAliasChanges(previousAliases map[string]string) error {
+	aliases, err := readClusterAliasMap()
+	if err != nil {
+		return err
+	}
+	previousClusterByAlias := make(map[string]string)
+	for clusterName, alias := range previousAliases {
+		previousClusterByAlias[alias] = clusterName
+	}
+	for clusterName, alias := range aliases {
+		previousAlias, found := previousAliases[clusterName]
+		if found && previousAlias != alias {
+			AuditOperation("cluster-alias-change", nil, fmt.Sprintf("cluster %s: alias changed from %s to %s", clusterName, previousAlias, alias))
+		} else if !found {
+			if previousClusterName, ok := previousClusterByAlias[alias]; ok && previousClusterName != clusterName {
+				AuditOperation("cluster-alias-change", nil, fmt.Sprintf("alias %s moved from cluster %s to cluster %s", alias, previousClusterName, clusterName))
+			}
+		}
+	}
+	for clusterName, alias := range previousAliases {
+		if _, found := aliases[clusterName]; !found {
+			AuditOperation("cluster-alias-expire", nil, fmt.Sprintf("cluster %s: alias %s expired", clusterName, alias))
+		}
+	}
+	return nil
+}
+
 // UpdateClusterAliases writes down the cluster_alias table based on information
-// gained from database_instance
+// gained from database_instance. Aliases of vanished clusters are expired, and alias
+// transitions are audited.
 func UpdateClusterAliases() error {
+	previousAliases, err := readClusterAliasMap()
+	if err != nil {
+		return err
+	}
 	writeFunc := func() error {
 		_, err := db.ExecOrchestrator(`
 			replace into
@@ -160,7 +277,10 @@ func UpdateClusterAliases() error {
 	if err := ExecDBWriteFunc(writeFunc); err != nil {
 		return err
 	}
-	return nil
+	if err := expireClusterAliases(); err != nil {
+		return err
+	}
+	return auditClusterAliasChanges(previousAliases)
 }
 
 // ReplaceAliasClusterName replaces alis mapping of one cluster name onto a new cluster name.
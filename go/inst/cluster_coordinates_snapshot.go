@@ -0,0 +1,64 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"time"
+)
+
+const clusterCoordinatesSnapshotTimestampFormat = "2006-01-02 15:04:05"
+
+// InstanceCoordinatesSnapshot is the binary log position of a single instance, as read in a cluster coordinates snapshot
+type InstanceCoordinatesSnapshot struct {
+	Key                   InstanceKey
+	Success               bool
+	Error                 string
+	SelfBinlogCoordinates BinlogCoordinates
+	ExecutedGtidSet       string
+}
+
+// ClusterCoordinatesSnapshot lists the binary log positions of all instances of a cluster, read within
+// a short period of time. Instances are listed by their hostname:port
+type ClusterCoordinatesSnapshot struct {
+	ClusterName       string
+	SnapshotTimestamp string
+	Instances         map[string]*InstanceCoordinatesSnapshot
+}
+
+// NewClusterCoordinatesSnapshot creates an empty snapshot for given cluster, timestamped now
+func NewClusterCoordinatesSnapshot(clusterName string) *ClusterCoordinatesSnapshot {
+	return &ClusterCoordinatesSnapshot{
+		ClusterName:       clusterName,
+		SnapshotTimestamp: time.Now().Format(clusterCoordinatesSnapshotTimestampFormat),
+		Instances:         make(map[string]*InstanceCoordinatesSnapshot),
+	}
+}
+
+// AddInstance adds (or overwrites) given instance's coordinates
+func (this *ClusterCoordinatesSnapshot) AddInstance(instanceSnapshot *InstanceCoordinatesSnapshot) {
+	this.Instances[instanceSnapshot.Key.StringCode()] = instanceSnapshot
+}
+
+// CountFailed returns the number of instances which could not be read
+func (this *ClusterCoordinatesSnapshot) CountFailed() (count int) {
+	for _, instanceSnapshot := range this.Instances {
+		if !instanceSnapshot.Success {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,151 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/github/orchestrator/go/db"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// ReadTopologyInstanceCoordinates reads the current binary log coordinates and executed GTID set of given
+// instance, with each query bound by given timeout.
+func ReadTopologyInstanceCoordinates(instance *Instance, timeout time.Duration) *InstanceCoordinatesSnapshot {
+	instanceSnapshot := &InstanceCoordinatesSnapshot{Key: instance.Key}
+	err := func() error {
+		sqlDB, err := db.OpenDiscovery(instance.Key.Hostname, instance.Key.Port)
+		if err != nil {
+			return err
+		}
+		discoveryDB := newDiscoveryDB(context.Background(), sqlDB, timeout)
+		found := false
+		// Coordinates and GTID set come from the very same statement, hence are consistent with each other
+		err = discoveryDB.QueryRowsMap("show master status", func(m sqlutils.RowMap) error {
+			instanceSnapshot.SelfBinlogCoordinates.LogFile = m.GetString("File")
+			instanceSnapshot.SelfBinlogCoordinates.LogPos = m.GetInt64("Position")
+			instanceSnapshot.ExecutedGtidSet = strings.Replace(m.GetStringD("Executed_Gtid_Set", ""), "\n", "", -1)
+			found = true
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("no binary log coordinates found on %+v; is binary logging enabled?", instance.Key)
+		}
+		if instance.IsMariaDB() {
+			return discoveryDB.QueryRow("select @@global.gtid_binlog_pos").Scan(&instanceSnapshot.ExecutedGtidSet)
+		}
+		return nil
+	}()
+	if err != nil {
+		instanceSnapshot.Error = err.Error()
+	} else {
+		instanceSnapshot.Success = true
+	}
+	return instanceSnapshot
+}
+
+// WriteClusterCoordinatesSnapshot stores given snapshot, replacing any previous snapshot of the same cluster
+func WriteClusterCoordinatesSnapshot(snapshot *ClusterCoordinatesSnapshot) error {
+	deleteStatement := `delete from cluster_coordinates_snapshot where cluster_name = ?`
+	insertStatement := `
+		insert into cluster_coordinates_snapshot (
+			cluster_name, hostname, port, snapshot_timestamp, read_success, read_error, binary_log_file, binary_log_pos, executed_gtid_set
+		) values (
+			?, ?, ?, ?, ?, ?, ?, ?, ?
+		)
+		`
+	dbh, err := db.OpenOrchestrator()
+	if err != nil {
+		return log.Errore(err)
+	}
+	tx, err := dbh.Begin()
+	if err != nil {
+		return log.Errore(err)
+	}
+	if _, err := tx.Exec(deleteStatement, snapshot.ClusterName); err != nil {
+		tx.Rollback()
+		return log.Errore(err)
+	}
+	for _, instanceSnapshot := range snapshot.Instances {
+		_, err := tx.Exec(insertStatement,
+			snapshot.ClusterName,
+			instanceSnapshot.Key.Hostname,
+			instanceSnapshot.Key.Port,
+			snapshot.SnapshotTimestamp,
+			instanceSnapshot.Success,
+			instanceSnapshot.Error,
+			instanceSnapshot.SelfBinlogCoordinates.LogFile,
+			instanceSnapshot.SelfBinlogCoordinates.LogPos,
+			instanceSnapshot.ExecutedGtidSet,
+		)
+		if err != nil {
+			tx.Rollback()
+			return log.Errore(err)
+		}
+	}
+	return log.Errore(tx.Commit())
+}
+
+// ReadLastClusterCoordinatesSnapshot reads the most recent coordinates snapshot taken on given cluster.
+// Returns nil when no snapshot has been taken.
+func ReadLastClusterCoordinatesSnapshot(clusterName string) (*ClusterCoordinatesSnapshot, error) {
+	var snapshot *ClusterCoordinatesSnapshot
+	query := `
+		select
+			hostname,
+			port,
+			snapshot_timestamp,
+			read_success,
+			read_error,
+			binary_log_file,
+			binary_log_pos,
+			executed_gtid_set
+		from
+			cluster_coordinates_snapshot
+		where
+			cluster_name = ?
+		order by
+			hostname, port
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(clusterName), func(m sqlutils.RowMap) error {
+		if snapshot == nil {
+			snapshot = &ClusterCoordinatesSnapshot{
+				ClusterName:       clusterName,
+				SnapshotTimestamp: m.GetString("snapshot_timestamp"),
+				Instances:         make(map[string]*InstanceCoordinatesSnapshot),
+			}
+		}
+		instanceSnapshot := &InstanceCoordinatesSnapshot{
+			Key:             InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")},
+			Success:         m.GetBool("read_success"),
+			Error:           m.GetString("read_error"),
+			ExecutedGtidSet: m.GetString("executed_gtid_set"),
+		}
+		instanceSnapshot.SelfBinlogCoordinates.LogFile = m.GetString("binary_log_file")
+		instanceSnapshot.SelfBinlogCoordinates.LogPos = m.GetInt64("binary_log_pos")
+		snapshot.AddInstance(instanceSnapshot)
+		return nil
+	})
+	return snapshot, log.Errore(err)
+}
@@ -0,0 +1,73 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestClusterCoordinatesSnapshotCountFailed(t *testing.T) {
+	snapshot := NewClusterCoordinatesSnapshot("cluster")
+	test.S(t).ExpectEquals(len(snapshot.Instances), 0)
+	test.S(t).ExpectEquals(snapshot.CountFailed(), 0)
+
+	snapshot.AddInstance(&InstanceCoordinatesSnapshot{Key: key1, Error: "not read"})
+	snapshot.AddInstance(&InstanceCoordinatesSnapshot{Key: key2, Error: "not read"})
+	test.S(t).ExpectEquals(len(snapshot.Instances), 2)
+	test.S(t).ExpectEquals(snapshot.CountFailed(), 2)
+
+	snapshot.AddInstance(&InstanceCoordinatesSnapshot{Key: key1, Success: true, SelfBinlogCoordinates: BinlogCoordinates{LogFile: "mysql-bin.000017", LogPos: 4}})
+	test.S(t).ExpectEquals(len(snapshot.Instances), 2)
+	test.S(t).ExpectEquals(snapshot.CountFailed(), 1)
+	test.S(t).ExpectTrue(snapshot.Instances[key1.StringCode()].Success)
+}
+
+func TestWriteClusterCoordinatesSnapshot(t *testing.T) {
+	defer useSQLiteBackend(t)()
+
+	snapshot, err := ReadLastClusterCoordinatesSnapshot("cluster")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(snapshot == nil)
+
+	snapshot = NewClusterCoordinatesSnapshot("cluster")
+	snapshot.AddInstance(&InstanceCoordinatesSnapshot{Key: key1, Success: true, SelfBinlogCoordinates: BinlogCoordinates{LogFile: "mysql-bin.000017", LogPos: 4}, ExecutedGtidSet: "00020194-3333-3333-3333-333333333333:1-7"})
+	snapshot.AddInstance(&InstanceCoordinatesSnapshot{Key: key2, Error: "not read"})
+	test.S(t).ExpectNil(WriteClusterCoordinatesSnapshot(snapshot))
+
+	stored, err := ReadLastClusterCoordinatesSnapshot("cluster")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(stored.ClusterName, "cluster")
+	test.S(t).ExpectEquals(len(stored.Instances), 2)
+	test.S(t).ExpectEquals(stored.CountFailed(), 1)
+	test.S(t).ExpectEquals(*stored.Instances[key1.StringCode()], *snapshot.Instances[key1.StringCode()])
+	test.S(t).ExpectEquals(stored.Instances[key2.StringCode()].Error, "not read")
+
+	// a later snapshot replaces the former one
+	snapshot = NewClusterCoordinatesSnapshot("cluster")
+	snapshot.AddInstance(&InstanceCoordinatesSnapshot{Key: key2, Success: true})
+	test.S(t).ExpectNil(WriteClusterCoordinatesSnapshot(snapshot))
+	stored, err = ReadLastClusterCoordinatesSnapshot("cluster")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(stored.Instances), 1)
+	test.S(t).ExpectEquals(stored.CountFailed(), 0)
+
+	stored, err = ReadLastClusterCoordinatesSnapshot("other")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(stored == nil)
+}
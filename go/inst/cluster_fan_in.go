@@ -0,0 +1,218 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/openark/golib/log"
+)
+
+// FanInMaster is an intermediate master, along with its current and its suggested number of replicas
+type FanInMaster struct {
+	Key                    InstanceKey
+	MasterKey              InstanceKey
+	DataCenter             string
+	Region                 string
+	CountReplicas          int
+	SuggestedCountReplicas int
+}
+
+// FanInMove is a suggested relocation of a replica from one intermediate master onto a sibling intermediate master
+type FanInMove struct {
+	Key     InstanceKey
+	FromKey InstanceKey
+	ToKey   InstanceKey
+}
+
+// FanInReport lists the intermediate masters of a cluster and the relocations which would balance their replicas
+type FanInReport struct {
+	ClusterName    string
+	Masters        []FanInMaster
+	SuggestedMoves []FanInMove
+}
+
+// fanInGroup identifies sibling intermediate masters among which replicas may be balanced
+type fanInGroup struct {
+	MasterKey  InstanceKey
+	DataCenter string
+}
+
+// isFanInTarget returns true when given intermediate master may take additional replicas
+func isFanInTarget(intermediateMaster *Instance) bool {
+	return intermediateMaster.IsLastCheckValid && !intermediateMaster.IsDowntimed && intermediateMaster.SQLDelay == 0
+}
+
+// isFanInMovable returns true when given replica may be relocated below given intermediate master.
+// Replicas with replicas of their own are never moved, nor are registered candidates (must/prefer promotion
+// rule), which are left where the operator placed them. Replicas only move within their data center and region.
+func isFanInMovable(replica *Instance, target *Instance) bool {
+	if !replica.IsLastCheckValid || replica.IsDowntimed {
+		return false
+	}
+	if isPreferredPromotionRule(replica.PromotionRule) {
+		return false
+	}
+	if replica.DataCenter != target.DataCenter || replica.Region != target.Region {
+		return false
+	}
+	if canReplicate, _ := replica.CanReplicateFrom(target); !canReplicate {
+		return false
+	}
+	if checkMoveReplicationFilters(replica, target) != nil || checkMoveVersionOrdering(replica, target) != nil {
+		return false
+	}
+	return true
+}
+
+// computeFanInReport computes the fan-in report of given cluster instances. Intermediate masters are grouped by
+// their own master and data center. Within each group, replicas are moved from the most loaded intermediate
+// master onto the least loaded one, as long as the two differ by more than a single replica.
+func computeFanInReport(clusterName string, instances [](*Instance)) *FanInReport {
+	report := &FanInReport{ClusterName: clusterName, Masters: []FanInMaster{}, SuggestedMoves: []FanInMove{}}
+
+	sortedInstances := append([](*Instance){}, instances...)
+	sort.Slice(sortedInstances, func(i, j int) bool {
+		return sortedInstances[i].Key.SmallerThan(&sortedInstances[j].Key)
+	})
+	replicasByMaster := make(map[InstanceKey]([](*Instance)))
+	for _, instance := range sortedInstances {
+		if instance.IsReplica() {
+			replicasByMaster[instance.MasterKey] = append(replicasByMaster[instance.MasterKey], instance)
+		}
+	}
+	groups := make(map[fanInGroup]([](*Instance)))
+	groupsOrder := []fanInGroup{}
+	for _, instance := range sortedInstances {
+		if !instance.IsReplica() || len(replicasByMaster[instance.Key]) == 0 {
+			continue
+		}
+		group := fanInGroup{MasterKey: instance.MasterKey, DataCenter: instance.DataCenter}
+		if _, found := groups[group]; !found {
+			groupsOrder = append(groupsOrder, group)
+		}
+		groups[group] = append(groups[group], instance)
+	}
+
+	originalCounts := make(map[InstanceKey]int)
+	counts := make(map[InstanceKey]int)
+	for _, group := range groupsOrder {
+		intermediateMasters := groups[group]
+		for _, intermediateMaster := range intermediateMasters {
+			originalCounts[intermediateMaster.Key] = len(replicasByMaster[intermediateMaster.Key])
+			counts[intermediateMaster.Key] = originalCounts[intermediateMaster.Key]
+		}
+		// Each move narrows the gap between two intermediate masters, hence this terminates
+		for moved := true; moved; {
+			moved = false
+			sort.SliceStable(intermediateMasters, func(i, j int) bool {
+				return counts[intermediateMasters[i].Key] < counts[intermediateMasters[j].Key]
+			})
+			for i := len(intermediateMasters) - 1; i > 0 && !moved; i-- {
+				source := intermediateMasters[i]
+				for j := 0; j < i && !moved; j++ {
+					target := intermediateMasters[j]
+					if counts[source.Key]-counts[target.Key] <= 1 {
+						break
+					}
+					if !isFanInTarget(target) {
+						continue
+					}
+					for k, replica := range replicasByMaster[source.Key] {
+						if len(replicasByMaster[replica.Key]) > 0 || !isFanInMovable(replica, target) {
+							continue
+						}
+						report.SuggestedMoves = append(report.SuggestedMoves, FanInMove{Key: replica.Key, FromKey: source.Key, ToKey: target.Key})
+						replicasByMaster[source.Key] = append(replicasByMaster[source.Key][:k], replicasByMaster[source.Key][k+1:]...)
+						counts[source.Key]--
+						counts[target.Key]++
+						moved = true
+						break
+					}
+				}
+			}
+		}
+	}
+
+	for _, instance := range sortedInstances {
+		if _, found := counts[instance.Key]; !found {
+			continue
+		}
+		report.Masters = append(report.Masters, FanInMaster{
+			Key:                    instance.Key,
+			MasterKey:              instance.MasterKey,
+			DataCenter:             instance.DataCenter,
+			Region:                 instance.Region,
+			CountReplicas:          originalCounts[instance.Key],
+			SuggestedCountReplicas: counts[instance.Key],
+		})
+	}
+	return report
+}
+
+// ClusterFanInReport returns the number of replicas of each intermediate master of given cluster, and suggests
+// relocations which would balance replicas among sibling intermediate masters. Nothing is changed.
+func ClusterFanInReport(clusterName string) (*FanInReport, error) {
+	instances, err := ReadClusterInstances(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	return computeFanInReport(clusterName, instances), nil
+}
+
+// checkRebalanceReplicationLag returns an error when given instance lags above ReasonableMaintenanceReplicationLagSeconds
+func checkRebalanceReplicationLag(instanceKey *InstanceKey) error {
+	instance, err := ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return err
+	}
+	if !instance.HasReasonableMaintenanceReplicationLag() {
+		return fmt.Errorf("%+v lags %d seconds, above ReasonableMaintenanceReplicationLagSeconds (%d)", *instanceKey, instance.SlaveLagSeconds.Int64, config.Config.ReasonableMaintenanceReplicationLagSeconds)
+	}
+	return nil
+}
+
+// ApplyRebalance performs the relocations suggested by ClusterFanInReport, one at a time. Before each move, the
+// replica and both intermediate masters are verified to have reasonable replication lag. The first failure halts
+// the operation; the moves performed until then are returned.
+func ApplyRebalance(clusterName string) (moves []FanInMove, err error) {
+	report, err := ClusterFanInReport(clusterName)
+	if err != nil {
+		return moves, err
+	}
+	if len(report.SuggestedMoves) == 0 {
+		return moves, nil
+	}
+	AuditOperation("apply-rebalance", nil, fmt.Sprintf("cluster: %s, moves: %d; starting", clusterName, len(report.SuggestedMoves)))
+	for _, move := range report.SuggestedMoves {
+		for _, instanceKey := range []InstanceKey{move.Key, move.FromKey, move.ToKey} {
+			if err := checkRebalanceReplicationLag(&instanceKey); err != nil {
+				AuditOperation("apply-rebalance", &move.Key, fmt.Sprintf("halting after %d out of %d moves: %+v", len(moves), len(report.SuggestedMoves), err))
+				return moves, fmt.Errorf("apply-rebalance: halted after %d out of %d moves: %+v", len(moves), len(report.SuggestedMoves), err)
+			}
+		}
+		if _, err := RelocateBelow(&move.Key, &move.ToKey, false); err != nil {
+			AuditOperation("apply-rebalance", &move.Key, fmt.Sprintf("failed relocating below %+v; halting after %d out of %d moves: %+v", move.ToKey, len(moves), len(report.SuggestedMoves), err))
+			return moves, fmt.Errorf("apply-rebalance: failed relocating %+v below %+v, halted after %d out of %d moves: %+v", move.Key, move.ToKey, len(moves), len(report.SuggestedMoves), err)
+		}
+		moves = append(moves, move)
+	}
+	log.Infof("apply-rebalance: relocated %d replicas of %s", len(moves), clusterName)
+	return moves, nil
+}
@@ -0,0 +1,81 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func generateFanInTestInstances() (instances [](*Instance), instancesMap map[string](*Instance)) {
+	leaves, instancesMap := generateTestInstances()
+	master := &Instance{Key: key1, Version: "5.6.7", Binlog_format: "STATEMENT"}
+	intermediateMaster2 := &Instance{Key: key2, ServerID: 2, MasterKey: key1, Version: "5.6.7", Binlog_format: "STATEMENT"}
+	intermediateMaster3 := &Instance{Key: key3, ServerID: 3, MasterKey: key1, Version: "5.6.7", Binlog_format: "STATEMENT"}
+	instances = append([](*Instance){master, intermediateMaster2, intermediateMaster3}, leaves...)
+	applyGeneralGoodToGoReplicationParams(instances)
+	for _, instance := range instances {
+		instance.DataCenter = "dc1"
+		instance.PromotionRule = NeutralPromoteRule
+		instance.ReadBinlogCoordinates = BinlogCoordinates{LogFile: "mysql.000001", LogPos: 4}
+	}
+	for _, leaf := range leaves {
+		leaf.MasterKey = key2
+	}
+	for _, instance := range []*Instance{master, intermediateMaster2, intermediateMaster3} {
+		instancesMap[instance.Key.StringCode()] = instance
+	}
+	instancesMap[i830Key.StringCode()].MasterKey = key3
+	return instances, instancesMap
+}
+
+func TestComputeFanInReport(t *testing.T) {
+	instances, _ := generateFanInTestInstances()
+	report := computeFanInReport("cluster", instances)
+	test.S(t).ExpectEquals(len(report.Masters), 2)
+	test.S(t).ExpectEquals(report.Masters[0].Key, key2)
+	test.S(t).ExpectEquals(report.Masters[0].CountReplicas, 5)
+	test.S(t).ExpectEquals(report.Masters[0].SuggestedCountReplicas, 3)
+	test.S(t).ExpectEquals(report.Masters[1].CountReplicas, 1)
+	test.S(t).ExpectEquals(report.Masters[1].SuggestedCountReplicas, 3)
+	test.S(t).ExpectEquals(len(report.SuggestedMoves), 2)
+	test.S(t).ExpectEquals(report.SuggestedMoves[0], FanInMove{Key: i710Key, FromKey: key2, ToKey: key3})
+	test.S(t).ExpectEquals(report.SuggestedMoves[1], FanInMove{Key: i720Key, FromKey: key2, ToKey: key3})
+}
+
+func TestComputeFanInReportRespectsPromotionRulesAndDataCenters(t *testing.T) {
+	instances, instancesMap := generateFanInTestInstances()
+	instancesMap[i710Key.StringCode()].DataCenter = "dc2"
+	instancesMap[i720Key.StringCode()].PromotionRule = PreferPromoteRule
+	report := computeFanInReport("cluster", instances)
+	test.S(t).ExpectEquals(len(report.SuggestedMoves), 2)
+	test.S(t).ExpectEquals(report.SuggestedMoves[0].Key, i730Key)
+	test.S(t).ExpectEquals(report.SuggestedMoves[1].Key, i810Key)
+
+	instancesMap[key3.StringCode()].DataCenter = "dc2"
+	report = computeFanInReport("cluster", instances)
+	test.S(t).ExpectEquals(len(report.SuggestedMoves), 0)
+}
+
+func TestComputeFanInReportBalanced(t *testing.T) {
+	instances, instancesMap := generateFanInTestInstances()
+	instancesMap[i710Key.StringCode()].MasterKey = key3
+	instancesMap[i720Key.StringCode()].MasterKey = key3
+	report := computeFanInReport("cluster", instances)
+	test.S(t).ExpectEquals(len(report.Masters), 2)
+	test.S(t).ExpectEquals(len(report.SuggestedMoves), 0)
+}
@@ -0,0 +1,147 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/openark/golib/log"
+)
+
+// clusterReadOnlyConcurrency is the number of instances of a cluster concurrently set read_only by SetClusterReadOnly
+const clusterReadOnlyConcurrency = 10
+
+// setInstanceReadOnly sets an instance read_only or writeable; overridden in tests
+var setInstanceReadOnly = SetReadOnly
+
+// ClusterReadOnlyResult is the outcome of setting or clearing read_only on a single instance of a cluster
+type ClusterReadOnlyResult struct {
+	Key      InstanceKey
+	ReadOnly bool
+	Success  bool
+	Error    string `json:",omitempty"`
+}
+
+// setInstancesReadOnly sets or clears read_only on given instances, in parallel, waiting up to
+// InstanceBulkOperationsWaitTimeoutSeconds overall. Instances not handled by then are reported as timed out,
+// the operation on them possibly still taking place.
+func setInstancesReadOnly(instances [](*Instance), readOnly bool) (results []ClusterReadOnlyResult) {
+	timeout := time.Duration(config.Config.InstanceBulkOperationsWaitTimeoutSeconds) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	setReadOnly := setInstanceReadOnly
+	timedOutResult := func(instance *Instance) ClusterReadOnlyResult {
+		return ClusterReadOnlyResult{Key: instance.Key, ReadOnly: readOnly, Error: fmt.Sprintf("timed out after %+v", timeout)}
+	}
+	concurrencyChan := make(chan bool, clusterReadOnlyConcurrency)
+	// buffered, such that operations completing past the timeout do not block
+	resultsChans := make([]chan ClusterReadOnlyResult, len(instances))
+	for i, instance := range instances {
+		instance := instance
+		resultsChan := make(chan ClusterReadOnlyResult, 1)
+		resultsChans[i] = resultsChan
+		go func() {
+			// Past the timeout, the caller is told the instance was not handled: do not act on it anymore
+			select {
+			case concurrencyChan <- true:
+			case <-ctx.Done():
+				resultsChan <- timedOutResult(instance)
+				return
+			}
+			defer func() { <-concurrencyChan }()
+			if ctx.Err() != nil {
+				resultsChan <- timedOutResult(instance)
+				return
+			}
+
+			result := ClusterReadOnlyResult{Key: instance.Key, ReadOnly: readOnly, Success: true}
+			if _, err := setReadOnly(&instance.Key, readOnly); err != nil {
+				result.Success = false
+				result.Error = err.Error()
+			}
+			resultsChan <- result
+		}()
+	}
+	for i, instance := range instances {
+		select {
+		case result := <-resultsChans[i]:
+			results = append(results, result)
+		case <-ctx.Done():
+			// Past the timeout, still collect instances which did complete
+			select {
+			case result := <-resultsChans[i]:
+				results = append(results, result)
+			default:
+				results = append(results, timedOutResult(instance))
+			}
+		}
+	}
+	return results
+}
+
+// countFailedReadOnlyResults returns the number of unsuccessful results
+func countFailedReadOnlyResults(results []ClusterReadOnlyResult) (countFailed int) {
+	for _, result := range results {
+		if !result.Success {
+			countFailed++
+		}
+	}
+	return countFailed
+}
+
+// SetClusterReadOnly sets all instances of given cluster, including its master, read_only, as an emergency
+// measure against writes. Unreachable instances do not stop the operation; the outcome is reported per instance.
+func SetClusterReadOnly(clusterName string) (results []ClusterReadOnlyResult, err error) {
+	instances, err := ReadClusterInstances(clusterName)
+	if err != nil {
+		return results, log.Errore(err)
+	}
+	if len(instances) == 0 {
+		return results, log.Errorf("SetClusterReadOnly: no instances found for cluster %s", clusterName)
+	}
+	results = setInstancesReadOnly(instances, true)
+	countFailed := countFailedReadOnlyResults(results)
+	AuditOperation("set-cluster-read-only", nil, fmt.Sprintf("cluster: %s, instances: %d, failed: %d", clusterName, len(results), countFailed))
+	if countFailed > 0 {
+		return results, log.Errorf("SetClusterReadOnly: %d out of %d instances of cluster %s could not be set read_only", countFailed, len(results), clusterName)
+	}
+	return results, nil
+}
+
+// SetClusterWriteableMasterOnly makes the master of given cluster writeable, leaving all other instances as they
+// are. This is the way back from SetClusterReadOnly.
+func SetClusterWriteableMasterOnly(clusterName string) (results []ClusterReadOnlyResult, err error) {
+	masters, err := ReadClusterMaster(clusterName)
+	if err != nil {
+		return results, log.Errore(err)
+	}
+	if len(masters) == 0 {
+		return results, log.Errorf("SetClusterWriteableMasterOnly: no master found for cluster %s", clusterName)
+	}
+	// With co-masters, ReadClusterMaster lists the writeable one, if any, first
+	results = setInstancesReadOnly(masters[0:1], false)
+	countFailed := countFailedReadOnlyResults(results)
+	AuditOperation("set-cluster-writeable-master-only", &masters[0].Key, fmt.Sprintf("cluster: %s, failed: %d", clusterName, countFailed))
+	if countFailed > 0 {
+		return results, log.Errorf("SetClusterWriteableMasterOnly: master %+v of cluster %s could not be set writeable: %s", masters[0].Key, clusterName, results[0].Error)
+	}
+	return results, nil
+}
@@ -0,0 +1,91 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestCountFailedReadOnlyResults(t *testing.T) {
+	results := []ClusterReadOnlyResult{
+		{Key: InstanceKey{Hostname: "master", Port: 3306}, ReadOnly: true, Success: true},
+		{Key: InstanceKey{Hostname: "replica1", Port: 3306}, ReadOnly: true, Error: "timed out after 10s"},
+		{Key: InstanceKey{Hostname: "replica2", Port: 3306}, ReadOnly: true, Success: true},
+	}
+	test.S(t).ExpectEquals(countFailedReadOnlyResults(results), 1)
+	test.S(t).ExpectEquals(countFailedReadOnlyResults(results[0:1]), 0)
+	test.S(t).ExpectEquals(countFailedReadOnlyResults(nil), 0)
+}
+
+func TestSetInstancesReadOnlyHungInstances(t *testing.T) {
+	defer func(f func(*InstanceKey, bool) (*Instance, error)) { setInstanceReadOnly = f }(setInstanceReadOnly)
+	defer func(seconds uint) { config.Config.InstanceBulkOperationsWaitTimeoutSeconds = seconds }(config.Config.InstanceBulkOperationsWaitTimeoutSeconds)
+	config.Config.InstanceBulkOperationsWaitTimeoutSeconds = 1
+
+	hung := make(chan bool)
+	defer close(hung)
+	setInstanceReadOnly = func(instanceKey *InstanceKey, readOnly bool) (*Instance, error) {
+		if instanceKey.Hostname != "master" {
+			<-hung
+		}
+		return &Instance{Key: *instanceKey, ReadOnly: readOnly}, nil
+	}
+	instances := [](*Instance){
+		{Key: InstanceKey{Hostname: "replica1", Port: 3306}},
+		{Key: InstanceKey{Hostname: "master", Port: 3306}},
+		{Key: InstanceKey{Hostname: "replica2", Port: 3306}},
+		{Key: InstanceKey{Hostname: "replica3", Port: 3306}},
+	}
+	startedAt := time.Now()
+	results := setInstancesReadOnly(instances, true)
+	test.S(t).ExpectTrue(time.Since(startedAt) < 5*time.Second)
+	test.S(t).ExpectEquals(len(results), 4)
+	test.S(t).ExpectEquals(countFailedReadOnlyResults(results), 3)
+	test.S(t).ExpectTrue(results[1].Success)
+	test.S(t).ExpectEquals(results[3].Error, "timed out after 1s")
+}
+
+func TestSetInstancesReadOnlyQueuedPastTimeout(t *testing.T) {
+	defer func(f func(*InstanceKey, bool) (*Instance, error)) { setInstanceReadOnly = f }(setInstanceReadOnly)
+	defer func(seconds uint) { config.Config.InstanceBulkOperationsWaitTimeoutSeconds = seconds }(config.Config.InstanceBulkOperationsWaitTimeoutSeconds)
+	config.Config.InstanceBulkOperationsWaitTimeoutSeconds = 1
+
+	hung := make(chan bool)
+	var calls int64
+	setInstanceReadOnly = func(instanceKey *InstanceKey, readOnly bool) (*Instance, error) {
+		atomic.AddInt64(&calls, 1)
+		<-hung
+		return &Instance{Key: *instanceKey, ReadOnly: readOnly}, nil
+	}
+	instances := [](*Instance){}
+	for i := 0; i < clusterReadOnlyConcurrency+2; i++ {
+		instances = append(instances, &Instance{Key: InstanceKey{Hostname: fmt.Sprintf("replica%d", i), Port: 3306}})
+	}
+	results := setInstancesReadOnly(instances, true)
+	test.S(t).ExpectEquals(countFailedReadOnlyResults(results), len(instances))
+
+	// Instances waiting on a concurrency slot are not acted upon once timed out
+	close(hung)
+	time.Sleep(100 * time.Millisecond)
+	test.S(t).ExpectEquals(atomic.LoadInt64(&calls), int64(clusterReadOnlyConcurrency))
+}
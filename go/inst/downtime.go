@@ -50,3 +50,40 @@ func (downtime *Downtime) Ended() bool {
 func (downtime *Downtime) EndsIn() time.Duration {
 	return downtime.EndsAt.Sub(time.Now())
 }
+
+// ClusterDowntime describes a downtime applied to all instances of a cluster
+type ClusterDowntime struct {
+	ClusterName string
+	Owner       string
+	Reason      string
+	Duration    time.Duration
+	BeginsAt    time.Time
+	EndsAt      time.Time
+}
+
+func NewClusterDowntime(clusterName string, owner string, reason string, duration time.Duration) *ClusterDowntime {
+	downtime := &ClusterDowntime{
+		ClusterName: clusterName,
+		Owner:       owner,
+		Reason:      reason,
+		Duration:    duration,
+		BeginsAt:    time.Now(),
+	}
+	downtime.EndsAt = downtime.BeginsAt.Add(downtime.Duration)
+	return downtime
+}
+
+func (downtime *ClusterDowntime) Ended() bool {
+	return downtime.EndsAt.Before(time.Now())
+}
+
+func (downtime *ClusterDowntime) EndsIn() time.Duration {
+	return downtime.EndsAt.Sub(time.Now())
+}
+
+// ClusterDowntimeResult is the outcome of beginning or ending a cluster downtime on a single instance
+type ClusterDowntimeResult struct {
+	Key     InstanceKey
+	Success bool
+	Error   string `json:",omitempty"`
+}
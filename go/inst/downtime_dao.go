@@ -88,6 +88,27 @@ func BeginDowntime(downtime *Downtime) (err error) {
 	return nil
 }
 
+// IsDowntimed returns whether given instance is currently downtimed. Unlike Instance.IsDowntimed, this does not
+// rely on the instance having been read from the backend.
+func IsDowntimed(instanceKey *InstanceKey) (downtimed bool, err error) {
+	query := `
+		select
+			count(*) > 0 as is_downtimed
+		from
+			database_instance_downtime
+		where
+			hostname = ?
+			and port = ?
+			and downtime_active = 1
+			and end_timestamp > NOW()
+		`
+	err = db.QueryOrchestrator(query, sqlutils.Args(instanceKey.Hostname, instanceKey.Port), func(m sqlutils.RowMap) error {
+		downtimed = m.GetBool("is_downtimed")
+		return nil
+	})
+	return downtimed, log.Errore(err)
+}
+
 // EndDowntime will remove downtime flag from an instance
 func EndDowntime(instanceKey *InstanceKey) (wasDowntimed bool, err error) {
 	res, err := db.ExecOrchestrator(`
@@ -111,6 +132,181 @@ func EndDowntime(instanceKey *InstanceKey) (wasDowntimed bool, err error) {
 	return wasDowntimed, err
 }
 
+// ExtendDowntime pushes the end of an active downtime by given duration, preserving its owner, reason and begin time
+func ExtendDowntime(instanceKey *InstanceKey, extraDuration time.Duration) error {
+	if extraDuration <= 0 {
+		return log.Errorf("ExtendDowntime: duration must be positive. Given value: %+v", extraDuration)
+	}
+	res, err := db.ExecOrchestrator(`
+			update
+				database_instance_downtime
+			set
+				end_timestamp = database_instance_downtime.end_timestamp + interval ? second
+			where
+				hostname = ?
+				and port = ?
+				and end_timestamp > now()
+			`,
+		int(extraDuration.Seconds()),
+		instanceKey.Hostname,
+		instanceKey.Port,
+	)
+	if err != nil {
+		return log.Errore(err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return log.Errorf("ExtendDowntime: %+v is not downtimed", *instanceKey)
+	}
+	AuditOperation("extend-downtime", instanceKey, fmt.Sprintf("extended by %+v", extraDuration))
+	return nil
+}
+
+// execClusterDowntimeStatements runs given statement once per instance of given cluster, followed by the given
+// cluster statement, all within a single transaction. Should any statement fail, the transaction is rolled back.
+// Returns the outcome per instance.
+func execClusterDowntimeStatements(clusterName string, instanceStatement string, instanceArgs []interface{}, clusterStatement string, clusterArgs []interface{}) (results []ClusterDowntimeResult, err error) {
+	instances, err := ReadClusterInstances(clusterName)
+	if err != nil {
+		return results, log.Errore(err)
+	}
+	if len(instances) == 0 {
+		return results, log.Errorf("No instances found for cluster %s", clusterName)
+	}
+	if db.IsSQLite() {
+		instanceStatement = sqlutils.ToSqlite3Dialect(instanceStatement)
+		clusterStatement = sqlutils.ToSqlite3Dialect(clusterStatement)
+	}
+	dbh, err := db.OpenOrchestrator()
+	if err != nil {
+		return results, log.Errore(err)
+	}
+	tx, err := dbh.Begin()
+	if err != nil {
+		return results, log.Errore(err)
+	}
+	countFailed := 0
+	for _, instance := range instances {
+		result := ClusterDowntimeResult{Key: instance.Key, Success: true}
+		args := append([]interface{}{instance.Key.Hostname, instance.Key.Port}, instanceArgs...)
+		if _, err := tx.Exec(instanceStatement, args...); err != nil {
+			result.Success = false
+			result.Error = err.Error()
+			countFailed++
+		}
+		results = append(results, result)
+	}
+	if countFailed == 0 {
+		if _, err = tx.Exec(clusterStatement, clusterArgs...); err == nil {
+			err = tx.Commit()
+		}
+	} else {
+		err = fmt.Errorf("%d out of %d instances of cluster %s failed", countFailed, len(instances), clusterName)
+	}
+	if err != nil {
+		tx.Rollback()
+		for i := range results {
+			results[i].Success = false
+		}
+		return results, log.Errore(err)
+	}
+	return results, nil
+}
+
+// BeginDowntimeCluster downtimes all instances of given cluster in a single transaction. The cluster downtime
+// is recorded as well, such that instances discovered later on in the cluster may inherit it (see InheritClusterDowntime).
+func BeginDowntimeCluster(clusterName string, owner string, reason string, duration time.Duration) (results []ClusterDowntimeResult, err error) {
+	if duration == 0 {
+		duration = config.MaintenanceExpireMinutes * time.Minute
+	}
+	durationSeconds := int(duration.Seconds())
+	results, err = execClusterDowntimeStatements(clusterName, `
+			insert
+				into database_instance_downtime (
+					hostname, port, downtime_active, begin_timestamp, end_timestamp, owner, reason
+				) VALUES (
+					?, ?, 1, NOW(), NOW() + INTERVAL ? SECOND, ?, ?
+				)
+				on duplicate key update
+					downtime_active=values(downtime_active),
+					begin_timestamp=values(begin_timestamp),
+					end_timestamp=values(end_timestamp),
+					owner=values(owner),
+					reason=values(reason)
+			`, sqlutils.Args(durationSeconds, owner, reason), `
+			insert
+				into database_cluster_downtime (
+					cluster_name, begin_timestamp, end_timestamp, owner, reason
+				) VALUES (
+					?, NOW(), NOW() + INTERVAL ? SECOND, ?, ?
+				)
+				on duplicate key update
+					begin_timestamp=values(begin_timestamp),
+					end_timestamp=values(end_timestamp),
+					owner=values(owner),
+					reason=values(reason)
+			`, sqlutils.Args(clusterName, durationSeconds, owner, reason),
+	)
+	if err != nil {
+		return results, err
+	}
+	AuditOperation("begin-downtime-cluster", nil, fmt.Sprintf("cluster: %s, instances: %d, owner: %s, reason: %s, duration: %+v", clusterName, len(results), owner, reason, duration))
+	return results, nil
+}
+
+// EndDowntimeCluster removes the downtime flag from all instances of given cluster, as well as the cluster downtime itself
+func EndDowntimeCluster(clusterName string) (results []ClusterDowntimeResult, err error) {
+	results, err = execClusterDowntimeStatements(clusterName, `
+			delete from
+				database_instance_downtime
+			where
+				hostname = ?
+				and port = ?
+			`, sqlutils.Args(), `
+			delete from
+				database_cluster_downtime
+			where
+				cluster_name = ?
+			`, sqlutils.Args(clusterName),
+	)
+	if err != nil {
+		return results, err
+	}
+	AuditOperation("end-downtime-cluster", nil, fmt.Sprintf("cluster: %s, instances: %d", clusterName, len(results)))
+	return results, nil
+}
+
+// ReadInheritedClusterDowntime returns the downtime a newly discovered instance inherits from its downtimed
+// cluster, for the remainder of the cluster downtime, or nil if none. This only applies when
+// InheritClusterDowntime is configured.
+func ReadInheritedClusterDowntime(instance *Instance) (downtime *Downtime, err error) {
+	if !config.Config.InheritClusterDowntime || instance.ClusterName == "" {
+		return nil, nil
+	}
+	query := `
+		select
+			begin_timestamp,
+			end_timestamp,
+			owner,
+			reason
+		from
+			database_cluster_downtime
+		where
+			cluster_name = ?
+			and end_timestamp > now()
+		`
+	err = db.QueryOrchestrator(query, sqlutils.Args(instance.ClusterName), func(m sqlutils.RowMap) error {
+		downtime = &Downtime{
+			Key:            &instance.Key,
+			Owner:          m.GetString("owner"),
+			Reason:         m.GetString("reason"),
+			BeginsAtString: m.GetString("begin_timestamp"),
+			EndsAtString:   m.GetString("end_timestamp"),
+		}
+		return nil
+	})
+	return downtime, log.Errore(err)
+}
+
 // renewLostInRecoveryDowntime renews hosts who are downtimed due to being lost in recovery, such that
 // their downtime never expires.
 func renewLostInRecoveryDowntime() error {
@@ -200,6 +396,18 @@ func ExpireDowntime() error {
 			AuditOperation("expire-downtime", nil, fmt.Sprintf("Expired %d entries", rowsAffected))
 		}
 	}
+	{
+		_, err := db.ExecOrchestrator(`
+			delete from
+				database_cluster_downtime
+			where
+				end_timestamp < NOW()
+			`,
+		)
+		if err != nil {
+			return log.Errore(err)
+		}
+	}
 
 	return nil
 }
@@ -0,0 +1,64 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestReadInheritedClusterDowntime(t *testing.T) {
+	defer useSQLiteBackend(t)()
+	defer func(inherit bool) { config.Config.InheritClusterDowntime = inherit }(config.Config.InheritClusterDowntime)
+	config.Config.InheritClusterDowntime = true
+
+	instances := mkTestInstances()
+	for _, instance := range instances[0:2] {
+		instance.ClusterName = "maintained"
+	}
+	test.S(t).ExpectNil(writeManyInstances(instances[0:2], true, true))
+	results, err := BeginDowntimeCluster("maintained", "dba", "planned maintenance", time.Hour)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(results), 2)
+
+	// 730 is discovered in the downtimed cluster
+	discovered := instances[2]
+	discovered.ClusterName = "maintained"
+	downtime, err := ReadInheritedClusterDowntime(discovered)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectNotNil(downtime)
+	test.S(t).ExpectEquals(*downtime.Key, i730k)
+	test.S(t).ExpectEquals(downtime.Reason, "planned maintenance")
+	test.S(t).ExpectNil(BeginDowntime(downtime))
+	downtimed, err := IsDowntimed(&i730k)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(downtimed)
+
+	discovered.ClusterName = "other"
+	downtime, err = ReadInheritedClusterDowntime(discovered)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(downtime == nil)
+
+	config.Config.InheritClusterDowntime = false
+	discovered.ClusterName = "maintained"
+	downtime, err = ReadInheritedClusterDowntime(discovered)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(downtime == nil)
+}
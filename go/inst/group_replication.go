@@ -0,0 +1,152 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+
+	"github.com/openark/golib/sqlutils"
+)
+
+// Group Replication member states & roles, as listed in performance_schema.replication_group_members
+const (
+	GroupReplicationMemberStateOnline      = "ONLINE"
+	GroupReplicationMemberStateRecovering  = "RECOVERING"
+	GroupReplicationMemberStateUnreachable = "UNREACHABLE"
+	GroupReplicationMemberStateOffline     = "OFFLINE"
+	GroupReplicationMemberStateError       = "ERROR"
+
+	GroupReplicationMemberRolePrimary   = "PRIMARY"
+	GroupReplicationMemberRoleSecondary = "SECONDARY"
+)
+
+// IsReplicationGroupMember returns true when this instance is a member of a Group Replication group
+func (this *Instance) IsReplicationGroupMember() bool {
+	return this.ReplicationGroupName != ""
+}
+
+// IsReplicationGroupPrimary returns true when this instance is a (or, in single primary mode, the) primary of its group
+func (this *Instance) IsReplicationGroupPrimary() bool {
+	return this.IsReplicationGroupMember() && this.ReplicationGroupMemberRole == GroupReplicationMemberRolePrimary
+}
+
+// IsReplicationGroupSecondary returns true when this instance is a secondary member of its group
+func (this *Instance) IsReplicationGroupSecondary() bool {
+	return this.IsReplicationGroupMember() && this.ReplicationGroupMemberRole != GroupReplicationMemberRolePrimary
+}
+
+// replicationGroupAnchorKey returns the key of the group member from which this member derives its cluster
+// attributes, such that all members of a group end up in the same cluster. In single primary mode this is
+// the primary; in multi primary mode this is the smallest member key. Returns nil when this instance is the anchor.
+func (this *Instance) replicationGroupAnchorKey() *InstanceKey {
+	if !this.IsReplicationGroupMember() {
+		return nil
+	}
+	if this.ReplicationGroupIsSinglePrimary {
+		if this.IsReplicationGroupPrimary() || !this.ReplicationGroupPrimaryInstanceKey.IsValid() {
+			return nil
+		}
+		return &this.ReplicationGroupPrimaryInstanceKey
+	}
+	var anchorKey *InstanceKey
+	for _, memberKey := range this.ReplicationGroupMembers.GetInstanceKeys() {
+		memberKey := memberKey
+		if memberKey.SmallerThan(&this.Key) && (anchorKey == nil || memberKey.SmallerThan(anchorKey)) {
+			anchorKey = &memberKey
+		}
+	}
+	return anchorKey
+}
+
+// CheckCanPromoteReplicationGroupMember refuses promotion operations on Group Replication secondaries:
+// the group elects its own primary, and classic replication changes would conflict with it.
+func CheckCanPromoteReplicationGroupMember(instance *Instance, operation string) error {
+	if instance.IsReplicationGroupSecondary() {
+		return fmt.Errorf("%s: %+v is a secondary member of replication group %s; its promotion is managed by Group Replication", operation, instance.Key, instance.ReplicationGroupName)
+	}
+	return nil
+}
+
+// populateGroupReplicationInformation reads the Group Replication membership of given instance, if any
+func populateGroupReplicationInformation(instance *Instance, db *discoveryDB) error {
+	memberRoleExpression := "MEMBER_ROLE"
+	if instance.IsSmallerMajorVersionByString("8.0") {
+		// MEMBER_ROLE is only available as of 8.0. On 5.7 the single primary is found in global status
+		memberRoleExpression = fmt.Sprintf(`if(MEMBER_ID = (
+				select VARIABLE_VALUE from performance_schema.global_status where VARIABLE_NAME = 'group_replication_primary_member'
+			), '%s', '%s')`, GroupReplicationMemberRolePrimary, GroupReplicationMemberRoleSecondary)
+	}
+	query := fmt.Sprintf(`
+		select
+			MEMBER_ID = @@global.server_uuid as is_self,
+			MEMBER_HOST as member_host,
+			MEMBER_PORT as member_port,
+			MEMBER_STATE as member_state,
+			%s as member_role
+		from
+			performance_schema.replication_group_members
+		`, memberRoleExpression)
+
+	memberState := ""
+	memberRole := ""
+	members := NewInstanceKeyMap()
+	primaries := NewInstanceKeyMap()
+	err := db.QueryRowsMap(query, func(m sqlutils.RowMap) error {
+		if m.GetBool("is_self") {
+			memberState = m.GetString("member_state")
+			memberRole = m.GetString("member_role")
+			return nil
+		}
+		memberKey, err := NewResolveInstanceKey(m.GetString("member_host"), m.GetInt("member_port"))
+		if err != nil || !memberKey.IsValid() {
+			return nil
+		}
+		members.AddKey(*memberKey)
+		if m.GetString("member_role") == GroupReplicationMemberRolePrimary {
+			primaries.AddKey(*memberKey)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if memberState == "" || memberState == GroupReplicationMemberStateOffline {
+		// Not an active member of any group
+		return nil
+	}
+	var groupName string
+	var isSinglePrimary bool
+	err = db.QueryRow("select @@global.group_replication_group_name, @@global.group_replication_single_primary_mode").Scan(&groupName, &isSinglePrimary)
+	if err != nil {
+		return err
+	}
+
+	instance.ReplicationGroupName = groupName
+	instance.ReplicationGroupIsSinglePrimary = isSinglePrimary
+	instance.ReplicationGroupMemberState = memberState
+	instance.ReplicationGroupMemberRole = memberRole
+	instance.ReplicationGroupMembers = *members
+	if !isSinglePrimary {
+		// All members are primaries
+		instance.ReplicationGroupMemberRole = GroupReplicationMemberRolePrimary
+	} else if memberRole == GroupReplicationMemberRolePrimary {
+		instance.ReplicationGroupPrimaryInstanceKey = instance.Key
+	} else if primaryKeys := primaries.GetInstanceKeys(); len(primaryKeys) == 1 {
+		instance.ReplicationGroupPrimaryInstanceKey = primaryKeys[0]
+	}
+	return nil
+}
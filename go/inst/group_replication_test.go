@@ -0,0 +1,74 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func newReplicationGroupMember(hostname string, role string, memberHostnames ...string) *Instance {
+	instance := NewInstance()
+	instance.Key = InstanceKey{Hostname: hostname, Port: 3306}
+	instance.ReplicationGroupName = "8a94f357-aab4-11df-86ab-c80aa9429562"
+	instance.ReplicationGroupIsSinglePrimary = true
+	instance.ReplicationGroupMemberState = GroupReplicationMemberStateOnline
+	instance.ReplicationGroupMemberRole = role
+	for _, memberHostname := range memberHostnames {
+		instance.ReplicationGroupMembers.AddKey(InstanceKey{Hostname: memberHostname, Port: 3306})
+	}
+	return instance
+}
+
+func TestReplicationGroupRoles(t *testing.T) {
+	instance := NewInstance()
+	test.S(t).ExpectFalse(instance.IsReplicationGroupMember())
+	test.S(t).ExpectFalse(instance.IsReplicationGroupSecondary())
+	test.S(t).ExpectNil(CheckCanPromoteReplicationGroupMember(instance, "test"))
+
+	primary := newReplicationGroupMember("gr1", GroupReplicationMemberRolePrimary, "gr2", "gr3")
+	test.S(t).ExpectTrue(primary.IsReplicationGroupMember())
+	test.S(t).ExpectTrue(primary.IsReplicationGroupPrimary())
+	test.S(t).ExpectFalse(primary.IsReplicationGroupSecondary())
+	test.S(t).ExpectNil(CheckCanPromoteReplicationGroupMember(primary, "test"))
+
+	secondary := newReplicationGroupMember("gr2", GroupReplicationMemberRoleSecondary, "gr1", "gr3")
+	test.S(t).ExpectTrue(secondary.IsReplicationGroupSecondary())
+	test.S(t).ExpectNotNil(CheckCanPromoteReplicationGroupMember(secondary, "test"))
+}
+
+func TestReplicationGroupAnchorKeySinglePrimary(t *testing.T) {
+	primary := newReplicationGroupMember("gr2", GroupReplicationMemberRolePrimary, "gr1", "gr3")
+	primary.ReplicationGroupPrimaryInstanceKey = primary.Key
+	test.S(t).ExpectTrue(primary.replicationGroupAnchorKey() == nil)
+
+	secondary := newReplicationGroupMember("gr1", GroupReplicationMemberRoleSecondary, "gr2", "gr3")
+	test.S(t).ExpectTrue(secondary.replicationGroupAnchorKey() == nil)
+	secondary.ReplicationGroupPrimaryInstanceKey = primary.Key
+	test.S(t).ExpectEquals(*secondary.replicationGroupAnchorKey(), primary.Key)
+}
+
+func TestReplicationGroupAnchorKeyMultiPrimary(t *testing.T) {
+	member1 := newReplicationGroupMember("gr1", GroupReplicationMemberRolePrimary, "gr2", "gr3")
+	member1.ReplicationGroupIsSinglePrimary = false
+	test.S(t).ExpectTrue(member1.replicationGroupAnchorKey() == nil)
+
+	member3 := newReplicationGroupMember("gr3", GroupReplicationMemberRolePrimary, "gr2", "gr1")
+	member3.ReplicationGroupIsSinglePrimary = false
+	test.S(t).ExpectEquals(*member3.replicationGroupAnchorKey(), member1.Key)
+}
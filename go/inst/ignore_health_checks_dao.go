@@ -0,0 +1,106 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/db"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// HealthChecksIgnore marks an instance which is unreachable by design, e.g. an analytics replica behind a
+// firewall. Unlike a downtime, it does not expire: it is kept until explicitly removed.
+type HealthChecksIgnore struct {
+	Key    *InstanceKey
+	Owner  string
+	Reason string
+}
+
+func NewHealthChecksIgnore(instanceKey *InstanceKey, owner string, reason string) *HealthChecksIgnore {
+	return &HealthChecksIgnore{
+		Key:    instanceKey,
+		Owner:  owner,
+		Reason: reason,
+	}
+}
+
+// IgnoreHealthChecks marks an instance such that it is no longer polled, analyzed for failures, nor listed as
+// problematic. It remains in its topology with its last known state.
+func IgnoreHealthChecks(ignore *HealthChecksIgnore) error {
+	if ignore.Owner == "" {
+		return fmt.Errorf("IgnoreHealthChecks: owner must be provided")
+	}
+	if ignore.Reason == "" {
+		return fmt.Errorf("IgnoreHealthChecks: reason must be provided")
+	}
+	_, err := db.ExecOrchestrator(`
+			insert
+				into database_instance_ignore_health_checks (
+					hostname, port, begin_timestamp, owner, reason
+				) VALUES (
+					?, ?, NOW(), ?, ?
+				)
+				on duplicate key update
+					begin_timestamp=values(begin_timestamp),
+					owner=values(owner),
+					reason=values(reason)
+			`,
+		ignore.Key.Hostname,
+		ignore.Key.Port,
+		ignore.Owner,
+		ignore.Reason,
+	)
+	if err != nil {
+		return log.Errore(err)
+	}
+	AuditOperation("ignore-health-checks", ignore.Key, fmt.Sprintf("owner: %s, reason: %s", ignore.Owner, ignore.Reason))
+	return nil
+}
+
+// UnignoreHealthChecks resumes polling and failure analysis of an instance
+func UnignoreHealthChecks(instanceKey *InstanceKey) (wasIgnored bool, err error) {
+	res, err := db.ExecOrchestrator(`
+			delete from
+				database_instance_ignore_health_checks
+			where
+				hostname = ?
+				and port = ?
+			`,
+		instanceKey.Hostname,
+		instanceKey.Port,
+	)
+	if err != nil {
+		return wasIgnored, log.Errore(err)
+	}
+	if affected, _ := res.RowsAffected(); affected > 0 {
+		wasIgnored = true
+		AuditOperation("unignore-health-checks", instanceKey, "")
+	}
+	return wasIgnored, err
+}
+
+// ReadHealthChecksIgnoredInstances returns all instances whose health checks are ignored, potentially filtered
+// by cluster
+func ReadHealthChecksIgnoredInstances(clusterName string) ([](*Instance), error) {
+	condition := `
+			database_instance_ignore_health_checks.hostname is not null
+			and ? IN ('', cluster_name)
+		`
+	return readInstancesByCondition(condition, sqlutils.Args(clusterName), "cluster_name asc, hostname asc, port asc")
+}
@@ -0,0 +1,54 @@
+package inst
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	test "github.com/openark/golib/tests"
+)
+
+// useSQLiteBackend points the backend at a fresh sqlite database. Call the returned function to restore the
+// previous backend configuration.
+func useSQLiteBackend(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "orchestrator-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	backendDB, dataFile := config.Config.BackendDB, config.Config.SQLite3DataFile
+	config.Config.BackendDB = "sqlite"
+	config.Config.SQLite3DataFile = filepath.Join(dir, "orchestrator.sqlite3")
+	if _, err := db.OpenOrchestrator(); err != nil {
+		t.Fatal(err)
+	}
+	return func() {
+		config.Config.BackendDB, config.Config.SQLite3DataFile = backendDB, dataFile
+		os.RemoveAll(dir)
+	}
+}
+
+func TestForgetLongUnseenInstancesKeepsIgnoredInstances(t *testing.T) {
+	defer useSQLiteBackend(t)()
+	defer func(hours uint) { config.Config.UnseenInstanceForgetHours = hours }(config.Config.UnseenInstanceForgetHours)
+	config.Config.UnseenInstanceForgetHours = 1
+
+	instances := mkTestInstances()
+	test.S(t).ExpectNil(writeManyInstances(instances[0:2], true, true))
+	_, err := db.ExecOrchestrator(`update database_instance set last_seen = NOW() - interval 2 hour`)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectNil(IgnoreHealthChecks(NewHealthChecksIgnore(&instances[0].Key, "dba", "firewalled")))
+
+	test.S(t).ExpectNil(ForgetLongUnseenInstances())
+
+	instance, found, err := ReadInstance(&instances[0].Key)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(found)
+	test.S(t).ExpectTrue(instance.IgnoreHealthChecks)
+
+	_, found, err = ReadInstance(&instances[1].Key)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectFalse(found)
+}
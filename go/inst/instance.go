@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/github/orchestrator/go/config"
+	"github.com/openark/golib/log"
 	"github.com/openark/golib/math"
 )
 
@@ -38,15 +39,24 @@ type Instance struct {
 	ServerID                  uint
 	ServerUUID                string
 	Version                   string
+	ParsedVersion             InstanceVersion
 	VersionComment            string
 	FlavorName                string
+	IsDetectedBinlogServer    bool // per BinlogServerVersionPattern or DetectBinlogServerQuery
 	ReadOnly                  bool
+	SuperReadOnly             bool
+	EventSchedulerEnabled     bool
+	CountEnabledEvents        uint
+	SQLMode                   string
+	MasterWritesBlocked       bool
 	Binlog_format             string
 	BinlogRowImage            string
 	LogBinEnabled             bool
 	LogSlaveUpdatesEnabled    bool
 	SelfBinlogCoordinates     BinlogCoordinates
+	OldestBinaryLogFile       string
 	MasterKey                 InstanceKey
+	RawMasterKey              InstanceKey
 	MasterUUID                string
 	AncestryUUID              string
 	IsDetachedMaster          bool
@@ -55,6 +65,8 @@ type Instance struct {
 	ReplicationSQLThreadState ReplicationThreadState
 	ReplicationIOThreadState  ReplicationThreadState
 	HasReplicationFilters     bool
+	ReplicationFilters        ReplicationFilters
+	ReplicationChannels       ReplicationChannels
 	GTIDMode                  string
 	SupportsOracleGTID        bool
 	UsingOracleGTID           bool
@@ -65,36 +77,68 @@ type Instance struct {
 	IsDetached                bool
 	RelaylogCoordinates       BinlogCoordinates
 	LastSQLError              string
+	LastSQLErrno              int
 	LastIOError               string
 	SecondsBehindMaster       sql.NullInt64
 	SQLDelay                  uint
+	SQLRemainingDelay         sql.NullInt64
+	SQLThreadLagEstimate      sql.NullInt64
+	SlaveNetTimeout           uint
+	HeartbeatPeriodSeconds    float64
+	ReceivedHeartbeats        int64
+	SecondsSinceLastHeartbeat sql.NullInt64
+	SlaveParallelWorkers      uint
+	HasReplicationGaps        bool
 	ExecutedGtidSet           string
 	GtidPurged                string
-	GtidErrant                string
+	MariaDBGtidCurrentPos     string
+	MariaDBGtidSlavePos       string
+	GtidErrant                string `json:",omitempty"`
 
 	masterExecutedGtidSet string // Not exported
 
-	SlaveLagSeconds                 sql.NullInt64
-	SlaveHosts                      InstanceKeyMap
-	ClusterName                     string
-	SuggestedClusterAlias           string
-	DataCenter                      string
-	Region                          string
-	PhysicalEnvironment             string
-	ReplicationDepth                uint
-	IsCoMaster                      bool
-	HasReplicationCredentials       bool
-	ReplicationCredentialsAvailable bool
-	SemiSyncEnforced                bool
-	SemiSyncMasterEnabled           bool
-	SemiSyncReplicaEnabled          bool
+	SlaveLagSeconds                   sql.NullInt64
+	SlaveHosts                        InstanceKeyMap
+	ClusterName                       string
+	SuggestedClusterAlias             string
+	DataCenter                        string
+	Region                            string
+	PhysicalEnvironment               string
+	ReplicationDepth                  uint
+	IsCoMaster                        bool
+	HasReplicationCredentials         bool
+	ReplicationCredentialsAvailable   bool
+	SemiSyncEnforced                  bool
+	SemiSyncMasterEnabled             bool
+	SemiSyncReplicaEnabled            bool
+	SemiSyncMasterStatus              bool
+	SemiSyncReplicaStatus             bool
+	SemiSyncMasterClients             uint
+	SemiSyncMasterWaitForReplicaCount uint
+
+	ReplicationGroupName               string
+	ReplicationGroupIsSinglePrimary    bool
+	ReplicationGroupMemberState        string
+	ReplicationGroupMemberRole         string
+	ReplicationGroupMembers            InstanceKeyMap
+	ReplicationGroupPrimaryInstanceKey InstanceKey
 
 	LastSeenTimestamp    string
 	IsLastCheckValid     bool
 	IsUpToDate           bool
 	IsRecentlyChecked    bool
 	SecondsSinceLastSeen sql.NullInt64
+	// RecentlyRestarted is set when Uptime is below RecentlyRestartedThresholdSeconds
+	RecentlyRestarted bool
+	// LastCheckedTimestamp is the time orchestrator last attempted to poll this instance, successfully
+	// or not, whereas LastSeenTimestamp is the time it last succeeded. IsStale is set when the instance
+	// has not been polled for a while, in which case its data tells nothing about its health.
+	LastCheckedTimestamp string
+	IsStale              bool
 	CountMySQLSnapshots  int
+	// LastCheckQueriesTimedOut is set when the last check found the instance, but some of its
+	// discovery queries timed out, leaving some of the instance's attributes stale
+	LastCheckQueriesTimedOut bool
 
 	// Careful. IsCandidate and PromotionRule are used together
 	// and probably need to be merged. IsCandidate's value may
@@ -107,8 +151,17 @@ type Instance struct {
 	DowntimeOwner        string
 	DowntimeEndTimestamp string
 	ElapsedDowntime      time.Duration
+	RemainingDowntime    time.Duration
 	UnresolvedHostname   string
 	AllowTLS             bool
+	// ReplicationSSLCipher is the configured MASTER_SSL_CIPHER, if any. AllowTLS tells whether replication uses SSL.
+	ReplicationSSLCipher string
+
+	// IgnoreHealthChecks is set on instances deliberately unreachable by orchestrator, e.g. firewalled
+	// analytics replicas. Such instances are neither polled nor analyzed; their last known state is kept.
+	IgnoreHealthChecks       bool
+	IgnoreHealthChecksOwner  string
+	IgnoreHealthChecksReason string
 
 	Problems []string
 
@@ -118,8 +171,9 @@ type Instance struct {
 // NewInstance creates a new, empty instance
 func NewInstance() *Instance {
 	return &Instance{
-		SlaveHosts: make(map[InstanceKey]bool),
-		Problems:   []string{},
+		SlaveHosts:              make(map[InstanceKey]bool),
+		ReplicationGroupMembers: make(map[InstanceKey]bool),
+		Problems:                []string{},
 	}
 }
 
@@ -201,7 +255,7 @@ func (this *Instance) IsBinlogServer() bool {
 	if this.isMaxScale() {
 		return true
 	}
-	return false
+	return this.IsDetectedBinlogServer
 }
 
 // IsOracleMySQL checks whether this is an Oracle MySQL distribution
@@ -239,6 +293,11 @@ func (this *Instance) applyFlavorName() {
 	}
 }
 
+// applyRecentlyRestarted sets RecentlyRestarted based on Uptime. Unknown (zero) uptime does not count as restarted
+func (this *Instance) applyRecentlyRestarted() {
+	this.RecentlyRestarted = this.Uptime > 0 && this.Uptime < config.Config.RecentlyRestartedThresholdSeconds
+}
+
 // FlavorNameAndMajorVersion returns a string of the combined
 // flavor and major version which is useful in some checks.
 func (this *Instance) FlavorNameAndMajorVersion() string {
@@ -249,6 +308,19 @@ func (this *Instance) FlavorNameAndMajorVersion() string {
 	return this.FlavorName + "-" + this.MajorVersionString()
 }
 
+// HasPlaintextReplication returns true when this replica replicates, on any of its channels, without SSL
+func (this *Instance) HasPlaintextReplication() bool {
+	if !this.AllowTLS {
+		return true
+	}
+	for _, channel := range this.ReplicationChannels {
+		if !channel.AllowTLS {
+			return true
+		}
+	}
+	return false
+}
+
 // IsReplica makes simple heuristics to decide whether this instance is a replica of another instance
 func (this *Instance) IsReplica() bool {
 	return this.MasterKey.Hostname != "" && this.MasterKey.Hostname != "_" && this.MasterKey.Port != 0 && (this.ReadBinlogCoordinates.LogFile != "" || this.UsingGTID())
@@ -279,6 +351,47 @@ func (this *Instance) SQLThreadUpToDate() bool {
 	return this.ReadBinlogCoordinates.Equals(&this.ExecBinlogCoordinates)
 }
 
+// SQLThreadLagSeconds returns the lag of the instance's sql thread, and whether it is known. Seconds_Behind_Master
+// is NULL while the IO thread is disconnected, as is the case when the master is dead; the lag is then as
+// estimated off the relay log backlog, see PopulateSQLThreadLagEstimates.
+func (this *Instance) SQLThreadLagSeconds() (lagSeconds int64, known bool) {
+	if this.SecondsBehindMaster.Valid {
+		return this.SecondsBehindMaster.Int64, true
+	}
+	if this.SQLThreadUpToDate() {
+		return 0, true
+	}
+	return this.SQLThreadLagEstimate.Int64, this.SQLThreadLagEstimate.Valid
+}
+
+// IsSQLThreadLaggingBeyond returns true when the instance's sql thread is known to lag by more than given
+// number of seconds. Zero maxLagSeconds means no limit.
+func (this *Instance) IsSQLThreadLaggingBeyond(maxLagSeconds uint) bool {
+	if maxLagSeconds == 0 {
+		return false
+	}
+	lagSeconds, known := this.SQLThreadLagSeconds()
+	return known && lagSeconds > int64(maxLagSeconds)
+}
+
+// HasBinlogMissingOnMaster returns true when this replica's IO thread fails (error 1236) requesting a binary log
+// which precedes the oldest binary log known on given master, i.e. which has been purged off the master.
+func (this *Instance) HasBinlogMissingOnMaster(master *Instance) bool {
+	if !strings.Contains(this.LastIOError, "1236") && !strings.Contains(this.LastIOError, "Could not find first log file") {
+		return false
+	}
+	if this.ReadBinlogCoordinates.IsEmpty() || master.OldestBinaryLogFile == "" {
+		return false
+	}
+	masterOldestCoordinates := BinlogCoordinates{LogFile: master.OldestBinaryLogFile}
+	return this.ReadBinlogCoordinates.FileSmallerThan(&masterOldestCoordinates)
+}
+
+// SupportsMariaDBGTID returns true when this is a MariaDB server which supports GTID (10.0 and above)
+func (this *Instance) SupportsMariaDBGTID() bool {
+	return this.IsMariaDB() && !this.IsSmallerMajorVersionByString("10.0")
+}
+
 // UsingGTID returns true when this replica is currently replicating via GTID (either Oracle or MariaDB)
 func (this *Instance) UsingGTID() bool {
 	return this.UsingOracleGTID || this.UsingMariaDBGTID
@@ -356,19 +469,25 @@ func (this *Instance) CanReplicateFrom(other *Instance) (bool, error) {
 	if !other.LogBinEnabled {
 		return false, fmt.Errorf("instance does not have binary logs enabled: %+v", other.Key)
 	}
-	if other.IsReplica() {
-		if !other.LogSlaveUpdatesEnabled {
-			return false, fmt.Errorf("instance does not have log_slave_updates enabled: %+v", other.Key)
-		}
+	forced := config.RuntimeCLIFlags.Force != nil && *config.RuntimeCLIFlags.Force
+	if other.IsReplica() && !other.LogSlaveUpdatesEnabled {
 		// OK for a master to not have log_slave_updates
-		// Not OK for a replica, for it has to relay the logs.
+		// Not OK for a replica, for it has to relay the logs: its own replicas would silently stop receiving its master's changes
+		if !forced {
+			return false, fmt.Errorf("instance does not have log_slave_updates enabled: %+v; replicas below it would not receive replicated events. Use --force to override", other.Key)
+		}
+		log.Warningf("Forcing %+v to replicate from %+v despite log_slave_updates being disabled on the latter", this.Key, other.Key)
 	}
 	if this.IsSmallerMajorVersion(other) && !this.IsBinlogServer() {
 		return false, fmt.Errorf("instance %+v has version %s, which is lower than %s on %+v ", this.Key, this.Version, other.Version, other.Key)
 	}
 	if this.LogBinEnabled && this.LogSlaveUpdatesEnabled {
-		if this.IsSmallerBinlogFormat(other) {
-			return false, fmt.Errorf("Cannot replicate from %+v binlog format on %+v to %+v on %+v", other.Binlog_format, other.Key, this.Binlog_format, this.Key)
+		// Binlog format only matters if this instance writes replicated events to its own binary logs.
+		if this.IsSmallerBinlogFormat(other) && !forced {
+			return false, fmt.Errorf("Cannot replicate from %+v binlog format on %+v to %+v on %+v. Use --force to override", other.Binlog_format, other.Key, this.Binlog_format, this.Key)
+		}
+		if this.Binlog_format == "ROW" && other.Binlog_format == "ROW" && IsSmallerBinlogRowImage(other.BinlogRowImage, this.BinlogRowImage) && !forced {
+			return false, fmt.Errorf("Cannot replicate from %+v binlog row image on %+v to %+v on %+v. Use --force to override", other.BinlogRowImage, other.Key, this.BinlogRowImage, this.Key)
 		}
 	}
 	if config.Config.VerifyReplicationFilters {
@@ -389,12 +508,13 @@ func (this *Instance) CanReplicateFrom(other *Instance) (bool, error) {
 }
 
 // HasReasonableMaintenanceReplicationLag returns true when the replica lag is reasonable, and maintenance operations should have a green light to go.
+// Lag is as measured by ReplicationLagQuery, if configured, or else Seconds_Behind_Master.
 func (this *Instance) HasReasonableMaintenanceReplicationLag() bool {
 	// replicas with SQLDelay are a special case
 	if this.SQLDelay > 0 {
-		return math.AbsInt64(this.SecondsBehindMaster.Int64-int64(this.SQLDelay)) <= int64(config.Config.ReasonableMaintenanceReplicationLagSeconds)
+		return math.AbsInt64(this.SlaveLagSeconds.Int64-int64(this.SQLDelay)) <= int64(config.Config.ReasonableMaintenanceReplicationLagSeconds)
 	}
-	return this.SecondsBehindMaster.Int64 <= int64(config.Config.ReasonableMaintenanceReplicationLagSeconds)
+	return this.SlaveLagSeconds.Int64 <= int64(config.Config.ReasonableMaintenanceReplicationLagSeconds)
 }
 
 // CanMove returns true if this instance's state allows it to be repositioned. For example,
@@ -412,7 +532,7 @@ func (this *Instance) CanMove() (bool, error) {
 	if !this.ReplicationIOThreadState.IsRunning() {
 		return false, fmt.Errorf("%+v: instance is not replicating", this.Key)
 	}
-	if !this.SecondsBehindMaster.Valid {
+	if !this.SlaveLagSeconds.Valid {
 		return false, fmt.Errorf("%+v: cannot determine slave lag", this.Key)
 	}
 	if !this.HasReasonableMaintenanceReplicationLag() {
@@ -474,7 +594,7 @@ func (this *Instance) LagStatusString() string {
 	if this.IsReplica() && !this.ReplicaRunning() {
 		return "null"
 	}
-	if this.IsReplica() && !this.SecondsBehindMaster.Valid {
+	if this.IsReplica() && !this.SlaveLagSeconds.Valid {
 		return "null"
 	}
 	if this.IsReplica() && this.SlaveLagSeconds.Int64 > int64(config.Config.ReasonableMaintenanceReplicationLagSeconds) {
@@ -511,6 +631,9 @@ func (this *Instance) descriptionTokens() (tokens []string) {
 		if this.IsDowntimed {
 			extraTokens = append(extraTokens, "downtimed")
 		}
+		if this.IgnoreHealthChecks {
+			extraTokens = append(extraTokens, "health-checks-ignored")
+		}
 		tokens = append(tokens, strings.Join(extraTokens, ","))
 	}
 	return tokens
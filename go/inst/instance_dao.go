@@ -18,6 +18,7 @@ package inst
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -72,6 +73,9 @@ var instanceKeyInformativeClusterName *cache.Cache
 var forgetInstanceKeys *cache.Cache
 var clusterInjectedPseudoGTIDCache *cache.Cache
 
+// enabledEventsCounts caches the number of enabled events per instance, as information_schema.events is costly to query
+var enabledEventsCounts = cache.New(config.CheckEnabledEventsIntervalSeconds*time.Second, time.Second)
+
 var accessDeniedCounter = metrics.NewCounter()
 var readTopologyInstanceCounter = metrics.NewCounter()
 var readInstanceCounter = metrics.NewCounter()
@@ -173,7 +177,7 @@ func logReadTopologyInstanceError(instanceKey *InstanceKey, hint string, err err
 // server and writes the result synchronously to the orchestrator
 // backend.
 func ReadTopologyInstance(instanceKey *InstanceKey) (*Instance, error) {
-	return ReadTopologyInstanceBufferable(instanceKey, false, nil)
+	return ReadTopologyInstanceBufferable(context.Background(), instanceKey, false, nil)
 }
 
 func RetryInstanceFunction(f func() (*Instance, error)) (instance *Instance, err error) {
@@ -203,13 +207,13 @@ func unrecoverableError(err error) bool {
 
 // Check if the instance is a MaxScale binlog server (a proxy not a real
 // MySQL server) and also update the resolved hostname
-func (instance *Instance) checkMaxScale(db *sql.DB, latency *stopwatch.NamedStopwatch) (isMaxScale bool, resolvedHostname string, err error) {
+func (instance *Instance) checkMaxScale(db *discoveryDB, latency *stopwatch.NamedStopwatch) (isMaxScale bool, resolvedHostname string, err error) {
 	if config.Config.SkipMaxScaleCheck {
 		return isMaxScale, resolvedHostname, err
 	}
 
 	latency.Start("instance")
-	err = sqlutils.QueryRowsMap(db, "show variables like 'maxscale%'", func(m sqlutils.RowMap) error {
+	err = db.QueryRowsMap("show variables like 'maxscale%'", func(m sqlutils.RowMap) error {
 		if m.GetString("Variable_name") == "MAXSCALE_VERSION" {
 			originalVersion := m.GetString("Value")
 			if originalVersion == "" {
@@ -276,7 +280,8 @@ func expectReplicationThreadsState(instanceKey *InstanceKey, expectedState Repli
 // It writes the information retrieved into orchestrator's backend.
 // - writes are optionally buffered.
 // - timing information can be collected for the stages performed.
-func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool, latency *stopwatch.NamedStopwatch) (*Instance, error) {
+// - queries on the instance fail once given context is done.
+func ReadTopologyInstanceBufferable(ctx context.Context, instanceKey *InstanceKey, bufferWrites bool, latency *stopwatch.NamedStopwatch) (*Instance, error) {
 	defer func() {
 		if err := recover(); err != nil {
 			logReadTopologyInstanceError(instanceKey, "Unexpected, aborting", fmt.Errorf("%+v", err))
@@ -294,6 +299,7 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 	isMaxScale := false
 	isMaxScale110 := false
 	slaveStatusFound := false
+	replicationChannels := ReplicationChannels{}
 	var resolveErr error
 
 	if !instanceKey.IsValid() {
@@ -310,7 +316,8 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 	})
 
 	latency.Start("instance")
-	db, err := db.OpenDiscovery(instanceKey.Hostname, instanceKey.Port)
+	sqlDB, err := db.OpenDiscovery(instanceKey.Hostname, instanceKey.Port)
+	db := newDiscoveryDB(ctx, sqlDB, discoveryQueryTimeout())
 	latency.Stop("instance")
 	if err != nil {
 		goto Cleanup
@@ -383,6 +390,12 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 			goto Cleanup
 		}
 		partialSuccess = true // We at least managed to read something from the server.
+		instance.ParsedVersion = ParseInstanceVersion(instance.Version)
+		instance.applyFlavorName()
+		// super_read_only only exists as of MySQL 5.7.8 and Percona Server 5.6.21; its absence means it's off
+		if serr := db.QueryRow("select @@global.super_read_only").Scan(&instance.SuperReadOnly); serr != nil {
+			instance.SuperReadOnly = false
+		}
 		switch strings.ToLower(config.Config.MySQLHostnameResolveMethod) {
 		case "none":
 			resolvedHostname = instance.Key.Hostname
@@ -402,27 +415,70 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 			waitGroup.Add(1)
 			go func() {
 				defer waitGroup.Done()
-				err = sqlutils.QueryRowsMap(db, "show master status", func(m sqlutils.RowMap) error {
+				err = db.QueryRowsMap("show master status", func(m sqlutils.RowMap) error {
 					var err error
 					instance.SelfBinlogCoordinates.LogFile = m.GetString("File")
 					instance.SelfBinlogCoordinates.LogPos = m.GetInt64("Position")
+					instance.ReplicationFilters.BinlogDoDB = normalizeReplicationFilter(m.GetStringD("Binlog_Do_DB", ""))
+					instance.ReplicationFilters.BinlogIgnoreDB = normalizeReplicationFilter(m.GetStringD("Binlog_Ignore_DB", ""))
 					return err
 				})
 			}()
+			waitGroup.Add(1)
+			go func() {
+				defer waitGroup.Done()
+				// Binary logs are listed oldest first
+				err = db.QueryRowsMap("show binary logs", func(m sqlutils.RowMap) error {
+					if instance.OldestBinaryLogFile == "" {
+						instance.OldestBinaryLogFile = m.GetString("Log_name")
+					}
+					return nil
+				})
+			}()
 		}
 
 		{
 			waitGroup.Add(1)
 			go func() {
 				defer waitGroup.Done()
-				err = sqlutils.QueryRowsMap(db, "show global status like 'rpl_semi_sync_%_status'", func(m sqlutils.RowMap) error {
-					if m.GetString("Variable_name") == "Rpl_semi_sync_master_status" {
+				semiSyncMasterPluginLoaded := false
+				err = db.QueryRowsMap("show global variables like 'rpl_semi_sync_%'", func(m sqlutils.RowMap) error {
+					switch m.GetString("Variable_name") {
+					case "rpl_semi_sync_master_enabled":
 						instance.SemiSyncMasterEnabled = (m.GetString("Value") == "ON")
-					} else if m.GetString("Variable_name") == "Rpl_semi_sync_slave_status" {
+						semiSyncMasterPluginLoaded = true
+					case "rpl_semi_sync_master_wait_for_slave_count":
+						instance.SemiSyncMasterWaitForReplicaCount = m.GetUint("Value")
+					case "rpl_semi_sync_slave_enabled":
 						instance.SemiSyncReplicaEnabled = (m.GetString("Value") == "ON")
 					}
 					return nil
 				})
+				logReadTopologyInstanceError(instanceKey, "show global variables like 'rpl_semi_sync_%'", err)
+				if semiSyncMasterPluginLoaded && instance.SemiSyncMasterWaitForReplicaCount == 0 {
+					// Prior to 5.7 there is no rpl_semi_sync_master_wait_for_slave_count; a single acknowledgement is awaited
+					instance.SemiSyncMasterWaitForReplicaCount = 1
+				}
+				err = db.QueryRowsMap("show global status like 'rpl_semi_sync_%'", func(m sqlutils.RowMap) error {
+					switch m.GetString("Variable_name") {
+					case "Rpl_semi_sync_master_status":
+						instance.SemiSyncMasterStatus = (m.GetString("Value") == "ON")
+					case "Rpl_semi_sync_master_clients":
+						instance.SemiSyncMasterClients = m.GetUint("Value")
+					case "Rpl_semi_sync_slave_status":
+						instance.SemiSyncReplicaStatus = (m.GetString("Value") == "ON")
+					}
+					return nil
+				})
+			}()
+		}
+		if instance.SupportsMariaDBGTID() {
+			waitGroup.Add(1)
+			go func() {
+				defer waitGroup.Done()
+				// MariaDB GTID positions; gtid_slave_pos is where a replica resumes from when using master_use_gtid=slave_pos
+				err := db.QueryRow("select @@global.gtid_current_pos, @@global.gtid_slave_pos").Scan(&instance.MariaDBGtidCurrentPos, &instance.MariaDBGtidSlavePos)
+				logReadTopologyInstanceError(instanceKey, "select @@global.gtid_current_pos, @@global.gtid_slave_pos", err)
 			}()
 		}
 		if (instance.IsOracleMySQL() || instance.IsPercona()) && !instance.IsSmallerMajorVersionByString("5.6") {
@@ -487,7 +543,13 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 
 	instance.ReplicationIOThreadState = ReplicationThreadStateNoThread
 	instance.ReplicationSQLThreadState = ReplicationThreadStateNoThread
-	err = sqlutils.QueryRowsMap(db, "show slave status", func(m sqlutils.RowMap) error {
+	err = db.QueryRowsMap("show slave status", func(m sqlutils.RowMap) error {
+		replicationChannels = append(replicationChannels, newReplicationChannelFromSlaveStatus(m))
+		if slaveStatusFound {
+			// A multi-source replica lists a row per replication channel. The instance's own replication
+			// attributes are those of the first row, which is the default channel, where it exists.
+			return nil
+		}
 		instance.HasReplicationCredentials = (m.GetString("Master_User") != "")
 		instance.ReplicationIOThreadState = ReplicationThreadStateFromStatus(m.GetString("Slave_IO_Running"))
 		instance.ReplicationSQLThreadState = ReplicationThreadStateFromStatus(m.GetString("Slave_SQL_Running"))
@@ -506,14 +568,24 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		instance.RelaylogCoordinates.LogPos = m.GetInt64("Relay_Log_Pos")
 		instance.RelaylogCoordinates.Type = RelayLog
 		instance.LastSQLError = emptyQuotesRegexp.ReplaceAllString(strconv.QuoteToASCII(m.GetString("Last_SQL_Error")), "")
+		instance.LastSQLErrno = m.GetIntD("Last_SQL_Errno", 0)
 		instance.LastIOError = emptyQuotesRegexp.ReplaceAllString(strconv.QuoteToASCII(m.GetString("Last_IO_Error")), "")
 		instance.SQLDelay = m.GetUintD("SQL_Delay", 0)
+		instance.SQLRemainingDelay = m.GetNullInt64("SQL_Remaining_Delay")
 		instance.UsingOracleGTID = (m.GetIntD("Auto_Position", 0) == 1)
 		instance.UsingMariaDBGTID = (m.GetStringD("Using_Gtid", "No") != "No")
 		instance.MasterUUID = m.GetStringD("Master_UUID", "No")
-		instance.HasReplicationFilters = ((m.GetStringD("Replicate_Do_DB", "") != "") || (m.GetStringD("Replicate_Ignore_DB", "") != "") || (m.GetStringD("Replicate_Do_Table", "") != "") || (m.GetStringD("Replicate_Ignore_Table", "") != "") || (m.GetStringD("Replicate_Wild_Do_Table", "") != "") || (m.GetStringD("Replicate_Wild_Ignore_Table", "") != ""))
+		instance.ReplicationFilters.ReplicateDoDB = normalizeReplicationFilter(m.GetStringD("Replicate_Do_DB", ""))
+		instance.ReplicationFilters.ReplicateIgnoreDB = normalizeReplicationFilter(m.GetStringD("Replicate_Ignore_DB", ""))
+		instance.ReplicationFilters.ReplicateDoTable = normalizeReplicationFilter(m.GetStringD("Replicate_Do_Table", ""))
+		instance.ReplicationFilters.ReplicateIgnoreTable = normalizeReplicationFilter(m.GetStringD("Replicate_Ignore_Table", ""))
+		instance.ReplicationFilters.ReplicateWildDoTable = normalizeReplicationFilter(m.GetStringD("Replicate_Wild_Do_Table", ""))
+		instance.ReplicationFilters.ReplicateWildIgnoreTable = normalizeReplicationFilter(m.GetStringD("Replicate_Wild_Ignore_Table", ""))
+		instance.HasReplicationFilters = instance.ReplicationFilters.HasReplicateFilters()
 
 		masterHostname := m.GetString("Master_Host")
+		// Master_Host:Master_Port as configured by CHANGE MASTER TO, e.g. a VIP, prior to any resolving
+		instance.RawMasterKey = InstanceKey{Hostname: masterHostname, Port: m.GetInt("Master_Port")}
 		if isMaxScale110 {
 			// Buggy buggy maxscale 1.1.0. Reported Master_Host can be corrupted.
 			// Therefore we (currently) take @@hostname (which is masquarading as master host anyhow)
@@ -538,6 +610,7 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		instance.SlaveLagSeconds = instance.SecondsBehindMaster
 
 		instance.AllowTLS = (m.GetString("Master_SSL_Allowed") == "Yes")
+		instance.ReplicationSSLCipher = m.GetString("Master_SSL_Cipher")
 		// Not breaking the flow even on error
 		slaveStatusFound = true
 		return nil
@@ -549,6 +622,19 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		err = fmt.Errorf("No 'SHOW SLAVE STATUS' output found for a MaxScale instance: %+v", instanceKey)
 		goto Cleanup
 	}
+	{
+		// Multi-source replication channels; not failing the discovery
+		err := populateReplicationChannels(instance, replicationChannels, db)
+		logReadTopologyInstanceError(instanceKey, "populateReplicationChannels", err)
+	}
+	if slaveStatusFound && !isMaxScale {
+		// Heartbeat period & age; not failing the discovery
+		err := populateReplicationHeartbeat(instance, db)
+		logReadTopologyInstanceError(instanceKey, "populateReplicationHeartbeat", err)
+		// Parallel replication workers & gaps; not failing the discovery
+		err = populateReplicationParallelism(instance, db)
+		logReadTopologyInstanceError(instanceKey, "populateReplicationParallelism", err)
+	}
 
 	if config.Config.ReplicationLagQuery != "" && !isMaxScale {
 		waitGroup.Add(1)
@@ -560,8 +646,11 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 					instance.SlaveLagSeconds.Int64 = 0
 				}
 			} else {
+				// Not failing the discovery; native lag is the next best thing
 				instance.SlaveLagSeconds = instance.SecondsBehindMaster
-				logReadTopologyInstanceError(instanceKey, "ReplicationLagQuery", err)
+				if util.ClearToLog("ReplicationLagQuery", instanceKey.StringCode()) {
+					log.Warningf("ReadTopologyInstance(%+v) ReplicationLagQuery failed; using Seconds_Behind_Master instead: %+v", *instanceKey, err)
+				}
 			}
 		}()
 	}
@@ -576,7 +665,7 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 	// Get replicas, either by SHOW SLAVE HOSTS or via PROCESSLIST
 	// MaxScale does not support PROCESSLIST, so SHOW SLAVE HOSTS is the only option
 	if config.Config.DiscoverByShowSlaveHosts || isMaxScale {
-		err := sqlutils.QueryRowsMap(db, `show slave hosts`,
+		err := db.QueryRowsMap(`show slave hosts`,
 			func(m sqlutils.RowMap) error {
 				// MaxScale 1.1 may trigger an error with this command, but
 				// also we may see issues if anything on the MySQL server locks up.
@@ -590,12 +679,21 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 						// - seen in 1.1.0 and 1.4.3.4
 						return nil
 					}
+					if host == "" && !isMaxScale {
+						// Replica does not have report_host configured. Skip it; we may yet
+						// find it via processlist.
+						log.Debugf("ReadTopologyInstance(%+v) 'show slave hosts' returned row with empty host for server_id %d; is report_host configured?", *instanceKey, m.GetUint("Server_id"))
+						return nil
+					}
 					// otherwise report the error to the caller
 					return fmt.Errorf("ReadTopologyInstance(%+v) 'show slave hosts' returned row with <host,port>: <%v,%v>", instanceKey, host, port)
 				}
 
 				replicaKey, err := NewResolveInstanceKey(host, port)
 				if err == nil && replicaKey.IsValid() {
+					if isStaleReportedReplicaKey(instanceKey, replicaKey, m.GetUint("Server_id")) {
+						return nil
+					}
 					instance.AddReplicaKey(replicaKey)
 					foundByShowSlaveHosts = true
 				}
@@ -610,7 +708,7 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		waitGroup.Add(1)
 		go func() {
 			defer waitGroup.Done()
-			err := sqlutils.QueryRowsMap(db, `
+			err := db.QueryRowsMap(`
       	select
       		substring_index(host, ':', 1) as slave_hostname
       	from
@@ -637,7 +735,7 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		waitGroup.Add(1)
 		go func() {
 			defer waitGroup.Done()
-			err := sqlutils.QueryRowsMap(db, `
+			err := db.QueryRowsMap(`
       	select
       		substring(service_URI,9) mysql_host
       	from
@@ -659,6 +757,57 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		}()
 	}
 
+	if !isMaxScale {
+		// Locally enabled events on a replica write data its master never sees; events replicated from the
+		// master are SLAVESIDE_DISABLED. Not failing the discovery.
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			err := db.QueryRow("select @@global.event_scheduler = 'ON'").Scan(&instance.EventSchedulerEnabled)
+			logReadTopologyInstanceError(instanceKey, "event_scheduler", err)
+			if err != nil || !instance.EventSchedulerEnabled {
+				// events do not run anyhow
+				return
+			}
+			instance.CountEnabledEvents, err = readCountEnabledEvents(instanceKey, func() (countEnabledEvents uint, err error) {
+				err = db.QueryRow("select count(*) from information_schema.events where status = 'ENABLED'").Scan(&countEnabledEvents)
+				return countEnabledEvents, err
+			})
+			logReadTopologyInstanceError(instanceKey, "information_schema.events", err)
+		}()
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			err := db.QueryRow("select @@global.sql_mode").Scan(&instance.SQLMode)
+			logReadTopologyInstanceError(instanceKey, "sql_mode", err)
+		}()
+	}
+
+	if instance.IsMasterWritesProbable() && !isMaxScale {
+		// The connection is healthy at this point; a write which cannot make it through means the master is locked
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			writesBlocked, err := probeMasterWrites(sqlDB, instance)
+			instance.MasterWritesBlocked = writesBlocked
+			logReadTopologyInstanceError(instanceKey, "master writes probe", err)
+		}()
+	}
+
+	if config.Config.BinlogServerVersionPattern != "" && !isMaxScale {
+		if matched, _ := regexp.MatchString(config.Config.BinlogServerVersionPattern, instance.Version); matched {
+			instance.IsDetectedBinlogServer = true
+		}
+	}
+	if config.Config.DetectBinlogServerQuery != "" && !isMaxScale && !instance.IsDetectedBinlogServer {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			err := db.QueryRow(config.Config.DetectBinlogServerQuery).Scan(&instance.IsDetectedBinlogServer)
+			logReadTopologyInstanceError(instanceKey, "DetectBinlogServerQuery", err)
+		}()
+	}
+
 	if config.Config.DetectDataCenterQuery != "" && !isMaxScale {
 		waitGroup.Add(1)
 		go func() {
@@ -704,6 +853,12 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		}()
 	}
 
+	if (instance.IsOracleMySQL() || instance.IsPercona()) && !instance.IsSmallerMajorVersionByString("5.7") {
+		// Group Replication membership; ReadInstanceClusterAttributes below depends on it
+		err := populateGroupReplicationInformation(instance, db)
+		logReadTopologyInstanceError(instanceKey, "populateGroupReplicationInformation", err)
+	}
+
 	{
 		latency.Start("backend")
 		err = ReadInstanceClusterAttributes(instance)
@@ -723,7 +878,7 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 			waitGroup.Add(1)
 			go func() {
 				defer waitGroup.Done()
-				if resultData, err := sqlutils.QueryResultData(db, config.Config.DetectPseudoGTIDQuery); err == nil {
+				if resultData, err := db.QueryResultData(config.Config.DetectPseudoGTIDQuery); err == nil {
 					if len(resultData) > 0 {
 						if len(resultData[0]) > 0 {
 							if resultData[0][0].Valid && resultData[0][0].String == "1" {
@@ -768,6 +923,20 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		}()
 	}
 
+	if config.Config.DetectInstancePoolQuery != "" && !isMaxScale {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			var delimitedPools string
+			err := db.QueryRow(config.Config.DetectInstancePoolQuery).Scan(&delimitedPools)
+			logReadTopologyInstanceError(instanceKey, "DetectInstancePoolQuery", err)
+			if pools := parseDetectedInstancePools(delimitedPools); err == nil && len(pools) > 0 {
+				err = registerInstancePools(instanceKey, pools)
+				logReadTopologyInstanceError(instanceKey, "registerInstancePools", err)
+			}
+		}()
+	}
+
 	ReadClusterAliasOverride(instance)
 	if !isMaxScale {
 		if instance.SuggestedClusterAlias == "" {
@@ -806,6 +975,12 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 
 Cleanup:
 	waitGroup.Wait()
+	instance.applyRecentlyRestarted()
+	// Some queries may have exceeded their deadline; the instance is still found, but not fully read
+	instance.LastCheckQueriesTimedOut = db.TimedOut()
+	if instanceFound && instance.LastCheckQueriesTimedOut {
+		log.Warningf("ReadTopologyInstance(%+v): some discovery queries exceeded %+v; instance is partially read", *instanceKey, db.timeout)
+	}
 
 	if instanceFound {
 		if instance.IsCoMaster {
@@ -917,7 +1092,12 @@ func ReadInstanceClusterAttributes(instance *Instance) (err error) {
 				from database_instance
 				where hostname=? and port=?
 	`
-	args := sqlutils.Args(instance.MasterKey.Hostname, instance.MasterKey.Port)
+	masterOrGroupAnchorKey := instance.MasterKey
+	if groupAnchorKey := instance.replicationGroupAnchorKey(); groupAnchorKey != nil && !instance.IsReplica() {
+		// Group Replication member: derive from the group's anchor member, such that the entire group is one cluster
+		masterOrGroupAnchorKey = *groupAnchorKey
+	}
+	args := sqlutils.Args(masterOrGroupAnchorKey.Hostname, masterOrGroupAnchorKey.Port)
 
 	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
 		masterClusterName = m.GetString("cluster_name")
@@ -1029,6 +1209,20 @@ func ReadInstancePromotionRule(instance *Instance) (err error) {
 	return log.Errore(err)
 }
 
+// readCountEnabledEvents returns the number of enabled events on given instance, as read by given function at most
+// once per CheckEnabledEventsIntervalSeconds
+func readCountEnabledEvents(instanceKey *InstanceKey, read func() (uint, error)) (uint, error) {
+	if countEnabledEvents, found := enabledEventsCounts.Get(instanceKey.StringCode()); found {
+		return countEnabledEvents.(uint), nil
+	}
+	countEnabledEvents, err := read()
+	if err != nil {
+		return 0, err
+	}
+	enabledEventsCounts.Set(instanceKey.StringCode(), countEnabledEvents, cache.DefaultExpiration)
+	return countEnabledEvents, nil
+}
+
 // readInstanceRow reads a single instance row from the orchestrator backend database.
 func readInstanceRow(m sqlutils.RowMap) *Instance {
 	instance := NewInstance()
@@ -1036,11 +1230,18 @@ func readInstanceRow(m sqlutils.RowMap) *Instance {
 	instance.Key.Hostname = m.GetString("hostname")
 	instance.Key.Port = m.GetInt("port")
 	instance.Uptime = m.GetUint("uptime")
+	instance.applyRecentlyRestarted()
 	instance.ServerID = m.GetUint("server_id")
 	instance.ServerUUID = m.GetString("server_uuid")
 	instance.Version = m.GetString("version")
 	instance.VersionComment = m.GetString("version_comment")
+	instance.IsDetectedBinlogServer = m.GetBool("binlog_server") && !instance.isMaxScale()
 	instance.ReadOnly = m.GetBool("read_only")
+	instance.SuperReadOnly = m.GetBool("super_read_only")
+	instance.EventSchedulerEnabled = m.GetBool("event_scheduler_enabled")
+	instance.CountEnabledEvents = m.GetUint("count_enabled_events")
+	instance.SQLMode = m.GetString("sql_mode")
+	instance.MasterWritesBlocked = m.GetBool("master_writes_blocked")
 	instance.Binlog_format = m.GetString("binlog_format")
 	instance.BinlogRowImage = m.GetString("binlog_row_image")
 	instance.LogBinEnabled = m.GetBool("log_bin")
@@ -1048,11 +1249,15 @@ func readInstanceRow(m sqlutils.RowMap) *Instance {
 	instance.MasterKey.Hostname = m.GetString("master_host")
 	instance.MasterKey.Port = m.GetInt("master_port")
 	instance.IsDetachedMaster = instance.MasterKey.IsDetached()
+	instance.RawMasterKey.Hostname = m.GetString("raw_master_host")
+	instance.RawMasterKey.Port = m.GetInt("raw_master_port")
 	instance.Slave_SQL_Running = m.GetBool("slave_sql_running")
 	instance.Slave_IO_Running = m.GetBool("slave_io_running")
 	instance.ReplicationSQLThreadState = ReplicationThreadState(m.GetInt("replication_sql_thread_state"))
 	instance.ReplicationIOThreadState = ReplicationThreadState(m.GetInt("replication_io_thread_state"))
 	instance.HasReplicationFilters = m.GetBool("has_replication_filters")
+	instance.ReplicationFilters.ReadJson(m.GetString("replication_filters"))
+	instance.ReplicationChannels.ReadJson(m.GetString("replication_channels"))
 	instance.SupportsOracleGTID = m.GetBool("supports_oracle_gtid")
 	instance.UsingOracleGTID = m.GetBool("oracle_gtid")
 	instance.MasterUUID = m.GetString("master_uuid")
@@ -1060,11 +1265,14 @@ func readInstanceRow(m sqlutils.RowMap) *Instance {
 	instance.ExecutedGtidSet = m.GetString("executed_gtid_set")
 	instance.GTIDMode = m.GetString("gtid_mode")
 	instance.GtidPurged = m.GetString("gtid_purged")
+	instance.MariaDBGtidCurrentPos = m.GetString("mariadb_gtid_current_pos")
+	instance.MariaDBGtidSlavePos = m.GetString("mariadb_gtid_slave_pos")
 	instance.GtidErrant = m.GetString("gtid_errant")
 	instance.UsingMariaDBGTID = m.GetBool("mariadb_gtid")
 	instance.UsingPseudoGTID = m.GetBool("pseudo_gtid")
 	instance.SelfBinlogCoordinates.LogFile = m.GetString("binary_log_file")
 	instance.SelfBinlogCoordinates.LogPos = m.GetInt64("binary_log_pos")
+	instance.OldestBinaryLogFile = m.GetString("oldest_binary_log_file")
 	instance.ReadBinlogCoordinates.LogFile = m.GetString("master_log_file")
 	instance.ReadBinlogCoordinates.LogPos = m.GetInt64("read_master_log_pos")
 	instance.ExecBinlogCoordinates.LogFile = m.GetString("relay_master_log_file")
@@ -1074,10 +1282,18 @@ func readInstanceRow(m sqlutils.RowMap) *Instance {
 	instance.RelaylogCoordinates.LogPos = m.GetInt64("relay_log_pos")
 	instance.RelaylogCoordinates.Type = RelayLog
 	instance.LastSQLError = m.GetString("last_sql_error")
+	instance.LastSQLErrno = m.GetInt("last_sql_errno")
 	instance.LastIOError = m.GetString("last_io_error")
 	instance.SecondsBehindMaster = m.GetNullInt64("seconds_behind_master")
 	instance.SlaveLagSeconds = m.GetNullInt64("slave_lag_seconds")
 	instance.SQLDelay = m.GetUint("sql_delay")
+	instance.SQLRemainingDelay = m.GetNullInt64("sql_remaining_delay")
+	instance.SlaveNetTimeout = m.GetUint("slave_net_timeout")
+	instance.HeartbeatPeriodSeconds, _ = strconv.ParseFloat(m.GetString("heartbeat_period"), 64)
+	instance.ReceivedHeartbeats = m.GetInt64("received_heartbeats")
+	instance.SecondsSinceLastHeartbeat = m.GetNullInt64("seconds_since_last_heartbeat")
+	instance.SlaveParallelWorkers = m.GetUint("slave_parallel_workers")
+	instance.HasReplicationGaps = m.GetBool("has_replication_gaps")
 	slaveHostsJSON := m.GetString("slave_hosts")
 	instance.ClusterName = m.GetString("cluster_name")
 	instance.SuggestedClusterAlias = m.GetString("suggested_cluster_alias")
@@ -1087,13 +1303,25 @@ func readInstanceRow(m sqlutils.RowMap) *Instance {
 	instance.SemiSyncEnforced = m.GetBool("semi_sync_enforced")
 	instance.SemiSyncMasterEnabled = m.GetBool("semi_sync_master_enabled")
 	instance.SemiSyncReplicaEnabled = m.GetBool("semi_sync_replica_enabled")
+	instance.SemiSyncMasterStatus = m.GetBool("semi_sync_master_status")
+	instance.SemiSyncReplicaStatus = m.GetBool("semi_sync_replica_status")
+	instance.SemiSyncMasterClients = m.GetUint("semi_sync_master_clients")
+	instance.SemiSyncMasterWaitForReplicaCount = m.GetUint("semi_sync_master_wait_for_replica_count")
+	instance.ReplicationGroupName = m.GetString("replication_group_name")
+	instance.ReplicationGroupIsSinglePrimary = m.GetBool("replication_group_is_single_primary_mode")
+	instance.ReplicationGroupMemberState = m.GetString("replication_group_member_state")
+	instance.ReplicationGroupMemberRole = m.GetString("replication_group_member_role")
+	instance.ReplicationGroupMembers.ReadJson(m.GetString("replication_group_members"))
+	instance.ReplicationGroupPrimaryInstanceKey = InstanceKey{Hostname: m.GetString("replication_group_primary_host"), Port: m.GetInt("replication_group_primary_port")}
 	instance.ReplicationDepth = m.GetUint("replication_depth")
 	instance.IsCoMaster = m.GetBool("is_co_master")
 	instance.ReplicationCredentialsAvailable = m.GetBool("replication_credentials_available")
 	instance.HasReplicationCredentials = m.GetBool("has_replication_credentials")
 	instance.IsUpToDate = (m.GetUint("seconds_since_last_checked") <= config.Config.InstancePollSeconds)
-	instance.IsRecentlyChecked = (m.GetUint("seconds_since_last_checked") <= config.Config.InstancePollSeconds*5)
+	instance.IsStale = (m.GetUint("seconds_since_last_checked") > StaleInstanceSeconds())
+	instance.IsRecentlyChecked = !instance.IsStale
 	instance.LastSeenTimestamp = m.GetString("last_seen")
+	instance.LastCheckedTimestamp = m.GetString("last_checked")
 	instance.IsLastCheckValid = m.GetBool("is_last_check_valid")
 	instance.SecondsSinceLastSeen = m.GetNullInt64("seconds_since_last_seen")
 	instance.IsCandidate = m.GetBool("is_candidate")
@@ -1103,19 +1331,27 @@ func readInstanceRow(m sqlutils.RowMap) *Instance {
 	instance.DowntimeOwner = m.GetString("downtime_owner")
 	instance.DowntimeEndTimestamp = m.GetString("downtime_end_timestamp")
 	instance.ElapsedDowntime = time.Second * time.Duration(m.GetInt("elapsed_downtime_seconds"))
+	instance.RemainingDowntime = time.Second * time.Duration(m.GetInt("remaining_downtime_seconds"))
+	instance.IgnoreHealthChecks = m.GetBool("ignore_health_checks")
+	instance.IgnoreHealthChecksOwner = m.GetString("ignore_health_checks_owner")
+	instance.IgnoreHealthChecksReason = m.GetString("ignore_health_checks_reason")
 	instance.UnresolvedHostname = m.GetString("unresolved_hostname")
 	instance.AllowTLS = m.GetBool("allow_tls")
+	instance.ReplicationSSLCipher = m.GetString("replication_ssl_cipher")
 	instance.InstanceAlias = m.GetString("instance_alias")
 	instance.LastDiscoveryLatency = time.Duration(m.GetInt64("last_discovery_latency")) * time.Nanosecond
+	instance.LastCheckQueriesTimedOut = m.GetBool("last_check_queries_timed_out")
 
 	instance.SlaveHosts.ReadJson(slaveHostsJSON)
+	instance.ParsedVersion = ParseInstanceVersion(instance.Version)
 	instance.applyFlavorName()
 
 	// problems
-	if !instance.IsLastCheckValid {
-		instance.Problems = append(instance.Problems, "last_check_invalid")
-	} else if !instance.IsRecentlyChecked {
+	if instance.IsStale {
+		// Whatever the last check says, it is too old to be told apart from a failure
 		instance.Problems = append(instance.Problems, "not_recently_checked")
+	} else if !instance.IsLastCheckValid {
+		instance.Problems = append(instance.Problems, "last_check_invalid")
 	} else if instance.ReplicationThreadsExist() && !instance.ReplicaRunning() {
 		instance.Problems = append(instance.Problems, "not_replicating")
 	} else if instance.SlaveLagSeconds.Valid && math.AbsInt64(instance.SlaveLagSeconds.Int64-int64(instance.SQLDelay)) > int64(config.Config.ReasonableReplicationLagSeconds) {
@@ -1149,13 +1385,18 @@ func readInstancesByCondition(condition string, args []interface{}, sort string)
 			(database_instance_downtime.downtime_active is not null and ifnull(database_instance_downtime.end_timestamp, now()) > now()) as is_downtimed,
     	ifnull(database_instance_downtime.reason, '') as downtime_reason,
 			ifnull(database_instance_downtime.owner, '') as downtime_owner,
-			ifnull(unix_timestamp() - unix_timestamp(begin_timestamp), 0) as elapsed_downtime_seconds,
-    	ifnull(database_instance_downtime.end_timestamp, '') as downtime_end_timestamp
+			ifnull(unix_timestamp() - unix_timestamp(database_instance_downtime.begin_timestamp), 0) as elapsed_downtime_seconds,
+			ifnull(unix_timestamp(database_instance_downtime.end_timestamp) - unix_timestamp(), 0) as remaining_downtime_seconds,
+    	ifnull(database_instance_downtime.end_timestamp, '') as downtime_end_timestamp,
+			database_instance_ignore_health_checks.hostname is not null as ignore_health_checks,
+			ifnull(database_instance_ignore_health_checks.owner, '') as ignore_health_checks_owner,
+			ifnull(database_instance_ignore_health_checks.reason, '') as ignore_health_checks_reason
 		from
 			database_instance
 			left join candidate_database_instance using (hostname, port)
 			left join hostname_unresolve using (hostname)
 			left join database_instance_downtime using (hostname, port)
+			left join database_instance_ignore_health_checks using (hostname, port)
 		where
 			%s
 		order by
@@ -1190,6 +1431,41 @@ func readInstancesByExactKey(instanceKey *InstanceKey) ([](*Instance), error) {
 	return readInstancesByCondition(condition, sqlutils.Args(instanceKey.Hostname, instanceKey.Port), "")
 }
 
+// readInstancesByKeys reads the instances of given keys in a single query. Keys not found in the backend
+// are silently skipped.
+func readInstancesByKeys(instanceKeys []InstanceKey) ([](*Instance), error) {
+	if len(instanceKeys) == 0 {
+		return [](*Instance){}, nil
+	}
+	conditions := []string{}
+	args := sqlutils.Args()
+	for _, instanceKey := range instanceKeys {
+		conditions = append(conditions, "(hostname = ? and port = ?)")
+		args = append(args, instanceKey.Hostname, instanceKey.Port)
+	}
+	return readInstancesByCondition(strings.Join(conditions, " or "), args, "")
+}
+
+// isStaleReportedReplicaKey cross-checks a replica reported by a master's SHOW SLAVE HOSTS against
+// what the backend knows about it. report_host may be stale or reused by a different server; if we
+// know the reported host to be a different server, we ignore the report. Unknown hosts are accepted,
+// so that they get discovered. So are hosts known to replicate from a different master: these have
+// been relocated since last read, as only connected replicas are reported.
+func isStaleReportedReplicaKey(masterKey *InstanceKey, replicaKey *InstanceKey, reportedServerId uint) bool {
+	replica, found, err := ReadInstance(replicaKey)
+	if err != nil || !found || replica == nil {
+		return false
+	}
+	if reportedServerId != 0 && replica.ServerID != 0 && replica.ServerID != reportedServerId {
+		log.Debugf("ReadTopologyInstance(%+v) 'show slave hosts' reports %+v with server_id %d, but it is known to have server_id %d; ignoring", *masterKey, *replicaKey, reportedServerId, replica.ServerID)
+		return true
+	}
+	if replica.IsReplica() && !replica.MasterKey.Equals(masterKey) {
+		log.Debugf("ReadTopologyInstance(%+v) 'show slave hosts' reports %+v, which is known to replicate from %+v; it has apparently been relocated", *masterKey, *replicaKey, replica.MasterKey)
+	}
+	return false
+}
+
 // ReadInstance reads an instance from the orchestrator backend database
 func ReadInstance(instanceKey *InstanceKey) (*Instance, bool, error) {
 	instances, err := readInstancesByExactKey(instanceKey)
@@ -1214,6 +1490,15 @@ func ReadClusterInstances(clusterName string) ([](*Instance), error) {
 	return readInstancesByCondition(condition, sqlutils.Args(clusterName), "")
 }
 
+// ReadClusterErrantGTIDInstances reads all instances of a given cluster which have errant GTID
+func ReadClusterErrantGTIDInstances(clusterName string) ([](*Instance), error) {
+	condition := `
+		cluster_name = ?
+		and gtid_errant != ''
+	`
+	return readInstancesByCondition(condition, sqlutils.Args(clusterName), "")
+}
+
 // ReadClusterWriteableMaster returns the/a writeable master of this cluster
 // Typically, the cluster name indicates the master of the cluster. However, in circular
 // master-master replication one master can assume the name of the cluster, and it is
@@ -1324,12 +1609,35 @@ func ReadProblemInstances(clusterName string) ([](*Instance), error) {
 	if err != nil {
 		return instances, err
 	}
+	duplicates, err := ReadDuplicateServerIDInstances(clusterName)
+	if err != nil {
+		return instances, err
+	}
+	for _, instance := range instances {
+		if _, ok := duplicates[instance.Key]; ok {
+			instance.Problems = append(instance.Problems, "duplicate_server_id")
+			delete(duplicates, instance.Key)
+		}
+	}
+	for instanceKey := range duplicates {
+		instance, found, err := ReadInstance(&instanceKey)
+		if err != nil {
+			return instances, err
+		}
+		if found {
+			instance.Problems = append(instance.Problems, "duplicate_server_id")
+			instances = append(instances, instance)
+		}
+	}
 	var reportedInstances [](*Instance)
 	for _, instance := range instances {
 		skip := false
 		if instance.IsDowntimed {
 			skip = true
 		}
+		if instance.IgnoreHealthChecks {
+			skip = true
+		}
 		if RegexpMatchPatterns(instance.Key.StringCode(), config.Config.ProblemIgnoreHostnameFilters) {
 			skip = true
 		}
@@ -1340,6 +1648,307 @@ func ReadProblemInstances(clusterName string) ([](*Instance), error) {
 	return reportedInstances, nil
 }
 
+// ReadDuplicateServerIDInstances returns all instances which share their server_id or server_uuid
+// with some other instance of the same cluster, each mapped to the instances it shares them with.
+// Instances which have not been fully read yet (zero server_id, empty server_uuid) are ignored.
+func ReadDuplicateServerIDInstances(clusterName string) (map[InstanceKey]*InstanceKeyMap, error) {
+	duplicates := make(map[InstanceKey]*InstanceKeyMap)
+	query := `
+		select
+			database_instance.hostname,
+			database_instance.port,
+			duplicate_instance.hostname as duplicate_hostname,
+			duplicate_instance.port as duplicate_port
+		from
+			database_instance
+			join database_instance as duplicate_instance on (
+				database_instance.cluster_name = duplicate_instance.cluster_name
+				and (
+					database_instance.hostname != duplicate_instance.hostname
+					or database_instance.port != duplicate_instance.port
+				)
+				and (
+					(database_instance.server_id != 0 and database_instance.server_id = duplicate_instance.server_id)
+					or (database_instance.server_uuid != '' and database_instance.server_uuid = duplicate_instance.server_uuid)
+				)
+			)
+		where
+			database_instance.cluster_name != ''
+			and ? IN ('', database_instance.cluster_name)
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(clusterName), func(m sqlutils.RowMap) error {
+		instanceKey := InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")}
+		duplicateKey := InstanceKey{Hostname: m.GetString("duplicate_hostname"), Port: m.GetInt("duplicate_port")}
+		if _, ok := duplicates[instanceKey]; !ok {
+			duplicates[instanceKey] = NewInstanceKeyMap()
+		}
+		duplicates[instanceKey].AddKey(duplicateKey)
+		return nil
+	})
+	return duplicates, log.Errore(err)
+}
+
+// ReadMultiSourceReplicas returns replicas with more than one replication channel
+func ReadMultiSourceReplicas(clusterName string) ([](*Instance), error) {
+	condition := `
+			database_instance.replication_channels != ''
+			and ? IN ('', database_instance.cluster_name)
+		`
+	return readInstancesByCondition(condition, sqlutils.Args(clusterName), "")
+}
+
+// ReadClustersFlavorNames returns the distinct flavors (MySQL, Percona, MariaDB) found in each cluster.
+// Binlog servers and instances of yet unknown flavor are ignored.
+func ReadClustersFlavorNames(clusterName string) (map[string][]string, error) {
+	clustersFlavorNames := make(map[string][]string)
+	query := `
+		select distinct
+			cluster_name,
+			flavor_name
+		from
+			database_instance
+		where
+			cluster_name != ''
+			and flavor_name not in ('', 'MaxScale', 'unknown')
+			and ? IN ('', cluster_name)
+		order by
+			cluster_name, flavor_name
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(clusterName), func(m sqlutils.RowMap) error {
+		instanceClusterName := m.GetString("cluster_name")
+		clustersFlavorNames[instanceClusterName] = append(clustersFlavorNames[instanceClusterName], m.GetString("flavor_name"))
+		return nil
+	})
+	return clustersFlavorNames, log.Errore(err)
+}
+
+// ReadIntermediateMastersWithoutLogSlaveUpdates returns replicas which have replicas of their own, yet do not
+// have log_slave_updates enabled. A co-master's own master replicating from it does not count.
+func ReadIntermediateMastersWithoutLogSlaveUpdates(clusterName string) ([](*Instance), error) {
+	condition := `
+			database_instance.master_host != ''
+			and database_instance.log_slave_updates = 0
+			and database_instance.binlog_server = 0
+			and ? IN ('', database_instance.cluster_name)
+			and exists (
+				select 1 from database_instance as replica_instance
+				where
+					replica_instance.master_host = database_instance.hostname
+					and replica_instance.master_port = database_instance.port
+					and not (
+						replica_instance.hostname = database_instance.master_host
+						and replica_instance.port = database_instance.master_port
+					)
+			)
+		`
+	return readInstancesByCondition(condition, sqlutils.Args(clusterName), "")
+}
+
+// ReadReplicasWithEnabledEvents returns read-only replicas which have the event scheduler running along with
+// enabled events, i.e. events which were not replicated from the master. A passive co-master, which keeps the
+// events it ran while active, does not count.
+func ReadReplicasWithEnabledEvents(clusterName string) ([](*Instance), error) {
+	condition := `
+			database_instance.master_host != ''
+			and database_instance.read_only = 1
+			and database_instance.is_co_master = 0
+			and database_instance.event_scheduler_enabled = 1
+			and database_instance.count_enabled_events > 0
+			and ? IN ('', database_instance.cluster_name)
+		`
+	return readInstancesByCondition(condition, sqlutils.Args(clusterName), "")
+}
+
+// ReadResurrectedOldMasters returns failed masters of successful, unacknowledged master recoveries which do not
+// replicate, i.e. were not repointed under the master which replaced them, and which have not since been
+// promoted by a later recovery.
+func ReadResurrectedOldMasters(clusterName string) ([](*Instance), error) {
+	condition := `
+			database_instance.master_host IN ('', '_')
+			and exists (
+				select
+					1
+				from
+					topology_recovery
+				where
+					topology_recovery.hostname = database_instance.hostname
+					and topology_recovery.port = database_instance.port
+					and topology_recovery.analysis IN (?, ?)
+					and topology_recovery.is_successful = 1
+					and topology_recovery.acknowledged = 0
+					and not exists (
+						select
+							1
+						from
+							topology_recovery later_recovery
+						where
+							later_recovery.successor_hostname = database_instance.hostname
+							and later_recovery.successor_port = database_instance.port
+							and later_recovery.is_successful = 1
+							and later_recovery.recovery_id > topology_recovery.recovery_id
+					)
+			)
+			and ? IN ('', database_instance.cluster_name)
+		`
+	return readInstancesByCondition(condition, sqlutils.Args(string(DeadMaster), string(DeadMasterAndSomeSlaves), clusterName), "")
+}
+
+// ReadReplicasWithOverdueHeartbeat returns replicating replicas which have not received a heartbeat from
+// their master within twice their heartbeat period
+func ReadReplicasWithOverdueHeartbeat(clusterName string) ([](*Instance), error) {
+	condition := `
+			database_instance.master_host != ''
+			and database_instance.slave_io_running = 1
+			and database_instance.heartbeat_period > 0
+			and database_instance.seconds_since_last_heartbeat > 2 * database_instance.heartbeat_period
+			and ? IN ('', database_instance.cluster_name)
+		`
+	return readInstancesByCondition(condition, sqlutils.Args(clusterName), "")
+}
+
+// ReadMasterHostDriftedReplicas returns replicas whose configured (raw) master host, e.g. a VIP, leads to a
+// different server than the master orchestrator resolved it to. Each replica is mapped to the instance it
+// actually replicates from, as identified by its reported master server_uuid.
+func ReadMasterHostDriftedReplicas(clusterName string) (map[InstanceKey]InstanceKey, error) {
+	drifted := make(map[InstanceKey]InstanceKey)
+	query := `
+		select
+			database_instance.hostname,
+			database_instance.port,
+			actual_master.hostname as actual_master_hostname,
+			actual_master.port as actual_master_port
+		from
+			database_instance
+			join database_instance as acknowledged_master on (
+				acknowledged_master.hostname = database_instance.master_host
+				and acknowledged_master.port = database_instance.master_port
+			)
+			join database_instance as actual_master on (
+				actual_master.server_uuid = database_instance.master_uuid
+				and (
+					actual_master.hostname != acknowledged_master.hostname
+					or actual_master.port != acknowledged_master.port
+				)
+			)
+		where
+			database_instance.master_uuid not in ('', 'No')
+			and acknowledged_master.server_uuid != database_instance.master_uuid
+			and database_instance.raw_master_host != ''
+			and (
+				database_instance.raw_master_host != database_instance.master_host
+				or database_instance.raw_master_port != database_instance.master_port
+			)
+			and ? IN ('', database_instance.cluster_name)
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(clusterName), func(m sqlutils.RowMap) error {
+		instanceKey := InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")}
+		drifted[instanceKey] = InstanceKey{Hostname: m.GetString("actual_master_hostname"), Port: m.GetInt("actual_master_port")}
+		return nil
+	})
+	return drifted, log.Errore(err)
+}
+
+// ReadReplicasPointingToWrongEndpoint returns replicas whose configured (raw) master host & port is not the key of
+// the master they replicate from. The master is identified by the replica's reported master server_uuid, or, when
+// unknown, by the replica's resolved master key. Replicas whose resolved master is a different server than the
+// one they replicate from are excluded: these are MasterHostDrift. Each replica is mapped to its master's key.
+func ReadReplicasPointingToWrongEndpoint(clusterName string) (map[InstanceKey]InstanceKey, error) {
+	replicas := make(map[InstanceKey]InstanceKey)
+	query := `
+		select
+			database_instance.hostname,
+			database_instance.port,
+			ifnull(uuid_master.hostname, resolved_master.hostname) as canonical_master_hostname,
+			ifnull(uuid_master.port, resolved_master.port) as canonical_master_port
+		from
+			database_instance
+			left join database_instance as uuid_master on (
+				database_instance.master_uuid not in ('', 'No')
+				and uuid_master.server_uuid = database_instance.master_uuid
+			)
+			left join database_instance as resolved_master on (
+				resolved_master.hostname = database_instance.master_host
+				and resolved_master.port = database_instance.master_port
+			)
+		where
+			database_instance.raw_master_host != ''
+			and (uuid_master.hostname is not null or resolved_master.hostname is not null)
+			and (
+				uuid_master.hostname is null
+				or resolved_master.hostname is null
+				or resolved_master.server_uuid = database_instance.master_uuid
+			)
+			and (
+				database_instance.raw_master_host != ifnull(uuid_master.hostname, resolved_master.hostname)
+				or database_instance.raw_master_port != ifnull(uuid_master.port, resolved_master.port)
+			)
+			and ? IN ('', database_instance.cluster_name)
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(clusterName), func(m sqlutils.RowMap) error {
+		instanceKey := InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")}
+		replicas[instanceKey] = InstanceKey{Hostname: m.GetString("canonical_master_hostname"), Port: m.GetInt("canonical_master_port")}
+		return nil
+	})
+	return replicas, log.Errore(err)
+}
+
+// readCanonicalMasterKey returns the key of the master given replica replicates from: the instance identified by the
+// replica's reported master server_uuid, or else the replica's resolved master.
+func readCanonicalMasterKey(instance *Instance) (*InstanceKey, error) {
+	if instance.MasterUUID != "" && instance.MasterUUID != "No" {
+		masters, err := readInstancesByCondition("server_uuid = ?", sqlutils.Args(instance.MasterUUID), "")
+		if err != nil {
+			return nil, err
+		}
+		if len(masters) > 1 {
+			return nil, fmt.Errorf("Found %d instances with server_uuid %s; cannot tell the master of %+v", len(masters), instance.MasterUUID, instance.Key)
+		}
+		if len(masters) == 1 {
+			return &masters[0].Key, nil
+		}
+	}
+	master, found, err := ReadInstance(&instance.MasterKey)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("Cannot find master %+v of %+v", instance.MasterKey, instance.Key)
+	}
+	return &master.Key, nil
+}
+
+// ReadReplicationFilteredInstancesInUnfilteredClusters returns instances which use replication filters,
+// in clusters whose master does not use any filters.
+func ReadReplicationFilteredInstancesInUnfilteredClusters(clusterName string) ([](*Instance), error) {
+	condition := `
+			database_instance.has_replication_filters = 1
+			and database_instance.cluster_name != ''
+			and ? IN ('', database_instance.cluster_name)
+			and not exists (
+				select 1 from database_instance as cluster_master
+				where
+					cluster_master.cluster_name = database_instance.cluster_name
+					and cluster_master.replication_depth = 0
+					and cluster_master.replication_filters != ''
+			)
+		`
+	return readInstancesByCondition(condition, sqlutils.Args(clusterName), "")
+}
+
+// ReadReplicasFailingOnMasterBinlogs returns replicas whose IO thread fails with error 1236 (fatal error reading
+// the master's binary logs).
+func ReadReplicasFailingOnMasterBinlogs(clusterName string) ([](*Instance), error) {
+	condition := `
+			database_instance.master_host != ''
+			and ? IN ('', database_instance.cluster_name)
+			and (
+				instr(database_instance.last_io_error, '1236') > 0
+				or instr(database_instance.last_io_error, 'Could not find first log file') > 0
+			)
+		`
+	return readInstancesByCondition(condition, sqlutils.Args(clusterName), "")
+}
+
 // SearchInstances reads all instances qualifying for some searchString
 func SearchInstances(searchString string) ([](*Instance), error) {
 	searchString = strings.TrimSpace(searchString)
@@ -2033,6 +2642,46 @@ func ReadClusterInfo(clusterName string) (*ClusterInfo, error) {
 	return &(clusters[0]), nil
 }
 
+// distinctNonEmptySorted returns the sorted, distinct, non empty tokens of given comma delimited listing
+func distinctNonEmptySorted(listing string) []string {
+	result := []string{}
+	seen := make(map[string]bool)
+	for _, token := range strings.Split(listing, ",") {
+		if token == "" || seen[token] {
+			continue
+		}
+		seen[token] = true
+		result = append(result, token)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// readClustersDistinctValues reads the distinct, non empty values of given database_instance column per cluster,
+// for all clusters or for given cluster, sorted. Values are read as rows of their own, such that listings are never
+// truncated, as GROUP_CONCAT may truncate them.
+func readClustersDistinctValues(clusterName string, column string) (map[string][]string, error) {
+	values := make(map[string][]string)
+	query := fmt.Sprintf(`
+		select distinct
+			cluster_name,
+			%s as value
+		from
+			database_instance
+		where
+			%s != ''
+			and ? in ('', cluster_name)
+		order by
+			cluster_name, value
+		`, column, column)
+	err := db.QueryOrchestrator(query, sqlutils.Args(clusterName), func(m sqlutils.RowMap) error {
+		name := m.GetString("cluster_name")
+		values[name] = append(values[name], m.GetString("value"))
+		return nil
+	})
+	return values, log.Errore(err)
+}
+
 // ReadClustersInfo reads names of all known clusters and some aggregated info
 func ReadClustersInfo(clusterName string) ([]ClusterInfo, error) {
 	clusters := []ClusterInfo{}
@@ -2043,12 +2692,21 @@ func ReadClustersInfo(clusterName string) ([]ClusterInfo, error) {
 		whereClause = `where cluster_name = ?`
 		args = append(args, clusterName)
 	}
+	clustersDataCenters, err := readClustersDistinctValues(clusterName, "data_center")
+	if err != nil {
+		return clusters, err
+	}
+	clustersPhysicalEnvironments, err := readClustersDistinctValues(clusterName, "physical_environment")
+	if err != nil {
+		return clusters, err
+	}
 	query := fmt.Sprintf(`
 		select
 			cluster_name,
 			count(*) as count_instances,
 			ifnull(min(alias), cluster_name) as alias,
-			ifnull(min(domain_name), '') as domain_name
+			ifnull(min(domain_name), '') as domain_name,
+			ifnull(group_concat(case when is_co_master then concat(hostname, ':', port) end), '') as co_masters
 		from
 			database_instance
 			left join cluster_alias using (cluster_name)
@@ -2057,13 +2715,16 @@ func ReadClustersInfo(clusterName string) ([]ClusterInfo, error) {
 		group by
 			cluster_name`, whereClause)
 
-	err := db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
 		clusterInfo := ClusterInfo{
 			ClusterName:    m.GetString("cluster_name"),
 			CountInstances: m.GetUint("count_instances"),
 			ClusterAlias:   m.GetString("alias"),
 			ClusterDomain:  m.GetString("domain_name"),
 		}
+		clusterInfo.DataCenters = append([]string{}, clustersDataCenters[clusterInfo.ClusterName]...)
+		clusterInfo.PhysicalEnvironments = append([]string{}, clustersPhysicalEnvironments[clusterInfo.ClusterName]...)
+		clusterInfo.CoMasters = distinctNonEmptySorted(m.GetString("co_masters"))
 		clusterInfo.ApplyClusterAlias()
 		clusterInfo.ReadRecoveryInfo()
 
@@ -2198,29 +2859,40 @@ func ReadAllMinimalInstances() ([]MinimalInstance, error) {
 // resulted in an actual check! This can happen when TCP/IP connections are hung, in which case the "check"
 // never returns. In such case we multiply interval by a factor, so as not to open too many connections on
 // the instance.
-func ReadOutdatedInstanceKeys() ([]InstanceKey, error) {
-	res := []InstanceKey{}
+// Keys of instances whose last check was invalid are returned in failedCheckKeys rather than in instanceKeys,
+// so that the caller may choose to re-check these first.
+// Instances whose health checks are ignored are never polled, and so never returned.
+func ReadOutdatedInstanceKeys() (instanceKeys []InstanceKey, failedCheckKeys []InstanceKey, err error) {
+	instanceKeys = []InstanceKey{}
+	failedCheckKeys = []InstanceKey{}
 	query := `
 		select
-			hostname, port
+			hostname, port,
+			ifnull(last_checked <= last_seen, 0) as is_last_check_valid
 		from
 			database_instance
+			left join database_instance_ignore_health_checks using (hostname, port)
 		where
 			case
 				when last_attempted_check <= last_checked
 				then last_checked < now() - interval ? second
 				else last_checked < now() - interval ? second
 			end
+			and database_instance_ignore_health_checks.hostname is null
 			`
 	args := sqlutils.Args(config.Config.InstancePollSeconds, 2*config.Config.InstancePollSeconds)
 
-	err := db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
 		instanceKey, merr := NewResolveInstanceKey(m.GetString("hostname"), m.GetInt("port"))
 		if merr != nil {
 			log.Errore(merr)
 		} else if !InstanceIsForgotten(instanceKey) {
 			// only if not in "forget" cache
-			res = append(res, *instanceKey)
+			if m.GetBool("is_last_check_valid") {
+				instanceKeys = append(instanceKeys, *instanceKey)
+			} else {
+				failedCheckKeys = append(failedCheckKeys, *instanceKey)
+			}
 		}
 		// We don;t return an error because we want to keep filling the outdated instances list.
 		return nil
@@ -2229,7 +2901,7 @@ func ReadOutdatedInstanceKeys() ([]InstanceKey, error) {
 	if err != nil {
 		log.Errore(err)
 	}
-	return res, err
+	return instanceKeys, failedCheckKeys, err
 
 }
 
@@ -2300,6 +2972,7 @@ func mkInsertOdkuForInstances(instances []*Instance, instanceWasActuallyFound bo
 		"version_comment",
 		"binlog_server",
 		"read_only",
+		"super_read_only",
 		"binlog_format",
 		"binlog_row_image",
 		"log_bin",
@@ -2313,6 +2986,7 @@ func mkInsertOdkuForInstances(instances []*Instance, instanceWasActuallyFound bo
 		"replication_sql_thread_state",
 		"replication_io_thread_state",
 		"has_replication_filters",
+		"replication_filters",
 		"supports_oracle_gtid",
 		"oracle_gtid",
 		"master_uuid",
@@ -2322,6 +2996,8 @@ func mkInsertOdkuForInstances(instances []*Instance, instanceWasActuallyFound bo
 		"gtid_purged",
 		"gtid_errant",
 		"mariadb_gtid",
+		"mariadb_gtid_current_pos",
+		"mariadb_gtid_slave_pos",
 		"pseudo_gtid",
 		"master_log_file",
 		"read_master_log_pos",
@@ -2334,6 +3010,7 @@ func mkInsertOdkuForInstances(instances []*Instance, instanceWasActuallyFound bo
 		"seconds_behind_master",
 		"slave_lag_seconds",
 		"sql_delay",
+		"sql_remaining_delay",
 		"num_slave_hosts",
 		"slave_hosts",
 		"cluster_name",
@@ -2349,8 +3026,37 @@ func mkInsertOdkuForInstances(instances []*Instance, instanceWasActuallyFound bo
 		"semi_sync_enforced",
 		"semi_sync_master_enabled",
 		"semi_sync_replica_enabled",
+		"semi_sync_master_status",
+		"semi_sync_replica_status",
+		"semi_sync_master_clients",
+		"semi_sync_master_wait_for_replica_count",
+		"replication_group_name",
+		"replication_group_is_single_primary_mode",
+		"replication_group_member_state",
+		"replication_group_member_role",
+		"replication_group_members",
+		"replication_group_primary_host",
+		"replication_group_primary_port",
+		"oldest_binary_log_file",
+		"raw_master_host",
+		"raw_master_port",
+		"replication_channels",
+		"slave_net_timeout",
+		"heartbeat_period",
+		"received_heartbeats",
+		"seconds_since_last_heartbeat",
+		"flavor_name",
+		"last_sql_errno",
+		"event_scheduler_enabled",
+		"count_enabled_events",
+		"sql_mode",
+		"master_writes_blocked",
+		"slave_parallel_workers",
+		"has_replication_gaps",
+		"replication_ssl_cipher",
 		"instance_alias",
 		"last_discovery_latency",
+		"last_check_queries_timed_out",
 	}
 
 	var values []string = make([]string, len(columns), len(columns))
@@ -2380,6 +3086,7 @@ func mkInsertOdkuForInstances(instances []*Instance, instanceWasActuallyFound bo
 		args = append(args, instance.VersionComment)
 		args = append(args, instance.IsBinlogServer())
 		args = append(args, instance.ReadOnly)
+		args = append(args, instance.SuperReadOnly)
 		args = append(args, instance.Binlog_format)
 		args = append(args, instance.BinlogRowImage)
 		args = append(args, instance.LogBinEnabled)
@@ -2393,6 +3100,7 @@ func mkInsertOdkuForInstances(instances []*Instance, instanceWasActuallyFound bo
 		args = append(args, instance.ReplicationSQLThreadState)
 		args = append(args, instance.ReplicationIOThreadState)
 		args = append(args, instance.HasReplicationFilters)
+		args = append(args, instance.ReplicationFilters.ToJSONString())
 		args = append(args, instance.SupportsOracleGTID)
 		args = append(args, instance.UsingOracleGTID)
 		args = append(args, instance.MasterUUID)
@@ -2402,6 +3110,8 @@ func mkInsertOdkuForInstances(instances []*Instance, instanceWasActuallyFound bo
 		args = append(args, instance.GtidPurged)
 		args = append(args, instance.GtidErrant)
 		args = append(args, instance.UsingMariaDBGTID)
+		args = append(args, instance.MariaDBGtidCurrentPos)
+		args = append(args, instance.MariaDBGtidSlavePos)
 		args = append(args, instance.UsingPseudoGTID)
 		args = append(args, instance.ReadBinlogCoordinates.LogFile)
 		args = append(args, instance.ReadBinlogCoordinates.LogPos)
@@ -2414,6 +3124,7 @@ func mkInsertOdkuForInstances(instances []*Instance, instanceWasActuallyFound bo
 		args = append(args, instance.SecondsBehindMaster)
 		args = append(args, instance.SlaveLagSeconds)
 		args = append(args, instance.SQLDelay)
+		args = append(args, instance.SQLRemainingDelay)
 		args = append(args, len(instance.SlaveHosts))
 		args = append(args, instance.SlaveHosts.ToJSONString())
 		args = append(args, instance.ClusterName)
@@ -2429,8 +3140,37 @@ func mkInsertOdkuForInstances(instances []*Instance, instanceWasActuallyFound bo
 		args = append(args, instance.SemiSyncEnforced)
 		args = append(args, instance.SemiSyncMasterEnabled)
 		args = append(args, instance.SemiSyncReplicaEnabled)
+		args = append(args, instance.SemiSyncMasterStatus)
+		args = append(args, instance.SemiSyncReplicaStatus)
+		args = append(args, instance.SemiSyncMasterClients)
+		args = append(args, instance.SemiSyncMasterWaitForReplicaCount)
+		args = append(args, instance.ReplicationGroupName)
+		args = append(args, instance.ReplicationGroupIsSinglePrimary)
+		args = append(args, instance.ReplicationGroupMemberState)
+		args = append(args, instance.ReplicationGroupMemberRole)
+		args = append(args, instance.ReplicationGroupMembers.ToJSONString())
+		args = append(args, instance.ReplicationGroupPrimaryInstanceKey.Hostname)
+		args = append(args, instance.ReplicationGroupPrimaryInstanceKey.Port)
+		args = append(args, instance.OldestBinaryLogFile)
+		args = append(args, instance.RawMasterKey.Hostname)
+		args = append(args, instance.RawMasterKey.Port)
+		args = append(args, instance.ReplicationChannels.ToJSONString())
+		args = append(args, instance.SlaveNetTimeout)
+		args = append(args, instance.HeartbeatPeriodSeconds)
+		args = append(args, instance.ReceivedHeartbeats)
+		args = append(args, instance.SecondsSinceLastHeartbeat)
+		args = append(args, instance.FlavorName)
+		args = append(args, instance.LastSQLErrno)
+		args = append(args, instance.EventSchedulerEnabled)
+		args = append(args, instance.CountEnabledEvents)
+		args = append(args, instance.SQLMode)
+		args = append(args, instance.MasterWritesBlocked)
+		args = append(args, instance.SlaveParallelWorkers)
+		args = append(args, instance.HasReplicationGaps)
+		args = append(args, instance.ReplicationSSLCipher)
 		args = append(args, instance.InstanceAlias)
 		args = append(args, instance.LastDiscoveryLatency.Nanoseconds())
+		args = append(args, instance.LastCheckQueriesTimedOut)
 	}
 
 	sql, err := mkInsertOdku("database_instance", columns, values, len(instances), insertIgnore)
@@ -2617,41 +3357,137 @@ func ForgetInstance(instanceKey *InstanceKey) error {
 	if rows == 0 {
 		return log.Errorf("ForgetInstance(): instance %+v not found", *instanceKey)
 	}
+	if err := deleteInstanceTags(instanceKey); err != nil {
+		return err
+	}
 	AuditOperation("forget", instanceKey, "")
 	return nil
 }
 
-// ForgetInstance removes an instance entry from the orchestrator backed database.
-// It may be auto-rediscovered through topology or requested for discovery by multiple means.
-func ForgetCluster(clusterName string) error {
+// ForgetCluster removes all instance entries of given cluster from the orchestrator backed database.
+// Instances may be auto-rediscovered through topology or requested for discovery by multiple means.
+// Returns the keys of forgotten instances.
+func ForgetCluster(clusterName string) ([]InstanceKey, error) {
 	clusterInstances, err := ReadClusterInstances(clusterName)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	instanceKeys := NewInstanceKeyMap()
+	instanceKeys.AddInstances(clusterInstances)
+	if len(*instanceKeys) == 0 {
+		return nil, nil
+	}
+	if err := forgetInstances(instanceKeys); err != nil {
+		return nil, err
 	}
-	if len(clusterInstances) == 0 {
+	AuditOperation("forget-cluster", nil, fmt.Sprintf("cluster: %s; forgotten instances: %s", clusterName, instanceKeys.ToCommaDelimitedList()))
+	return instanceKeys.GetInstanceKeys(), nil
+}
+
+// ReadInstanceKeysByPattern returns keys of all instances whose hostname:port matches given regular expression
+func ReadInstanceKeysByPattern(pattern string) ([]InstanceKey, error) {
+	res := []InstanceKey{}
+	patternRegexp, err := regexp.Compile(pattern)
+	if err != nil {
+		return res, err
+	}
+	query := `
+		select
+			hostname, port
+		from
+			database_instance
+			`
+	err = db.QueryOrchestrator(query, sqlutils.Args(), func(m sqlutils.RowMap) error {
+		instanceKey := InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")}
+		if patternRegexp.MatchString(instanceKey.StringCode()) {
+			res = append(res, instanceKey)
+		}
 		return nil
+	})
+	return res, log.Errore(err)
+}
+
+// ForgetInstancesByPattern removes entries of all instances whose hostname:port matches given regular expression
+// from the orchestrator backed database. Returns the keys of forgotten instances.
+func ForgetInstancesByPattern(pattern string) ([]InstanceKey, error) {
+	matchingKeys, err := ReadInstanceKeysByPattern(pattern)
+	if err != nil {
+		return nil, err
 	}
-	for _, instance := range clusterInstances {
-		forgetInstanceKeys.Set(instance.Key.StringCode(), true, cache.DefaultExpiration)
-		AuditOperation("forget", &instance.Key, "")
+	if len(matchingKeys) == 0 {
+		return nil, nil
 	}
-	_, err = db.ExecOrchestrator(`
-			delete
-				from database_instance
-			where
-				cluster_name = ?`,
-		clusterName,
-	)
-	return err
+	instanceKeys := NewInstanceKeyMap()
+	instanceKeys.AddKeys(matchingKeys)
+	if err := forgetInstances(instanceKeys); err != nil {
+		return nil, err
+	}
+	AuditOperation("forget-by-pattern", nil, fmt.Sprintf("pattern: %s; forgotten instances: %s", pattern, instanceKeys.ToCommaDelimitedList()))
+	return instanceKeys.GetInstanceKeys(), nil
+}
+
+// CheckForgetSafetyThreshold returns an error when forgetting given number of instances at once
+// exceeds ForgetInstancesSafetyThreshold, unless forced.
+func CheckForgetSafetyThreshold(count int, force bool) error {
+	if force || config.Config.ForgetInstancesSafetyThreshold == 0 {
+		return nil
+	}
+	if count > int(config.Config.ForgetInstancesSafetyThreshold) {
+		return fmt.Errorf("Will not forget %d instances, which exceeds ForgetInstancesSafetyThreshold (%d). Use force to override", count, config.Config.ForgetInstancesSafetyThreshold)
+	}
+	return nil
+}
+
+// forgetInstances deletes given instances from the backend database in a single transaction, and purges them
+// from discovery related and hostname resolve caches
+func forgetInstances(instanceKeys *InstanceKeyMap) error {
+	dbh, err := db.OpenOrchestrator()
+	if err != nil {
+		return log.Errore(err)
+	}
+	tx, err := dbh.Begin()
+	if err != nil {
+		return log.Errore(err)
+	}
+	hostnames := make(map[string]bool)
+	for instanceKey := range *instanceKeys {
+		forgetInstanceKeys.Set(instanceKey.StringCode(), true, cache.DefaultExpiration)
+		instanceKeyInformativeClusterName.Delete(instanceKey.StringCode())
+		for _, query := range []string{
+			`delete from database_instance where hostname = ? and port = ?`,
+			`delete from database_instance_tags where hostname = ? and port = ?`,
+		} {
+			if _, err := tx.Exec(query, instanceKey.Hostname, instanceKey.Port); err != nil {
+				tx.Rollback()
+				return log.Errore(err)
+			}
+		}
+		hostnames[instanceKey.Hostname] = true
+	}
+	if err := tx.Commit(); err != nil {
+		return log.Errore(err)
+	}
+	forgetHostnameResolves(hostnames)
+	return nil
 }
 
 // ForgetLongUnseenInstances will remove entries of all instacnes that have long since been last seen.
+// Instances whose health checks are ignored are never seen again by design, and are kept.
 func ForgetLongUnseenInstances() error {
 	sqlResult, err := db.ExecOrchestrator(`
 			delete
 				from database_instance
 			where
-				last_seen < NOW() - interval ? hour`,
+				last_seen < NOW() - interval ? hour
+				and not exists (
+					select
+						1
+					from
+						database_instance_ignore_health_checks
+					where
+						database_instance_ignore_health_checks.hostname = database_instance.hostname
+						and database_instance_ignore_health_checks.port = database_instance.port
+				)`,
 		config.Config.UnseenInstanceForgetHours,
 	)
 	if err != nil {
@@ -2781,6 +3617,31 @@ func GetHeuristiclyRecentCoordinatesForInstance(instanceKey *InstanceKey) (selfC
 	return selfCoordinates, relayLogCoordinates, err
 }
 
+// GetCoordinatesForInstanceAsOf returns the most recent coordinates recorded for given instance at least
+// given number of seconds ago. Returns nil coordinates when no such record exists.
+func GetCoordinatesForInstanceAsOf(instanceKey *InstanceKey, secondsAgo uint) (selfCoordinates *BinlogCoordinates, relayLogCoordinates *BinlogCoordinates, err error) {
+	query := `
+		select
+			binary_log_file, binary_log_pos, relay_log_file, relay_log_pos
+		from
+			database_instance_coordinates_history
+		where
+			hostname = ?
+			and port = ?
+			and recorded_timestamp <= NOW() - INTERVAL ? SECOND
+		order by
+			recorded_timestamp desc
+			limit 1
+			`
+	err = db.QueryOrchestrator(query, sqlutils.Args(instanceKey.Hostname, instanceKey.Port, secondsAgo), func(m sqlutils.RowMap) error {
+		selfCoordinates = &BinlogCoordinates{LogFile: m.GetString("binary_log_file"), LogPos: m.GetInt64("binary_log_pos")}
+		relayLogCoordinates = &BinlogCoordinates{LogFile: m.GetString("relay_log_file"), LogPos: m.GetInt64("relay_log_pos"), Type: RelayLog}
+
+		return nil
+	})
+	return selfCoordinates, relayLogCoordinates, err
+}
+
 // GetPreviousKnownRelayLogCoordinatesForInstance returns known relay log coordinates, that are not the
 // exact current coordinates
 func GetPreviousKnownRelayLogCoordinatesForInstance(instance *Instance) (relayLogCoordinates *BinlogCoordinates, err error) {
@@ -2812,6 +3673,58 @@ func GetPreviousKnownRelayLogCoordinatesForInstance(instance *Instance) (relayLo
 	return relayLogCoordinates, err
 }
 
+// ReadSQLThreadLagEstimate estimates the lag of given replica's sql thread off its master's coordinates history:
+// the time between the master writing the replica's executed coordinates and it writing the replica's read
+// coordinates. Coordinates written past the master's last recorded snapshot are taken to be written at that
+// snapshot. The estimate is accurate up to the history's recording interval, and invalid when no history exists.
+func ReadSQLThreadLagEstimate(replica *Instance) (lagSeconds sql.NullInt64, err error) {
+	query := `
+		select
+			unix_timestamp(ifnull(read_at, last_recorded)) - unix_timestamp(ifnull(exec_at, last_recorded)) as lag_seconds
+		from (
+			select
+				(
+					select min(recorded_timestamp) from database_instance_coordinates_history
+					where hostname = ? and port = ? and (binary_log_file, binary_log_pos) >= (?, ?)
+				) as exec_at,
+				(
+					select min(recorded_timestamp) from database_instance_coordinates_history
+					where hostname = ? and port = ? and (binary_log_file, binary_log_pos) >= (?, ?)
+				) as read_at,
+				(
+					select max(recorded_timestamp) from database_instance_coordinates_history
+					where hostname = ? and port = ?
+				) as last_recorded
+		) as history_timestamps
+		`
+	args := sqlutils.Args(
+		replica.MasterKey.Hostname, replica.MasterKey.Port, replica.ExecBinlogCoordinates.LogFile, replica.ExecBinlogCoordinates.LogPos,
+		replica.MasterKey.Hostname, replica.MasterKey.Port, replica.ReadBinlogCoordinates.LogFile, replica.ReadBinlogCoordinates.LogPos,
+		replica.MasterKey.Hostname, replica.MasterKey.Port,
+	)
+	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		lagSeconds = m.GetNullInt64("lag_seconds")
+		return nil
+	})
+	return lagSeconds, log.Errore(err)
+}
+
+// PopulateSQLThreadLagEstimates sets SQLThreadLagEstimate on given replicas whose sql thread lag is otherwise
+// unknown, i.e. Seconds_Behind_Master is NULL while relay logs are not fully applied
+func PopulateSQLThreadLagEstimates(replicas [](*Instance)) error {
+	for _, replica := range replicas {
+		if replica == nil || replica.SecondsBehindMaster.Valid || replica.SQLThreadUpToDate() {
+			continue
+		}
+		lagSeconds, err := ReadSQLThreadLagEstimate(replica)
+		if err != nil {
+			return err
+		}
+		replica.SQLThreadLagEstimate = lagSeconds
+	}
+	return nil
+}
+
 // ResetInstanceRelaylogCoordinatesHistory forgets about the history of an instance. This action is desirable
 // when relay logs become obsolete or irrelevant. Such is the case on `CHANGE MASTER TO`: servers gets compeltely
 // new relay logs.
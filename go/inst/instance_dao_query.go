@@ -0,0 +1,133 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// discoveryQueryTimeout returns the deadline for a single query issued while reading a topology instance.
+// Unless explicitly configured, it is derived from InstancePollSeconds: a query which takes longer than
+// a poll interval is of no use to the next poll anyhow.
+func discoveryQueryTimeout() time.Duration {
+	if config.Config.DiscoveryQueryTimeoutSeconds > 0 {
+		return time.Duration(config.Config.DiscoveryQueryTimeoutSeconds) * time.Second
+	}
+	if config.Config.InstancePollSeconds > 0 {
+		return time.Duration(config.Config.InstancePollSeconds) * time.Second
+	}
+	return time.Second
+}
+
+// discoveryDB runs discovery queries on a topology instance, each bound by its own deadline, such that
+// a single wedged query cannot hold a discovery worker for the full network timeout. Queries are further
+// bound by the context of the discovery as a whole, and fail right away once it is done.
+// It is safe for concurrent use.
+type discoveryDB struct {
+	db       *sql.DB
+	ctx      context.Context
+	timeout  time.Duration
+	timedOut int64
+}
+
+func newDiscoveryDB(ctx context.Context, db *sql.DB, timeout time.Duration) *discoveryDB {
+	return &discoveryDB{db: db, ctx: ctx, timeout: timeout}
+}
+
+// discoveryRow is the deadline-bound equivalent of *sql.Row
+type discoveryRow struct {
+	row    *sql.Row
+	ctx    context.Context
+	cancel context.CancelFunc
+	parent *discoveryDB
+}
+
+// Scan scans the row and releases the query's context
+func (this *discoveryRow) Scan(dest ...interface{}) error {
+	defer this.cancel()
+	err := this.row.Scan(dest...)
+	this.parent.checkDeadline(this.ctx)
+	return err
+}
+
+// checkDeadline records whether given query context expired
+func (this *discoveryDB) checkDeadline(ctx context.Context) {
+	if ctx.Err() == context.DeadlineExceeded {
+		atomic.StoreInt64(&this.timedOut, 1)
+	}
+}
+
+// TimedOut returns true when any of the queries issued so far exceeded its deadline
+func (this *discoveryDB) TimedOut() bool {
+	return atomic.LoadInt64(&this.timedOut) != 0
+}
+
+// QueryRow runs a single row query bound by the query deadline
+func (this *discoveryDB) QueryRow(query string, args ...interface{}) *discoveryRow {
+	ctx, cancel := context.WithTimeout(this.ctx, this.timeout)
+	return &discoveryRow{
+		row:    this.db.QueryRowContext(ctx, query, args...),
+		ctx:    ctx,
+		cancel: cancel,
+		parent: this,
+	}
+}
+
+// QueryRowsMap is the deadline-bound equivalent of sqlutils.QueryRowsMap
+func (this *discoveryDB) QueryRowsMap(query string, onRow func(sqlutils.RowMap) error, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(this.ctx, this.timeout)
+	defer cancel()
+	defer this.checkDeadline(ctx)
+
+	rows, err := this.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return log.Errore(err)
+	}
+	defer rows.Close()
+	if err := sqlutils.ScanRowsToMaps(rows, onRow); err != nil {
+		return err
+	}
+	return rows.Err()
+}
+
+// QueryResultData is the deadline-bound equivalent of sqlutils.QueryResultData
+func (this *discoveryDB) QueryResultData(query string, args ...interface{}) (resultData sqlutils.ResultData, err error) {
+	ctx, cancel := context.WithTimeout(this.ctx, this.timeout)
+	defer cancel()
+	defer this.checkDeadline(ctx)
+
+	rows, err := this.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return resultData, log.Errore(err)
+	}
+	defer rows.Close()
+	err = sqlutils.ScanRowsToArrays(rows, func(rowData []sqlutils.CellData) error {
+		resultData = append(resultData, rowData)
+		return nil
+	})
+	if err != nil {
+		return resultData, err
+	}
+	return resultData, rows.Err()
+}
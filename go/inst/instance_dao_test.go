@@ -57,18 +57,18 @@ func TestMkInsertOdkuSingle(t *testing.T) {
 	// one instance
 	s1 := `INSERT ignore INTO database_instance
                 (hostname, port, last_checked, last_attempted_check, last_check_partial_success, uptime, server_id, server_uuid,
-									version, major_version, version_comment, binlog_server, read_only, binlog_format,
+									version, major_version, version_comment, binlog_server, read_only, super_read_only, binlog_format,
 									binlog_row_image, log_bin, log_slave_updates, binary_log_file, binary_log_pos, master_host, master_port,
-									slave_sql_running, slave_io_running, replication_sql_thread_state, replication_io_thread_state, has_replication_filters, supports_oracle_gtid, oracle_gtid, master_uuid, ancestry_uuid, executed_gtid_set, gtid_mode, gtid_purged, gtid_errant, mariadb_gtid, pseudo_gtid,
-									master_log_file, read_master_log_pos, relay_master_log_file, exec_master_log_pos, relay_log_file, relay_log_pos, last_sql_error, last_io_error, seconds_behind_master, slave_lag_seconds, sql_delay, num_slave_hosts, slave_hosts, cluster_name, suggested_cluster_alias, data_center, region, physical_environment, replication_depth, is_co_master, replication_credentials_available, has_replication_credentials, allow_tls, semi_sync_enforced, semi_sync_master_enabled, semi_sync_replica_enabled, instance_alias, last_discovery_latency, last_seen)
+									slave_sql_running, slave_io_running, replication_sql_thread_state, replication_io_thread_state, has_replication_filters, replication_filters, supports_oracle_gtid, oracle_gtid, master_uuid, ancestry_uuid, executed_gtid_set, gtid_mode, gtid_purged, gtid_errant, mariadb_gtid, mariadb_gtid_current_pos, mariadb_gtid_slave_pos, pseudo_gtid,
+									master_log_file, read_master_log_pos, relay_master_log_file, exec_master_log_pos, relay_log_file, relay_log_pos, last_sql_error, last_io_error, seconds_behind_master, slave_lag_seconds, sql_delay, sql_remaining_delay, num_slave_hosts, slave_hosts, cluster_name, suggested_cluster_alias, data_center, region, physical_environment, replication_depth, is_co_master, replication_credentials_available, has_replication_credentials, allow_tls, semi_sync_enforced, semi_sync_master_enabled, semi_sync_replica_enabled, semi_sync_master_status, semi_sync_replica_status, semi_sync_master_clients, semi_sync_master_wait_for_replica_count, replication_group_name, replication_group_is_single_primary_mode, replication_group_member_state, replication_group_member_role, replication_group_members, replication_group_primary_host, replication_group_primary_port, oldest_binary_log_file, raw_master_host, raw_master_port, replication_channels, slave_net_timeout, heartbeat_period, received_heartbeats, seconds_since_last_heartbeat, flavor_name, last_sql_errno, event_scheduler_enabled, count_enabled_events, sql_mode, master_writes_blocked, slave_parallel_workers, has_replication_gaps, replication_ssl_cipher, instance_alias, last_discovery_latency, last_check_queries_timed_out, last_seen)
         VALUES
-                (?, ?, NOW(), NOW(), 1, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW())
+                (?, ?, NOW(), NOW(), 1, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW())
         ON DUPLICATE KEY UPDATE
-                hostname=VALUES(hostname), port=VALUES(port), last_checked=VALUES(last_checked), last_attempted_check=VALUES(last_attempted_check), last_check_partial_success=VALUES(last_check_partial_success), uptime=VALUES(uptime), server_id=VALUES(server_id), server_uuid=VALUES(server_uuid), version=VALUES(version), major_version=VALUES(major_version), version_comment=VALUES(version_comment), binlog_server=VALUES(binlog_server), read_only=VALUES(read_only), binlog_format=VALUES(binlog_format), binlog_row_image=VALUES(binlog_row_image), log_bin=VALUES(log_bin), log_slave_updates=VALUES(log_slave_updates), binary_log_file=VALUES(binary_log_file), binary_log_pos=VALUES(binary_log_pos), master_host=VALUES(master_host), master_port=VALUES(master_port), slave_sql_running=VALUES(slave_sql_running), slave_io_running=VALUES(slave_io_running), replication_sql_thread_state=VALUES(replication_sql_thread_state), replication_io_thread_state=VALUES(replication_io_thread_state), has_replication_filters=VALUES(has_replication_filters), supports_oracle_gtid=VALUES(supports_oracle_gtid), oracle_gtid=VALUES(oracle_gtid), master_uuid=VALUES(master_uuid), ancestry_uuid=VALUES(ancestry_uuid), executed_gtid_set=VALUES(executed_gtid_set), gtid_mode=VALUES(gtid_mode), gtid_purged=VALUES(gtid_purged), gtid_errant=VALUES(gtid_errant), mariadb_gtid=VALUES(mariadb_gtid), pseudo_gtid=VALUES(pseudo_gtid), master_log_file=VALUES(master_log_file), read_master_log_pos=VALUES(read_master_log_pos), relay_master_log_file=VALUES(relay_master_log_file), exec_master_log_pos=VALUES(exec_master_log_pos), relay_log_file=VALUES(relay_log_file), relay_log_pos=VALUES(relay_log_pos), last_sql_error=VALUES(last_sql_error), last_io_error=VALUES(last_io_error), seconds_behind_master=VALUES(seconds_behind_master), slave_lag_seconds=VALUES(slave_lag_seconds), sql_delay=VALUES(sql_delay), num_slave_hosts=VALUES(num_slave_hosts), slave_hosts=VALUES(slave_hosts), cluster_name=VALUES(cluster_name), suggested_cluster_alias=VALUES(suggested_cluster_alias), data_center=VALUES(data_center), region=VALUES(region), physical_environment=VALUES(physical_environment), replication_depth=VALUES(replication_depth), is_co_master=VALUES(is_co_master), replication_credentials_available=VALUES(replication_credentials_available), has_replication_credentials=VALUES(has_replication_credentials), allow_tls=VALUES(allow_tls), semi_sync_enforced=VALUES(semi_sync_enforced), semi_sync_master_enabled=VALUES(semi_sync_master_enabled), semi_sync_replica_enabled=VALUES(semi_sync_replica_enabled), instance_alias=VALUES(instance_alias), last_discovery_latency=VALUES(last_discovery_latency), last_seen=VALUES(last_seen)
+                hostname=VALUES(hostname), port=VALUES(port), last_checked=VALUES(last_checked), last_attempted_check=VALUES(last_attempted_check), last_check_partial_success=VALUES(last_check_partial_success), uptime=VALUES(uptime), server_id=VALUES(server_id), server_uuid=VALUES(server_uuid), version=VALUES(version), major_version=VALUES(major_version), version_comment=VALUES(version_comment), binlog_server=VALUES(binlog_server), read_only=VALUES(read_only), super_read_only=VALUES(super_read_only), binlog_format=VALUES(binlog_format), binlog_row_image=VALUES(binlog_row_image), log_bin=VALUES(log_bin), log_slave_updates=VALUES(log_slave_updates), binary_log_file=VALUES(binary_log_file), binary_log_pos=VALUES(binary_log_pos), master_host=VALUES(master_host), master_port=VALUES(master_port), slave_sql_running=VALUES(slave_sql_running), slave_io_running=VALUES(slave_io_running), replication_sql_thread_state=VALUES(replication_sql_thread_state), replication_io_thread_state=VALUES(replication_io_thread_state), has_replication_filters=VALUES(has_replication_filters), replication_filters=VALUES(replication_filters), supports_oracle_gtid=VALUES(supports_oracle_gtid), oracle_gtid=VALUES(oracle_gtid), master_uuid=VALUES(master_uuid), ancestry_uuid=VALUES(ancestry_uuid), executed_gtid_set=VALUES(executed_gtid_set), gtid_mode=VALUES(gtid_mode), gtid_purged=VALUES(gtid_purged), gtid_errant=VALUES(gtid_errant), mariadb_gtid=VALUES(mariadb_gtid), mariadb_gtid_current_pos=VALUES(mariadb_gtid_current_pos), mariadb_gtid_slave_pos=VALUES(mariadb_gtid_slave_pos), pseudo_gtid=VALUES(pseudo_gtid), master_log_file=VALUES(master_log_file), read_master_log_pos=VALUES(read_master_log_pos), relay_master_log_file=VALUES(relay_master_log_file), exec_master_log_pos=VALUES(exec_master_log_pos), relay_log_file=VALUES(relay_log_file), relay_log_pos=VALUES(relay_log_pos), last_sql_error=VALUES(last_sql_error), last_io_error=VALUES(last_io_error), seconds_behind_master=VALUES(seconds_behind_master), slave_lag_seconds=VALUES(slave_lag_seconds), sql_delay=VALUES(sql_delay), sql_remaining_delay=VALUES(sql_remaining_delay), num_slave_hosts=VALUES(num_slave_hosts), slave_hosts=VALUES(slave_hosts), cluster_name=VALUES(cluster_name), suggested_cluster_alias=VALUES(suggested_cluster_alias), data_center=VALUES(data_center), region=VALUES(region), physical_environment=VALUES(physical_environment), replication_depth=VALUES(replication_depth), is_co_master=VALUES(is_co_master), replication_credentials_available=VALUES(replication_credentials_available), has_replication_credentials=VALUES(has_replication_credentials), allow_tls=VALUES(allow_tls), semi_sync_enforced=VALUES(semi_sync_enforced), semi_sync_master_enabled=VALUES(semi_sync_master_enabled), semi_sync_replica_enabled=VALUES(semi_sync_replica_enabled), semi_sync_master_status=VALUES(semi_sync_master_status), semi_sync_replica_status=VALUES(semi_sync_replica_status), semi_sync_master_clients=VALUES(semi_sync_master_clients), semi_sync_master_wait_for_replica_count=VALUES(semi_sync_master_wait_for_replica_count), replication_group_name=VALUES(replication_group_name), replication_group_is_single_primary_mode=VALUES(replication_group_is_single_primary_mode), replication_group_member_state=VALUES(replication_group_member_state), replication_group_member_role=VALUES(replication_group_member_role), replication_group_members=VALUES(replication_group_members), replication_group_primary_host=VALUES(replication_group_primary_host), replication_group_primary_port=VALUES(replication_group_primary_port), oldest_binary_log_file=VALUES(oldest_binary_log_file), raw_master_host=VALUES(raw_master_host), raw_master_port=VALUES(raw_master_port), replication_channels=VALUES(replication_channels), slave_net_timeout=VALUES(slave_net_timeout), heartbeat_period=VALUES(heartbeat_period), received_heartbeats=VALUES(received_heartbeats), seconds_since_last_heartbeat=VALUES(seconds_since_last_heartbeat), flavor_name=VALUES(flavor_name), last_sql_errno=VALUES(last_sql_errno), event_scheduler_enabled=VALUES(event_scheduler_enabled), count_enabled_events=VALUES(count_enabled_events), sql_mode=VALUES(sql_mode), master_writes_blocked=VALUES(master_writes_blocked), slave_parallel_workers=VALUES(slave_parallel_workers), has_replication_gaps=VALUES(has_replication_gaps), replication_ssl_cipher=VALUES(replication_ssl_cipher), instance_alias=VALUES(instance_alias), last_discovery_latency=VALUES(last_discovery_latency), last_check_queries_timed_out=VALUES(last_check_queries_timed_out), last_seen=VALUES(last_seen)
         `
-	a1 := `i710, 3306, 0, 710, , 5.6.7, 5.6, MySQL, false, false, STATEMENT,
+	a1 := `i710, 3306, 0, 710, , 5.6.7, 5.6, MySQL, false, false, false, STATEMENT,
 	FULL, false, false, , 0, , 0,
-	false, false, 0, 0, false, false, false, , , , , , , false, false, , 0, mysql.000007, 10, , 0, , , {0 false}, {0 false}, 0, 0, [], , , , , , 0, false, false, false, false, false, false, false, , 0, `
+	false, false, 0, 0, false, , false, false, , , , , , , false, , , false, , 0, mysql.000007, 10, , 0, , , {0 false}, {0 false}, 0, {0 false}, 0, [], , , , , , 0, false, false, false, false, false, false, false, false, false, 0, 0, , false, , , [], , 0, , , 0, , 0, 0, 0, {0 false}, , 0, false, 0, , false, 0, false, , , 0, false, `
 
 	sql1, args1, err := mkInsertOdkuForInstances(instances[:1], false, true)
 	test.S(t).ExpectNil(err)
@@ -81,19 +81,19 @@ func TestMkInsertOdkuThree(t *testing.T) {
 
 	// three instances
 	s3 := `INSERT  INTO database_instance
-                (hostname, port, last_checked, last_attempted_check, last_check_partial_success, uptime, server_id, server_uuid, version, major_version, version_comment, binlog_server, read_only, binlog_format, binlog_row_image, log_bin, log_slave_updates, binary_log_file, binary_log_pos, master_host, master_port, slave_sql_running, slave_io_running, replication_sql_thread_state, replication_io_thread_state, has_replication_filters, supports_oracle_gtid, oracle_gtid, master_uuid, ancestry_uuid, executed_gtid_set, gtid_mode, gtid_purged, gtid_errant, mariadb_gtid, pseudo_gtid, master_log_file, read_master_log_pos, relay_master_log_file, exec_master_log_pos, relay_log_file, relay_log_pos, last_sql_error, last_io_error, seconds_behind_master, slave_lag_seconds, sql_delay, num_slave_hosts, slave_hosts, cluster_name, suggested_cluster_alias, data_center, region, physical_environment, replication_depth, is_co_master, replication_credentials_available, has_replication_credentials, allow_tls, semi_sync_enforced, semi_sync_master_enabled, semi_sync_replica_enabled, instance_alias, last_discovery_latency, last_seen)
+                (hostname, port, last_checked, last_attempted_check, last_check_partial_success, uptime, server_id, server_uuid, version, major_version, version_comment, binlog_server, read_only, super_read_only, binlog_format, binlog_row_image, log_bin, log_slave_updates, binary_log_file, binary_log_pos, master_host, master_port, slave_sql_running, slave_io_running, replication_sql_thread_state, replication_io_thread_state, has_replication_filters, replication_filters, supports_oracle_gtid, oracle_gtid, master_uuid, ancestry_uuid, executed_gtid_set, gtid_mode, gtid_purged, gtid_errant, mariadb_gtid, mariadb_gtid_current_pos, mariadb_gtid_slave_pos, pseudo_gtid, master_log_file, read_master_log_pos, relay_master_log_file, exec_master_log_pos, relay_log_file, relay_log_pos, last_sql_error, last_io_error, seconds_behind_master, slave_lag_seconds, sql_delay, sql_remaining_delay, num_slave_hosts, slave_hosts, cluster_name, suggested_cluster_alias, data_center, region, physical_environment, replication_depth, is_co_master, replication_credentials_available, has_replication_credentials, allow_tls, semi_sync_enforced, semi_sync_master_enabled, semi_sync_replica_enabled, semi_sync_master_status, semi_sync_replica_status, semi_sync_master_clients, semi_sync_master_wait_for_replica_count, replication_group_name, replication_group_is_single_primary_mode, replication_group_member_state, replication_group_member_role, replication_group_members, replication_group_primary_host, replication_group_primary_port, oldest_binary_log_file, raw_master_host, raw_master_port, replication_channels, slave_net_timeout, heartbeat_period, received_heartbeats, seconds_since_last_heartbeat, flavor_name, last_sql_errno, event_scheduler_enabled, count_enabled_events, sql_mode, master_writes_blocked, slave_parallel_workers, has_replication_gaps, replication_ssl_cipher, instance_alias, last_discovery_latency, last_check_queries_timed_out, last_seen)
         VALUES
-                (?, ?, NOW(), NOW(), 1, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW()),
-                (?, ?, NOW(), NOW(), 1, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW()),
-                (?, ?, NOW(), NOW(), 1, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW())
+                (?, ?, NOW(), NOW(), 1, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW()),
+                (?, ?, NOW(), NOW(), 1, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW()),
+                (?, ?, NOW(), NOW(), 1, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW())
         ON DUPLICATE KEY UPDATE
-                hostname=VALUES(hostname), port=VALUES(port), last_checked=VALUES(last_checked), last_attempted_check=VALUES(last_attempted_check), last_check_partial_success=VALUES(last_check_partial_success), uptime=VALUES(uptime), server_id=VALUES(server_id), server_uuid=VALUES(server_uuid), version=VALUES(version), major_version=VALUES(major_version), version_comment=VALUES(version_comment), binlog_server=VALUES(binlog_server), read_only=VALUES(read_only), binlog_format=VALUES(binlog_format), binlog_row_image=VALUES(binlog_row_image), log_bin=VALUES(log_bin), log_slave_updates=VALUES(log_slave_updates), binary_log_file=VALUES(binary_log_file), binary_log_pos=VALUES(binary_log_pos), master_host=VALUES(master_host), master_port=VALUES(master_port), slave_sql_running=VALUES(slave_sql_running), slave_io_running=VALUES(slave_io_running), replication_sql_thread_state=VALUES(replication_sql_thread_state), replication_io_thread_state=VALUES(replication_io_thread_state), has_replication_filters=VALUES(has_replication_filters), supports_oracle_gtid=VALUES(supports_oracle_gtid), oracle_gtid=VALUES(oracle_gtid), master_uuid=VALUES(master_uuid), ancestry_uuid=VALUES(ancestry_uuid), executed_gtid_set=VALUES(executed_gtid_set), gtid_mode=VALUES(gtid_mode), gtid_purged=VALUES(gtid_purged), gtid_errant=VALUES(gtid_errant), mariadb_gtid=VALUES(mariadb_gtid), pseudo_gtid=VALUES(pseudo_gtid), master_log_file=VALUES(master_log_file), read_master_log_pos=VALUES(read_master_log_pos), relay_master_log_file=VALUES(relay_master_log_file), exec_master_log_pos=VALUES(exec_master_log_pos), relay_log_file=VALUES(relay_log_file), relay_log_pos=VALUES(relay_log_pos), last_sql_error=VALUES(last_sql_error), last_io_error=VALUES(last_io_error), seconds_behind_master=VALUES(seconds_behind_master), slave_lag_seconds=VALUES(slave_lag_seconds), sql_delay=VALUES(sql_delay), num_slave_hosts=VALUES(num_slave_hosts), slave_hosts=VALUES(slave_hosts), cluster_name=VALUES(cluster_name), suggested_cluster_alias=VALUES(suggested_cluster_alias), data_center=VALUES(data_center), region=VALUES(region),
-								physical_environment=VALUES(physical_environment), replication_depth=VALUES(replication_depth), is_co_master=VALUES(is_co_master), replication_credentials_available=VALUES(replication_credentials_available), has_replication_credentials=VALUES(has_replication_credentials), allow_tls=VALUES(allow_tls), semi_sync_enforced=VALUES(semi_sync_enforced), semi_sync_master_enabled=VALUES(semi_sync_master_enabled), semi_sync_replica_enabled=VALUES(semi_sync_replica_enabled), instance_alias=VALUES(instance_alias), last_discovery_latency=VALUES(last_discovery_latency), last_seen=VALUES(last_seen)
+                hostname=VALUES(hostname), port=VALUES(port), last_checked=VALUES(last_checked), last_attempted_check=VALUES(last_attempted_check), last_check_partial_success=VALUES(last_check_partial_success), uptime=VALUES(uptime), server_id=VALUES(server_id), server_uuid=VALUES(server_uuid), version=VALUES(version), major_version=VALUES(major_version), version_comment=VALUES(version_comment), binlog_server=VALUES(binlog_server), read_only=VALUES(read_only), super_read_only=VALUES(super_read_only), binlog_format=VALUES(binlog_format), binlog_row_image=VALUES(binlog_row_image), log_bin=VALUES(log_bin), log_slave_updates=VALUES(log_slave_updates), binary_log_file=VALUES(binary_log_file), binary_log_pos=VALUES(binary_log_pos), master_host=VALUES(master_host), master_port=VALUES(master_port), slave_sql_running=VALUES(slave_sql_running), slave_io_running=VALUES(slave_io_running), replication_sql_thread_state=VALUES(replication_sql_thread_state), replication_io_thread_state=VALUES(replication_io_thread_state), has_replication_filters=VALUES(has_replication_filters), replication_filters=VALUES(replication_filters), supports_oracle_gtid=VALUES(supports_oracle_gtid), oracle_gtid=VALUES(oracle_gtid), master_uuid=VALUES(master_uuid), ancestry_uuid=VALUES(ancestry_uuid), executed_gtid_set=VALUES(executed_gtid_set), gtid_mode=VALUES(gtid_mode), gtid_purged=VALUES(gtid_purged), gtid_errant=VALUES(gtid_errant), mariadb_gtid=VALUES(mariadb_gtid), mariadb_gtid_current_pos=VALUES(mariadb_gtid_current_pos), mariadb_gtid_slave_pos=VALUES(mariadb_gtid_slave_pos), pseudo_gtid=VALUES(pseudo_gtid), master_log_file=VALUES(master_log_file), read_master_log_pos=VALUES(read_master_log_pos), relay_master_log_file=VALUES(relay_master_log_file), exec_master_log_pos=VALUES(exec_master_log_pos), relay_log_file=VALUES(relay_log_file), relay_log_pos=VALUES(relay_log_pos), last_sql_error=VALUES(last_sql_error), last_io_error=VALUES(last_io_error), seconds_behind_master=VALUES(seconds_behind_master), slave_lag_seconds=VALUES(slave_lag_seconds), sql_delay=VALUES(sql_delay), sql_remaining_delay=VALUES(sql_remaining_delay), num_slave_hosts=VALUES(num_slave_hosts), slave_hosts=VALUES(slave_hosts), cluster_name=VALUES(cluster_name), suggested_cluster_alias=VALUES(suggested_cluster_alias), data_center=VALUES(data_center), region=VALUES(region),
+								physical_environment=VALUES(physical_environment), replication_depth=VALUES(replication_depth), is_co_master=VALUES(is_co_master), replication_credentials_available=VALUES(replication_credentials_available), has_replication_credentials=VALUES(has_replication_credentials), allow_tls=VALUES(allow_tls), semi_sync_enforced=VALUES(semi_sync_enforced), semi_sync_master_enabled=VALUES(semi_sync_master_enabled), semi_sync_replica_enabled=VALUES(semi_sync_replica_enabled), semi_sync_master_status=VALUES(semi_sync_master_status), semi_sync_replica_status=VALUES(semi_sync_replica_status), semi_sync_master_clients=VALUES(semi_sync_master_clients), semi_sync_master_wait_for_replica_count=VALUES(semi_sync_master_wait_for_replica_count), replication_group_name=VALUES(replication_group_name), replication_group_is_single_primary_mode=VALUES(replication_group_is_single_primary_mode), replication_group_member_state=VALUES(replication_group_member_state), replication_group_member_role=VALUES(replication_group_member_role), replication_group_members=VALUES(replication_group_members), replication_group_primary_host=VALUES(replication_group_primary_host), replication_group_primary_port=VALUES(replication_group_primary_port), oldest_binary_log_file=VALUES(oldest_binary_log_file), raw_master_host=VALUES(raw_master_host), raw_master_port=VALUES(raw_master_port), replication_channels=VALUES(replication_channels), slave_net_timeout=VALUES(slave_net_timeout), heartbeat_period=VALUES(heartbeat_period), received_heartbeats=VALUES(received_heartbeats), seconds_since_last_heartbeat=VALUES(seconds_since_last_heartbeat), flavor_name=VALUES(flavor_name), last_sql_errno=VALUES(last_sql_errno), event_scheduler_enabled=VALUES(event_scheduler_enabled), count_enabled_events=VALUES(count_enabled_events), sql_mode=VALUES(sql_mode), master_writes_blocked=VALUES(master_writes_blocked), slave_parallel_workers=VALUES(slave_parallel_workers), has_replication_gaps=VALUES(has_replication_gaps), replication_ssl_cipher=VALUES(replication_ssl_cipher), instance_alias=VALUES(instance_alias), last_discovery_latency=VALUES(last_discovery_latency), last_check_queries_timed_out=VALUES(last_check_queries_timed_out), last_seen=VALUES(last_seen)
         `
 	a3 := `
-		i710, 3306, 0, 710, , 5.6.7, 5.6, MySQL, false, false, STATEMENT, FULL, false, false, , 0, , 0, false, false, 0, 0, false, false, false, , , , , , , false, false, , 0, mysql.000007, 10, , 0, , , {0 false}, {0 false}, 0, 0, [], , , , , , 0, false, false, false, false, false, false, false, , 0,
-		i720, 3306, 0, 720, , 5.6.7, 5.6, MySQL, false, false, STATEMENT, FULL, false, false, , 0, , 0, false, false, 0, 0, false, false, false, , , , , , , false, false, , 0, mysql.000007, 20, , 0, , , {0 false}, {0 false}, 0, 0, [], , , , , , 0, false, false, false, false, false, false, false, , 0,
-		i730, 3306, 0, 730, , 5.6.7, 5.6, MySQL, false, false, STATEMENT, FULL, false, false, , 0, , 0, false, false, 0, 0, false, false, false, , , , , , , false, false, , 0, mysql.000007, 30, , 0, , , {0 false}, {0 false}, 0, 0, [], , , , , , 0, false, false, false, false, false, false, false, , 0,
+		i710, 3306, 0, 710, , 5.6.7, 5.6, MySQL, false, false, false, STATEMENT, FULL, false, false, , 0, , 0, false, false, 0, 0, false, , false, false, , , , , , , false, , , false, , 0, mysql.000007, 10, , 0, , , {0 false}, {0 false}, 0, {0 false}, 0, [], , , , , , 0, false, false, false, false, false, false, false, false, false, 0, 0, , false, , , [], , 0, , , 0, , 0, 0, 0, {0 false}, , 0, false, 0, , false, 0, false, , , 0, false,
+		i720, 3306, 0, 720, , 5.6.7, 5.6, MySQL, false, false, false, STATEMENT, FULL, false, false, , 0, , 0, false, false, 0, 0, false, , false, false, , , , , , , false, , , false, , 0, mysql.000007, 20, , 0, , , {0 false}, {0 false}, 0, {0 false}, 0, [], , , , , , 0, false, false, false, false, false, false, false, false, false, 0, 0, , false, , , [], , 0, , , 0, , 0, 0, 0, {0 false}, , 0, false, 0, , false, 0, false, , , 0, false,
+		i730, 3306, 0, 730, , 5.6.7, 5.6, MySQL, false, false, false, STATEMENT, FULL, false, false, , 0, , 0, false, false, 0, 0, false, , false, false, , , , , , , false, , , false, , 0, mysql.000007, 30, , 0, , , {0 false}, {0 false}, 0, {0 false}, 0, [], , , , , , 0, false, false, false, false, false, false, false, false, false, 0, 0, , false, , , [], , 0, , , 0, , 0, 0, 0, {0 false}, , 0, false, 0, , false, 0, false, , , 0, false,
 		`
 
 	sql3, args3, err := mkInsertOdkuForInstances(instances[:3], true, true)
@@ -110,3 +110,143 @@ func fmtArgs(args []interface{}) string {
 	}
 	return b.String()
 }
+
+func TestWriteReadLastCheckQueriesTimedOut(t *testing.T) {
+	defer useSQLiteBackend(t)()
+
+	instances := mkTestInstances()
+	instances[0].LastCheckQueriesTimedOut = true
+	test.S(t).ExpectNil(writeManyInstances(instances[0:2], true, true))
+	// The partial success of the last check is a separate matter, updated on its own
+	test.S(t).ExpectNil(UpdateInstanceLastChecked(&instances[1].Key, true))
+
+	instance, found, err := ReadInstance(&instances[0].Key)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(found)
+	test.S(t).ExpectTrue(instance.LastCheckQueriesTimedOut)
+
+	instance, found, err = ReadInstance(&instances[1].Key)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(found)
+	test.S(t).ExpectFalse(instance.LastCheckQueriesTimedOut)
+}
+
+func TestReadInstancesByKeys(t *testing.T) {
+	defer useSQLiteBackend(t)()
+
+	test.S(t).ExpectNil(writeManyInstances(mkTestInstances(), true, true))
+
+	instances, err := readInstancesByKeys(nil)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(instances), 0)
+
+	unknownKey := InstanceKey{Hostname: "unknown", Port: 3306}
+	instances, err = readInstancesByKeys([]InstanceKey{i730k, unknownKey, i710k})
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(instances), 2)
+	test.S(t).ExpectEquals(instances[0].Key, i710k)
+	test.S(t).ExpectEquals(instances[1].Key, i730k)
+}
+
+func TestForgetCluster(t *testing.T) {
+	defer useSQLiteBackend(t)()
+	// forgotten instances are otherwise not written by following tests
+	defer forgetInstanceKeys.Flush()
+
+	instances := mkTestInstances()
+	instances[0].ClusterName = "forgotten"
+	instances[1].ClusterName = "forgotten"
+	instances[2].ClusterName = "other"
+	test.S(t).ExpectNil(writeManyInstances(instances, true, true))
+	test.S(t).ExpectNil(PutInstanceTag(&i710k, &Tag{TagName: "role", TagValue: "backup", HasValue: true}))
+	test.S(t).ExpectNil(PutInstanceTag(&i730k, &Tag{TagName: "role", TagValue: "backup", HasValue: true}))
+
+	forgottenKeys, err := ForgetCluster("forgotten")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(forgottenKeys), 2)
+	for _, instanceKey := range []InstanceKey{i710k, i720k} {
+		_, found, err := ReadInstance(&instanceKey)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectFalse(found)
+	}
+	_, found, err := ReadInstance(&i730k)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(found)
+
+	tags, err := ReadInstanceTags(&i710k)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(tags), 0)
+	tags, err = ReadInstanceTags(&i730k)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(tags), 1)
+
+	forgottenKeys, err = ForgetInstancesByPattern(`^i730`)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(forgottenKeys), 1)
+	_, found, err = ReadInstance(&i730k)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectFalse(found)
+}
+
+func TestIsStaleReportedReplicaKey(t *testing.T) {
+	defer useSQLiteBackend(t)()
+
+	instances := mkTestInstances()
+	// 720 is known to replicate from 710; 730 is not a replica
+	instances[1].MasterKey = i710k
+	test.S(t).ExpectNil(writeManyInstances(instances, true, true))
+
+	unknownKey := InstanceKey{Hostname: "unknown", Port: 3306}
+	test.S(t).ExpectFalse(isStaleReportedReplicaKey(&i710k, &unknownKey, 999))
+	test.S(t).ExpectFalse(isStaleReportedReplicaKey(&i710k, &i720k, 720))
+	test.S(t).ExpectFalse(isStaleReportedReplicaKey(&i710k, &i720k, 0))
+	// report_host reused by a different server
+	test.S(t).ExpectTrue(isStaleReportedReplicaKey(&i710k, &i720k, 999))
+	// 720 relocated under 730 since last read
+	test.S(t).ExpectFalse(isStaleReportedReplicaKey(&i730k, &i720k, 720))
+}
+
+func TestReadClustersDistinctValues(t *testing.T) {
+	defer useSQLiteBackend(t)()
+
+	instances := mkTestInstances()
+	for i, dataCenter := range []string{"dc2", "dc1", "dc2"} {
+		instances[i].DataCenter = dataCenter
+		instances[i].ClusterName = "dcs"
+	}
+	instances[2].ClusterName = "other"
+	instances[2].DataCenter = ""
+	test.S(t).ExpectNil(writeManyInstances(instances, true, true))
+
+	dataCenters, err := readClustersDistinctValues("", "data_center")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(dataCenters), 1)
+	test.S(t).ExpectEquals(strings.Join(dataCenters["dcs"], ","), "dc1,dc2")
+	dataCenters, err = readClustersDistinctValues("other", "data_center")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(dataCenters), 0)
+}
+
+func TestReadCountEnabledEvents(t *testing.T) {
+	instanceKey := InstanceKey{Hostname: "events", Port: 3306}
+	defer enabledEventsCounts.Delete(instanceKey.StringCode())
+
+	reads := 0
+	read := func() (uint, error) {
+		reads++
+		return 3, nil
+	}
+	failingRead := func() (uint, error) {
+		reads++
+		return 0, fmt.Errorf("access denied")
+	}
+	_, err := readCountEnabledEvents(&instanceKey, failingRead)
+	test.S(t).ExpectNotNil(err)
+	// failed reads are not cached
+	for i := 0; i < 2; i++ {
+		countEnabledEvents, err := readCountEnabledEvents(&instanceKey, read)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(countEnabledEvents, uint(3))
+	}
+	test.S(t).ExpectEquals(reads, 2)
+}
@@ -71,6 +71,16 @@ func TestIsSmallerBinlogFormat(t *testing.T) {
 	test.S(t).ExpectFalse(iRow.IsSmallerBinlogFormat(iMixed))
 }
 
+func TestIsSmallerBinlogRowImage(t *testing.T) {
+	test.S(t).ExpectTrue(IsSmallerBinlogRowImage("MINIMAL", "FULL"))
+	test.S(t).ExpectTrue(IsSmallerBinlogRowImage("MINIMAL", "NOBLOB"))
+	test.S(t).ExpectTrue(IsSmallerBinlogRowImage("NOBLOB", "FULL"))
+	test.S(t).ExpectTrue(IsSmallerBinlogRowImage("minimal", "full"))
+	test.S(t).ExpectFalse(IsSmallerBinlogRowImage("FULL", "FULL"))
+	test.S(t).ExpectFalse(IsSmallerBinlogRowImage("FULL", "MINIMAL"))
+	test.S(t).ExpectFalse(IsSmallerBinlogRowImage("", "FULL"))
+}
+
 func TestIsDescendant(t *testing.T) {
 	{
 		i57 := Instance{Key: key1, Version: "5.7"}
@@ -141,6 +151,46 @@ func TestCanReplicateFrom(t *testing.T) {
 	test.S(t).ExpectTrue(canReplicate)
 	canReplicate, _ = iStatement.CanReplicateFrom(&iRow)
 	test.S(t).ExpectFalse(canReplicate)
+
+	iStatement.LogSlaveUpdatesEnabled = false
+	canReplicate, err = iStatement.CanReplicateFrom(&iRow)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(canReplicate) // does not write replicated events to its binary logs
+}
+
+func TestCanReplicateFromLogSlaveUpdates(t *testing.T) {
+	iMaster := Instance{Key: key1, ServerID: 1, Version: "5.6", LogBinEnabled: true}
+	iIntermediate := Instance{Key: key2, MasterKey: key1, ReadBinlogCoordinates: BinlogCoordinates{LogFile: "mysql-bin.000001", LogPos: 4}, ServerID: 2, Version: "5.6", LogBinEnabled: true}
+	iReplica := Instance{Key: key3, ServerID: 3, Version: "5.6", LogBinEnabled: true}
+
+	canReplicate, err := iReplica.CanReplicateFrom(&iMaster)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(canReplicate) // OK for a master to not have log_slave_updates
+
+	canReplicate, err = iReplica.CanReplicateFrom(&iIntermediate)
+	test.S(t).ExpectNotNil(err)
+	test.S(t).ExpectFalse(canReplicate)
+
+	iIntermediate.LogSlaveUpdatesEnabled = true
+	canReplicate, err = iReplica.CanReplicateFrom(&iIntermediate)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(canReplicate)
+}
+
+func TestCanReplicateFromBinlogRowImage(t *testing.T) {
+	iMinimal := Instance{Key: key1, Binlog_format: "ROW", BinlogRowImage: "MINIMAL", ServerID: 1, Version: "5.6", LogBinEnabled: true, LogSlaveUpdatesEnabled: true}
+	iFull := Instance{Key: key2, Binlog_format: "ROW", BinlogRowImage: "FULL", ServerID: 2, Version: "5.6", LogBinEnabled: true, LogSlaveUpdatesEnabled: true}
+
+	canReplicate, err := iMinimal.CanReplicateFrom(&iFull)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(canReplicate)
+	canReplicate, _ = iFull.CanReplicateFrom(&iMinimal)
+	test.S(t).ExpectFalse(canReplicate)
+
+	iFull.Binlog_format = "MIXED"
+	canReplicate, err = iMinimal.CanReplicateFrom(&iFull)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(canReplicate) // row image only checked when both are ROW
 }
 
 func TestNextGTID(t *testing.T) {
@@ -205,6 +255,11 @@ func TestHumanReadableDescription(t *testing.T) {
 		desc := i57.HumanReadableDescription()
 		test.S(t).ExpectEquals(desc, "[unknown,invalid,5.7.8-log,rw,ROW,>>,P-GTID]")
 	}
+	{
+		i57.IgnoreHealthChecks = true
+		desc := i57.HumanReadableDescription()
+		test.S(t).ExpectEquals(desc, "[unknown,invalid,5.7.8-log,rw,ROW,>>,P-GTID,health-checks-ignored]")
+	}
 }
 
 func TestTabulatedDescription(t *testing.T) {
@@ -238,3 +293,33 @@ func TestReplicationThreads(t *testing.T) {
 		test.S(t).ExpectFalse(i.ReplicationThreadsExist())
 	}
 }
+
+func TestHasBinlogMissingOnMaster(t *testing.T) {
+	master := &Instance{Key: key1, OldestBinaryLogFile: "mysql-bin.000010"}
+	purgedError := "Got fatal error 1236 from master when reading data from binary log: 'Could not find first log file name in binary log index file'"
+	{
+		replica := &Instance{Key: key2, LastIOError: purgedError, ReadBinlogCoordinates: BinlogCoordinates{LogFile: "mysql-bin.000007", LogPos: 4}}
+		test.S(t).ExpectTrue(replica.HasBinlogMissingOnMaster(master))
+	}
+	{
+		replica := &Instance{Key: key2, LastIOError: purgedError, ReadBinlogCoordinates: BinlogCoordinates{LogFile: "mysql-bin.000010", LogPos: 4}}
+		test.S(t).ExpectFalse(replica.HasBinlogMissingOnMaster(master))
+	}
+	{
+		replica := &Instance{Key: key2, LastIOError: "error connecting to master", ReadBinlogCoordinates: BinlogCoordinates{LogFile: "mysql-bin.000007", LogPos: 4}}
+		test.S(t).ExpectFalse(replica.HasBinlogMissingOnMaster(master))
+	}
+	{
+		replica := &Instance{Key: key2, LastIOError: purgedError, ReadBinlogCoordinates: BinlogCoordinates{LogFile: "mysql-bin.000007", LogPos: 4}}
+		test.S(t).ExpectFalse(replica.HasBinlogMissingOnMaster(&Instance{Key: key1}))
+	}
+}
+
+func TestIsBinlogServer(t *testing.T) {
+	test.S(t).ExpectFalse((&Instance{Version: "5.7.26-log"}).IsBinlogServer())
+	test.S(t).ExpectTrue((&Instance{Version: "1.4.3-maxscale"}).IsBinlogServer())
+	test.S(t).ExpectTrue((&Instance{Version: "5.7.26-ripple", IsDetectedBinlogServer: true}).IsBinlogServer())
+
+	replica := &Instance{Version: "5.7.26-ripple", IsDetectedBinlogServer: true, LogBinEnabled: true, LogSlaveUpdatesEnabled: true}
+	test.S(t).ExpectFalse(isGenerallyValidAsCandidateReplica(replica))
+}
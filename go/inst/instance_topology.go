@@ -202,8 +202,17 @@ func InstanceIsMasterOf(allegedMaster, allegedReplica *Instance) bool {
 }
 
 // MoveEquivalent will attempt moving instance indicated by instanceKey below another instance,
-// based on known master coordinates equivalence
-func MoveEquivalent(instanceKey, otherKey *InstanceKey) (*Instance, error) {
+// based on known master coordinates equivalence. Moving below an instance of a different cluster is
+// refused unless allowCrossCluster.
+func MoveEquivalent(instanceKey, otherKey *InstanceKey, allowCrossCluster bool) (*Instance, error) {
+	if err := checkCrossClusterMove("move-equivalent", instanceKey, otherKey, allowCrossCluster); err != nil {
+		return nil, err
+	}
+	return moveEquivalent(instanceKey, otherKey)
+}
+
+// moveEquivalent is the implementation of MoveEquivalent, without the cross-cluster check
+func moveEquivalent(instanceKey, otherKey *InstanceKey) (*Instance, error) {
 	instance, found, err := ReadInstance(instanceKey)
 	if err != nil || !found {
 		return instance, err
@@ -211,6 +220,9 @@ func MoveEquivalent(instanceKey, otherKey *InstanceKey) (*Instance, error) {
 	if instance.Key.Equals(otherKey) {
 		return instance, fmt.Errorf("MoveEquivalent: attempt to move an instance below itself %+v", instance.Key)
 	}
+	if err := CheckCanRelocateReplica(instance, "move-equivalent"); err != nil {
+		return instance, err
+	}
 
 	// Are there equivalent coordinates to this instance?
 	instanceCoordinates := &InstanceBinlogCoordinates{Key: instance.MasterKey, Coordinates: instance.ExecBinlogCoordinates}
@@ -228,6 +240,11 @@ func MoveEquivalent(instanceKey, otherKey *InstanceKey) (*Instance, error) {
 	// This excludes the case, for example, that the master is itself not replicating.
 	// Now if we DO get to happen on equivalent coordinates, we need to double check. For CHANGE MASTER to happen we must
 	// stop the replica anyhow. But then let's verify the position hasn't changed.
+	if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), fmt.Sprintf("move equivalent below %+v", *otherKey)); merr != nil {
+		return instance, fmt.Errorf("Cannot begin maintenance on %+v: %+v", *instanceKey, merr)
+	} else {
+		defer EndMaintenance(maintenanceToken)
+	}
 	knownExecBinlogCoordinates := instance.ExecBinlogCoordinates
 	instance, err = StopSlave(instanceKey)
 	if err != nil {
@@ -251,6 +268,72 @@ Cleanup:
 	return instance, err
 }
 
+// checkMoveReplicationFilters refuses placing an instance below another instance whose replication filters
+// differ, as that may silently lose data. Moving below an instance with same filters as the instance's
+// current master is fine: the filtering upstream of the instance remains the same.
+// This check is overridden by --force.
+func checkMoveReplicationFilters(instance, other *Instance) error {
+	if instance.ReplicationFilters.Equals(&other.ReplicationFilters) {
+		return nil
+	}
+	if instance.IsReplica() {
+		if master, found, _ := ReadInstance(&instance.MasterKey); found && master.ReplicationFilters.Equals(&other.ReplicationFilters) {
+			return nil
+		}
+	}
+	if *config.RuntimeCLIFlags.Force {
+		log.Warningf("Forcing move of %+v below %+v despite different replication filters", instance.Key, other.Key)
+		return nil
+	}
+	return fmt.Errorf("Refusing to move %+v below %+v: replication filters differ: %s. Use --force to override", instance.Key, other.Key, strings.Join(instance.ReplicationFilters.Differences(&other.ReplicationFilters), ", "))
+}
+
+// isCrossClusterMove returns true when given instances, of given cluster aliases, belong to different clusters.
+// Clusters are compared by alias when known, such that co-masters, which may transiently be recorded under
+// different cluster names, are not flagged. Instances whose cluster is not yet known are not flagged.
+func isCrossClusterMove(instance, other *Instance, clusterAlias, otherClusterAlias string) bool {
+	if instance.ClusterName == "" || other.ClusterName == "" {
+		return false
+	}
+	if instance.ClusterName == other.ClusterName {
+		return false
+	}
+	if clusterAlias != "" && clusterAlias == otherClusterAlias {
+		return false
+	}
+	return true
+}
+
+// checkCrossClusterMove refuses placing given instance (or its replicas) below an instance of a different
+// cluster, which is far more likely a typo than intent, and would silently corrupt replication.
+// This check is overridden by allowCrossCluster, in which case the attempted move is audited, whatever its
+// outcome. Recoveries allow cross-cluster moves.
+func checkCrossClusterMove(operation string, instanceKey, otherKey *InstanceKey, allowCrossCluster bool) error {
+	if instanceKey == nil || otherKey == nil {
+		return nil
+	}
+	instance, found, err := ReadInstance(instanceKey)
+	if err != nil || !found {
+		// The move itself reports an unknown instance
+		return err
+	}
+	other, found, err := ReadInstance(otherKey)
+	if err != nil || !found {
+		return err
+	}
+	clusterAlias, _ := ReadAliasByClusterName(instance.ClusterName)
+	otherClusterAlias, _ := ReadAliasByClusterName(other.ClusterName)
+	if !isCrossClusterMove(instance, other, clusterAlias, otherClusterAlias) {
+		return nil
+	}
+	if !allowCrossCluster {
+		return fmt.Errorf("Refusing to move %+v of cluster %s below %+v of cluster %s. Use --allow-cross-cluster (API: allow-cross-cluster=true) to override", *instanceKey, clusterAlias, *otherKey, otherClusterAlias)
+	}
+	log.Warningf("Allowing move of %+v of cluster %s below %+v of cluster %s", *instanceKey, clusterAlias, *otherKey, otherClusterAlias)
+	AuditOperation("relocate-cross-cluster", instanceKey, fmt.Sprintf("%s: moving %+v of cluster %s below %+v of cluster %s", operation, *instanceKey, clusterAlias, *otherKey, otherClusterAlias))
+	return nil
+}
+
 // MoveUp will attempt moving instance indicated by instanceKey up the topology hierarchy.
 // It will perform all safety and sanity checks and will tamper with this instance's replication
 // as well as its master.
@@ -259,6 +342,9 @@ func MoveUp(instanceKey *InstanceKey) (*Instance, error) {
 	if err != nil {
 		return instance, err
 	}
+	if err := CheckCanRelocateReplica(instance, "move-up"); err != nil {
+		return instance, err
+	}
 	if !instance.IsReplica() {
 		return instance, fmt.Errorf("instance is not a replica: %+v", instanceKey)
 	}
@@ -278,21 +364,29 @@ func MoveUp(instanceKey *InstanceKey) (*Instance, error) {
 	if canReplicate, err := instance.CanReplicateFrom(master); canReplicate == false {
 		return instance, err
 	}
+	if grandparent, found, _ := ReadInstance(&master.MasterKey); found {
+		if err := checkMoveReplicationFilters(instance, grandparent); err != nil {
+			return instance, err
+		}
+		if err := checkMoveVersionOrdering(instance, grandparent); err != nil {
+			return instance, err
+		}
+	}
 	if master.IsBinlogServer() {
 		// Quick solution via binlog servers
-		return Repoint(instanceKey, &master.MasterKey, GTIDHintDeny)
+		return repoint(instanceKey, &master.MasterKey, GTIDHintDeny)
 	}
 
 	log.Infof("Will move %+v up the topology", *instanceKey)
 
 	if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), "move up"); merr != nil {
-		err = fmt.Errorf("Cannot begin maintenance on %+v", *instanceKey)
+		err = fmt.Errorf("Cannot begin maintenance on %+v: %+v", *instanceKey, merr)
 		goto Cleanup
 	} else {
 		defer EndMaintenance(maintenanceToken)
 	}
 	if maintenanceToken, merr := BeginMaintenance(&master.Key, GetMaintenanceOwner(), fmt.Sprintf("child %+v moves up", *instanceKey)); merr != nil {
-		err = fmt.Errorf("Cannot begin maintenance on %+v", master.Key)
+		err = fmt.Errorf("Cannot begin maintenance on %+v: %+v", master.Key, merr)
 		goto Cleanup
 	} else {
 		defer EndMaintenance(maintenanceToken)
@@ -359,7 +453,7 @@ func MoveUpReplicas(instanceKey *InstanceKey, pattern string) ([](*Instance), *I
 	}
 
 	if instance.IsBinlogServer() {
-		replicas, err, errors := RepointReplicasTo(instanceKey, pattern, &instance.MasterKey)
+		replicas, err, errors := repointReplicasTo(instanceKey, pattern, &instance.MasterKey)
 		// Bail out!
 		return replicas, instance, err, errors
 	}
@@ -375,14 +469,14 @@ func MoveUpReplicas(instanceKey *InstanceKey, pattern string) ([](*Instance), *I
 	log.Infof("Will move replicas of %+v up the topology", *instanceKey)
 
 	if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), "move up replicas"); merr != nil {
-		err = fmt.Errorf("Cannot begin maintenance on %+v", *instanceKey)
+		err = fmt.Errorf("Cannot begin maintenance on %+v: %+v", *instanceKey, merr)
 		goto Cleanup
 	} else {
 		defer EndMaintenance(maintenanceToken)
 	}
 	for _, replica := range replicas {
 		if maintenanceToken, merr := BeginMaintenance(&replica.Key, GetMaintenanceOwner(), fmt.Sprintf("%+v moves up", replica.Key)); merr != nil {
-			err = fmt.Errorf("Cannot begin maintenance on %+v", replica.Key)
+			err = fmt.Errorf("Cannot begin maintenance on %+v: %+v", replica.Key, merr)
 			goto Cleanup
 		} else {
 			defer EndMaintenance(maintenanceToken)
@@ -411,7 +505,7 @@ func MoveUpReplicas(instanceKey *InstanceKey, pattern string) ([](*Instance), *I
 				}
 				if instance.IsBinlogServer() {
 					// Special case. Just repoint
-					replica, err = Repoint(&replica.Key, instanceKey, GTIDHintDeny)
+					replica, err = repoint(&replica.Key, instanceKey, GTIDHintDeny)
 					if err != nil {
 						replicaErr = err
 						return
@@ -478,11 +572,20 @@ func MoveBelow(instanceKey, siblingKey *InstanceKey) (*Instance, error) {
 	if err != nil {
 		return instance, err
 	}
+	if err := CheckCanRelocateReplica(instance, "move-below"); err != nil {
+		return instance, err
+	}
+	if err := checkMoveReplicationFilters(instance, sibling); err != nil {
+		return instance, err
+	}
+	if err := checkMoveVersionOrdering(instance, sibling); err != nil {
+		return instance, err
+	}
 
 	if sibling.IsBinlogServer() {
 		// Binlog server has same coordinates as master
 		// Easy solution!
-		return Repoint(instanceKey, &sibling.Key, GTIDHintDeny)
+		return repoint(instanceKey, &sibling.Key, GTIDHintDeny)
 	}
 
 	rinstance, _, _ := ReadInstance(&instance.Key)
@@ -504,13 +607,13 @@ func MoveBelow(instanceKey, siblingKey *InstanceKey) (*Instance, error) {
 	log.Infof("Will move %+v below %+v", instanceKey, siblingKey)
 
 	if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), fmt.Sprintf("move below %+v", *siblingKey)); merr != nil {
-		err = fmt.Errorf("Cannot begin maintenance on %+v", *instanceKey)
+		err = fmt.Errorf("Cannot begin maintenance on %+v: %+v", *instanceKey, merr)
 		goto Cleanup
 	} else {
 		defer EndMaintenance(maintenanceToken)
 	}
 	if maintenanceToken, merr := BeginMaintenance(siblingKey, GetMaintenanceOwner(), fmt.Sprintf("%+v moves below this", *instanceKey)); merr != nil {
-		err = fmt.Errorf("Cannot begin maintenance on %+v", *siblingKey)
+		err = fmt.Errorf("Cannot begin maintenance on %+v: %+v", *siblingKey, merr)
 		goto Cleanup
 	} else {
 		defer EndMaintenance(maintenanceToken)
@@ -595,6 +698,9 @@ func CheckMoveViaGTID(instance, otherInstance *Instance) (err error) {
 
 // moveInstanceBelowViaGTID will attempt moving given instance below another instance using either Oracle GTID or MariaDB GTID.
 func moveInstanceBelowViaGTID(instance, otherInstance *Instance) (*Instance, error) {
+	if err := CheckCanRelocateReplica(instance, "move-below-gtid"); err != nil {
+		return instance, err
+	}
 	rinstance, _, _ := ReadInstance(&instance.Key)
 	if canMove, merr := rinstance.CanMoveViaMatch(); !canMove {
 		return instance, merr
@@ -613,7 +719,7 @@ func moveInstanceBelowViaGTID(instance, otherInstance *Instance) (*Instance, err
 
 	var err error
 	if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), fmt.Sprintf("move below %+v", *otherInstanceKey)); merr != nil {
-		err = fmt.Errorf("Cannot begin maintenance on %+v", *instanceKey)
+		err = fmt.Errorf("Cannot begin maintenance on %+v: %+v", *instanceKey, merr)
 		goto Cleanup
 	} else {
 		defer EndMaintenance(maintenanceToken)
@@ -640,7 +746,11 @@ Cleanup:
 }
 
 // MoveBelowGTID will attempt moving instance indicated by instanceKey below another instance using either Oracle GTID or MariaDB GTID.
-func MoveBelowGTID(instanceKey, otherKey *InstanceKey) (*Instance, error) {
+// Moving below an instance of a different cluster is refused unless allowCrossCluster.
+func MoveBelowGTID(instanceKey, otherKey *InstanceKey, allowCrossCluster bool) (*Instance, error) {
+	if err := checkCrossClusterMove("move-gtid", instanceKey, otherKey, allowCrossCluster); err != nil {
+		return nil, err
+	}
 	instance, err := ReadTopologyInstance(instanceKey)
 	if err != nil {
 		return instance, err
@@ -649,6 +759,12 @@ func MoveBelowGTID(instanceKey, otherKey *InstanceKey) (*Instance, error) {
 	if err != nil {
 		return instance, err
 	}
+	if err := checkMoveReplicationFilters(instance, other); err != nil {
+		return instance, err
+	}
+	if err := checkMoveVersionOrdering(instance, other); err != nil {
+		return instance, err
+	}
 	return moveInstanceBelowViaGTID(instance, other)
 }
 
@@ -718,7 +834,11 @@ func moveReplicasViaGTID(replicas [](*Instance), other *Instance, postponedFunct
 }
 
 // MoveReplicasGTID will (attempt to) move all replicas of given master below given instance.
-func MoveReplicasGTID(masterKey *InstanceKey, belowKey *InstanceKey, pattern string) (movedReplicas [](*Instance), unmovedReplicas [](*Instance), err error, errs []error) {
+// Moving below an instance of a different cluster is refused unless allowCrossCluster.
+func MoveReplicasGTID(masterKey *InstanceKey, belowKey *InstanceKey, pattern string, allowCrossCluster bool) (movedReplicas [](*Instance), unmovedReplicas [](*Instance), err error, errs []error) {
+	if err := checkCrossClusterMove("move-replicas-gtid", masterKey, belowKey, allowCrossCluster); err != nil {
+		return movedReplicas, unmovedReplicas, err, errs
+	}
 	belowInstance, err := ReadTopologyInstance(belowKey)
 	if err != nil {
 		// Can't access "below" ==> can't move replicas beneath it
@@ -748,11 +868,23 @@ func MoveReplicasGTID(masterKey *InstanceKey, belowKey *InstanceKey, pattern str
 // Two use cases:
 // - masterKey is nil: use case is corrupted relay logs on replica
 // - masterKey is not nil: using Binlog servers (coordinates remain the same)
-func Repoint(instanceKey *InstanceKey, masterKey *InstanceKey, gtidHint OperationGTIDHint) (*Instance, error) {
+// Repointing below an instance of a different cluster is refused unless allowCrossCluster.
+func Repoint(instanceKey *InstanceKey, masterKey *InstanceKey, gtidHint OperationGTIDHint, allowCrossCluster bool) (*Instance, error) {
+	if err := checkCrossClusterMove("repoint", instanceKey, masterKey, allowCrossCluster); err != nil {
+		return nil, err
+	}
+	return repoint(instanceKey, masterKey, gtidHint)
+}
+
+// repoint is the implementation of Repoint, without the cross-cluster check
+func repoint(instanceKey *InstanceKey, masterKey *InstanceKey, gtidHint OperationGTIDHint) (*Instance, error) {
 	instance, err := ReadTopologyInstance(instanceKey)
 	if err != nil {
 		return instance, err
 	}
+	if err := CheckCanRelocateReplica(instance, "repoint"); err != nil {
+		return instance, err
+	}
 	if !instance.IsReplica() {
 		return instance, fmt.Errorf("instance is not a replica: %+v", *instanceKey)
 	}
@@ -786,7 +918,7 @@ func Repoint(instanceKey *InstanceKey, masterKey *InstanceKey, gtidHint Operatio
 	log.Infof("Will repoint %+v to master %+v", *instanceKey, *masterKey)
 
 	if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), "repoint"); merr != nil {
-		err = fmt.Errorf("Cannot begin maintenance on %+v", *instanceKey)
+		err = fmt.Errorf("Cannot begin maintenance on %+v: %+v", *instanceKey, merr)
 		goto Cleanup
 	} else {
 		defer EndMaintenance(maintenanceToken)
@@ -820,6 +952,33 @@ Cleanup:
 
 }
 
+// RepointToCanonical repoints given replica onto the canonical key of the master it already replicates from,
+// keeping its coordinates. This fixes replicas configured with a non-canonical endpoint of their master, such as
+// a secondary IP or a proxy port (see ReplicaPointsToWrongEndpoint analysis).
+func RepointToCanonical(instanceKey *InstanceKey) (*Instance, error) {
+	instance, err := ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return instance, err
+	}
+	if !instance.IsReplica() {
+		return instance, fmt.Errorf("instance is not a replica: %+v", *instanceKey)
+	}
+	canonicalMasterKey, err := readCanonicalMasterKey(instance)
+	if err != nil {
+		return instance, err
+	}
+	rawMasterKey := instance.RawMasterKey
+	if rawMasterKey.Equals(canonicalMasterKey) {
+		return instance, fmt.Errorf("%+v already replicates from canonical endpoint %+v", *instanceKey, *canonicalMasterKey)
+	}
+	instance, err = repoint(instanceKey, canonicalMasterKey, GTIDHintNeutral)
+	if err != nil {
+		return instance, err
+	}
+	AuditOperation("repoint-to-canonical", instanceKey, fmt.Sprintf("replica %+v repointed from %+v to canonical endpoint %+v", *instanceKey, rawMasterKey, *canonicalMasterKey))
+	return instance, nil
+}
+
 // RepointTo repoints list of replicas onto another master.
 // Binlog Server is the major use case
 func RepointTo(replicas [](*Instance), belowKey *InstanceKey) ([](*Instance), error, []error) {
@@ -845,7 +1004,7 @@ func RepointTo(replicas [](*Instance), belowKey *InstanceKey) ([](*Instance), er
 		go func() {
 			defer func() { barrier <- &replica.Key }()
 			ExecuteOnTopology(func() {
-				replica, replicaErr := Repoint(&replica.Key, belowKey, GTIDHintNeutral)
+				replica, replicaErr := repoint(&replica.Key, belowKey, GTIDHintNeutral)
 
 				func() {
 					// Instantaneous mutex.
@@ -874,8 +1033,17 @@ func RepointTo(replicas [](*Instance), belowKey *InstanceKey) ([](*Instance), er
 }
 
 // RepointReplicasTo repoints replicas of a given instance (possibly filtered) onto another master.
-// Binlog Server is the major use case
-func RepointReplicasTo(instanceKey *InstanceKey, pattern string, belowKey *InstanceKey) ([](*Instance), error, []error) {
+// Binlog Server is the major use case. Repointing below an instance of a different cluster is refused
+// unless allowCrossCluster.
+func RepointReplicasTo(instanceKey *InstanceKey, pattern string, belowKey *InstanceKey, allowCrossCluster bool) ([](*Instance), error, []error) {
+	if err := checkCrossClusterMove("repoint-replicas", instanceKey, belowKey, allowCrossCluster); err != nil {
+		return [](*Instance){}, err, []error{}
+	}
+	return repointReplicasTo(instanceKey, pattern, belowKey)
+}
+
+// repointReplicasTo is the implementation of RepointReplicasTo, without the cross-cluster check
+func repointReplicasTo(instanceKey *InstanceKey, pattern string, belowKey *InstanceKey) ([](*Instance), error, []error) {
 	res := [](*Instance){}
 	errs := []error{}
 
@@ -899,7 +1067,20 @@ func RepointReplicasTo(instanceKey *InstanceKey, pattern string, belowKey *Insta
 
 // RepointReplicas repoints all replicas of a given instance onto its existing master.
 func RepointReplicas(instanceKey *InstanceKey, pattern string) ([](*Instance), error, []error) {
-	return RepointReplicasTo(instanceKey, pattern, nil)
+	return repointReplicasTo(instanceKey, pattern, nil)
+}
+
+// checkCoMastersConsistency verifies given replica and its master may form a co-master pair. Each is to
+// replicate from the other: they must agree on gtid_mode, and the master must relay the replica's writes to
+// its other replicas.
+func checkCoMastersConsistency(instance, master *Instance) error {
+	if instance.GTIDMode != master.GTIDMode {
+		return fmt.Errorf("%+v has gtid_mode %s while %+v has gtid_mode %s; co-masters must agree on gtid_mode", instance.Key, instance.GTIDMode, master.Key, master.GTIDMode)
+	}
+	if !master.LogSlaveUpdatesEnabled && len(master.SlaveHosts) > 1 {
+		return fmt.Errorf("%+v does not have log_slave_updates enabled; as co-master of %+v its other replicas would not receive writes made on %+v", master.Key, instance.Key, instance.Key)
+	}
+	return nil
 }
 
 // MakeCoMaster will attempt to make an instance co-master with its master, by making its master a replica of its own.
@@ -909,6 +1090,12 @@ func MakeCoMaster(instanceKey *InstanceKey) (*Instance, error) {
 	if err != nil {
 		return instance, err
 	}
+	if err := CheckCanPromoteReplicationGroupMember(instance, "make-co-master"); err != nil {
+		return instance, err
+	}
+	if err := CheckCanRelocateReplica(instance, "make-co-master"); err != nil {
+		return instance, err
+	}
 	if canMove, merr := instance.CanMove(); !canMove {
 		return instance, merr
 	}
@@ -948,17 +1135,23 @@ func MakeCoMaster(instanceKey *InstanceKey) (*Instance, error) {
 	if canReplicate, err := master.CanReplicateFrom(instance); !canReplicate {
 		return instance, err
 	}
+	if err := checkMoveVersionOrdering(master, instance); err != nil {
+		return instance, err
+	}
+	if err := checkCoMastersConsistency(instance, master); err != nil {
+		return instance, err
+	}
 	log.Infof("Will make %+v co-master of %+v", instanceKey, master.Key)
 
 	var gitHint OperationGTIDHint = GTIDHintNeutral
 	if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), fmt.Sprintf("make co-master of %+v", master.Key)); merr != nil {
-		err = fmt.Errorf("Cannot begin maintenance on %+v", *instanceKey)
+		err = fmt.Errorf("Cannot begin maintenance on %+v: %+v", *instanceKey, merr)
 		goto Cleanup
 	} else {
 		defer EndMaintenance(maintenanceToken)
 	}
 	if maintenanceToken, merr := BeginMaintenance(&master.Key, GetMaintenanceOwner(), fmt.Sprintf("%+v turns into co-master of this", *instanceKey)); merr != nil {
-		err = fmt.Errorf("Cannot begin maintenance on %+v", master.Key)
+		err = fmt.Errorf("Cannot begin maintenance on %+v: %+v", master.Key, merr)
 		goto Cleanup
 	} else {
 		defer EndMaintenance(maintenanceToken)
@@ -1022,7 +1215,7 @@ func ResetSlaveOperation(instanceKey *InstanceKey) (*Instance, error) {
 	log.Infof("Will reset replica on %+v", instanceKey)
 
 	if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), "reset replica"); merr != nil {
-		err = fmt.Errorf("Cannot begin maintenance on %+v", *instanceKey)
+		err = fmt.Errorf("Cannot begin maintenance on %+v: %+v", *instanceKey, merr)
 		goto Cleanup
 	} else {
 		defer EndMaintenance(maintenanceToken)
@@ -1059,6 +1252,9 @@ func DetachReplicaMasterHost(instanceKey *InstanceKey) (*Instance, error) {
 	if err != nil {
 		return instance, err
 	}
+	if err := CheckCanRelocateReplica(instance, "detach-replica-master-host"); err != nil {
+		return instance, err
+	}
 	if !instance.IsReplica() {
 		return instance, fmt.Errorf("instance is not a replica: %+v", *instanceKey)
 	}
@@ -1070,7 +1266,7 @@ func DetachReplicaMasterHost(instanceKey *InstanceKey) (*Instance, error) {
 	log.Infof("Will detach master host on %+v. Detached key is %+v", *instanceKey, *detachedMasterKey)
 
 	if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), "detach-replica-master-host"); merr != nil {
-		err = fmt.Errorf("Cannot begin maintenance on %+v", *instanceKey)
+		err = fmt.Errorf("Cannot begin maintenance on %+v: %+v", *instanceKey, merr)
 		goto Cleanup
 	} else {
 		defer EndMaintenance(maintenanceToken)
@@ -1103,6 +1299,9 @@ func ReattachReplicaMasterHost(instanceKey *InstanceKey) (*Instance, error) {
 	if err != nil {
 		return instance, err
 	}
+	if err := CheckCanRelocateReplica(instance, "reattach-replica-master-host"); err != nil {
+		return instance, err
+	}
 	if !instance.IsReplica() {
 		return instance, fmt.Errorf("instance is not a replica: %+v", *instanceKey)
 	}
@@ -1115,7 +1314,7 @@ func ReattachReplicaMasterHost(instanceKey *InstanceKey) (*Instance, error) {
 	log.Infof("Will reattach master host on %+v. Reattached key is %+v", *instanceKey, *reattachedMasterKey)
 
 	if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), "reattach-replica-master-host"); merr != nil {
-		err = fmt.Errorf("Cannot begin maintenance on %+v", *instanceKey)
+		err = fmt.Errorf("Cannot begin maintenance on %+v: %+v", *instanceKey, merr)
 		goto Cleanup
 	} else {
 		defer EndMaintenance(maintenanceToken)
@@ -1156,7 +1355,7 @@ func EnableGTID(instanceKey *InstanceKey) (*Instance, error) {
 
 	log.Infof("Will attempt to enable GTID on %+v", *instanceKey)
 
-	instance, err = Repoint(instanceKey, nil, GTIDHintForce)
+	instance, err = repoint(instanceKey, nil, GTIDHintForce)
 	if err != nil {
 		return instance, err
 	}
@@ -1181,7 +1380,7 @@ func DisableGTID(instanceKey *InstanceKey) (*Instance, error) {
 
 	log.Infof("Will attempt to disable GTID on %+v", *instanceKey)
 
-	instance, err = Repoint(instanceKey, nil, GTIDHintDeny)
+	instance, err = repoint(instanceKey, nil, GTIDHintDeny)
 	if err != nil {
 		return instance, err
 	}
@@ -1271,7 +1470,7 @@ func ErrantGTIDResetMaster(instanceKey *InstanceKey) (instance *Instance, err er
 	waitInterval := time.Second * 5
 
 	if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), "reset-master-gtid"); merr != nil {
-		err = fmt.Errorf("Cannot begin maintenance on %+v", *instanceKey)
+		err = fmt.Errorf("Cannot begin maintenance on %+v: %+v", *instanceKey, merr)
 		goto Cleanup
 	} else {
 		defer EndMaintenance(maintenanceToken)
@@ -1506,8 +1705,32 @@ func CorrelateRelaylogCoordinates(instance *Instance, relaylogCoordinates *Binlo
 // The refactoring is based on matching binlog entries, not on "classic" positions comparisons.
 // The "other instance" could be the sibling of the moving instance any of its ancestors. It may actually be
 // a cousin of some sort (though unlikely). The only important thing is that the "other instance" is more
-// advanced in replication than given instance.
-func MatchBelow(instanceKey, otherKey *InstanceKey, requireInstanceMaintenance bool) (*Instance, *BinlogCoordinates, error) {
+// advanced in replication than given instance. Moving below an instance of a different cluster is refused
+// unless allowCrossCluster.
+func MatchBelow(instanceKey, otherKey *InstanceKey, requireInstanceMaintenance bool, allowCrossCluster bool) (*Instance, *BinlogCoordinates, error) {
+	if err := checkCrossClusterMove("match", instanceKey, otherKey, allowCrossCluster); err != nil {
+		return nil, nil, err
+	}
+	return matchBelowWithChecks(instanceKey, otherKey, requireInstanceMaintenance)
+}
+
+// matchBelowWithChecks is the implementation of MatchBelow, without the cross-cluster check
+func matchBelowWithChecks(instanceKey, otherKey *InstanceKey, requireInstanceMaintenance bool) (*Instance, *BinlogCoordinates, error) {
+	instance, found, _ := ReadInstance(instanceKey)
+	if otherInstance, otherFound, _ := ReadInstance(otherKey); found && otherFound {
+		if err := checkMoveReplicationFilters(instance, otherInstance); err != nil {
+			return instance, nil, err
+		}
+		if err := checkMoveVersionOrdering(instance, otherInstance); err != nil {
+			return instance, nil, err
+		}
+	}
+	return matchBelow(instanceKey, otherKey, requireInstanceMaintenance)
+}
+
+// matchBelow is the implementation of MatchBelow, without the cross-cluster and replication filters checks.
+// It is used by operations which move multiple replicas at once, e.g. as part of a recovery.
+func matchBelow(instanceKey, otherKey *InstanceKey, requireInstanceMaintenance bool) (*Instance, *BinlogCoordinates, error) {
 	instance, err := ReadTopologyInstance(instanceKey)
 	if err != nil {
 		return instance, nil, err
@@ -1518,6 +1741,9 @@ func MatchBelow(instanceKey, otherKey *InstanceKey, requireInstanceMaintenance b
 	if instanceKey.Equals(otherKey) {
 		return instance, nil, fmt.Errorf("MatchBelow: attempt to match an instance below itself %+v", *instanceKey)
 	}
+	if err := CheckCanRelocateReplica(instance, "match-below"); err != nil {
+		return instance, nil, err
+	}
 	otherInstance, err := ReadTopologyInstance(otherKey)
 	if err != nil {
 		return instance, nil, err
@@ -1544,7 +1770,7 @@ func MatchBelow(instanceKey, otherKey *InstanceKey, requireInstanceMaintenance b
 
 	if requireInstanceMaintenance {
 		if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), fmt.Sprintf("match below %+v", *otherKey)); merr != nil {
-			err = fmt.Errorf("Cannot begin maintenance on %+v", *instanceKey)
+			err = fmt.Errorf("Cannot begin maintenance on %+v: %+v", *instanceKey, merr)
 			goto Cleanup
 		} else {
 			defer EndMaintenance(maintenanceToken)
@@ -1602,7 +1828,7 @@ func RematchReplica(instanceKey *InstanceKey, requireInstanceMaintenance bool) (
 	if err != nil || !found {
 		return instance, nil, err
 	}
-	return MatchBelow(instanceKey, &masterInstance.Key, requireInstanceMaintenance)
+	return matchBelowWithChecks(instanceKey, &masterInstance.Key, requireInstanceMaintenance)
 }
 
 // MakeMaster will take an instance, make all its siblings its replicas (via pseudo-GTID) and make it master
@@ -1612,6 +1838,9 @@ func MakeMaster(instanceKey *InstanceKey) (*Instance, error) {
 	if err != nil {
 		return instance, err
 	}
+	if err := CheckCanPromoteReplicationGroupMember(instance, "make-master"); err != nil {
+		return instance, err
+	}
 	masterInstance, err := ReadTopologyInstance(&instance.MasterKey)
 	if err == nil {
 		// If the read succeeded, check the master status.
@@ -1638,7 +1867,7 @@ func MakeMaster(instanceKey *InstanceKey) (*Instance, error) {
 	}
 
 	if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), fmt.Sprintf("siblings match below this: %+v", *instanceKey)); merr != nil {
-		err = fmt.Errorf("Cannot begin maintenance on %+v", *instanceKey)
+		err = fmt.Errorf("Cannot begin maintenance on %+v: %+v", *instanceKey, merr)
 		goto Cleanup
 	} else {
 		defer EndMaintenance(maintenanceToken)
@@ -1664,17 +1893,48 @@ Cleanup:
 // TakeSiblings is a convenience method for turning siblings of a replica to be its subordinates.
 // This operation is a syntatctic sugar on top relocate-replicas, which uses any available means to the objective:
 // GTID, Pseudo-GTID, binlog servers, standard replication...
-func TakeSiblings(instanceKey *InstanceKey) (instance *Instance, takenSiblings int, err error) {
+// Siblings which fail to relocate do not abort the operation; their errors are returned in errs.
+// With dryRun, no change is made; takenSiblings is the number of siblings which pass sanity checks.
+func TakeSiblings(instanceKey *InstanceKey, dryRun bool) (instance *Instance, takenSiblings int, err error, errs []error) {
 	instance, err = ReadTopologyInstance(instanceKey)
 	if err != nil {
-		return instance, 0, err
+		return instance, 0, err, errs
+	}
+	if err := CheckCanPromoteReplicationGroupMember(instance, "take-siblings"); err != nil {
+		return instance, 0, err, errs
 	}
 	if !instance.IsReplica() {
-		return instance, takenSiblings, log.Errorf("take-siblings: instance %+v is not a replica.", *instanceKey)
+		return instance, takenSiblings, log.Errorf("take-siblings: instance %+v is not a replica.", *instanceKey), errs
 	}
-	relocatedReplicas, _, err, _ := RelocateReplicas(&instance.MasterKey, instanceKey, "")
+	if dryRun {
+		siblings, err := ReadReplicaInstances(&instance.MasterKey)
+		if err != nil {
+			return instance, 0, err, errs
+		}
+		for _, sibling := range siblings {
+			if sibling.Key.Equals(instanceKey) {
+				continue
+			}
+			if canReplicate, err := sibling.CanReplicateFrom(instance); !canReplicate {
+				errs = append(errs, fmt.Errorf("%+v: %+v", sibling.Key, err))
+				continue
+			}
+			if err := checkMoveReplicationFilters(sibling, instance); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if err := checkMoveVersionOrdering(sibling, instance); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			log.Infof("take-siblings: dry-run: would relocate %+v below %+v", sibling.Key, *instanceKey)
+			takenSiblings++
+		}
+		return instance, takenSiblings, nil, errs
+	}
+	relocatedReplicas, _, err, errs := relocateReplicas(&instance.MasterKey, instanceKey, "")
 
-	return instance, len(relocatedReplicas), err
+	return instance, len(relocatedReplicas), err, errs
 }
 
 // Created this function to allow a hook to be called after a successful TakeMaster event
@@ -1710,11 +1970,20 @@ func TakeMasterHook(successor *Instance, demoted *Instance) {
 // (they continue replicate without change)
 // Note that the master must itself be a replica; however the grandparent does not necessarily have to be reachable
 // and can in fact be dead.
-func TakeMaster(instanceKey *InstanceKey, allowTakingCoMaster bool) (*Instance, error) {
+// With requireInstanceMaintenance, maintenance is taken on both instance and its master. Recovery does not take it:
+// an unrelated maintenance must not block promotion of the recovery's candidate.
+// With dryRun, sanity checks are made but no change is made.
+func TakeMaster(instanceKey *InstanceKey, allowTakingCoMaster bool, requireInstanceMaintenance bool, dryRun bool) (*Instance, error) {
 	instance, err := ReadTopologyInstance(instanceKey)
 	if err != nil {
 		return instance, err
 	}
+	if err := CheckCanPromoteReplicationGroupMember(instance, "take-master"); err != nil {
+		return instance, err
+	}
+	if err := CheckCanRelocateReplica(instance, "take-master"); err != nil {
+		return instance, err
+	}
 	masterInstance, found, err := ReadInstance(&instance.MasterKey)
 	if err != nil || !found {
 		return instance, err
@@ -1727,6 +1996,22 @@ func TakeMaster(instanceKey *InstanceKey, allowTakingCoMaster bool) (*Instance,
 	if canReplicate, err := masterInstance.CanReplicateFrom(instance); canReplicate == false {
 		return instance, err
 	}
+	if dryRun {
+		log.Infof("TakeMaster: dry-run: %+v would take its master %+v", *instanceKey, masterInstance.Key)
+		return instance, nil
+	}
+	if requireInstanceMaintenance {
+		if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), fmt.Sprintf("take master %+v", masterInstance.Key)); merr != nil {
+			return instance, fmt.Errorf("Cannot begin maintenance on %+v: %+v", *instanceKey, merr)
+		} else {
+			defer EndMaintenance(maintenanceToken)
+		}
+		if maintenanceToken, merr := BeginMaintenance(&masterInstance.Key, GetMaintenanceOwner(), fmt.Sprintf("%+v takes this master", *instanceKey)); merr != nil {
+			return instance, fmt.Errorf("Cannot begin maintenance on %+v: %+v", masterInstance.Key, merr)
+		} else {
+			defer EndMaintenance(maintenanceToken)
+		}
+	}
 	// We begin
 	masterInstance, err = StopSlave(&masterInstance.Key)
 	if err != nil {
@@ -1785,6 +2070,9 @@ func MakeLocalMaster(instanceKey *InstanceKey) (*Instance, error) {
 	if err != nil {
 		return instance, err
 	}
+	if err := CheckCanPromoteReplicationGroupMember(instance, "make-local-master"); err != nil {
+		return instance, err
+	}
 	masterInstance, found, err := ReadInstance(&instance.MasterKey)
 	if err != nil || !found {
 		return instance, err
@@ -1808,7 +2096,7 @@ func MakeLocalMaster(instanceKey *InstanceKey) (*Instance, error) {
 		goto Cleanup
 	}
 
-	_, _, err = MatchBelow(instanceKey, &grandparentInstance.Key, true)
+	_, _, err = matchBelowWithChecks(instanceKey, &grandparentInstance.Key, true)
 	if err != nil {
 		goto Cleanup
 	}
@@ -1910,7 +2198,7 @@ func MultiMatchBelow(replicas [](*Instance), belowKey *InstanceKey, postponedFun
 		go func() {
 			defer func() { barrier <- &replica.Key }()
 			matchFunc := func() error {
-				replica, _, replicaErr := MatchBelow(&replica.Key, belowKey, true)
+				replica, _, replicaErr := matchBelow(&replica.Key, belowKey, true)
 
 				replicaMutex.Lock()
 				defer replicaMutex.Unlock()
@@ -1943,7 +2231,16 @@ func MultiMatchBelow(replicas [](*Instance), belowKey *InstanceKey, postponedFun
 }
 
 // MultiMatchReplicas will match (via pseudo-gtid) all replicas of given master below given instance.
-func MultiMatchReplicas(masterKey *InstanceKey, belowKey *InstanceKey, pattern string) ([](*Instance), *Instance, error, []error) {
+// Matching below an instance of a different cluster is refused unless allowCrossCluster.
+func MultiMatchReplicas(masterKey *InstanceKey, belowKey *InstanceKey, pattern string, allowCrossCluster bool) ([](*Instance), *Instance, error, []error) {
+	if err := checkCrossClusterMove("match-replicas", masterKey, belowKey, allowCrossCluster); err != nil {
+		return [](*Instance){}, nil, err, []error{}
+	}
+	return multiMatchReplicas(masterKey, belowKey, pattern)
+}
+
+// multiMatchReplicas is the implementation of MultiMatchReplicas, without the cross-cluster check
+func multiMatchReplicas(masterKey *InstanceKey, belowKey *InstanceKey, pattern string) ([](*Instance), *Instance, error, []error) {
 	res := [](*Instance){}
 	errs := []error{}
 
@@ -1974,7 +2271,7 @@ func MultiMatchReplicas(masterKey *InstanceKey, belowKey *InstanceKey, pattern s
 		binlogCase = true
 	}
 	if binlogCase {
-		replicas, err, errors := RepointReplicasTo(masterKey, pattern, belowKey)
+		replicas, err, errors := repointReplicasTo(masterKey, pattern, belowKey)
 		// Bail out!
 		return replicas, masterInstance, err, errors
 	}
@@ -2015,7 +2312,7 @@ func MatchUp(instanceKey *InstanceKey, requireInstanceMaintenance bool) (*Instan
 		return instance, nil, fmt.Errorf("master is not a replica itself: %+v", master.Key)
 	}
 
-	return MatchBelow(instanceKey, &master.MasterKey, requireInstanceMaintenance)
+	return matchBelowWithChecks(instanceKey, &master.MasterKey, requireInstanceMaintenance)
 }
 
 // MatchUpReplicas will move all replicas of given master up the replication chain,
@@ -2030,7 +2327,7 @@ func MatchUpReplicas(masterKey *InstanceKey, pattern string) ([](*Instance), *In
 		return res, nil, err, errs
 	}
 
-	return MultiMatchReplicas(masterKey, &masterInstance.MasterKey, pattern)
+	return multiMatchReplicas(masterKey, &masterInstance.MasterKey, pattern)
 }
 
 func isGenerallyValidAsBinlogSource(replica *Instance) bool {
@@ -2057,6 +2354,10 @@ func isGenerallyValidAsCandidateReplica(replica *Instance) bool {
 		// Can't regroup under a binlog server because it does not support pseudo-gtid related queries such as SHOW BINLOG EVENTS
 		return false
 	}
+	if replica.IgnoreHealthChecks {
+		// Not polled: its last known state may be long stale, and orchestrator may not even be able to reach it
+		return false
+	}
 
 	return true
 }
@@ -2133,6 +2434,36 @@ func getPriorityBinlogFormatForCandidate(replicas [](*Instance)) (priorityBinlog
 	return sorted.First(), nil
 }
 
+// candidateReplicaDisqualification returns the reason for which given replica may not be chosen as candidate to
+// take over its siblings, or empty string when it can be chosen. This is the gating logic of chooseCandidateReplica.
+func candidateReplicaDisqualification(replica *Instance, priorityMajorVersion string, priorityBinlogFormat string, allowDelayed bool) string {
+	if !replica.IsLastCheckValid {
+		return "last check is invalid"
+	}
+	if !replica.LogBinEnabled {
+		return "binary logs are disabled"
+	}
+	if !replica.LogSlaveUpdatesEnabled {
+		return "log_slave_updates is disabled"
+	}
+	if replica.IsBinlogServer() {
+		return "is a binlog server"
+	}
+	if IsBannedFromBeingCandidateReplica(replica) {
+		return fmt.Sprintf("banned from promotion: promotion rule is %s or hostname matches PromotionIgnoreHostnameFilters", replica.PromotionRule)
+	}
+	if !allowDelayed && replica.SQLDelay > 0 {
+		return fmt.Sprintf("intentionally delayed by %d seconds", replica.SQLDelay)
+	}
+	if IsSmallerMajorVersion(priorityMajorVersion, replica.MajorVersionString()) {
+		return fmt.Sprintf("major version %s is newer than prevailing %s", replica.MajorVersionString(), priorityMajorVersion)
+	}
+	if IsSmallerBinlogFormat(priorityBinlogFormat, replica.Binlog_format) {
+		return fmt.Sprintf("binlog format %s cannot feed prevailing %s", replica.Binlog_format, priorityBinlogFormat)
+	}
+	return ""
+}
+
 // chooseCandidateReplica
 func chooseCandidateReplica(replicas [](*Instance)) (candidateReplica *Instance, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas [](*Instance), err error) {
 	if len(replicas) == 0 {
@@ -2141,25 +2472,33 @@ func chooseCandidateReplica(replicas [](*Instance)) (candidateReplica *Instance,
 	priorityMajorVersion, _ := getPriorityMajorVersionForCandidate(replicas)
 	priorityBinlogFormat, _ := getPriorityBinlogFormatForCandidate(replicas)
 
-	for _, replica := range replicas {
-		replica := replica
-		if isGenerallyValidAsCandidateReplica(replica) &&
-			!IsBannedFromBeingCandidateReplica(replica) &&
-			!IsSmallerMajorVersion(priorityMajorVersion, replica.MajorVersionString()) &&
-			!IsSmallerBinlogFormat(priorityBinlogFormat, replica.Binlog_format) {
-			// this is the one
-			candidateReplica = replica
+	// Intentionally delayed replicas (MASTER_DELAY) are treated as must_not, unless there is no other candidate
+	for _, allowDelayed := range []bool{false, true} {
+		for _, replica := range replicas {
+			replica := replica
+			if candidateReplicaDisqualification(replica, priorityMajorVersion, priorityBinlogFormat, allowDelayed) == "" {
+				// this is the one
+				candidateReplica = replica
+				break
+			}
+		}
+		if candidateReplica != nil {
 			break
 		}
 	}
 	if candidateReplica == nil {
 		// Unable to find a candidate that will master others.
-		// Instead, pick a (single) replica which is not banned.
-		for _, replica := range replicas {
-			replica := replica
-			if !IsBannedFromBeingCandidateReplica(replica) {
-				// this is the one
-				candidateReplica = replica
+		// Instead, pick a (single) replica which is not banned, preferably not delayed.
+		for _, allowDelayed := range []bool{false, true} {
+			for _, replica := range replicas {
+				replica := replica
+				if !IsBannedFromBeingCandidateReplica(replica) && (allowDelayed || replica.SQLDelay == 0) {
+					// this is the one
+					candidateReplica = replica
+					break
+				}
+			}
+			if candidateReplica != nil {
 				break
 			}
 		}
@@ -2401,7 +2740,7 @@ func RegroupReplicasPseudoGTIDIncludingSubReplicasOfBinlogServers(
 		if candidateReplica.ExecBinlogCoordinates.SmallerThan(&mostUpToDateBinlogServer.ExecBinlogCoordinates) {
 			log.Debugf("RegroupReplicasIncludingSubReplicasOfBinlogServers: candidate replica %+v coordinates smaller than binlog server %+v", candidateReplica.Key, mostUpToDateBinlogServer.Key)
 			// Need to align under binlog server...
-			candidateReplica, err = Repoint(&candidateReplica.Key, &mostUpToDateBinlogServer.Key, GTIDHintDeny)
+			candidateReplica, err = repoint(&candidateReplica.Key, &mostUpToDateBinlogServer.Key, GTIDHintDeny)
 			if err != nil {
 				return log.Errore(err)
 			}
@@ -2412,7 +2751,7 @@ func RegroupReplicasPseudoGTIDIncludingSubReplicasOfBinlogServers(
 			}
 			log.Debugf("RegroupReplicasIncludingSubReplicasOfBinlogServers: aligned candidate replica %+v under binlog server %+v", candidateReplica.Key, mostUpToDateBinlogServer.Key)
 			// and move back
-			candidateReplica, err = Repoint(&candidateReplica.Key, masterKey, GTIDHintDeny)
+			candidateReplica, err = repoint(&candidateReplica.Key, masterKey, GTIDHintDeny)
 			if err != nil {
 				return log.Errore(err)
 			}
@@ -2425,7 +2764,7 @@ func RegroupReplicasPseudoGTIDIncludingSubReplicasOfBinlogServers(
 			log.Debugf("RegroupReplicasIncludingSubReplicasOfBinlogServers: matching replicas of binlog server %+v below %+v", binlogServer.Key, candidateReplica.Key)
 			// Right now sequentially.
 			// At this point just do what you can, don't return an error
-			MultiMatchReplicas(&binlogServer.Key, &candidateReplica.Key, "")
+			multiMatchReplicas(&binlogServer.Key, &candidateReplica.Key, "")
 			log.Debugf("RegroupReplicasIncludingSubReplicasOfBinlogServers: done matching replicas of binlog server %+v below %+v", binlogServer.Key, candidateReplica.Key)
 		}
 		log.Debugf("RegroupReplicasIncludingSubReplicasOfBinlogServers: done handling binlog regrouping for %+v; will proceed with normal RegroupReplicas", *masterKey)
@@ -2484,6 +2823,72 @@ func RegroupReplicasGTID(
 	return unmovedReplicas, movedReplicas, cannotReplicateReplicas, candidateReplica, err
 }
 
+// RechainBinlogServersViaGTID re-chains the binlog servers which replicate from given master below given instance.
+// Binlog servers do not support GTID, and regrouping replicas via GTID leaves them, and their replicas, behind.
+// The replicas of the binlog servers are moved below given instance via GTID, and remain there; the binlog servers
+// are then pointed below given instance at its current binary log coordinates.
+func RechainBinlogServersViaGTID(masterKey *InstanceKey, other *Instance, postponedFunctionsContainer *PostponedFunctionsContainer) (rechainedBinlogServers [](*Instance), movedReplicas [](*Instance), unmovedReplicas [](*Instance), err error) {
+	binlogServers, err := ReadBinlogServerReplicaInstances(masterKey)
+	if err != nil {
+		return rechainedBinlogServers, movedReplicas, unmovedReplicas, log.Errore(err)
+	}
+	var replicas [](*Instance)
+	for _, binlogServer := range binlogServers {
+		binlogServerReplicas, err := ReadReplicaInstances(&binlogServer.Key)
+		if err != nil {
+			return rechainedBinlogServers, movedReplicas, unmovedReplicas, log.Errore(err)
+		}
+		for _, replica := range binlogServerReplicas {
+			if replica.UsingGTID() {
+				replicas = append(replicas, replica)
+			} else {
+				unmovedReplicas = append(unmovedReplicas, replica)
+			}
+		}
+	}
+	movedReplicas, unmovedGTIDReplicas, err, _ := moveReplicasViaGTID(replicas, other, postponedFunctionsContainer)
+	unmovedReplicas = append(unmovedReplicas, unmovedGTIDReplicas...)
+
+	for _, binlogServer := range binlogServers {
+		rechainedBinlogServer, rechainErr := rechainBinlogServer(&binlogServer.Key, &other.Key)
+		if rechainErr != nil {
+			err = rechainErr
+			continue
+		}
+		rechainedBinlogServers = append(rechainedBinlogServers, rechainedBinlogServer)
+	}
+	AuditOperation("rechain-binlog-servers-gtid", masterKey, fmt.Sprintf("re-chained %d of %d binlog servers below %+v; moved %d of their replicas below %+v via GTID; %d not moved", len(rechainedBinlogServers), len(binlogServers), other.Key, len(movedReplicas), other.Key, len(unmovedReplicas)))
+	return rechainedBinlogServers, movedReplicas, unmovedReplicas, log.Errore(err)
+}
+
+// rechainBinlogServer points a binlog server below given master, at the master's current binary log coordinates.
+// A binlog server serves its master's binary logs as they are, and so cannot resume from its previous coordinates.
+func rechainBinlogServer(binlogServerKey *InstanceKey, masterKey *InstanceKey) (*Instance, error) {
+	master, err := ReadTopologyInstance(masterKey)
+	if err != nil {
+		return nil, log.Errore(err)
+	}
+	if maintenanceToken, merr := BeginMaintenance(binlogServerKey, GetMaintenanceOwner(), fmt.Sprintf("rechain below %+v", *masterKey)); merr != nil {
+		return nil, fmt.Errorf("Cannot begin maintenance on %+v: %+v", *binlogServerKey, merr)
+	} else {
+		defer EndMaintenance(maintenanceToken)
+	}
+	binlogServer, err := StopSlave(binlogServerKey)
+	if err != nil {
+		return binlogServer, log.Errore(err)
+	}
+	binlogServer, err = ChangeMasterTo(binlogServerKey, masterKey, &master.SelfBinlogCoordinates, false, GTIDHintDeny)
+	if err != nil {
+		return binlogServer, log.Errore(err)
+	}
+	binlogServer, err = StartSlave(binlogServerKey)
+	if err != nil {
+		return binlogServer, log.Errore(err)
+	}
+	AuditOperation("rechain-binlog-server", binlogServerKey, fmt.Sprintf("binlog server %+v re-chained below %+v at %+v", *binlogServerKey, *masterKey, master.SelfBinlogCoordinates))
+	return binlogServer, nil
+}
+
 // RegroupReplicasBinlogServers works on a binlog-servers topology. It picks the most up-to-date BLS and repoints all other
 // BLS below it
 func RegroupReplicasBinlogServers(masterKey *InstanceKey, returnReplicaEvenOnFailureToRegroup bool) (repointedBinlogServers [](*Instance), promotedBinlogServer *Instance, err error) {
@@ -2571,103 +2976,136 @@ func RegroupReplicas(masterKey *InstanceKey, returnReplicaEvenOnFailureToRegroup
 
 // relocateBelowInternal is a protentially recursive function which chooses how to relocate an instance below another.
 // It may choose to use Pseudo-GTID, or normal binlog positions, or take advantage of binlog servers,
-// or it may combine any of the above in a multi-step operation.
-func relocateBelowInternal(instance, other *Instance) (*Instance, error) {
+// or it may combine any of the above in a multi-step operation. Returns the method used.
+func relocateBelowInternal(instance, other *Instance) (*Instance, string, error) {
 	if canReplicate, err := instance.CanReplicateFrom(other); !canReplicate {
-		return instance, log.Errorf("%+v cannot replicate from %+v. Reason: %+v", instance.Key, other.Key, err)
+		return instance, "", log.Errorf("%+v cannot replicate from %+v. Reason: %+v", instance.Key, other.Key, err)
 	}
 	// simplest:
 	if InstanceIsMasterOf(other, instance) {
 		// already the desired setup.
-		return Repoint(&instance.Key, &other.Key, GTIDHintNeutral)
+		instance, err := repoint(&instance.Key, &other.Key, GTIDHintNeutral)
+		return instance, "repoint", err
 	}
 	// Do we have record of equivalent coordinates?
 	if !instance.IsBinlogServer() {
-		if movedInstance, err := MoveEquivalent(&instance.Key, &other.Key); err == nil {
-			return movedInstance, nil
+		if movedInstance, err := moveEquivalent(&instance.Key, &other.Key); err == nil {
+			return movedInstance, "equivalence", nil
 		}
 	}
 	// Try and take advantage of binlog servers:
 	if InstancesAreSiblings(instance, other) && other.IsBinlogServer() {
-		return MoveBelow(&instance.Key, &other.Key)
+		instance, err := MoveBelow(&instance.Key, &other.Key)
+		return instance, "binlog-server", err
 	}
 	instanceMaster, _, err := ReadInstance(&instance.MasterKey)
 	if err != nil {
-		return instance, err
+		return instance, "", err
 	}
 	if instanceMaster != nil && instanceMaster.MasterKey.Equals(&other.Key) && instanceMaster.IsBinlogServer() {
 		// Moving to grandparent via binlog server
-		return Repoint(&instance.Key, &instanceMaster.MasterKey, GTIDHintDeny)
+		instance, err := repoint(&instance.Key, &instanceMaster.MasterKey, GTIDHintDeny)
+		return instance, "binlog-server", err
 	}
 	if other.IsBinlogServer() {
 		if instanceMaster != nil && instanceMaster.IsBinlogServer() && InstancesAreSiblings(instanceMaster, other) {
 			// Special case: this is a binlog server family; we move under the uncle, in one single step
-			return Repoint(&instance.Key, &other.Key, GTIDHintDeny)
+			instance, err := repoint(&instance.Key, &other.Key, GTIDHintDeny)
+			return instance, "binlog-server", err
 		}
 
 		// Relocate to its master, then repoint to the binlog server
 		otherMaster, found, err := ReadInstance(&other.MasterKey)
 		if err != nil {
-			return instance, err
+			return instance, "", err
 		}
 		if !found {
-			return instance, log.Errorf("Cannot find master %+v", other.MasterKey)
+			return instance, "", log.Errorf("Cannot find master %+v", other.MasterKey)
 		}
 		if !other.IsLastCheckValid {
-			return instance, log.Errorf("Binlog server %+v is not reachable. It would take two steps to relocate %+v below it, and I won't even do the first step.", other.Key, instance.Key)
+			return instance, "", log.Errorf("Binlog server %+v is not reachable. It would take two steps to relocate %+v below it, and I won't even do the first step.", other.Key, instance.Key)
 		}
 
 		log.Debugf("Relocating to a binlog server; will first attempt to relocate to the binlog server's master: %+v, and then repoint down", otherMaster.Key)
-		if _, err := relocateBelowInternal(instance, otherMaster); err != nil {
-			return instance, err
+		_, method, err := relocateBelowInternal(instance, otherMaster)
+		if err != nil {
+			return instance, "", err
 		}
-		return Repoint(&instance.Key, &other.Key, GTIDHintDeny)
+		instance, err = repoint(&instance.Key, &other.Key, GTIDHintDeny)
+		return instance, method + ",binlog-server", err
 	}
 	if instance.IsBinlogServer() {
 		// Can only move within the binlog-server family tree
 		// And these have been covered just now: move up from a master binlog server, move below a binling binlog server.
 		// sure, the family can be more complex, but we keep these operations atomic
-		return nil, log.Errorf("Relocating binlog server %+v below %+v turns to be too complex; please do it manually", instance.Key, other.Key)
+		return nil, "", log.Errorf("Relocating binlog server %+v below %+v turns to be too complex; please do it manually", instance.Key, other.Key)
 	}
 	// Next, try GTID
 	if _, _, gtidCompatible := instancesAreGTIDAndCompatible(instance, other); gtidCompatible {
-		return moveInstanceBelowViaGTID(instance, other)
+		if err := checkRelocateViaGTID(instance, other); err != nil {
+			log.Infof("Will not relocate %+v below %+v via GTID: %+v", instance.Key, other.Key, err)
+		} else {
+			instance, err := moveInstanceBelowViaGTID(instance, other)
+			return instance, "gtid", err
+		}
 	}
 
 	// Next, try Pseudo-GTID
 	if instance.UsingPseudoGTID && other.UsingPseudoGTID {
 		// We prefer PseudoGTID to anything else because, while it takes longer to run, it does not issue
 		// a STOP SLAVE on any server other than "instance" itself.
-		instance, _, err := MatchBelow(&instance.Key, &other.Key, true)
-		return instance, err
+		instance, _, err := matchBelowWithChecks(&instance.Key, &other.Key, true)
+		return instance, "pseudo-gtid", err
 	}
 	// No Pseudo-GTID; cehck simple binlog file/pos operations:
 	if InstancesAreSiblings(instance, other) {
 		// If comastering, only move below if it's read-only
 		if !other.IsCoMaster || other.ReadOnly {
-			return MoveBelow(&instance.Key, &other.Key)
+			instance, err := MoveBelow(&instance.Key, &other.Key)
+			return instance, "binlog-coordinates", err
 		}
 	}
 	// See if we need to MoveUp
 	if instanceMaster != nil && instanceMaster.MasterKey.Equals(&other.Key) {
 		// Moving to grandparent--handles co-mastering writable case
-		return MoveUp(&instance.Key)
+		instance, err := MoveUp(&instance.Key)
+		return instance, "binlog-coordinates", err
 	}
 	if instanceMaster != nil && instanceMaster.IsBinlogServer() {
 		// Break operation into two: move (repoint) up, then continue
 		if _, err := MoveUp(&instance.Key); err != nil {
-			return instance, err
+			return instance, "", err
 		}
-		return relocateBelowInternal(instance, other)
+		instance, method, err := relocateBelowInternal(instance, other)
+		return instance, "binlog-server," + method, err
 	}
 	// Too complex
-	return nil, log.Errorf("Relocating %+v below %+v turns to be too complex; please do it manually", instance.Key, other.Key)
+	return nil, "", log.Errorf("Relocating %+v below %+v turns to be too complex; please do it manually", instance.Key, other.Key)
+}
+
+// checkRelocateViaGTID checks whether relocating an instance below another may
+// use GTID: on top of the instances being GTID compatible, the other instance
+// must not have purged GTID entries the instance has not executed, and with
+// Oracle GTID, the instance must not have errant GTID, which would otherwise
+// find its way to the other instance's replication chain.
+func checkRelocateViaGTID(instance, other *Instance) error {
+	if err := CheckMoveViaGTID(instance, other); err != nil {
+		return err
+	}
+	if instance.UsingOracleGTID && instance.GtidErrant != "" {
+		return fmt.Errorf("%+v has errant GTID: %s", instance.Key, instance.GtidErrant)
+	}
+	return nil
 }
 
 // RelocateBelow will attempt moving instance indicated by instanceKey below another instance.
 // Orchestrator will try and figure out the best way to relocate the server. This could span normal
 // binlog-position, pseudo-gtid, repointing, binlog servers...
-func RelocateBelow(instanceKey, otherKey *InstanceKey) (*Instance, error) {
+// Relocating below an instance of a different cluster is refused unless allowCrossCluster.
+func RelocateBelow(instanceKey, otherKey *InstanceKey, allowCrossCluster bool) (*Instance, error) {
+	if err := checkCrossClusterMove("relocate", instanceKey, otherKey, allowCrossCluster); err != nil {
+		return nil, err
+	}
 	instance, found, err := ReadInstance(instanceKey)
 	if err != nil || !found {
 		return instance, log.Errorf("Error reading %+v", *instanceKey)
@@ -2679,9 +3117,15 @@ func RelocateBelow(instanceKey, otherKey *InstanceKey) (*Instance, error) {
 	if other.IsDescendantOf(instance) {
 		return instance, log.Errorf("relocate: %+v is a descendant of %+v", *otherKey, instance.Key)
 	}
-	instance, err = relocateBelowInternal(instance, other)
+	if err := checkMoveReplicationFilters(instance, other); err != nil {
+		return instance, err
+	}
+	if err := checkMoveVersionOrdering(instance, other); err != nil {
+		return instance, err
+	}
+	instance, method, err := relocateBelowInternal(instance, other)
 	if err == nil {
-		AuditOperation("relocate-below", instanceKey, fmt.Sprintf("relocated %+v below %+v", *instanceKey, *otherKey))
+		AuditOperation("relocate-below", instanceKey, fmt.Sprintf("relocated %+v below %+v via %s", *instanceKey, *otherKey, method))
 	}
 	return instance, err
 }
@@ -2764,7 +3208,16 @@ func relocateReplicasInternal(replicas [](*Instance), instance, other *Instance)
 // RelocateReplicas will attempt moving replicas of an instance indicated by instanceKey below another instance.
 // Orchestrator will try and figure out the best way to relocate the servers. This could span normal
 // binlog-position, pseudo-gtid, repointing, binlog servers...
-func RelocateReplicas(instanceKey, otherKey *InstanceKey, pattern string) (replicas [](*Instance), other *Instance, err error, errs []error) {
+// Relocating below an instance of a different cluster is refused unless allowCrossCluster.
+func RelocateReplicas(instanceKey, otherKey *InstanceKey, pattern string, allowCrossCluster bool) (replicas [](*Instance), other *Instance, err error, errs []error) {
+	if err := checkCrossClusterMove("relocate-replicas", instanceKey, otherKey, allowCrossCluster); err != nil {
+		return replicas, other, err, errs
+	}
+	return relocateReplicas(instanceKey, otherKey, pattern)
+}
+
+// relocateReplicas is the implementation of RelocateReplicas, without the cross-cluster check
+func relocateReplicas(instanceKey, otherKey *InstanceKey, pattern string) (replicas [](*Instance), other *Instance, err error, errs []error) {
 
 	instance, found, err := ReadInstance(instanceKey)
 	if err != nil || !found {
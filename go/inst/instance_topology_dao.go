@@ -404,11 +404,54 @@ func StopSlave(instanceKey *InstanceKey) (*Instance, error) {
 		return instance, log.Errore(err)
 	}
 	instance, err = ReadTopologyInstance(instanceKey)
+	if err == nil && instance.HasReplicationGaps {
+		// Executed coordinates are meaningless while parallel replication gaps exist
+		instance, err = CloseReplicationGaps(instanceKey)
+	}
 
 	log.Infof("Stopped replication on %+v, Self:%+v, Exec:%+v", *instanceKey, instance.SelfBinlogCoordinates, instance.ExecBinlogCoordinates)
 	return instance, err
 }
 
+// CloseReplicationGaps runs the SQL thread of a stopped, parallel replicating, replica until gaps left behind by
+// its workers are filled, such that its executed coordinates are consistent, then waits for the SQL thread to stop.
+func CloseReplicationGaps(instanceKey *InstanceKey) (*Instance, error) {
+	instance, err := ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return instance, log.Errore(err)
+	}
+	if !instance.HasReplicationGaps {
+		return instance, nil
+	}
+	if instance.ReplicationSQLThreadState.IsRunning() {
+		return instance, fmt.Errorf("CloseReplicationGaps: SQL thread is running on %+v", *instanceKey)
+	}
+	log.Infof("Closing parallel replication gaps on %+v", *instanceKey)
+	if _, err := ExecInstance(instanceKey, `start slave sql_thread until sql_after_mts_gaps`); err != nil {
+		return instance, log.Errore(err)
+	}
+	timeout := time.Duration(config.Config.InstanceBulkOperationsWaitTimeoutSeconds) * time.Second
+	startTime := time.Now()
+	for {
+		instance, err = ReadTopologyInstance(instanceKey)
+		if err != nil {
+			return instance, log.Errore(err)
+		}
+		if !instance.ReplicationSQLThreadState.IsRunning() {
+			break
+		}
+		if time.Since(startTime) > timeout {
+			return instance, log.Errorf("CloseReplicationGaps: timeout waiting for SQL thread to stop on %+v", *instanceKey)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if instance.HasReplicationGaps {
+		return instance, log.Errorf("CloseReplicationGaps: gaps remain on %+v; last SQL error: %s", *instanceKey, instance.LastSQLError)
+	}
+	AuditOperation("close-replication-gaps", instanceKey, fmt.Sprintf("Exec: %+v", instance.ExecBinlogCoordinates))
+	return instance, nil
+}
+
 // waitForReplicationState waits for both replication threads to be either running or not running, together.
 // This is useful post- `start slave` operation, ensuring both threads are actually running,
 // or post `stop slave` operation, ensuring both threads are not running.
@@ -587,6 +630,41 @@ func EnableSemiSync(instanceKey *InstanceKey, master, slave bool) error {
 	return err
 }
 
+// ResetReplicationDelay issues CHANGE MASTER TO MASTER_DELAY=0 on a delayed replica, then lets its
+// SQL thread apply all relay logs, waiting up to given timeout (0 for no limit). Replication is left stopped.
+// This is used when a delayed replica is about to be promoted.
+func ResetReplicationDelay(instanceKey *InstanceKey, timeout time.Duration) (*Instance, error) {
+	instance, err := ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return instance, log.Errore(err)
+	}
+	if !instance.ReplicationThreadsExist() {
+		return instance, fmt.Errorf("instance is not a replica: %+v", instanceKey)
+	}
+	if instance.SQLDelay == 0 {
+		return instance, nil
+	}
+	sqlDelay := instance.SQLDelay
+
+	if *config.RuntimeCLIFlags.Noop {
+		return instance, fmt.Errorf("noop: aborting CHANGE MASTER TO MASTER_DELAY=0 operation on %+v; signaling error but nothing went wrong.", *instanceKey)
+	}
+	for _, cmd := range []string{`stop slave`, `change master to master_delay=0`, `start slave sql_thread`} {
+		if _, err := ExecInstance(instanceKey, cmd); err != nil {
+			return instance, log.Errorf("%+v: ResetReplicationDelay: '%q' failed: %+v", *instanceKey, cmd, err)
+		}
+	}
+	if instance, err = WaitForSQLThreadUpToDate(instanceKey, timeout, 0); err != nil {
+		return instance, err
+	}
+	if _, err := ExecInstance(instanceKey, `stop slave`); err != nil {
+		return instance, log.Errore(err)
+	}
+
+	AuditOperation("reset-replication-delay", instanceKey, fmt.Sprintf("reset MASTER_DELAY from %d seconds to 0", sqlDelay))
+	return ReadTopologyInstance(instanceKey)
+}
+
 // ChangeMasterCredentials issues a CHANGE MASTER TO... MASTER_USER=, MASTER_PASSWORD=...
 func ChangeMasterCredentials(instanceKey *InstanceKey, masterUser string, masterPassword string) (*Instance, error) {
 	instance, err := ReadTopologyInstance(instanceKey)
@@ -668,6 +746,12 @@ func ChangeMasterTo(instanceKey *InstanceKey, masterKey *InstanceKey, masterBinl
 		return instance, log.Errore(err)
 	}
 
+	if err := CheckCanRelocateReplica(instance, "ChangeMasterTo"); err != nil {
+		return instance, err
+	}
+	if err := CheckReplicationCycle(instanceKey, masterKey); err != nil {
+		return instance, log.Errore(err)
+	}
 	if instance.ReplicationThreadsExist() && !instance.ReplicationThreadsStopped() {
 		return instance, fmt.Errorf("ChangeMasterTo: Cannot change master on: %+v because replication threads are not stopped", *instanceKey)
 	}
@@ -695,9 +779,10 @@ func ChangeMasterTo(instanceKey *InstanceKey, masterKey *InstanceKey, masterBinl
 	var changeMasterFunc func() error
 	changedViaGTID := false
 	if instance.UsingMariaDBGTID && gtidHint != GTIDHintDeny {
-		// Keep on using GTID
+		// Keep on using GTID. Explicitly use slave_pos: current_pos would include the replica's own
+		// binlog events, which the new master does not have
 		changeMasterFunc = func() error {
-			_, err := ExecInstance(instanceKey, "change master to master_host=?, master_port=?",
+			_, err := ExecInstance(instanceKey, "change master to master_host=?, master_port=?, master_use_gtid=slave_pos",
 				changeToMasterKey.Hostname, changeToMasterKey.Port)
 			return err
 		}
@@ -757,6 +842,12 @@ func ChangeMasterTo(instanceKey *InstanceKey, masterKey *InstanceKey, masterBinl
 	if err != nil {
 		return instance, log.Errore(err)
 	}
+	if instance.AllowTLS {
+		// Explicitly keep SSL replication, which a master requiring secure transport depends on
+		if _, err := ExecInstance(instanceKey, "change master to master_ssl=1, master_ssl_cipher=?", instance.ReplicationSSLCipher); err != nil {
+			return instance, log.Errore(err)
+		}
+	}
 	WriteMasterPositionEquivalence(&originalMasterKey, &originalExecBinlogCoordinates, changeToMasterKey, masterBinlogCoordinates)
 	ResetInstanceRelaylogCoordinatesHistory(instanceKey)
 
@@ -863,6 +954,12 @@ func setGTIDPurged(instance *Instance, gtidPurged string) error {
 	return err
 }
 
+// setGTIDNextStatement returns a SET GTID_NEXT statement for given GTID entry, or for AUTOMATIC.
+// The value is single quoted: double quoted strings are identifiers under ANSI_QUOTES sql_mode.
+func setGTIDNextStatement(gtidNext string) string {
+	return fmt.Sprintf(`SET GTID_NEXT='%s'`, gtidNext)
+}
+
 // injectEmptyGTIDTransaction
 func injectEmptyGTIDTransaction(instanceKey *InstanceKey, gtidEntry *OracleGtidSetEntry) error {
 	db, err := db.OpenTopology(instanceKey.Hostname, instanceKey.Port)
@@ -876,7 +973,7 @@ func injectEmptyGTIDTransaction(instanceKey *InstanceKey, gtidEntry *OracleGtidS
 	}
 	defer conn.Close()
 
-	if _, err := conn.ExecContext(ctx, fmt.Sprintf(`SET GTID_NEXT="%s"`, gtidEntry.String())); err != nil {
+	if _, err := conn.ExecContext(ctx, setGTIDNextStatement(gtidEntry.String())); err != nil {
 		return err
 	}
 	tx, err := conn.BeginTx(ctx, &sql.TxOptions{})
@@ -886,7 +983,7 @@ func injectEmptyGTIDTransaction(instanceKey *InstanceKey, gtidEntry *OracleGtidS
 	if err := tx.Commit(); err != nil {
 		return err
 	}
-	if _, err := conn.ExecContext(ctx, `SET GTID_NEXT="AUTOMATIC"`); err != nil {
+	if _, err := conn.ExecContext(ctx, setGTIDNextStatement("AUTOMATIC")); err != nil {
 		return err
 	}
 	return nil
@@ -1023,14 +1120,19 @@ func SetReadOnly(instanceKey *InstanceKey, readOnly bool) (*Instance, error) {
 		}
 	}
 
+	if !readOnly && instance.SuperReadOnly {
+		// super_read_only implies read_only, hence must be disabled first
+		if _, err := ExecInstance(instanceKey, "set global super_read_only = 0"); err != nil {
+			return instance, log.Errore(err)
+		}
+	}
 	if _, err := ExecInstance(instanceKey, "set global read_only = ?", readOnly); err != nil {
 		return instance, log.Errore(err)
 	}
-	if config.Config.UseSuperReadOnly {
-		if _, err := ExecInstance(instanceKey, "set global super_read_only = ?", readOnly); err != nil {
+	if readOnly && config.Config.UseSuperReadOnly {
+		if _, err := ExecInstance(instanceKey, "set global super_read_only = 1"); err != nil {
 			// We don't bail out here. super_read_only is only available on
 			// MySQL 5.7.8 and Percona Server 5.6.21-70
-			// At this time orchestrator does not verify whether a server supports super_read_only or not.
 			// It makes a best effort to set it.
 			log.Errore(err)
 		}
@@ -1079,6 +1181,15 @@ func KillQuery(instanceKey *InstanceKey, process int64) (*Instance, error) {
 	return instance, err
 }
 
+// injectPseudoGTIDStatement returns the Pseudo-GTID statement for given hint: either AutoPseudoGTIDStatement, or
+// orchestrator's own statement, which quotes identifiers with backticks
+func injectPseudoGTIDStatement(hint string) string {
+	if config.Config.AutoPseudoGTIDStatement != "" {
+		return strings.Replace(config.Config.AutoPseudoGTIDStatement, "{hint}", hint, -1)
+	}
+	return fmt.Sprintf("drop view if exists `%s`.`_asc:%s`", config.PseudoGTIDSchema, hint)
+}
+
 // injectPseudoGTID injects a Pseudo-GTID statement on a writable instance
 func injectPseudoGTID(instance *Instance) (hint string, err error) {
 	if *config.RuntimeCLIFlags.Noop {
@@ -1088,14 +1199,17 @@ func injectPseudoGTID(instance *Instance) (hint string, err error) {
 	now := time.Now()
 	randomHash := util.RandomHash()[0:16]
 	hint = fmt.Sprintf("%.8x:%.8x:%s", now.Unix(), instance.ServerID, randomHash)
-	query := fmt.Sprintf("drop view if exists `%s`.`_asc:%s`", config.PseudoGTIDSchema, hint)
-	_, err = ExecInstance(&instance.Key, query)
+	_, err = ExecInstance(&instance.Key, injectPseudoGTIDStatement(hint))
 	return hint, log.Errore(err)
 }
 
 // canInjectPseudoGTID checks orchestrator's grants to determine whether is has the
 // privilege of auto-injecting pseudo-GTID
 func canInjectPseudoGTID(instanceKey *InstanceKey) (canInject bool, err error) {
+	if config.Config.AutoPseudoGTIDStatement != "" {
+		// Grants required by a custom statement are unknown; a failing injection tells
+		return true, nil
+	}
 	if canInject, found := supportedAutoPseudoGTIDWriters.Get(instanceKey.StringCode()); found {
 		return canInject.(bool), nil
 	}
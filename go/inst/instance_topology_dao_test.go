@@ -0,0 +1,145 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package inst
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+// strictSQLModeViolation returns why given statement would be parsed differently under
+// ANSI_QUOTES,NO_BACKSLASH_ESCAPES,PIPES_AS_CONCAT sql_mode, or empty string
+func strictSQLModeViolation(statement string) string {
+	var quote rune
+	previous := ' '
+	for _, c := range statement {
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0 && c == '\\':
+			return "backslash escape under NO_BACKSLASH_ESCAPES"
+		case quote != 0:
+		case c == '\'' || c == '`':
+			quote = c
+		case c == '"':
+			return "double quoted string under ANSI_QUOTES"
+		case c == '|' && previous == '|':
+			return "|| under PIPES_AS_CONCAT"
+		}
+		previous = c
+	}
+	if quote != 0 {
+		return "unterminated quote"
+	}
+	return ""
+}
+
+func TestStrictSQLModeViolation(t *testing.T) {
+	test.S(t).ExpectEquals(strictSQLModeViolation(`SET GTID_NEXT='AUTOMATIC'`), "")
+	test.S(t).ExpectEquals(strictSQLModeViolation("drop view if exists `meta`.`_asc:\"x\"`"), "")
+	test.S(t).ExpectNotEquals(strictSQLModeViolation(`SET GTID_NEXT="AUTOMATIC"`), "")
+	test.S(t).ExpectNotEquals(strictSQLModeViolation(`select 'a\'b'`), "")
+	test.S(t).ExpectNotEquals(strictSQLModeViolation(`select 'a' || 'b'`), "")
+	test.S(t).ExpectNotEquals(strictSQLModeViolation(`select 'a`), "")
+}
+
+func TestStatementsSQLModeAgnostic(t *testing.T) {
+	statements := []string{
+		setGTIDNextStatement("00020192-1111-1111-1111-111111111111:42"),
+		setGTIDNextStatement("AUTOMATIC"),
+		injectPseudoGTIDStatement("5d2f0c6e:00000001:0123456789abcdef"),
+	}
+	channelStatements, err := repointChannelStatements("channel_1")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(channelStatements), 3)
+	statements = append(statements, channelStatements...)
+	for _, statement := range statements {
+		test.S(t).ExpectEquals(strictSQLModeViolation(statement), "")
+	}
+	_, err = repointChannelStatements(`channel_1" or "1`)
+	test.S(t).ExpectNotNil(err)
+}
+
+// issuedStatementLiterals returns the statements this package passes as literals (or as literal fmt.Sprintf formats)
+// to ExecInstance and ExecContext, mapped to their source position
+func issuedStatementLiterals(t *testing.T) map[string]string {
+	statements := make(map[string]string)
+	fileSet := token.NewFileSet()
+	packages, err := parser.ParseDir(fileSet, ".", func(info os.FileInfo) bool { return !strings.HasSuffix(info.Name(), "_test.go") }, 0)
+	test.S(t).ExpectNil(err)
+	for _, pkg := range packages {
+		ast.Inspect(pkg, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok || len(call.Args) < 2 {
+				return true
+			}
+			var name string
+			switch fun := call.Fun.(type) {
+			case *ast.Ident:
+				name = fun.Name
+			case *ast.SelectorExpr:
+				name = fun.Sel.Name
+			}
+			if name != "ExecInstance" && name != "ExecContext" {
+				return true
+			}
+			statement := call.Args[1]
+			if sprintf, ok := statement.(*ast.CallExpr); ok && len(sprintf.Args) > 0 {
+				if selector, ok := sprintf.Fun.(*ast.SelectorExpr); ok && selector.Sel.Name == "Sprintf" {
+					statement = sprintf.Args[0]
+				}
+			}
+			if literal, ok := statement.(*ast.BasicLit); ok && literal.Kind == token.STRING {
+				value, err := strconv.Unquote(literal.Value)
+				test.S(t).ExpectNil(err)
+				statements[value] = fileSet.Position(literal.Pos()).String()
+			}
+			return true
+		})
+	}
+	return statements
+}
+
+func TestIssuedStatementLiteralsSQLModeAgnostic(t *testing.T) {
+	statements := issuedStatementLiterals(t)
+	// sanity: the scan does find statements issued by this package
+	test.S(t).ExpectTrue(len(statements) > 20)
+	_, found := statements["change master to master_host=?, master_port=?, master_auto_position=1"]
+	test.S(t).ExpectTrue(found)
+	for statement, position := range statements {
+		if violation := strictSQLModeViolation(statement); violation != "" {
+			t.Errorf("%s: %s: %s", position, violation, statement)
+		}
+	}
+}
+
+func TestInjectPseudoGTIDStatement(t *testing.T) {
+	defer func(statement string) { config.Config.AutoPseudoGTIDStatement = statement }(config.Config.AutoPseudoGTIDStatement)
+
+	config.Config.AutoPseudoGTIDStatement = ""
+	test.S(t).ExpectEquals(injectPseudoGTIDStatement("5d2f0c6e:00000001:0123456789abcdef"), "drop view if exists `_pseudo_gtid_`.`_asc:5d2f0c6e:00000001:0123456789abcdef`")
+
+	config.Config.AutoPseudoGTIDStatement = "drop view if exists `meta`.`_pseudo_gtid_hint__asc:{hint}`"
+	test.S(t).ExpectEquals(injectPseudoGTIDStatement("5d2f0c6e:00000001:0123456789abcdef"), "drop view if exists `meta`.`_pseudo_gtid_hint__asc:5d2f0c6e:00000001:0123456789abcdef`")
+}
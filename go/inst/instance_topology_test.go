@@ -102,6 +102,24 @@ func TestSortInstancesDataCenterHint(t *testing.T) {
 	test.S(t).ExpectEquals(instances[0].Key, i810Key)
 }
 
+func TestSortInstancesSameCoordinatesRecentlyRestarted(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	for _, instance := range instances {
+		instance.ExecBinlogCoordinates = instances[0].ExecBinlogCoordinates
+		instance.RecentlyRestarted = true
+	}
+	instancesMap[i720Key.StringCode()].RecentlyRestarted = false
+	sortInstances(instances)
+	test.S(t).ExpectEquals(instances[0].Key, i720Key)
+}
+
+func TestSortInstancesRecentlyRestartedMoreAdvanced(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	instancesMap[i830Key.StringCode()].RecentlyRestarted = true
+	sortInstances(instances)
+	test.S(t).ExpectEquals(instances[0].Key, i830Key)
+}
+
 func TestSortInstancesGtidErrant(t *testing.T) {
 	instances, instancesMap := generateTestInstances()
 	for _, instance := range instances {
@@ -238,6 +256,12 @@ func TestIsGenerallyValidAsCandidateReplica(t *testing.T) {
 	for _, instance := range instances {
 		test.S(t).ExpectTrue(isGenerallyValidAsCandidateReplica(instance))
 	}
+	for _, instance := range instances {
+		instance.IgnoreHealthChecks = true
+	}
+	for _, instance := range instances {
+		test.S(t).ExpectFalse(isGenerallyValidAsCandidateReplica(instance))
+	}
 }
 
 func TestIsBannedFromBeingCandidateReplica(t *testing.T) {
@@ -481,6 +505,32 @@ func TestChooseCandidateReplicaMustNotPromoteRule(t *testing.T) {
 	test.S(t).ExpectEquals(len(cannotReplicateReplicas), 0)
 }
 
+func TestChooseCandidateReplicaDelayedReplica(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	applyGeneralGoodToGoReplicationParams(instances)
+	instancesMap[i830Key.StringCode()].SQLDelay = 3600
+	instances = sortedReplicas(instances, NoStopReplication)
+	candidate, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, err := chooseCandidateReplica(instances)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(candidate.Key, i820Key)
+	test.S(t).ExpectEquals(len(aheadReplicas), 1)
+	test.S(t).ExpectEquals(len(equalReplicas), 0)
+	test.S(t).ExpectEquals(len(laterReplicas), 4)
+	test.S(t).ExpectEquals(len(cannotReplicateReplicas), 0)
+}
+
+func TestChooseCandidateReplicaOnlyDelayedReplicas(t *testing.T) {
+	instances, _ := generateTestInstances()
+	applyGeneralGoodToGoReplicationParams(instances)
+	for _, instance := range instances {
+		instance.SQLDelay = 3600
+	}
+	instances = sortedReplicas(instances, NoStopReplication)
+	candidate, _, _, _, _, err := chooseCandidateReplica(instances)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(candidate.Key, i830Key)
+}
+
 func TestChooseCandidateReplicaPreferNotPromoteRule(t *testing.T) {
 	instances, instancesMap := generateTestInstances()
 	applyGeneralGoodToGoReplicationParams(instances)
@@ -568,3 +618,35 @@ func TestChooseCandidateReplicaPromoteRuleOrdering3(t *testing.T) {
 	test.S(t).ExpectEquals(len(laterReplicas), 0)
 	test.S(t).ExpectEquals(len(cannotReplicateReplicas), 0)
 }
+
+func TestIsCrossClusterMove(t *testing.T) {
+	instance := &Instance{Key: i710Key, ClusterName: "i710:3306"}
+	other := &Instance{Key: i720Key, ClusterName: "i710:3306"}
+	test.S(t).ExpectFalse(isCrossClusterMove(instance, other, "alpha", "alpha"))
+
+	other.ClusterName = "i810:3306"
+	test.S(t).ExpectTrue(isCrossClusterMove(instance, other, "i710:3306", "i810:3306"))
+	test.S(t).ExpectTrue(isCrossClusterMove(instance, other, "alpha", "beta"))
+	test.S(t).ExpectTrue(isCrossClusterMove(instance, other, "", ""))
+	// Co-masters transiently recorded under different cluster names share the alias
+	test.S(t).ExpectFalse(isCrossClusterMove(instance, other, "alpha", "alpha"))
+
+	other.ClusterName = ""
+	test.S(t).ExpectFalse(isCrossClusterMove(instance, other, "alpha", "beta"))
+}
+
+func TestCheckCoMastersConsistency(t *testing.T) {
+	master := &Instance{Key: InstanceKey{Hostname: "master", Port: 3306}, GTIDMode: "ON", LogSlaveUpdatesEnabled: true, SlaveHosts: make(map[InstanceKey]bool)}
+	instance := &Instance{Key: InstanceKey{Hostname: "replica", Port: 3306}, GTIDMode: "ON"}
+	master.SlaveHosts.AddKey(instance.Key)
+	master.SlaveHosts.AddKey(InstanceKey{Hostname: "other", Port: 3306})
+	test.S(t).ExpectNil(checkCoMastersConsistency(instance, master))
+
+	master.LogSlaveUpdatesEnabled = false
+	test.S(t).ExpectNotNil(checkCoMastersConsistency(instance, master))
+	delete(master.SlaveHosts, InstanceKey{Hostname: "other", Port: 3306})
+	test.S(t).ExpectNil(checkCoMastersConsistency(instance, master))
+
+	instance.GTIDMode = "OFF"
+	test.S(t).ExpectNotNil(checkCoMastersConsistency(instance, master))
+}
@@ -145,6 +145,10 @@ func (this *InstancesSorterByExec) Less(i, j int) bool {
 		if this.instances[j].GtidErrant == "" && this.instances[i].GtidErrant != "" {
 			return true
 		}
+		// Prefer if not recently restarted, e.g. having a warm buffer pool
+		if !this.instances[j].RecentlyRestarted && this.instances[i].RecentlyRestarted {
+			return true
+		}
 		// Prefer candidates:
 		if this.instances[j].PromotionRule.SmallerThan(this.instances[i].PromotionRule) {
 			return true
@@ -153,6 +157,29 @@ func (this *InstancesSorterByExec) Less(i, j int) bool {
 	return this.instances[i].ExecBinlogCoordinates.SmallerThan(&this.instances[j].ExecBinlogCoordinates)
 }
 
+// RemoveRecentlyRestartedInstances returns given instances, excluding those which are recently restarted
+func RemoveRecentlyRestartedInstances(instances [](*Instance)) [](*Instance) {
+	result := [](*Instance){}
+	for _, instance := range instances {
+		if !instance.RecentlyRestarted {
+			result = append(result, instance)
+		}
+	}
+	return result
+}
+
+// RemoveSQLThreadLaggingInstances returns given instances, excluding those whose sql thread lags by more than
+// given number of seconds
+func RemoveSQLThreadLaggingInstances(instances [](*Instance), maxLagSeconds uint) [](*Instance) {
+	result := [](*Instance){}
+	for _, instance := range instances {
+		if !instance.IsSQLThreadLaggingBeyond(maxLagSeconds) {
+			result = append(result, instance)
+		}
+	}
+	return result
+}
+
 // filterInstancesByPattern will filter given array of instances according to regular expression pattern
 func filterInstancesByPattern(instances [](*Instance), pattern string) [](*Instance) {
 	if pattern == "" {
@@ -249,6 +276,20 @@ func IsSmallerBinlogFormat(binlogFormat string, otherBinlogFormat string) bool {
 	return false
 }
 
+// IsSmallerBinlogRowImage tests two binlog row images and sees if one is "smaller" than the other,
+// ordered MINIMAL, NOBLOB, FULL. Row images cannot be enriched as they flow down the topology.
+func IsSmallerBinlogRowImage(binlogRowImage string, otherBinlogRowImage string) bool {
+	binlogRowImage = strings.ToUpper(binlogRowImage)
+	otherBinlogRowImage = strings.ToUpper(otherBinlogRowImage)
+	if binlogRowImage == "MINIMAL" {
+		return (otherBinlogRowImage == "NOBLOB" || otherBinlogRowImage == "FULL")
+	}
+	if binlogRowImage == "NOBLOB" {
+		return otherBinlogRowImage == "FULL"
+	}
+	return false
+}
+
 // RegexpMatchPatterns returns true if s matches any of the provided regexpPatterns
 func RegexpMatchPatterns(s string, regexpPatterns []string) bool {
 	for _, filter := range regexpPatterns {
@@ -1,6 +1,7 @@
 package inst
 
 import (
+	"database/sql"
 	"testing"
 )
 
@@ -28,3 +29,36 @@ func TestRegexpMatchPatterns(t *testing.T) {
 		}
 	}
 }
+
+func TestRemoveSQLThreadLaggingInstances(t *testing.T) {
+	upToDate := &Instance{Key: InstanceKey{Hostname: "up-to-date", Port: 3306}, SecondsBehindMaster: sql.NullInt64{Int64: 0, Valid: true}}
+	lagging := &Instance{Key: InstanceKey{Hostname: "lagging", Port: 3306}, SecondsBehindMaster: sql.NullInt64{Int64: 2400, Valid: true}}
+	unknown := &Instance{Key: InstanceKey{Hostname: "unknown", Port: 3306}}
+	instances := [](*Instance){upToDate, lagging, unknown}
+
+	if result := RemoveSQLThreadLaggingInstances(instances, 0); len(result) != 3 {
+		t.Errorf("RemoveSQLThreadLaggingInstances with no limit: expected 3 instances, got %d", len(result))
+	}
+	result := RemoveSQLThreadLaggingInstances(instances, 60)
+	if len(result) != 2 || result[0] != upToDate || result[1] != unknown {
+		t.Errorf("RemoveSQLThreadLaggingInstances: expected up-to-date and unknown instances, got %+v", result)
+	}
+}
+
+func TestRemoveSQLThreadLaggingInstancesIOThreadDisconnected(t *testing.T) {
+	// Seconds_Behind_Master is NULL, as is the case when the master is dead
+	readCoordinates := BinlogCoordinates{LogFile: "mysql-bin.000017", LogPos: 4000}
+	drained := &Instance{Key: InstanceKey{Hostname: "drained", Port: 3306}, ReadBinlogCoordinates: readCoordinates, ExecBinlogCoordinates: readCoordinates}
+	lagging := &Instance{Key: InstanceKey{Hostname: "lagging", Port: 3306}, ReadBinlogCoordinates: readCoordinates, ExecBinlogCoordinates: BinlogCoordinates{LogFile: "mysql-bin.000016", LogPos: 4000}, SQLThreadLagEstimate: sql.NullInt64{Int64: 2400, Valid: true}}
+	slightlyBehind := &Instance{Key: InstanceKey{Hostname: "slightly-behind", Port: 3306}, ReadBinlogCoordinates: readCoordinates, ExecBinlogCoordinates: BinlogCoordinates{LogFile: "mysql-bin.000017", LogPos: 3000}, SQLThreadLagEstimate: sql.NullInt64{Int64: 5, Valid: true}}
+	unknown := &Instance{Key: InstanceKey{Hostname: "unknown", Port: 3306}, ReadBinlogCoordinates: readCoordinates, ExecBinlogCoordinates: BinlogCoordinates{LogFile: "mysql-bin.000017", LogPos: 3000}}
+	instances := [](*Instance){drained, lagging, slightlyBehind, unknown}
+
+	if lagSeconds, known := drained.SQLThreadLagSeconds(); !known || lagSeconds != 0 {
+		t.Errorf("SQLThreadLagSeconds: expected drained instance to have known zero lag, got %d, %t", lagSeconds, known)
+	}
+	result := RemoveSQLThreadLaggingInstances(instances, 60)
+	if len(result) != 3 || result[0] != drained || result[1] != slightlyBehind || result[2] != unknown {
+		t.Errorf("RemoveSQLThreadLaggingInstances: expected drained, slightly behind and unknown instances, got %+v", result)
+	}
+}
@@ -0,0 +1,93 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/openark/golib/log"
+)
+
+var instanceVersionRegexp = regexp.MustCompile(`^([0-9]+)[.]([0-9]+)(?:[.]([0-9]+))?`)
+
+// InstanceVersion is a parsed, comparable server version, e.g. 5.7.26 out of "5.7.26-29-log"
+type InstanceVersion struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// ParseInstanceVersion parses given @@version. Unparsable versions result in 0.0.0
+func ParseInstanceVersion(version string) InstanceVersion {
+	instanceVersion := InstanceVersion{}
+	submatch := instanceVersionRegexp.FindStringSubmatch(version)
+	if len(submatch) == 0 {
+		return instanceVersion
+	}
+	instanceVersion.Major, _ = strconv.Atoi(submatch[1])
+	instanceVersion.Minor, _ = strconv.Atoi(submatch[2])
+	instanceVersion.Patch, _ = strconv.Atoi(submatch[3])
+	return instanceVersion
+}
+
+// IsEmpty returns true when the version is unknown
+func (this InstanceVersion) IsEmpty() bool {
+	return this == InstanceVersion{}
+}
+
+// SmallerThan returns true if this version strictly precedes the other, comparing major, minor and patch numbers
+func (this InstanceVersion) SmallerThan(other InstanceVersion) bool {
+	if this.Major != other.Major {
+		return this.Major < other.Major
+	}
+	if this.Minor != other.Minor {
+		return this.Minor < other.Minor
+	}
+	return this.Patch < other.Patch
+}
+
+func (this InstanceVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", this.Major, this.Minor, this.Patch)
+}
+
+// IsSmallerVersion tests this instance against another and returns true if this instance is of a smaller version,
+// down to the patch level. e.g. 5.7.20 is a smaller version as compared to 5.7.26
+func (this *Instance) IsSmallerVersion(other *Instance) bool {
+	if this.ParsedVersion.IsEmpty() || other.ParsedVersion.IsEmpty() {
+		return false
+	}
+	return this.ParsedVersion.SmallerThan(other.ParsedVersion)
+}
+
+// checkMoveVersionOrdering refuses placing an instance below another instance of a newer version of the same flavor,
+// as replicating from a newer version to an older one is unsupported. CanReplicateFrom already refuses newer major
+// versions; this check applies down to the patch level, and only to topology refactoring operations: failovers
+// may still promote an upgraded replica.
+// This check is overridden by --force.
+func checkMoveVersionOrdering(instance, other *Instance) error {
+	if instance.FlavorName != other.FlavorName || !instance.IsSmallerVersion(other) {
+		return nil
+	}
+	if config.RuntimeCLIFlags.Force != nil && *config.RuntimeCLIFlags.Force {
+		log.Warningf("Forcing move of %+v (version %s) below %+v (version %s)", instance.Key, instance.ParsedVersion, other.Key, other.ParsedVersion)
+		return nil
+	}
+	return fmt.Errorf("Refusing to move %+v below %+v: version %s would replicate from newer version %s. Use --force to override", instance.Key, other.Key, instance.ParsedVersion, other.ParsedVersion)
+}
@@ -0,0 +1,55 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestParseInstanceVersion(t *testing.T) {
+	test.S(t).ExpectEquals(ParseInstanceVersion("5.7.26-29-log"), InstanceVersion{Major: 5, Minor: 7, Patch: 26})
+	test.S(t).ExpectEquals(ParseInstanceVersion("10.3.12-MariaDB-log"), InstanceVersion{Major: 10, Minor: 3, Patch: 12})
+	test.S(t).ExpectEquals(ParseInstanceVersion("8.0.16"), InstanceVersion{Major: 8, Minor: 0, Patch: 16})
+	test.S(t).ExpectEquals(ParseInstanceVersion("5.6"), InstanceVersion{Major: 5, Minor: 6})
+	test.S(t).ExpectTrue(ParseInstanceVersion("").IsEmpty())
+	test.S(t).ExpectTrue(ParseInstanceVersion("maxscale").IsEmpty())
+	test.S(t).ExpectEquals(ParseInstanceVersion("5.7.26-29-log").String(), "5.7.26")
+}
+
+func TestInstanceVersionSmallerThan(t *testing.T) {
+	test.S(t).ExpectTrue(ParseInstanceVersion("5.7.20").SmallerThan(ParseInstanceVersion("5.7.26")))
+	test.S(t).ExpectTrue(ParseInstanceVersion("5.7.26").SmallerThan(ParseInstanceVersion("8.0.1")))
+	test.S(t).ExpectTrue(ParseInstanceVersion("5.6.40").SmallerThan(ParseInstanceVersion("5.7.1")))
+	test.S(t).ExpectFalse(ParseInstanceVersion("5.7.26").SmallerThan(ParseInstanceVersion("5.7.26-log")))
+	test.S(t).ExpectFalse(ParseInstanceVersion("8.0.1").SmallerThan(ParseInstanceVersion("5.7.26")))
+}
+
+func TestCheckMoveVersionOrdering(t *testing.T) {
+	i5720 := &Instance{Key: key1, Version: "5.7.20-log", ParsedVersion: ParseInstanceVersion("5.7.20-log"), FlavorName: "MySQL"}
+	i5726 := &Instance{Key: key2, Version: "5.7.26-log", ParsedVersion: ParseInstanceVersion("5.7.26-log"), FlavorName: "MySQL"}
+
+	test.S(t).ExpectNil(checkMoveVersionOrdering(i5726, i5720))
+	test.S(t).ExpectNotNil(checkMoveVersionOrdering(i5720, i5726))
+
+	i5726.FlavorName = "Percona"
+	test.S(t).ExpectNil(checkMoveVersionOrdering(i5720, i5726)) // versions not comparable across flavors
+
+	i5720.ParsedVersion = InstanceVersion{}
+	i5726.FlavorName = "MySQL"
+	test.S(t).ExpectNil(checkMoveVersionOrdering(i5720, i5726)) // unknown version
+}
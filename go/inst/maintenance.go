@@ -25,6 +25,7 @@ type Maintenance struct {
 	MaintenanceId  uint
 	Key            InstanceKey
 	BeginTimestamp string
+	EndTimestamp   string
 	SecondsElapsed uint
 	IsActive       bool
 	Owner          string
@@ -27,15 +27,16 @@ import (
 	"github.com/openark/golib/sqlutils"
 )
 
-// ReadActiveMaintenance returns the list of currently active maintenance entries
-func ReadActiveMaintenance() ([]Maintenance, error) {
+// readActiveMaintenance returns the currently active maintenance entries satisfying given condition
+func readActiveMaintenance(whereCondition string, args []interface{}) ([]Maintenance, error) {
 	res := []Maintenance{}
-	query := `
+	query := fmt.Sprintf(`
 		select
 			database_instance_maintenance_id,
 			hostname,
 			port,
 			begin_timestamp,
+			end_timestamp,
 			unix_timestamp() - unix_timestamp(begin_timestamp) as seconds_elapsed,
 			maintenance_active,
 			owner,
@@ -44,15 +45,17 @@ func ReadActiveMaintenance() ([]Maintenance, error) {
 			database_instance_maintenance
 		where
 			maintenance_active = 1
+			%s
 		order by
 			database_instance_maintenance_id
-		`
-	err := db.QueryOrchestratorRowsMap(query, func(m sqlutils.RowMap) error {
+		`, whereCondition)
+	err := db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
 		maintenance := Maintenance{}
 		maintenance.MaintenanceId = m.GetUint("database_instance_maintenance_id")
 		maintenance.Key.Hostname = m.GetString("hostname")
 		maintenance.Key.Port = m.GetInt("port")
 		maintenance.BeginTimestamp = m.GetString("begin_timestamp")
+		maintenance.EndTimestamp = m.GetString("end_timestamp")
 		maintenance.SecondsElapsed = m.GetUint("seconds_elapsed")
 		maintenance.IsActive = m.GetBool("maintenance_active")
 		maintenance.Owner = m.GetString("owner")
@@ -66,7 +69,29 @@ func ReadActiveMaintenance() ([]Maintenance, error) {
 		log.Errore(err)
 	}
 	return res, err
+}
+
+// ReadActiveMaintenance returns the list of currently active maintenance entries
+func ReadActiveMaintenance() ([]Maintenance, error) {
+	return readActiveMaintenance("", sqlutils.Args())
+}
 
+// readActiveMaintenanceByInstanceKey returns the active maintenance entry on given instance, or nil if there is none
+func readActiveMaintenanceByInstanceKey(instanceKey *InstanceKey) (*Maintenance, error) {
+	maintenanceList, err := readActiveMaintenance("and hostname = ? and port = ?", sqlutils.Args(instanceKey.Hostname, instanceKey.Port))
+	if err != nil || len(maintenanceList) == 0 {
+		return nil, err
+	}
+	return &maintenanceList[0], nil
+}
+
+// readActiveMaintenanceByToken returns the active maintenance entry of given token, or nil if there is none
+func readActiveMaintenanceByToken(maintenanceToken int64) (*Maintenance, error) {
+	maintenanceList, err := readActiveMaintenance("and database_instance_maintenance_id = ?", sqlutils.Args(maintenanceToken))
+	if err != nil || len(maintenanceList) == 0 {
+		return nil, err
+	}
+	return &maintenanceList[0], nil
 }
 
 // BeginBoundedMaintenance will make new maintenance entry for given instanceKey.
@@ -99,7 +124,11 @@ func BeginBoundedMaintenance(instanceKey *InstanceKey, owner string, reason stri
 	}
 
 	if affected, _ := res.RowsAffected(); affected == 0 {
-		err = fmt.Errorf("Cannot begin maintenance for instance: %+v; maintenance reason: %+v", instanceKey, reason)
+		if maintenance, _ := readActiveMaintenanceByInstanceKey(instanceKey); maintenance != nil {
+			err = fmt.Errorf("already under maintenance by %s: %s", maintenance.Owner, maintenance.Reason)
+		} else {
+			err = fmt.Errorf("Cannot begin maintenance for instance: %+v; maintenance reason: %+v", instanceKey, reason)
+		}
 	} else {
 		// success
 		maintenanceToken, _ = res.LastInsertId()
@@ -188,8 +217,10 @@ func ReadMaintenanceInstanceKey(maintenanceToken int64) (*InstanceKey, error) {
 	return res, log.Errore(err)
 }
 
-// EndMaintenance will terminate an active maintenance via maintenanceToken
+// EndMaintenance will terminate an active maintenance via maintenanceToken. This may forcibly release a maintenance
+// held by another owner or another operation; the released owner and reason are audited.
 func EndMaintenance(maintenanceToken int64) (wasMaintenance bool, err error) {
+	maintenance, _ := readActiveMaintenanceByToken(maintenanceToken)
 	res, err := db.ExecOrchestrator(`
 			update
 				database_instance_maintenance
@@ -208,7 +239,11 @@ func EndMaintenance(maintenanceToken int64) (wasMaintenance bool, err error) {
 		// success
 		wasMaintenance = true
 		instanceKey, _ := ReadMaintenanceInstanceKey(maintenanceToken)
-		AuditOperation("end-maintenance", instanceKey, fmt.Sprintf("maintenanceToken: %d", maintenanceToken))
+		message := fmt.Sprintf("maintenanceToken: %d", maintenanceToken)
+		if maintenance != nil {
+			message = fmt.Sprintf("%s, owner: %s, reason: %s", message, maintenance.Owner, maintenance.Reason)
+		}
+		AuditOperation("end-maintenance", instanceKey, message)
 	}
 	return wasMaintenance, err
 }
@@ -0,0 +1,78 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestBeginMaintenanceConflict(t *testing.T) {
+	defer useSQLiteBackend(t)()
+	defer func(auditToBackendDB bool) { config.Config.AuditToBackendDB = auditToBackendDB }(config.Config.AuditToBackendDB)
+	config.Config.AuditToBackendDB = true
+
+	instanceKey := &InstanceKey{Hostname: "db-1", Port: 3306}
+	otherKey := &InstanceKey{Hostname: "db-2", Port: 3306}
+	token, err := BeginMaintenance(instanceKey, "dba", "upgrading")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(token > 0)
+
+	_, err = BeginMaintenance(instanceKey, "orchestrator", "move up")
+	test.S(t).ExpectNotNil(err)
+	test.S(t).ExpectEquals(err.Error(), "already under maintenance by dba: upgrading")
+	otherToken, err := BeginMaintenance(otherKey, "orchestrator", "move up")
+	test.S(t).ExpectNil(err)
+
+	maintenance, err := readActiveMaintenanceByInstanceKey(instanceKey)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(maintenance.MaintenanceId, uint(token))
+	test.S(t).ExpectEquals(maintenance.Owner, "dba")
+	test.S(t).ExpectEquals(maintenance.Reason, "upgrading")
+	test.S(t).ExpectTrue(maintenance.EndTimestamp != "")
+	maintenance, err = readActiveMaintenanceByToken(otherToken)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(maintenance.Key, *otherKey)
+
+	maintenanceList, err := ReadActiveMaintenance()
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(maintenanceList), 2)
+
+	wasMaintenance, err := EndMaintenance(token)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(wasMaintenance)
+	maintenance, err = readActiveMaintenanceByInstanceKey(instanceKey)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(maintenance == nil)
+
+	audits, err := ReadRecentAudit(instanceKey, 0)
+	test.S(t).ExpectNil(err)
+	released := false
+	for _, audit := range audits {
+		if audit.AuditType == "end-maintenance" && strings.Contains(audit.Message, "owner: dba, reason: upgrading") {
+			released = true
+		}
+	}
+	test.S(t).ExpectTrue(released)
+
+	// Once released, maintenance may be taken again
+	_, err = BeginMaintenance(instanceKey, "orchestrator", "move up")
+	test.S(t).ExpectNil(err)
+}
@@ -0,0 +1,80 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/go-sql-driver/mysql"
+)
+
+// masterWritesProbeTable is the table, within MasterWritesProbeSchema, written to by the master writes probe
+const masterWritesProbeTable = "master_writes_probe"
+
+// errLockWaitTimeout is ER_LOCK_WAIT_TIMEOUT
+const errLockWaitTimeout = 1205
+
+// IsMasterWritesProbable returns true when the master writes probe applies to this instance: the probe is
+// configured, and this is a writable master. Replicas are never probed.
+func (this *Instance) IsMasterWritesProbable() bool {
+	if config.Config.MasterWritesProbeSchema == "" {
+		return false
+	}
+	return !this.IsReplica() && !this.ReadOnly && !this.IsBinlogServer()
+}
+
+// masterWritesProbeStatement returns the statement by which given master's probe row is written
+func masterWritesProbeStatement() string {
+	return fmt.Sprintf("insert into `%s`.`%s` (server_id, probe_timestamp) values (?, now()) on duplicate key update probe_timestamp = now()",
+		config.Config.MasterWritesProbeSchema, masterWritesProbeTable)
+}
+
+// probeMasterWrites writes the probe row of given master, and returns true when the write is still waiting after
+// MasterWritesProbeTimeoutSeconds. This is the case on a master locked by FLUSH TABLES WITH READ LOCK, or on a
+// semi-sync master waiting for replica acknowledgement. Any other failure (e.g. missing table) is returned as error.
+func probeMasterWrites(sqlDB *sql.DB, instance *Instance) (writesBlocked bool, err error) {
+	timeout := time.Duration(config.Config.MasterWritesProbeTimeoutSeconds) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	// Bounds the server side wait on a global read lock, should the connection outlive the context
+	if _, err := conn.ExecContext(ctx, `set session lock_wait_timeout = ?`, config.Config.MasterWritesProbeTimeoutSeconds); err != nil {
+		return false, err
+	}
+	_, err = conn.ExecContext(ctx, masterWritesProbeStatement(), instance.ServerID)
+	if ctx.Err() == context.DeadlineExceeded {
+		return true, nil
+	}
+	// The connection returns to the pool, where later discovery queries expect the default lock_wait_timeout
+	if _, rerr := conn.ExecContext(context.Background(), `set session lock_wait_timeout = @@global.lock_wait_timeout`); rerr != nil && err == nil {
+		err = rerr
+	}
+	if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == errLockWaitTimeout {
+		return true, nil
+	}
+	return false, err
+}
@@ -0,0 +1,46 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package inst
+
+import (
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestIsMasterWritesProbable(t *testing.T) {
+	defer func(schema string) { config.Config.MasterWritesProbeSchema = schema }(config.Config.MasterWritesProbeSchema)
+
+	master := &Instance{Key: key1}
+	replica := &Instance{Key: key2, MasterKey: key1, ReadBinlogCoordinates: BinlogCoordinates{LogFile: "mysql-bin.000001", LogPos: 4}}
+	config.Config.MasterWritesProbeSchema = ""
+	test.S(t).ExpectFalse(master.IsMasterWritesProbable())
+
+	config.Config.MasterWritesProbeSchema = "meta"
+	test.S(t).ExpectTrue(master.IsMasterWritesProbable())
+	test.S(t).ExpectFalse(replica.IsMasterWritesProbable())
+	master.ReadOnly = true
+	test.S(t).ExpectFalse(master.IsMasterWritesProbable())
+}
+
+func TestMasterWritesProbeStatement(t *testing.T) {
+	defer func(schema string) { config.Config.MasterWritesProbeSchema = schema }(config.Config.MasterWritesProbeSchema)
+
+	config.Config.MasterWritesProbeSchema = "meta"
+	test.S(t).ExpectEquals(masterWritesProbeStatement(), "insert into `meta`.`master_writes_probe` (server_id, probe_timestamp) values (?, now()) on duplicate key update probe_timestamp = now()")
+	test.S(t).ExpectEquals(strictSQLModeViolation(masterWritesProbeStatement()), "")
+}
@@ -77,3 +77,13 @@ func ApplyPoolInstances(submission *PoolInstancesSubmission) error {
 	writePoolInstances(submission.Pool, instanceKeys)
 	return nil
 }
+
+// parseDetectedInstancePools parses the result of DetectInstancePoolQuery: a comma delimited list of pool names
+func parseDetectedInstancePools(delimitedPools string) (pools []string) {
+	for _, pool := range strings.Split(delimitedPools, ",") {
+		if pool = strings.TrimSpace(pool); pool != "" {
+			pools = append(pools, pool)
+		}
+	}
+	return pools
+}
@@ -51,6 +51,29 @@ func writePoolInstances(pool string, instanceKeys [](*InstanceKey)) error {
 	return ExecDBWriteFunc(writeFunc)
 }
 
+// registerInstancePools adds given instance to given pools, refreshing the registration time of
+// existing memberships. Pools the instance is no longer detected in are left to expire.
+func registerInstancePools(instanceKey *InstanceKey, pools []string) error {
+	writeFunc := func() error {
+		for _, pool := range pools {
+			_, err := db.ExecOrchestrator(`
+				insert into database_instance_pool (
+					hostname, port, pool, registered_at
+				) values (
+					?, ?, ?, now()
+				) on duplicate key update
+					registered_at = values(registered_at)
+				`, instanceKey.Hostname, instanceKey.Port, pool,
+			)
+			if err != nil {
+				return log.Errore(err)
+			}
+		}
+		return nil
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
 // ReadClusterPoolInstances reads cluster-pool-instance associationsfor given cluster and pool
 func ReadClusterPoolInstances(clusterName string, pool string) (result [](*ClusterPoolInstance), err error) {
 	args := sqlutils.Args()
@@ -0,0 +1,168 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+)
+
+// PromotionCandidateRejection is a replica which would not be promoted, and why
+type PromotionCandidateRejection struct {
+	Key    InstanceKey
+	Reason string
+}
+
+// PromotionSuggestion is the replica orchestrator would promote should a master die now. Candidate is nil when
+// no replica is promotable. RejectedCandidates lists all other replicas, best ranked first. LostReplicas lists
+// those which regrouping would leave behind.
+type PromotionSuggestion struct {
+	MasterKey          InstanceKey
+	Candidate          *Instance
+	Reason             string
+	RejectedCandidates []PromotionCandidateRejection
+	LostReplicas       []InstanceKey
+}
+
+func (this *PromotionSuggestion) reject(replica *Instance, reason string) {
+	this.RejectedCandidates = append(this.RejectedCandidates, PromotionCandidateRejection{Key: replica.Key, Reason: reason})
+}
+
+// isPreferredPromotionRule returns true for must & prefer promotion rules, i.e. registered candidates
+func isPreferredPromotionRule(rule CandidatePromotionRule) bool {
+	return rule == MustPromoteRule || rule == PreferPromoteRule
+}
+
+// PromotedReplicaReplacer is the replacement phase of a master recovery: given the replica chosen during regroup,
+// and the replicas regroup would move below it, it returns the replica which would take over, or nil when the
+// chosen replica would be promoted as is.
+type PromotedReplicaReplacer func(regroupCandidate *Instance, regroupedReplicas [](*Instance)) (*Instance, error)
+
+// suggestPromotionCandidate applies the recovery's candidate selection onto given replicas of given master.
+// Replicas are expected to be sorted (see sortInstancesDataCenterHint).
+// First is the regroup phase (chooseCandidateReplica): the most up to date valid replica is chosen, and others
+// are either lost or will replicate from it. Then given replacer, the recovery's replacement phase, may have
+// another replica take its place.
+func suggestPromotionCandidate(master *Instance, replicas [](*Instance), replace PromotedReplicaReplacer) (*PromotionSuggestion, error) {
+	suggestion := &PromotionSuggestion{MasterKey: master.Key, RejectedCandidates: []PromotionCandidateRejection{}, LostReplicas: []InstanceKey{}}
+	if len(replicas) == 0 {
+		suggestion.Reason = "no replicas found"
+		return suggestion, nil
+	}
+	priorityMajorVersion, _ := getPriorityMajorVersionForCandidate(replicas)
+	priorityBinlogFormat, _ := getPriorityBinlogFormatForCandidate(replicas)
+
+	// chooseCandidateReplica modifies the underlying array of given slice
+	candidate, aheadReplicas, _, _, cannotReplicateReplicas, err := chooseCandidateReplica(append([](*Instance){}, replicas...))
+	if err != nil || candidate == nil {
+		for _, replica := range replicas {
+			reason := candidateReplicaDisqualification(replica, priorityMajorVersion, priorityBinlogFormat, true)
+			if reason == "" {
+				reason = "not promotable"
+			}
+			suggestion.reject(replica, reason)
+		}
+		suggestion.Reason = "no replica is valid as candidate; recovery would fail"
+		return suggestion, nil
+	}
+	regroupCandidate := candidate
+	suggestion.Reason = "most up to date valid replica"
+	if candidate.SQLDelay > 0 {
+		suggestion.Reason = fmt.Sprintf("only valid replica, though delayed by %d seconds", candidate.SQLDelay)
+	}
+	lostReplicas := NewInstanceKeyMap()
+	for _, replica := range aheadReplicas {
+		lostReplicas.AddKey(replica.Key)
+		suggestion.LostReplicas = append(suggestion.LostReplicas, replica.Key)
+	}
+	cannotReplicate := NewInstanceKeyMap()
+	for _, replica := range cannotReplicateReplicas {
+		cannotReplicate.AddKey(replica.Key)
+		suggestion.LostReplicas = append(suggestion.LostReplicas, replica.Key)
+	}
+
+	regroupedReplicas := [](*Instance){}
+	for _, replica := range replicas {
+		if replica.Key.Equals(&candidate.Key) || lostReplicas.HasKey(replica.Key) || cannotReplicate.HasKey(replica.Key) {
+			continue
+		}
+		regroupedReplicas = append(regroupedReplicas, replica)
+	}
+	replacement, err := replace(candidate, regroupedReplicas)
+	if err != nil {
+		return suggestion, err
+	}
+	if replacement != nil && !replacement.Key.Equals(&candidate.Key) {
+		suggestion.Reason = fmt.Sprintf("takes over %+v, which regroup would choose; promotion rule: %s, data center: %s", candidate.Key, replacement.PromotionRule, replacement.DataCenter)
+		suggestion.reject(candidate, fmt.Sprintf("chosen during regroup, then replaced by %+v", replacement.Key))
+		candidate = replacement
+	}
+	suggestion.Candidate = candidate
+
+	// Rank all other replicas against the chosen one
+	for _, replica := range replicas {
+		if replica.Key.Equals(&candidate.Key) || replica.Key.Equals(&regroupCandidate.Key) {
+			// Already accounted for
+			continue
+		}
+		disqualification := candidateReplicaDisqualification(replica, priorityMajorVersion, priorityBinlogFormat, false)
+		switch {
+		case lostReplicas.HasKey(replica.Key) && disqualification != "":
+			suggestion.reject(replica, fmt.Sprintf("%s; being ahead of chosen replica, would be lost", disqualification))
+		case lostReplicas.HasKey(replica.Key):
+			suggestion.reject(replica, "ahead of chosen replica; would be lost")
+		case cannotReplicate.HasKey(replica.Key):
+			_, err := replica.CanReplicateFrom(regroupCandidate)
+			suggestion.reject(replica, fmt.Sprintf("cannot replicate from chosen replica; would be lost: %+v", err))
+		case disqualification != "":
+			suggestion.reject(replica, disqualification)
+		case replica.ExecBinlogCoordinates.SmallerThan(&candidate.ExecBinlogCoordinates):
+			suggestion.reject(replica, fmt.Sprintf("behind: executed up to %+v while chosen replica executed up to %+v", replica.ExecBinlogCoordinates, candidate.ExecBinlogCoordinates))
+		case replica.RecentlyRestarted:
+			suggestion.reject(replica, fmt.Sprintf("recently restarted: uptime %d seconds", replica.Uptime))
+		case candidate.DataCenter == master.DataCenter && replica.DataCenter != master.DataCenter:
+			suggestion.reject(replica, fmt.Sprintf("in data center %s while master in %s", replica.DataCenter, master.DataCenter))
+		default:
+			suggestion.reject(replica, fmt.Sprintf("ranked lower; promotion rule: %s", replica.PromotionRule))
+		}
+	}
+	return suggestion, nil
+}
+
+// SuggestPromotionCandidate returns the replica which recovery would promote should given master die now, along
+// with the reasons for rejecting the other replicas. Given replicas are left out, as though they were gone.
+// It changes nothing: replication is not stopped, and replicas are assessed by their last known state.
+func SuggestPromotionCandidate(masterKey *InstanceKey, excludedKeys *InstanceKeyMap, replace PromotedReplicaReplacer) (*PromotionSuggestion, error) {
+	master, found, err := ReadInstance(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("SuggestPromotionCandidate: cannot find instance %+v", *masterKey)
+	}
+	readReplicas, err := getReplicasForSorting(masterKey, false)
+	if err != nil {
+		return nil, err
+	}
+	replicas := [](*Instance){}
+	for _, replica := range readReplicas {
+		if !excludedKeys.HasKey(replica.Key) {
+			replicas = append(replicas, replica)
+		}
+	}
+	sortInstancesDataCenterHint(replicas, master.DataCenter)
+	return suggestPromotionCandidate(master, replicas, replace)
+}
@@ -0,0 +1,106 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package inst
+
+import (
+	"fmt"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+// keepRegroupCandidate is a replacement phase which never replaces the replica chosen during regroup
+func keepRegroupCandidate(regroupCandidate *Instance, regroupedReplicas [](*Instance)) (*Instance, error) {
+	return nil, nil
+}
+
+func TestSuggestPromotionCandidate(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	applyGeneralGoodToGoReplicationParams(instances)
+	for _, instance := range instances {
+		instance.PromotionRule = NeutralPromoteRule
+	}
+	instancesMap[i830Key.StringCode()].LogSlaveUpdatesEnabled = false
+	master := &Instance{Key: key1}
+	sortInstances(instances)
+
+	suggestion, err := suggestPromotionCandidate(master, instances, keepRegroupCandidate)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectNotNil(suggestion.Candidate)
+	test.S(t).ExpectEquals(suggestion.Candidate.Key, i820Key)
+	test.S(t).ExpectEquals(len(suggestion.RejectedCandidates), 5)
+	test.S(t).ExpectEquals(suggestion.RejectedCandidates[0].Key, i830Key)
+	test.S(t).ExpectEquals(suggestion.RejectedCandidates[1].Key, i810Key)
+	// i830 is ahead of i820, yet cannot be its master
+	test.S(t).ExpectEquals(len(suggestion.LostReplicas), 1)
+	test.S(t).ExpectEquals(suggestion.LostReplicas[0], i830Key)
+}
+
+func TestSuggestPromotionCandidateReplaced(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	applyGeneralGoodToGoReplicationParams(instances)
+	for _, instance := range instances {
+		instance.PromotionRule = NeutralPromoteRule
+	}
+	instancesMap[i720Key.StringCode()].PromotionRule = PreferPromoteRule
+	master := &Instance{Key: key1}
+	sortInstances(instances)
+
+	var regroupedKeys *InstanceKeyMap
+	replaceWithRegisteredCandidate := func(regroupCandidate *Instance, regroupedReplicas [](*Instance)) (*Instance, error) {
+		regroupedKeys = NewInstanceKeyMap()
+		for _, replica := range regroupedReplicas {
+			regroupedKeys.AddKey(replica.Key)
+			if replica.PromotionRule == PreferPromoteRule {
+				return replica, nil
+			}
+		}
+		return nil, nil
+	}
+	suggestion, err := suggestPromotionCandidate(master, instances, replaceWithRegisteredCandidate)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectNotNil(suggestion.Candidate)
+	test.S(t).ExpectEquals(suggestion.Candidate.Key, i720Key)
+	test.S(t).ExpectEquals(len(suggestion.RejectedCandidates), 5)
+	// The most up to date replica is chosen during regroup, then replaced
+	test.S(t).ExpectEquals(suggestion.RejectedCandidates[0].Key, i830Key)
+	// The replacement phase sees the replicas regroup would move below the chosen one, and only those
+	test.S(t).ExpectFalse(regroupedKeys.HasKey(i830Key))
+	test.S(t).ExpectTrue(regroupedKeys.HasKey(i720Key))
+}
+
+func TestSuggestPromotionCandidateReplacementError(t *testing.T) {
+	instances, _ := generateTestInstances()
+	applyGeneralGoodToGoReplicationParams(instances)
+	sortInstances(instances)
+
+	failingReplacement := func(regroupCandidate *Instance, regroupedReplicas [](*Instance)) (*Instance, error) {
+		return nil, fmt.Errorf("cannot read candidates")
+	}
+	_, err := suggestPromotionCandidate(&Instance{Key: key1}, instances, failingReplacement)
+	test.S(t).ExpectNotNil(err)
+}
+
+func TestSuggestPromotionCandidateNone(t *testing.T) {
+	instances, _ := generateTestInstances()
+	for _, instance := range instances {
+		instance.PromotionRule = MustNotPromoteRule
+	}
+	suggestion, err := suggestPromotionCandidate(&Instance{Key: key1}, instances, keepRegroupCandidate)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(suggestion.Candidate == nil)
+	test.S(t).ExpectEquals(len(suggestion.RejectedCandidates), 6)
+}
@@ -0,0 +1,137 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/util"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+	"github.com/rcrowley/go-metrics"
+)
+
+// sqlThreadAutoRestartWindowMinutes is the period within which AutoRestartReplicationSQLThreadMaxPerHour applies
+const sqlThreadAutoRestartWindowMinutes = 60
+
+var sqlThreadAutoRestartCounter = metrics.NewCounter()
+var sqlThreadAutoRestartExhaustedCounter = metrics.NewCounter()
+
+func init() {
+	metrics.Register("replication.sql_thread_auto_restart", sqlThreadAutoRestartCounter)
+	metrics.Register("replication.sql_thread_auto_restart_exhausted", sqlThreadAutoRestartExhaustedCounter)
+}
+
+// IsSQLThreadAutoRestartable returns true when this replica's SQL thread is stopped on an error listed
+// in AutoRestartReplicationSQLThreadErrorCodes
+func (this *Instance) IsSQLThreadAutoRestartable() bool {
+	if !this.IsReplica() || this.ReplicationSQLThreadState != ReplicationThreadStateStopped || this.LastSQLErrno == 0 {
+		return false
+	}
+	for _, errorCode := range config.Config.AutoRestartReplicationSQLThreadErrorCodes {
+		if uint(this.LastSQLErrno) == errorCode {
+			return true
+		}
+	}
+	return false
+}
+
+// readRecentSQLThreadAutoRestarts returns the number of automated SQL thread restarts on given instance within
+// the last sqlThreadAutoRestartWindowMinutes. Restarts are persisted so that the limit survives orchestrator restarts.
+func readRecentSQLThreadAutoRestarts(instanceKey *InstanceKey) (count uint, err error) {
+	query := `
+		select
+			count(*) as count_restarts
+		from
+			replication_sql_thread_auto_restart
+		where
+			hostname = ?
+			and port = ?
+			and restart_timestamp >= NOW() - INTERVAL ? MINUTE
+		`
+	err = db.QueryOrchestrator(query, sqlutils.Args(instanceKey.Hostname, instanceKey.Port, sqlThreadAutoRestartWindowMinutes), func(m sqlutils.RowMap) error {
+		count = m.GetUint("count_restarts")
+		return nil
+	})
+	return count, log.Errore(err)
+}
+
+// writeSQLThreadAutoRestart records an automated SQL thread restart on given instance
+func writeSQLThreadAutoRestart(instance *Instance) error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			insert ignore
+				into replication_sql_thread_auto_restart (
+					hostname, port, restart_timestamp, last_sql_errno
+				) values (
+					?, ?, NOW(), ?
+				)
+			`, instance.Key.Hostname, instance.Key.Port, instance.LastSQLErrno,
+		)
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
+// ExpireSQLThreadAutoRestarts removes records of automated SQL thread restarts which no longer count towards the limit
+func ExpireSQLThreadAutoRestarts() error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+				delete from replication_sql_thread_auto_restart
+				where restart_timestamp < NOW() - INTERVAL ? MINUTE
+				`, sqlThreadAutoRestartWindowMinutes,
+		)
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
+// AutoRestartReplicationSQLThread starts the SQL thread of a replica stopped on a known-transient error (see
+// IsSQLThreadAutoRestartable), unless it has already been auto-restarted AutoRestartReplicationSQLThreadMaxPerHour
+// times within the last hour, in which case the replica is assumed to be genuinely broken and is left to the operator.
+// A downtimed replica is left to the operator as well.
+func AutoRestartReplicationSQLThread(instance *Instance) (restarted bool, err error) {
+	if !instance.IsSQLThreadAutoRestartable() {
+		return false, nil
+	}
+	// instance is freshly read off the replica itself, hence does not indicate downtime
+	if downtimed, err := IsDowntimed(&instance.Key); err != nil || downtimed {
+		return false, err
+	}
+	countRestarts, err := readRecentSQLThreadAutoRestarts(&instance.Key)
+	if err != nil {
+		return false, err
+	}
+	if countRestarts >= config.Config.AutoRestartReplicationSQLThreadMaxPerHour {
+		sqlThreadAutoRestartExhaustedCounter.Inc(1)
+		if util.ClearToLog("AutoRestartReplicationSQLThread", instance.Key.StringCode()) {
+			log.Warningf("AutoRestartReplicationSQLThread: %+v SQL thread stopped on error %d; not restarting as it has been restarted %d times in the last %d minutes", instance.Key, instance.LastSQLErrno, countRestarts, sqlThreadAutoRestartWindowMinutes)
+		}
+		return false, nil
+	}
+	if err := writeSQLThreadAutoRestart(instance); err != nil {
+		return false, err
+	}
+	if _, err := ExecInstance(&instance.Key, `start slave sql_thread`); err != nil {
+		return false, log.Errore(err)
+	}
+	sqlThreadAutoRestartCounter.Inc(1)
+	AuditOperation("auto-restart-sql-thread", &instance.Key, fmt.Sprintf("started SQL thread stopped on error %d: %s; restart %d/%d in the last %d minutes", instance.LastSQLErrno, instance.LastSQLError, countRestarts+1, config.Config.AutoRestartReplicationSQLThreadMaxPerHour, sqlThreadAutoRestartWindowMinutes))
+	return true, nil
+}
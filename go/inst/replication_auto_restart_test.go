@@ -0,0 +1,91 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package inst
+
+import (
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestIsSQLThreadAutoRestartable(t *testing.T) {
+	defer func(errorCodes []uint) {
+		config.Config.AutoRestartReplicationSQLThreadErrorCodes = errorCodes
+	}(config.Config.AutoRestartReplicationSQLThreadErrorCodes)
+
+	replica := &Instance{
+		Key:                       key2,
+		MasterKey:                 key1,
+		ReadBinlogCoordinates:     BinlogCoordinates{LogFile: "mysql-bin.000012", LogPos: 4000},
+		ReplicationIOThreadState:  ReplicationThreadStateRunning,
+		ReplicationSQLThreadState: ReplicationThreadStateStopped,
+		LastSQLErrno:              1213,
+	}
+	config.Config.AutoRestartReplicationSQLThreadErrorCodes = []uint{}
+	test.S(t).ExpectFalse(replica.IsSQLThreadAutoRestartable())
+
+	config.Config.AutoRestartReplicationSQLThreadErrorCodes = []uint{1205, 1213}
+	test.S(t).ExpectTrue(replica.IsSQLThreadAutoRestartable())
+
+	replica.LastSQLErrno = 1062
+	test.S(t).ExpectFalse(replica.IsSQLThreadAutoRestartable())
+
+	replica.LastSQLErrno = 1205
+	replica.ReplicationSQLThreadState = ReplicationThreadStateRunning
+	test.S(t).ExpectFalse(replica.IsSQLThreadAutoRestartable())
+}
+
+func TestAutoRestartReplicationSQLThreadSkipsDowntimed(t *testing.T) {
+	defer useSQLiteBackend(t)()
+	defer func(errorCodes []uint) {
+		config.Config.AutoRestartReplicationSQLThreadErrorCodes = errorCodes
+	}(config.Config.AutoRestartReplicationSQLThreadErrorCodes)
+	config.Config.AutoRestartReplicationSQLThreadErrorCodes = []uint{1213}
+
+	replica := &Instance{
+		Key:                       key2,
+		MasterKey:                 key1,
+		ReplicationIOThreadState:  ReplicationThreadStateRunning,
+		ReplicationSQLThreadState: ReplicationThreadStateStopped,
+		LastSQLErrno:              1213,
+	}
+	downtimed, err := IsDowntimed(&replica.Key)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectFalse(downtimed)
+
+	test.S(t).ExpectNil(BeginDowntime(NewDowntime(&replica.Key, "dba", "rebuilding", time.Hour)))
+	downtimed, err = IsDowntimed(&replica.Key)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(downtimed)
+	downtimed, err = IsDowntimed(&key1)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectFalse(downtimed)
+
+	restarted, err := AutoRestartReplicationSQLThread(replica)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectFalse(restarted)
+	countRestarts, err := readRecentSQLThreadAutoRestarts(&replica.Key)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(countRestarts, uint(0))
+
+	_, err = EndDowntime(&replica.Key)
+	test.S(t).ExpectNil(err)
+	downtimed, err = IsDowntimed(&replica.Key)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectFalse(downtimed)
+}
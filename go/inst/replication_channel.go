@@ -0,0 +1,248 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+var replicationChannelNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_.-]*$`)
+
+// ReplicationChannel is a single replication source of a multi-source (FOR CHANNEL) replica
+type ReplicationChannel struct {
+	Name                      string
+	MasterKey                 InstanceKey
+	MasterUUID                string
+	ReplicationIOThreadState  ReplicationThreadState
+	ReplicationSQLThreadState ReplicationThreadState
+	UsingOracleGTID           bool
+	SecondsBehindMaster       sql.NullInt64
+	LastIOError               string
+	LastSQLError              string
+	AllowTLS                  bool
+	ReplicationSSLCipher      string
+}
+
+// ReplicationChannels is the list of replication sources of a multi-source replica
+type ReplicationChannels []ReplicationChannel
+
+// newReplicationChannelFromSlaveStatus reads a replication channel off its SHOW SLAVE STATUS row
+func newReplicationChannelFromSlaveStatus(m sqlutils.RowMap) ReplicationChannel {
+	channel := ReplicationChannel{
+		Name:                      m.GetStringD("Channel_Name", ""),
+		MasterKey:                 InstanceKey{Hostname: m.GetString("Master_Host"), Port: m.GetInt("Master_Port")},
+		ReplicationIOThreadState:  ReplicationThreadStateFromStatus(m.GetString("Slave_IO_Running")),
+		ReplicationSQLThreadState: ReplicationThreadStateFromStatus(m.GetString("Slave_SQL_Running")),
+		UsingOracleGTID:           (m.GetIntD("Auto_Position", 0) == 1),
+		SecondsBehindMaster:       m.GetNullInt64("Seconds_Behind_Master"),
+		LastIOError:               emptyQuotesRegexp.ReplaceAllString(strconv.QuoteToASCII(m.GetString("Last_IO_Error")), ""),
+		LastSQLError:              emptyQuotesRegexp.ReplaceAllString(strconv.QuoteToASCII(m.GetString("Last_SQL_Error")), ""),
+		AllowTLS:                  (m.GetString("Master_SSL_Allowed") == "Yes"),
+		ReplicationSSLCipher:      m.GetString("Master_SSL_Cipher"),
+	}
+	if resolvedHostname, err := ResolveHostname(channel.MasterKey.Hostname); err == nil {
+		channel.MasterKey.Hostname = resolvedHostname
+	}
+	return channel
+}
+
+// IsBroken returns true when either replication thread of this channel is not running
+func (this *ReplicationChannel) IsBroken() bool {
+	return !this.ReplicationIOThreadState.IsRunning() || !this.ReplicationSQLThreadState.IsRunning()
+}
+
+// IsLagging returns true when this channel lags beyond ReasonableReplicationLagSeconds
+func (this *ReplicationChannel) IsLagging() bool {
+	return this.SecondsBehindMaster.Valid && this.SecondsBehindMaster.Int64 > int64(config.Config.ReasonableReplicationLagSeconds)
+}
+
+// Names returns the names of all channels
+func (this ReplicationChannels) Names() []string {
+	names := []string{}
+	for _, channel := range this {
+		names = append(names, channel.Name)
+	}
+	return names
+}
+
+// ToJSONString returns the channels as JSON, or an empty string when there are none
+func (this ReplicationChannels) ToJSONString() string {
+	if len(this) == 0 {
+		return ""
+	}
+	bytes, _ := json.Marshal(this)
+	return string(bytes)
+}
+
+// ReadJson populates the channels from given JSON string. An empty string means no channels.
+func (this *ReplicationChannels) ReadJson(jsonString string) error {
+	*this = nil
+	if jsonString == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(jsonString), this)
+}
+
+// IsMultiSourceReplica returns true when this instance replicates from more than one replication channel
+func (this *Instance) IsMultiSourceReplica() bool {
+	return len(this.ReplicationChannels) > 1
+}
+
+// GetReplicationChannel returns the replication channel of given name, or nil if there is no such channel
+func (this *Instance) GetReplicationChannel(channelName string) *ReplicationChannel {
+	for i := range this.ReplicationChannels {
+		if this.ReplicationChannels[i].Name == channelName {
+			return &this.ReplicationChannels[i]
+		}
+	}
+	return nil
+}
+
+// CheckCanRelocateReplica refuses topology changes on multi-source replicas: CHANGE MASTER TO, STOP SLAVE etc.
+// would apply to all channels at once. Such replicas can only be changed one explicitly specified channel at a time.
+func CheckCanRelocateReplica(instance *Instance, operation string) error {
+	if instance.IsMultiSourceReplica() {
+		return fmt.Errorf("%s: %+v is a multi-source replica (channels: %s); a replication channel must be explicitly specified", operation, instance.Key, strings.Join(instance.ReplicationChannels.Names(), ", "))
+	}
+	return nil
+}
+
+// populateReplicationChannels reads the connection status of given replication channels, as found in SHOW SLAVE STATUS,
+// from performance_schema, and assigns them to the instance. Only multi-source replicas get their channels assigned.
+func populateReplicationChannels(instance *Instance, channels ReplicationChannels, db *discoveryDB) error {
+	if len(channels) <= 1 {
+		return nil
+	}
+	query := `
+		select
+			CHANNEL_NAME as channel_name,
+			SOURCE_UUID as source_uuid,
+			SERVICE_STATE as service_state,
+			LAST_ERROR_MESSAGE as last_error_message
+		from
+			performance_schema.replication_connection_status
+		`
+	err := db.QueryRowsMap(query, func(m sqlutils.RowMap) error {
+		for i := range channels {
+			if channels[i].Name != m.GetString("channel_name") {
+				continue
+			}
+			channels[i].MasterUUID = m.GetString("source_uuid")
+			switch m.GetString("service_state") {
+			case "ON":
+				channels[i].ReplicationIOThreadState = ReplicationThreadStateRunning
+			case "OFF":
+				channels[i].ReplicationIOThreadState = ReplicationThreadStateStopped
+			default:
+				channels[i].ReplicationIOThreadState = ReplicationThreadStateOther
+			}
+			channels[i].LastIOError = emptyQuotesRegexp.ReplaceAllString(strconv.QuoteToASCII(m.GetString("last_error_message")), "")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	instance.ReplicationChannels = channels
+	return nil
+}
+
+// forChannelClause returns the FOR CHANNEL clause for given replication channel
+func forChannelClause(channelName string) (string, error) {
+	if !replicationChannelNameRegexp.MatchString(channelName) {
+		return "", fmt.Errorf("Invalid replication channel name: %s", channelName)
+	}
+	return fmt.Sprintf("for channel '%s'", channelName), nil
+}
+
+// repointChannelStatements returns the statements which stop given replication channel, point it at a master
+// (host and port as arguments) using GTID auto positioning, and start it again
+func repointChannelStatements(channelName string) (statements []string, err error) {
+	forChannel, err := forChannelClause(channelName)
+	if err != nil {
+		return statements, err
+	}
+	statements = []string{
+		fmt.Sprintf("stop slave %s", forChannel),
+		fmt.Sprintf("change master to master_host=?, master_port=?, master_auto_position=1 %s", forChannel),
+		fmt.Sprintf("start slave %s", forChannel),
+	}
+	return statements, nil
+}
+
+// RepointChannel repoints a single replication channel of a (multi-source) replica onto given master, keeping
+// other channels intact. The channel must be using GTID auto positioning, such that no coordinates are required.
+// Repointing below an instance of a different cluster is refused unless allowCrossCluster.
+func RepointChannel(instanceKey *InstanceKey, channelName string, masterKey *InstanceKey, allowCrossCluster bool) (*Instance, error) {
+	if err := checkCrossClusterMove("repoint-channel", instanceKey, masterKey, allowCrossCluster); err != nil {
+		return nil, err
+	}
+	instance, err := ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return instance, err
+	}
+	channel := instance.GetReplicationChannel(channelName)
+	if channel == nil {
+		return instance, fmt.Errorf("repoint-channel: %+v has no replication channel '%s'", *instanceKey, channelName)
+	}
+	if !channel.UsingOracleGTID {
+		return instance, fmt.Errorf("repoint-channel: channel '%s' on %+v does not use GTID auto positioning", channelName, *instanceKey)
+	}
+	statements, err := repointChannelStatements(channelName)
+	if err != nil {
+		return instance, err
+	}
+	if err := CheckReplicationCycle(instanceKey, masterKey); err != nil {
+		return instance, log.Errore(err)
+	}
+	changeToMasterKey, _, err := UnresolveHostname(masterKey)
+	if err != nil {
+		return instance, err
+	}
+	if *config.RuntimeCLIFlags.Noop {
+		return instance, fmt.Errorf("noop: aborting repoint-channel operation on %+v; signalling error but nothing went wrong.", *instanceKey)
+	}
+
+	log.Infof("Will repoint channel '%s' of %+v to master %+v", channelName, *instanceKey, *masterKey)
+	if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), "repoint-channel"); merr != nil {
+		return instance, fmt.Errorf("Cannot begin maintenance on %+v: %+v", *instanceKey, merr)
+	} else {
+		defer EndMaintenance(maintenanceToken)
+	}
+
+	if _, err := ExecInstance(instanceKey, statements[0]); err != nil {
+		return instance, log.Errore(err)
+	}
+	_, err = ExecInstance(instanceKey, statements[1], changeToMasterKey.Hostname, changeToMasterKey.Port)
+	if _, serr := ExecInstance(instanceKey, statements[2]); serr != nil && err == nil {
+		err = serr
+	}
+	if err != nil {
+		return instance, log.Errore(err)
+	}
+	AuditOperation("repoint-channel", instanceKey, fmt.Sprintf("replica %+v channel '%s' repointed to master: %+v", *instanceKey, channelName, *masterKey))
+	return ReadTopologyInstance(instanceKey)
+}
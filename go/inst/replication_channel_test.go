@@ -0,0 +1,86 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package inst
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestReplicationChannelsJson(t *testing.T) {
+	channels := ReplicationChannels{}
+	test.S(t).ExpectEquals(channels.ToJSONString(), "")
+
+	channels = ReplicationChannels{
+		{Name: "orders", MasterKey: key1, ReplicationIOThreadState: ReplicationThreadStateRunning, ReplicationSQLThreadState: ReplicationThreadStateRunning},
+		{Name: "users", MasterKey: key2, ReplicationIOThreadState: ReplicationThreadStateStopped, ReplicationSQLThreadState: ReplicationThreadStateRunning},
+	}
+	readChannels := ReplicationChannels{}
+	err := readChannels.ReadJson(channels.ToJSONString())
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(readChannels), 2)
+	test.S(t).ExpectEquals(readChannels[1].Name, "users")
+	test.S(t).ExpectTrue(readChannels[1].MasterKey.Equals(&key2))
+	test.S(t).ExpectTrue(readChannels[1].ReplicationIOThreadState.IsStopped())
+
+	err = readChannels.ReadJson("")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(readChannels), 0)
+}
+
+func TestReplicationChannelState(t *testing.T) {
+	channel := ReplicationChannel{ReplicationIOThreadState: ReplicationThreadStateRunning, ReplicationSQLThreadState: ReplicationThreadStateRunning}
+	test.S(t).ExpectFalse(channel.IsBroken())
+	test.S(t).ExpectFalse(channel.IsLagging())
+
+	channel.SecondsBehindMaster = sql.NullInt64{Int64: int64(config.Config.ReasonableReplicationLagSeconds) + 1, Valid: true}
+	test.S(t).ExpectTrue(channel.IsLagging())
+
+	channel.ReplicationIOThreadState = ReplicationThreadStateOther
+	test.S(t).ExpectTrue(channel.IsBroken())
+}
+
+func TestCheckCanRelocateReplica(t *testing.T) {
+	instance := &Instance{Key: key3, MasterKey: key1}
+	test.S(t).ExpectNil(CheckCanRelocateReplica(instance, "move-up"))
+	test.S(t).ExpectFalse(instance.IsMultiSourceReplica())
+
+	instance.ReplicationChannels = ReplicationChannels{{Name: "orders", MasterKey: key1}, {Name: "users", MasterKey: key2}}
+	test.S(t).ExpectTrue(instance.IsMultiSourceReplica())
+	test.S(t).ExpectNotNil(CheckCanRelocateReplica(instance, "move-up"))
+	test.S(t).ExpectTrue(instance.GetReplicationChannel("users").MasterKey.Equals(&key2))
+	test.S(t).ExpectTrue(instance.GetReplicationChannel("missing") == nil)
+}
+
+func TestForChannelClause(t *testing.T) {
+	{
+		clause, err := forChannelClause("orders")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(clause, "for channel 'orders'")
+	}
+	{
+		clause, err := forChannelClause("")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(clause, "for channel ''")
+	}
+	{
+		_, err := forChannelClause("orders'; drop table t; --")
+		test.S(t).ExpectNotNil(err)
+	}
+}
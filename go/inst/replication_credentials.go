@@ -0,0 +1,186 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/openark/golib/log"
+)
+
+var passwordRefEnvVariableRegexp = regexp.MustCompile(`^[$][{]([a-zA-Z_][a-zA-Z0-9_]*)[}]$`)
+
+// credentialsVerificationTimeout is the time a replica's IO thread is given to reconnect with new credentials
+const credentialsVerificationTimeout = 10 * time.Second
+
+// CredentialsChangeResult is the outcome of changing replication credentials on a single replica
+type CredentialsChangeResult struct {
+	Key      InstanceKey
+	Success  bool
+	Verified bool
+	Error    string
+}
+
+// ResolvePasswordRef returns the password referenced by given reference, which is either "${ENV_VARIABLE}"
+// or "file:/path/to/file". The password itself is never accepted, so that it does not show in process lists
+// or shell history. Errors never include the password.
+func ResolvePasswordRef(passwordRef string) (string, error) {
+	if submatch := passwordRefEnvVariableRegexp.FindStringSubmatch(passwordRef); len(submatch) > 1 {
+		password, ok := os.LookupEnv(submatch[1])
+		if !ok {
+			return "", fmt.Errorf("Password environment variable %s is not set", submatch[1])
+		}
+		return password, nil
+	}
+	if strings.HasPrefix(passwordRef, "file:") {
+		fileName := strings.TrimPrefix(passwordRef, "file:")
+		contents, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			return "", fmt.Errorf("Cannot read password file %s: %+v", fileName, err)
+		}
+		return strings.TrimRight(string(contents), "\r\n"), nil
+	}
+	return "", fmt.Errorf("Password reference must be either ${ENV_VARIABLE} or file:/path/to/file")
+}
+
+// changeReplicaMasterCredentials stops the IO thread of given replica, changes its replication credentials and
+// restarts whatever replication threads were running. It then verifies the IO thread reconnects.
+// Prior to MySQL 5.7, and on MariaDB, CHANGE MASTER TO requires both replication threads to be stopped.
+func changeReplicaMasterCredentials(instanceKey *InstanceKey, masterUser string, masterPassword string) (verified bool, err error) {
+	instance, err := ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return false, err
+	}
+	if !instance.IsReplica() {
+		return false, fmt.Errorf("%+v is not a replica", *instanceKey)
+	}
+	if err := CheckCanRelocateReplica(instance, "change-master-credentials"); err != nil {
+		return false, err
+	}
+	if *config.RuntimeCLIFlags.Noop {
+		return false, fmt.Errorf("noop: aborting change-master-credentials operation on %+v; signalling error but nothing went wrong.", *instanceKey)
+	}
+	if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), "change-master-credentials"); merr != nil {
+		return false, fmt.Errorf("Cannot begin maintenance on %+v: %+v", *instanceKey, merr)
+	} else {
+		defer EndMaintenance(maintenanceToken)
+	}
+	ioThreadWasRunning := instance.ReplicationIOThreadState.IsRunning()
+	sqlThreadWasRunning := instance.ReplicationSQLThreadState.IsRunning()
+	stopStatements := []string{`stop slave io_thread`}
+	startStatements := []string{}
+	if ioThreadWasRunning {
+		startStatements = append(startStatements, `start slave io_thread`)
+	}
+	if !(instance.IsOracleMySQL() || instance.IsPercona()) || instance.IsSmallerMajorVersionByString("5.7") {
+		stopStatements = []string{`stop slave`}
+		if sqlThreadWasRunning {
+			startStatements = append(startStatements, `start slave sql_thread`)
+		}
+	}
+	for _, statement := range stopStatements {
+		if _, err := ExecInstance(instanceKey, statement); err != nil {
+			return false, err
+		}
+	}
+	_, err = ExecInstance(instanceKey, "change master to master_user=?, master_password=?", masterUser, masterPassword)
+	for _, statement := range startStatements {
+		// Restoring replication even if the change failed
+		if _, serr := ExecInstance(instanceKey, statement); serr != nil && err == nil {
+			err = serr
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+	if !ioThreadWasRunning {
+		// Nothing to verify; the new credentials will be used once replication is started
+		return false, nil
+	}
+	startTime := time.Now()
+	for {
+		instance, err = ReadTopologyInstance(instanceKey)
+		if err == nil && instance.ReplicationIOThreadState.IsRunning() {
+			return true, nil
+		}
+		if time.Since(startTime) > credentialsVerificationTimeout {
+			break
+		}
+		time.Sleep(time.Second)
+	}
+	if err != nil {
+		return false, err
+	}
+	return false, fmt.Errorf("%+v: IO thread not running %+v after credentials change: %s", *instanceKey, credentialsVerificationTimeout, instance.LastIOError)
+}
+
+// ChangeClusterMasterCredentials changes the replication credentials on all replicas of given cluster, one at a
+// time, upstream replicas first. The password is given by reference (see ResolvePasswordRef). Each replica's IO
+// thread is briefly stopped, and is verified to reconnect. The first failure halts the operation; results list
+// all attempted replicas. The password is never logged nor audited.
+func ChangeClusterMasterCredentials(clusterName string, masterUser string, passwordRef string) (results []CredentialsChangeResult, err error) {
+	if masterUser == "" {
+		return results, fmt.Errorf("change-master-credentials: empty user")
+	}
+	masterPassword, err := ResolvePasswordRef(passwordRef)
+	if err != nil {
+		return results, err
+	}
+	instances, err := ReadClusterInstances(clusterName)
+	if err != nil {
+		return results, err
+	}
+	replicas := [](*Instance){}
+	for _, instance := range instances {
+		if instance.IsReplica() {
+			replicas = append(replicas, instance)
+		}
+	}
+	if len(replicas) == 0 {
+		return results, fmt.Errorf("change-master-credentials: no replicas found in cluster %s", clusterName)
+	}
+	sort.SliceStable(replicas, func(i, j int) bool {
+		if replicas[i].ReplicationDepth != replicas[j].ReplicationDepth {
+			return replicas[i].ReplicationDepth < replicas[j].ReplicationDepth
+		}
+		return replicas[i].Key.SmallerThan(&replicas[j].Key)
+	})
+
+	AuditOperation("change-master-credentials", nil, fmt.Sprintf("cluster: %s, user: %s, replicas: %d; starting", clusterName, masterUser, len(replicas)))
+	for _, replica := range replicas {
+		result := CredentialsChangeResult{Key: replica.Key}
+		result.Verified, err = changeReplicaMasterCredentials(&replica.Key, masterUser, masterPassword)
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			AuditOperation("change-master-credentials", &replica.Key, fmt.Sprintf("failed changing replication credentials to user %s: %s; halting", masterUser, result.Error))
+			return results, fmt.Errorf("change-master-credentials: failed on %+v, halted after %d out of %d replicas: %+v", replica.Key, len(results), len(replicas), err)
+		}
+		result.Success = true
+		results = append(results, result)
+		AuditOperation("change-master-credentials", &replica.Key, fmt.Sprintf("changed replication credentials to user %s; verified: %t", masterUser, result.Verified))
+	}
+	log.Infof("change-master-credentials: changed replication credentials on %d replicas of %s", len(results), clusterName)
+	return results, nil
+}
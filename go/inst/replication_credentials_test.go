@@ -0,0 +1,55 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package inst
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestResolvePasswordRef(t *testing.T) {
+	{
+		os.Setenv("ORCHESTRATOR_TEST_REPL_PASSWORD", "s3cr3t")
+		defer os.Unsetenv("ORCHESTRATOR_TEST_REPL_PASSWORD")
+		password, err := ResolvePasswordRef("${ORCHESTRATOR_TEST_REPL_PASSWORD}")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(password, "s3cr3t")
+	}
+	{
+		_, err := ResolvePasswordRef("${ORCHESTRATOR_TEST_UNSET_PASSWORD}")
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		file, err := ioutil.TempFile("", "orchestrator-test-password")
+		test.S(t).ExpectNil(err)
+		defer os.Remove(file.Name())
+		file.WriteString("s3cr3t\n")
+		file.Close()
+
+		password, err := ResolvePasswordRef("file:" + file.Name())
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(password, "s3cr3t")
+	}
+	{
+		_, err := ResolvePasswordRef("s3cr3t")
+		test.S(t).ExpectNotNil(err)
+		test.S(t).ExpectFalse(strings.Contains(err.Error(), "s3cr3t"))
+	}
+}
@@ -0,0 +1,156 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/github/orchestrator/go/db"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// readMasterKeysMap maps each instance of given cluster (empty for all clusters) onto its master, for those
+// instances which have a master. Only instance and master keys are read, which is all loop detection needs.
+func readMasterKeysMap(clusterName string) (masterOf map[InstanceKey]InstanceKey, err error) {
+	masterOf = make(map[InstanceKey]InstanceKey)
+	query := `
+		select
+			hostname,
+			port,
+			master_host,
+			master_port
+		from
+			database_instance
+		where
+			? in ('', cluster_name)
+	`
+	err = db.QueryOrchestrator(query, sqlutils.Args(clusterName), func(m sqlutils.RowMap) error {
+		instanceKey := InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")}
+		masterKey := InstanceKey{Hostname: m.GetString("master_host"), Port: m.GetInt("master_port")}
+		if masterKey.IsValid() {
+			masterOf[instanceKey] = masterKey
+		}
+		return nil
+	})
+	return masterOf, log.Errore(err)
+}
+
+// isSanctionedReplicationCycle returns true for co-master pairs, the one replication loop orchestrator supports
+func isSanctionedReplicationCycle(cycle []InstanceKey) bool {
+	return len(cycle) == 2
+}
+
+// findReplicationCycles walks master pointers from each instance, and returns each replication loop found, save
+// for co-master pairs. Members of each loop are listed in replication order, starting with the smallest key.
+func findReplicationCycles(masterOf map[InstanceKey]InstanceKey) [][]InstanceKey {
+	cycles := [][]InstanceKey{}
+	instanceKeys := []InstanceKey{}
+	for instanceKey := range masterOf {
+		instanceKeys = append(instanceKeys, instanceKey)
+	}
+	sort.Slice(instanceKeys, func(i, j int) bool { return instanceKeys[i].SmallerThan(&instanceKeys[j]) })
+
+	visited := make(map[InstanceKey]bool)
+	for _, startKey := range instanceKeys {
+		// position of each instance on the current walk
+		walkPositions := make(map[InstanceKey]int)
+		walk := []InstanceKey{}
+		for instanceKey, ok := startKey, true; ok; instanceKey, ok = masterOf[instanceKey] {
+			if position, onWalk := walkPositions[instanceKey]; onWalk {
+				if cycle := walk[position:]; !isSanctionedReplicationCycle(cycle) {
+					cycles = append(cycles, normalizedReplicationCycle(cycle))
+				}
+				break
+			}
+			if visited[instanceKey] {
+				// Any loop reachable from here was already found
+				break
+			}
+			visited[instanceKey] = true
+			walkPositions[instanceKey] = len(walk)
+			walk = append(walk, instanceKey)
+		}
+	}
+	return cycles
+}
+
+// normalizedReplicationCycle rotates given loop such that it starts with its smallest key
+func normalizedReplicationCycle(cycle []InstanceKey) []InstanceKey {
+	smallest := 0
+	for i := range cycle {
+		if cycle[i].SmallerThan(&cycle[smallest]) {
+			smallest = i
+		}
+	}
+	return append(append([]InstanceKey{}, cycle[smallest:]...), cycle[:smallest]...)
+}
+
+// introducedReplicationCycle returns the replication loop which would form should given instance replicate from
+// given master, or nil if none would. Co-master pairs are not considered a loop.
+func introducedReplicationCycle(instanceKey InstanceKey, masterKey InstanceKey, masterOf map[InstanceKey]InstanceKey) []InstanceKey {
+	cycle := []InstanceKey{instanceKey}
+	visited := map[InstanceKey]bool{instanceKey: true}
+	for key, ok := masterKey, true; ok; key, ok = masterOf[key] {
+		if key.Equals(&instanceKey) {
+			if isSanctionedReplicationCycle(cycle) {
+				return nil
+			}
+			return cycle
+		}
+		if visited[key] {
+			// A pre-existing loop which given instance is not part of
+			return nil
+		}
+		visited[key] = true
+		cycle = append(cycle, key)
+	}
+	return nil
+}
+
+// replicationCycleDescription presents given replication loop, e.g. "a:3306 -> b:3306 -> a:3306", where
+// each instance replicates from the one following it
+func replicationCycleDescription(cycle []InstanceKey) string {
+	tokens := []string{}
+	for _, instanceKey := range cycle {
+		tokens = append(tokens, instanceKey.DisplayString())
+	}
+	if len(cycle) > 0 {
+		tokens = append(tokens, cycle[0].DisplayString())
+	}
+	return strings.Join(tokens, " -> ")
+}
+
+// CheckReplicationCycle returns an error if having given instance replicate from given master would form a
+// replication loop, other than a co-master pair. The resulting topology is simulated based on the master's
+// cluster, as known to the backend.
+func CheckReplicationCycle(instanceKey *InstanceKey, masterKey *InstanceKey) error {
+	master, found, err := ReadInstance(masterKey)
+	if err != nil || !found {
+		return err
+	}
+	masterOf, err := readMasterKeysMap(master.ClusterName)
+	if err != nil {
+		return err
+	}
+	if cycle := introducedReplicationCycle(*instanceKey, *masterKey, masterOf); cycle != nil {
+		return fmt.Errorf("Refusing to have %+v replicate from %+v: this would form a replication loop: %s", instanceKey.DisplayString(), masterKey.DisplayString(), replicationCycleDescription(cycle))
+	}
+	return nil
+}
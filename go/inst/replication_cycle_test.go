@@ -0,0 +1,109 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+var (
+	cycleKey1 = InstanceKey{Hostname: "db-1", Port: 3306}
+	cycleKey2 = InstanceKey{Hostname: "db-2", Port: 3306}
+	cycleKey3 = InstanceKey{Hostname: "db-3", Port: 3306}
+	cycleKey4 = InstanceKey{Hostname: "db-4", Port: 3306}
+)
+
+func TestFindReplicationCycles(t *testing.T) {
+	{
+		// tree: 2 and 3 under 1, 4 under 3
+		masterOf := map[InstanceKey]InstanceKey{cycleKey2: cycleKey1, cycleKey3: cycleKey1, cycleKey4: cycleKey3}
+		test.S(t).ExpectEquals(len(findReplicationCycles(masterOf)), 0)
+	}
+	{
+		// co-masters 1 & 2, 3 under 1
+		masterOf := map[InstanceKey]InstanceKey{cycleKey1: cycleKey2, cycleKey2: cycleKey1, cycleKey3: cycleKey1}
+		test.S(t).ExpectEquals(len(findReplicationCycles(masterOf)), 0)
+	}
+	{
+		// loop 2 -> 3 -> 1 -> 2, 4 under 3
+		masterOf := map[InstanceKey]InstanceKey{cycleKey2: cycleKey3, cycleKey3: cycleKey1, cycleKey1: cycleKey2, cycleKey4: cycleKey3}
+		cycles := findReplicationCycles(masterOf)
+		test.S(t).ExpectEquals(len(cycles), 1)
+		test.S(t).ExpectEquals(len(cycles[0]), 3)
+		test.S(t).ExpectEquals(cycles[0][0], cycleKey1)
+		test.S(t).ExpectEquals(cycles[0][1], cycleKey2)
+		test.S(t).ExpectEquals(cycles[0][2], cycleKey3)
+		test.S(t).ExpectEquals(replicationCycleDescription(cycles[0]), "db-1:3306 -> db-2:3306 -> db-3:3306 -> db-1:3306")
+	}
+	{
+		// replicating from itself
+		masterOf := map[InstanceKey]InstanceKey{cycleKey1: cycleKey1}
+		test.S(t).ExpectEquals(len(findReplicationCycles(masterOf)), 1)
+	}
+}
+
+func TestIntroducedReplicationCycle(t *testing.T) {
+	// chain: 1 <- 2 <- 3
+	masterOf := map[InstanceKey]InstanceKey{cycleKey2: cycleKey1, cycleKey3: cycleKey2}
+	{
+		cycle := introducedReplicationCycle(cycleKey1, cycleKey3, masterOf)
+		test.S(t).ExpectEquals(len(cycle), 3)
+		test.S(t).ExpectEquals(cycle[0], cycleKey1)
+		test.S(t).ExpectEquals(cycle[1], cycleKey3)
+	}
+	// co-masters
+	test.S(t).ExpectTrue(introducedReplicationCycle(cycleKey1, cycleKey2, masterOf) == nil)
+	// plain relocation
+	test.S(t).ExpectTrue(introducedReplicationCycle(cycleKey3, cycleKey1, masterOf) == nil)
+	test.S(t).ExpectTrue(introducedReplicationCycle(cycleKey4, cycleKey3, masterOf) == nil)
+	{
+		// pre-existing loop which the moved instance is not part of
+		masterOf := map[InstanceKey]InstanceKey{cycleKey1: cycleKey2, cycleKey2: cycleKey3, cycleKey3: cycleKey1}
+		test.S(t).ExpectTrue(introducedReplicationCycle(cycleKey4, cycleKey1, masterOf) == nil)
+	}
+}
+
+func TestReadMasterKeysMap(t *testing.T) {
+	defer useSQLiteBackend(t)()
+
+	instances := mkTestInstances()
+	// loop 710 -> 720 -> 730 -> 710
+	instances[0].MasterKey = i720k
+	instances[1].MasterKey = i730k
+	instances[2].MasterKey = i710k
+	for _, instance := range instances {
+		instance.ClusterName = "loop"
+	}
+	test.S(t).ExpectNil(writeManyInstances(instances, true, true))
+
+	masterOf, err := readMasterKeysMap("loop")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(masterOf), 3)
+	test.S(t).ExpectEquals(masterOf[i710k], i720k)
+	test.S(t).ExpectEquals(masterOf[i730k], i710k)
+	masterOf, err = readMasterKeysMap("other")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(masterOf), 0)
+
+	analysis, err := getCircularReplicationAnalysis("", &ReplicationAnalysisHints{})
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(analysis), 1)
+	test.S(t).ExpectEquals(analysis[0].Analysis, AnalysisCode(CircularReplication))
+	test.S(t).ExpectEquals(analysis[0].AnalyzedInstanceKey, i710k)
+	test.S(t).ExpectEquals(len(analysis[0].CircularReplicationMembers), 3)
+}
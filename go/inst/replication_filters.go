@@ -0,0 +1,133 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ReplicationFilters lists the replication (SHOW SLAVE STATUS) and binary log (SHOW MASTER STATUS)
+// filters of an instance. Values are comma delimited lists, as reported by MySQL, normalized so that
+// ordering does not matter.
+type ReplicationFilters struct {
+	ReplicateDoDB            string `json:",omitempty"`
+	ReplicateIgnoreDB        string `json:",omitempty"`
+	ReplicateDoTable         string `json:",omitempty"`
+	ReplicateIgnoreTable     string `json:",omitempty"`
+	ReplicateWildDoTable     string `json:",omitempty"`
+	ReplicateWildIgnoreTable string `json:",omitempty"`
+	BinlogDoDB               string `json:",omitempty"`
+	BinlogIgnoreDB           string `json:",omitempty"`
+}
+
+// normalizeReplicationFilter sorts the entries of a comma delimited filter list
+func normalizeReplicationFilter(filter string) string {
+	entries := []string{}
+	for _, entry := range strings.Split(filter, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+// namedFilters returns the filters along with their MySQL names, in a fixed order
+func (this *ReplicationFilters) namedFilters() [][2]string {
+	return [][2]string{
+		{"replicate_do_db", this.ReplicateDoDB},
+		{"replicate_ignore_db", this.ReplicateIgnoreDB},
+		{"replicate_do_table", this.ReplicateDoTable},
+		{"replicate_ignore_table", this.ReplicateIgnoreTable},
+		{"replicate_wild_do_table", this.ReplicateWildDoTable},
+		{"replicate_wild_ignore_table", this.ReplicateWildIgnoreTable},
+		{"binlog_do_db", this.BinlogDoDB},
+		{"binlog_ignore_db", this.BinlogIgnoreDB},
+	}
+}
+
+// Normalize normalizes all filter lists
+func (this *ReplicationFilters) Normalize() {
+	this.ReplicateDoDB = normalizeReplicationFilter(this.ReplicateDoDB)
+	this.ReplicateIgnoreDB = normalizeReplicationFilter(this.ReplicateIgnoreDB)
+	this.ReplicateDoTable = normalizeReplicationFilter(this.ReplicateDoTable)
+	this.ReplicateIgnoreTable = normalizeReplicationFilter(this.ReplicateIgnoreTable)
+	this.ReplicateWildDoTable = normalizeReplicationFilter(this.ReplicateWildDoTable)
+	this.ReplicateWildIgnoreTable = normalizeReplicationFilter(this.ReplicateWildIgnoreTable)
+	this.BinlogDoDB = normalizeReplicationFilter(this.BinlogDoDB)
+	this.BinlogIgnoreDB = normalizeReplicationFilter(this.BinlogIgnoreDB)
+}
+
+// HasReplicateFilters returns true when any of the replicate_* filters is set
+func (this *ReplicationFilters) HasReplicateFilters() bool {
+	return this.ReplicateDoDB != "" || this.ReplicateIgnoreDB != "" ||
+		this.ReplicateDoTable != "" || this.ReplicateIgnoreTable != "" ||
+		this.ReplicateWildDoTable != "" || this.ReplicateWildIgnoreTable != ""
+}
+
+// IsEmpty returns true when no filter whatsoever is set
+func (this *ReplicationFilters) IsEmpty() bool {
+	return !this.HasReplicateFilters() && this.BinlogDoDB == "" && this.BinlogIgnoreDB == ""
+}
+
+// Equals returns true when both filter sets are identical
+func (this *ReplicationFilters) Equals(other *ReplicationFilters) bool {
+	return *this == *other
+}
+
+// Differences returns a description of each filter which differs between this and other
+func (this *ReplicationFilters) Differences(other *ReplicationFilters) (differences []string) {
+	otherFilters := other.namedFilters()
+	for i, filter := range this.namedFilters() {
+		if filter[1] != otherFilters[i][1] {
+			differences = append(differences, fmt.Sprintf("%s ('%s' vs '%s')", filter[0], filter[1], otherFilters[i][1]))
+		}
+	}
+	return differences
+}
+
+// String returns a human readable description of the filters which are set
+func (this *ReplicationFilters) String() string {
+	descriptions := []string{}
+	for _, filter := range this.namedFilters() {
+		if filter[1] != "" {
+			descriptions = append(descriptions, fmt.Sprintf("%s=%s", filter[0], filter[1]))
+		}
+	}
+	return strings.Join(descriptions, "; ")
+}
+
+// ToJSONString returns the filters as JSON, or an empty string when there are none
+func (this *ReplicationFilters) ToJSONString() string {
+	if this.IsEmpty() {
+		return ""
+	}
+	bytes, _ := json.Marshal(this)
+	return string(bytes)
+}
+
+// ReadJson populates the filters from given JSON string. An empty string means no filters.
+func (this *ReplicationFilters) ReadJson(jsonString string) error {
+	*this = ReplicationFilters{}
+	if jsonString == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(jsonString), this)
+}
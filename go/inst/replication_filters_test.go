@@ -0,0 +1,73 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestNormalizeReplicationFilter(t *testing.T) {
+	test.S(t).ExpectEquals(normalizeReplicationFilter(""), "")
+	test.S(t).ExpectEquals(normalizeReplicationFilter("db1"), "db1")
+	test.S(t).ExpectEquals(normalizeReplicationFilter("db2,db1"), "db1,db2")
+	test.S(t).ExpectEquals(normalizeReplicationFilter(" db2, db1 ,"), "db1,db2")
+}
+
+func TestReplicationFiltersEquals(t *testing.T) {
+	filters := ReplicationFilters{}
+	test.S(t).ExpectTrue(filters.IsEmpty())
+	test.S(t).ExpectTrue(filters.Equals(&ReplicationFilters{}))
+
+	filters.ReplicateDoDB = "db1"
+	test.S(t).ExpectFalse(filters.IsEmpty())
+	test.S(t).ExpectTrue(filters.HasReplicateFilters())
+	test.S(t).ExpectFalse(filters.Equals(&ReplicationFilters{}))
+	test.S(t).ExpectTrue(filters.Equals(&ReplicationFilters{ReplicateDoDB: "db1"}))
+
+	binlogFilters := ReplicationFilters{BinlogDoDB: "db1"}
+	test.S(t).ExpectFalse(binlogFilters.IsEmpty())
+	test.S(t).ExpectFalse(binlogFilters.HasReplicateFilters())
+}
+
+func TestReplicationFiltersDifferences(t *testing.T) {
+	filters := ReplicationFilters{ReplicateDoDB: "db1", BinlogIgnoreDB: "db3"}
+	other := ReplicationFilters{ReplicateDoDB: "db2", BinlogIgnoreDB: "db3"}
+	differences := filters.Differences(&other)
+	test.S(t).ExpectEquals(len(differences), 1)
+	test.S(t).ExpectEquals(differences[0], "replicate_do_db ('db1' vs 'db2')")
+	test.S(t).ExpectEquals(len(filters.Differences(&filters)), 0)
+}
+
+func TestReplicationFiltersJSON(t *testing.T) {
+	filters := ReplicationFilters{}
+	test.S(t).ExpectEquals(filters.ToJSONString(), "")
+
+	filters.ReplicateIgnoreTable = "db1.t1,db1.t2"
+	jsonString := filters.ToJSONString()
+	test.S(t).ExpectEquals(jsonString, `{"ReplicateIgnoreTable":"db1.t1,db1.t2"}`)
+
+	read := ReplicationFilters{BinlogDoDB: "db1"}
+	err := read.ReadJson(jsonString)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(read.Equals(&filters))
+
+	err = read.ReadJson("")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(read.IsEmpty())
+}
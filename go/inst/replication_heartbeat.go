@@ -0,0 +1,112 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/openark/golib/sqlutils"
+)
+
+// HeartbeatStallWindowSeconds is the age beyond which a replica's last heartbeat is considered overdue:
+// twice the heartbeat period. Returns 0 when the heartbeat period is unknown or heartbeats are disabled.
+func (this *Instance) HeartbeatStallWindowSeconds() uint {
+	if this.HeartbeatPeriodSeconds <= 0 {
+		return 0
+	}
+	return uint(math.Ceil(2 * this.HeartbeatPeriodSeconds))
+}
+
+// IsHeartbeatOverdue returns true when this replica has not received a heartbeat within twice its heartbeat period
+func (this *Instance) IsHeartbeatOverdue() bool {
+	window := this.HeartbeatStallWindowSeconds()
+	if window == 0 || !this.SecondsSinceLastHeartbeat.Valid {
+		return false
+	}
+	return this.SecondsSinceLastHeartbeat.Int64 > int64(window)
+}
+
+// IsReplicationStalled returns true when this replica's heartbeat is overdue while its master is known to be
+// writing. Given are the master's and this replica's coordinates as recorded at least a heartbeat stall window ago.
+// A master only sends heartbeats when it has no events to send; the replica is stalled when its master's binary
+// logs advanced since, yet it received neither events (its relay log did not advance) nor heartbeats.
+func (this *Instance) IsReplicationStalled(master *Instance, masterPreviousCoordinates *BinlogCoordinates, previousRelaylogCoordinates *BinlogCoordinates) bool {
+	if !this.IsHeartbeatOverdue() || !this.ReplicationIOThreadState.IsRunning() {
+		return false
+	}
+	if masterPreviousCoordinates == nil || previousRelaylogCoordinates == nil {
+		return false
+	}
+	if !masterPreviousCoordinates.SmallerThan(&master.SelfBinlogCoordinates) {
+		// Master not known to be writing
+		return false
+	}
+	return this.RelaylogCoordinates.Equals(previousRelaylogCoordinates)
+}
+
+// populateReplicationHeartbeat reads the replication timeout and heartbeat status of given replica. As of 5.7
+// these are found in performance_schema (default, or else first, channel); earlier versions and MariaDB expose
+// heartbeat status variables.
+func populateReplicationHeartbeat(instance *Instance, db *discoveryDB) error {
+	if err := db.QueryRow("select @@global.slave_net_timeout").Scan(&instance.SlaveNetTimeout); err != nil {
+		return err
+	}
+	if (instance.IsOracleMySQL() || instance.IsPercona()) && !instance.IsSmallerMajorVersionByString("5.7") {
+		query := `
+			select
+				replication_connection_configuration.HEARTBEAT_INTERVAL as heartbeat_period,
+				replication_connection_status.COUNT_RECEIVED_HEARTBEATS as received_heartbeats,
+				if(replication_connection_status.COUNT_RECEIVED_HEARTBEATS > 0,
+					timestampdiff(second, replication_connection_status.LAST_HEARTBEAT_TIMESTAMP, now()),
+					null
+				) as seconds_since_last_heartbeat
+			from
+				performance_schema.replication_connection_configuration
+				join performance_schema.replication_connection_status using (CHANNEL_NAME)
+			order by
+				CHANNEL_NAME
+			limit 1
+			`
+		return db.QueryRowsMap(query, func(m sqlutils.RowMap) error {
+			instance.HeartbeatPeriodSeconds, _ = strconv.ParseFloat(m.GetString("heartbeat_period"), 64)
+			instance.ReceivedHeartbeats = m.GetInt64("received_heartbeats")
+			instance.SecondsSinceLastHeartbeat = m.GetNullInt64("seconds_since_last_heartbeat")
+			return nil
+		})
+	}
+	lastHeartbeat := ""
+	err := db.QueryRowsMap("show global status like 'Slave_%heartbeat%'", func(m sqlutils.RowMap) error {
+		switch m.GetString("Variable_name") {
+		case "Slave_heartbeat_period":
+			instance.HeartbeatPeriodSeconds, _ = strconv.ParseFloat(m.GetString("Value"), 64)
+		case "Slave_received_heartbeats":
+			instance.ReceivedHeartbeats = m.GetInt64("Value")
+		case "Slave_last_heartbeat":
+			lastHeartbeat = m.GetString("Value")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if lastHeartbeat == "" || instance.ReceivedHeartbeats == 0 {
+		// MariaDB does not expose the time of last heartbeat
+		return nil
+	}
+	return db.QueryRow("select timestampdiff(second, ?, now())", lastHeartbeat).Scan(&instance.SecondsSinceLastHeartbeat)
+}
@@ -0,0 +1,74 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package inst
+
+import (
+	"database/sql"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestIsHeartbeatOverdue(t *testing.T) {
+	replica := &Instance{Key: key2, HeartbeatPeriodSeconds: 2.5}
+	test.S(t).ExpectEquals(replica.HeartbeatStallWindowSeconds(), uint(5))
+	test.S(t).ExpectFalse(replica.IsHeartbeatOverdue())
+
+	replica.SecondsSinceLastHeartbeat = sql.NullInt64{Int64: 5, Valid: true}
+	test.S(t).ExpectFalse(replica.IsHeartbeatOverdue())
+	replica.SecondsSinceLastHeartbeat.Int64 = 6
+	test.S(t).ExpectTrue(replica.IsHeartbeatOverdue())
+
+	replica.HeartbeatPeriodSeconds = 0
+	test.S(t).ExpectEquals(replica.HeartbeatStallWindowSeconds(), uint(0))
+	test.S(t).ExpectFalse(replica.IsHeartbeatOverdue())
+}
+
+func TestIsReplicationStalled(t *testing.T) {
+	master := &Instance{Key: key1, SelfBinlogCoordinates: BinlogCoordinates{LogFile: "mysql-bin.000012", LogPos: 5000}}
+	replica := &Instance{
+		Key:                       key2,
+		MasterKey:                 key1,
+		ReplicationIOThreadState:  ReplicationThreadStateRunning,
+		RelaylogCoordinates:       BinlogCoordinates{LogFile: "relay-bin.000003", LogPos: 800, Type: RelayLog},
+		HeartbeatPeriodSeconds:    30,
+		SecondsSinceLastHeartbeat: sql.NullInt64{Int64: 90, Valid: true},
+	}
+	masterPreviousCoordinates := &BinlogCoordinates{LogFile: "mysql-bin.000012", LogPos: 4000}
+	previousRelaylogCoordinates := &BinlogCoordinates{LogFile: "relay-bin.000003", LogPos: 800, Type: RelayLog}
+	test.S(t).ExpectTrue(replica.IsReplicationStalled(master, masterPreviousCoordinates, previousRelaylogCoordinates))
+
+	// Unknown history
+	test.S(t).ExpectFalse(replica.IsReplicationStalled(master, nil, previousRelaylogCoordinates))
+	test.S(t).ExpectFalse(replica.IsReplicationStalled(master, masterPreviousCoordinates, nil))
+
+	// Idle master
+	test.S(t).ExpectFalse(replica.IsReplicationStalled(master, &master.SelfBinlogCoordinates, previousRelaylogCoordinates))
+
+	// Replica receives events
+	previousRelaylogCoordinates.LogPos = 700
+	test.S(t).ExpectFalse(replica.IsReplicationStalled(master, masterPreviousCoordinates, previousRelaylogCoordinates))
+	previousRelaylogCoordinates.LogPos = 800
+
+	// Recent heartbeat
+	replica.SecondsSinceLastHeartbeat.Int64 = 20
+	test.S(t).ExpectFalse(replica.IsReplicationStalled(master, masterPreviousCoordinates, previousRelaylogCoordinates))
+	replica.SecondsSinceLastHeartbeat.Int64 = 90
+
+	// IO thread not running is a different problem
+	replica.ReplicationIOThreadState = ReplicationThreadStateStopped
+	test.S(t).ExpectFalse(replica.IsReplicationStalled(master, masterPreviousCoordinates, previousRelaylogCoordinates))
+}
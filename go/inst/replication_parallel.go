@@ -0,0 +1,73 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"github.com/openark/golib/sqlutils"
+)
+
+// hasReplicationGaps returns true when any parallel replication worker has executed beyond the coordinator's
+// executed coordinates (the low-water mark). Transactions in between may or may not have been executed, hence
+// the executed coordinates cannot be trusted for repositioning.
+func hasReplicationGaps(execCoordinates BinlogCoordinates, workersCoordinates []BinlogCoordinates) bool {
+	if execCoordinates.IsEmpty() {
+		return false
+	}
+	for _, workerCoordinates := range workersCoordinates {
+		if workerCoordinates.IsEmpty() {
+			continue
+		}
+		if execCoordinates.SmallerThan(&workerCoordinates) {
+			return true
+		}
+	}
+	return false
+}
+
+// populateReplicationParallelism reads the number of parallel replication workers of given replica, and, if
+// its SQL thread is stopped, whether its workers left gaps behind. Gaps are detected via mysql.slave_worker_info,
+// which is only populated with relay_log_info_repository=TABLE. MariaDB does not leave gaps upon stopping.
+func populateReplicationParallelism(instance *Instance, db *discoveryDB) error {
+	instance.SlaveParallelWorkers = 0
+	instance.HasReplicationGaps = false
+	switch {
+	case instance.IsMariaDB() && !instance.IsSmallerMajorVersionByString("10.0"):
+		return db.QueryRow("select @@global.slave_parallel_threads").Scan(&instance.SlaveParallelWorkers)
+	case (instance.IsOracleMySQL() || instance.IsPercona()) && !instance.IsSmallerMajorVersionByString("5.6"):
+		if err := db.QueryRow("select @@global.slave_parallel_workers").Scan(&instance.SlaveParallelWorkers); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+	if instance.SlaveParallelWorkers == 0 || instance.ReplicationSQLThreadState.IsRunning() {
+		return nil
+	}
+	workersCoordinates := []BinlogCoordinates{}
+	err := db.QueryRowsMap("select Master_log_name, Master_log_pos from mysql.slave_worker_info", func(m sqlutils.RowMap) error {
+		workersCoordinates = append(workersCoordinates, BinlogCoordinates{
+			LogFile: m.GetString("Master_log_name"),
+			LogPos:  m.GetInt64("Master_log_pos"),
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	instance.HasReplicationGaps = hasReplicationGaps(instance.ExecBinlogCoordinates, workersCoordinates)
+	return nil
+}
@@ -0,0 +1,32 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestHasReplicationGaps(t *testing.T) {
+	exec := BinlogCoordinates{LogFile: "mysql-bin.000017", LogPos: 1000}
+	test.S(t).ExpectFalse(hasReplicationGaps(exec, nil))
+	test.S(t).ExpectFalse(hasReplicationGaps(exec, []BinlogCoordinates{exec, {}}))
+	test.S(t).ExpectFalse(hasReplicationGaps(exec, []BinlogCoordinates{{LogFile: "mysql-bin.000016", LogPos: 5000}}))
+	test.S(t).ExpectTrue(hasReplicationGaps(exec, []BinlogCoordinates{exec, {LogFile: "mysql-bin.000017", LogPos: 1200}}))
+	test.S(t).ExpectTrue(hasReplicationGaps(exec, []BinlogCoordinates{{LogFile: "mysql-bin.000018", LogPos: 4}}))
+	test.S(t).ExpectFalse(hasReplicationGaps(BinlogCoordinates{}, []BinlogCoordinates{exec}))
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/patrickmn/go-cache"
 	"net"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -47,6 +48,14 @@ func (this HostnameUnresolve) String() string {
 	return fmt.Sprintf("%s %s", this.hostname, this.unresolvedHostname)
 }
 
+// HostnameResolveCacheEntry describes an entry of the in-memory hostname resolve cache
+type HostnameResolveCacheEntry struct {
+	Hostname          string
+	ResolvedHostname  string
+	ExpiresAt         time.Time
+	ResolvedTimestamp string // as persisted in the hostname_resolve backend table; empty if not persisted
+}
+
 type HostnameRegistration struct {
 	CreatedAt time.Time
 	Key       InstanceKey
@@ -225,17 +234,72 @@ func FlushNontrivialResolveCacheToDatabase() error {
 	return nil
 }
 
+// ResetHostnameResolveCache is a synonym to FlushAllResolveCache
 func ResetHostnameResolveCache() error {
+	return FlushAllResolveCache()
+}
+
+// FlushAllResolveCache purges all hostname resolves, both in-memory and in the backend database, such that
+// all hostnames are resolved afresh upon next use.
+func FlushAllResolveCache() error {
 	err := deleteHostnameResolves()
 	getHostnameResolvesLightweightCache().Flush()
+	hostnameIPsCache.Flush()
 	hostnameResolvesLightweightCacheLoadedOnceFromDB = false
 	return err
 }
 
+// FlushResolveHostname purges given hostname from the hostname resolve caches, both in-memory and in the
+// backend database. This is useful when a DNS record is repointed.
+func FlushResolveHostname(hostname string) error {
+	hostname = strings.TrimSpace(hostname)
+	if hostname == "" {
+		return errors.New("FlushResolveHostname: empty hostname")
+	}
+	forgetHostnameResolves(map[string]bool{hostname: true})
+	return deleteHostnameResolve(hostname)
+}
+
+// forgetHostnameResolves purges given hostnames from the hostname resolve caches: both entries resolving
+// these hostnames and entries resolving into these hostnames.
+func forgetHostnameResolves(hostnames map[string]bool) {
+	resolveCache := getHostnameResolvesLightweightCache()
+	for hostname, item := range resolveCache.Items() {
+		if resolvedHostname, ok := item.Object.(string); hostnames[hostname] || (ok && hostnames[resolvedHostname]) {
+			resolveCache.Delete(hostname)
+		}
+	}
+	for hostname := range hostnames {
+		hostnameIPsCache.Delete(hostname)
+	}
+}
+
 func HostnameResolveCache() (map[string]cache.Item, error) {
 	return getHostnameResolvesLightweightCache().Items(), nil
 }
 
+// ResolveCacheEntries returns the content of the in-memory hostname resolve cache, sorted by hostname,
+// along with expiry and persisted resolve timestamps.
+func ResolveCacheEntries() (entries []HostnameResolveCacheEntry, err error) {
+	resolvedTimestamps, err := readHostnameResolveTimestamps()
+	if err != nil {
+		return entries, err
+	}
+	for hostname, item := range getHostnameResolvesLightweightCache().Items() {
+		entry := HostnameResolveCacheEntry{
+			Hostname:          hostname,
+			ResolvedTimestamp: resolvedTimestamps[hostname],
+		}
+		entry.ResolvedHostname, _ = item.Object.(string)
+		if item.Expiration > 0 {
+			entry.ExpiresAt = time.Unix(0, item.Expiration)
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Hostname < entries[j].Hostname })
+	return entries, nil
+}
+
 func UnresolveHostname(instanceKey *InstanceKey) (InstanceKey, bool, error) {
 	if *config.RuntimeCLIFlags.SkipUnresolve {
 		return *instanceKey, false, nil
@@ -315,6 +315,36 @@ func DeleteInvalidHostnameResolves() error {
 	return err
 }
 
+// deleteHostnameResolve removes resolves of, or into, given hostname
+func deleteHostnameResolve(hostname string) error {
+	_, err := db.ExecOrchestrator(`
+			delete
+				from hostname_resolve
+			where
+				hostname = ?
+				or resolved_hostname = ?`,
+		hostname, hostname,
+	)
+	return err
+}
+
+// readHostnameResolveTimestamps returns the resolve timestamp of all persisted resolves, mapped by hostname
+func readHostnameResolveTimestamps() (map[string]string, error) {
+	resolvedTimestamps := make(map[string]string)
+	query := `
+		select
+			hostname,
+			resolved_timestamp
+		from
+			hostname_resolve
+		`
+	err := db.QueryOrchestratorRowsMap(query, func(m sqlutils.RowMap) error {
+		resolvedTimestamps[m.GetString("hostname")] = m.GetString("resolved_timestamp")
+		return nil
+	})
+	return resolvedTimestamps, log.Errore(err)
+}
+
 // deleteHostnameResolves compeltely erases the database cache
 func deleteHostnameResolves() error {
 	_, err := db.ExecOrchestrator(`
@@ -90,8 +90,9 @@ func ParseIntersectTags(tagsString string) (tags [](*Tag), err error) {
 }
 
 type InstanceTag struct {
-	Key InstanceKey
-	T   Tag
+	Key           InstanceKey
+	T             Tag
+	ExpireSeconds uint
 }
 
 func GetInstanceKeysByTags(tagsString string) (tagged *InstanceKeyMap, err error) {
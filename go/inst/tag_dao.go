@@ -25,22 +25,32 @@ import (
 )
 
 func PutInstanceTag(instanceKey *InstanceKey, tag *Tag) (err error) {
-	_, err = db.ExecOrchestrator(`
+	return PutExpiringInstanceTag(instanceKey, tag, 0)
+}
+
+// PutExpiringInstanceTag tags given instance. The tag expires after given number of seconds,
+// or never expires if expireSeconds is 0.
+func PutExpiringInstanceTag(instanceKey *InstanceKey, tag *Tag, expireSeconds uint) (err error) {
+	expireTimestamp := `NULL`
+	args := sqlutils.Args(instanceKey.Hostname, instanceKey.Port, tag.TagName, tag.TagValue)
+	if expireSeconds > 0 {
+		expireTimestamp = `NOW() + interval ? second`
+		args = append(args, expireSeconds)
+	}
+	query := fmt.Sprintf(`
 			insert
 				into database_instance_tags (
-					hostname, port, tag_name, tag_value, last_updated
+					hostname, port, tag_name, tag_value, last_updated, expire_timestamp
 				) VALUES (
-					?, ?, ?, ?, NOW()
+					?, ?, ?, ?, NOW(), %s
 				)
 				on duplicate key update
 					tag_value=values(tag_value),
-					last_updated=values(last_updated)
-			`,
-		instanceKey.Hostname,
-		instanceKey.Port,
-		tag.TagName,
-		tag.TagValue,
+					last_updated=values(last_updated),
+					expire_timestamp=values(expire_timestamp)
+			`, expireTimestamp,
 	)
+	_, err = db.ExecOrchestrator(query, args...)
 	return err
 }
 
@@ -109,6 +119,7 @@ func ReadInstanceTag(instanceKey *InstanceKey, tag *Tag) (tagExists bool, err er
 			hostname = ?
 			and port = ?
 			and tag_name = ?
+			and (expire_timestamp is null or expire_timestamp > now())
 			`
 	args := sqlutils.Args(instanceKey.Hostname, instanceKey.Port, tag.TagName)
 	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
@@ -134,6 +145,7 @@ func ReadInstanceTags(instanceKey *InstanceKey) (tags [](*Tag), err error) {
 		where
 			hostname = ?
 			and port = ?
+			and (expire_timestamp is null or expire_timestamp > now())
 		order by tag_name
 			`
 	args := sqlutils.Args(instanceKey.Hostname, instanceKey.Port)
@@ -180,7 +192,8 @@ func GetInstanceKeysByTag(tag *Tag) (tagged *InstanceKeyMap, err error) {
 		from
 			database_instance_tags
 		where
-			%s
+			(expire_timestamp is null or expire_timestamp > now())
+			and %s
 		order by hostname, port
 		`, clause)
 	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
@@ -190,3 +203,55 @@ func GetInstanceKeysByTag(tag *Tag) (tagged *InstanceKeyMap, err error) {
 	})
 	return tagged, log.Errore(err)
 }
+
+// ReadInstancesByTag reads instances tagged by given tags string (see ParseIntersectTags).
+// If clusterName is non empty, only instances of that cluster are returned.
+func ReadInstancesByTag(clusterName string, tagsString string) (instances [](*Instance), err error) {
+	instances = [](*Instance){}
+	tagged, err := GetInstanceKeysByTags(tagsString)
+	if err != nil {
+		return instances, err
+	}
+	for _, instanceKey := range tagged.GetInstanceKeys() {
+		instanceKey := instanceKey
+		instance, found, err := ReadInstance(&instanceKey)
+		if err != nil {
+			return instances, err
+		}
+		if !found {
+			continue
+		}
+		if clusterName != "" && instance.ClusterName != clusterName {
+			continue
+		}
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+// deleteInstanceTags removes all tags of given instance
+func deleteInstanceTags(instanceKey *InstanceKey) error {
+	_, err := db.ExecOrchestrator(`
+			delete from
+				database_instance_tags
+			where
+				hostname = ?
+				and port = ?
+			`,
+		instanceKey.Hostname,
+		instanceKey.Port,
+	)
+	return log.Errore(err)
+}
+
+// ExpireInstanceTags removes tags whose expiry time has passed
+func ExpireInstanceTags() error {
+	_, err := db.ExecOrchestrator(`
+			delete from
+				database_instance_tags
+			where
+				expire_timestamp < now()
+			`,
+	)
+	return log.Errore(err)
+}
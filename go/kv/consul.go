@@ -75,7 +75,10 @@ func (this *consulStore) GetKeyValue(key string) (value string, found bool, err
 	if err != nil {
 		return value, found, err
 	}
-	return string(pair.Value), (pair != nil), nil
+	if pair == nil {
+		return value, false, nil
+	}
+	return string(pair.Value), true, nil
 }
 
 func (this *consulStore) DistributePairs(kvPairs [](*KVPair)) (err error) {
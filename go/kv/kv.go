@@ -75,6 +75,41 @@ func GetValue(key string) (value string, found bool, err error) {
 	return value, found, err
 }
 
+// StoreValue is the value of a key as read from a single KV store
+type StoreValue struct {
+	Store string
+	Value string
+	Found bool
+	Err   error
+}
+
+// GetStoreValues reads given key from each KV store in use: the internal store, and Consul and ZooKeeper
+// where configured. Unlike GetValue, this tells whether external stores are reachable and agree.
+func GetStoreValues(key string) (values []StoreValue) {
+	for _, store := range getKVStores() {
+		var storeName string
+		switch store := store.(type) {
+		case *internalKVStore:
+			storeName = "internal"
+		case *consulStore:
+			if store.client == nil {
+				continue
+			}
+			storeName = "consul"
+		case *zkStore:
+			if store.zook == nil {
+				continue
+			}
+			storeName = "zk"
+		default:
+			storeName = fmt.Sprintf("%T", store)
+		}
+		value, found, err := store.GetKeyValue(key)
+		values = append(values, StoreValue{Store: storeName, Value: value, Found: found, Err: err})
+	}
+	return values
+}
+
 func PutValue(key string, value string) (err error) {
 	for _, store := range getKVStores() {
 		if err := store.PutKeyValue(key, value); err != nil {
@@ -69,6 +69,9 @@ func (this *zkStore) GetKeyValue(key string) (value string, found bool, err erro
 		return value, false, nil
 	}
 	result, err := this.zook.Get(normalizeKey(key))
+	if err == zkconstants.ErrNoNode {
+		return value, false, nil
+	}
 	if err != nil {
 		return value, false, err
 	}
@@ -0,0 +1,96 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/discovery"
+	"github.com/github/orchestrator/go/inst"
+	orcraft "github.com/github/orchestrator/go/raft"
+
+	"github.com/openark/golib/log"
+)
+
+// readTopologyInstanceCoordinates reads the coordinates of a single instance; overridden in tests
+var readTopologyInstanceCoordinates = inst.ReadTopologyInstanceCoordinates
+
+// SnapshotClusterCoordinates reads the binary log coordinates and executed GTID sets of all instances of given
+// cluster, in parallel and within ClusterCoordinatesSnapshotTimeoutSeconds, and stores the snapshot.
+// Reads are as concurrent as discovery of the default discovery queue is. Instances which could not be read
+// in time are marked as failed; the snapshot is returned and stored nonetheless.
+func SnapshotClusterCoordinates(clusterName string) (*inst.ClusterCoordinatesSnapshot, error) {
+	instances, err := inst.ReadClusterInstances(clusterName)
+	if err != nil {
+		return nil, log.Errore(err)
+	}
+	if len(instances) == 0 {
+		return nil, log.Errorf("No instances found for cluster %s", clusterName)
+	}
+	snapshot := inst.NewClusterCoordinatesSnapshot(clusterName)
+	for _, instance := range instances {
+		snapshot.AddInstance(&inst.InstanceCoordinatesSnapshot{Key: instance.Key, Error: "not read within snapshot deadline"})
+	}
+
+	concurrency := int(discovery.CreateOrReturnQueue(config.DefaultDiscoveryQueue).MaxConcurrency())
+	if concurrency == 0 || concurrency > len(instances) {
+		concurrency = len(instances)
+	}
+	timeout := time.Duration(config.Config.ClusterCoordinatesSnapshotTimeoutSeconds) * time.Second
+	deadline := time.Now().Add(timeout)
+
+	concurrencyChan := make(chan bool, concurrency)
+	// buffered, such that reads completing past the deadline do not block
+	resultsChan := make(chan *inst.InstanceCoordinatesSnapshot, len(instances))
+	for _, instance := range instances {
+		instance := instance
+		go func() {
+			concurrencyChan <- true
+			defer func() { <-concurrencyChan }()
+
+			if remaining := time.Until(deadline); remaining > 0 {
+				resultsChan <- readTopologyInstanceCoordinates(instance, remaining)
+			}
+		}()
+	}
+	timedOut := time.After(timeout)
+collectResults:
+	for range instances {
+		select {
+		case instanceSnapshot := <-resultsChan:
+			snapshot.AddInstance(instanceSnapshot)
+		case <-timedOut:
+			break collectResults
+		}
+	}
+	if countFailed := snapshot.CountFailed(); countFailed > 0 {
+		log.Warningf("SnapshotClusterCoordinates: %d out of %d instances of %s could not be read", countFailed, len(instances), clusterName)
+	}
+
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("write-cluster-coordinates-snapshot", snapshot)
+	} else {
+		err = inst.WriteClusterCoordinatesSnapshot(snapshot)
+	}
+	if err != nil {
+		return snapshot, fmt.Errorf("Snapshot taken but not stored: %+v", err)
+	}
+	inst.AuditOperation("snapshot-cluster-coordinates", nil, fmt.Sprintf("cluster: %s, instances: %d, failed: %d", clusterName, len(instances), snapshot.CountFailed()))
+	return snapshot, nil
+}
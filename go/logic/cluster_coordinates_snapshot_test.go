@@ -0,0 +1,99 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/inst"
+	test "github.com/openark/golib/tests"
+)
+
+func TestSnapshotClusterCoordinates(t *testing.T) {
+	defer useSQLiteBackend(t)()
+	defer func(seconds uint) { config.Config.ClusterCoordinatesSnapshotTimeoutSeconds = seconds }(config.Config.ClusterCoordinatesSnapshotTimeoutSeconds)
+	config.Config.ClusterCoordinatesSnapshotTimeoutSeconds = 1
+	defer func(read func(*inst.Instance, time.Duration) *inst.InstanceCoordinatesSnapshot) {
+		readTopologyInstanceCoordinates = read
+	}(readTopologyInstanceCoordinates)
+
+	for _, hostname := range []string{"ok", "broken", "slow"} {
+		_, err := db.ExecOrchestrator(`
+			insert into database_instance (
+				hostname, port, cluster_name, last_checked, last_seen, server_id, version, binlog_format, log_bin, log_slave_updates,
+				binary_log_file, binary_log_pos, master_host, master_port, slave_sql_running, slave_io_running, master_log_file,
+				read_master_log_pos, relay_master_log_file, exec_master_log_pos, num_slave_hosts, slave_hosts
+			) values (?, 3306, 'snapshot', now(), now(), 1, '5.7.26', 'ROW', 1, 1, '', 0, '', 0, 0, 0, '', 0, '', 0, 0, '')`,
+			hostname,
+		)
+		test.S(t).ExpectNil(err)
+	}
+	release := make(chan bool)
+	defer close(release)
+	readTopologyInstanceCoordinates = func(instance *inst.Instance, timeout time.Duration) *inst.InstanceCoordinatesSnapshot {
+		instanceSnapshot := &inst.InstanceCoordinatesSnapshot{Key: instance.Key}
+		switch instance.Key.Hostname {
+		case "ok":
+			instanceSnapshot.Success = true
+			instanceSnapshot.SelfBinlogCoordinates = inst.BinlogCoordinates{LogFile: "mysql-bin.000017", LogPos: 4}
+		case "broken":
+			instanceSnapshot.Error = "connection refused"
+		case "slow":
+			// still reading once the deadline passes
+			<-release
+			instanceSnapshot.Success = true
+		}
+		return instanceSnapshot
+	}
+
+	snapshot, err := SnapshotClusterCoordinates("snapshot")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(snapshot.Instances), 3)
+	test.S(t).ExpectEquals(snapshot.CountFailed(), 2)
+	test.S(t).ExpectTrue(snapshot.Instances["ok:3306"].Success)
+	test.S(t).ExpectEquals(snapshot.Instances["broken:3306"].Error, "connection refused")
+	test.S(t).ExpectEquals(snapshot.Instances["slow:3306"].Error, "not read within snapshot deadline")
+
+	stored, err := inst.ReadLastClusterCoordinatesSnapshot("snapshot")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(stored.Instances), 3)
+	test.S(t).ExpectEquals(stored.CountFailed(), 2)
+	test.S(t).ExpectEquals(stored.Instances["ok:3306"].SelfBinlogCoordinates, snapshot.Instances["ok:3306"].SelfBinlogCoordinates)
+
+	_, err = SnapshotClusterCoordinates("no-such-cluster")
+	test.S(t).ExpectNotNil(err)
+}
+
+func TestApplyWriteClusterCoordinatesSnapshot(t *testing.T) {
+	defer useSQLiteBackend(t)()
+
+	snapshot := inst.NewClusterCoordinatesSnapshot("applied")
+	snapshot.AddInstance(&inst.InstanceCoordinatesSnapshot{Key: inst.InstanceKey{Hostname: "ok", Port: 3306}, Success: true, ExecutedGtidSet: "00020194-3333-3333-3333-333333333333:1-7"})
+	value, err := json.Marshal(snapshot)
+	test.S(t).ExpectNil(err)
+	response := NewCommandApplier().ApplyCommand("write-cluster-coordinates-snapshot", value)
+	test.S(t).ExpectTrue(response == nil)
+
+	stored, err := inst.ReadLastClusterCoordinatesSnapshot("applied")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(fmt.Sprintf("%+v", *stored.Instances["ok:3306"]), fmt.Sprintf("%+v", *snapshot.Instances["ok:3306"]))
+}
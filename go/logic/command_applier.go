@@ -17,6 +17,7 @@
 package logic
 
 import (
+	"context"
 	"encoding/json"
 
 	"github.com/github/orchestrator/go/inst"
@@ -51,10 +52,24 @@ func (applier *CommandApplier) ApplyCommand(op string, value []byte) interface{}
 		return applier.forget(value)
 	case "forget-cluster":
 		return applier.forgetCluster(value)
+	case "forget-by-pattern":
+		return applier.forgetByPattern(value)
 	case "begin-downtime":
 		return applier.beginDowntime(value)
 	case "end-downtime":
 		return applier.endDowntime(value)
+	case "extend-downtime":
+		return applier.extendDowntime(value)
+	case "begin-downtime-cluster":
+		return applier.beginDowntimeCluster(value)
+	case "end-downtime-cluster":
+		return applier.endDowntimeCluster(value)
+	case "ignore-health-checks":
+		return applier.ignoreHealthChecks(value)
+	case "unignore-health-checks":
+		return applier.unignoreHealthChecks(value)
+	case "write-cluster-coordinates-snapshot":
+		return applier.writeClusterCoordinatesSnapshot(value)
 	case "register-candidate":
 		return applier.registerCandidate(value)
 	case "ack-recovery":
@@ -71,10 +86,22 @@ func (applier *CommandApplier) ApplyCommand(op string, value []byte) interface{}
 		return applier.writeRecoveryStep(value)
 	case "resolve-recovery":
 		return applier.resolveRecovery(value)
+	case "resolve-simulated-recovery":
+		return applier.resolveSimulatedRecovery(value)
+	case "update-recovery-lost-replicas":
+		return applier.updateRecoveryLostReplicas(value)
 	case "disable-global-recoveries":
 		return applier.disableGlobalRecoveries(value)
 	case "enable-global-recoveries":
 		return applier.enableGlobalRecoveries(value)
+	case "disable-cluster-recoveries":
+		return applier.disableClusterRecoveries(value)
+	case "enable-cluster-recoveries":
+		return applier.enableClusterRecoveries(value)
+	case "begin-graceful-master-takeover":
+		return applier.beginGracefulMasterTakeover(value)
+	case "end-graceful-master-takeover":
+		return applier.endGracefulMasterTakeover(value)
 	case "put-key-value":
 		return applier.putKeyValue(value)
 	case "put-instance-tag":
@@ -105,7 +132,7 @@ func (applier *CommandApplier) discover(value []byte) interface{} {
 	if err := json.Unmarshal(value, &instanceKey); err != nil {
 		return log.Errore(err)
 	}
-	DiscoverInstance(instanceKey)
+	DiscoverInstance(context.Background(), instanceKey, "raft")
 	return nil
 }
 
@@ -132,7 +159,16 @@ func (applier *CommandApplier) forgetCluster(value []byte) interface{} {
 	if err := json.Unmarshal(value, &clusterName); err != nil {
 		return log.Errore(err)
 	}
-	err := inst.ForgetCluster(clusterName)
+	err := ForgetCluster(clusterName)
+	return err
+}
+
+func (applier *CommandApplier) forgetByPattern(value []byte) interface{} {
+	var pattern string
+	if err := json.Unmarshal(value, &pattern); err != nil {
+		return log.Errore(err)
+	}
+	err := ForgetInstancesByPattern(pattern)
 	return err
 }
 
@@ -154,6 +190,69 @@ func (applier *CommandApplier) endDowntime(value []byte) interface{} {
 	return err
 }
 
+func (applier *CommandApplier) extendDowntime(value []byte) interface{} {
+	downtime := inst.Downtime{}
+	if err := json.Unmarshal(value, &downtime); err != nil {
+		return log.Errore(err)
+	}
+	err := inst.ExtendDowntime(downtime.Key, downtime.Duration)
+	return err
+}
+
+func (applier *CommandApplier) beginDowntimeCluster(value []byte) interface{} {
+	downtime := inst.ClusterDowntime{}
+	if err := json.Unmarshal(value, &downtime); err != nil {
+		return log.Errore(err)
+	}
+	if downtime.Ended() {
+		// No point in applying it; it's expired
+		return nil
+	}
+	results, err := inst.BeginDowntimeCluster(downtime.ClusterName, downtime.Owner, downtime.Reason, downtime.EndsIn())
+	if err != nil {
+		return err
+	}
+	return results
+}
+
+func (applier *CommandApplier) endDowntimeCluster(value []byte) interface{} {
+	var clusterName string
+	if err := json.Unmarshal(value, &clusterName); err != nil {
+		return log.Errore(err)
+	}
+	results, err := inst.EndDowntimeCluster(clusterName)
+	if err != nil {
+		return err
+	}
+	return results
+}
+
+func (applier *CommandApplier) ignoreHealthChecks(value []byte) interface{} {
+	ignore := inst.HealthChecksIgnore{}
+	if err := json.Unmarshal(value, &ignore); err != nil {
+		return log.Errore(err)
+	}
+	err := inst.IgnoreHealthChecks(&ignore)
+	return err
+}
+
+func (applier *CommandApplier) unignoreHealthChecks(value []byte) interface{} {
+	instanceKey := inst.InstanceKey{}
+	if err := json.Unmarshal(value, &instanceKey); err != nil {
+		return log.Errore(err)
+	}
+	_, err := inst.UnignoreHealthChecks(&instanceKey)
+	return err
+}
+
+func (applier *CommandApplier) writeClusterCoordinatesSnapshot(value []byte) interface{} {
+	snapshot := inst.ClusterCoordinatesSnapshot{}
+	if err := json.Unmarshal(value, &snapshot); err != nil {
+		return log.Errore(err)
+	}
+	return inst.WriteClusterCoordinatesSnapshot(&snapshot)
+}
+
 func (applier *CommandApplier) registerCandidate(value []byte) interface{} {
 	candidate := inst.CandidateDatabaseInstance{}
 	if err := json.Unmarshal(value, &candidate); err != nil {
@@ -245,6 +344,28 @@ func (applier *CommandApplier) resolveRecovery(value []byte) interface{} {
 	return nil
 }
 
+func (applier *CommandApplier) updateRecoveryLostReplicas(value []byte) interface{} {
+	topologyRecovery := TopologyRecovery{}
+	if err := json.Unmarshal(value, &topologyRecovery); err != nil {
+		return log.Errore(err)
+	}
+	if err := writeTopologyRecoveryLostReplicas(&topologyRecovery); err != nil {
+		return log.Errore(err)
+	}
+	return nil
+}
+
+func (applier *CommandApplier) resolveSimulatedRecovery(value []byte) interface{} {
+	topologyRecovery := TopologyRecovery{}
+	if err := json.Unmarshal(value, &topologyRecovery); err != nil {
+		return log.Errore(err)
+	}
+	if err := writeResolveSimulatedRecovery(&topologyRecovery); err != nil {
+		return log.Errore(err)
+	}
+	return nil
+}
+
 func (applier *CommandApplier) disableGlobalRecoveries(value []byte) interface{} {
 	err := DisableRecovery()
 	return err
@@ -255,6 +376,42 @@ func (applier *CommandApplier) enableGlobalRecoveries(value []byte) interface{}
 	return err
 }
 
+func (applier *CommandApplier) disableClusterRecoveries(value []byte) interface{} {
+	var clusterName string
+	if err := json.Unmarshal(value, &clusterName); err != nil {
+		return log.Errore(err)
+	}
+	err := DisableClusterRecovery(clusterName)
+	return err
+}
+
+func (applier *CommandApplier) enableClusterRecoveries(value []byte) interface{} {
+	var clusterName string
+	if err := json.Unmarshal(value, &clusterName); err != nil {
+		return log.Errore(err)
+	}
+	err := EnableClusterRecovery(clusterName)
+	return err
+}
+
+func (applier *CommandApplier) beginGracefulMasterTakeover(value []byte) interface{} {
+	var clusterName string
+	if err := json.Unmarshal(value, &clusterName); err != nil {
+		return log.Errore(err)
+	}
+	err := inst.BeginGracefulMasterTakeover(clusterName)
+	return err
+}
+
+func (applier *CommandApplier) endGracefulMasterTakeover(value []byte) interface{} {
+	var clusterName string
+	if err := json.Unmarshal(value, &clusterName); err != nil {
+		return log.Errore(err)
+	}
+	err := inst.EndGracefulMasterTakeover(clusterName)
+	return err
+}
+
 func (applier *CommandApplier) putKeyValue(value []byte) interface{} {
 	kvPair := kv.KVPair{}
 	if err := json.Unmarshal(value, &kvPair); err != nil {
@@ -269,7 +426,7 @@ func (applier *CommandApplier) putInstanceTag(value []byte) interface{} {
 	if err := json.Unmarshal(value, &instanceTag); err != nil {
 		return log.Errore(err)
 	}
-	err := inst.PutInstanceTag(&instanceTag.Key, &instanceTag.T)
+	err := inst.PutExpiringInstanceTag(&instanceTag.Key, &instanceTag.T, instanceTag.ExpireSeconds)
 	return err
 }
 
@@ -28,9 +28,16 @@ package logic
 // but we won't be doing that many recoveries at once so the load
 // on this table is expected to be very low. It should be fine to
 // go to the database each time.
+//
+// Recoveries may similarly be disabled per cluster, via the table
+// orchestrator.cluster_recovery_disable. Both toggles are persisted
+// in the backend, hence honored by all orchestrator nodes.
 
 import (
+	"fmt"
+
 	"github.com/github/orchestrator/go/db"
+	orcraft "github.com/github/orchestrator/go/raft"
 	"github.com/openark/golib/log"
 	"github.com/openark/golib/sqlutils"
 )
@@ -83,3 +90,118 @@ func SetRecoveryDisabled(disabled bool) error {
 	}
 	return EnableRecovery()
 }
+
+// RecoveryToggles reports the runtime toggles disabling automated recoveries
+type RecoveryToggles struct {
+	DisabledGlobally bool
+	DisabledClusters []string
+}
+
+// IsClusterRecoveryDisabled returns true if recoveries are disabled for given cluster. This does not
+// consider the global toggle.
+func IsClusterRecoveryDisabled(clusterName string) (disabled bool, err error) {
+	query := `
+		SELECT
+			COUNT(*) as mycount
+		FROM
+			cluster_recovery_disable
+		WHERE
+			cluster_name=?
+		`
+	err = db.QueryOrchestrator(query, sqlutils.Args(clusterName), func(m sqlutils.RowMap) error {
+		disabled = (m.GetInt("mycount") > 0)
+		return nil
+	})
+	if err != nil {
+		err = log.Errorf("recovery.IsClusterRecoveryDisabled(): %v", err)
+	}
+	return disabled, err
+}
+
+// DisableClusterRecovery ensures recoveries are disabled for given cluster
+func DisableClusterRecovery(clusterName string) error {
+	_, err := db.ExecOrchestrator(`
+		INSERT IGNORE INTO cluster_recovery_disable
+			(cluster_name, disabled_timestamp)
+		VALUES  (?, NOW())
+	`, clusterName,
+	)
+	return err
+}
+
+// EnableClusterRecovery ensures recoveries are enabled for given cluster, subject to the global toggle
+func EnableClusterRecovery(clusterName string) error {
+	_, err := db.ExecOrchestrator(`
+		DELETE FROM cluster_recovery_disable WHERE cluster_name=?
+	`, clusterName,
+	)
+	return err
+}
+
+// carryOverClusterRecoveryDisabled keeps recoveries disabled on a cluster which changes its name, as when its master
+// fails over and the cluster is named after the promoted master
+func carryOverClusterRecoveryDisabled(oldClusterName string, newClusterName string) error {
+	if oldClusterName == newClusterName {
+		return nil
+	}
+	if disabled, err := IsClusterRecoveryDisabled(oldClusterName); err != nil || !disabled {
+		return err
+	}
+	if orcraft.IsRaftEnabled() {
+		if _, err := orcraft.PublishCommand("disable-cluster-recoveries", newClusterName); err != nil {
+			return err
+		}
+		_, err := orcraft.PublishCommand("enable-cluster-recoveries", oldClusterName)
+		return err
+	}
+	if err := DisableClusterRecovery(newClusterName); err != nil {
+		return err
+	}
+	return EnableClusterRecovery(oldClusterName)
+}
+
+// ReadRecoveryDisabledClusters returns the names of clusters for which recoveries are disabled
+func ReadRecoveryDisabledClusters() (clusterNames []string, err error) {
+	clusterNames = []string{}
+	query := `
+		SELECT
+			cluster_name
+		FROM
+			cluster_recovery_disable
+		ORDER BY
+			cluster_name
+		`
+	err = db.QueryOrchestrator(query, sqlutils.Args(), func(m sqlutils.RowMap) error {
+		clusterNames = append(clusterNames, m.GetString("cluster_name"))
+		return nil
+	})
+	if err != nil {
+		err = log.Errorf("recovery.ReadRecoveryDisabledClusters(): %v", err)
+	}
+	return clusterNames, err
+}
+
+// ReadRecoveryToggles returns the current global and per-cluster recovery toggles
+func ReadRecoveryToggles() (toggles RecoveryToggles, err error) {
+	if toggles.DisabledGlobally, err = IsRecoveryDisabled(); err != nil {
+		return toggles, err
+	}
+	toggles.DisabledClusters, err = ReadRecoveryDisabledClusters()
+	return toggles, err
+}
+
+// RecoveryDisabledReason returns a description of why recoveries on given cluster are disabled, or an empty
+// string if they are not
+func RecoveryDisabledReason(clusterName string) (reason string, err error) {
+	if disabled, err := IsRecoveryDisabled(); err != nil {
+		return reason, err
+	} else if disabled {
+		return "disabled globally", nil
+	}
+	if disabled, err := IsClusterRecoveryDisabled(clusterName); err != nil {
+		return reason, err
+	} else if disabled {
+		return fmt.Sprintf("disabled for cluster %s", clusterName), nil
+	}
+	return reason, nil
+}
@@ -0,0 +1,106 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	test "github.com/openark/golib/tests"
+)
+
+// useSQLiteBackend points the backend at a fresh sqlite database. Call the returned function to restore the
+// previous backend configuration.
+func useSQLiteBackend(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "orchestrator-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	backendDB, dataFile := config.Config.BackendDB, config.Config.SQLite3DataFile
+	config.Config.BackendDB = "sqlite"
+	config.Config.SQLite3DataFile = filepath.Join(dir, "orchestrator.sqlite3")
+	if _, err := db.OpenOrchestrator(); err != nil {
+		t.Fatal(err)
+	}
+	return func() {
+		config.Config.BackendDB, config.Config.SQLite3DataFile = backendDB, dataFile
+		os.RemoveAll(dir)
+	}
+}
+
+func TestClusterRecoveryDisabled(t *testing.T) {
+	defer useSQLiteBackend(t)()
+
+	disabled, err := IsClusterRecoveryDisabled("c1:3306")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectFalse(disabled)
+
+	test.S(t).ExpectNil(DisableClusterRecovery("c1:3306"))
+	disabled, err = IsClusterRecoveryDisabled("c1:3306")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(disabled)
+	disabled, err = IsClusterRecoveryDisabled("c2:3306")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectFalse(disabled)
+
+	test.S(t).ExpectNil(EnableClusterRecovery("c1:3306"))
+	disabled, err = IsClusterRecoveryDisabled("c1:3306")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectFalse(disabled)
+}
+
+func TestRecoveryDisabledReason(t *testing.T) {
+	defer useSQLiteBackend(t)()
+
+	reason, err := RecoveryDisabledReason("c1:3306")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(reason, "")
+
+	test.S(t).ExpectNil(DisableClusterRecovery("c1:3306"))
+	reason, err = RecoveryDisabledReason("c1:3306")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(reason, "disabled for cluster c1:3306")
+
+	test.S(t).ExpectNil(DisableRecovery())
+	reason, err = RecoveryDisabledReason("c2:3306")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(reason, "disabled globally")
+}
+
+func TestCarryOverClusterRecoveryDisabled(t *testing.T) {
+	defer useSQLiteBackend(t)()
+
+	test.S(t).ExpectNil(DisableClusterRecovery("old-master:3306"))
+	test.S(t).ExpectNil(carryOverClusterRecoveryDisabled("old-master:3306", "promoted:3306"))
+
+	disabled, err := IsClusterRecoveryDisabled("promoted:3306")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(disabled)
+	disabled, err = IsClusterRecoveryDisabled("old-master:3306")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectFalse(disabled)
+
+	// Nothing to carry over from a cluster with recoveries enabled
+	test.S(t).ExpectNil(carryOverClusterRecoveryDisabled("other:3306", "other-promoted:3306"))
+	disabled, err = IsClusterRecoveryDisabled("other-promoted:3306")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectFalse(disabled)
+}
@@ -0,0 +1,93 @@
+/*
+   Copyright 2017 Shlomi Noach, GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/discovery"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+const restoredDiscoveryReason = "restored backlog"
+
+// persistDiscoveryBacklog writes the keys waiting on the discovery queues to the
+// backend database, replacing any previously persisted backlog
+func persistDiscoveryBacklog() error {
+	if _, err := db.ExecOrchestrator(`delete from discovery_queue_backlog`); err != nil {
+		return log.Errore(err)
+	}
+	persisted := 0
+	for _, queue := range getDiscoveryRouter().Queues() {
+		for _, queuedKey := range queue.Snapshot().Queued {
+			_, err := db.ExecOrchestrator(`
+					replace into discovery_queue_backlog (
+						hostname, port, reason, queued_at
+					) values (
+						?, ?, ?, NOW() - interval ? second
+					)
+				`,
+				queuedKey.Key.Hostname,
+				queuedKey.Key.Port,
+				queuedKey.Reason,
+				int(time.Since(queuedKey.QueuedAt).Seconds()),
+			)
+			if err != nil {
+				return log.Errore(err)
+			}
+			persisted++
+		}
+	}
+	log.Infof("persistDiscoveryBacklog: persisted %d discovery requests", persisted)
+	return nil
+}
+
+// restoreDiscoveryBacklog requeues the discovery requests persisted by persistDiscoveryBacklog,
+// skipping those older than PersistDiscoveryQueueMaxAgeSeconds, then clears the persisted backlog
+func restoreDiscoveryBacklog() error {
+	query := `
+		select
+			hostname, port
+		from
+			discovery_queue_backlog
+		where
+			queued_at >= now() - interval ? second
+		order by
+			queued_at
+		`
+	var instanceKeys []inst.InstanceKey
+	err := db.QueryOrchestrator(query, sqlutils.Args(config.Config.PersistDiscoveryQueueMaxAgeSeconds), func(m sqlutils.RowMap) error {
+		instanceKeys = append(instanceKeys, inst.InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")})
+		return nil
+	})
+	if err != nil {
+		return log.Errore(err)
+	}
+	for _, instanceKey := range instanceKeys {
+		getDiscoveryRouter().PushRequest(discovery.NewDiscoveryRequest(instanceKey, restoredDiscoveryReason), discovery.NormalPriority)
+	}
+	log.Infof("restoreDiscoveryBacklog: requeued %d discovery requests", len(instanceKeys))
+
+	if _, err := db.ExecOrchestrator(`delete from discovery_queue_backlog`); err != nil {
+		return log.Errore(err)
+	}
+	return nil
+}
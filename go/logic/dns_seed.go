@@ -0,0 +1,119 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/discovery"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/openark/golib/log"
+)
+
+// lookupSRV resolves DNS SRV records; overridden in tests
+var lookupSRV = net.LookupSRV
+
+// dnsSeedKeys holds the instance keys last resolved per DNS seed name, so that vanished targets can be noticed
+var dnsSeedKeys = map[string]*inst.InstanceKeyMap{}
+var dnsSeedMutex sync.Mutex
+
+// resolveDNSSeeds resolves given DNS SRV names into the instance keys of their targets. A name which fails
+// to resolve is logged and left out of the result, without affecting the other names.
+func resolveDNSSeeds(names []string) (resolved map[string]*inst.InstanceKeyMap) {
+	resolved = make(map[string]*inst.InstanceKeyMap)
+	for _, name := range names {
+		_, addrs, err := lookupSRV("", "", name)
+		if err != nil {
+			log.Errorf("resolveDNSSeeds: unable to resolve %s: %+v", name, err)
+			continue
+		}
+		instanceKeys := inst.NewInstanceKeyMap()
+		for _, addr := range addrs {
+			instanceKey := inst.InstanceKey{Hostname: strings.TrimSuffix(addr.Target, "."), Port: int(addr.Port)}
+			if instanceKey.IsValid() {
+				instanceKeys.AddKey(instanceKey)
+			}
+		}
+		resolved[name] = instanceKeys
+	}
+	return resolved
+}
+
+// missingDNSSeedKeys returns the keys previously resolved for any of the currently resolved names, and which
+// are not found in any of the current resolutions. Names which failed to resolve are not considered.
+func missingDNSSeedKeys(previous map[string]*inst.InstanceKeyMap, current map[string]*inst.InstanceKeyMap) (missing []inst.InstanceKey) {
+	currentKeys := inst.NewInstanceKeyMap()
+	for _, instanceKeys := range current {
+		currentKeys.AddKeys(instanceKeys.GetInstanceKeys())
+	}
+	for name := range current {
+		previousKeys, ok := previous[name]
+		if !ok {
+			continue
+		}
+		for _, instanceKey := range previousKeys.GetInstanceKeys() {
+			if !currentKeys.HasKey(instanceKey) {
+				missing = append(missing, instanceKey)
+			}
+		}
+	}
+	return missing
+}
+
+// SeedDiscoveryFromDNS resolves DNSSeedSRVNames and queues discovery of targets not already known.
+// Targets which disappeared from DNS since the previous resolution are logged, and only forgotten
+// when DNSSeedForgetMissing is set.
+func SeedDiscoveryFromDNS() {
+	dnsSeedMutex.Lock()
+	defer dnsSeedMutex.Unlock()
+
+	resolved := resolveDNSSeeds(config.Config.DNSSeedSRVNames)
+	missing := missingDNSSeedKeys(dnsSeedKeys, resolved)
+	for name, instanceKeys := range resolved {
+		dnsSeedKeys[name] = instanceKeys
+	}
+
+	for name, instanceKeys := range resolved {
+		for _, instanceKey := range instanceKeys.GetInstanceKeys() {
+			instanceKey := instanceKey
+			instanceKey.ResolveHostname()
+			if _, found, _ := inst.ReadInstance(&instanceKey); found {
+				continue
+			}
+			log.Debugf("SeedDiscoveryFromDNS: queueing %+v, found in %s", instanceKey, name)
+			getDiscoveryRouter().PushRequest(discovery.NewDiscoveryRequest(instanceKey, fmt.Sprintf("dns seed %s", name)), discovery.NormalPriority)
+		}
+	}
+	for _, instanceKey := range missing {
+		instanceKey := instanceKey
+		if !config.Config.DNSSeedForgetMissing {
+			log.Infof("SeedDiscoveryFromDNS: %+v is no longer listed in DNS seeds", instanceKey)
+			continue
+		}
+		instanceKey.ResolveHostname()
+		log.Infof("SeedDiscoveryFromDNS: %+v is no longer listed in DNS seeds; forgetting", instanceKey)
+		if err := inst.ForgetInstance(&instanceKey); err != nil {
+			log.Errore(err)
+			continue
+		}
+		forgetDiscovery([]inst.InstanceKey{instanceKey})
+	}
+}
@@ -0,0 +1,65 @@
+package logic
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/github/orchestrator/go/inst"
+	test "github.com/openark/golib/tests"
+)
+
+func TestResolveDNSSeeds(t *testing.T) {
+	defer func(f func(string, string, string) (string, []*net.SRV, error)) { lookupSRV = f }(lookupSRV)
+	lookupSRV = func(service, proto, name string) (string, []*net.SRV, error) {
+		switch name {
+		case "_mysql._tcp.orders.example.com":
+			return name, []*net.SRV{
+				{Target: "db1.example.com.", Port: 3306},
+				{Target: "db2.example.com.", Port: 3307},
+			}, nil
+		case "_mysql._tcp.users.example.com":
+			return name, []*net.SRV{{Target: "db3.example.com.", Port: 3306}}, nil
+		}
+		return "", nil, fmt.Errorf("no such host: %s", name)
+	}
+
+	resolved := resolveDNSSeeds([]string{"_mysql._tcp.broken.example.com", "_mysql._tcp.orders.example.com", "_mysql._tcp.users.example.com"})
+	test.S(t).ExpectEquals(len(resolved), 2)
+	test.S(t).ExpectEquals(len(resolved["_mysql._tcp.orders.example.com"].GetInstanceKeys()), 2)
+	test.S(t).ExpectTrue(resolved["_mysql._tcp.orders.example.com"].HasKey(inst.InstanceKey{Hostname: "db2.example.com", Port: 3307}))
+	test.S(t).ExpectTrue(resolved["_mysql._tcp.users.example.com"].HasKey(inst.InstanceKey{Hostname: "db3.example.com", Port: 3306}))
+}
+
+func TestMissingDNSSeedKeys(t *testing.T) {
+	key1 := inst.InstanceKey{Hostname: "db1.example.com", Port: 3306}
+	key2 := inst.InstanceKey{Hostname: "db2.example.com", Port: 3306}
+	key3 := inst.InstanceKey{Hostname: "db3.example.com", Port: 3306}
+	keyMap := func(keys ...inst.InstanceKey) *inst.InstanceKeyMap {
+		m := inst.NewInstanceKeyMap()
+		m.AddKeys(keys)
+		return m
+	}
+	previous := map[string]*inst.InstanceKeyMap{
+		"orders": keyMap(key1, key2),
+		"users":  keyMap(key3),
+	}
+	{
+		// key2 moved to another name; users failed to resolve
+		current := map[string]*inst.InstanceKeyMap{
+			"orders":  keyMap(key1),
+			"billing": keyMap(key2),
+		}
+		test.S(t).ExpectEquals(len(missingDNSSeedKeys(previous, current)), 0)
+	}
+	{
+		current := map[string]*inst.InstanceKeyMap{
+			"orders": keyMap(key1),
+			"users":  keyMap(),
+		}
+		missing := missingDNSSeedKeys(previous, current)
+		test.S(t).ExpectEquals(len(missing), 2)
+		test.S(t).ExpectTrue(keyMap(missing...).HasKey(key2))
+		test.S(t).ExpectTrue(keyMap(missing...).HasKey(key3))
+	}
+}
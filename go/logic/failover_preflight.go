@@ -0,0 +1,242 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	goos "os"
+	"os/exec"
+	"strings"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/kv"
+)
+
+// FailoverPreflightCheck is the outcome of a single failover precondition
+type FailoverPreflightCheck struct {
+	Name    string
+	Passed  bool
+	Details string
+}
+
+// FailoverPreflightReport tells whether the master of a cluster could be failed over right now, per precondition
+type FailoverPreflightReport struct {
+	ClusterName string
+	MasterKey   inst.InstanceKey
+	Passed      bool
+	Checks      []FailoverPreflightCheck
+}
+
+func (this *FailoverPreflightReport) addCheck(name string, passed bool, details string) {
+	this.Checks = append(this.Checks, FailoverPreflightCheck{Name: name, Passed: passed, Details: details})
+	if !passed {
+		this.Passed = false
+	}
+}
+
+// FailedChecks returns the names of failed checks
+func (this *FailoverPreflightReport) FailedChecks() []string {
+	names := []string{}
+	for _, check := range this.Checks {
+		if !check.Passed {
+			names = append(names, check.Name)
+		}
+	}
+	return names
+}
+
+// Summary presents the report in a single line
+func (this *FailoverPreflightReport) Summary() string {
+	if this.Passed {
+		return fmt.Sprintf("failover preconditions on %s: all %d checks passed", this.ClusterName, len(this.Checks))
+	}
+	tokens := []string{}
+	for _, check := range this.Checks {
+		if !check.Passed {
+			tokens = append(tokens, fmt.Sprintf("%s (%s)", check.Name, check.Details))
+		}
+	}
+	return fmt.Sprintf("failover preconditions on %s: failed: %s", this.ClusterName, strings.Join(tokens, "; "))
+}
+
+// hookExecutable returns the executable a hook command runs, i.e. its first token
+func hookExecutable(command string) string {
+	command = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(command), "&"))
+	if tokens := strings.Fields(command); len(tokens) > 0 {
+		return tokens[0]
+	}
+	return ""
+}
+
+// unresolvableHooks returns the failover hooks whose executable cannot be found
+func unresolvableHooks() []string {
+	unresolvable := []string{}
+	hooks := []struct {
+		description string
+		processes   []string
+	}{
+		{"PreFailoverProcesses", config.Config.PreFailoverProcesses},
+		{"PostMasterFailoverProcesses", config.Config.PostMasterFailoverProcesses},
+		{"PostFailoverProcesses", config.Config.PostFailoverProcesses},
+		{"PostUnsuccessfulFailoverProcesses", config.Config.PostUnsuccessfulFailoverProcesses},
+	}
+	for _, hook := range hooks {
+		for i, command := range hook.processes {
+			executable := hookExecutable(command)
+			var err error
+			if strings.Contains(executable, "/") {
+				_, err = goos.Stat(executable)
+			} else {
+				_, err = exec.LookPath(executable)
+			}
+			if err != nil {
+				unresolvable = append(unresolvable, fmt.Sprintf("%s hook %d: %s", hook.description, i+1, executable))
+			}
+		}
+	}
+	return unresolvable
+}
+
+// checkKVPairs tells whether given KV pairs are found, with their expected values, in every KV store in use
+func checkKVPairs(kvPairs [](*kv.KVPair), getStoreValues func(key string) []kv.StoreValue) (passed bool, details string) {
+	passed = true
+	tokens := []string{}
+	for _, kvPair := range kvPairs {
+		for _, storeValue := range getStoreValues(kvPair.Key) {
+			switch {
+			case storeValue.Err != nil:
+				passed = false
+				tokens = append(tokens, fmt.Sprintf("%s %s: %s", storeValue.Store, kvPair.Key, storeValue.Err.Error()))
+			case !storeValue.Found:
+				passed = false
+				tokens = append(tokens, fmt.Sprintf("%s %s: not found", storeValue.Store, kvPair.Key))
+			case storeValue.Value != kvPair.Value:
+				passed = false
+				tokens = append(tokens, fmt.Sprintf("%s %s: %s, expected %s", storeValue.Store, kvPair.Key, storeValue.Value, kvPair.Value))
+			}
+		}
+	}
+	return passed, strings.Join(tokens, ", ")
+}
+
+// CheckFailoverPreconditions evaluates whether the master of given cluster could be failed over right now:
+// recoveries are enabled and not blocked, a promotion candidate exists which is neither lagging, nor has errant
+// GTIDs, nor is downtimed, and KV stores and failover hooks are usable. Nothing is changed.
+func CheckFailoverPreconditions(clusterName string) (*FailoverPreflightReport, error) {
+	return checkFailoverPreconditions(clusterName, "")
+}
+
+// checkFailoverPreconditions evaluates failover preconditions, not considering the given (ongoing) recovery
+// as blocking
+func checkFailoverPreconditions(clusterName string, ongoingRecoveryUID string) (*FailoverPreflightReport, error) {
+	report := &FailoverPreflightReport{ClusterName: clusterName, Passed: true, Checks: []FailoverPreflightCheck{}}
+	clusterInfo, err := inst.ReadClusterInfo(clusterName)
+	if err != nil {
+		return report, err
+	}
+	masters, err := inst.ReadClusterMaster(clusterName)
+	if err != nil {
+		return report, err
+	}
+	if len(masters) == 0 {
+		return report, fmt.Errorf("CheckFailoverPreconditions: no master found for cluster %+v", clusterName)
+	}
+	master := masters[0]
+	report.MasterKey = master.Key
+
+	// Recovery enabled
+	if disabled, err := IsRecoveryDisabled(); err != nil {
+		report.addCheck("recovery-enabled", false, err.Error())
+	} else if disabled {
+		report.addCheck("recovery-enabled", false, "recoveries are disabled globally")
+	} else if !clusterInfo.HasAutomatedMasterRecovery {
+		report.addCheck("recovery-enabled", false, "cluster does not match RecoverMasterClusterFilters")
+	} else {
+		report.addCheck("recovery-enabled", true, "")
+	}
+
+	// Recovery not blocked
+	if recoveries, err := ReadInActivePeriodClusterRecovery(clusterName); err != nil {
+		report.addCheck("recovery-not-blocked", false, err.Error())
+	} else {
+		blockingRecoveries := []string{}
+		for i := range recoveries {
+			if recoveries[i].UID != ongoingRecoveryUID {
+				blockingRecoveries = append(blockingRecoveries, fmt.Sprintf("%s on %s", recoveries[i].AnalysisEntry.Analysis, recoveries[i].AnalysisEntry.AnalyzedInstanceKey.DisplayString()))
+			}
+		}
+		if len(blockingRecoveries) > 0 {
+			report.addCheck("recovery-not-blocked", false, fmt.Sprintf("cluster in active period (RecoveryPeriodBlockSeconds) of recent recovery: %s", strings.Join(blockingRecoveries, ", ")))
+		} else {
+			report.addCheck("recovery-not-blocked", true, "")
+		}
+	}
+
+	// Promotion candidate
+	suggestion, err := SuggestPromotionCandidate(&master.Key)
+	if err != nil {
+		report.addCheck("promotion-candidate", false, err.Error())
+	} else if suggestion.Candidate == nil {
+		report.addCheck("promotion-candidate", false, suggestion.Reason)
+	} else {
+		candidate := suggestion.Candidate
+		report.addCheck("promotion-candidate", true, fmt.Sprintf("%s: %s", candidate.Key.DisplayString(), suggestion.Reason))
+
+		if !candidate.SlaveLagSeconds.Valid {
+			report.addCheck("candidate-lag", false, fmt.Sprintf("%s: replication lag unknown", candidate.Key.DisplayString()))
+		} else if candidate.SlaveLagSeconds.Int64 > int64(config.Config.ReasonableReplicationLagSeconds) {
+			report.addCheck("candidate-lag", false, fmt.Sprintf("%s: lagging %d seconds, beyond ReasonableReplicationLagSeconds", candidate.Key.DisplayString(), candidate.SlaveLagSeconds.Int64))
+		} else {
+			report.addCheck("candidate-lag", true, fmt.Sprintf("%s: lagging %d seconds", candidate.Key.DisplayString(), candidate.SlaveLagSeconds.Int64))
+		}
+
+		if candidate.GtidErrant != "" {
+			report.addCheck("candidate-errant-gtid", false, fmt.Sprintf("%s: errant GTID: %s", candidate.Key.DisplayString(), candidate.GtidErrant))
+		} else {
+			report.addCheck("candidate-errant-gtid", true, "")
+		}
+
+		if candidate.IsDowntimed {
+			report.addCheck("candidate-not-downtimed", false, fmt.Sprintf("%s: downtimed by %s until %s: %s", candidate.Key.DisplayString(), candidate.DowntimeOwner, candidate.DowntimeEndTimestamp, candidate.DowntimeReason))
+		} else {
+			report.addCheck("candidate-not-downtimed", true, "")
+		}
+	}
+
+	// KV stores
+	kvPassed, kvDetails := checkKVPairs(inst.GetClusterMasterKVPairs(clusterInfo.ClusterAlias, &master.Key), kv.GetStoreValues)
+	report.addCheck("kv-stores", kvPassed, kvDetails)
+
+	// Hooks
+	if unresolvable := unresolvableHooks(); len(unresolvable) > 0 {
+		report.addCheck("hooks", false, fmt.Sprintf("executable not found: %s", strings.Join(unresolvable, ", ")))
+	} else {
+		report.addCheck("hooks", true, "")
+	}
+	return report, nil
+}
+
+// auditFailoverPreconditions records, at the start of given master recovery, the failover preconditions of its cluster
+func auditFailoverPreconditions(topologyRecovery *TopologyRecovery) {
+	report, err := checkFailoverPreconditions(topologyRecovery.AnalysisEntry.ClusterDetails.ClusterName, topologyRecovery.UID)
+	if err != nil {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("failover preconditions: cannot evaluate: %+v", err))
+		return
+	}
+	AuditTopologyRecovery(topologyRecovery, report.Summary())
+}
@@ -0,0 +1,75 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/github/orchestrator/go/kv"
+	test "github.com/openark/golib/tests"
+)
+
+func TestHookExecutable(t *testing.T) {
+	test.S(t).ExpectEquals(hookExecutable("echo 'Will recover from {failureType}' >> /tmp/recovery.log"), "echo")
+	test.S(t).ExpectEquals(hookExecutable("  /usr/local/bin/failover-notify {failedHost} &"), "/usr/local/bin/failover-notify")
+	test.S(t).ExpectEquals(hookExecutable(""), "")
+}
+
+func TestFailoverPreflightReport(t *testing.T) {
+	report := &FailoverPreflightReport{ClusterName: "db-1:3306", Passed: true}
+	report.addCheck("recovery-enabled", true, "")
+	test.S(t).ExpectTrue(report.Passed)
+	test.S(t).ExpectEquals(report.Summary(), "failover preconditions on db-1:3306: all 1 checks passed")
+
+	report.addCheck("candidate-lag", false, "db-2:3306: lagging 20 seconds")
+	report.addCheck("hooks", true, "")
+	test.S(t).ExpectFalse(report.Passed)
+	test.S(t).ExpectEquals(len(report.FailedChecks()), 1)
+	test.S(t).ExpectEquals(report.FailedChecks()[0], "candidate-lag")
+	test.S(t).ExpectEquals(report.Summary(), "failover preconditions on db-1:3306: failed: candidate-lag (db-2:3306: lagging 20 seconds)")
+}
+
+func TestCheckKVPairs(t *testing.T) {
+	kvPairs := [](*kv.KVPair){kv.NewKVPair("mysql/master/c1", "db-1:3306")}
+	storeValues := map[string][]kv.StoreValue{}
+	getStoreValues := func(key string) []kv.StoreValue { return storeValues[key] }
+
+	storeValues["mysql/master/c1"] = []kv.StoreValue{
+		{Store: "internal", Value: "db-1:3306", Found: true},
+		{Store: "consul", Value: "db-1:3306", Found: true},
+	}
+	passed, details := checkKVPairs(kvPairs, getStoreValues)
+	test.S(t).ExpectTrue(passed)
+	test.S(t).ExpectEquals(details, "")
+
+	storeValues["mysql/master/c1"] = []kv.StoreValue{
+		{Store: "internal", Value: "db-1:3306", Found: true},
+		{Store: "consul", Value: "db-0:3306", Found: true},
+	}
+	passed, details = checkKVPairs(kvPairs, getStoreValues)
+	test.S(t).ExpectFalse(passed)
+	test.S(t).ExpectEquals(details, "consul mysql/master/c1: db-0:3306, expected db-1:3306")
+
+	storeValues["mysql/master/c1"] = []kv.StoreValue{
+		{Store: "internal", Found: false},
+		{Store: "zk", Err: errors.New("connection refused")},
+	}
+	passed, details = checkKVPairs(kvPairs, getStoreValues)
+	test.S(t).ExpectFalse(passed)
+	test.S(t).ExpectEquals(details, "internal mysql/master/c1: not found, zk mysql/master/c1: connection refused")
+}
@@ -17,10 +17,13 @@
 package logic
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"os"
 	"os/signal"
+	"regexp"
+	"runtime/debug"
 	"sync"
 	"sync/atomic"
 	"syscall"
@@ -46,31 +49,50 @@ const (
 	discoveryMetricsName        = "DISCOVERY_METRICS"
 	yieldAfterUnhealthyDuration = 5 * config.HealthPollSeconds * time.Second
 	fatalAfterUnhealthyDuration = 30 * config.HealthPollSeconds * time.Second
+	discoveryShutdownTimeout    = 10 * time.Second
 )
 
-// discoveryQueue is a channel of deduplicated instanceKey-s
-// that were requested for discovery.  It can be continuously updated
-// as discovery process progresses.
-var discoveryQueue *discovery.Queue
+// discoveryRouter routes deduplicated instanceKey-s that were requested
+// for discovery onto discovery queues. These can be continuously updated
+// as discovery process progresses. Access via getDiscoveryRouter().
+var discoveryRouter *discovery.Router
+var discoveryRouterMutex sync.Mutex
+var discoveryWorkers = make(map[*discovery.Queue]uint)
+var discoveryWorkersMutex sync.Mutex
 var snapshotDiscoveryKeys chan inst.InstanceKey
 var snapshotDiscoveryKeysMutex sync.Mutex
 
 var discoveriesCounter = metrics.NewCounter()
 var failedDiscoveriesCounter = metrics.NewCounter()
 var instancePollSecondsExceededCounter = metrics.NewCounter()
+var discoveryTimeoutsCounter = metrics.NewCounter()
+var discoveryPanicsCounter = metrics.NewCounter()
+var discoveryAbandonedGauge = metrics.NewGauge()
 var discoveryQueueLengthGauge = metrics.NewGauge()
+var discoveryActiveGauge = metrics.NewGauge()
+var discoveryExpiredGauge = metrics.NewGauge()
+var discoveryDroppedGauge = metrics.NewGauge()
+var discoveryCooldownGauge = metrics.NewGauge()
 var discoveryRecentCountGauge = metrics.NewGauge()
 var isElectedGauge = metrics.NewGauge()
 var isHealthyGauge = metrics.NewGauge()
 var isRaftHealthyGauge = metrics.NewGauge()
 var isRaftLeaderGauge = metrics.NewGauge()
+var pseudoGTIDInjectSuccessCounter = metrics.NewCounter()
+var pseudoGTIDInjectFailCounter = metrics.NewCounter()
 var discoveryMetrics = collection.CreateOrReturnCollection(discoveryMetricsName)
 
 var isElectedNode int64 = 0
+var abandonedDiscoveries int64 = 0
 
 var recentDiscoveryOperationKeys *cache.Cache
 var pseudoGTIDPublishCache = cache.New(time.Minute, time.Second)
 var kvFoundCache = cache.New(10*time.Minute, time.Minute)
+var kvPendingCache = cache.New(cache.NoExpiration, time.Minute)
+var panickedDiscoveryKeys = cache.New(10*time.Minute, time.Minute)
+
+// metricNameInvalidCharsRegexp matches characters which may not appear in a metric name token
+var metricNameInvalidCharsRegexp = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
 
 func init() {
 	snapshotDiscoveryKeys = make(chan inst.InstanceKey, 10)
@@ -78,15 +100,31 @@ func init() {
 	metrics.Register("discoveries.attempt", discoveriesCounter)
 	metrics.Register("discoveries.fail", failedDiscoveriesCounter)
 	metrics.Register("discoveries.instance_poll_seconds_exceeded", instancePollSecondsExceededCounter)
+	metrics.Register("discoveries.timeout", discoveryTimeoutsCounter)
+	metrics.Register("discoveries.panic", discoveryPanicsCounter)
+	metrics.Register("discoveries.abandoned", discoveryAbandonedGauge)
 	metrics.Register("discoveries.queue_length", discoveryQueueLengthGauge)
+	metrics.Register("discoveries.active", discoveryActiveGauge)
+	metrics.Register("discoveries.expired", discoveryExpiredGauge)
+	metrics.Register("discoveries.dropped", discoveryDroppedGauge)
+	metrics.Register("discoveries.cooldown", discoveryCooldownGauge)
 	metrics.Register("discoveries.recent_count", discoveryRecentCountGauge)
 	metrics.Register("elect.is_elected", isElectedGauge)
 	metrics.Register("health.is_healthy", isHealthyGauge)
+	metrics.Register("pseudo_gtid.inject.success", pseudoGTIDInjectSuccessCounter)
+	metrics.Register("pseudo_gtid.inject.fail", pseudoGTIDInjectFailCounter)
 	metrics.Register("raft.is_healthy", isRaftHealthyGauge)
 	metrics.Register("raft.is_leader", isRaftLeaderGauge)
 
 	ometrics.OnMetricsTick(func() {
-		discoveryQueueLengthGauge.Update(int64(discoveryQueue.QueueLen()))
+		discoveryQueueLengthGauge.Update(int64(getDiscoveryRouter().QueueLen()))
+		discoveryActiveGauge.Update(int64(getDiscoveryRouter().ActiveWorkers()))
+		discoveryExpiredGauge.Update(int64(getDiscoveryRouter().ExpiredCount()))
+		discoveryDroppedGauge.Update(int64(getDiscoveryRouter().DroppedCount()))
+		discoveryCooldownGauge.Update(int64(getDiscoveryRouter().CooldownCount()))
+	})
+	ometrics.OnMetricsTick(func() {
+		discoveryAbandonedGauge.Update(atomic.LoadInt64(&abandonedDiscoveries))
 	})
 	ometrics.OnMetricsTick(func() {
 		if recentDiscoveryOperationKeys == nil {
@@ -148,6 +186,10 @@ func acceptSignals() {
 			case syscall.SIGTERM:
 				log.Infof("Received SIGTERM. Shutting down orchestrator")
 				discoveryMetrics.StopAutoExpiration()
+				if config.Config.PersistDiscoveryQueue {
+					persistDiscoveryBacklog()
+				}
+				stopDiscoveryQueue()
 				// probably should poke other go routines to stop cleanly here ...
 				inst.AuditOperation("shutdown", nil, "Triggered via SIGTERM")
 				os.Exit(0)
@@ -156,39 +198,302 @@ func acceptSignals() {
 	}()
 }
 
-// handleDiscoveryRequests iterates the discoveryQueue channel and calls upon
-// instance discovery per entry.
+// stopDiscoveryQueue abandons pending discovery requests and waits (for a limited time)
+// for discoveries in progress to complete.
+func stopDiscoveryQueue() {
+	stopped := make(chan struct{})
+	go func() {
+		var wg sync.WaitGroup
+		for _, queue := range getDiscoveryRouter().Queues() {
+			wg.Add(1)
+			go func(queue *discovery.Queue) {
+				defer wg.Done()
+				queue.Stop(false)
+			}(queue)
+		}
+		wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(discoveryShutdownTimeout):
+		log.Warningf("Timed out waiting on discoveries in progress after %+v", discoveryShutdownTimeout)
+	}
+}
+
+// getDiscoveryRouter returns the discovery router. Until setupDiscoveryQueues runs, this is a router
+// onto the default queue, created on first use.
+func getDiscoveryRouter() *discovery.Router {
+	discoveryRouterMutex.Lock()
+	defer discoveryRouterMutex.Unlock()
+	if discoveryRouter == nil {
+		discoveryRouter = discovery.NewRouter(nil, discovery.CreateOrReturnQueue(config.DefaultDiscoveryQueue), nil)
+	}
+	return discoveryRouter
+}
+
+// setupDiscoveryQueues creates the default discovery queue, as well as any
+// named queues configured in DiscoveryQueues. When DataCenterPattern is
+// configured, instances are routed onto the queue named by their data center.
+func setupDiscoveryQueues() {
+	defaultQueue := discovery.CreateOrReturnQueue(config.DefaultDiscoveryQueue)
+	queues := make(map[string]*discovery.Queue)
+	for name := range config.Config.DiscoveryQueues {
+		if name == config.DefaultDiscoveryQueue {
+			continue
+		}
+		queues[name] = discovery.CreateOrReturnQueue(name)
+	}
+	var classifier discovery.Classifier
+	if config.Config.DataCenterPattern != "" && len(queues) > 0 {
+		if pattern, err := regexp.Compile(config.Config.DataCenterPattern); err == nil {
+			classifier = func(instanceKey inst.InstanceKey) string {
+				if match := pattern.FindStringSubmatch(instanceKey.Hostname); len(match) > 1 {
+					return match[1]
+				}
+				return ""
+			}
+		} else {
+			log.Errore(err)
+		}
+	}
+	router := discovery.NewRouter(classifier, defaultQueue, queues)
+	if getDiscoveryRouter().Paused() {
+		// Discovery was paused before the named queues were set up
+		router.Pause()
+	}
+	discoveryRouterMutex.Lock()
+	discoveryRouter = router
+	discoveryRouterMutex.Unlock()
+
+	retryDelay := time.Duration(config.Config.DiscoveryRetryDelaySeconds) * time.Second
+	clusterClassifier := func(instanceKey inst.InstanceKey) string {
+		clusterName, _ := inst.GetClusterName(&instanceKey)
+		return clusterName
+	}
+	for _, queue := range getDiscoveryRouter().Queues() {
+		queue.SetRetryPolicy(config.Config.DiscoveryMaxRetries, retryDelay)
+		queue.SetClusterClassifier(clusterClassifier)
+	}
+}
+
+// handleDiscoveryRequests starts the discovery workers, per discovery queue
 func handleDiscoveryRequests() {
-	discoveryQueue = discovery.CreateOrReturnQueue("DEFAULT")
+	maxConcurrency := config.Config.DiscoveryMaxConcurrency
+	if queueMaxConcurrency, found := config.Config.DiscoveryQueues[config.DefaultDiscoveryQueue]; found {
+		maxConcurrency = queueMaxConcurrency
+	}
+	SetDiscoveryMaxConcurrency(maxConcurrency)
+
+	for name, queueMaxConcurrency := range config.Config.DiscoveryQueues {
+		if name == config.DefaultDiscoveryQueue {
+			continue
+		}
+		setQueueMaxConcurrency(discovery.CreateOrReturnQueue(name), queueMaxConcurrency)
+	}
+}
+
+// SetDiscoveryMaxConcurrency changes the number of concurrent discoveries of the default
+// discovery queue at runtime. Additional discovery workers are created as needed. When
+// lowering the value, discoveries in progress are not interrupted.
+func SetDiscoveryMaxConcurrency(maxConcurrency uint) {
+	setQueueMaxConcurrency(discovery.CreateOrReturnQueue(config.DefaultDiscoveryQueue), maxConcurrency)
+}
+
+// DiscoverCluster queues forced discovery requests for all known instances of given cluster.
+// Returns the number of instances queued.
+func DiscoverCluster(clusterName string) (int, error) {
+	instances, err := inst.ReadClusterInstances(clusterName)
+	if err != nil {
+		return 0, err
+	}
+	requests := []discovery.DiscoveryRequest{}
+	for _, instance := range instances {
+		request := discovery.NewDiscoveryRequest(instance.Key, fmt.Sprintf("discover cluster %s", clusterName))
+		request.Forced = true
+		requests = append(requests, request)
+	}
+	getDiscoveryRouter().PushRequests(requests, discovery.NormalPriority)
+	return len(requests), nil
+}
 
+// ForgetCluster forgets all instances of given cluster, and takes them out of discovery
+func ForgetCluster(clusterName string) error {
+	instanceKeys, err := inst.ForgetCluster(clusterName)
+	forgetDiscovery(instanceKeys)
+	return err
+}
+
+// ForgetInstancesByPattern forgets all instances whose hostname:port matches given regular
+// expression, and takes them out of discovery
+func ForgetInstancesByPattern(pattern string) error {
+	instanceKeys, err := inst.ForgetInstancesByPattern(pattern)
+	forgetDiscovery(instanceKeys)
+	return err
+}
+
+// forgetDiscovery takes given instances out of the discovery queues, such that
+// they are not requeued for polling
+func forgetDiscovery(instanceKeys []inst.InstanceKey) {
+	for _, instanceKey := range instanceKeys {
+		getDiscoveryRouter().Forget(instanceKey)
+	}
+}
+
+// IsDiscoveryInCooldown returns true when given instance was successfully discovered
+// within the last DiscoverySuccessCooldownSeconds
+func IsDiscoveryInCooldown(instanceKey inst.InstanceKey) bool {
+	return getDiscoveryRouter().Queue(instanceKey).InCooldown(instanceKey)
+}
+
+// PauseDiscovery stops dispatching discovery requests, which are still queued
+// until ResumeDiscovery is called. Discoveries in progress are not interrupted.
+func PauseDiscovery() {
+	getDiscoveryRouter().Pause()
+}
+
+// ResumeDiscovery resumes dispatching discovery requests after PauseDiscovery
+func ResumeDiscovery() {
+	getDiscoveryRouter().Resume()
+}
+
+// IsDiscoveryPaused returns true when discovery is paused
+func IsDiscoveryPaused() bool {
+	return getDiscoveryRouter().Paused()
+}
+
+// setQueueMaxConcurrency changes the number of concurrent discoveries of given queue,
+// creating discovery workers as needed.
+func setQueueMaxConcurrency(queue *discovery.Queue, maxConcurrency uint) {
+	discoveryWorkersMutex.Lock()
+	defer discoveryWorkersMutex.Unlock()
+
+	queue.SetMaxConcurrency(maxConcurrency)
 	// create a pool of discovery workers
-	for i := uint(0); i < config.Config.DiscoveryMaxConcurrency; i++ {
-		go func() {
-			for {
-				instanceKey := discoveryQueue.Consume()
-				// Possibly this used to be the elected node, but has
-				// been demoted, while still the queue is full.
-				if !IsLeaderOrActive() {
-					log.Debugf("Node apparently demoted. Skipping discovery of %+v. "+
-						"Remaining queue size: %+v", instanceKey, discoveryQueue.QueueLen())
-					discoveryQueue.Release(instanceKey)
-					continue
-				}
+	for ; discoveryWorkers[queue] < maxConcurrency; discoveryWorkers[queue]++ {
+		go discoveryWorker(queue, discoverInstanceIfActive)
+	}
+}
 
-				DiscoverInstance(instanceKey)
-				discoveryQueue.Release(instanceKey)
-			}
+// discoveryWorker consumes keys from the discovery queue and discovers them
+// until the queue is stopped. Discovery errors are reported to the queue, which
+// retries the key as per its retry policy. A key whose discovery panics is requeued once.
+func discoveryWorker(queue *discovery.Queue, discover func(context.Context, discovery.DiscoveryRequest) error) {
+	for {
+		request, ok := queue.ConsumeRequest()
+		if !ok {
+			// queue is stopped
+			return
+		}
+		discoverWithTimeout(queue, request, discover)
+	}
+}
+
+// releaseDiscovery releases a discovered key from given queue along with the outcome of its discovery
+func releaseDiscovery(queue *discovery.Queue, instanceKey inst.InstanceKey, panicked bool, err error) {
+	if !panicked {
+		queue.ReleaseWithResult(instanceKey, err)
+		return
+	}
+	queue.Release(instanceKey)
+	if err := panickedDiscoveryKeys.Add(instanceKey.StringCode(), true, cache.DefaultExpiration); err == nil {
+		log.Infof("discoveryWorker: requeuing %+v after panic", instanceKey)
+		queue.PushRequest(discovery.NewDiscoveryRequest(instanceKey, "requeue after panic"), discovery.NormalPriority)
+	}
+}
+
+// discoverInstanceIfActive discovers an instance, unless this node is not the leader
+func discoverInstanceIfActive(ctx context.Context, request discovery.DiscoveryRequest) error {
+	// Possibly this used to be the elected node, but has
+	// been demoted, while still the queue is full.
+	if !IsLeaderOrActive() {
+		log.Debugf("Node apparently demoted. Skipping discovery of %+v. "+
+			"Remaining queue size: %+v", request.Key, getDiscoveryRouter().QueueLen())
+		return nil
+	}
+	return DiscoverInstance(ctx, request.Key, request.Reason)
+}
+
+// discoverRecoverably runs given discovery function, recovering from a panic so
+// as not to lose the discovery worker. Returns true when the discovery panicked,
+// or else the discovery error.
+func discoverRecoverably(ctx context.Context, request discovery.DiscoveryRequest, discover func(context.Context, discovery.DiscoveryRequest) error) (panicked bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			discoveryPanicsCounter.Inc(1)
+			log.Errorf("discoverRecoverably: panic while discovering %+v: %+v\n%s", request.Key, r, debug.Stack())
+			panicked = true
+		}
+	}()
+	return false, discover(ctx, request)
+}
+
+// discoverWithTimeout runs given discovery function with a context which expires once
+// DiscoveryTimeoutSeconds have passed, then releases the key from given queue.
+// A discovery still running past its deadline, e.g. blocked on a connection attempt, is
+// abandoned so that a hung discovery does not hold a worker forever. The abandoned key is
+// released, with a timeout error, only once the discovery returns, such that the key is not
+// discovered twice concurrently. Abandoned discoveries are counted by the discoveries.abandoned gauge.
+func discoverWithTimeout(queue *discovery.Queue, request discovery.DiscoveryRequest, discover func(context.Context, discovery.DiscoveryRequest) error) {
+	instanceKey := request.Key
+	if config.Config.DiscoveryTimeoutSeconds == 0 {
+		panicked, err := discoverRecoverably(context.Background(), request, discover)
+		releaseDiscovery(queue, instanceKey, panicked, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.Config.DiscoveryTimeoutSeconds)*time.Second)
+
+	type discoveryResult struct {
+		panicked bool
+		err      error
+	}
+	done := make(chan discoveryResult, 1)
+	go func() {
+		defer cancel()
+		panicked, err := discoverRecoverably(ctx, request, discover)
+		done <- discoveryResult{panicked: panicked, err: err}
+	}()
+	select {
+	case result := <-done:
+		releaseDiscovery(queue, instanceKey, result.panicked, result.err)
+	case <-ctx.Done():
+		queue.Abandon(instanceKey)
+		discoveryTimeoutsCounter.Inc(1)
+		atomic.AddInt64(&abandonedDiscoveries, 1)
+		log.Warningf("discoverWithTimeout: discovery of %+v timed out after %ds; abandoning", instanceKey, config.Config.DiscoveryTimeoutSeconds)
+		go func() {
+			<-done
+			atomic.AddInt64(&abandonedDiscoveries, -1)
+			releaseDiscovery(queue, instanceKey, false, fmt.Errorf("discovery of %+v timed out after %ds", instanceKey, config.Config.DiscoveryTimeoutSeconds))
 		}()
 	}
 }
 
+// discoveryRecentlyAttempted notes an attempt to discover given instance, and returns true when it was already
+// attempted within InstancePollSeconds. The retry of a failed discovery is never considered recent, as it
+// follows the failed attempt by DiscoveryRetryDelaySeconds.
+func discoveryRecentlyAttempted(instanceKey inst.InstanceKey, reason string) bool {
+	// Calculate the expiry period each time as InstancePollSeconds
+	// _may_ change during the run of the process (via SIGHUP) and
+	// it is not possible to change the cache's default expiry..
+	if reason == discovery.RetryReason {
+		recentDiscoveryOperationKeys.Set(instanceKey.DisplayString(), true, instancePollSecondsDuration())
+		return false
+	}
+	existsInCacheError := recentDiscoveryOperationKeys.Add(instanceKey.DisplayString(), true, instancePollSecondsDuration())
+	return existsInCacheError != nil
+}
+
 // DiscoverInstance will attempt to discover (poll) an instance (unless
 // it is already up to date) and will also ensure that its master and
-// replicas (if any) are also checked.
-func DiscoverInstance(instanceKey inst.InstanceKey) {
+// replicas (if any) are also checked. Returns an error when the instance could not be read;
+// skipping discovery of an instance is not an error. The reason, describing what
+// triggered the discovery, is logged and audited when a new instance is discovered.
+// Queries on the instance fail once given context is done.
+func DiscoverInstance(ctx context.Context, instanceKey inst.InstanceKey, reason string) error {
 	if inst.InstanceIsForgotten(&instanceKey) {
 		log.Debugf("discoverInstance: skipping discovery of %+v because it is set to be forgotten", instanceKey)
-		return
+		return nil
 	}
 	// create stopwatch entries
 	latency := stopwatch.NewNamedStopwatch()
@@ -209,29 +514,30 @@ func DiscoverInstance(instanceKey inst.InstanceKey) {
 
 	instanceKey.ResolveHostname()
 	if !instanceKey.IsValid() {
-		return
+		return nil
 	}
 
-	// Calculate the expiry period each time as InstancePollSeconds
-	// _may_ change during the run of the process (via SIGHUP) and
-	// it is not possible to change the cache's default expiry..
-	if existsInCacheError := recentDiscoveryOperationKeys.Add(instanceKey.DisplayString(), true, instancePollSecondsDuration()); existsInCacheError != nil {
-		// Just recently attempted
-		return
+	if discoveryRecentlyAttempted(instanceKey, reason) {
+		return nil
 	}
 
 	latency.Start("backend")
 	instance, found, err := inst.ReadInstance(&instanceKey)
 	latency.Stop("backend")
+	if found && instance.IgnoreHealthChecks {
+		// Deliberately unreachable; keep its last known state
+		return nil
+	}
 	if found && instance.IsUpToDate && instance.IsLastCheckValid {
 		// we've already discovered this one. Skip!
-		return
+		return nil
 	}
 
 	discoveriesCounter.Inc(1)
+	log.Debugf("discoverInstance: discovering %+v, reason: %s", instanceKey, reason)
 
 	// First we've ever heard of this instance. Continue investigation:
-	instance, err = inst.ReadTopologyInstanceBufferable(&instanceKey, config.Config.BufferInstanceWrites, latency)
+	instance, err = inst.ReadTopologyInstanceBufferable(ctx, &instanceKey, config.Config.BufferInstanceWrites, latency)
 	// panic can occur (IO stuff). Therefore it may happen
 	// that instance is nil. Check it, but first get the timing metrics.
 	totalLatency := latency.Elapsed("total")
@@ -240,6 +546,10 @@ func DiscoverInstance(instanceKey inst.InstanceKey) {
 
 	if instance == nil {
 		failedDiscoveriesCounter.Inc(1)
+		if err == nil {
+			err = fmt.Errorf("DiscoverInstance: unable to read %+v", instanceKey)
+		}
+		getDiscoveryRouter().Queue(instanceKey).RecordResult(instanceKey, err)
 		discoveryMetrics.Append(&discovery.Metric{
 			Timestamp:       time.Now(),
 			InstanceKey:     instanceKey,
@@ -256,7 +566,7 @@ func DiscoverInstance(instanceKey inst.InstanceKey) {
 				instanceLatency.Seconds(),
 				err)
 		}
-		return
+		return err
 	}
 
 	discoveryMetrics.Append(&discovery.Metric{
@@ -267,11 +577,21 @@ func DiscoverInstance(instanceKey inst.InstanceKey) {
 		InstanceLatency: instanceLatency,
 		Err:             nil,
 	})
+	getDiscoveryRouter().Queue(instanceKey).RecordResult(instanceKey, nil)
+	if !found {
+		inst.AuditOperation("discover-instance", &instanceKey, fmt.Sprintf("discovered new instance; reason: %s", reason))
+		inheritClusterDowntime(instance)
+	}
 
 	if !IsLeaderOrActive() {
 		// Maybe this node was elected before, but isn't elected anymore.
 		// If not elected, stop drilling up/down the topology
-		return
+		return nil
+	}
+
+	if IsLeader() && len(config.Config.AutoRestartReplicationSQLThreadErrorCodes) > 0 {
+		// Opt-in repair of replicas stopped on known-transient errors
+		inst.AutoRestartReplicationSQLThread(instance)
 	}
 
 	// Investigate replicas:
@@ -284,13 +604,37 @@ func DiscoverInstance(instanceKey inst.InstanceKey) {
 		}
 
 		if replicaKey.IsValid() {
-			discoveryQueue.Push(replicaKey)
+			getDiscoveryRouter().PushRequest(discovery.NewDiscoveryRequest(replicaKey, fmt.Sprintf("replica of %+v", instanceKey)), discovery.NormalPriority)
+		}
+	}
+	// Investigate Group Replication members:
+	for _, memberKey := range instance.ReplicationGroupMembers.GetInstanceKeys() {
+		if memberKey.IsValid() {
+			getDiscoveryRouter().PushRequest(discovery.NewDiscoveryRequest(memberKey, fmt.Sprintf("replication group member of %+v", instanceKey)), discovery.NormalPriority)
 		}
 	}
 	// Investigate master:
 	if instance.MasterKey.IsValid() {
-		discoveryQueue.Push(instance.MasterKey)
+		getDiscoveryRouter().PushRequest(discovery.NewDiscoveryRequest(instance.MasterKey, fmt.Sprintf("master of %+v", instanceKey)), discovery.NormalPriority)
 	}
+	return nil
+}
+
+// inheritClusterDowntime downtimes a newly discovered instance whose cluster is downtimed. With raft, the
+// downtime is published by the leader, which discovers the instance just as well.
+func inheritClusterDowntime(instance *inst.Instance) error {
+	downtime, err := inst.ReadInheritedClusterDowntime(instance)
+	if err != nil || downtime == nil {
+		return err
+	}
+	if orcraft.IsRaftEnabled() {
+		if !orcraft.IsLeader() {
+			return nil
+		}
+		_, err = orcraft.PublishCommand("begin-downtime", downtime)
+		return err
+	}
+	return inst.BeginDowntime(downtime)
 }
 
 // onHealthTick handles the actions to take to discover/poll instances
@@ -332,7 +676,7 @@ func onHealthTick() {
 	if !IsLeaderOrActive() {
 		return
 	}
-	instanceKeys, err := inst.ReadOutdatedInstanceKeys()
+	instanceKeys, failedCheckKeys, err := inst.ReadOutdatedInstanceKeys()
 	if err != nil {
 		log.Errore(err)
 	}
@@ -354,11 +698,17 @@ func onHealthTick() {
 			instanceKeys = append(instanceKeys, <-snapshotDiscoveryKeys)
 		}
 	}()
+	// Instances which failed their last check are re-checked first
+	for _, instanceKey := range failedCheckKeys {
+		if instanceKey.IsValid() {
+			getDiscoveryRouter().PushRequest(discovery.NewDiscoveryRequest(instanceKey, "failed check"), discovery.HighPriority)
+		}
+	}
 	// avoid any logging unless there's something to be done
 	if len(instanceKeys) > 0 {
 		for _, instanceKey := range instanceKeys {
 			if instanceKey.IsValid() {
-				discoveryQueue.Push(instanceKey)
+				getDiscoveryRouter().PushRequest(discovery.NewDiscoveryRequest(instanceKey, "poll"), discovery.NormalPriority)
 			}
 		}
 	}
@@ -378,31 +728,76 @@ func publishDiscoverMasters() error {
 	return log.Errore(err)
 }
 
+// pseudoGTIDClusterCounter returns the per-cluster counter of Pseudo-GTID injections with given outcome,
+// e.g. pseudo_gtid.inject.cluster.my_master_3306.success
+func pseudoGTIDClusterCounter(clusterName string, outcome string) metrics.Counter {
+	name := fmt.Sprintf("pseudo_gtid.inject.cluster.%s.%s", metricNameInvalidCharsRegexp.ReplaceAllString(clusterName, "_"), outcome)
+	return metrics.GetOrRegisterCounter(name, metrics.DefaultRegistry)
+}
+
+// injectPseudoGTIDOnWriter injects a Pseudo-GTID entry on given writer, accounting the outcome in both the
+// overall and the per-cluster metrics
+func injectPseudoGTIDOnWriter(instance *inst.Instance) (injected bool, err error) {
+	clusterName := instance.ClusterName
+	if injected, err = inst.CheckAndInjectPseudoGTIDOnWriter(instance); err != nil {
+		pseudoGTIDInjectFailCounter.Inc(1)
+		pseudoGTIDClusterCounter(clusterName, "fail").Inc(1)
+		return injected, err
+	}
+	if !injected {
+		return injected, nil
+	}
+	pseudoGTIDInjectSuccessCounter.Inc(1)
+	pseudoGTIDClusterCounter(clusterName, "success").Inc(1)
+	if orcraft.IsRaftEnabled() {
+		// We prefer not saturating our raft communication. Pseudo-GTID information is
+		// OK to be cached for a while.
+		if _, found := pseudoGTIDPublishCache.Get(clusterName); !found {
+			pseudoGTIDPublishCache.Set(clusterName, true, cache.DefaultExpiration)
+			orcraft.PublishCommand("injected-pseudo-gtid", clusterName)
+		}
+	} else {
+		inst.RegisterInjectedPseudoGTID(clusterName)
+	}
+	return injected, nil
+}
+
 // InjectPseudoGTIDOnWriters will inject a PseudoGTID entry on all writable, accessible,
-// supported writers.
+// supported writers. Downtimed writers are skipped. As writers are read anew on each
+// invocation, injection follows masters as they move, e.g. upon failover.
 func InjectPseudoGTIDOnWriters() error {
 	instances, err := inst.ReadWriteableClustersMasters()
 	if err != nil {
 		return log.Errore(err)
 	}
-	for i := range rand.Perm(len(instances)) {
+	for _, i := range rand.Perm(len(instances)) {
 		instance := instances[i]
-		go func() {
-			if injected, _ := inst.CheckAndInjectPseudoGTIDOnWriter(instance); injected {
-				clusterName := instance.ClusterName
-				if orcraft.IsRaftEnabled() {
-					// We prefer not saturating our raft communication. Pseudo-GTID information is
-					// OK to be cached for a while.
-					if _, found := pseudoGTIDPublishCache.Get(clusterName); !found {
-						pseudoGTIDPublishCache.Set(clusterName, true, cache.DefaultExpiration)
-						orcraft.PublishCommand("injected-pseudo-gtid", clusterName)
-					}
-				} else {
-					inst.RegisterInjectedPseudoGTID(clusterName)
-				}
-			}
-		}()
+		if instance.IsDowntimed {
+			continue
+		}
+		go injectPseudoGTIDOnWriter(instance)
+	}
+	return nil
+}
+
+// putKVPairToStores writes a pair directly to kv stores; overridden in tests
+var putKVPairToStores = kv.PutKVPair
+
+// putKVPair writes given pair to kv stores; via raft when raft is enabled. A pair which fails to be written
+// is marked as pending, such that the periodic SubmitMastersToKvStores resubmits it regardless of what the
+// internal store has.
+func putKVPair(kvPair *kv.KVPair) (err error) {
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("put-key-value", kvPair)
+	} else {
+		err = putKVPairToStores(kvPair)
 	}
+	if err != nil {
+		kvFoundCache.Delete(kvPair.Key)
+		kvPendingCache.Set(kvPair.Key, true, cache.NoExpiration)
+		return err
+	}
+	kvPendingCache.Delete(kvPair.Key)
 	return nil
 }
 
@@ -415,10 +810,17 @@ func SubmitMastersToKvStores(clusterName string, force bool) (kvPairs [](*kv.KVP
 	if err != nil {
 		return kvPairs, submittedCount, log.Errore(err)
 	}
+	submittedCount, err = submitKVPairs(kvPairs, force)
+	return kvPairs, submittedCount, err
+}
+
+// submitKVPairs writes given pairs to kv stores. Unless forced, pairs which the internal store already has are
+// skipped, except for pending pairs, which previously failed to be written.
+func submitKVPairs(kvPairs [](*kv.KVPair), force bool) (submittedCount int, err error) {
 	var selectedError error
 	var submitKvPairs [](*kv.KVPair)
 	for _, kvPair := range kvPairs {
-		if !force {
+		if _, pending := kvPendingCache.Get(kvPair.Key); !force && !pending {
 			// !force: Called periodically to auto-populate KV
 			// We'd like to avoid some overhead.
 			if _, found := kvFoundCache.Get(kvPair.Key); found {
@@ -436,12 +838,7 @@ func SubmitMastersToKvStores(clusterName string, force bool) (kvPairs [](*kv.KVP
 	}
 	log.Debugf("kv.SubmitMastersToKvStores: submitKvPairs: %+v", len(submitKvPairs))
 	for _, kvPair := range submitKvPairs {
-		if orcraft.IsRaftEnabled() {
-			_, err = orcraft.PublishCommand("put-key-value", kvPair)
-		} else {
-			err = kv.PutKVPair(kvPair)
-		}
-		if err == nil {
+		if err = putKVPair(kvPair); err == nil {
 			submittedCount++
 		} else {
 			selectedError = err
@@ -450,7 +847,7 @@ func SubmitMastersToKvStores(clusterName string, force bool) (kvPairs [](*kv.KVP
 	if err := kv.DistributePairs(kvPairs); err != nil {
 		log.Errore(err)
 	}
-	return kvPairs, submittedCount, log.Errore(selectedError)
+	return submittedCount, log.Errore(selectedError)
 }
 
 // ContinuousDiscovery starts an asynchronuous infinite discovery process where instances are
@@ -463,6 +860,10 @@ func ContinuousDiscovery() {
 	recentDiscoveryOperationKeys = cache.New(instancePollSecondsDuration(), time.Second)
 
 	inst.LoadHostnameResolveCache()
+	setupDiscoveryQueues()
+	if config.Config.PersistDiscoveryQueue {
+		restoreDiscoveryBacklog()
+	}
 	go handleDiscoveryRequests()
 
 	healthTick := time.Tick(config.HealthPollSeconds * time.Second)
@@ -470,12 +871,20 @@ func ContinuousDiscovery() {
 	caretakingTick := time.Tick(time.Minute)
 	raftCaretakingTick := time.Tick(10 * time.Minute)
 	recoveryTick := time.Tick(time.Duration(config.RecoveryPollSeconds) * time.Second)
-	autoPseudoGTIDTick := time.Tick(time.Duration(config.PseudoGTIDIntervalSeconds) * time.Second)
+	autoPseudoGTIDTick := time.Tick(time.Duration(config.Config.AutoPseudoGTIDIntervalSeconds) * time.Second)
 	var recoveryEntrance int64
 	var snapshotTopologiesTick <-chan time.Time
 	if config.Config.SnapshotTopologiesIntervalHours > 0 {
 		snapshotTopologiesTick = time.Tick(time.Duration(config.Config.SnapshotTopologiesIntervalHours) * time.Hour)
 	}
+	var binlogSummaryTick <-chan time.Time
+	if config.Config.BinlogSummaryIntervalMinutes > 0 {
+		binlogSummaryTick = time.Tick(time.Duration(config.Config.BinlogSummaryIntervalMinutes) * time.Minute)
+	}
+	var dnsSeedTick <-chan time.Time
+	if len(config.Config.DNSSeedSRVNames) > 0 && config.Config.DNSSeedIntervalSeconds > 0 {
+		dnsSeedTick = time.Tick(time.Duration(config.Config.DNSSeedIntervalSeconds) * time.Second)
+	}
 
 	runCheckAndRecoverOperationsTimeRipe := func() bool {
 		return time.Since(continuousDiscoveryStartTime) >= checkAndRecoverWaitPeriod
@@ -539,8 +948,10 @@ func ContinuousDiscovery() {
 					go inst.ExpireAudit()
 					go inst.ExpireMasterPositionEquivalence()
 					go inst.ExpirePoolInstances()
+					go inst.ExpireInstanceTags()
 					go inst.FlushNontrivialResolveCacheToDatabase()
 					go inst.ExpireInjectedPseudoGTID()
+					go inst.ExpireSQLThreadAutoRestarts()
 					go process.ExpireNodesHistory()
 					go process.ExpireAccessTokens()
 					go process.ExpireAvailableNodes()
@@ -590,6 +1001,18 @@ func ContinuousDiscovery() {
 					go inst.SnapshotTopologies()
 				}
 			}()
+		case <-binlogSummaryTick:
+			go func() {
+				if IsLeaderOrActive() {
+					inst.CollectMastersBinlogSummaries()
+				}
+			}()
+		case <-dnsSeedTick:
+			go func() {
+				if IsLeaderOrActive() {
+					SeedDiscoveryFromDNS()
+				}
+			}()
 		}
 	}
 }
@@ -0,0 +1,294 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/discovery"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/kv"
+	test "github.com/openark/golib/tests"
+	"github.com/patrickmn/go-cache"
+)
+
+// expectWithin fails the test unless given channel is readable within a few seconds
+func expectWithin(t *testing.T, c <-chan bool, description string) {
+	select {
+	case <-c:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %s", description)
+	}
+}
+
+// stopWorker stops given queue and waits for its worker to return
+func stopWorker(t *testing.T, queue *discovery.Queue, wait bool, workerDone <-chan bool) {
+	stopped := make(chan bool)
+	go func() {
+		queue.Stop(wait)
+		<-workerDone
+		stopped <- true
+	}()
+	expectWithin(t, stopped, "discovery worker to stop")
+}
+
+func TestDiscoveryWorkerSurvivesPanic(t *testing.T) {
+	queue := discovery.NewQueue("TestDiscoveryWorkerSurvivesPanic")
+	panickingKey := inst.InstanceKey{Hostname: "panic", Port: 3306}
+	panickedDiscoveryKeys.Delete(panickingKey.StringCode())
+	defer panickedDiscoveryKeys.Delete(panickingKey.StringCode())
+	keys := []inst.InstanceKey{
+		panickingKey,
+		{Hostname: "host1", Port: 3306},
+		{Hostname: "host2", Port: 3306},
+	}
+
+	var discoveredMutex sync.Mutex
+	var wg sync.WaitGroup
+	// panicking key is expected to be requeued once
+	wg.Add(len(keys) + 1)
+	discovered := make(map[inst.InstanceKey]int)
+	discover := func(ctx context.Context, request discovery.DiscoveryRequest) error {
+		instanceKey := request.Key
+		defer wg.Done()
+		discoveredMutex.Lock()
+		discovered[instanceKey]++
+		discoveredMutex.Unlock()
+		if instanceKey == panickingKey {
+			panic("deliberate panic")
+		}
+		return nil
+	}
+	for _, key := range keys {
+		queue.Push(key)
+	}
+
+	workerDone := make(chan bool, 1)
+	go func() {
+		discoveryWorker(queue, discover)
+		workerDone <- true
+	}()
+	discoveriesDone := make(chan bool)
+	go func() {
+		wg.Wait()
+		discoveriesDone <- true
+	}()
+	expectWithin(t, discoveriesDone, "discoveries")
+	stopWorker(t, queue, true, workerDone)
+
+	test.S(t).ExpectEquals(discovered[panickingKey], 2)
+	test.S(t).ExpectEquals(discovered[keys[1]], 1)
+	test.S(t).ExpectEquals(discovered[keys[2]], 1)
+	test.S(t).ExpectEquals(queue.ActiveWorkers(), 0)
+}
+
+func TestDiscoveryWorkerTimeout(t *testing.T) {
+	defer func(timeoutSeconds uint) { config.Config.DiscoveryTimeoutSeconds = timeoutSeconds }(config.Config.DiscoveryTimeoutSeconds)
+	config.Config.DiscoveryTimeoutSeconds = 1
+
+	queue := discovery.NewQueue("TestDiscoveryWorkerTimeout")
+	hungKey := inst.InstanceKey{Hostname: "hung", Port: 3306}
+	var discoveries int64
+	started := make(chan bool, 1)
+	unblock := make(chan bool)
+	// Blocks regardless of its context, as would a discovery stuck on a connection attempt
+	discover := func(ctx context.Context, request discovery.DiscoveryRequest) error {
+		atomic.AddInt64(&discoveries, 1)
+		started <- true
+		<-unblock
+		return nil
+	}
+	events := queue.Subscribe(10)
+	defer queue.Unsubscribe(events)
+	queue.Push(hungKey)
+
+	workerDone := make(chan bool, 1)
+	go func() {
+		discoveryWorker(queue, discover)
+		workerDone <- true
+	}()
+	expectWithin(t, started, "discovery to start")
+	for start := time.Now(); atomic.LoadInt64(&abandonedDiscoveries) == 0; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("expected discovery of %+v to be abandoned", hungKey)
+		}
+	}
+	test.S(t).ExpectEquals(atomic.LoadInt64(&abandonedDiscoveries), int64(1))
+	test.S(t).ExpectEquals(queue.ActiveWorkers(), 0)
+
+	// Still being discovered, hence not dispatched again
+	queue.Push(hungKey)
+	test.S(t).ExpectEquals(queue.QueueLen(), 0)
+	test.S(t).ExpectEquals(atomic.LoadInt64(&discoveries), int64(1))
+
+	close(unblock)
+	var event discovery.CompletionEvent
+	select {
+	case event = <-events:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for completion of %+v", hungKey)
+	}
+	test.S(t).ExpectEquals(event.Key, hungKey)
+	test.S(t).ExpectNotNil(event.Err)
+	test.S(t).ExpectTrue(strings.Contains(event.Err.Error(), "timed out"))
+	test.S(t).ExpectEquals(atomic.LoadInt64(&abandonedDiscoveries), int64(0))
+	test.S(t).ExpectEquals(atomic.LoadInt64(&discoveries), int64(1))
+
+	stopWorker(t, queue, false, workerDone)
+}
+
+func TestFailedDiscoveryIsRetried(t *testing.T) {
+	defer func(keys *cache.Cache) { recentDiscoveryOperationKeys = keys }(recentDiscoveryOperationKeys)
+	recentDiscoveryOperationKeys = cache.New(time.Minute, time.Second)
+
+	queue := discovery.NewQueue("TestFailedDiscoveryIsRetried")
+	queue.SetRetryPolicy(2, 10*time.Millisecond)
+	failingKey := inst.InstanceKey{Hostname: "restarting", Port: 3306}
+	var attempts int64
+	retried := make(chan bool, 1)
+	// Fails the first attempt, as would a connection refused during a restart
+	discover := func(ctx context.Context, request discovery.DiscoveryRequest) error {
+		if discoveryRecentlyAttempted(request.Key, request.Reason) {
+			return nil
+		}
+		if atomic.AddInt64(&attempts, 1) == 1 {
+			return errors.New("connection refused")
+		}
+		retried <- true
+		return nil
+	}
+	queue.Push(failingKey)
+
+	workerDone := make(chan bool, 1)
+	go func() {
+		discoveryWorker(queue, discover)
+		workerDone <- true
+	}()
+	expectWithin(t, retried, "retry of failed discovery")
+	stopWorker(t, queue, false, workerDone)
+	test.S(t).ExpectEquals(atomic.LoadInt64(&attempts), int64(2))
+}
+
+func TestDiscoveryRecentlyAttempted(t *testing.T) {
+	defer func(keys *cache.Cache) { recentDiscoveryOperationKeys = keys }(recentDiscoveryOperationKeys)
+	recentDiscoveryOperationKeys = cache.New(time.Minute, time.Second)
+
+	key := inst.InstanceKey{Hostname: "host1", Port: 3306}
+	test.S(t).ExpectFalse(discoveryRecentlyAttempted(key, "poll"))
+	test.S(t).ExpectTrue(discoveryRecentlyAttempted(key, "poll"))
+	test.S(t).ExpectFalse(discoveryRecentlyAttempted(key, discovery.RetryReason))
+	test.S(t).ExpectTrue(discoveryRecentlyAttempted(key, "poll"))
+}
+
+func TestPutKVPairClearsPending(t *testing.T) {
+	kvPair := kv.NewKVPair("mysql/master/testcluster", "host1:3306")
+	kvPendingCache.Set(kvPair.Key, true, cache.NoExpiration)
+	defer kvPendingCache.Delete(kvPair.Key)
+
+	test.S(t).ExpectNil(putKVPair(kvPair))
+	_, pending := kvPendingCache.Get(kvPair.Key)
+	test.S(t).ExpectFalse(pending)
+}
+
+func TestWriteMasterKVPairsMarksFailedPairsPending(t *testing.T) {
+	defer useSQLiteBackend(t)()
+	defer func(interval time.Duration) { kvWriteRetryInterval = interval }(kvWriteRetryInterval)
+	kvWriteRetryInterval = 0
+	defer func() { putKVPairToStores = kv.PutKVPair }()
+	failingPair := kv.NewKVPair("mysql/master/testcluster/hostname", "host2")
+	healthyPair := kv.NewKVPair("mysql/master/testcluster/port", "3306")
+	defer kvPendingCache.Delete(failingPair.Key)
+	defer kvPendingCache.Delete(healthyPair.Key)
+	attempts := map[string]int{}
+	putKVPairToStores = func(kvPair *kv.KVPair) error {
+		attempts[kvPair.Key]++
+		if kvPair.Key == failingPair.Key {
+			return errors.New("consul unreachable")
+		}
+		return nil
+	}
+
+	failuresBefore := recoverKVWriteFailureCounter.Count()
+	topologyRecovery := &TopologyRecovery{UID: "kv-recovery"}
+	err := writeMasterKVPairs(topologyRecovery, [](*kv.KVPair){failingPair, healthyPair})
+	test.S(t).ExpectNotNil(err)
+	test.S(t).ExpectEquals(attempts[failingPair.Key], kvWriteAttempts)
+	test.S(t).ExpectEquals(attempts[healthyPair.Key], 1)
+	test.S(t).ExpectEquals(recoverKVWriteFailureCounter.Count()-failuresBefore, int64(1))
+	_, pending := kvPendingCache.Get(failingPair.Key)
+	test.S(t).ExpectTrue(pending)
+	_, pending = kvPendingCache.Get(healthyPair.Key)
+	test.S(t).ExpectFalse(pending)
+
+	steps, err := ReadTopologyRecoverySteps(topologyRecovery.UID)
+	test.S(t).ExpectNil(err)
+	audited := false
+	for _, step := range steps {
+		if strings.Contains(step.Message, "failed writing "+failingPair.Key) {
+			audited = true
+		}
+	}
+	test.S(t).ExpectTrue(audited)
+}
+
+func TestSubmitKVPairsRetriesPendingPairs(t *testing.T) {
+	defer func() { putKVPairToStores = kv.PutKVPair }()
+	pendingPair := kv.NewKVPair("mysql/master/testcluster/hostname", "host2")
+	foundPair := kv.NewKVPair("mysql/master/testcluster/port", "3306")
+	defer kvPendingCache.Delete(pendingPair.Key)
+	defer kvFoundCache.Delete(pendingPair.Key)
+	defer kvFoundCache.Delete(foundPair.Key)
+	// both pairs are believed to be found, but the pending pair previously failed to be written
+	kvFoundCache.Set(pendingPair.Key, true, cache.DefaultExpiration)
+	kvFoundCache.Set(foundPair.Key, true, cache.DefaultExpiration)
+	kvPendingCache.Set(pendingPair.Key, true, cache.NoExpiration)
+	kvPairs := [](*kv.KVPair){pendingPair, foundPair}
+
+	var submitted []string
+	putKVPairToStores = func(kvPair *kv.KVPair) error {
+		submitted = append(submitted, kvPair.Key)
+		return errors.New("consul unreachable")
+	}
+	submittedCount, err := submitKVPairs(kvPairs, false)
+	test.S(t).ExpectNotNil(err)
+	test.S(t).ExpectEquals(submittedCount, 0)
+	test.S(t).ExpectEquals(strings.Join(submitted, ","), pendingPair.Key)
+	_, pending := kvPendingCache.Get(pendingPair.Key)
+	test.S(t).ExpectTrue(pending)
+
+	submitted = nil
+	putKVPairToStores = func(kvPair *kv.KVPair) error {
+		submitted = append(submitted, kvPair.Key)
+		return nil
+	}
+	submittedCount, err = submitKVPairs(kvPairs, false)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(submittedCount, 1)
+	test.S(t).ExpectEquals(strings.Join(submitted, ","), pendingPair.Key)
+	_, pending = kvPendingCache.Get(pendingPair.Key)
+	test.S(t).ExpectFalse(pending)
+
+	// no longer pending: the pair is not resubmitted
+	submitted = nil
+	kvFoundCache.Set(pendingPair.Key, true, cache.DefaultExpiration)
+	submittedCount, err = submitKVPairs(kvPairs, false)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(submittedCount, 0)
+	test.S(t).ExpectEquals(len(submitted), 0)
+}
+
+func TestDiscoveryRouterBeforeSetup(t *testing.T) {
+	test.S(t).ExpectTrue(getDiscoveryRouter() != nil)
+	test.S(t).ExpectFalse(IsDiscoveryInCooldown(inst.InstanceKey{Hostname: "host1", Port: 3306}))
+
+	PauseDiscovery()
+	defer ResumeDiscovery()
+	test.S(t).ExpectTrue(IsDiscoveryPaused())
+	ResumeDiscovery()
+	test.S(t).ExpectFalse(IsDiscoveryPaused())
+}
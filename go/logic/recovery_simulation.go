@@ -0,0 +1,260 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/raft"
+)
+
+// SimulatedHook is a hook a simulated recovery would have run, with its placeholders rendered
+type SimulatedHook struct {
+	Hooks   string // e.g. PreFailoverProcesses
+	Command string
+	Async   bool
+}
+
+// RecoverySimulationPlan is what a simulated master recovery would have done, had it been real
+type RecoverySimulationPlan struct {
+	RecoveryType       MasterRecoveryType
+	CandidateKey       *inst.InstanceKey // nil when no replica is promotable
+	CandidateReason    string
+	ReplicasToMove     []inst.InstanceKey // replicas which would replicate from the candidate
+	LostReplicas       []inst.InstanceKey
+	RejectedCandidates []inst.PromotionCandidateRejection
+	Hooks              []SimulatedHook
+}
+
+// simulatedHooks renders given hooks the way executeProcesses would run them for given recovery
+func simulatedHooks(processes []string, description string, topologyRecovery *TopologyRecovery) (hooks []SimulatedHook) {
+	for _, process := range processes {
+		command, async := prepareCommand(process, topologyRecovery)
+		hooks = append(hooks, SimulatedHook{Hooks: description, Command: command, Async: async})
+	}
+	return hooks
+}
+
+// promotedReplicaReplacer has the replacement phase of given recovery run by SuggestReplacementForPromotedReplica,
+// as though regroup had already moved the regrouped replicas below the chosen one
+func promotedReplicaReplacer(topologyRecovery *TopologyRecovery) inst.PromotedReplicaReplacer {
+	return func(regroupCandidate *inst.Instance, regroupedReplicas [](*inst.Instance)) (*inst.Instance, error) {
+		regroupedKeys := inst.NewInstanceKeyMap()
+		for _, replica := range regroupedReplicas {
+			regroupedKeys.AddKey(replica.Key)
+		}
+		replacement, actionRequired, err := suggestReplacementForPromotedReplica(topologyRecovery, &topologyRecovery.AnalysisEntry.AnalyzedInstanceKey, regroupCandidate, nil, regroupedKeys)
+		if err != nil || !actionRequired {
+			return nil, err
+		}
+		return replacement, nil
+	}
+}
+
+// suggestDeadMasterPromotion computes the replica which given recovery of a dead master would promote, along the
+// path of recoverDeadMaster and checkAndRecoverDeadMaster: regroup, replacement of the chosen replica, fallback
+// off a disqualified candidate and geographic constraints. Replicas are assessed by their last known state.
+func suggestDeadMasterPromotion(topologyRecovery *TopologyRecovery) (*inst.PromotionSuggestion, error) {
+	analysisEntry := &topologyRecovery.AnalysisEntry
+	failedCandidateKeys := inst.NewInstanceKeyMap()
+	failedCandidates := []inst.PromotionCandidateRejection{}
+	for attempt := uint(1); ; attempt++ {
+		suggestion, err := inst.SuggestPromotionCandidate(&analysisEntry.AnalyzedInstanceKey, failedCandidateKeys, promotedReplicaReplacer(topologyRecovery))
+		if err != nil {
+			return nil, err
+		}
+		suggestion.RejectedCandidates = append(append([]inst.PromotionCandidateRejection{}, failedCandidates...), suggestion.RejectedCandidates...)
+		if suggestion.Candidate == nil {
+			return suggestion, nil
+		}
+		if disqualification := promotionCandidateDisqualification(suggestion.Candidate); disqualification != "" {
+			rejection := inst.PromotionCandidateRejection{Key: suggestion.Candidate.Key, Reason: fmt.Sprintf("would fail verification before promotion: %s", disqualification)}
+			if candidateFallbackAllowed(true, attempt) {
+				// Its replicas would be regrouped below the next-ranked of them
+				failedCandidateKeys.AddKey(suggestion.Candidate.Key)
+				failedCandidates = append(failedCandidates, rejection)
+				continue
+			}
+			suggestion.RejectedCandidates = append([]inst.PromotionCandidateRejection{rejection}, suggestion.RejectedCandidates...)
+			suggestion.Reason = fmt.Sprintf("chosen replica %+v would fail verification before promotion; recovery would fail", suggestion.Candidate.Key)
+			suggestion.Candidate = nil
+			return suggestion, nil
+		}
+		if satisfied, reason := MasterFailoverGeographicConstraintSatisfied(analysisEntry, suggestion.Candidate); !satisfied {
+			suggestion.RejectedCandidates = append([]inst.PromotionCandidateRejection{{Key: suggestion.Candidate.Key, Reason: reason}}, suggestion.RejectedCandidates...)
+			suggestion.Reason = fmt.Sprintf("%s; recovery would fail", reason)
+			suggestion.Candidate = nil
+		}
+		return suggestion, nil
+	}
+}
+
+// SuggestPromotionCandidate returns the replica which recovery would promote should given master die now, along
+// with the reasons for rejecting the other replicas. Nothing is changed nor audited.
+func SuggestPromotionCandidate(masterKey *inst.InstanceKey) (*inst.PromotionSuggestion, error) {
+	master, found, err := inst.ReadInstance(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("SuggestPromotionCandidate: cannot find instance %+v", *masterKey)
+	}
+	analysisEntry, err := forceAnalysisEntry(master.ClusterName, inst.DeadMaster, "", masterKey)
+	if err != nil {
+		return nil, err
+	}
+	// Not a registered recovery: it has no UID, and its steps are not audited
+	return suggestDeadMasterPromotion(&TopologyRecovery{AnalysisEntry: analysisEntry})
+}
+
+// deadMasterRecoverySimulationPlan computes the plan of a dead master recovery based on the suggested
+// promotion. Successor and lost replicas are set on given recovery, as hooks are rendered in their light.
+func deadMasterRecoverySimulationPlan(topologyRecovery *TopologyRecovery, suggestion *inst.PromotionSuggestion, skipProcesses bool) *RecoverySimulationPlan {
+	plan := &RecoverySimulationPlan{
+		RecoveryType:       deadMasterRecoveryType(&topologyRecovery.AnalysisEntry),
+		CandidateReason:    suggestion.Reason,
+		ReplicasToMove:     []inst.InstanceKey{},
+		LostReplicas:       suggestion.LostReplicas,
+		RejectedCandidates: suggestion.RejectedCandidates,
+		Hooks:              []SimulatedHook{},
+	}
+	if !skipProcesses {
+		plan.Hooks = append(plan.Hooks, simulatedHooks(config.Config.PreFailoverProcesses, "PreFailoverProcesses", topologyRecovery)...)
+	}
+	if suggestion.Candidate == nil {
+		topologyRecovery.LostReplicas.AddKeys(suggestion.LostReplicas)
+		for _, rejection := range suggestion.RejectedCandidates {
+			topologyRecovery.LostReplicas.AddKey(rejection.Key)
+		}
+		if !skipProcesses {
+			plan.Hooks = append(plan.Hooks, simulatedHooks(config.Config.PostUnsuccessfulFailoverProcesses, "PostUnsuccessfulFailoverProcesses", topologyRecovery)...)
+		}
+		return plan
+	}
+	plan.CandidateKey = &suggestion.Candidate.Key
+	lostReplicas := inst.NewInstanceKeyMap()
+	lostReplicas.AddKeys(suggestion.LostReplicas)
+	for _, rejection := range suggestion.RejectedCandidates {
+		if !lostReplicas.HasKey(rejection.Key) {
+			plan.ReplicasToMove = append(plan.ReplicasToMove, rejection.Key)
+		}
+	}
+	topologyRecovery.SuccessorKey = plan.CandidateKey
+	topologyRecovery.SuccessorAlias = suggestion.Candidate.InstanceAlias
+	topologyRecovery.LostReplicas.AddKeys(plan.LostReplicas)
+	topologyRecovery.ParticipatingInstanceKeys.AddKey(*plan.CandidateKey)
+	topologyRecovery.ParticipatingInstanceKeys.AddKeys(plan.ReplicasToMove)
+	if !skipProcesses {
+		plan.Hooks = append(plan.Hooks, simulatedHooks(config.Config.PostMasterFailoverProcesses, "PostMasterFailoverProcesses", topologyRecovery)...)
+		plan.Hooks = append(plan.Hooks, simulatedHooks(config.Config.PostFailoverProcesses, "PostFailoverProcesses", topologyRecovery)...)
+	}
+	return plan
+}
+
+// auditRecoverySimulationPlan audits the plan of a simulated recovery as its steps
+func auditRecoverySimulationPlan(topologyRecovery *TopologyRecovery, plan *RecoverySimulationPlan) {
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("SimulateDeadMasterRecovery: masterRecoveryType=%+v", plan.RecoveryType))
+	if plan.CandidateKey == nil {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("SimulateDeadMasterRecovery: would fail; %s", plan.CandidateReason))
+	} else {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("SimulateDeadMasterRecovery: would promote %+v: %s", *plan.CandidateKey, plan.CandidateReason))
+	}
+	for _, key := range plan.ReplicasToMove {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("SimulateDeadMasterRecovery: would move %+v below %+v", key, *plan.CandidateKey))
+	}
+	for _, key := range plan.LostReplicas {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("SimulateDeadMasterRecovery: would lose %+v", key))
+	}
+	for _, hook := range plan.Hooks {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("SimulateDeadMasterRecovery: would run %s hook: %s", hook.Hooks, hook.Command))
+	}
+}
+
+// simulateDeadMasterRecovery computes the plan of a recovery of the dead master, and records it as a simulated
+// recovery. Nothing is executed: replication is not stopped, no replica is moved, no hook is run.
+func simulateDeadMasterRecovery(topologyRecovery *TopologyRecovery, skipProcesses bool) error {
+	topologyRecovery.Type = MasterRecovery
+	topologyRecovery.IsSimulated = true
+	analysisEntry := &topologyRecovery.AnalysisEntry
+	topologyRecovery.RecoveryType = deadMasterRecoveryType(analysisEntry)
+
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("SimulateDeadMasterRecovery: will simulate recovery of %+v; nothing will be executed", analysisEntry.AnalyzedInstanceKey))
+	if suggestion, err := suggestDeadMasterPromotion(topologyRecovery); err != nil {
+		topologyRecovery.AddError(err)
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("SimulateDeadMasterRecovery: cannot compute plan: %+v", err))
+	} else {
+		topologyRecovery.SimulationPlan = deadMasterRecoverySimulationPlan(topologyRecovery, suggestion, skipProcesses)
+		auditRecoverySimulationPlan(topologyRecovery, topologyRecovery.SimulationPlan)
+	}
+	return resolveSimulatedRecovery(topologyRecovery)
+}
+
+// resolveSimulatedRecovery persists the outcome of a simulated recovery
+func resolveSimulatedRecovery(topologyRecovery *TopologyRecovery) error {
+	if orcraft.IsRaftEnabled() {
+		_, err := orcraft.PublishCommand("resolve-simulated-recovery", topologyRecovery)
+		return err
+	}
+	return writeResolveSimulatedRecovery(topologyRecovery)
+}
+
+// SimulateMasterFailover computes and records what ForceMasterFailover would do on given cluster, without
+// doing it. The simulated recovery is acknowledged right away, such that it does not block actual recoveries.
+func SimulateMasterFailover(clusterName string) (topologyRecovery *TopologyRecovery, err error) {
+	clusterMasters, err := inst.ReadClusterMaster(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot deduce cluster master for %+v", clusterName)
+	}
+	if len(clusterMasters) != 1 {
+		return nil, fmt.Errorf("Cannot deduce cluster master for %+v", clusterName)
+	}
+	clusterMaster := clusterMasters[0]
+
+	analysisEntry, err := forceAnalysisEntry(clusterName, inst.DeadMaster, inst.ForceMasterFailoverCommandHint, &clusterMaster.Key)
+	if err != nil {
+		return nil, err
+	}
+	// Registering directly, rather than via AttemptRecoveryRegistration, neither blocks on nor implicitly
+	// acknowledges existing recoveries
+	topologyRecovery, err = writeTopologyRecovery(NewTopologyRecovery(analysisEntry))
+	if err != nil {
+		return nil, err
+	}
+	if topologyRecovery == nil {
+		return nil, fmt.Errorf("SimulateMasterFailover: a recovery on %+v is already active", clusterMaster.Key)
+	}
+	if orcraft.IsRaftEnabled() {
+		if _, err := orcraft.PublishCommand("write-recovery", topologyRecovery); err != nil {
+			return nil, err
+		}
+	}
+	if err := simulateDeadMasterRecovery(topologyRecovery, false); err != nil {
+		return topologyRecovery, err
+	}
+
+	comment := "simulated recovery (dry run)"
+	if orcraft.IsRaftEnabled() {
+		ack := NewRecoveryAcknowledgement(inst.GetMaintenanceOwner(), comment)
+		ack.UID = topologyRecovery.UID
+		_, err = orcraft.PublishCommand("ack-recovery", ack)
+	} else {
+		_, err = AcknowledgeRecoveryByUID(topologyRecovery.UID, inst.GetMaintenanceOwner(), comment)
+	}
+	return topologyRecovery, err
+}
@@ -46,6 +46,7 @@ type SnapshotData struct {
 	HostnameResolves,
 	HostnameUnresolves,
 	DowntimedInstances,
+	HealthChecksIgnoredInstances,
 	Candidates,
 	Detections,
 	KVStore,
@@ -96,6 +97,7 @@ func CreateSnapshotData() *SnapshotData {
 	readTableData("hostname_resolve", &snapshotData.HostnameResolves)
 	readTableData("hostname_unresolve", &snapshotData.HostnameUnresolves)
 	readTableData("database_instance_downtime", &snapshotData.DowntimedInstances)
+	readTableData("database_instance_ignore_health_checks", &snapshotData.HealthChecksIgnoredInstances)
 	readTableData("candidate_database_instance", &snapshotData.Candidates)
 	readTableData("topology_failure_detection", &snapshotData.Detections)
 	readTableData("kv_store", &snapshotData.KVStore)
@@ -202,6 +204,7 @@ func (this *SnapshotDataCreatorApplier) Restore(rc io.ReadCloser) error {
 	writeTableData("hostname_resolve", &snapshotData.HostnameResolves)
 	writeTableData("hostname_unresolve", &snapshotData.HostnameUnresolves)
 	writeTableData("database_instance_downtime", &snapshotData.DowntimedInstances)
+	writeTableData("database_instance_ignore_health_checks", &snapshotData.HealthChecksIgnoredInstances)
 	writeTableData("candidate_database_instance", &snapshotData.Candidates)
 	writeTableData("kv_store", &snapshotData.KVStore)
 	writeTableData("topology_recovery", &snapshotData.Recovery)
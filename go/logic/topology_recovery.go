@@ -28,6 +28,7 @@ import (
 
 	"github.com/github/orchestrator/go/attributes"
 	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/discovery"
 	"github.com/github/orchestrator/go/inst"
 	"github.com/github/orchestrator/go/kv"
 	ometrics "github.com/github/orchestrator/go/metrics"
@@ -80,11 +81,12 @@ func NewInternalAcknowledgement() *RecoveryAcknowledgement {
 
 // BlockedTopologyRecovery represents an entry in the blocked_topology_recovery table
 type BlockedTopologyRecovery struct {
-	FailedInstanceKey    inst.InstanceKey
-	ClusterName          string
-	Analysis             inst.AnalysisCode
-	LastBlockedTimestamp string
-	BlockingRecoveryId   int64
+	FailedInstanceKey     inst.InstanceKey
+	ClusterName           string
+	Analysis              inst.AnalysisCode
+	LastBlockedTimestamp  string
+	BlockingRecoveryId    int64
+	BlockRemainingSeconds int64
 }
 
 // TopologyRecovery represents an entry in the topology_recovery table
@@ -113,6 +115,11 @@ type TopologyRecovery struct {
 	RelatedRecoveryId         int64
 	Type                      RecoveryType
 	RecoveryType              MasterRecoveryType
+	GeographicConstraint      string                  // the Prevent*/Prefer* master failover constraints applied, comma delimited
+	FailureReason             string                  // distinct reason of a failed recovery, where known; see FailureReason* constants
+	Timeline                  []TopologyRecoveryStep  // completed recovery phases; only read by the audit-recovery API
+	IsSimulated               bool                    // the recovery only computed its plan, see RecoverySimulationMode
+	SimulationPlan            *RecoverySimulationPlan // what a simulated recovery would have done
 }
 
 func NewTopologyRecovery(replicationAnalysis inst.ReplicationAnalysis) *TopologyRecovery {
@@ -140,11 +147,19 @@ func (this *TopologyRecovery) AddErrors(errs []error) {
 	}
 }
 
+// TopologyRecoveryStep is a single audited step of a recovery. A step which completes a recovery phase
+// (e.g. candidate selection) further indicates the phase, the instance involved, the outcome and the
+// duration of that phase. Such steps make for the recovery's timeline.
 type TopologyRecoveryStep struct {
-	Id          int64
-	RecoveryUID string
-	AuditAt     string
-	Message     string
+	Id             int64
+	RecoveryUID    string
+	AuditAt        string
+	Message        string
+	Phase          string
+	Key            inst.InstanceKey
+	Outcome        string
+	Error          string
+	DurationMillis int64
 }
 
 func NewTopologyRecoveryStep(uid string, message string) *TopologyRecoveryStep {
@@ -163,9 +178,19 @@ const (
 	MasterRecoveryBinlogServer                    = "MasterRecoveryBinlogServer"
 )
 
+// Failure reasons of a master recovery, passed to hooks as {failureReason}
+const (
+	FailureReasonCrossDataCenterPromotion = "CrossDataCenterPromotionPrevented"
+	FailureReasonCrossRegionPromotion     = "CrossRegionPromotionPrevented"
+)
+
 var emergencyReadTopologyInstanceMap *cache.Cache
 var emergencyRestartReplicaTopologyInstanceMap *cache.Cache
 var emergencyOperationGracefulPeriodMap *cache.Cache
+var noWriteableMasterProcessesMap *cache.Cache
+var resurrectedOldMasterFenceMap *cache.Cache
+var resurrectedOldMasterFenceAttemptMap *cache.Cache
+var resurrectedOldMasterFenceFailuresMap *cache.Cache
 
 // InstancesByCountReplicas sorts instances by umber of replicas, descending
 type InstancesByCountReplicas [](*inst.Instance)
@@ -190,6 +215,13 @@ var recoverDeadCoMasterCounter = metrics.NewCounter()
 var recoverDeadCoMasterSuccessCounter = metrics.NewCounter()
 var recoverDeadCoMasterFailureCounter = metrics.NewCounter()
 var countPendingRecoveriesGauge = metrics.NewGauge()
+var recoverKVWriteFailureCounter = metrics.NewCounter()
+
+// kvWriteAttempts is the number of attempts at writing each KV pair of a newly promoted master
+const kvWriteAttempts = 3
+
+// kvWriteRetryInterval is the pause between attempts at writing a KV pair; overridden in tests
+var kvWriteRetryInterval = time.Second
 
 func init() {
 	metrics.Register("recover.dead_master.start", recoverDeadMasterCounter)
@@ -202,6 +234,7 @@ func init() {
 	metrics.Register("recover.dead_co_master.success", recoverDeadCoMasterSuccessCounter)
 	metrics.Register("recover.dead_co_master.fail", recoverDeadCoMasterFailureCounter)
 	metrics.Register("recover.pending", countPendingRecoveriesGauge)
+	metrics.Register("recover.kv_write.fail", recoverKVWriteFailureCounter)
 
 	go initializeTopologyRecoveryPostConfiguration()
 
@@ -220,22 +253,62 @@ func initializeTopologyRecoveryPostConfiguration() {
 	emergencyReadTopologyInstanceMap = cache.New(time.Second, time.Millisecond*250)
 	emergencyRestartReplicaTopologyInstanceMap = cache.New(time.Second*30, time.Second)
 	emergencyOperationGracefulPeriodMap = cache.New(time.Second*5, time.Millisecond*500)
+	noWriteableMasterProcessesMap = cache.New(time.Duration(config.Config.FailureDetectionPeriodBlockMinutes)*time.Minute, time.Minute)
+	resurrectedOldMasterFenceMap = cache.New(time.Duration(config.Config.FailureDetectionPeriodBlockMinutes)*time.Minute, time.Minute)
+	resurrectedOldMasterFenceAttemptMap = cache.New(time.Duration(config.Config.FailureDetectionPeriodBlockMinutes)*time.Minute, time.Second)
+	resurrectedOldMasterFenceFailuresMap = cache.New(time.Duration(config.Config.FailureDetectionPeriodBlockMinutes)*time.Minute, time.Minute)
 }
 
 // AuditTopologyRecovery audits a single step in a topology recovery process.
 func AuditTopologyRecovery(topologyRecovery *TopologyRecovery, message string) error {
 	log.Infof("topology_recovery: %s", message)
-	if topologyRecovery == nil {
+	if topologyRecovery == nil || topologyRecovery.UID == "" {
+		// Not a registered recovery, e.g. a promotion suggestion
 		return nil
 	}
 
 	recoveryStep := NewTopologyRecoveryStep(topologyRecovery.UID, message)
+	return auditTopologyRecoveryStep(recoveryStep)
+}
+
+// AuditTopologyRecoveryPhase audits the completion of a recovery phase begun at given time, along with the
+// instance it involved, if any, and its outcome. Returns given error.
+func AuditTopologyRecoveryPhase(topologyRecovery *TopologyRecovery, phase string, instanceKey *inst.InstanceKey, startedAt time.Time, err error) error {
+	recoveryStep := NewTopologyRecoveryStep("", "")
+	recoveryStep.Phase = phase
+	recoveryStep.DurationMillis = time.Since(startedAt).Nanoseconds() / int64(time.Millisecond)
+	recoveryStep.Outcome = "success"
+	if err != nil {
+		recoveryStep.Outcome = "failure"
+		recoveryStep.Error = err.Error()
+	}
+	recoveryStep.Message = fmt.Sprintf("phase %s: %s in %dms", phase, recoveryStep.Outcome, recoveryStep.DurationMillis)
+	if instanceKey != nil {
+		recoveryStep.Key = *instanceKey
+		recoveryStep.Message = fmt.Sprintf("phase %s on %+v: %s in %dms", phase, *instanceKey, recoveryStep.Outcome, recoveryStep.DurationMillis)
+	}
+	if err != nil {
+		recoveryStep.Message = fmt.Sprintf("%s: %+v", recoveryStep.Message, err)
+	}
+	log.Infof("topology_recovery: %s", recoveryStep.Message)
+	if topologyRecovery == nil {
+		return err
+	}
+	recoveryStep.RecoveryUID = topologyRecovery.UID
+	if auditErr := auditTopologyRecoveryStep(recoveryStep); auditErr != nil {
+		log.Errorf("topology_recovery: cannot audit phase %s of recovery %s: %+v", phase, topologyRecovery.UID, auditErr)
+	}
+	return err
+}
+
+// auditTopologyRecoveryStep writes given step, via raft when raft is enabled. Steps are written as the recovery
+// progresses, such that a crashed recovery still leaves a partial timeline.
+func auditTopologyRecoveryStep(recoveryStep *TopologyRecoveryStep) error {
 	if orcraft.IsRaftEnabled() {
 		_, err := orcraft.PublishCommand("write-recovery-step", recoveryStep)
 		return err
-	} else {
-		return writeTopologyRecoveryStep(recoveryStep)
 	}
+	return writeTopologyRecoveryStep(recoveryStep)
 }
 
 func resolveRecovery(topologyRecovery *TopologyRecovery, successorInstance *inst.Instance) error {
@@ -252,6 +325,15 @@ func resolveRecovery(topologyRecovery *TopologyRecovery, successorInstance *inst
 	}
 }
 
+// updateTopologyRecoveryLostReplicas persists the lost replicas of a completed recovery
+func updateTopologyRecoveryLostReplicas(topologyRecovery *TopologyRecovery) error {
+	if orcraft.IsRaftEnabled() {
+		_, err := orcraft.PublishCommand("update-recovery-lost-replicas", topologyRecovery)
+		return err
+	}
+	return writeTopologyRecoveryLostReplicas(topologyRecovery)
+}
+
 // prepareCommand replaces agreed-upon placeholders with analysis data
 func prepareCommand(command string, topologyRecovery *TopologyRecovery) (result string, async bool) {
 	analysisEntry := &topologyRecovery.AnalysisEntry
@@ -265,11 +347,15 @@ func prepareCommand(command string, topologyRecovery *TopologyRecovery) (result
 	command = strings.Replace(command, "{command}", analysisEntry.CommandHint, -1)
 	command = strings.Replace(command, "{failedHost}", analysisEntry.AnalyzedInstanceKey.Hostname, -1)
 	command = strings.Replace(command, "{failedPort}", fmt.Sprintf("%d", analysisEntry.AnalyzedInstanceKey.Port), -1)
+	command = strings.Replace(command, "{failedDataCenter}", analysisEntry.AnalyzedInstanceDataCenter, -1)
+	command = strings.Replace(command, "{failedRegion}", analysisEntry.AnalyzedInstanceRegion, -1)
+	command = strings.Replace(command, "{failedPhysicalEnvironment}", analysisEntry.AnalyzedInstancePhysicalEnvironment, -1)
 	command = strings.Replace(command, "{failureCluster}", analysisEntry.ClusterDetails.ClusterName, -1)
 	command = strings.Replace(command, "{failureClusterAlias}", analysisEntry.ClusterDetails.ClusterAlias, -1)
 	command = strings.Replace(command, "{failureClusterDomain}", analysisEntry.ClusterDetails.ClusterDomain, -1)
 	command = strings.Replace(command, "{countSlaves}", fmt.Sprintf("%d", analysisEntry.CountReplicas), -1)
 	command = strings.Replace(command, "{countReplicas}", fmt.Sprintf("%d", analysisEntry.CountReplicas), -1)
+	command = strings.Replace(command, "{countReplicatingReplicasPerDataCenter}", analysisEntry.ValidReplicatingReplicasPerDataCenterString(), -1)
 	command = strings.Replace(command, "{isDowntimed}", fmt.Sprint(analysisEntry.IsDowntimed), -1)
 	command = strings.Replace(command, "{autoMasterRecovery}", fmt.Sprint(analysisEntry.ClusterDetails.HasAutomatedMasterRecovery), -1)
 	command = strings.Replace(command, "{autoIntermediateMasterRecovery}", fmt.Sprint(analysisEntry.ClusterDetails.HasAutomatedIntermediateMasterRecovery), -1)
@@ -277,6 +363,7 @@ func prepareCommand(command string, topologyRecovery *TopologyRecovery) (result
 	command = strings.Replace(command, "{recoveryUID}", topologyRecovery.UID, -1)
 
 	command = strings.Replace(command, "{isSuccessful}", fmt.Sprint(topologyRecovery.SuccessorKey != nil), -1)
+	command = strings.Replace(command, "{failureReason}", topologyRecovery.FailureReason, -1)
 	if topologyRecovery.SuccessorKey != nil {
 		command = strings.Replace(command, "{successorHost}", topologyRecovery.SuccessorKey.Hostname, -1)
 		command = strings.Replace(command, "{successorPort}", fmt.Sprintf("%d", topologyRecovery.SuccessorKey.Port), -1)
@@ -303,15 +390,20 @@ func applyEnvironmentVariables(topologyRecovery *TopologyRecovery) []string {
 	env = append(env, fmt.Sprintf("ORC_COMMAND=%s", analysisEntry.CommandHint))
 	env = append(env, fmt.Sprintf("ORC_FAILED_HOST=%s", analysisEntry.AnalyzedInstanceKey.Hostname))
 	env = append(env, fmt.Sprintf("ORC_FAILED_PORT=%d", analysisEntry.AnalyzedInstanceKey.Port))
+	env = append(env, fmt.Sprintf("ORC_FAILED_DATA_CENTER=%s", analysisEntry.AnalyzedInstanceDataCenter))
+	env = append(env, fmt.Sprintf("ORC_FAILED_REGION=%s", analysisEntry.AnalyzedInstanceRegion))
+	env = append(env, fmt.Sprintf("ORC_FAILED_PHYSICAL_ENVIRONMENT=%s", analysisEntry.AnalyzedInstancePhysicalEnvironment))
 	env = append(env, fmt.Sprintf("ORC_FAILURE_CLUSTER=%s", analysisEntry.ClusterDetails.ClusterName))
 	env = append(env, fmt.Sprintf("ORC_FAILURE_CLUSTER_ALIAS=%s", analysisEntry.ClusterDetails.ClusterAlias))
 	env = append(env, fmt.Sprintf("ORC_FAILURE_CLUSTER_DOMAIN=%s", analysisEntry.ClusterDetails.ClusterDomain))
 	env = append(env, fmt.Sprintf("ORC_COUNT_REPLICAS=%d", analysisEntry.CountReplicas))
+	env = append(env, fmt.Sprintf("ORC_COUNT_REPLICATING_REPLICAS_PER_DATA_CENTER=%s", analysisEntry.ValidReplicatingReplicasPerDataCenterString()))
 	env = append(env, fmt.Sprintf("ORC_IS_DOWNTIMED=%v", analysisEntry.IsDowntimed))
 	env = append(env, fmt.Sprintf("ORC_AUTO_MASTER_RECOVERY=%v", analysisEntry.ClusterDetails.HasAutomatedMasterRecovery))
 	env = append(env, fmt.Sprintf("ORC_AUTO_INTERMEDIATE_MASTER_RECOVERY=%v", analysisEntry.ClusterDetails.HasAutomatedIntermediateMasterRecovery))
 	env = append(env, fmt.Sprintf("ORC_ORCHESTRATOR_HOST=%s", process.ThisHostname))
 	env = append(env, fmt.Sprintf("ORC_IS_SUCCESSFUL=%v", (topologyRecovery.SuccessorKey != nil)))
+	env = append(env, fmt.Sprintf("ORC_FAILURE_REASON=%s", topologyRecovery.FailureReason))
 	env = append(env, fmt.Sprintf("ORC_LOST_REPLICAS=%s", topologyRecovery.LostReplicas.ToCommaDelimitedList()))
 	env = append(env, fmt.Sprintf("ORC_REPLICA_HOSTS=%s", analysisEntry.SlaveHosts.ToCommaDelimitedList()))
 	env = append(env, fmt.Sprintf("ORC_RECOVERY_UID=%s", topologyRecovery.UID))
@@ -327,12 +419,28 @@ func applyEnvironmentVariables(topologyRecovery *TopologyRecovery) []string {
 	return env
 }
 
+// processOutputAuditMaxLength limits the hook output recorded in a recovery's audit
+const processOutputAuditMaxLength = 4096
+
+// processOutputAuditMessage formats a hook's output for a recovery's audit, keeping its tail where too long
+func processOutputAuditMessage(fullDescription string, output string) string {
+	output = strings.TrimSpace(output)
+	if len(output) > processOutputAuditMaxLength {
+		output = fmt.Sprintf("(truncated) ...%s", output[len(output)-processOutputAuditMaxLength:])
+	}
+	return fmt.Sprintf("Output of %s: %s", fullDescription, output)
+}
+
 func executeProcess(command string, env []string, topologyRecovery *TopologyRecovery, fullDescription string) (err error) {
 	// Log the command to be run and record how long it takes as this may be useful
 	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Running %s: %s", fullDescription, command))
 	start := time.Now()
 	var info string
-	if err = os.CommandRun(command, env); err == nil {
+	output, err := os.CommandRunWithTimeout(command, env, time.Duration(config.Config.ProcessesTimeoutSeconds)*time.Second)
+	if strings.TrimSpace(output) != "" {
+		AuditTopologyRecovery(topologyRecovery, processOutputAuditMessage(fullDescription, output))
+	}
+	if err == nil {
 		info = fmt.Sprintf("Completed %s in %v", fullDescription, time.Since(start))
 	} else {
 		info = fmt.Sprintf("Execution of %s failed in %v with error: %v", fullDescription, time.Since(start), err)
@@ -350,6 +458,9 @@ func executeProcesses(processes []string, description string, topologyRecovery *
 	}
 
 	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Running %d %s hooks", len(processes), description))
+	defer func(startedAt time.Time) {
+		AuditTopologyRecoveryPhase(topologyRecovery, description, nil, startedAt, err)
+	}(time.Now())
 	for i, command := range processes {
 		command, async := prepareCommand(command, topologyRecovery)
 		env := applyEnvironmentVariables(topologyRecovery)
@@ -431,7 +542,7 @@ func recoverDeadMasterInBinlogServerTopology(topologyRecovery *TopologyRecovery)
 	if err != nil {
 		return promotedReplica, log.Errore(err)
 	}
-	promotedBinlogServer, err = inst.Repoint(&promotedBinlogServer.Key, &promotedReplica.Key, inst.GTIDHintDeny)
+	promotedBinlogServer, err = inst.Repoint(&promotedBinlogServer.Key, &promotedReplica.Key, inst.GTIDHintDeny, true)
 	if err != nil {
 		return nil, log.Errore(err)
 	}
@@ -463,7 +574,7 @@ func recoverDeadMasterInBinlogServerTopology(topologyRecovery *TopologyRecovery)
 						return err
 					}
 				}
-				_, err = inst.Repoint(&binlogServerReplica.Key, &promotedReplica.Key, inst.GTIDHintDeny)
+				_, err = inst.Repoint(&binlogServerReplica.Key, &promotedReplica.Key, inst.GTIDHintDeny, true)
 				return err
 			}
 			topologyRecovery.AddPostponedFunction(postponedFunction, fmt.Sprintf("recoverDeadMasterInBinlogServerTopology, moving binlog server %+v", binlogServerReplica.Key))
@@ -473,6 +584,21 @@ func recoverDeadMasterInBinlogServerTopology(topologyRecovery *TopologyRecovery)
 	return promotedReplica, err
 }
 
+// deadMasterRecoveryType returns the method by which the replicas of a dead master are regrouped
+func deadMasterRecoveryType(analysisEntry *inst.ReplicationAnalysis) MasterRecoveryType {
+	if analysisEntry.OracleGTIDImmediateTopology || analysisEntry.MariaDBGTIDImmediateTopology {
+		return MasterRecoveryGTID
+	}
+	if analysisEntry.BinlogServerImmediateTopology {
+		return MasterRecoveryBinlogServer
+	}
+	if analysisEntry.GTIDWithBinlogServersImmediateTopology && !analysisEntry.PseudoGTIDImmediateTopology {
+		// Regrouping via Pseudo-GTID re-chains binlog servers; without it, replicas of binlog servers are moved via GTID
+		return MasterRecoveryGTID
+	}
+	return MasterRecoveryPseudoGTID
+}
+
 // recoverDeadMaster recovers a dead master, complete logic inside
 func recoverDeadMaster(topologyRecovery *TopologyRecovery, candidateInstanceKey *inst.InstanceKey, skipProcesses bool) (promotedReplica *inst.Instance, lostReplicas [](*inst.Instance), err error) {
 	topologyRecovery.Type = MasterRecovery
@@ -490,14 +616,13 @@ func recoverDeadMaster(topologyRecovery *TopologyRecovery, candidateInstanceKey
 
 	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: will recover %+v", *failedInstanceKey))
 
-	var masterRecoveryType MasterRecoveryType = MasterRecoveryPseudoGTID
-	if analysisEntry.OracleGTIDImmediateTopology || analysisEntry.MariaDBGTIDImmediateTopology {
-		masterRecoveryType = MasterRecoveryGTID
-	} else if analysisEntry.BinlogServerImmediateTopology {
-		masterRecoveryType = MasterRecoveryBinlogServer
-	}
+	masterRecoveryType := deadMasterRecoveryType(analysisEntry)
 	topologyRecovery.RecoveryType = masterRecoveryType
 	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: masterRecoveryType=%+v", masterRecoveryType))
+	topologyRecovery.GeographicConstraint = masterFailoverGeographicConstraint()
+	if topologyRecovery.GeographicConstraint != "" {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: geographic constraint=%s, failed master in data center %s, region %s", topologyRecovery.GeographicConstraint, analysisEntry.AnalyzedInstanceDataCenter, analysisEntry.AnalyzedInstanceRegion))
+	}
 
 	promotedReplicaIsIdeal := func(promoted *inst.Instance) bool {
 		if promoted == nil {
@@ -519,11 +644,25 @@ func recoverDeadMaster(topologyRecovery *TopologyRecovery, candidateInstanceKey
 		}
 		return false
 	}
+	regroupStartedAt := time.Now()
 	switch masterRecoveryType {
 	case MasterRecoveryGTID:
 		{
 			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: regrouping replicas via GTID"))
 			lostReplicas, _, cannotReplicateReplicas, promotedReplica, err = inst.RegroupReplicasGTID(failedInstanceKey, true, nil, &topologyRecovery.PostponedFunctionsContainer, promotedReplicaIsIdeal)
+			if err == nil && promotedReplica != nil && analysisEntry.GTIDWithBinlogServersImmediateTopology {
+				AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: re-chaining binlog servers below %+v, moving their %d replicas via GTID", promotedReplica.Key, analysisEntry.CountBinlogServerSubReplicas))
+				rechainedBinlogServers, _, unmovedReplicas, rechainErr := inst.RechainBinlogServersViaGTID(failedInstanceKey, promotedReplica, &topologyRecovery.PostponedFunctionsContainer)
+				if rechainErr != nil {
+					AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: error re-chaining binlog servers: %+v", rechainErr))
+				}
+				// Binlog servers cannot be regrouped via GTID, and are reported as lost by the regroup unless re-chained
+				for _, binlogServer := range rechainedBinlogServers {
+					lostReplicas = inst.RemoveInstance(lostReplicas, &binlogServer.Key)
+					cannotReplicateReplicas = inst.RemoveInstance(cannotReplicateReplicas, &binlogServer.Key)
+				}
+				lostReplicas = append(lostReplicas, unmovedReplicas...)
+			}
 		}
 	case MasterRecoveryPseudoGTID:
 		{
@@ -536,6 +675,11 @@ func recoverDeadMaster(topologyRecovery *TopologyRecovery, candidateInstanceKey
 			promotedReplica, err = recoverDeadMasterInBinlogServerTopology(topologyRecovery)
 		}
 	}
+	if promotedReplica != nil {
+		AuditTopologyRecoveryPhase(topologyRecovery, "regroup-replicas", &promotedReplica.Key, regroupStartedAt, err)
+	} else {
+		AuditTopologyRecoveryPhase(topologyRecovery, "regroup-replicas", failedInstanceKey, regroupStartedAt, err)
+	}
 	topologyRecovery.AddError(err)
 	lostReplicas = append(lostReplicas, cannotReplicateReplicas...)
 	for _, replica := range lostReplicas {
@@ -567,10 +711,41 @@ func recoverDeadMaster(topologyRecovery *TopologyRecovery, candidateInstanceKey
 	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: %d postponed functions", topologyRecovery.PostponedFunctionsContainer.Len()))
 
 	if promotedReplica != nil && !postponedAll {
+		candidateSelectionStartedAt := time.Now()
 		promotedReplica, err = replacePromotedReplicaWithCandidate(topologyRecovery, &analysisEntry.AnalyzedInstanceKey, promotedReplica, candidateInstanceKey)
+		if promotedReplica != nil {
+			AuditTopologyRecoveryPhase(topologyRecovery, "candidate-selection", &promotedReplica.Key, candidateSelectionStartedAt, err)
+		} else {
+			AuditTopologyRecoveryPhase(topologyRecovery, "candidate-selection", nil, candidateSelectionStartedAt, err)
+		}
 		topologyRecovery.AddError(err)
 	}
 
+	if promotedReplica != nil {
+		// An explicitly requested candidate is not replaced
+		var fallbackLostReplicas [](*inst.Instance)
+		promotedReplica, fallbackLostReplicas, err = verifyOrFallBackPromotedReplica(topologyRecovery, masterRecoveryType, promotedReplica, candidateInstanceKey == nil)
+		topologyRecovery.AddError(err)
+		for _, replica := range fallbackLostReplicas {
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: - lost replica: %+v", replica.Key))
+			inst.BeginDowntime(inst.NewDowntime(&replica.Key, inst.GetMaintenanceOwner(), inst.DowntimeLostInRecoveryMessage, time.Duration(config.LostInRecoveryDowntimeSeconds)*time.Second))
+		}
+		lostReplicas = append(lostReplicas, fallbackLostReplicas...)
+	}
+
+	if promotedReplica != nil && promotedReplica.SQLDelay > 0 && config.Config.ResetMasterDelayOnPromotion {
+		// A delayed replica is only ever chosen when there is no other candidate
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: promoted replica %+v is delayed by %d seconds; resetting its delay per ResetMasterDelayOnPromotion", promotedReplica.Key, promotedReplica.SQLDelay))
+		resetDelayStartedAt := time.Now()
+		replica, err := inst.ResetReplicationDelay(&promotedReplica.Key, time.Duration(config.Config.DelayMasterPromotionTimeoutSeconds)*time.Second)
+		AuditTopologyRecoveryPhase(topologyRecovery, "reset-master-delay", &promotedReplica.Key, resetDelayStartedAt, err)
+		if err != nil {
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: cannot reset delay of %+v: %+v", promotedReplica.Key, err))
+		} else if replica != nil {
+			promotedReplica = replica
+		}
+	}
+
 	if promotedReplica == nil {
 		message := "Failure: no replica promoted."
 		AuditTopologyRecovery(topologyRecovery, message)
@@ -583,6 +758,149 @@ func recoverDeadMaster(topologyRecovery *TopologyRecovery, candidateInstanceKey
 	return promotedReplica, lostReplicas, err
 }
 
+// promotionCandidateDisqualification returns the reason for which given freshly read replica may not be
+// promoted, or empty string
+func promotionCandidateDisqualification(replica *inst.Instance) string {
+	if replica == nil {
+		return "not found"
+	}
+	if !replica.IsLastCheckValid {
+		return "last check is invalid"
+	}
+	if replica.LastSQLError != "" {
+		return fmt.Sprintf("replication sql thread error: %s", replica.LastSQLError)
+	}
+	return ""
+}
+
+// verifyPromotionCandidate reads given replica directly, returning its fresh state along with the reason for
+// which it may not be promoted, or empty string. Backend data may present as healthy a replica which has since
+// failed, e.g. along with its master in a rack failure.
+func verifyPromotionCandidate(replicaKey *inst.InstanceKey) (replica *inst.Instance, disqualification string) {
+	replica, err := inst.ReadTopologyInstance(replicaKey)
+	if err != nil {
+		return replica, fmt.Sprintf("unreachable: %+v", err)
+	}
+	return replica, promotionCandidateDisqualification(replica)
+}
+
+// regroupReplicasOfFailedCandidate regroups the replicas of a promotion candidate which failed re-verification
+// below the next-ranked of them
+func regroupReplicasOfFailedCandidate(topologyRecovery *TopologyRecovery, masterRecoveryType MasterRecoveryType, failedCandidateKey *inst.InstanceKey) (lostReplicas [](*inst.Instance), promotedReplica *inst.Instance, err error) {
+	var cannotReplicateReplicas [](*inst.Instance)
+	switch masterRecoveryType {
+	case MasterRecoveryGTID:
+		lostReplicas, _, cannotReplicateReplicas, promotedReplica, err = inst.RegroupReplicasGTID(failedCandidateKey, true, nil, &topologyRecovery.PostponedFunctionsContainer, nil)
+	case MasterRecoveryPseudoGTID:
+		lostReplicas, _, _, cannotReplicateReplicas, promotedReplica, err = inst.RegroupReplicasPseudoGTIDIncludingSubReplicasOfBinlogServers(failedCandidateKey, true, nil, &topologyRecovery.PostponedFunctionsContainer, nil)
+	default:
+		err = fmt.Errorf("no fallback on %+v recovery", masterRecoveryType)
+	}
+	return append(lostReplicas, cannotReplicateReplicas...), promotedReplica, err
+}
+
+// candidateFallbackAllowed tells whether a promotion candidate which failed verification on given attempt (1-based)
+// may be replaced by the next-ranked replica
+func candidateFallbackAllowed(allowFallback bool, attempt uint) bool {
+	return allowFallback && attempt <= config.Config.MasterFailoverCandidateFallbackAttempts
+}
+
+// verifyOrFallBackPromotedReplica re-verifies the replica chosen for promotion right before it is promoted. If it
+// is found unreachable or with broken replication, and fallback is allowed, the replicas which were relocated
+// below it are regrouped below the next-ranked of them, which is verified in turn, up to
+// MasterFailoverCandidateFallbackAttempts times. Returns the replica to promote, if any, and the replicas lost
+// on the way, including failed candidates.
+func verifyOrFallBackPromotedReplica(topologyRecovery *TopologyRecovery, masterRecoveryType MasterRecoveryType, promotedReplica *inst.Instance, allowFallback bool) (verifiedReplica *inst.Instance, lostReplicas [](*inst.Instance), err error) {
+	for attempt := uint(1); ; attempt++ {
+		verificationStartedAt := time.Now()
+		replica, disqualification := verifyPromotionCandidate(&promotedReplica.Key)
+		if disqualification == "" {
+			AuditTopologyRecoveryPhase(topologyRecovery, "candidate-verification", &replica.Key, verificationStartedAt, nil)
+			return replica, lostReplicas, nil
+		}
+		err = fmt.Errorf("RecoverDeadMaster: chosen replica %+v failed verification before promotion: %s", promotedReplica.Key, disqualification)
+		AuditTopologyRecoveryPhase(topologyRecovery, "candidate-verification", &promotedReplica.Key, verificationStartedAt, err)
+		AuditTopologyRecovery(topologyRecovery, err.Error())
+		lostReplicas = append(lostReplicas, promotedReplica)
+		if !candidateFallbackAllowed(allowFallback, attempt) {
+			return nil, lostReplicas, err
+		}
+
+		fallbackStartedAt := time.Now()
+		fallbackLostReplicas, fallbackReplica, fallbackErr := regroupReplicasOfFailedCandidate(topologyRecovery, masterRecoveryType, &promotedReplica.Key)
+		lostReplicas = append(lostReplicas, fallbackLostReplicas...)
+		if fallbackReplica == nil {
+			if fallbackErr == nil {
+				fallbackErr = fmt.Errorf("no replica to fall back to")
+			}
+			AuditTopologyRecoveryPhase(topologyRecovery, "candidate-fallback", &promotedReplica.Key, fallbackStartedAt, fallbackErr)
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: cannot fall back from %+v: %+v", promotedReplica.Key, fallbackErr))
+			return nil, lostReplicas, err
+		}
+		AuditTopologyRecoveryPhase(topologyRecovery, "candidate-fallback", &fallbackReplica.Key, fallbackStartedAt, fallbackErr)
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: fell back from %+v to %+v (attempt %d of %d)", promotedReplica.Key, fallbackReplica.Key, attempt, config.Config.MasterFailoverCandidateFallbackAttempts))
+		promotedReplica = fallbackReplica
+	}
+}
+
+// auditPromotedReplicaEventScheduler notes a promoted replica's running event scheduler in the recovery audit.
+// The event scheduler state is deliberately left unchanged; however, events which were enabled on the replica
+// now write on the new master.
+func auditPromotedReplicaEventScheduler(topologyRecovery *TopologyRecovery, promotedReplica *inst.Instance, recoveryName string) {
+	if !promotedReplica.EventSchedulerEnabled {
+		return
+	}
+	message := fmt.Sprintf("%s: NOTE: promoted %+v has event_scheduler=ON with %d enabled events; event scheduler state left unchanged", recoveryName, promotedReplica.Key, promotedReplica.CountEnabledEvents)
+	AuditTopologyRecovery(topologyRecovery, message)
+	inst.AuditOperation("promoted-with-event-scheduler", &promotedReplica.Key, message)
+}
+
+// injectPseudoGTIDOnPromotedMaster takes over AutoPseudoGTID injection on a promoted master right away, rather
+// than on the next AutoPseudoGTIDIntervalSeconds. Nothing is injected while the promoted master is read-only.
+func injectPseudoGTIDOnPromotedMaster(topologyRecovery *TopologyRecovery, promotedMasterKey *inst.InstanceKey, recoveryName string) {
+	promotedMaster, err := inst.ReadTopologyInstance(promotedMasterKey)
+	if err != nil || promotedMaster.ReadOnly {
+		return
+	}
+	injected, err := injectPseudoGTIDOnWriter(promotedMaster)
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- %s: injecting Pseudo-GTID on promoted master: success=%t", recoveryName, injected && err == nil))
+}
+
+// masterFailoverGeographicConstraint lists the configured constraints on where a master may fail over to
+func masterFailoverGeographicConstraint() string {
+	constraints := []string{}
+	if config.Config.PreventCrossDataCenterMasterFailover {
+		constraints = append(constraints, "PreventCrossDataCenterMasterFailover")
+	} else if config.Config.PreferSameDataCenterMasterFailover {
+		constraints = append(constraints, "PreferSameDataCenterMasterFailover")
+	}
+	if config.Config.PreventCrossRegionMasterFailover {
+		constraints = append(constraints, "PreventCrossRegionMasterFailover")
+	}
+	return strings.Join(constraints, ",")
+}
+
+// masterFailoverGeographicFailureReason returns the failure reason of a recovery whose promoted server violates
+// PreventCrossDataCenterMasterFailover or PreventCrossRegionMasterFailover, or empty string
+func masterFailoverGeographicFailureReason(analysisEntry *inst.ReplicationAnalysis, promotedInstance *inst.Instance) string {
+	if config.Config.PreventCrossDataCenterMasterFailover && promotedInstance.DataCenter != analysisEntry.AnalyzedInstanceDataCenter {
+		return FailureReasonCrossDataCenterPromotion
+	}
+	if config.Config.PreventCrossRegionMasterFailover && promotedInstance.Region != analysisEntry.AnalyzedInstanceRegion {
+		return FailureReasonCrossRegionPromotion
+	}
+	return ""
+}
+
+// preferSameDataCenterReplacement returns true when, per PreferSameDataCenterMasterFailover, a server promoted
+// outside the failed master's data center should be replaced by one inside it
+func preferSameDataCenterReplacement(analysisEntry *inst.ReplicationAnalysis, promotedInstance *inst.Instance) bool {
+	if config.Config.PreventCrossDataCenterMasterFailover || !config.Config.PreferSameDataCenterMasterFailover {
+		return false
+	}
+	return promotedInstance.DataCenter != analysisEntry.AnalyzedInstanceDataCenter
+}
+
 func MasterFailoverGeographicConstraintSatisfied(analysisEntry *inst.ReplicationAnalysis, suggestedInstance *inst.Instance) (satisfied bool, dissatisfiedReason string) {
 	if config.Config.PreventCrossDataCenterMasterFailover {
 		if suggestedInstance.DataCenter != analysisEntry.AnalyzedInstanceDataCenter {
@@ -597,11 +915,69 @@ func MasterFailoverGeographicConstraintSatisfied(analysisEntry *inst.Replication
 	return true, ""
 }
 
+// isForcedPromotion returns true when the recovery is a forced master failover or takeover. These are disaster
+// scenario operations, and promote a replica regardless of how far its sql thread lags.
+func isForcedPromotion(topologyRecovery *TopologyRecovery) bool {
+	switch topologyRecovery.AnalysisEntry.CommandHint {
+	case inst.ForceMasterFailoverCommandHint, inst.ForceMasterTakeoverCommandHint:
+		return true
+	}
+	return false
+}
+
+// promotionMaxSQLThreadLagSeconds returns the sql thread lag beyond which candidates are deprioritized in given
+// recovery; zero for no limit
+func promotionMaxSQLThreadLagSeconds(topologyRecovery *TopologyRecovery) uint {
+	if isForcedPromotion(topologyRecovery) {
+		return 0
+	}
+	return config.Config.PromotionMaxSQLThreadLagSeconds
+}
+
+// regroupedBelow returns given instances, where those which regroup would move below given promoted replica
+// are copies replicating from it, as they would after regroup
+func regroupedBelow(instances [](*inst.Instance), promotedReplica *inst.Instance, regroupedKeys *inst.InstanceKeyMap) [](*inst.Instance) {
+	if regroupedKeys == nil {
+		return instances
+	}
+	result := [](*inst.Instance){}
+	for _, instance := range instances {
+		if regroupedKeys.HasKey(instance.Key) {
+			regrouped := *instance
+			regrouped.MasterKey = promotedReplica.Key
+			instance = &regrouped
+		}
+		result = append(result, instance)
+	}
+	return result
+}
+
 // SuggestReplacementForPromotedReplica returns a server to take over the already
 // promoted replica, if such server is found and makes an improvement over the promoted replica.
 func SuggestReplacementForPromotedReplica(topologyRecovery *TopologyRecovery, deadInstanceKey *inst.InstanceKey, promotedReplica *inst.Instance, candidateInstanceKey *inst.InstanceKey) (replacement *inst.Instance, actionRequired bool, err error) {
+	return suggestReplacementForPromotedReplica(topologyRecovery, deadInstanceKey, promotedReplica, candidateInstanceKey, nil)
+}
+
+// suggestReplacementForPromotedReplica is SuggestReplacementForPromotedReplica, where given regrouped replicas, if any,
+// are assessed as though regroup had already moved them below the promoted replica. This lets a recovery
+// simulation run the replacement phase before regroup, or without it.
+func suggestReplacementForPromotedReplica(topologyRecovery *TopologyRecovery, deadInstanceKey *inst.InstanceKey, promotedReplica *inst.Instance, candidateInstanceKey *inst.InstanceKey, regroupedKeys *inst.InstanceKeyMap) (replacement *inst.Instance, actionRequired bool, err error) {
 	candidateReplicas, _ := inst.ReadClusterCandidateInstances(promotedReplica.ClusterName)
 	candidateReplicas = inst.RemoveInstance(candidateReplicas, deadInstanceKey)
+	candidateReplicas = regroupedBelow(candidateReplicas, promotedReplica, regroupedKeys)
+	if notRecentlyRestarted := inst.RemoveRecentlyRestartedInstances(candidateReplicas); len(notRecentlyRestarted) > 0 {
+		// Recently restarted candidates are only considered when there is no other candidate
+		candidateReplicas = notRecentlyRestarted
+	}
+	maxLagSeconds := promotionMaxSQLThreadLagSeconds(topologyRecovery)
+	if maxLagSeconds > 0 {
+		inst.PopulateSQLThreadLagEstimates(candidateReplicas)
+		inst.PopulateSQLThreadLagEstimates([](*inst.Instance){promotedReplica})
+	}
+	if notLagging := inst.RemoveSQLThreadLaggingInstances(candidateReplicas, maxLagSeconds); len(notLagging) > 0 {
+		// Likewise candidates lagging beyond PromotionMaxSQLThreadLagSeconds
+		candidateReplicas = notLagging
+	}
 	deadInstance, _, err := inst.ReadInstance(deadInstanceKey)
 	if err != nil {
 		deadInstance = nil
@@ -644,6 +1020,23 @@ func SuggestReplacementForPromotedReplica(topologyRecovery *TopologyRecovery, de
 			}
 		}
 	}
+	if candidateInstanceKey == nil && preferSameDataCenterReplacement(&topologyRecovery.AnalysisEntry, promotedReplica) {
+		// Try any server in same DC as the dead instance, regardless of env and promotion rule
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("+ searching for a server in same DC as dead master, per PreferSameDataCenterMasterFailover"))
+		neutralReplicas, _ := inst.ReadClusterNeutralPromotionRuleInstances(promotedReplica.ClusterName)
+		neutralReplicas = regroupedBelow(neutralReplicas, promotedReplica, regroupedKeys)
+		if notLagging := inst.RemoveSQLThreadLaggingInstances(neutralReplicas, maxLagSeconds); len(notLagging) > 0 {
+			neutralReplicas = notLagging
+		}
+		for _, replica := range append(append([](*inst.Instance){}, candidateReplicas...), neutralReplicas...) {
+			if canTakeOverPromotedServerAsMaster(replica, promotedReplica) &&
+				replica.DataCenter == topologyRecovery.AnalysisEntry.AnalyzedInstanceDataCenter {
+				candidateInstanceKey = &replica.Key
+				AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("no candidate was offered for %+v but orchestrator picks %+v as candidate replacement, based on being in same DC as failed instance", *deadInstanceKey, replica.Key))
+				break
+			}
+		}
+	}
 	if candidateInstanceKey == nil {
 		// We cannot find a candidate in same DC and ENV as dead master
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("+ checking if promoted replica is an OK candidate"))
@@ -696,10 +1089,30 @@ func SuggestReplacementForPromotedReplica(topologyRecovery *TopologyRecovery, de
 		keepSearchingHint = fmt.Sprintf("Will keep searching; %s", reason)
 	} else if promotedReplica.PromotionRule == inst.PreferNotPromoteRule {
 		keepSearchingHint = fmt.Sprintf("Will keep searching because we have promoted a server with prefer_not rule: %+v", promotedReplica.Key)
+	} else if promotedReplica.RecentlyRestarted {
+		keepSearchingHint = fmt.Sprintf("Will keep searching because we have promoted a recently restarted server: %+v, uptime: %d seconds", promotedReplica.Key, promotedReplica.Uptime)
+	} else if lagSeconds, _ := promotedReplica.SQLThreadLagSeconds(); promotedReplica.IsSQLThreadLaggingBeyond(maxLagSeconds) {
+		keepSearchingHint = fmt.Sprintf("Will keep searching because we have promoted a server whose sql thread lags by %d seconds, beyond PromotionMaxSQLThreadLagSeconds: %+v", lagSeconds, promotedReplica.Key)
 	}
 	if keepSearchingHint != "" {
 		AuditTopologyRecovery(topologyRecovery, keepSearchingHint)
 		neutralReplicas, _ := inst.ReadClusterNeutralPromotionRuleInstances(promotedReplica.ClusterName)
+		neutralReplicas = regroupedBelow(neutralReplicas, promotedReplica, regroupedKeys)
+		if promotedReplica.RecentlyRestarted {
+			// Replacing with another recently restarted server makes no improvement
+			neutralReplicas = inst.RemoveRecentlyRestartedInstances(neutralReplicas)
+		} else if notRecentlyRestarted := inst.RemoveRecentlyRestartedInstances(neutralReplicas); len(notRecentlyRestarted) > 0 {
+			neutralReplicas = notRecentlyRestarted
+		}
+		if maxLagSeconds > 0 {
+			inst.PopulateSQLThreadLagEstimates(neutralReplicas)
+		}
+		if promotedReplica.IsSQLThreadLaggingBeyond(maxLagSeconds) {
+			// Replacing with another lagging server makes no improvement
+			neutralReplicas = inst.RemoveSQLThreadLaggingInstances(neutralReplicas, maxLagSeconds)
+		} else if notLagging := inst.RemoveSQLThreadLaggingInstances(neutralReplicas, maxLagSeconds); len(notLagging) > 0 {
+			neutralReplicas = notLagging
+		}
 
 		if candidateInstanceKey == nil {
 			// Still nothing? Then we didn't find a replica marked as "candidate". OK, further down the stream we have:
@@ -777,7 +1190,7 @@ func replacePromotedReplicaWithCandidate(topologyRecovery *TopologyRecovery, dea
 
 	if candidateInstance.MasterKey.Equals(&promotedReplica.Key) {
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("replace-promoted-replica-with-candidate: suggested candidate %+v is replica of promoted instance %+v. Will try and take its master", candidateInstance.Key, promotedReplica.Key))
-		candidateInstance, err = inst.TakeMaster(&candidateInstance.Key, topologyRecovery.Type == CoMasterRecovery)
+		candidateInstance, err = inst.TakeMaster(&candidateInstance.Key, topologyRecovery.Type == CoMasterRecovery, false, false)
 		if err != nil {
 			return promotedReplica, log.Errore(err)
 		}
@@ -787,7 +1200,7 @@ func replacePromotedReplicaWithCandidate(topologyRecovery *TopologyRecovery, dea
 		relocateReplicasFunc := func() error {
 			log.Debugf("replace-promoted-replica-with-candidate: relocating replicas of %+v below %+v", promotedReplica.Key, candidateInstance.Key)
 
-			relocatedReplicas, _, err, _ := inst.RelocateReplicas(&promotedReplica.Key, &candidateInstance.Key, "")
+			relocatedReplicas, _, err, _ := inst.RelocateReplicas(&promotedReplica.Key, &candidateInstance.Key, "", true)
 			log.Debugf("replace-promoted-replica-with-candidate: + relocated %+v replicas of %+v below %+v", len(relocatedReplicas), promotedReplica.Key, candidateInstance.Key)
 			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("relocated %+v replicas of %+v below %+v", len(relocatedReplicas), promotedReplica.Key, candidateInstance.Key))
 			return log.Errore(err)
@@ -806,6 +1219,41 @@ func replacePromotedReplicaWithCandidate(topologyRecovery *TopologyRecovery, dea
 	return promotedReplica, nil
 }
 
+// writeMasterKVPairs writes the KV pairs of a newly promoted master to kv stores, retrying each pair up to
+// kvWriteAttempts times. A failure is audited and counted (recover.kv_write.fail), but does not fail the recovery:
+// pairs which could not be written are resubmitted by the periodic SubmitMastersToKvStores.
+func writeMasterKVPairs(topologyRecovery *TopologyRecovery, kvPairs [](*kv.KVPair)) (err error) {
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Writing KV %+v", kvPairs))
+	for _, kvPair := range kvPairs {
+		var putErr error
+		for attempt := 1; attempt <= kvWriteAttempts; attempt++ {
+			if putErr = putKVPair(kvPair); putErr == nil {
+				break
+			}
+			if attempt < kvWriteAttempts {
+				time.Sleep(kvWriteRetryInterval)
+			}
+		}
+		if putErr != nil {
+			recoverKVWriteFailureCounter.Inc(1)
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- Writing KV: failed writing %s after %d attempts; will be resubmitted periodically: %+v", kvPair.Key, kvWriteAttempts, putErr))
+			err = log.Errore(putErr)
+		}
+	}
+	if orcraft.IsRaftEnabled() {
+		// since we'll be affecting 3rd party tools here, we _prefer_ to mitigate re-applying
+		// of the put-key-value event upon startup. We _recommend_ a snapshot in the near future.
+		go orcraft.PublishCommand("async-snapshot", "")
+	}
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Distributing KV %+v", kvPairs))
+	if distributeErr := kv.DistributePairs(kvPairs); distributeErr != nil {
+		recoverKVWriteFailureCounter.Inc(1)
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- Distributing KV: failed: %+v", distributeErr))
+		err = log.Errore(distributeErr)
+	}
+	return err
+}
+
 // checkAndRecoverDeadMaster checks a given analysis, decides whether to take action, and possibly takes action
 // Returns true when action was taken.
 func checkAndRecoverDeadMaster(analysisEntry inst.ReplicationAnalysis, candidateInstanceKey *inst.InstanceKey, forceInstanceRecovery bool, skipProcesses bool) (bool, *TopologyRecovery, error) {
@@ -817,6 +1265,13 @@ func checkAndRecoverDeadMaster(analysisEntry inst.ReplicationAnalysis, candidate
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("found an active or recent recovery on %+v. Will not issue another RecoverDeadMaster.", analysisEntry.AnalyzedInstanceKey))
 		return false, nil, err
 	}
+	auditFailoverPreconditions(topologyRecovery)
+
+	if config.Config.RecoverySimulationMode && !forceInstanceRecovery {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverySimulationMode: will simulate DeadMaster recovery on %+v", analysisEntry.ClusterDetails.ClusterName))
+		err := simulateDeadMasterRecovery(topologyRecovery, skipProcesses)
+		return true, topologyRecovery, err
+	}
 
 	// That's it! We must do recovery!
 	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("will handle DeadMaster event on %+v", analysisEntry.ClusterDetails.ClusterName))
@@ -831,14 +1286,22 @@ func checkAndRecoverDeadMaster(analysisEntry inst.ReplicationAnalysis, candidate
 		}
 		// Scenarios where we might cancel the promotion.
 		if satisfied, reason := MasterFailoverGeographicConstraintSatisfied(&analysisEntry, promotedReplica); !satisfied {
+			topologyRecovery.FailureReason = masterFailoverGeographicFailureReason(&analysisEntry, promotedReplica)
 			return nil, fmt.Errorf("RecoverDeadMaster: failed %+v promotion; %s", promotedReplica.Key, reason)
 		}
+		if isForcedPromotion(topologyRecovery) && !promotedReplica.SQLThreadUpToDate() {
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: %s; promoting %+v even though its sql thread is not up to date", topologyRecovery.AnalysisEntry.CommandHint, promotedReplica.Key))
+			return promotedReplica, err
+		}
 		if config.Config.FailMasterPromotionIfSQLThreadNotUpToDate && !promotedReplica.SQLThreadUpToDate() {
 			return nil, fmt.Errorf("RecoverDeadMaster: failed promotion. FailMasterPromotionIfSQLThreadNotUpToDate is set and promoted replica %+v 's sql thread is not up to date (relay logs still unapplied). Aborting promotion", promotedReplica.Key)
 		}
 		if config.Config.DelayMasterPromotionIfSQLThreadNotUpToDate && !promotedReplica.SQLThreadUpToDate() {
 			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("DelayMasterPromotionIfSQLThreadNotUpToDate: waiting for SQL thread on %+v", promotedReplica.Key))
-			if _, err := inst.WaitForSQLThreadUpToDate(&promotedReplica.Key, 0, 0); err != nil {
+			catchUpStartedAt := time.Now()
+			_, err := inst.WaitForSQLThreadUpToDate(&promotedReplica.Key, time.Duration(config.Config.DelayMasterPromotionTimeoutSeconds)*time.Second, 0)
+			AuditTopologyRecoveryPhase(topologyRecovery, "catch-up-wait", &promotedReplica.Key, catchUpStartedAt, err)
+			if err != nil {
 				return nil, fmt.Errorf("DelayMasterPromotionIfSQLThreadNotUpToDate error: %+v", err)
 			}
 			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("DelayMasterPromotionIfSQLThreadNotUpToDate: SQL thread caught up on %+v", promotedReplica.Key))
@@ -858,10 +1321,13 @@ func checkAndRecoverDeadMaster(analysisEntry inst.ReplicationAnalysis, candidate
 		recoverDeadMasterSuccessCounter.Inc(1)
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: successfully promoted %+v", promotedReplica.Key))
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: promoted server coordinates: %+v", promotedReplica.SelfBinlogCoordinates))
+		auditPromotedReplicaEventScheduler(topologyRecovery, promotedReplica, "RecoverDeadMaster")
 
 		if config.Config.ApplyMySQLPromotionAfterMasterFailover || analysisEntry.CommandHint == inst.GracefulMasterTakeoverCommandHint {
 			// on GracefulMasterTakeoverCommandHint it makes utter sense to RESET SLAVE ALL and read_only=0, and there is no sense in not doing so.
 			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: will apply MySQL changes to promoted master"))
+			promotionStartedAt := time.Now()
+			var promotionErr error
 			{
 				_, err := inst.ResetSlaveOperation(&promotedReplica.Key)
 				if err != nil {
@@ -871,40 +1337,31 @@ func checkAndRecoverDeadMaster(analysisEntry inst.ReplicationAnalysis, candidate
 				AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: applying RESET SLAVE ALL on promoted master: success=%t", (err == nil)))
 				if err != nil {
 					AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: NOTE that %+v is promoted even though SHOW SLAVE STATUS may still show it has a master", promotedReplica.Key))
+					promotionErr = err
 				}
 			}
 			{
 				_, err := inst.SetReadOnly(&promotedReplica.Key, false)
 				AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: applying read-only=0 on promoted master: success=%t", (err == nil)))
+				if err != nil {
+					promotionErr = err
+				}
 			}
+			AuditTopologyRecoveryPhase(topologyRecovery, "apply-promotion", &promotedReplica.Key, promotionStartedAt, promotionErr)
 			// Let's attempt, though we won't necessarily succeed, to set old master as read-only
 			go func() {
 				_, err := inst.SetReadOnly(&analysisEntry.AnalyzedInstanceKey, true)
 				AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: applying read-only=1 on demoted master: success=%t", (err == nil)))
 			}()
 		}
+		if config.Config.AutoPseudoGTID {
+			go injectPseudoGTIDOnPromotedMaster(topologyRecovery, &promotedReplica.Key, "RecoverDeadMaster")
+		}
 
 		kvPairs := inst.GetClusterMasterKVPairs(analysisEntry.ClusterDetails.ClusterAlias, &promotedReplica.Key)
-		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Writing KV %+v", kvPairs))
-		if orcraft.IsRaftEnabled() {
-			for _, kvPair := range kvPairs {
-				_, err := orcraft.PublishCommand("put-key-value", kvPair)
-				log.Errore(err)
-			}
-			// since we'll be affecting 3rd party tools here, we _prefer_ to mitigate re-applying
-			// of the put-key-value event upon startup. We _recommend_ a snapshot in the near future.
-			go orcraft.PublishCommand("async-snapshot", "")
-		} else {
-			for _, kvPair := range kvPairs {
-				err := kv.PutKVPair(kvPair)
-				log.Errore(err)
-			}
-		}
-		{
-			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Distributing KV %+v", kvPairs))
-			err := kv.DistributePairs(kvPairs)
-			log.Errore(err)
-		}
+		kvWriteStartedAt := time.Now()
+		kvWriteErr := writeMasterKVPairs(topologyRecovery, kvPairs)
+		AuditTopologyRecoveryPhase(topologyRecovery, "kv-write", &promotedReplica.Key, kvWriteStartedAt, kvWriteErr)
 		if config.Config.MasterFailoverDetachReplicaMasterHost {
 			postponedFunction := func() error {
 				AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: detaching master host on promoted master"))
@@ -923,6 +1380,9 @@ func checkAndRecoverDeadMaster(analysisEntry inst.ReplicationAnalysis, candidate
 			} else {
 				inst.ReplaceAliasClusterName(before, after)
 			}
+			if err := carryOverClusterRecoveryDisabled(analysisEntry.ClusterDetails.ClusterName, after); err != nil {
+				AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: error keeping recoveries disabled on %v: %+v", after, err))
+			}
 			return nil
 		}()
 
@@ -932,6 +1392,9 @@ func checkAndRecoverDeadMaster(analysisEntry inst.ReplicationAnalysis, candidate
 			// Execute post master-failover processes
 			executeProcesses(config.Config.PostMasterFailoverProcesses, "PostMasterFailoverProcesses", topologyRecovery, false)
 		}
+		if config.Config.StrandedReplicasRematchSeconds > 0 {
+			go rematchStrandedReplicasPeriodically(topologyRecovery.UID)
+		}
 	} else {
 		recoverDeadMasterFailureCounter.Inc(1)
 	}
@@ -939,6 +1402,129 @@ func checkAndRecoverDeadMaster(analysisEntry inst.ReplicationAnalysis, candidate
 	return true, topologyRecovery, err
 }
 
+// strandedReplicaRematchBlocker returns the reason for which given replica, still replicating from a failed
+// master, should not be relocated below the promoted master, or empty string if it can be
+func strandedReplicaRematchBlocker(replica *inst.Instance, promotedMaster *inst.Instance, inMaintenance bool) string {
+	if replica.Key.Equals(&promotedMaster.Key) {
+		return "is the promoted master"
+	}
+	if !replica.IsLastCheckValid {
+		return "last check is invalid"
+	}
+	if inMaintenance {
+		return "is under maintenance"
+	}
+	if replica.IsDowntimed && replica.DowntimeReason != inst.DowntimeLostInRecoveryMessage {
+		// Downtime set by the recovery itself on lost replicas does not count: those are the replicas to salvage
+		return "is downtimed"
+	}
+	if replica.UsingOracleGTID {
+		if !promotedMaster.SupportsOracleGTID {
+			return "promoted master does not support GTID"
+		}
+		// A replica which executed transactions the promoted master does not have must not be repointed: it would
+		// carry errant transactions
+		missing, err := inst.GTIDSubtract(&promotedMaster.Key, replica.ExecutedGtidSet, promotedMaster.ExecutedGtidSet)
+		if err != nil {
+			return fmt.Sprintf("cannot compare GTID sets: %+v", err)
+		}
+		if missing != "" {
+			return fmt.Sprintf("has transactions missing on promoted master: %s", missing)
+		}
+	}
+	return ""
+}
+
+// RematchStrandedReplicas relocates replicas which still replicate from the failed master of given successful
+// master recovery below the promoted master, using GTID or Pseudo-GTID as available. Salvaged replicas are
+// removed from the recovery's lost replicas.
+func RematchStrandedReplicas(topologyRecovery *TopologyRecovery) (salvagedReplicas [](*inst.Instance), err error) {
+	salvagedReplicas = [](*inst.Instance){}
+	if !topologyRecovery.IsSuccessful || topologyRecovery.SuccessorKey == nil {
+		return salvagedReplicas, fmt.Errorf("RematchStrandedReplicas: recovery %s did not promote a master", topologyRecovery.UID)
+	}
+	failedMasterKey := &topologyRecovery.AnalysisEntry.AnalyzedInstanceKey
+	promotedMaster, found, err := inst.ReadInstance(topologyRecovery.SuccessorKey)
+	if err != nil {
+		return salvagedReplicas, err
+	}
+	if !found {
+		return salvagedReplicas, fmt.Errorf("RematchStrandedReplicas: cannot find promoted master %+v", *topologyRecovery.SuccessorKey)
+	}
+	if recoveryDisabledReason, err := RecoveryDisabledReason(promotedMaster.ClusterName); err != nil {
+		return salvagedReplicas, fmt.Errorf("RematchStrandedReplicas: unable to determine if recovery is disabled: %v", err)
+	} else if recoveryDisabledReason != "" {
+		return salvagedReplicas, fmt.Errorf("RematchStrandedReplicas: not rematching replicas of recovery %s: recoveries %s", topologyRecovery.UID, recoveryDisabledReason)
+	}
+	replicas, err := inst.ReadReplicaInstances(failedMasterKey)
+	if err != nil {
+		return salvagedReplicas, err
+	}
+	for _, replica := range replicas {
+		inMaintenance, err := inst.InMaintenance(&replica.Key)
+		if err != nil {
+			continue
+		}
+		if reason := strandedReplicaRematchBlocker(replica, promotedMaster, inMaintenance); reason != "" {
+			log.Debugf("RematchStrandedReplicas: skipping %+v: %s", replica.Key, reason)
+			continue
+		}
+		relocatedReplica, err := inst.RelocateBelow(&replica.Key, &promotedMaster.Key, true)
+		if err != nil {
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RematchStrandedReplicas: failed relocating %+v below %+v: %+v", replica.Key, promotedMaster.Key, err))
+			continue
+		}
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RematchStrandedReplicas: relocated %+v below %+v", replica.Key, promotedMaster.Key))
+		if replica.IsDowntimed && replica.DowntimeReason == inst.DowntimeLostInRecoveryMessage {
+			inst.EndDowntime(&replica.Key)
+		}
+		delete(topologyRecovery.LostReplicas, replica.Key)
+		salvagedReplicas = append(salvagedReplicas, relocatedReplica)
+	}
+	if len(salvagedReplicas) > 0 {
+		inst.AuditOperation("rematch-stranded-replicas", topologyRecovery.SuccessorKey, fmt.Sprintf("recovery %s: salvaged %d replicas of %+v", topologyRecovery.UID, len(salvagedReplicas), *failedMasterKey))
+		if err := updateTopologyRecoveryLostReplicas(topologyRecovery); err != nil {
+			return salvagedReplicas, err
+		}
+	}
+	return salvagedReplicas, nil
+}
+
+// rematchStrandedReplicasPeriodically runs RematchStrandedReplicas on given recovery every InstancePollSeconds,
+// for StrandedReplicasRematchSeconds following the recovery. The recovery is re-read on each pass, such that
+// this does not share state with the (possibly still running) recovery flow.
+func rematchStrandedReplicasPeriodically(recoveryUID string) {
+	deadline := time.Now().Add(time.Duration(config.Config.StrandedReplicasRematchSeconds) * time.Second)
+	ticker := time.NewTicker(time.Duration(config.Config.InstancePollSeconds) * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		if time.Now().After(deadline) {
+			return
+		}
+		if !IsLeaderOrActive() {
+			continue
+		}
+		recoveries, err := ReadRecoveryByUID(recoveryUID)
+		if err != nil || len(recoveries) == 0 {
+			continue
+		}
+		RematchStrandedReplicas(&recoveries[0])
+	}
+}
+
+// RematchClusterStrandedReplicas runs RematchStrandedReplicas on the latest successful master recovery of
+// given cluster
+func RematchClusterStrandedReplicas(clusterName string) (salvagedReplicas [](*inst.Instance), err error) {
+	recoveries, err := ReadLatestSuccessfulMasterRecovery(clusterName)
+	if err != nil {
+		return salvagedReplicas, err
+	}
+	if len(recoveries) == 0 {
+		return salvagedReplicas, fmt.Errorf("RematchClusterStrandedReplicas: no successful master recovery found for cluster %+v", clusterName)
+	}
+	return RematchStrandedReplicas(&recoveries[0])
+}
+
 // isGeneralyValidAsCandidateSiblingOfIntermediateMaster sees that basic server configuration and state are valid
 func isGeneralyValidAsCandidateSiblingOfIntermediateMaster(sibling *inst.Instance) bool {
 	if !sibling.LogBinEnabled {
@@ -1093,6 +1679,7 @@ func RecoverDeadIntermediateMaster(topologyRecovery *TopologyRecovery, skipProce
 	if err != nil {
 		return nil, topologyRecovery.AddError(err)
 	}
+	orphanedReplicas, _ := inst.ReadReplicaInstances(failedInstanceKey)
 	// Find possible candidate
 	candidateSiblingOfIntermediateMaster, _ := GetCandidateSiblingOfIntermediateMaster(topologyRecovery, intermediateMasterInstance)
 	relocateReplicasToCandidateSibling := func() {
@@ -1101,7 +1688,7 @@ func RecoverDeadIntermediateMaster(topologyRecovery *TopologyRecovery, skipProce
 		}
 		// We have a candidate
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadIntermediateMaster: will attempt a candidate intermediate master: %+v", candidateSiblingOfIntermediateMaster.Key))
-		relocatedReplicas, candidateSibling, err, errs := inst.RelocateReplicas(failedInstanceKey, &candidateSiblingOfIntermediateMaster.Key, "")
+		relocatedReplicas, candidateSibling, err, errs := inst.RelocateReplicas(failedInstanceKey, &candidateSiblingOfIntermediateMaster.Key, "", true)
 		topologyRecovery.AddErrors(errs)
 		topologyRecovery.ParticipatingInstanceKeys.AddKey(candidateSiblingOfIntermediateMaster.Key)
 
@@ -1156,7 +1743,7 @@ func RecoverDeadIntermediateMaster(topologyRecovery *TopologyRecovery, skipProce
 		// So, match up all that's left, plan D
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadIntermediateMaster: will next attempt to relocate up from %+v", *failedInstanceKey))
 
-		relocatedReplicas, masterInstance, err, errs := inst.RelocateReplicas(failedInstanceKey, &analysisEntry.AnalyzedInstanceMasterKey, "")
+		relocatedReplicas, masterInstance, err, errs := inst.RelocateReplicas(failedInstanceKey, &analysisEntry.AnalyzedInstanceMasterKey, "", true)
 		topologyRecovery.AddErrors(errs)
 		topologyRecovery.ParticipatingInstanceKeys.AddKey(analysisEntry.AnalyzedInstanceMasterKey)
 
@@ -1175,16 +1762,51 @@ func RecoverDeadIntermediateMaster(topologyRecovery *TopologyRecovery, skipProce
 	if !recoveryResolved {
 		successorInstance = nil
 	}
+	// Record the outcome per orphaned replica; those still replicating from the dead intermediate master are lost
+	relocatedOrphanedReplicas := [](*inst.Instance){}
+	for _, replica := range orphanedReplicas {
+		if relocatedReplica, _, err := inst.ReadInstance(&replica.Key); err == nil && relocatedReplica != nil {
+			relocatedOrphanedReplicas = append(relocatedOrphanedReplicas, relocatedReplica)
+		} else {
+			relocatedOrphanedReplicas = append(relocatedOrphanedReplicas, replica)
+		}
+	}
+	outcomes, lostReplicas := orphanedReplicasOutcome(failedInstanceKey, relocatedOrphanedReplicas)
+	for _, outcome := range outcomes {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadIntermediateMaster: %s", outcome))
+	}
+	topologyRecovery.LostReplicas.AddInstances(lostReplicas)
 	resolveRecovery(topologyRecovery, successorInstance)
 	return successorInstance, err
 }
 
+// orphanedReplicasOutcome describes, per replica of a dead intermediate master, where it was relocated to.
+// Replicas which still replicate from the dead intermediate master are returned as lost.
+func orphanedReplicasOutcome(failedInstanceKey *inst.InstanceKey, replicas [](*inst.Instance)) (outcomes []string, lostReplicas [](*inst.Instance)) {
+	for _, replica := range replicas {
+		if replica.MasterKey.Equals(failedInstanceKey) {
+			outcomes = append(outcomes, fmt.Sprintf("%+v: could not be relocated", replica.Key))
+			lostReplicas = append(lostReplicas, replica)
+		} else {
+			outcomes = append(outcomes, fmt.Sprintf("%+v: relocated below %+v", replica.Key, replica.MasterKey))
+		}
+	}
+	return outcomes, lostReplicas
+}
+
 // checkAndRecoverDeadIntermediateMaster checks a given analysis, decides whether to take action, and possibly takes action
 // Returns true when action was taken.
 func checkAndRecoverDeadIntermediateMaster(analysisEntry inst.ReplicationAnalysis, candidateInstanceKey *inst.InstanceKey, forceInstanceRecovery bool, skipProcesses bool) (bool, *TopologyRecovery, error) {
 	if !(forceInstanceRecovery || analysisEntry.ClusterDetails.HasAutomatedIntermediateMasterRecovery) {
 		return false, nil, nil
 	}
+	if config.Config.RecoverySimulationMode && !forceInstanceRecovery {
+		// Only master recoveries are simulated
+		if util.ClearToLog("RecoverDeadIntermediateMaster: simulation", analysisEntry.AnalyzedInstanceKey.StringCode()) {
+			log.Infof("RecoverySimulationMode: not running RecoverDeadIntermediateMaster on %+v", analysisEntry.AnalyzedInstanceKey)
+		}
+		return false, nil, nil
+	}
 	topologyRecovery, err := AttemptRecoveryRegistration(&analysisEntry, !forceInstanceRecovery, !forceInstanceRecovery)
 	if topologyRecovery == nil {
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadIntermediateMaster: found an active or recent recovery on %+v. Will not issue another RecoverDeadIntermediateMaster.", analysisEntry.AnalyzedInstanceKey))
@@ -1336,6 +1958,13 @@ func checkAndRecoverDeadCoMaster(analysisEntry inst.ReplicationAnalysis, candida
 	if !(forceInstanceRecovery || analysisEntry.ClusterDetails.HasAutomatedMasterRecovery) {
 		return false, nil, nil
 	}
+	if config.Config.RecoverySimulationMode && !forceInstanceRecovery {
+		// Only master recoveries are simulated
+		if util.ClearToLog("RecoverDeadCoMaster: simulation", analysisEntry.AnalyzedInstanceKey.StringCode()) {
+			log.Infof("RecoverySimulationMode: not running RecoverDeadCoMaster on %+v", analysisEntry.AnalyzedInstanceKey)
+		}
+		return false, nil, nil
+	}
 	topologyRecovery, err := AttemptRecoveryRegistration(&analysisEntry, !forceInstanceRecovery, !forceInstanceRecovery)
 	if topologyRecovery == nil {
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("found an active or recent recovery on %+v. Will not issue another RecoverDeadCoMaster.", analysisEntry.AnalyzedInstanceKey))
@@ -1358,11 +1987,15 @@ func checkAndRecoverDeadCoMaster(analysisEntry inst.ReplicationAnalysis, candida
 		}
 		// success
 		recoverDeadCoMasterSuccessCounter.Inc(1)
+		auditPromotedReplicaEventScheduler(topologyRecovery, promotedReplica, "RecoverDeadCoMaster")
 
 		if config.Config.ApplyMySQLPromotionAfterMasterFailover {
 			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: will apply MySQL changes to promoted master"))
 			inst.SetReadOnly(&promotedReplica.Key, false)
 		}
+		if config.Config.AutoPseudoGTID {
+			go injectPseudoGTIDOnPromotedMaster(topologyRecovery, &promotedReplica.Key, "RecoverDeadCoMaster")
+		}
 		if !skipProcesses {
 			// Execute post intermediate-master-failover processes
 			topologyRecovery.SuccessorKey = &promotedReplica.Key
@@ -1393,6 +2026,22 @@ func emergentlyReadTopologyInstance(instanceKey *inst.InstanceKey, analysisCode
 	})
 }
 
+// recheckStaleAnalysisInstances urgently queues discovery of the instances on whose stale data the given analysis is based
+func recheckStaleAnalysisInstances(analysisEntry *inst.ReplicationAnalysis) {
+	reason := fmt.Sprintf("stale data in %s analysis", analysisEntry.Analysis)
+	instanceKeys := []inst.InstanceKey{analysisEntry.AnalyzedInstanceKey}
+	if analysisEntry.CountStaleReplicas > 0 {
+		instanceKeys = append(instanceKeys, analysisEntry.SlaveHosts.GetInstanceKeys()...)
+	}
+	requests := []discovery.DiscoveryRequest{}
+	for _, instanceKey := range instanceKeys {
+		request := discovery.NewDiscoveryRequest(instanceKey, reason)
+		request.Forced = true
+		requests = append(requests, request)
+	}
+	getDiscoveryRouter().PushRequests(requests, discovery.HighPriority)
+}
+
 // Force reading of replicas of given instance. This is because we suspect the instance is dead, and want to speed up
 // detection of replication failure from its replicas.
 func emergentlyReadTopologyInstanceReplicas(instanceKey *inst.InstanceKey, analysisCode inst.AnalysisCode) {
@@ -1462,6 +2111,12 @@ func checkAndExecuteFailureDetectionProcesses(analysisEntry inst.ReplicationAnal
 	if skipProcesses {
 		return true, false, nil
 	}
+	// Acknowledging a recovery clears its failure detection. Should the failure persist, do not notify again on
+	// what a human has just reviewed.
+	if recoveries, err := ReadRecentlyAcknowledgedInstanceRecovery(&analysisEntry.AnalyzedInstanceKey, analysisEntry.Analysis); err == nil && len(recoveries) > 0 {
+		log.Infof("checkAndExecuteFailureDetectionProcesses: %+v on %+v was acknowledged by %s; skipping OnFailureDetectionProcesses", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey, recoveries[0].AcknowledgedBy)
+		return true, false, nil
+	}
 	err = executeProcesses(config.Config.OnFailureDetectionProcesses, "OnFailureDetectionProcesses", NewTopologyRecovery(analysisEntry), true)
 	return true, true, err
 }
@@ -1505,6 +2160,16 @@ func getCheckAndRecoverFunction(analysisCode inst.AnalysisCode, analyzedInstance
 		return checkAndRecoverGenericProblem, false
 	case inst.AllMasterSlavesNotReplicatingOrDead:
 		return checkAndRecoverGenericProblem, false
+	// master, non actionable; the master is alive, but detection hooks may page
+	case inst.LockedSemiSyncMaster:
+		return checkAndRecoverGenericProblem, false
+	case inst.BlockedMaster:
+		return checkAndRecoverGenericProblem, false
+	// replica, non actionable; detection hooks may take on remediation (e.g. re-clone)
+	case inst.ReplicaBinlogMissingOnMaster:
+		return checkAndRecoverGenericProblem, false
+	case inst.ReplicaHeartbeatStalled:
+		return checkAndRecoverGenericProblem, false
 	}
 	// Right now this is mostly causing noise with no clear action.
 	// Will revisit this in the future.
@@ -1558,6 +2223,17 @@ func executeCheckAndRecoverFunction(analysisEntry inst.ReplicationAnalysis, cand
 		log.Infof("executeCheckAndRecoverFunction: proceeding with %+v detection on %+v; isActionable?: %+v; skipProcesses: %+v", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey, isActionableRecovery, skipProcesses)
 	}
 
+	if isActionableRecovery && !forceInstanceRecovery && analysisEntry.IsBasedOnStaleData() {
+		// Stale data cannot tell a failed instance from one orchestrator has not been polling (e.g. because
+		// orchestrator itself was down). Have it re-checked; the next analysis will run on fresh data.
+		if util.ClearToLog("executeCheckAndRecoverFunction: stale", analysisEntry.AnalyzedInstanceKey.StringCode()) {
+			log.Warningf("executeCheckAndRecoverFunction: %+v analysis on %+v is based on stale data (instance stale: %+v, stale replicas: %+v); requesting a re-check rather than recovering",
+				analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey, analysisEntry.IsStale, analysisEntry.CountStaleReplicas)
+		}
+		recheckStaleAnalysisInstances(&analysisEntry)
+		return false, nil, nil
+	}
+
 	// At this point we have validated there's a failure scenario for which we have a recovery path.
 
 	if orcraft.IsRaftEnabled() {
@@ -1588,21 +2264,22 @@ func executeCheckAndRecoverFunction(analysisEntry inst.ReplicationAnalysis, cand
 
 	// We're about to embark on recovery shortly...
 
-	// Check for recovery being disabled globally
-	if recoveryDisabledGlobally, err := IsRecoveryDisabled(); err != nil {
+	// Check for recovery being disabled globally or for this cluster. This is the last check before acting,
+	// such that toggles set while detection processes ran are honored.
+	if recoveryDisabledReason, err := RecoveryDisabledReason(analysisEntry.ClusterDetails.ClusterName); err != nil {
 		// Unexpected. Shouldn't get this
-		log.Errorf("Unable to determine if recovery is disabled globally: %v", err)
-	} else if recoveryDisabledGlobally {
+		log.Errorf("Unable to determine if recovery is disabled: %v", err)
+	} else if recoveryDisabledReason != "" {
 		if !forceInstanceRecovery {
 			log.Infof("CheckAndRecover: Analysis: %+v, InstanceKey: %+v, candidateInstanceKey: %+v, "+
-				"skipProcesses: %v: NOT Recovering host (disabled globally)",
-				analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey, candidateInstanceKey, skipProcesses)
+				"skipProcesses: %v: NOT Recovering host (%s)",
+				analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey, candidateInstanceKey, skipProcesses, recoveryDisabledReason)
 
 			return false, nil, err
 		}
 		log.Infof("CheckAndRecover: Analysis: %+v, InstanceKey: %+v, candidateInstanceKey: %+v, "+
-			"skipProcesses: %v: recoveries disabled globally but forcing this recovery",
-			analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey, candidateInstanceKey, skipProcesses)
+			"skipProcesses: %v: recoveries %s but forcing this recovery",
+			analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey, candidateInstanceKey, skipProcesses, recoveryDisabledReason)
 	}
 
 	// Actually attempt recovery:
@@ -1621,6 +2298,10 @@ func executeCheckAndRecoverFunction(analysisEntry inst.ReplicationAnalysis, cand
 	} else {
 		log.Infof("Topology recovery: %+v", *topologyRecovery)
 	}
+	if topologyRecovery.IsSimulated {
+		// Nothing was done; there are no processes to run nor postponed functions to wait on
+		return recoveryAttempted, topologyRecovery, err
+	}
 	if !skipProcesses {
 		if topologyRecovery.SuccessorKey == nil {
 			// Execute general unsuccessful post failover processes
@@ -1660,11 +2341,27 @@ func CheckAndRecover(specificInstance *inst.InstanceKey, candidateInstanceKey *i
 				continue
 			}
 		}
+		if specificInstance == nil && analysisEntry.Analysis == inst.ResurrectedOldMaster {
+			// The failed master is typically downtimed following the recovery; we fence it all the same
+			go fenceResurrectedOldMaster(analysisEntry)
+			continue
+		}
 		if analysisEntry.SkippableDueToDowntime && specificInstance == nil {
 			// Only recover a downtimed server if explicitly requested
+			if analysisEntry.Analysis != inst.NoProblem && util.ClearToLog("CheckAndRecover: downtimed", analysisEntry.AnalyzedInstanceKey.StringCode()) {
+				downtimeDescription := analysisEntry.DowntimeString()
+				if downtimeDescription == "" {
+					downtimeDescription = "replicas downtimed"
+				}
+				log.Infof("CheckAndRecover: suppressing %+v on %+v: %s", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey, downtimeDescription)
+			}
 			continue
 		}
 
+		if specificInstance == nil && analysisEntry.HasStructureAnalysis(inst.NoWriteableMasterStructureWarning) {
+			go executeNoWriteableMasterProcesses(analysisEntry)
+		}
+
 		if specificInstance != nil {
 			// force mode. Keep it synchronuous
 			var topologyRecovery *TopologyRecovery
@@ -1683,6 +2380,113 @@ func CheckAndRecover(specificInstance *inst.InstanceKey, candidateInstanceKey *i
 	return recoveryAttempted, promotedReplicaKey, err
 }
 
+// executeNoWriteableMasterProcesses runs OnNoWriteableMasterProcesses for a read_only master, at most once per
+// FailureDetectionPeriodBlockMinutes per master
+func executeNoWriteableMasterProcesses(analysisEntry inst.ReplicationAnalysis) {
+	if len(config.Config.OnNoWriteableMasterProcesses) == 0 {
+		return
+	}
+	if err := noWriteableMasterProcessesMap.Add(analysisEntry.AnalyzedInstanceKey.StringCode(), true, cache.DefaultExpiration); err != nil {
+		// Recently executed
+		return
+	}
+	// There is no failure as such; hooks get the structure warning as {failureType}
+	analysisEntry.Analysis = inst.AnalysisCode(inst.NoWriteableMasterStructureWarning)
+	topologyRecovery := &TopologyRecovery{AnalysisEntry: analysisEntry}
+	executeProcesses(config.Config.OnNoWriteableMasterProcesses, "OnNoWriteableMasterProcesses", topologyRecovery, false)
+}
+
+// setInstanceReadOnly sets an instance read_only or writeable; overridden in tests
+var setInstanceReadOnly = inst.SetReadOnly
+
+// setResurrectedOldMasterReadOnly sets given resurrected old master read_only, unless it already is. It returns
+// whether the instance was changed.
+func setResurrectedOldMasterReadOnly(instance *inst.Instance) (changed bool, err error) {
+	if instance.ReadOnly && (!config.Config.UseSuperReadOnly || instance.SuperReadOnly) {
+		return false, nil
+	}
+	if _, err := setInstanceReadOnly(&instance.Key, true); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// resurrectedOldMasterFenceBackoff returns the time to wait before fencing an instance again, after given number
+// of consecutive failed attempts: doubling from RecoveryPollSeconds, up to FailureDetectionPeriodBlockMinutes
+func resurrectedOldMasterFenceBackoff(failures int) time.Duration {
+	maxBackoff := time.Duration(config.Config.FailureDetectionPeriodBlockMinutes) * time.Minute
+	if failures > 16 {
+		return maxBackoff
+	}
+	backoff := time.Duration(config.RecoveryPollSeconds) * time.Second << uint(failures)
+	if backoff > maxBackoff {
+		return maxBackoff
+	}
+	return backoff
+}
+
+// fenceResurrectedOldMaster makes sure a failed master which came back to life, and which was not repointed
+// under its successor, does not take writes: it is set read_only, and ResurrectedOldMasterFenceProcesses are
+// executed. This is a recovery action like any other: it honors disabled recoveries. read_only is verified on
+// every analysis pass, such that a failed attempt is retried; the hooks run at most once per
+// FailureDetectionPeriodBlockMinutes per instance, once the instance is read_only.
+// At most one fence per instance is in flight; failed attempts are retried with an exponential backoff.
+func fenceResurrectedOldMaster(analysisEntry inst.ReplicationAnalysis) {
+	key := analysisEntry.AnalyzedInstanceKey.StringCode()
+	if err := resurrectedOldMasterFenceAttemptMap.Add(key, true, cache.DefaultExpiration); err != nil {
+		// In flight, or backing off a failed attempt
+		return
+	}
+	if err := fenceResurrectedOldMasterInstance(analysisEntry); err != nil {
+		log.Errore(err)
+		failures := 1
+		if value, found := resurrectedOldMasterFenceFailuresMap.Get(key); found {
+			failures = value.(int) + 1
+		}
+		resurrectedOldMasterFenceFailuresMap.Set(key, failures, cache.DefaultExpiration)
+		resurrectedOldMasterFenceAttemptMap.Set(key, true, resurrectedOldMasterFenceBackoff(failures))
+		return
+	}
+	resurrectedOldMasterFenceFailuresMap.Delete(key)
+	resurrectedOldMasterFenceAttemptMap.Delete(key)
+}
+
+// fenceResurrectedOldMasterInstance is a single attempt at fencing a resurrected old master; see
+// fenceResurrectedOldMaster
+func fenceResurrectedOldMasterInstance(analysisEntry inst.ReplicationAnalysis) error {
+	if recoveryDisabledReason, err := RecoveryDisabledReason(analysisEntry.ClusterDetails.ClusterName); err != nil {
+		return fmt.Errorf("fenceResurrectedOldMaster: unable to determine if recovery is disabled: %v", err)
+	} else if recoveryDisabledReason != "" {
+		log.Infof("fenceResurrectedOldMaster: NOT fencing %+v (%s)", analysisEntry.AnalyzedInstanceKey, recoveryDisabledReason)
+		return nil
+	}
+	instance, found, err := inst.ReadInstance(&analysisEntry.AnalyzedInstanceKey)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("fenceResurrectedOldMaster: instance not found: %+v", analysisEntry.AnalyzedInstanceKey)
+	}
+	if changed, err := setResurrectedOldMasterReadOnly(instance); err != nil {
+		return fmt.Errorf("fenceResurrectedOldMaster: unable to set %+v read_only: %+v", instance.Key, err)
+	} else if changed {
+		inst.AuditOperation("fence-resurrected-old-master", &instance.Key, "set read_only on failed master of an unacknowledged recovery")
+	}
+	if len(config.Config.ResurrectedOldMasterFenceProcesses) == 0 {
+		return nil
+	}
+	if _, found := resurrectedOldMasterFenceMap.Get(instance.Key.StringCode()); found {
+		// Recently fenced
+		return nil
+	}
+	topologyRecovery := &TopologyRecovery{AnalysisEntry: analysisEntry}
+	if err := executeProcesses(config.Config.ResurrectedOldMasterFenceProcesses, "ResurrectedOldMasterFenceProcesses", topologyRecovery, false); err != nil {
+		return err
+	}
+	resurrectedOldMasterFenceMap.Set(instance.Key.StringCode(), true, cache.DefaultExpiration)
+	return nil
+}
+
 func forceAnalysisEntry(clusterName string, analysisCode inst.AnalysisCode, commandHint string, failedInstanceKey *inst.InstanceKey) (analysisEntry inst.ReplicationAnalysis, err error) {
 	clusterInfo, err := inst.ReadClusterInfo(clusterName)
 	if err != nil {
@@ -1782,6 +2586,41 @@ func ForceMasterTakeover(clusterName string, destination *inst.Instance) (topolo
 	return topologyRecovery, nil
 }
 
+// readOnlyRollbackStep describes, for an aborted takeover's audit, the outcome of undoing read_only on the master
+func readOnlyRollbackStep(masterKey *inst.InstanceKey, rollbackErr error) string {
+	if rollbackErr != nil {
+		return fmt.Sprintf("FAILED rolling back read_only on %+v: %+v", *masterKey, rollbackErr)
+	}
+	return fmt.Sprintf("rolled back read_only on %+v", *masterKey)
+}
+
+// gracefulMasterTakeoverAbortSummary audits a takeover aborted by err, along with what was and wasn't done
+func gracefulMasterTakeoverAbortSummary(masterKey, designatedKey *inst.InstanceKey, stepsDone []string, err error) string {
+	done := "nothing"
+	if len(stepsDone) > 0 {
+		done = strings.Join(stepsDone, "; ")
+	}
+	return fmt.Sprintf("aborted takeover by %+v: %+v. Done: %s. Not done: promotion of %+v, demotion of %+v", *designatedKey, err, done, *designatedKey, *masterKey)
+}
+
+// beginGracefulMasterTakeover marks given cluster as undergoing a graceful master takeover, on all raft nodes
+func beginGracefulMasterTakeover(clusterName string) error {
+	if orcraft.IsRaftEnabled() {
+		_, err := orcraft.PublishCommand("begin-graceful-master-takeover", clusterName)
+		return err
+	}
+	return inst.BeginGracefulMasterTakeover(clusterName)
+}
+
+// endGracefulMasterTakeover clears the mark set by beginGracefulMasterTakeover
+func endGracefulMasterTakeover(clusterName string) error {
+	if orcraft.IsRaftEnabled() {
+		_, err := orcraft.PublishCommand("end-graceful-master-takeover", clusterName)
+		return err
+	}
+	return inst.EndGracefulMasterTakeover(clusterName)
+}
+
 // GracefulMasterTakeover will demote master of existing topology and promote its
 // direct replica instead.
 // It expects that replica to have no siblings.
@@ -1835,6 +2674,12 @@ func GracefulMasterTakeover(clusterName string, designatedKey *inst.InstanceKey)
 	if inst.IsBannedFromBeingCandidateReplica(designatedInstance) {
 		return nil, nil, fmt.Errorf("GracefulMasterTakeover: designated instance %+v cannot be promoted due to promotion rule or it is explicitly ignored in PromotionIgnoreHostnameFilters configuration", designatedInstance.Key)
 	}
+	if clusterMaster.IsReplicationGroupMember() {
+		return nil, nil, fmt.Errorf("GracefulMasterTakeover: master %+v is a member of replication group %s; use Group Replication to change its primary", clusterMaster.Key, clusterMaster.ReplicationGroupName)
+	}
+	if err := inst.CheckCanPromoteReplicationGroupMember(designatedInstance, "GracefulMasterTakeover"); err != nil {
+		return nil, nil, err
+	}
 
 	masterOfDesignatedInstance, err := inst.GetInstanceMaster(designatedInstance)
 	if err != nil {
@@ -1843,13 +2688,25 @@ func GracefulMasterTakeover(clusterName string, designatedKey *inst.InstanceKey)
 	if !masterOfDesignatedInstance.Key.Equals(&clusterMaster.Key) {
 		return nil, nil, fmt.Errorf("Sanity check failure. It seems like the designated instance %+v does not replicate from the master %+v (designated instance's master key is %+v). This error is strange. Panicking", designatedInstance.Key, clusterMaster.Key, designatedInstance.MasterKey)
 	}
+	if !designatedInstance.IsLastCheckValid || !designatedInstance.ReplicaRunning() {
+		return nil, nil, fmt.Errorf("GracefulMasterTakeover: designated instance %+v is not healthy: last check valid: %+v, replication running: %+v. Aborting", designatedInstance.Key, designatedInstance.IsLastCheckValid, designatedInstance.ReplicaRunning())
+	}
 	if !designatedInstance.HasReasonableMaintenanceReplicationLag() {
 		return nil, nil, fmt.Errorf("Desginated instance %+v seems to be lagging to much for thie operation. Aborting.", designatedInstance.Key)
 	}
 
+	// clusterMaster may be re-read while the topology changes; its key is not
+	clusterMasterKey := clusterMaster.Key
+	// Once the topology is changed, an aborted takeover is audited along with what was and wasn't done
+	stepsDone := []string{}
+	auditAbort := func(err error) error {
+		inst.AuditOperation("graceful-master-takeover", &clusterMasterKey, gracefulMasterTakeoverAbortSummary(&clusterMasterKey, &designatedInstance.Key, stepsDone, err))
+		return err
+	}
+
 	if len(clusterMasterDirectReplicas) > 1 {
 		log.Infof("GracefulMasterTakeover: Will let %+v take over its siblings", designatedInstance.Key)
-		relocatedReplicas, _, err, _ := inst.RelocateReplicas(&clusterMaster.Key, &designatedInstance.Key, "")
+		relocatedReplicas, _, err, _ := inst.RelocateReplicas(&clusterMasterKey, &designatedInstance.Key, "", true)
 		if len(relocatedReplicas) != len(clusterMasterDirectReplicas)-1 {
 			// We are unable to make designated instance master of all its siblings
 			relocatedReplicasKeyMap := inst.NewInstanceKeyMap()
@@ -1869,34 +2726,51 @@ func GracefulMasterTakeover(clusterName string, designatedKey *inst.InstanceKey)
 					log.Warningf("GracefulMasterTakeover: unable to relocate %+v below designated %+v, but since it is downtimed (downtime reason: %s) I will proceed", directReplica.Key, designatedInstance.Key, directReplica.DowntimeReason)
 					continue
 				}
-				return nil, nil, fmt.Errorf("Desginated instance %+v cannot take over all of its siblings. Error: %+v", designatedInstance.Key, err)
+				if len(relocatedReplicas) > 0 {
+					stepsDone = append(stepsDone, fmt.Sprintf("relocated %d of %d siblings below %+v", len(relocatedReplicas), len(clusterMasterDirectReplicas)-1, designatedInstance.Key))
+				}
+				return nil, nil, auditAbort(fmt.Errorf("Desginated instance %+v cannot take over all of its siblings. Error: %+v", designatedInstance.Key, err))
 			}
 		}
+		stepsDone = append(stepsDone, fmt.Sprintf("relocated %d siblings below %+v", len(relocatedReplicas), designatedInstance.Key))
 	}
-	log.Infof("GracefulMasterTakeover: Will demote %+v and promote %+v instead", clusterMaster.Key, designatedInstance.Key)
+	log.Infof("GracefulMasterTakeover: Will demote %+v and promote %+v instead", clusterMasterKey, designatedInstance.Key)
 
 	replicationUser, replicationPassword, replicationCredentialsError := inst.ReadReplicationCredentials(&designatedInstance.Key)
 
-	analysisEntry, err := forceAnalysisEntry(clusterName, inst.DeadMaster, inst.GracefulMasterTakeoverCommandHint, &clusterMaster.Key)
+	analysisEntry, err := forceAnalysisEntry(clusterName, inst.DeadMaster, inst.GracefulMasterTakeoverCommandHint, &clusterMasterKey)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, auditAbort(err)
 	}
 	preGracefulTakeoverTopologyRecovery := &TopologyRecovery{
 		SuccessorKey:  &designatedInstance.Key,
 		AnalysisEntry: analysisEntry,
 	}
 	if err := executeProcesses(config.Config.PreGracefulTakeoverProcesses, "PreGracefulTakeoverProcesses", preGracefulTakeoverTopologyRecovery, true); err != nil {
-		return nil, nil, fmt.Errorf("Failed running PreGracefulTakeoverProcesses: %+v", err)
+		return nil, nil, auditAbort(fmt.Errorf("Failed running PreGracefulTakeoverProcesses: %+v", err))
 	}
 
-	log.Infof("GracefulMasterTakeover: Will set %+v as read_only", clusterMaster.Key)
-	if clusterMaster, err = inst.SetReadOnly(&clusterMaster.Key, true); err != nil {
-		return nil, nil, err
+	if err := beginGracefulMasterTakeover(clusterName); err != nil {
+		log.Errorf("GracefulMasterTakeover: cannot mark %+v as undergoing a takeover; its read_only master may be analyzed as NoWriteableMaster: %+v", clusterName, err)
+	}
+	defer endGracefulMasterTakeover(clusterName)
+
+	log.Infof("GracefulMasterTakeover: Will set %+v as read_only", clusterMasterKey)
+	readOnlyMaster, err := inst.SetReadOnly(&clusterMasterKey, true)
+	if err != nil {
+		return nil, nil, auditAbort(err)
 	}
+	clusterMaster = readOnlyMaster
+	stepsDone = append(stepsDone, fmt.Sprintf("set %+v read_only", clusterMasterKey))
 	demotedMasterSelfBinlogCoordinates := &clusterMaster.SelfBinlogCoordinates
 	log.Infof("GracefulMasterTakeover: Will wait for %+v to reach master coordinates %+v", designatedInstance.Key, *demotedMasterSelfBinlogCoordinates)
-	if designatedInstance, _, err = inst.WaitForExecBinlogCoordinatesToReach(&designatedInstance.Key, demotedMasterSelfBinlogCoordinates, time.Duration(config.Config.ReasonableMaintenanceReplicationLagSeconds)*time.Second); err != nil {
-		return nil, nil, err
+	if caughtUpInstance, _, err := inst.WaitForExecBinlogCoordinatesToReach(&designatedInstance.Key, demotedMasterSelfBinlogCoordinates, time.Duration(config.Config.ReasonableMaintenanceReplicationLagSeconds)*time.Second); err != nil {
+		// Catch-up timed out or failed; undo setting read-only on original master.
+		_, rollbackErr := inst.SetReadOnly(&clusterMasterKey, false)
+		stepsDone = append(stepsDone, readOnlyRollbackStep(&clusterMasterKey, rollbackErr))
+		return nil, nil, auditAbort(fmt.Errorf("GracefulMasterTakeover: %+v did not catch up with master coordinates %+v within %d seconds: %+v", designatedInstance.Key, *demotedMasterSelfBinlogCoordinates, config.Config.ReasonableMaintenanceReplicationLagSeconds, err))
+	} else {
+		designatedInstance = caughtUpInstance
 	}
 	promotedMasterCoordinates = &designatedInstance.SelfBinlogCoordinates
 
@@ -1906,36 +2780,43 @@ func GracefulMasterTakeover(clusterName string, designatedKey *inst.InstanceKey)
 		log.Errorf("GracefulMasterTakeover: noting an error, and for now proceeding: %+v", err)
 	}
 	if !recoveryAttempted {
-		return nil, nil, fmt.Errorf("GracefulMasterTakeover: unexpected error: recovery not attempted. This should not happen")
+		return nil, nil, auditAbort(fmt.Errorf("GracefulMasterTakeover: unexpected error: recovery not attempted. This should not happen"))
 	}
 	if topologyRecovery == nil {
-		return nil, nil, fmt.Errorf("GracefulMasterTakeover: recovery attempted but with no results. This should not happen")
+		return nil, nil, auditAbort(fmt.Errorf("GracefulMasterTakeover: recovery attempted but with no results. This should not happen"))
 	}
 	if topologyRecovery.SuccessorKey == nil {
 		// Promotion fails.
 		// Undo setting read-only on original master.
-		inst.SetReadOnly(&clusterMaster.Key, false)
-		return nil, nil, fmt.Errorf("GracefulMasterTakeover: Recovery attempted yet no replica promoted; err=%+v", err)
+		_, rollbackErr := inst.SetReadOnly(&clusterMasterKey, false)
+		stepsDone = append(stepsDone, readOnlyRollbackStep(&clusterMasterKey, rollbackErr))
+		return nil, nil, auditAbort(fmt.Errorf("GracefulMasterTakeover: Recovery attempted yet no replica promoted; err=%+v", err))
 	}
 	var gtidHint inst.OperationGTIDHint = inst.GTIDHintNeutral
 	if topologyRecovery.RecoveryType == MasterRecoveryGTID {
 		gtidHint = inst.GTIDHintForce
 	}
-	clusterMaster, err = inst.ChangeMasterTo(&clusterMaster.Key, &designatedInstance.Key, promotedMasterCoordinates, false, gtidHint)
-	if !clusterMaster.SelfBinlogCoordinates.Equals(demotedMasterSelfBinlogCoordinates) {
-		log.Errorf("GracefulMasterTakeover: sanity problem. Demoted master's coordinates changed from %+v to %+v while supposed to have been frozen", *demotedMasterSelfBinlogCoordinates, clusterMaster.SelfBinlogCoordinates)
-	}
-	if !clusterMaster.HasReplicationCredentials && replicationCredentialsError == nil {
-		_, credentialsErr := inst.ChangeMasterCredentials(&clusterMaster.Key, replicationUser, replicationPassword)
-		if err == nil {
-			err = credentialsErr
+	demotedMaster, err := inst.ChangeMasterTo(&clusterMasterKey, &designatedInstance.Key, promotedMasterCoordinates, false, gtidHint)
+	if err != nil {
+		// The demoted master is not pointed at the promoted master; there is nothing to sanity check or configure
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("GracefulMasterTakeover: promoted %+v, but failed repointing demoted master %+v below it: %+v. Demoted master is read_only and not replicating", designatedInstance.Key, clusterMasterKey, err))
+	} else {
+		clusterMaster = demotedMaster
+		if !clusterMaster.SelfBinlogCoordinates.Equals(demotedMasterSelfBinlogCoordinates) {
+			log.Errorf("GracefulMasterTakeover: sanity problem. Demoted master's coordinates changed from %+v to %+v while supposed to have been frozen", *demotedMasterSelfBinlogCoordinates, clusterMaster.SelfBinlogCoordinates)
+		}
+		if !clusterMaster.HasReplicationCredentials && replicationCredentialsError == nil {
+			_, credentialsErr := inst.ChangeMasterCredentials(&clusterMasterKey, replicationUser, replicationPassword)
+			if err == nil {
+				err = credentialsErr
+			}
 		}
-	}
 
-	if designatedInstance.AllowTLS {
-		_, enableSSLErr := inst.EnableMasterSSL(&clusterMaster.Key)
-		if err == nil {
-			err = enableSSLErr
+		if designatedInstance.AllowTLS {
+			_, enableSSLErr := inst.EnableMasterSSL(&clusterMasterKey)
+			if err == nil {
+				err = enableSSLErr
+			}
 		}
 	}
 	executeProcesses(config.Config.PostGracefulTakeoverProcesses, "PostGracefulTakeoverProcesses", topologyRecovery, false)
@@ -17,6 +17,7 @@
 package logic
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -242,6 +243,11 @@ func AttemptRecoveryRegistration(analysisEntry *inst.ReplicationAnalysis, failIf
 	if err != nil {
 		return nil, log.Errore(err)
 	}
+	if topologyRecovery == nil {
+		// Another recovery, possibly by another orchestrator node, has registered first
+		auditRecoveryOwnedElsewhere(analysisEntry)
+		return nil, nil
+	}
 	if orcraft.IsRaftEnabled() {
 		if _, err := orcraft.PublishCommand("write-recovery", topologyRecovery); err != nil {
 			return nil, log.Errore(err)
@@ -250,6 +256,19 @@ func AttemptRecoveryRegistration(analysisEntry *inst.ReplicationAnalysis, failIf
 	return topologyRecovery, nil
 }
 
+// auditRecoveryOwnedElsewhere audits the fact given analysis will not be recovered by this node, since a recovery
+// on same instance is already registered, and by which node.
+func auditRecoveryOwnedElsewhere(analysisEntry *inst.ReplicationAnalysis) {
+	recoveries, err := ReadActiveInstanceRecovery(&analysisEntry.AnalyzedInstanceKey)
+	if err != nil || len(recoveries) == 0 {
+		return
+	}
+	owner := &recoveries[0]
+	message := fmt.Sprintf("%+v recovery owned elsewhere: recovery %s registered by %s (token %s)", analysisEntry.Analysis, owner.UID, owner.ProcessingNodeHostname, owner.ProcessingNodeToken)
+	log.Infof("AttemptRecoveryRegistration: %s", message)
+	inst.AuditOperation("recovery-owned-elsewhere", &analysisEntry.AnalyzedInstanceKey, message)
+}
+
 // ClearActiveRecoveries clears the "in_active_period" flag for old-enough recoveries, thereby allowing for
 // further recoveries on cleared instances.
 func ClearActiveRecoveries() error {
@@ -462,15 +481,18 @@ func AcknowledgeInstanceCompletedRecoveries(instanceKey *inst.InstanceKey, owner
 }
 
 // AcknowledgeCrashedRecoveries marks recoveries whose processing nodes has crashed as acknowledged.
+// A recovery is only considered crashed RecoveryRegistrationExpirySeconds after it was registered, at which
+// point its registration expires and another node may take over.
 func AcknowledgeCrashedRecoveries() (countAcknowledgedEntries int64, err error) {
 	whereClause := `
 			in_active_period = 1
 			and end_recovery is null
+			and start_active_period < now() - interval ? second
 			and concat(processing_node_hostname, ':', processcing_node_token) not in (
 				select concat(hostname, ':', token) from node_health
 			)
 		`
-	return acknowledgeRecoveries("orchestrator", "detected crashed recovery", true, whereClause, sqlutils.Args())
+	return acknowledgeRecoveries("orchestrator", "detected crashed recovery", true, whereClause, sqlutils.Args(config.Config.RecoveryRegistrationExpirySeconds))
 }
 
 // ResolveRecovery is called on completion of a recovery process and updates the recovery status.
@@ -489,6 +511,8 @@ func writeResolveRecovery(topologyRecovery *TopologyRecovery) error {
 				lost_slaves = ?,
 				participating_instances = ?,
 				all_errors = ?,
+				geographic_constraint = ?,
+				failure_reason = ?,
 				end_recovery = NOW()
 			where
 				uid = ?
@@ -496,6 +520,61 @@ func writeResolveRecovery(topologyRecovery *TopologyRecovery) error {
 		topologyRecovery.SuccessorAlias, topologyRecovery.LostReplicas.ToCommaDelimitedList(),
 		topologyRecovery.ParticipatingInstanceKeys.ToCommaDelimitedList(),
 		strings.Join(topologyRecovery.AllErrors, "\n"),
+		topologyRecovery.GeographicConstraint,
+		topologyRecovery.FailureReason,
+		topologyRecovery.UID,
+	)
+	return log.Errore(err)
+}
+
+// writeResolveSimulatedRecovery persists the plan of a simulated recovery. Its would-be successor is recorded,
+// yet the recovery is never marked successful.
+func writeResolveSimulatedRecovery(topologyRecovery *TopologyRecovery) error {
+	var successorKeyToWrite inst.InstanceKey
+	if topologyRecovery.SuccessorKey != nil {
+		successorKeyToWrite = *topologyRecovery.SuccessorKey
+	}
+	simulationPlan := ""
+	if topologyRecovery.SimulationPlan != nil {
+		b, err := json.Marshal(topologyRecovery.SimulationPlan)
+		if err != nil {
+			return log.Errore(err)
+		}
+		simulationPlan = string(b)
+	}
+	_, err := db.ExecOrchestrator(`
+			update topology_recovery set
+				is_successful = 0,
+				is_simulated = 1,
+				successor_hostname = ?,
+				successor_port = ?,
+				successor_alias = ?,
+				lost_slaves = ?,
+				participating_instances = ?,
+				all_errors = ?,
+				simulation_plan = ?,
+				end_recovery = NOW()
+			where
+				uid = ?
+			`, successorKeyToWrite.Hostname, successorKeyToWrite.Port,
+		topologyRecovery.SuccessorAlias, topologyRecovery.LostReplicas.ToCommaDelimitedList(),
+		topologyRecovery.ParticipatingInstanceKeys.ToCommaDelimitedList(),
+		strings.Join(topologyRecovery.AllErrors, "\n"),
+		simulationPlan,
+		topologyRecovery.UID,
+	)
+	return log.Errore(err)
+}
+
+// writeTopologyRecoveryLostReplicas updates the lost replicas of a completed recovery, e.g. as stranded replicas
+// are salvaged following the recovery
+func writeTopologyRecoveryLostReplicas(topologyRecovery *TopologyRecovery) error {
+	_, err := db.ExecOrchestrator(`
+			update topology_recovery set
+				lost_slaves = ?
+			where
+				uid = ?
+			`, topologyRecovery.LostReplicas.ToCommaDelimitedList(),
 		topologyRecovery.UID,
 	)
 	return log.Errore(err)
@@ -515,6 +594,7 @@ func readRecoveries(whereCondition string, limit string, args []interface{}) ([]
       IFNULL(end_active_period_unixtime, 0) as end_active_period_unixtime,
       IFNULL(end_recovery, '') AS end_recovery,
       is_successful,
+      is_simulated,
       processing_node_hostname,
       processcing_node_token,
       ifnull(successor_hostname, '') as successor_hostname,
@@ -528,6 +608,9 @@ func readRecoveries(whereCondition string, limit string, args []interface{}) ([]
       participating_instances,
       lost_slaves,
       all_errors,
+      geographic_constraint,
+      failure_reason,
+      ifnull(simulation_plan, '') as simulation_plan,
       acknowledged,
       acknowledged_at,
       acknowledged_by,
@@ -549,6 +632,7 @@ func readRecoveries(whereCondition string, limit string, args []interface{}) ([]
 		topologyRecovery.RecoveryStartTimestamp = m.GetString("start_active_period")
 		topologyRecovery.RecoveryEndTimestamp = m.GetString("end_recovery")
 		topologyRecovery.IsSuccessful = m.GetBool("is_successful")
+		topologyRecovery.IsSimulated = m.GetBool("is_simulated")
 		topologyRecovery.ProcessingNodeHostname = m.GetString("processing_node_hostname")
 		topologyRecovery.ProcessingNodeToken = m.GetString("processcing_node_token")
 
@@ -568,6 +652,14 @@ func readRecoveries(whereCondition string, limit string, args []interface{}) ([]
 		topologyRecovery.AnalysisEntry.ClusterDetails.ReadRecoveryInfo()
 
 		topologyRecovery.AllErrors = strings.Split(m.GetString("all_errors"), "\n")
+		topologyRecovery.GeographicConstraint = m.GetString("geographic_constraint")
+		topologyRecovery.FailureReason = m.GetString("failure_reason")
+		if simulationPlan := m.GetString("simulation_plan"); simulationPlan != "" {
+			topologyRecovery.SimulationPlan = &RecoverySimulationPlan{}
+			if err := json.Unmarshal([]byte(simulationPlan), topologyRecovery.SimulationPlan); err != nil {
+				log.Errore(err)
+			}
+		}
 		topologyRecovery.LostReplicas.ReadCommaDelimitedList(m.GetString("lost_slaves"))
 		topologyRecovery.ParticipatingInstanceKeys.ReadCommaDelimitedList(m.GetString("participating_instances"))
 
@@ -633,6 +725,16 @@ func ReadInActivePeriodSuccessorInstanceRecovery(instanceKey *inst.InstanceKey)
 	return readRecoveries(whereClause, ``, sqlutils.Args(instanceKey.Hostname, instanceKey.Port))
 }
 
+// ReadActiveInstanceRecovery reads the unfinished recovery of given failed instance, if any
+func ReadActiveInstanceRecovery(instanceKey *inst.InstanceKey) ([]TopologyRecovery, error) {
+	whereClause := `
+		where
+			in_active_period=1
+			and end_recovery is null
+			and hostname=? and port=?`
+	return readRecoveries(whereClause, ``, sqlutils.Args(instanceKey.Hostname, instanceKey.Port))
+}
+
 // ReadRecentlyActiveInstanceRecovery reads recently completed entries for a given instance
 func ReadRecentlyActiveInstanceRecovery(instanceKey *inst.InstanceKey) ([]TopologyRecovery, error) {
 	whereClause := `
@@ -672,6 +774,18 @@ func ReadRecoveryByUID(recoveryUID string) ([]TopologyRecovery, error) {
 	return readRecoveries(whereClause, ``, sqlutils.Args(recoveryUID))
 }
 
+// ReadLatestSuccessfulMasterRecovery reads the latest successful master recovery of given cluster, where the
+// cluster is either the one which failed or the one led by the promoted master
+func ReadLatestSuccessfulMasterRecovery(clusterName string) ([]TopologyRecovery, error) {
+	whereClause := `
+		where
+			is_successful = 1
+			and analysis in (?, ?)
+			and ? in (cluster_name, concat(successor_hostname, ':', successor_port))
+		`
+	return readRecoveries(whereClause, `limit 1`, sqlutils.Args(string(inst.DeadMaster), string(inst.DeadMasterAndSomeSlaves), clusterName))
+}
+
 // ReadCRecoveries reads latest recovery entries from topology_recovery
 func ReadRecentRecoveries(clusterName string, unacknowledgedOnly bool, page int) ([]TopologyRecovery, error) {
 	whereConditions := []string{}
@@ -694,6 +808,32 @@ func ReadRecentRecoveries(clusterName string, unacknowledgedOnly bool, page int)
 	return readRecoveries(whereClause, limit, args)
 }
 
+// ReadUnacknowledgedRecoveries reads a page of recoveries not yet acknowledged, most recent first, potentially
+// filtered by cluster name (empty to unfilter)
+func ReadUnacknowledgedRecoveries(clusterName string, page int) ([]TopologyRecovery, error) {
+	whereClause := `
+		where
+			acknowledged = 0
+			and ? in ('', cluster_name)`
+	limit := `
+		limit ?
+		offset ?`
+	return readRecoveries(whereClause, limit, sqlutils.Args(clusterName, config.AuditPageSize, page*config.AuditPageSize))
+}
+
+// ReadRecentlyAcknowledgedInstanceRecovery reads recoveries of given analysis on given instance which were
+// acknowledged within the last FailureDetectionPeriodBlockMinutes
+func ReadRecentlyAcknowledgedInstanceRecovery(instanceKey *inst.InstanceKey, analysis inst.AnalysisCode) ([]TopologyRecovery, error) {
+	whereClause := `
+		where
+			acknowledged = 1
+			and acknowledged_at > now() - interval ? minute
+			and hostname = ?
+			and port = ?
+			and analysis = ?`
+	return readRecoveries(whereClause, ``, sqlutils.Args(config.Config.FailureDetectionPeriodBlockMinutes, instanceKey.Hostname, instanceKey.Port, string(analysis)))
+}
+
 // readRecoveries reads recovery entry/audit entries from topology_recovery
 func readFailureDetections(whereCondition string, limit string, args []interface{}) ([]TopologyRecovery, error) {
 	res := []TopologyRecovery{}
@@ -773,21 +913,23 @@ func ReadFailureDetectionsForClusterAlias(clusterAlias string) ([]TopologyRecove
 func ReadBlockedRecoveries(clusterName string) ([]BlockedTopologyRecovery, error) {
 	res := []BlockedTopologyRecovery{}
 	whereClause := ""
-	args := sqlutils.Args()
+	args := sqlutils.Args(config.Config.RecoveryPeriodBlockSeconds)
 	if clusterName != "" {
-		whereClause = `where cluster_name = ?`
+		whereClause = `where blocked_topology_recovery.cluster_name = ?`
 		args = append(args, clusterName)
 	}
 	query := fmt.Sprintf(`
 		select
-				hostname,
-				port,
-				cluster_name,
-				analysis,
-				last_blocked_timestamp,
-				blocking_recovery_id
+				blocked_topology_recovery.hostname,
+				blocked_topology_recovery.port,
+				blocked_topology_recovery.cluster_name,
+				blocked_topology_recovery.analysis,
+				blocked_topology_recovery.last_blocked_timestamp,
+				blocked_topology_recovery.blocking_recovery_id,
+				ifnull(unix_timestamp() - unix_timestamp(topology_recovery.start_active_period), ?) as block_elapsed_seconds
 			from
 				blocked_topology_recovery
+				left join topology_recovery on (blocking_recovery_id = topology_recovery.recovery_id and in_active_period = 1)
 			%s
 			order by
 				last_blocked_timestamp desc
@@ -800,6 +942,7 @@ func ReadBlockedRecoveries(clusterName string) ([]BlockedTopologyRecovery, error
 		blockedTopologyRecovery.Analysis = inst.AnalysisCode(m.GetString("analysis"))
 		blockedTopologyRecovery.LastBlockedTimestamp = m.GetString("last_blocked_timestamp")
 		blockedTopologyRecovery.BlockingRecoveryId = m.GetInt64("blocking_recovery_id")
+		blockedTopologyRecovery.BlockRemainingSeconds = recoveryBlockRemainingSeconds(m.GetInt64("block_elapsed_seconds"))
 
 		res = append(res, blockedTopologyRecovery)
 		return nil
@@ -808,14 +951,52 @@ func ReadBlockedRecoveries(clusterName string) ([]BlockedTopologyRecovery, error
 	return res, log.Errore(err)
 }
 
+// recoveryBlockRemainingSeconds returns the time left of the active period of a recovery which started given
+// number of seconds ago
+func recoveryBlockRemainingSeconds(elapsedSeconds int64) int64 {
+	remainingSeconds := int64(config.Config.RecoveryPeriodBlockSeconds) - elapsedSeconds
+	if remainingSeconds < 0 {
+		return 0
+	}
+	return remainingSeconds
+}
+
+// ReadClusterRecoveryBlock returns the recovery whose active period blocks further automated recoveries on given
+// cluster the longest, along with its remaining block time. Returns a zero recovery id if the cluster is not blocked.
+// The block is persisted in the backend database, hence applies across restarts and orchestrator nodes.
+func ReadClusterRecoveryBlock(clusterName string) (blockingRecoveryId int64, remainingSeconds int64, err error) {
+	query := `
+		select
+				recovery_id,
+				unix_timestamp() - unix_timestamp(start_active_period) as block_elapsed_seconds
+			from
+				topology_recovery
+			where
+				in_active_period = 1
+				and cluster_name = ?
+			order by
+				start_active_period desc
+		`
+	err = db.QueryOrchestrator(query, sqlutils.Args(clusterName), func(m sqlutils.RowMap) error {
+		if blockingRecoveryId == 0 {
+			blockingRecoveryId = m.GetInt64("recovery_id")
+			remainingSeconds = recoveryBlockRemainingSeconds(m.GetInt64("block_elapsed_seconds"))
+		}
+		return nil
+	})
+	return blockingRecoveryId, remainingSeconds, log.Errore(err)
+}
+
 // writeTopologyRecoveryStep writes down a single step in a recovery process
 func writeTopologyRecoveryStep(topologyRecoveryStep *TopologyRecoveryStep) error {
 	sqlResult, err := db.ExecOrchestrator(`
 			insert ignore
 				into topology_recovery_steps (
-					recovery_step_id, recovery_uid, audit_at, message
-				) values (?, ?, now(), ?)
+					recovery_step_id, recovery_uid, audit_at, message, phase, hostname, port, outcome, error_message, duration_millis
+				) values (?, ?, now(), ?, ?, ?, ?, ?, ?, ?)
 			`, sqlutils.NilIfZero(topologyRecoveryStep.Id), topologyRecoveryStep.RecoveryUID, topologyRecoveryStep.Message,
+		topologyRecoveryStep.Phase, topologyRecoveryStep.Key.Hostname, topologyRecoveryStep.Key.Port,
+		topologyRecoveryStep.Outcome, topologyRecoveryStep.Error, topologyRecoveryStep.DurationMillis,
 	)
 	if err != nil {
 		return log.Errore(err)
@@ -826,23 +1007,34 @@ func writeTopologyRecoveryStep(topologyRecoveryStep *TopologyRecoveryStep) error
 
 // ReadTopologyRecoverySteps reads recovery steps for a given recovery
 func ReadTopologyRecoverySteps(recoveryUID string) ([]TopologyRecoveryStep, error) {
+	return readTopologyRecoverySteps(`recovery_uid=?`, sqlutils.Args(recoveryUID))
+}
+
+func readTopologyRecoverySteps(whereCondition string, args []interface{}) ([]TopologyRecoveryStep, error) {
 	res := []TopologyRecoveryStep{}
-	query := `
+	query := fmt.Sprintf(`
 		select
-			recovery_step_id, recovery_uid, audit_at, message
+			recovery_step_id, recovery_uid, audit_at, message,
+			phase, hostname, port, outcome, ifnull(error_message, '') as error_message, duration_millis
 		from
 			topology_recovery_steps
 		where
-			recovery_uid=?
+			%s
 		order by
 			recovery_step_id asc
-		`
-	err := db.QueryOrchestrator(query, sqlutils.Args(recoveryUID), func(m sqlutils.RowMap) error {
+		`, whereCondition)
+	err := db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
 		recoveryStep := TopologyRecoveryStep{}
-		recoveryStep.RecoveryUID = recoveryUID
+		recoveryStep.RecoveryUID = m.GetString("recovery_uid")
 		recoveryStep.Id = m.GetInt64("recovery_step_id")
 		recoveryStep.AuditAt = m.GetString("audit_at")
 		recoveryStep.Message = m.GetString("message")
+		recoveryStep.Phase = m.GetString("phase")
+		recoveryStep.Key.Hostname = m.GetString("hostname")
+		recoveryStep.Key.Port = m.GetInt("port")
+		recoveryStep.Outcome = m.GetString("outcome")
+		recoveryStep.Error = m.GetString("error_message")
+		recoveryStep.DurationMillis = m.GetInt64("duration_millis")
 
 		res = append(res, recoveryStep)
 		return nil
@@ -850,6 +1042,34 @@ func ReadTopologyRecoverySteps(recoveryUID string) ([]TopologyRecoveryStep, erro
 	return res, log.Errore(err)
 }
 
+// ReadTopologyRecoveriesTimelines populates the Timeline of given recoveries, i.e. their completed phases in
+// order of completion
+func ReadTopologyRecoveriesTimelines(recoveries []TopologyRecovery) error {
+	if len(recoveries) == 0 {
+		return nil
+	}
+	recoveriesIndexes := make(map[string]int)
+	placeholders := []string{}
+	args := sqlutils.Args()
+	for i := range recoveries {
+		recoveries[i].Timeline = []TopologyRecoveryStep{}
+		recoveriesIndexes[recoveries[i].UID] = i
+		placeholders = append(placeholders, "?")
+		args = append(args, recoveries[i].UID)
+	}
+	whereCondition := fmt.Sprintf(`phase != '' and recovery_uid in (%s)`, strings.Join(placeholders, ", "))
+	steps, err := readTopologyRecoverySteps(whereCondition, args)
+	if err != nil {
+		return err
+	}
+	for _, step := range steps {
+		if i, ok := recoveriesIndexes[step.RecoveryUID]; ok {
+			recoveries[i].Timeline = append(recoveries[i].Timeline, step)
+		}
+	}
+	return nil
+}
+
 // ExpireFailureDetectionHistory removes old rows from the topology_failure_detection table
 func ExpireFailureDetectionHistory() error {
 	return inst.ExpireTableData("topology_failure_detection", "start_active_period")
@@ -0,0 +1,182 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/inst"
+	test "github.com/openark/golib/tests"
+)
+
+func TestRecoveryBlockRemainingSeconds(t *testing.T) {
+	defer func(seconds int) { config.Config.RecoveryPeriodBlockSeconds = seconds }(config.Config.RecoveryPeriodBlockSeconds)
+	config.Config.RecoveryPeriodBlockSeconds = 600
+
+	test.S(t).ExpectEquals(recoveryBlockRemainingSeconds(0), int64(600))
+	test.S(t).ExpectEquals(recoveryBlockRemainingSeconds(450), int64(150))
+	test.S(t).ExpectEquals(recoveryBlockRemainingSeconds(600), int64(0))
+	test.S(t).ExpectEquals(recoveryBlockRemainingSeconds(3600), int64(0))
+}
+
+func TestWriteReadTopologyRecoverySteps(t *testing.T) {
+	defer useSQLiteBackend(t)()
+
+	instanceKey := inst.InstanceKey{Hostname: "db-2", Port: 3306}
+	recovery := &TopologyRecovery{UID: "recovery-1"}
+	otherRecovery := &TopologyRecovery{UID: "recovery-2"}
+	test.S(t).ExpectNil(AuditTopologyRecovery(recovery, "will handle DeadMaster event"))
+	test.S(t).ExpectNil(AuditTopologyRecoveryPhase(recovery, "regroup", nil, time.Now(), nil))
+	test.S(t).ExpectNil(AuditTopologyRecoveryPhase(otherRecovery, "regroup", nil, time.Now(), nil))
+	promotionErr := errors.New("cannot set read_only=0")
+	test.S(t).ExpectEquals(AuditTopologyRecoveryPhase(recovery, "promotion", &instanceKey, time.Now(), promotionErr), promotionErr)
+
+	steps, err := ReadTopologyRecoverySteps(recovery.UID)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(steps), 3)
+	test.S(t).ExpectEquals(steps[0].Message, "will handle DeadMaster event")
+	test.S(t).ExpectEquals(steps[0].Phase, "")
+	test.S(t).ExpectEquals(steps[1].Phase, "regroup")
+	test.S(t).ExpectEquals(steps[1].Outcome, "success")
+	test.S(t).ExpectEquals(steps[2].Phase, "promotion")
+	test.S(t).ExpectEquals(steps[2].Key, instanceKey)
+	test.S(t).ExpectEquals(steps[2].Outcome, "failure")
+	test.S(t).ExpectEquals(steps[2].Error, "cannot set read_only=0")
+
+	// Timelines list phases only, grouped by recovery, in order
+	recoveries := []TopologyRecovery{*recovery, *otherRecovery, {UID: "recovery-3"}}
+	test.S(t).ExpectNil(ReadTopologyRecoveriesTimelines(recoveries))
+	test.S(t).ExpectEquals(len(recoveries[0].Timeline), 2)
+	test.S(t).ExpectEquals(recoveries[0].Timeline[0].Phase, "regroup")
+	test.S(t).ExpectEquals(recoveries[0].Timeline[1].Phase, "promotion")
+	test.S(t).ExpectEquals(len(recoveries[1].Timeline), 1)
+	test.S(t).ExpectEquals(recoveries[1].Timeline[0].RecoveryUID, "recovery-2")
+	test.S(t).ExpectEquals(len(recoveries[2].Timeline), 0)
+}
+
+func TestReadUnacknowledgedRecoveries(t *testing.T) {
+	defer useSQLiteBackend(t)()
+
+	writeRecovery := func(hostname string, clusterName string) *TopologyRecovery {
+		analysisEntry := inst.ReplicationAnalysis{
+			AnalyzedInstanceKey: inst.InstanceKey{Hostname: hostname, Port: 3306},
+			Analysis:            inst.DeadMaster,
+		}
+		analysisEntry.ClusterDetails.ClusterName = clusterName
+		recovery, err := writeTopologyRecovery(NewTopologyRecovery(analysisEntry))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectNotNil(recovery)
+		return recovery
+	}
+	// c1 gets one recovery beyond a full page, c2 a single one, and another c1 recovery is acknowledged
+	var c1Recoveries []*TopologyRecovery
+	for i := 0; i <= config.AuditPageSize; i++ {
+		c1Recoveries = append(c1Recoveries, writeRecovery(fmt.Sprintf("db-c1-%d", i), "c1"))
+	}
+	c2Recovery := writeRecovery("db-c2", "c2")
+	acknowledgedRecovery := writeRecovery("db-c1-acknowledged", "c1")
+	_, err := AcknowledgeRecoveryByUID(acknowledgedRecovery.UID, "test", "handled")
+	test.S(t).ExpectNil(err)
+
+	recoveries, err := ReadUnacknowledgedRecoveries("", 0)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(recoveries), config.AuditPageSize)
+	test.S(t).ExpectEquals(recoveries[0].UID, c2Recovery.UID)
+	test.S(t).ExpectEquals(recoveries[1].UID, c1Recoveries[config.AuditPageSize].UID)
+	recoveries, err = ReadUnacknowledgedRecoveries("", 1)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(recoveries), 2)
+	test.S(t).ExpectEquals(recoveries[0].UID, c1Recoveries[1].UID)
+	test.S(t).ExpectEquals(recoveries[1].UID, c1Recoveries[0].UID)
+
+	recoveries, err = ReadUnacknowledgedRecoveries("c1", 0)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(recoveries), config.AuditPageSize)
+	for _, recovery := range recoveries {
+		test.S(t).ExpectEquals(recovery.AnalysisEntry.ClusterDetails.ClusterName, "c1")
+		test.S(t).ExpectFalse(recovery.Acknowledged)
+	}
+	recoveries, err = ReadUnacknowledgedRecoveries("c1", 1)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(recoveries), 1)
+	test.S(t).ExpectEquals(recoveries[0].UID, c1Recoveries[0].UID)
+	recoveries, err = ReadUnacknowledgedRecoveries("c2", 0)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(recoveries), 1)
+	test.S(t).ExpectEquals(recoveries[0].UID, c2Recovery.UID)
+}
+
+func TestAcknowledgeCrashedRecoveries(t *testing.T) {
+	defer useSQLiteBackend(t)()
+	defer func(seconds uint) { config.Config.RecoveryRegistrationExpirySeconds = seconds }(config.Config.RecoveryRegistrationExpirySeconds)
+	config.Config.RecoveryRegistrationExpirySeconds = 60
+
+	_, err := db.ExecOrchestrator(`insert into node_health (hostname, token, last_seen_active) values (?, ?, now())`, "node-1", "token-1")
+	test.S(t).ExpectNil(err)
+	// writeRecovery registers a recovery on given processing node, started given minutes ago
+	writeRecovery := func(hostname string, processingNodeHostname string, processingNodeToken string, startedMinutesAgo int, ended bool) *TopologyRecovery {
+		analysisEntry := inst.ReplicationAnalysis{
+			AnalyzedInstanceKey: inst.InstanceKey{Hostname: hostname, Port: 3306},
+			Analysis:            inst.DeadMaster,
+		}
+		recovery, err := writeTopologyRecovery(NewTopologyRecovery(analysisEntry))
+		test.S(t).ExpectNil(err)
+		_, err = db.ExecOrchestrator(`
+			update topology_recovery set
+				processing_node_hostname = ?,
+				processcing_node_token = ?,
+				start_active_period = now() - interval ? minute
+			where uid = ?`,
+			processingNodeHostname, processingNodeToken, startedMinutesAgo, recovery.UID,
+		)
+		test.S(t).ExpectNil(err)
+		if ended {
+			_, err = db.ExecOrchestrator(`update topology_recovery set end_recovery = now() where uid = ?`, recovery.UID)
+			test.S(t).ExpectNil(err)
+		}
+		return recovery
+	}
+	healthyNodeRecovery := writeRecovery("db-1", "node-1", "token-1", 10, false)
+	crashedNodeRecovery := writeRecovery("db-2", "node-2", "token-2", 10, false)
+	restartedNodeRecovery := writeRecovery("db-3", "node-1", "token-0", 10, false)
+	unexpiredRecovery := writeRecovery("db-4", "node-2", "token-2", 0, false)
+	endedRecovery := writeRecovery("db-5", "node-2", "token-2", 10, true)
+
+	count, err := AcknowledgeCrashedRecoveries()
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(count, int64(2))
+
+	expectAcknowledged := map[string]bool{
+		healthyNodeRecovery.UID:   false,
+		crashedNodeRecovery.UID:   true,
+		restartedNodeRecovery.UID: true,
+		unexpiredRecovery.UID:     false,
+		endedRecovery.UID:         false,
+	}
+	for uid, acknowledged := range expectAcknowledged {
+		recoveries, err := ReadRecoveryByUID(uid)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(recoveries), 1)
+		test.S(t).ExpectEquals(recoveries[0].Acknowledged, acknowledged)
+		test.S(t).ExpectEquals(recoveries[0].IsActive, !acknowledged)
+	}
+}
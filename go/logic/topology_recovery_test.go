@@ -0,0 +1,291 @@
+/*
+   Copyright 2019 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/inst"
+	test "github.com/openark/golib/tests"
+)
+
+func TestOrphanedReplicasOutcome(t *testing.T) {
+	failedKey := inst.InstanceKey{Hostname: "im", Port: 3306}
+	siblingKey := inst.InstanceKey{Hostname: "sibling", Port: 3306}
+	replicas := [](*inst.Instance){
+		{Key: inst.InstanceKey{Hostname: "r1", Port: 3306}, MasterKey: siblingKey},
+		{Key: inst.InstanceKey{Hostname: "r2", Port: 3306}, MasterKey: failedKey},
+	}
+	outcomes, lostReplicas := orphanedReplicasOutcome(&failedKey, replicas)
+	test.S(t).ExpectEquals(len(outcomes), 2)
+	test.S(t).ExpectEquals(outcomes[0], "r1:3306: relocated below sibling:3306")
+	test.S(t).ExpectEquals(outcomes[1], "r2:3306: could not be relocated")
+	test.S(t).ExpectEquals(len(lostReplicas), 1)
+	test.S(t).ExpectEquals(lostReplicas[0].Key.Hostname, "r2")
+}
+
+func TestIsForcedPromotion(t *testing.T) {
+	defer func(seconds uint) { config.Config.PromotionMaxSQLThreadLagSeconds = seconds }(config.Config.PromotionMaxSQLThreadLagSeconds)
+	config.Config.PromotionMaxSQLThreadLagSeconds = 60
+
+	recovery := NewTopologyRecovery(inst.ReplicationAnalysis{Analysis: inst.DeadMaster})
+	test.S(t).ExpectFalse(isForcedPromotion(recovery))
+	test.S(t).ExpectEquals(promotionMaxSQLThreadLagSeconds(recovery), uint(60))
+
+	forcedRecovery := NewTopologyRecovery(inst.ReplicationAnalysis{Analysis: inst.DeadMaster, CommandHint: inst.ForceMasterFailoverCommandHint})
+	test.S(t).ExpectTrue(isForcedPromotion(forcedRecovery))
+	test.S(t).ExpectEquals(promotionMaxSQLThreadLagSeconds(forcedRecovery), uint(0))
+}
+
+func TestMasterFailoverGeographicConstraint(t *testing.T) {
+	defer func(preventDC, preferDC, preventRegion bool) {
+		config.Config.PreventCrossDataCenterMasterFailover = preventDC
+		config.Config.PreferSameDataCenterMasterFailover = preferDC
+		config.Config.PreventCrossRegionMasterFailover = preventRegion
+	}(config.Config.PreventCrossDataCenterMasterFailover, config.Config.PreferSameDataCenterMasterFailover, config.Config.PreventCrossRegionMasterFailover)
+
+	analysisEntry := &inst.ReplicationAnalysis{AnalyzedInstanceDataCenter: "dc1", AnalyzedInstanceRegion: "r1"}
+	sameDataCenter := &inst.Instance{DataCenter: "dc1", Region: "r1"}
+	otherDataCenter := &inst.Instance{DataCenter: "dc2", Region: "r1"}
+	otherRegion := &inst.Instance{DataCenter: "dc3", Region: "r2"}
+
+	config.Config.PreventCrossDataCenterMasterFailover = false
+	config.Config.PreferSameDataCenterMasterFailover = true
+	config.Config.PreventCrossRegionMasterFailover = true
+	test.S(t).ExpectEquals(masterFailoverGeographicConstraint(), "PreferSameDataCenterMasterFailover,PreventCrossRegionMasterFailover")
+	test.S(t).ExpectFalse(preferSameDataCenterReplacement(analysisEntry, sameDataCenter))
+	test.S(t).ExpectTrue(preferSameDataCenterReplacement(analysisEntry, otherDataCenter))
+	test.S(t).ExpectEquals(masterFailoverGeographicFailureReason(analysisEntry, otherDataCenter), "")
+	test.S(t).ExpectEquals(masterFailoverGeographicFailureReason(analysisEntry, otherRegion), FailureReasonCrossRegionPromotion)
+
+	config.Config.PreventCrossDataCenterMasterFailover = true
+	test.S(t).ExpectEquals(masterFailoverGeographicConstraint(), "PreventCrossDataCenterMasterFailover,PreventCrossRegionMasterFailover")
+	test.S(t).ExpectFalse(preferSameDataCenterReplacement(analysisEntry, otherDataCenter))
+	test.S(t).ExpectEquals(masterFailoverGeographicFailureReason(analysisEntry, sameDataCenter), "")
+	test.S(t).ExpectEquals(masterFailoverGeographicFailureReason(analysisEntry, otherDataCenter), FailureReasonCrossDataCenterPromotion)
+
+	config.Config.PreventCrossDataCenterMasterFailover = false
+	config.Config.PreferSameDataCenterMasterFailover = false
+	config.Config.PreventCrossRegionMasterFailover = false
+	test.S(t).ExpectEquals(masterFailoverGeographicConstraint(), "")
+}
+
+func TestStrandedReplicaRematchBlocker(t *testing.T) {
+	promotedMaster := &inst.Instance{Key: inst.InstanceKey{Hostname: "promoted", Port: 3306}, IsLastCheckValid: true}
+	replica := &inst.Instance{Key: inst.InstanceKey{Hostname: "replica", Port: 3306}, IsLastCheckValid: true}
+	test.S(t).ExpectEquals(strandedReplicaRematchBlocker(replica, promotedMaster, false), "")
+	test.S(t).ExpectEquals(strandedReplicaRematchBlocker(promotedMaster, promotedMaster, false), "is the promoted master")
+
+	replica.IsLastCheckValid = false
+	test.S(t).ExpectEquals(strandedReplicaRematchBlocker(replica, promotedMaster, false), "last check is invalid")
+
+	replica.IsLastCheckValid = true
+	test.S(t).ExpectEquals(strandedReplicaRematchBlocker(replica, promotedMaster, true), "is under maintenance")
+
+	replica.IsDowntimed = true
+	replica.DowntimeReason = "upgrading"
+	test.S(t).ExpectEquals(strandedReplicaRematchBlocker(replica, promotedMaster, false), "is downtimed")
+	replica.DowntimeReason = inst.DowntimeLostInRecoveryMessage
+	test.S(t).ExpectEquals(strandedReplicaRematchBlocker(replica, promotedMaster, false), "")
+
+	replica.IsDowntimed = false
+	replica.UsingOracleGTID = true
+	test.S(t).ExpectEquals(strandedReplicaRematchBlocker(replica, promotedMaster, false), "promoted master does not support GTID")
+}
+
+func TestRematchStrandedReplicasHonorsDisabledRecoveries(t *testing.T) {
+	defer useSQLiteBackend(t)()
+
+	promotedMaster := &inst.Instance{Key: inst.InstanceKey{Hostname: "promoted", Port: 3306}, ClusterName: "promoted:3306"}
+	_, err := db.ExecOrchestrator(`
+		insert into database_instance (
+			hostname, port, cluster_name, last_checked, last_seen, server_id, version, binlog_format, log_bin, log_slave_updates,
+			binary_log_file, binary_log_pos, master_host, master_port, slave_sql_running, slave_io_running, master_log_file,
+			read_master_log_pos, relay_master_log_file, exec_master_log_pos, num_slave_hosts, slave_hosts
+		) values (?, ?, ?, now(), now(), 1, '5.7.26', 'ROW', 1, 1, '', 0, '', 0, 0, 0, '', 0, '', 0, 0, '')`,
+		promotedMaster.Key.Hostname, promotedMaster.Key.Port, promotedMaster.ClusterName,
+	)
+	test.S(t).ExpectNil(err)
+	topologyRecovery := &TopologyRecovery{
+		UID:           "rematch-recovery",
+		IsSuccessful:  true,
+		SuccessorKey:  &promotedMaster.Key,
+		AnalysisEntry: inst.ReplicationAnalysis{AnalyzedInstanceKey: inst.InstanceKey{Hostname: "failed", Port: 3306}},
+	}
+
+	test.S(t).ExpectNil(DisableClusterRecovery(promotedMaster.ClusterName))
+	salvagedReplicas, err := RematchStrandedReplicas(topologyRecovery)
+	test.S(t).ExpectNotNil(err)
+	test.S(t).ExpectTrue(strings.Contains(err.Error(), "disabled for cluster promoted:3306"))
+	test.S(t).ExpectEquals(len(salvagedReplicas), 0)
+
+	test.S(t).ExpectNil(EnableClusterRecovery(promotedMaster.ClusterName))
+	test.S(t).ExpectNil(DisableRecovery())
+	_, err = RematchStrandedReplicas(topologyRecovery)
+	test.S(t).ExpectNotNil(err)
+	test.S(t).ExpectTrue(strings.Contains(err.Error(), "disabled globally"))
+
+	test.S(t).ExpectNil(EnableRecovery())
+	salvagedReplicas, err = RematchStrandedReplicas(topologyRecovery)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(salvagedReplicas), 0)
+}
+
+func TestDeadMasterRecoverySimulationPlan(t *testing.T) {
+	defer func(pre, post []string) {
+		config.Config.PreFailoverProcesses, config.Config.PostFailoverProcesses = pre, post
+	}(config.Config.PreFailoverProcesses, config.Config.PostFailoverProcesses)
+	config.Config.PreFailoverProcesses = []string{"echo pre {failedHost}"}
+	config.Config.PostFailoverProcesses = []string{"echo post {successorHost} {countLostReplicas} &"}
+
+	failedKey := inst.InstanceKey{Hostname: "master", Port: 3306}
+	candidateKey := inst.InstanceKey{Hostname: "r1", Port: 3306}
+	suggestion := &inst.PromotionSuggestion{
+		MasterKey: failedKey,
+		Candidate: &inst.Instance{Key: candidateKey},
+		Reason:    "most up to date valid replica",
+		RejectedCandidates: []inst.PromotionCandidateRejection{
+			{Key: inst.InstanceKey{Hostname: "r2", Port: 3306}, Reason: "ahead of chosen replica; would be lost"},
+			{Key: inst.InstanceKey{Hostname: "r3", Port: 3306}, Reason: "ranked lower"},
+		},
+		LostReplicas: []inst.InstanceKey{{Hostname: "r2", Port: 3306}},
+	}
+	topologyRecovery := NewTopologyRecovery(inst.ReplicationAnalysis{AnalyzedInstanceKey: failedKey})
+	plan := deadMasterRecoverySimulationPlan(topologyRecovery, suggestion, false)
+	test.S(t).ExpectTrue(plan.CandidateKey.Equals(&candidateKey))
+	test.S(t).ExpectEquals(len(plan.ReplicasToMove), 1)
+	test.S(t).ExpectEquals(plan.ReplicasToMove[0].Hostname, "r3")
+	test.S(t).ExpectEquals(len(plan.LostReplicas), 1)
+	test.S(t).ExpectTrue(topologyRecovery.SuccessorKey.Equals(&candidateKey))
+	test.S(t).ExpectEquals(len(plan.Hooks), 2)
+	test.S(t).ExpectEquals(plan.Hooks[0].Hooks, "PreFailoverProcesses")
+	test.S(t).ExpectEquals(plan.Hooks[0].Command, "echo pre master")
+	test.S(t).ExpectEquals(plan.Hooks[1].Command, "echo post r1 1 ")
+	test.S(t).ExpectTrue(plan.Hooks[1].Async)
+
+	plan = deadMasterRecoverySimulationPlan(NewTopologyRecovery(inst.ReplicationAnalysis{}), suggestion, true)
+	test.S(t).ExpectEquals(len(plan.Hooks), 0)
+}
+
+func TestRegroupedBelow(t *testing.T) {
+	masterKey := inst.InstanceKey{Hostname: "master", Port: 3306}
+	promotedReplica := &inst.Instance{Key: inst.InstanceKey{Hostname: "r1", Port: 3306}, MasterKey: masterKey}
+	regrouped := &inst.Instance{Key: inst.InstanceKey{Hostname: "r2", Port: 3306}, MasterKey: masterKey}
+	lost := &inst.Instance{Key: inst.InstanceKey{Hostname: "r3", Port: 3306}, MasterKey: masterKey}
+	instances := [](*inst.Instance){regrouped, lost}
+
+	test.S(t).ExpectEquals(regroupedBelow(instances, promotedReplica, nil)[0], regrouped)
+
+	regroupedKeys := inst.NewInstanceKeyMap()
+	regroupedKeys.AddKey(regrouped.Key)
+	result := regroupedBelow(instances, promotedReplica, regroupedKeys)
+	test.S(t).ExpectEquals(len(result), 2)
+	test.S(t).ExpectTrue(result[0].MasterKey.Equals(&promotedReplica.Key))
+	test.S(t).ExpectTrue(result[1].MasterKey.Equals(&masterKey))
+	// Read instances are left unchanged
+	test.S(t).ExpectTrue(regrouped.MasterKey.Equals(&masterKey))
+}
+
+func TestPromotionCandidateDisqualification(t *testing.T) {
+	test.S(t).ExpectEquals(promotionCandidateDisqualification(nil), "not found")
+
+	replica := &inst.Instance{Key: inst.InstanceKey{Hostname: "candidate", Port: 3306}, IsLastCheckValid: true}
+	test.S(t).ExpectEquals(promotionCandidateDisqualification(replica), "")
+
+	replica.LastSQLError = "Duplicate entry"
+	test.S(t).ExpectEquals(promotionCandidateDisqualification(replica), "replication sql thread error: Duplicate entry")
+
+	replica.IsLastCheckValid = false
+	test.S(t).ExpectEquals(promotionCandidateDisqualification(replica), "last check is invalid")
+}
+
+func TestGracefulMasterTakeoverAbortSummary(t *testing.T) {
+	masterKey := inst.InstanceKey{Hostname: "master", Port: 3306}
+	designatedKey := inst.InstanceKey{Hostname: "designated", Port: 3306}
+	abortErr := errors.New("catch-up timed out")
+
+	summary := gracefulMasterTakeoverAbortSummary(&masterKey, &designatedKey, nil, abortErr)
+	test.S(t).ExpectEquals(summary, "aborted takeover by designated:3306: catch-up timed out. Done: nothing. Not done: promotion of designated:3306, demotion of master:3306")
+
+	stepsDone := []string{"set master:3306 read_only"}
+	stepsDone = append(stepsDone, readOnlyRollbackStep(&masterKey, nil))
+	summary = gracefulMasterTakeoverAbortSummary(&masterKey, &designatedKey, stepsDone, abortErr)
+	test.S(t).ExpectEquals(summary, "aborted takeover by designated:3306: catch-up timed out. Done: set master:3306 read_only; rolled back read_only on master:3306. Not done: promotion of designated:3306, demotion of master:3306")
+
+	test.S(t).ExpectEquals(readOnlyRollbackStep(&masterKey, errors.New("connection refused")), "FAILED rolling back read_only on master:3306: connection refused")
+}
+
+func TestCandidateFallbackAllowed(t *testing.T) {
+	defer func(attempts uint) { config.Config.MasterFailoverCandidateFallbackAttempts = attempts }(config.Config.MasterFailoverCandidateFallbackAttempts)
+
+	config.Config.MasterFailoverCandidateFallbackAttempts = 2
+	test.S(t).ExpectTrue(candidateFallbackAllowed(true, 1))
+	test.S(t).ExpectTrue(candidateFallbackAllowed(true, 2))
+	test.S(t).ExpectFalse(candidateFallbackAllowed(true, 3))
+	test.S(t).ExpectFalse(candidateFallbackAllowed(false, 1))
+
+	config.Config.MasterFailoverCandidateFallbackAttempts = 0
+	test.S(t).ExpectFalse(candidateFallbackAllowed(true, 1))
+}
+
+func TestSetResurrectedOldMasterReadOnlyRetries(t *testing.T) {
+	defer func(f func(*inst.InstanceKey, bool) (*inst.Instance, error)) { setInstanceReadOnly = f }(setInstanceReadOnly)
+	defer func(useSuperReadOnly bool) { config.Config.UseSuperReadOnly = useSuperReadOnly }(config.Config.UseSuperReadOnly)
+	config.Config.UseSuperReadOnly = false
+
+	calls := 0
+	setInstanceReadOnly = func(instanceKey *inst.InstanceKey, readOnly bool) (*inst.Instance, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("connection refused")
+		}
+		return &inst.Instance{Key: *instanceKey, ReadOnly: readOnly}, nil
+	}
+	instance := &inst.Instance{Key: inst.InstanceKey{Hostname: "old-master", Port: 3306}}
+
+	changed, err := setResurrectedOldMasterReadOnly(instance)
+	test.S(t).ExpectNotNil(err)
+	test.S(t).ExpectFalse(changed)
+
+	// next analysis pass
+	changed, err = setResurrectedOldMasterReadOnly(instance)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(changed)
+	test.S(t).ExpectEquals(calls, 2)
+
+	instance.ReadOnly = true
+	changed, err = setResurrectedOldMasterReadOnly(instance)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectFalse(changed)
+	test.S(t).ExpectEquals(calls, 2)
+}
+
+func TestResurrectedOldMasterFenceBackoff(t *testing.T) {
+	defer func(minutes int) { config.Config.FailureDetectionPeriodBlockMinutes = minutes }(config.Config.FailureDetectionPeriodBlockMinutes)
+	config.Config.FailureDetectionPeriodBlockMinutes = 60
+
+	test.S(t).ExpectEquals(resurrectedOldMasterFenceBackoff(1), 2*time.Second)
+	test.S(t).ExpectEquals(resurrectedOldMasterFenceBackoff(2), 4*time.Second)
+	test.S(t).ExpectEquals(resurrectedOldMasterFenceBackoff(10), 1024*time.Second)
+	test.S(t).ExpectEquals(resurrectedOldMasterFenceBackoff(12), 60*time.Minute)
+	test.S(t).ExpectEquals(resurrectedOldMasterFenceBackoff(100), 60*time.Minute)
+}
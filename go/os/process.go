@@ -17,12 +17,14 @@
 package os
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/github/orchestrator/go/config"
 	"github.com/openark/golib/log"
@@ -35,20 +37,57 @@ var EmptyEnv = []string{}
 // command to a temporary file and then ask the shell to execute
 // it, after which the temporary file is removed.
 func CommandRun(commandText string, env []string, arguments ...string) error {
+	cmdOutput, err := CommandRunWithTimeout(commandText, env, 0, arguments...)
+	if err != nil {
+		return log.Errore(fmt.Errorf("(%s) %s", err.Error(), cmdOutput))
+	}
+	return nil
+}
+
+// CommandRunWithTimeout executes some text as a command, as CommandRun does, and returns its combined
+// stdout and stderr. Should the command run for longer than given timeout, it is killed along with any
+// process it has spawned. A zero timeout means no limit.
+func CommandRunWithTimeout(commandText string, env []string, timeout time.Duration, arguments ...string) (cmdOutput string, err error) {
 	// show the actual command we have been asked to run
 	log.Infof("CommandRun(%v,%+v)", commandText, arguments)
 
 	cmd, shellScript, err := generateShellScript(commandText, env, arguments...)
 	defer os.Remove(shellScript)
 	if err != nil {
-		return log.Errore(err)
+		return "", log.Errore(err)
 	}
+	// Run in own process group, so that a timeout kills the command's children as well
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
 
 	var waitStatus syscall.WaitStatus
 
 	log.Infof("CommandRun/running: %s", strings.Join(cmd.Args, " "))
-	cmdOutput, err := cmd.CombinedOutput()
-	log.Infof("CommandRun: %s\n", string(cmdOutput))
+	if err := cmd.Start(); err != nil {
+		return "", log.Errore(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	var timeoutChan <-chan time.Time
+	if timeout > 0 {
+		timeoutChan = time.After(timeout)
+	}
+	timedOut := false
+	select {
+	case err = <-done:
+	case <-timeoutChan:
+		timedOut = true
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		err = <-done
+	}
+	cmdOutput = output.String()
+	log.Infof("CommandRun: %s\n", cmdOutput)
+	if timedOut {
+		return cmdOutput, log.Errorf("CommandRun: timed out after %+v", timeout)
+	}
 	if err != nil {
 		// Did the command fail because of an unsuccessful exit code
 		if exitError, ok := err.(*exec.ExitError); ok {
@@ -56,14 +95,14 @@ func CommandRun(commandText string, env []string, arguments ...string) error {
 			log.Errorf("CommandRun: failed. exit status %d", waitStatus.ExitStatus())
 		}
 
-		return log.Errore(fmt.Errorf("(%s) %s", err.Error(), cmdOutput))
+		return cmdOutput, err
 	}
 
 	// Command was successful
 	waitStatus = cmd.ProcessState.Sys().(syscall.WaitStatus)
 	log.Infof("CommandRun successful. exit status %d", waitStatus.ExitStatus())
 
-	return nil
+	return cmdOutput, nil
 }
 
 // generateShellScript generates a temporary shell script based on
@@ -3,6 +3,7 @@ package os
 import (
 	"fmt"
 	"testing"
+	"time"
 )
 
 func TestCommandRun(t *testing.T) {
@@ -16,3 +17,25 @@ func TestCommandRun(t *testing.T) {
 		t.Errorf(fmt.Sprintf("Expected CommandRun to return an Error '%s' but got '%s'", expectedMsg, cmdErr.Error()))
 	}
 }
+
+func TestCommandRunWithTimeout(t *testing.T) {
+	output, cmdErr := CommandRunWithTimeout("echo out && echo err >&2", []string{}, time.Second)
+	if cmdErr != nil {
+		t.Errorf("Expected CommandRunWithTimeout to succeed, but got '%s'", cmdErr.Error())
+	}
+	if output != "out\nerr\n" {
+		t.Errorf("Expected CommandRunWithTimeout to capture stdout and stderr, but got '%s'", output)
+	}
+
+	start := time.Now()
+	output, cmdErr = CommandRunWithTimeout("echo started && sleep 10", []string{}, 200*time.Millisecond)
+	if cmdErr == nil {
+		t.Error("Expected CommandRunWithTimeout to time out, but no error returned")
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("Expected CommandRunWithTimeout to kill the command on timeout, but it ran for %v", time.Since(start))
+	}
+	if output != "started\n" {
+		t.Errorf("Expected CommandRunWithTimeout to capture output of timed out command, but got '%s'", output)
+	}
+}
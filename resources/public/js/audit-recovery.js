@@ -53,6 +53,12 @@ $(document).ready(function() {
       });
       moreInfo += "</ul></div>";
     }
+    if (audit.GeographicConstraint) {
+      moreInfo += "<div>Geographic constraint: <code>" + audit.GeographicConstraint + "</code></div>";
+    }
+    if (audit.FailureReason) {
+      moreInfo += "<div>Failure reason: <code>" + audit.FailureReason + "</code></div>";
+    }
     if (audit.AllErrors.length > 0 && audit.AllErrors[0]) {
       moreInfo += "All errors:<ul>";
       audit.AllErrors.forEach(function(err) {
@@ -60,6 +66,22 @@ $(document).ready(function() {
       });
       moreInfo += "</ul>";
     }
+    if (audit.Timeline && audit.Timeline.length > 0) {
+      moreInfo += "<div>Timeline:<ul>";
+      audit.Timeline.forEach(function(step) {
+        var stepTitle = "<code>" + step.Phase + "</code>";
+        if (step.Key.Hostname) {
+          stepTitle += " on <code>" + getInstanceTitle(step.Key.Hostname, step.Key.Port) + "</code>";
+        }
+        var outcomeClass = (step.Outcome == "success") ? "text-success" : "text-danger";
+        moreInfo += '<li>' + stepTitle + ': <span class="' + outcomeClass + '">' + step.Outcome + '</span> in ' + (step.DurationMillis / 1000).toFixed(3) + 's';
+        if (step.Error) {
+          moreInfo += " (" + step.Error + ")";
+        }
+        moreInfo += "</li>";
+      });
+      moreInfo += "</ul></div>";
+    }
     moreInfo += '<div><a href="' + appUrl('/web/audit-failure-detection/id/' + audit.LastDetectionId) + '">Related detection</a></div>';
     moreInfo += '<div>Proccessed by <code>' + audit.ProcessingNodeHostname + '</code></div>';
     return moreInfo;
@@ -20,6 +20,23 @@ var interestingAnalysis = {
 	"AllIntermediateMasterSlavesNotReplicating" : true,
 	"UnreachableIntermediateMaster" : true,
 	"BinlogServerFailingToConnectToMaster" : true,
+	"DuplicateServerID" : true,
+	"LockedSemiSyncMaster" : true,
+	"BlockedMaster" : true,
+	"LowBinlogRetentionMargin" : true,
+	"ReplicationFiltersInUnfilteredCluster" : true,
+	"ReplicaBinlogMissingOnMaster" : true,
+	"MasterHostDrift" : true,
+	"BrokenReplicationChannel" : true,
+	"LaggingReplicationChannel" : true,
+	"ReplicaHeartbeatStalled" : true,
+	"NoLogSlaveUpdatesOnIntermediateMasters" : true,
+	"MixedFlavorsInCluster" : true,
+	"ReplicaPointsToWrongEndpoint" : true,
+	"ReplicaWithEnabledEvents" : true,
+	"CircularReplication" : true,
+	"PlaintextReplication" : true,
+	"ResurrectedOldMaster" : true,
 };
 
 var errorMapping = {
@@ -46,5 +63,9 @@ var errorMapping = {
 	"errant_gtid": {
 		"badge": "label-errant",
 		"description": "Errant GTID"
+	},
+	"duplicate_server_id": {
+		"badge": "label-danger",
+		"description": "Duplicate server_id/server_uuid"
 	}
 };
@@ -16,11 +16,11 @@ function Cluster() {
 
   var _instanceCommands = {
     "recover-auto": function(e) {
-      apiCommand("/api/recover/" + _instancesMap[e.draggedNodeId].Key.Hostname + "/" + _instancesMap[e.draggedNodeId].Key.Port);
+      apiCommand("/api/recover/" + _instancesMap[e.draggedNodeId].Key.Hostname + "/" + _instancesMap[e.draggedNodeId].Key.Port + "?acknowledgeRecoveryBlock=true");
       return true;
     },
     "recover-auto-lite": function(e) {
-      apiCommand("/api/recover-lite/" + _instancesMap[e.draggedNodeId].Key.Hostname + "/" + _instancesMap[e.draggedNodeId].Key.Port);
+      apiCommand("/api/recover-lite/" + _instancesMap[e.draggedNodeId].Key.Hostname + "/" + _instancesMap[e.draggedNodeId].Key.Port + "?acknowledgeRecoveryBlock=true");
       return true;
     },
     "force-master-failover": function(e) {
@@ -30,7 +30,7 @@ function Cluster() {
     "recover-suggested-successor": function(e) {
       var suggestedSuccessorHost = $(e.target).attr("data-successor-host");
       var suggestedSuccessorPort = $(e.target).attr("data-successor-port");
-      apiCommand("/api/recover/" + _instancesMap[e.draggedNodeId].Key.Hostname + "/" + _instancesMap[e.draggedNodeId].Key.Port + "/" + suggestedSuccessorHost + "/" + suggestedSuccessorPort);
+      apiCommand("/api/recover/" + _instancesMap[e.draggedNodeId].Key.Hostname + "/" + _instancesMap[e.draggedNodeId].Key.Port + "/" + suggestedSuccessorHost + "/" + suggestedSuccessorPort + "?acknowledgeRecoveryBlock=true");
       return true;
     },
     "relocate-replicas": function(e) {
@@ -1506,7 +1506,7 @@ function Cluster() {
     var analysisContent = '<div><strong>' + analysisEntry.Analysis + "</strong></div>";
     var extraText = '';
     if  (analysisEntry.IsDowntimed) {
-      extraText = '<i>downtime till ' + analysisEntry.DowntimeEndTimestamp + '</i>';
+      extraText = '<i>downtimed by ' + analysisEntry.DowntimeOwner + ' till ' + analysisEntry.DowntimeEndTimestamp + ': ' + analysisEntry.DowntimeReason + '</i>';
     } else if (analysisEntry.IsReplicasDowntimed) {
       extraText = '<i>replicas downtimed</i>';
     }
@@ -1754,7 +1754,7 @@ function Cluster() {
     getData("/api/blocked-recoveries/cluster/" + currentClusterName(), function(blockedRecoveries) {
       // Result is an array: either empty (no active recovery) or with multiple entries
       blockedRecoveries.forEach(function(blockedRecovery) {
-        addAlert('A <strong>' + blockedRecovery.Analysis + '</strong> on ' + getInstanceTitle(blockedRecovery.FailedInstanceKey.Hostname, blockedRecovery.FailedInstanceKey.Port) + ' is blocked due to a <a href="' + appUrl('/web/audit-recovery/id/' + blockedRecovery.BlockingRecoveryId) + '">previous recovery</a>');
+        addAlert('A <strong>' + blockedRecovery.Analysis + '</strong> on ' + getInstanceTitle(blockedRecovery.FailedInstanceKey.Hostname, blockedRecovery.FailedInstanceKey.Port) + ' is blocked due to a <a href="' + appUrl('/web/audit-recovery/id/' + blockedRecovery.BlockingRecoveryId) + '">previous recovery</a> for another ' + blockedRecovery.BlockRemainingSeconds + ' seconds');
       });
     });
 
@@ -50,7 +50,7 @@ $(document).ready(function() {
     replicationAnalysis.Details.forEach(function(analysisEntry) {
       if (analysisEntry.Analysis in interestingAnalysis) {
         clustersMap[analysisEntry.ClusterDetails.ClusterName].analysisEntries.push(analysisEntry);
-        if (!analysisEntry.IsDowntimed) {
+        if (!analysisEntry.IsDowntimed && !analysisEntry.SkippableDueToDowntime) {
           clustersMap[analysisEntry.ClusterDetails.ClusterName].allAnalysisDowntimed = false;
         }
       }
@@ -79,7 +79,7 @@ $(document).ready(function() {
 
     function displayAnalysisEntry(analysisEntry, popoverElement) {
       var blockedKey = getBlockedRecoveryKey(analysisEntry.AnalyzedInstanceKey.Hostname, analysisEntry.AnalyzedInstanceKey.Port, analysisEntry.Analysis);
-      var displayText = '<hr/><span><strong>' + analysisEntry.Analysis + (analysisEntry.IsDowntimed ? '<br/>[<i>downtime till ' + analysisEntry.DowntimeEndTimestamp + '</i>]' : '') + (blockedrecoveriesMap[blockedKey] ? '<br/><span class="glyphicon glyphicon-exclamation-sign text-danger"></span> Blocked' : '') + "</strong></span>" + "<br/>" + "<span>" + analysisEntry.AnalyzedInstanceKey.Hostname + ":" + analysisEntry.AnalyzedInstanceKey.Port + "</span>";
+      var displayText = '<hr/><span><strong>' + analysisEntry.Analysis + (analysisEntry.IsDowntimed ? '<br/>[<i>downtimed by ' + analysisEntry.DowntimeOwner + ' till ' + analysisEntry.DowntimeEndTimestamp + '</i>]' : '') + (blockedrecoveriesMap[blockedKey] ? '<br/><span class="glyphicon glyphicon-exclamation-sign text-danger"></span> Blocked' : '') + "</strong></span>" + "<br/>" + "<span>" + analysisEntry.AnalyzedInstanceKey.Hostname + ":" + analysisEntry.AnalyzedInstanceKey.Port + "</span>";
       if (analysisEntry.IsDowntimed) {
         displayText = '<div class="downtimed">' + displayText + '</div>';
       } else if (blockedrecoveriesMap[blockedKey]) {
@@ -2,25 +2,37 @@ $(document).ready(function() {
   showLoader();
 
   var problemsURI = "/api/problems";
+  var unacknowledgedRecoveriesURI = "/api/unacknowledged-recoveries";
+  var auditRecoveryURI = "/web/audit-recovery";
   if (typeof currentClusterName != "undefined") {
     problemsURI += "/" + currentClusterName();
+    unacknowledgedRecoveriesURI += "/cluster/" + currentClusterName();
+    auditRecoveryURI += "/cluster/" + currentClusterName();
   }
   $.get(appUrl(problemsURI), function(instances) {
     instances = instances || [];
     $.get(appUrl("/api/maintenance"), function(maintenanceList) {
       maintenanceList = maintenanceList || [];
       normalizeInstances(instances, maintenanceList);
-      displayProblemInstances(instances);
+      $.get(appUrl(unacknowledgedRecoveriesURI), function(unacknowledgedRecoveries) {
+        unacknowledgedRecoveries = unacknowledgedRecoveries || [];
+        displayProblemInstances(instances, unacknowledgedRecoveries);
+      }, "json");
     }, "json");
   }, "json");
 
-  function displayProblemInstances(instances) {
+  function displayProblemInstances(instances, unacknowledgedRecoveries) {
     hideLoader();
 
     if (isAnonymized()) {
       $("#instance_problems").remove();
       return;
     }
+    if (unacknowledgedRecoveries.length > 0) {
+      var li = $('<li><a href="' + appUrl(auditRecoveryURI) + '"><strong>' + unacknowledgedRecoveries.length + '</strong> unacknowledged recoveries</a></li>');
+      $("#instance_problems ul").append(li);
+      $("#instance_problems_button").addClass("btn-warning");
+    }
 
     function SortByProblemOrder(instance0, instance1) {
       var orderDiff = instance0.problemOrder - instance1.problemOrder;
@@ -51,7 +63,7 @@ $(document).ready(function() {
         });
         if (countProblemInstances == 0) {
           // First problem instance
-          $("#instance_problems_button").addClass("btn-" + instance.renderHint)
+          $("#instance_problems_button").removeClass("btn-warning").addClass("btn-" + instance.renderHint)
         }
         countProblemInstances += 1;
       }
@@ -59,7 +71,7 @@ $(document).ready(function() {
     if (countProblemInstances > 0 && (autoshowProblems() == "true") && ($.cookie("anonymize") != "true")) {
       $("#instance_problems .dropdown-toggle").dropdown('toggle');
     }
-    if (countProblemInstances == 0) {
+    if (countProblemInstances == 0 && unacknowledgedRecoveries.length == 0) {
       $("#instance_problems").hide();
     }
 
@@ -118,6 +118,24 @@ function toHumanFormat(bytes) {
   return (bytes / Math.pow(1024, e)).toFixed(2) + " " + s[e];
 }
 
+function replicationFiltersDescription(filters) {
+  var filterNames = {
+    "ReplicateDoDB": "replicate_do_db",
+    "ReplicateIgnoreDB": "replicate_ignore_db",
+    "ReplicateDoTable": "replicate_do_table",
+    "ReplicateIgnoreTable": "replicate_ignore_table",
+    "ReplicateWildDoTable": "replicate_wild_do_table",
+    "ReplicateWildIgnoreTable": "replicate_wild_ignore_table",
+    "BinlogDoDB": "binlog_do_db",
+    "BinlogIgnoreDB": "binlog_ignore_db"
+  };
+  return Object.keys(filterNames).filter(function(key) {
+    return filters && filters[key];
+  }).map(function(key) {
+    return filterNames[key] + "=" + filters[key];
+  }).join("; ");
+}
+
 function getInstanceId(host, port) {
   return "instance__" + host.replace(/[.]/g, "_") + "__" + port
 }
@@ -203,7 +221,7 @@ function addNodeModalDataAttribute(name, value) {
   if (value == "false" || value === false) {
     codeClass = "text-danger";
   }
-  if (name == "Maintenance") {
+  if (name == "Maintenance" || name == "Health checks") {
     codeClass = "text-danger";
   }
   $('#modalDataAttributesTable').append(
@@ -239,6 +257,11 @@ function openNodeModal(node) {
     td = addNodeModalDataAttribute("Maintenance", node.maintenanceReason);
     $('#node_modal button[data-btn=end-maintenance]').appendTo(td.find("div")).show();
   }
+  $('#node_modal button[data-btn=unignore-health-checks]').hide();
+  if (node.IgnoreHealthChecks) {
+    td = addNodeModalDataAttribute("Health checks", "Ignored by " + node.IgnoreHealthChecksOwner + ": " + node.IgnoreHealthChecksReason + ". Not polled; last known state shown");
+    $('#node_modal button[data-btn=unignore-health-checks]').appendTo(td.find("div")).show();
+  }
 
   if (node.InstanceAlias) {
     addNodeModalDataAttribute("Instance Alias", node.InstanceAlias);
@@ -274,6 +297,13 @@ function openNodeModal(node) {
     addNodeModalDataAttribute("Seconds behind master", node.SecondsBehindMaster.Valid ? node.SecondsBehindMaster.Int64 : "null");
     addNodeModalDataAttribute("Replication lag", node.SlaveLagSeconds.Valid ? node.SlaveLagSeconds.Int64 : "null");
     addNodeModalDataAttribute("SQL delay", node.SQLDelay);
+    if (node.SlaveParallelWorkers > 0) {
+      addNodeModalDataAttribute("Parallel workers", node.SlaveParallelWorkers);
+      addNodeModalDataAttribute("Replication gaps", node.HasReplicationGaps ? "yes" : "no");
+    }
+    if (replicationFiltersDescription(node.ReplicationFilters)) {
+      addNodeModalDataAttribute("Replication filters", replicationFiltersDescription(node.ReplicationFilters));
+    }
 
     var masterCoordinatesEl = addNodeModalDataAttribute("Master coordinates", node.ExecBinlogCoordinates.LogFile + ":" + node.ExecBinlogCoordinates.LogPos);
     $('#node_modal [data-btn-group=move-equivalent] ul').empty();
@@ -318,6 +348,9 @@ function openNodeModal(node) {
     addNodeModalDataAttribute("Server UUID", node.ServerUUID);
   }
   addNodeModalDataAttribute("Version", node.Version);
+  if (node.SQLMode) {
+    addNodeModalDataAttribute("SQL mode", node.SQLMode);
+  }
   var td = addNodeModalDataAttribute("Read only", booleanString(node.ReadOnly));
   $('#node_modal button[data-btn=set-read-only]').appendTo(td.find("div"))
   $('#node_modal button[data-btn=set-writeable]').appendTo(td.find("div"))
@@ -337,6 +370,9 @@ function openNodeModal(node) {
   addNodeModalDataAttribute("GTID supported", booleanString(node.supportsGTID));
   if (node.supportsGTID) {
     var td = addNodeModalDataAttribute("GTID based replication", booleanString(node.usingGTID));
+    if (node.gtidFlavor) {
+      addNodeModalDataAttribute("GTID flavor", node.gtidFlavor);
+    }
     $('#node_modal button[data-btn=enable-gtid]').appendTo(td.find("div"))
     $('#node_modal button[data-btn=disable-gtid]').appendTo(td.find("div"))
     if (node.GTIDMode) {
@@ -348,6 +384,12 @@ function openNodeModal(node) {
     if (node.GtidPurged) {
       addNodeModalDataAttribute("GTID purged", node.GtidPurged);
     }
+    if (node.MariaDBGtidCurrentPos) {
+      addNodeModalDataAttribute("GTID current pos", node.MariaDBGtidCurrentPos);
+    }
+    if (node.MariaDBGtidSlavePos) {
+      addNodeModalDataAttribute("GTID slave pos", node.MariaDBGtidSlavePos);
+    }
     if (node.GtidErrant) {
       td = addNodeModalDataAttribute("GTID errant", node.GtidErrant);
       $('#node_modal [data-btn-group=gtid-errant-fix]').appendTo(td.find("div"))
@@ -355,9 +397,17 @@ function openNodeModal(node) {
     }
   }
   addNodeModalDataAttribute("Semi-sync enforced", booleanString(node.SemiSyncEnforced));
+  if (node.ReplicationGroupName) {
+    addNodeModalDataAttribute("Replication group", node.ReplicationGroupName);
+    addNodeModalDataAttribute("Group member role", node.ReplicationGroupMemberRole + (node.ReplicationGroupIsSinglePrimary ? "" : " (multi primary)"));
+    addNodeModalDataAttribute("Group member state", node.ReplicationGroupMemberState);
+  }
 
   addNodeModalDataAttribute("Uptime", node.Uptime);
   addNodeModalDataAttribute("Allow TLS", node.AllowTLS);
+  if (node.ReplicationSSLCipher) {
+    addNodeModalDataAttribute("Replication SSL cipher", node.ReplicationSSLCipher);
+  }
   addNodeModalDataAttribute("Cluster",
     '<a href="' + appUrl('/web/cluster/' + node.ClusterName) + '">' + node.ClusterName + '</a>');
   addNodeModalDataAttribute("Audit",
@@ -572,11 +622,14 @@ function openNodeModal(node) {
     $('#node_modal button[data-btn=recover]').show();
   }
   $('#node_modal button[data-btn=recover]').click(function() {
-    apiCommand("/api/recover/" + node.Key.Hostname + "/" + node.Key.Port);
+    apiCommand("/api/recover/" + node.Key.Hostname + "/" + node.Key.Port + "?acknowledgeRecoveryBlock=true");
   });
   $('#node_modal button[data-btn=end-maintenance]').click(function() {
     apiCommand("/api/end-maintenance/" + node.Key.Hostname + "/" + node.Key.Port);
   });
+  $('#node_modal button[data-btn=unignore-health-checks]').click(function() {
+    apiCommand("/api/unignore-health-checks/" + node.Key.Hostname + "/" + node.Key.Port);
+  });
 
   if (!isAuthorizedForAction()) {
     $('#node_modal button[data-btn]').hide();
@@ -605,6 +658,12 @@ function normalizeInstance(instance) {
   instance.isSeenRecently = instance.SecondsSinceLastSeen.Valid && instance.SecondsSinceLastSeen.Int64 <= 3600;
   instance.supportsGTID = instance.SupportsOracleGTID || instance.UsingMariaDBGTID;
   instance.usingGTID = instance.UsingOracleGTID || instance.UsingMariaDBGTID;
+  instance.gtidFlavor = "";
+  if (instance.UsingMariaDBGTID) {
+    instance.gtidFlavor = "MariaDB";
+  } else if (instance.SupportsOracleGTID) {
+    instance.gtidFlavor = "Oracle";
+  }
   instance.isMaxScale = (instance.Version.indexOf("maxscale") >= 0);
 
   // used by cluster-tree
@@ -671,11 +730,11 @@ function normalizeInstanceProblem(instance) {
   if (instance.inMaintenanceProblem()) {
     instance.problemDescription = "This instance is now under maintenance due to some pending operation.\nSee audit page";
     instance.problemOrder = 1;
+  } else if (instance.notRecentlyCheckedProblem()) {
+    instance.problemDescription = "Orchestrator has not made an attempt to reach this instance for a while now (stale).\nIts state is unknown: it is not necessarily dead. Consider refreshing or re-discovering this instance";
+    instance.problemOrder = 2;
   } else if (instance.lastCheckInvalidProblem()) {
     instance.problemDescription = "Instance cannot be reached by orchestrator.\nIt might be dead or there may be a network problem";
-    instance.problemOrder = 2;
-  } else if (instance.notRecentlyCheckedProblem()) {
-    instance.problemDescription = "Orchestrator has not made an attempt to reach this instance for a while now.\nThis should generally not happen; consider refreshing or re-discovering this instance";
     instance.problemOrder = 3;
   } else if (instance.notReplicatingProblem()) {
     // check replicas only; where not replicating
@@ -842,7 +901,7 @@ function renderInstanceElement(popoverElement, instance, renderType) {
       } else if (instance.GtidErrant) {
         popoverElement.find("h3 div.pull-right").prepend('<span class="glyphicon text-danger glyphicon-globe" title="Errant GTID found"></span> ');
       } else {
-        popoverElement.find("h3 div.pull-right").prepend('<span class="glyphicon glyphicon-globe" title="Using GTID"></span> ');
+        popoverElement.find("h3 div.pull-right").prepend('<span class="glyphicon glyphicon-globe" title="Using ' + instance.gtidFlavor + ' GTID"></span> ');
       }
     }
     if (instance.UsingPseudoGTID) {
@@ -858,7 +917,7 @@ function renderInstanceElement(popoverElement, instance, renderType) {
       popoverElement.find("h3 div.pull-right").prepend('<span class="glyphicon glyphicon-camera" title="' + instance.CountMySQLSnapshots + ' snapshots"></span> ');
     }
     if (instance.HasReplicationFilters) {
-      popoverElement.find("h3 div.pull-right").prepend('<span class="glyphicon glyphicon-filter" title="Using replication filters"></span> ');
+      popoverElement.find("h3 div.pull-right").prepend('<span class="glyphicon glyphicon-filter" title="Using replication filters: ' + replicationFiltersDescription(instance.ReplicationFilters) + '"></span> ');
     }
     if (instance.SemiSyncMasterEnabled) {
       popoverElement.find("h3 div.pull-right").prepend('<span class="glyphicon glyphicon-check" title="Semi sync enabled (master side)"></span> ');
@@ -889,7 +948,16 @@ function renderInstanceElement(popoverElement, instance, renderType) {
       popoverElement.find("h3 div.pull-right").prepend('<span class="glyphicon glyphicon-volume-off" title="' + downtimeMessage + '"></span> ');
     }
 
-    if (instance.lastCheckInvalidProblem()) {
+    if (instance.IgnoreHealthChecks) {
+      var ignoreHealthChecksMessage = 'Health checks ignored by ' + instance.IgnoreHealthChecksOwner + ': ' + instance.IgnoreHealthChecksReason + '.\nNot polled; last known state shown';
+      popoverElement.find("h3 div.pull-right").prepend('<span class="glyphicon glyphicon-eye-close" title="' + ignoreHealthChecksMessage + '"></span> ');
+    }
+
+    if (instance.IgnoreHealthChecks) {
+      // Deliberately unreachable: its last check says nothing of its health
+      instance.renderHint = "ignored";
+      indicateLastSeenInStatus = true;
+    } else if (instance.lastCheckInvalidProblem()) {
       instance.renderHint = "fatal";
       indicateLastSeenInStatus = true;
     } else if (instance.notRecentlyCheckedProblem()) {